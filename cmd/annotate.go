@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var annotateClear bool
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [task-id-or-name] [message]",
+	Short: "Attach a note to an agent",
+	Long: `Attach a timestamped note to an agent.
+
+Notes record context for humans monitoring a long-running swarm (e.g.
+"paused while we fix CI"). They are kept in the agent's state and shown by
+'swarm inspect' and 'swarm summary'. Without a message, the agent's notes
+are listed.
+
+The agent can be specified by its ID, name, or special identifier:
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
+	Example: `  # Add a note to an agent
+  swarm annotate my-agent "paused while we fix CI"
+
+  # List an agent's notes
+  swarm annotate my-agent
+
+  # Remove all notes from an agent
+  swarm annotate my-agent --clear`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agent, err := ResolveAgentIdentifier(mgr, args[0])
+		if err != nil {
+			return err
+		}
+
+		message := strings.TrimSpace(strings.Join(args[1:], " "))
+
+		if annotateClear {
+			if message != "" {
+				return fmt.Errorf("--clear cannot be combined with a message")
+			}
+			if err := mgr.ClearNotes(agent.ID); err != nil {
+				return fmt.Errorf("failed to clear notes: %w", err)
+			}
+			fmt.Printf("Cleared %d note(s) from %s\n", len(agent.Notes), agent.ID)
+			return nil
+		}
+
+		if message == "" {
+			if len(agent.Notes) == 0 {
+				fmt.Println("No notes")
+				return nil
+			}
+			printNotes(agent.Notes)
+			return nil
+		}
+
+		if err := mgr.AddNote(agent.ID, message); err != nil {
+			return fmt.Errorf("failed to add note: %w", err)
+		}
+		fmt.Println(agent.ID)
+		return nil
+	},
+}
+
+// printNotes prints notes one per line, oldest first.
+func printNotes(notes []state.Note) {
+	for _, n := range notes {
+		fmt.Printf("  %s  %s\n", n.Time.Format(time.DateTime), n.Text)
+	}
+}
+
+func init() {
+	annotateCmd.Flags().BoolVar(&annotateClear, "clear", false, "Remove all notes from the agent")
+	rootCmd.AddCommand(annotateCmd)
+
+	annotateCmd.ValidArgsFunction = completeAgentIdentifier
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [task-id-or-name] [instruction]",
+	Short: "Approve an agent waiting at an approval gate",
+	Long: `Approve an agent paused with 'swarm stop --await-approval', so it resumes.
+
+The approval is recorded as a note on the agent ('swarm inspect' shows it).
+An instruction after the agent, e.g. an answer to its question, is added to
+the prompt of its next iteration, as with 'swarm exec'.
+
+The agent can be specified by its ID, name, or special identifier:
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
+	Example: `  # Approve an agent
+  swarm approve abc123
+
+  # Approve, with an answer for the next iteration
+  swarm approve my-agent "yes, drop the legacy column"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agent, err := ResolveAgentIdentifier(mgr, args[0])
+		if err != nil {
+			return err
+		}
+		if agent.Status != "running" {
+			return fmt.Errorf("agent is not running (status: %s)", agent.Status)
+		}
+		if !agent.Paused || agent.Approval == "" {
+			return fmt.Errorf("agent %s is not awaiting approval", agent.ID)
+		}
+
+		// Queue the instruction before resuming, so the next iteration gets it
+		if instruction := strings.TrimSpace(strings.Join(args[1:], " ")); instruction != "" {
+			if _, err := mgr.QueueMessage(agent.ID, instruction); err != nil {
+				return err
+			}
+		}
+		if err := mgr.SetPaused(agent.ID, false); err != nil {
+			return fmt.Errorf("failed to update agent state: %w", err)
+		}
+		if err := mgr.AddNote(agent.ID, "Approved: "+agent.Approval); err != nil {
+			fmt.Printf("Warning: failed to record the approval: %v\n", err)
+		}
+
+		fmt.Printf("Agent %s approved: %s\n", agent.ID, agent.Approval)
+		if agent.Name != "" {
+			fmt.Printf("Name: %s\n", agent.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(approveCmd)
+
+	approveCmd.ValidArgsFunction = completeRunningAgentIdentifier
+}
@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/eiannone/keyboard"
 	"github.com/fatih/color"
-	"github.com/mj1618/swarm-cli/internal/process"
+	"github.com/mj1618/swarm-cli/internal/logparser"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,8 @@ import (
 var (
 	attachNoInteractive bool
 	attachTail          int
+	attachReplay        int
+	attachRaw           bool
 )
 
 var attachCmd = &cobra.Command{
@@ -25,11 +28,25 @@ var attachCmd = &cobra.Command{
 	Short: "Attach to a running agent for interactive monitoring",
 	Long: `Attach to a running detached agent for interactive monitoring.
 
-Shows a status header with agent info that updates in real-time,
-follows log output, and provides keyboard shortcuts for control.
+Follows the agent's log, pretty-printed as 'swarm logs' does, above a
+status footer that updates live with the agent's status, iteration, tokens,
+cost, and current task. Keyboard shortcuts control the agent without
+leaving the view:
+  p        pause after the current iteration
+  r        resume
+  + / -    add or remove an iteration
+  k        kill the agent (asks to confirm)
+  q        detach, leaving the agent running
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
+
+Use --replay N to start with the agent's last N iterations instead of the
+last --tail lines, so you get context instead of joining mid-thought.
+
+Use --raw to print the log lines as written instead of pretty-printing them.
 
 Press 'q' or Ctrl+C to detach without killing the agent.`,
 	Example: `  # Attach to agent by ID
@@ -46,7 +63,13 @@ Press 'q' or Ctrl+C to detach without killing the agent.`,
   swarm attach my-agent --no-interactive
 
   # Show last 100 lines when attaching
-  swarm attach my-agent --tail 100`,
+  swarm attach my-agent --tail 100
+
+  # Replay the last 2 iterations before following
+  swarm attach my-agent --replay 2
+
+  # Follow the raw log lines
+  swarm attach my-agent --raw`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		agentIdentifier := args[0]
@@ -91,10 +114,23 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 	}
 	defer keyboard.Close()
 
-	// Clear screen and print initial header
-	fmt.Print("\033[2J\033[H")
-	printAttachStatusHeader(agent)
-	printAttachHelpLine()
+	// Draw the status footer below the log, or a header if the terminal
+	// is too small for it
+	footer := newAttachFooter()
+	if footer != nil {
+		defer footer.close()
+		footer.draw(agent)
+	} else {
+		fmt.Print("\033[2J\033[H")
+		printAttachStatusHeader(agent)
+		color.New(color.Faint).Println("Press: [p]ause  [r]esume  [+]iter  [-]iter  [k]ill  [q]uit")
+		fmt.Println()
+	}
+	refreshFooter := func() {
+		if footer != nil {
+			footer.draw(agent)
+		}
+	}
 
 	// Open log file
 	file, err := os.Open(agent.LogFile)
@@ -103,8 +139,9 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 	}
 	defer file.Close()
 
-	// Show last N lines
-	if err := showLastLinesAttach(file, attachTail); err != nil {
+	// Show last N lines (or iterations)
+	out := newAttachOutput()
+	if err := showHistoryAttach(file, out); err != nil {
 		return err
 	}
 
@@ -120,6 +157,7 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 	// Goroutine: read log file
 	go func() {
 		reader := bufio.NewReader(file)
+		var partial string
 		for {
 			select {
 			case <-done:
@@ -127,12 +165,15 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 			default:
 				line, err := reader.ReadString('\n')
 				if err == io.EOF {
+					// Keep a partly written line until the rest arrives
+					partial += line
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
 				if err != nil {
 					return
 				}
+				line, partial = partial+line, ""
 				select {
 				case logLines <- line:
 				case <-done:
@@ -178,19 +219,19 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 	for {
 		select {
 		case line := <-logLines:
-			fmt.Print(line)
+			out.writeLine(strings.TrimRight(line, "\r\n"))
 
 		case <-statusTicker.C:
 			// Refresh agent state
 			updated, err := mgr.Get(agent.ID)
 			if err == nil {
 				agent = updated
-				// Refresh status header in place
-				refreshAttachStatusHeader(agent)
+				refreshFooter()
 			}
 			// Check if terminated
 			if agent.Status == "terminated" {
 				close(done)
+				out.flush()
 				fmt.Println("\n[swarm] Agent terminated")
 				return nil
 			}
@@ -200,6 +241,7 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 
 			if char == 'q' {
 				close(done)
+				out.flush()
 				fmt.Println("\n[swarm] Detached from agent (agent still running)")
 				return nil
 			}
@@ -251,10 +293,11 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 					if err := mgr.SetTerminateMode(agent.ID, "immediate"); err != nil {
 						fmt.Printf("\n[swarm] Error setting terminate mode: %v\n", err)
 					}
-					if err := process.Kill(agent.PID); err != nil {
+					if err := agent.Kill(); err != nil {
 						fmt.Printf("\n[swarm] Warning: could not send signal: %v\n", err)
 					}
 					close(done)
+					out.flush()
 					fmt.Println("\n[swarm] Agent killed")
 					return nil
 				}
@@ -262,6 +305,7 @@ func attachInteractive(mgr *state.Manager, agent *state.AgentState) error {
 			case 0: // Check for Ctrl+C
 				// handled by keyboard library key
 			}
+			refreshFooter()
 
 		default:
 			// Small sleep to prevent busy loop
@@ -283,8 +327,9 @@ func attachNonInteractive(mgr *state.Manager, agent *state.AgentState) error {
 	}
 	defer file.Close()
 
-	// Show last N lines
-	if err := showLastLinesAttach(file, attachTail); err != nil {
+	// Show last N lines (or iterations)
+	out := newAttachOutput()
+	if err := showHistoryAttach(file, out); err != nil {
 		return err
 	}
 
@@ -298,6 +343,7 @@ func attachNonInteractive(mgr *state.Manager, agent *state.AgentState) error {
 	reader := bufio.NewReader(file)
 	statusTicker := time.NewTicker(5 * time.Second)
 	defer statusTicker.Stop()
+	var partial string
 
 	for {
 		select {
@@ -309,19 +355,23 @@ func attachNonInteractive(mgr *state.Manager, agent *state.AgentState) error {
 			}
 			// Check if terminated
 			if agent.Status == "terminated" {
+				out.flush()
 				fmt.Println("\n[swarm] Agent terminated")
 				return nil
 			}
 		default:
 			line, err := reader.ReadString('\n')
 			if err == io.EOF {
+				// Keep a partly written line until the rest arrives
+				partial += line
 				time.Sleep(100 * time.Millisecond)
 				continue
 			}
 			if err != nil {
 				return fmt.Errorf("error reading log file: %w", err)
 			}
-			fmt.Print(line)
+			line, partial = partial+line, ""
+			out.writeLine(strings.TrimRight(line, "\r\n"))
 		}
 	}
 }
@@ -334,60 +384,99 @@ func printAttachStatusHeader(agent *state.AgentState) {
 		name = "-"
 	}
 
-	statusStr := agent.Status
-	if agent.Paused {
-		if agent.PausedAt != nil {
-			statusStr = "paused"
-		} else {
-			statusStr = "pausing"
-		}
-	}
-
-	iterStr := fmt.Sprintf("%d/%d", agent.CurrentIter, agent.Iterations)
-	if agent.Iterations == 0 {
-		iterStr = fmt.Sprintf("%d/∞", agent.CurrentIter)
-	}
-
 	bold.Printf("╭─ Agent: %s (%s) ", name, agent.ID)
 	fmt.Println(strings.Repeat("─", 40) + "╮")
 
 	fmt.Printf("│ Status: %-10s │ Iteration: %-8s │ Model: %-20s │\n",
-		statusStr, iterStr, truncateString(agent.Model, 20))
+		attachStatus(agent), attachIterations(agent), truncateString(agent.Model, 20))
 	fmt.Println("╰" + strings.Repeat("─", 73) + "╯")
 }
 
-func printAttachHelpLine() {
-	dim := color.New(color.Faint)
-	dim.Println("Press: [p]ause  [r]esume  [+]iter  [-]iter  [k]ill  [q]uit")
-	fmt.Println()
+// attachOutput prints log lines, pretty-printed unless --raw is set.
+type attachOutput struct {
+	parser *logparser.Parser
+}
+
+func newAttachOutput() *attachOutput {
+	if attachRaw {
+		return &attachOutput{}
+	}
+	return &attachOutput{parser: logparser.NewParser(os.Stdout)}
 }
 
-func refreshAttachStatusHeader(agent *state.AgentState) {
-	statusStr := agent.Status
-	if agent.Paused {
-		if agent.PausedAt != nil {
-			statusStr = "paused"
-		} else {
-			statusStr = "pausing"
+// writeLine prints a log line, without its trailing newline.
+func (o *attachOutput) writeLine(line string) {
+	if o.parser == nil {
+		fmt.Println(line)
+		return
+	}
+	o.parser.ProcessLine(line)
+}
+
+// flush finishes any output the parser is holding back.
+func (o *attachOutput) flush() {
+	if o.parser != nil {
+		o.parser.Flush()
+	}
+}
+
+// showHistoryAttach shows the log history before following: the last
+// --replay iterations, or else the last --tail lines.
+func showHistoryAttach(file *os.File, out *attachOutput) error {
+	if attachReplay > 0 {
+		return replayIterationsAttach(file, attachReplay, out)
+	}
+	return showLastLinesAttach(file, attachTail, out)
+}
+
+// iterationStartRe matches the line a runner logs at the start of each
+// iteration, capturing the iteration number.
+var iterationStartRe = regexp.MustCompile(`^(\[swarm\] )?=== Iteration (\d+)(/\d+)? ===`)
+
+// replayStart returns the index of the line the last n iterations start at
+// (0 if there are no more than n).
+func replayStart(lines []string, n int) int {
+	var starts []int
+	for i, line := range lines {
+		if iterationStartRe.MatchString(line) {
+			starts = append(starts, i)
 		}
 	}
+	if len(starts) <= n {
+		return 0
+	}
+	return starts[len(starts)-n]
+}
 
-	iterStr := fmt.Sprintf("%d/%d", agent.CurrentIter, agent.Iterations)
-	if agent.Iterations == 0 {
-		iterStr = fmt.Sprintf("%d/∞", agent.CurrentIter)
+// replayIterationsAttach prints the last n iterations of a log.
+func replayIterationsAttach(file *os.File, n int, out *attachOutput) error {
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading log file: %w", err)
 	}
 
-	// Save cursor, move to line 2, update, restore cursor
-	fmt.Print("\033[s")    // Save cursor
-	fmt.Print("\033[2;1H") // Move to line 2, column 1
+	if len(lines) == 0 {
+		fmt.Println("(log file is empty)")
+		return nil
+	}
 
-	fmt.Printf("│ Status: %-10s │ Iteration: %-8s │ Model: %-20s │",
-		statusStr, iterStr, truncateString(agent.Model, 20))
-	fmt.Print("\033[K") // Clear to end of line
-	fmt.Print("\033[u") // Restore cursor
+	color.New(color.Faint).Printf("--- Replaying the last %d iteration(s) ---\n", n)
+	for _, line := range lines[replayStart(lines, n):] {
+		out.writeLine(line)
+	}
+	out.flush()
+	color.New(color.Faint).Println("--- End of replay ---")
+	return nil
 }
 
-func showLastLinesAttach(file *os.File, n int) error {
+func showLastLinesAttach(file *os.File, n int, out *attachOutput) error {
 	// Get file size
 	stat, err := file.Stat()
 	if err != nil {
@@ -420,7 +509,7 @@ func showLastLinesAttach(file *os.File, n int) error {
 
 	// Print the lines
 	for _, line := range lines {
-		fmt.Println(line)
+		out.writeLine(line)
 	}
 
 	return nil
@@ -436,6 +525,8 @@ func truncateString(s string, max int) string {
 func init() {
 	attachCmd.Flags().BoolVar(&attachNoInteractive, "no-interactive", false, "Disable keyboard controls")
 	attachCmd.Flags().IntVar(&attachTail, "tail", 50, "Number of lines to show from the end")
+	attachCmd.Flags().IntVar(&attachReplay, "replay", 0, "Replay the last N iterations before following (instead of --tail)")
+	attachCmd.Flags().BoolVar(&attachRaw, "raw", false, "Print log lines as written instead of pretty-printing them")
 	rootCmd.AddCommand(attachCmd)
 
 	// Add dynamic completion for agent identifier
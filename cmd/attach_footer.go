@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// attachFooterHeight is the number of terminal lines the attach status
+// footer takes.
+const attachFooterHeight = 4
+
+// attachMinHeight is the smallest terminal the footer is drawn on; shorter
+// terminals get the log only.
+const attachMinHeight = attachFooterHeight + 6
+
+// attachFooter draws a live status footer at the bottom of the terminal,
+// below a scroll region the log is printed into.
+type attachFooter struct {
+	width  int
+	height int
+}
+
+// newAttachFooter sets up the footer, or returns nil if stdout isn't a
+// terminal big enough for it.
+func newAttachFooter() *attachFooter {
+	f := &attachFooter{}
+	if !f.resize() {
+		return nil
+	}
+	f.setup()
+	return f
+}
+
+// resize reads the terminal size and reports whether it's usable and
+// changed.
+func (f *attachFooter) resize() bool {
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || height < attachMinHeight || width <= 0 {
+		return false
+	}
+	if width == f.width && height == f.height {
+		return false
+	}
+	f.width, f.height = width, height
+	return true
+}
+
+// setup clears the screen and limits scrolling to the lines above the
+// footer, leaving the cursor at the bottom of the log area.
+func (f *attachFooter) setup() {
+	logHeight := f.height - attachFooterHeight
+	fmt.Printf("\033[2J\033[1;%dr\033[%d;1H", logHeight, logHeight)
+}
+
+// draw redraws the footer for agent without moving the cursor. After a
+// resize it sets up the screen again first.
+func (f *attachFooter) draw(agent *state.AgentState) {
+	if f.resize() {
+		f.setup()
+	}
+	faint := color.New(color.Faint)
+	bold := color.New(color.Bold)
+
+	fmt.Print("\0337") // Save cursor
+	for i, line := range attachFooterLines(agent, f.width) {
+		fmt.Printf("\033[%d;1H\033[2K", f.height-attachFooterHeight+1+i)
+		switch i {
+		case 1:
+			bold.Print(line)
+		case 2:
+			fmt.Print(line)
+		default:
+			faint.Print(line)
+		}
+	}
+	fmt.Print("\0338") // Restore cursor
+}
+
+// close removes the footer and restores normal scrolling, leaving the cursor
+// where the footer was.
+func (f *attachFooter) close() {
+	fmt.Printf("\033[r\033[%d;1H\033[J", f.height-attachFooterHeight+1)
+}
+
+// attachFooterLines returns the footer's lines for agent, each cut to width:
+// a rule naming the agent, its status, iteration, tokens, cost, and model,
+// its current task, and the keys.
+func attachFooterLines(agent *state.AgentState, width int) []string {
+	name := agent.Name
+	if name == "" {
+		name = "-"
+	}
+	rule := fmt.Sprintf("── %s (%s) ", name, agent.ID)
+	if pad := width - len([]rune(rule)); pad > 0 {
+		rule += strings.Repeat("─", pad)
+	}
+
+	model := agent.Model
+	if model == "" {
+		model = "-"
+	}
+	status := fmt.Sprintf("%s · iteration %s · tokens %s in / %s out · $%.2f · %s",
+		attachStatus(agent), attachIterations(agent),
+		formatTokenCount(agent.InputTokens), formatTokenCount(agent.OutputTokens),
+		agent.TotalCost, model)
+
+	task := agent.CurrentTask
+	if task == "" {
+		task = "-"
+	}
+
+	lines := []string{
+		rule,
+		status,
+		"Task: " + task,
+		"[p]ause  [r]esume  [+]iter  [-]iter  [k]ill  [q]uit",
+	}
+	for i, line := range lines {
+		lines[i] = truncateTop(line, width)
+	}
+	return lines
+}
+
+// attachStatus returns agent's status, telling a pause that's waiting for
+// the current iteration to end from one that has taken effect.
+func attachStatus(agent *state.AgentState) string {
+	if !agent.Paused {
+		return agent.Status
+	}
+	if agent.PausedAt != nil {
+		return "paused"
+	}
+	return "pausing"
+}
+
+// attachIterations returns agent's progress, e.g. "3/10", or "3/∞" if it
+// runs until stopped.
+func attachIterations(agent *state.AgentState) string {
+	if agent.Iterations == 0 {
+		return fmt.Sprintf("%d/∞", agent.CurrentIter)
+	}
+	return fmt.Sprintf("%d/%d", agent.CurrentIter, agent.Iterations)
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/state"
 )
 
 func TestTruncateString(t *testing.T) {
@@ -70,7 +73,7 @@ func TestAttachCmdExists(t *testing.T) {
 
 func TestAttachCmdFlags(t *testing.T) {
 	// Verify the flags exist
-	flags := []string{"no-interactive", "tail"}
+	flags := []string{"no-interactive", "tail", "raw"}
 	for _, flag := range flags {
 		f := attachCmd.Flags().Lookup(flag)
 		if f == nil {
@@ -85,3 +88,76 @@ func TestAttachCmdRequiresArg(t *testing.T) {
 		t.Error("attach command should have Args validation")
 	}
 }
+
+func TestReplayStart(t *testing.T) {
+	lines := []string{
+		`{"type": "system", "subtype": "init"}`,
+		"[swarm] === Iteration 1/3 ===",
+		`{"type": "result", "result": "one"}`,
+		"[swarm] === Iteration 2/3 ===",
+		`{"type": "result", "result": "two"}`,
+		"[swarm] === Iteration 3 ===",
+		`{"type": "thinking", "text": "three"}`,
+	}
+
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{1, 5},
+		{2, 3},
+		{3, 0},
+		{10, 0},
+	}
+	for _, tt := range tests {
+		if got := replayStart(lines, tt.n); got != tt.want {
+			t.Errorf("replayStart(lines, %d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestAttachFooterLines(t *testing.T) {
+	agent := &state.AgentState{
+		ID:           "abc123",
+		Name:         "coder",
+		Status:       "running",
+		Model:        "sonnet",
+		CurrentIter:  3,
+		Iterations:   10,
+		InputTokens:  12300,
+		OutputTokens: 450,
+		TotalCost:    0.42,
+		CurrentTask:  "Read: auth.ts",
+	}
+
+	lines := attachFooterLines(agent, 200)
+	if len(lines) != attachFooterHeight {
+		t.Fatalf("got %d lines, want %d", len(lines), attachFooterHeight)
+	}
+	if !strings.HasPrefix(lines[0], "── coder (abc123) ─") {
+		t.Errorf("rule = %q", lines[0])
+	}
+	if want := "running · iteration 3/10 · tokens 12.3K in / 450 out · $0.42 · sonnet"; lines[1] != want {
+		t.Errorf("status = %q, want %q", lines[1], want)
+	}
+	if lines[2] != "Task: Read: auth.ts" {
+		t.Errorf("task = %q", lines[2])
+	}
+
+	agent.Paused = true
+	agent.Iterations = 0
+	agent.CurrentTask = ""
+	lines = attachFooterLines(agent, 200)
+	if !strings.HasPrefix(lines[1], "pausing · iteration 3/∞ ·") {
+		t.Errorf("status = %q", lines[1])
+	}
+	if lines[2] != "Task: -" {
+		t.Errorf("task = %q", lines[2])
+	}
+
+	for _, line := range attachFooterLines(agent, 20) {
+		if w := len([]rune(line)); w > 20 {
+			t.Errorf("line %q is %d wide, want at most 20", line, w)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	claimOwner   string
+	claimTTL     time.Duration
+	claimRelease bool
+	claimList    bool
+	claimFormat  string
+)
+
+var claimCmd = &cobra.Command{
+	Use:   "claim [key]",
+	Short: "Claim a key so parallel agents don't act on it twice",
+	Long: `Atomically claim a key, e.g. the name of a task a planner is about to
+create, so parallel agents coordinate reliably: of several agents claiming the
+same key, exactly one succeeds. Claiming is a compare-and-set across all swarm
+processes on the machine.
+
+The command exits 0 when the claim is yours (including a key you already hold)
+and 1 when another owner holds it, printing the holder, so agents and scripts
+can branch on it:
+
+  swarm claim "task:add-auth" && echo "- [ ] Add auth" >> TODO.md
+
+The owner defaults to $SWARM_RUN_ID/$SWARM_TASK_NAME, which swarm exports to
+every agent: the run (the agent's ID, or the pipeline run's ID for pipeline
+tasks) and its task. Tasks running side by side in a pipeline therefore own
+their claims separately, while iterations of the same task can reclaim their
+keys. Outside an agent, pass --owner.
+
+Claims are kept per project (or shared by all projects with --global) until
+released, or until --ttl passes so a crashed owner doesn't hold a key forever.`,
+	Example: `  # Claim a key in an agent's shell (exit code 1 if another agent has it)
+  swarm claim "task:add-auth"
+
+  # Claim for 30 minutes as a named owner
+  swarm claim "deploy:staging" --owner ci --ttl 30m
+
+  # Release a claim
+  swarm claim "task:add-auth" --release
+
+  # List the current claims
+  swarm claim --list`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		if claimList {
+			if len(args) > 0 {
+				return fmt.Errorf("--list doesn't take a key")
+			}
+			return listClaims(mgr)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires a key to claim (or --list)")
+		}
+		key := args[0]
+
+		owner := claimOwner
+		if owner == "" {
+			owner = defaultClaimOwner()
+		}
+		if owner == "" {
+			return fmt.Errorf("no owner: pass --owner, or run from an agent (which has SWARM_RUN_ID set)")
+		}
+
+		if claimRelease {
+			if err := mgr.ReleaseClaim(key, owner); err != nil {
+				return err
+			}
+			fmt.Printf("Released %s\n", key)
+			return nil
+		}
+
+		claim, ok, err := mgr.Claim(key, owner, claimTTL)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s is already claimed by %s (since %s)\n", key, claim.Owner, claim.ClaimedAt.Format(time.DateTime))
+			os.Exit(1)
+		}
+		fmt.Printf("Claimed %s\n", key)
+		return nil
+	},
+}
+
+// defaultClaimOwner returns the claim owner of the agent this runs under:
+// its run and task, since every task of a pipeline run shares SWARM_RUN_ID.
+// Returns "" outside an agent.
+func defaultClaimOwner() string {
+	runID := os.Getenv("SWARM_RUN_ID")
+	if runID == "" {
+		return ""
+	}
+	if task := os.Getenv("SWARM_TASK_NAME"); task != "" {
+		return runID + "/" + task
+	}
+	return runID
+}
+
+// listClaims prints the current claims.
+func listClaims(mgr *state.Manager) error {
+	claims, err := mgr.Claims()
+	if err != nil {
+		return fmt.Errorf("failed to list claims: %w", err)
+	}
+
+	if claimFormat == "json" {
+		if claims == nil {
+			claims = []state.Claim{}
+		}
+		output, err := json.MarshalIndent(claims, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(claims) == 0 {
+		fmt.Println("No claims")
+		return nil
+	}
+	for _, c := range claims {
+		expires := ""
+		if c.ExpiresAt != nil {
+			expires = fmt.Sprintf(" (expires in %s)", time.Until(*c.ExpiresAt).Round(time.Second))
+		}
+		fmt.Printf("  %s  %s  %s%s\n", c.ClaimedAt.Format(time.DateTime), c.Key, c.Owner, expires)
+	}
+	return nil
+}
+
+func init() {
+	claimCmd.Flags().StringVar(&claimOwner, "owner", "", "Owner of the claim (default $SWARM_RUN_ID/$SWARM_TASK_NAME)")
+	claimCmd.Flags().DurationVar(&claimTTL, "ttl", 0, "Let the claim lapse after this long (e.g. 30m; default never)")
+	claimCmd.Flags().BoolVar(&claimRelease, "release", false, "Release the claim instead of taking it")
+	claimCmd.Flags().BoolVar(&claimList, "list", false, "List the current claims")
+	claimCmd.Flags().StringVar(&claimFormat, "format", "", "Output format for --list: json or table (default)")
+	rootCmd.AddCommand(claimCmd)
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import "testing"
+
+func TestDefaultClaimOwner(t *testing.T) {
+	t.Setenv("SWARM_RUN_ID", "")
+	t.Setenv("SWARM_TASK_NAME", "planner")
+	if got := defaultClaimOwner(); got != "" {
+		t.Errorf("defaultClaimOwner() = %q outside an agent, want none", got)
+	}
+
+	// Tasks of one pipeline run share the run ID, but not the owner
+	t.Setenv("SWARM_RUN_ID", "run1")
+	if got := defaultClaimOwner(); got != "run1/planner" {
+		t.Errorf("defaultClaimOwner() = %q, want run1/planner", got)
+	}
+	t.Setenv("SWARM_TASK_NAME", "")
+	if got := defaultClaimOwner(); got != "run1" {
+		t.Errorf("defaultClaimOwner() = %q without a task name, want run1", got)
+	}
+}
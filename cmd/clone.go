@@ -43,6 +43,8 @@ The source agent can be running or terminated.
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 By default, the cloned agent runs in the current directory. Use --same-dir
 to run in the source agent's original directory.`,
@@ -275,6 +277,13 @@ to run in the source agent's original directory.`,
 			if effectiveName != "" {
 				detachedArgs = append(detachedArgs, "--name", effectiveName)
 			}
+			if source.Image != "" {
+				detachedArgs = append(detachedArgs, "--image", source.Image)
+			}
+			for _, a := range source.AgentArgs {
+				detachedArgs = append(detachedArgs, "--agent-arg="+a)
+			}
+			detachedArgs = append(detachedArgs, paramArgs(source.ModelParams)...)
 			// Pass expanded env vars to child
 			for _, e := range expandedEnv {
 				detachedArgs = append(detachedArgs, "--_internal-env", e)
@@ -305,6 +314,10 @@ to run in the source agent's original directory.`,
 				LogFile:       logFile,
 				WorkingDir:    effectiveWorkingDir,
 				EnvNames:      envNames,
+				Image:         source.Image,
+				AgentArgs:     source.AgentArgs,
+				ModelParams:   source.ModelParams,
+				Labels:        appConfig.WithDefaultLabels(nil),
 				OnComplete:    cloneOnComplete,
 			}
 
@@ -344,7 +357,12 @@ to run in the source agent's original directory.`,
 				Status:        "running",
 				WorkingDir:    effectiveWorkingDir,
 				EnvNames:      envNames,
+				Image:         source.Image,
+				AgentArgs:     source.AgentArgs,
+				ModelParams:   source.ModelParams,
+				Labels:        appConfig.WithDefaultLabels(nil),
 				OnComplete:    cloneOnComplete,
+				Manifest:      agent.NewManifest(appConfig.AgentCommand().WithImage(source.Image), effectiveWorkingDir),
 			}
 
 			if err := mgr.Register(agentState); err != nil {
@@ -361,12 +379,8 @@ to run in the source agent's original directory.`,
 				}
 				_ = mgr.Update(agentState)
 
-				// Execute on-complete hook
-				if agentState.OnComplete != "" {
-					if err := agent.ExecuteOnCompleteHook(agentState); err != nil {
-						fmt.Printf("[swarm] Warning: on-complete hook failed: %v\n", err)
-					}
-				}
+				// Execute on-complete/on-failure/on-timeout hooks
+				runAgentHooks(mgr, agentState)
 			}()
 
 			fmt.Printf("Cloning agent %s with prompt: %s, model: %s\n", source.ID, promptName, effectiveModel)
@@ -374,15 +388,25 @@ to run in the source agent's original directory.`,
 			cfg := agent.Config{
 				Model:   effectiveModel,
 				Prompt:  promptContent,
-				Command: appConfig.AgentCommand(),
+				Command: appConfig.AgentCommand().WithImage(source.Image).WithExtraArgs(source.AgentArgs).WithParams(source.ModelParams),
 				Env:     expandedEnv,
+				Run:     agent.RunInfo{Iteration: 1, TotalIterations: 1, TaskName: effectiveName, RunID: taskID},
 			}
 
 			agentRunner := agent.NewRunner(cfg)
 			err = agentRunner.Run(os.Stdout)
+			if err := mgr.AppendHistory(agentState.ID, agentRunner.IterationRecord(1, agentState.StartedAt, err, appConfig)); err != nil {
+				fmt.Printf("[swarm] Warning: failed to record iteration history: %v\n", err)
+			}
 			if err != nil {
 				agentState.FailedIters = 1
 				agentState.LastError = err.Error()
+				agentState.LastErrorClass = agentRunner.ClassifyFailure(err)
+				agentState.ExitReason = agentState.LastErrorClass
+				if tail := agentRunner.StderrTail(); len(tail) > 0 {
+					agentState.LastStderr = strings.Join(tail, "\n")
+				}
+				agentRunner.PrintStderrTail(os.Stdout)
 				return err
 			}
 			agentState.SuccessfulIters = 1
@@ -403,6 +427,10 @@ to run in the source agent's original directory.`,
 			Status:        "running",
 			WorkingDir:    effectiveWorkingDir,
 			EnvNames:      envNames,
+			Image:         source.Image,
+			AgentArgs:     source.AgentArgs,
+			ModelParams:   source.ModelParams,
+			Labels:        appConfig.WithDefaultLabels(nil),
 			OnComplete:    cloneOnComplete,
 		}
 
@@ -422,7 +450,7 @@ to run in the source agent's original directory.`,
 			Manager:           mgr,
 			AgentState:        agentState,
 			PromptContent:     promptContent,
-			Command:           appConfig.AgentCommand(),
+			Command:           appConfig.AgentCommand().WithImage(source.Image).WithExtraArgs(source.AgentArgs).WithParams(source.ModelParams),
 			Config:            appConfig,
 			Env:               expandedEnv,
 			Output:            os.Stdout,
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// columnSpec is a table column chosen with --columns or the list_columns /
+// top_columns config, with an optional width override ("name:30").
+type columnSpec struct {
+	Name  string
+	Width int // 0 = the column's default width
+}
+
+// parseColumns parses column choices such as "id,name:30,cost" (entries may
+// also be given as separate elements) against the available column names.
+func parseColumns(entries []string, available []string) ([]columnSpec, error) {
+	known := make(map[string]bool, len(available))
+	for _, name := range available {
+		known[name] = true
+	}
+
+	var specs []columnSpec
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, part := range strings.Split(entry, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, widthStr, hasWidth := strings.Cut(part, ":")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !known[name] {
+				return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(available, ", "))
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("column %q listed more than once", name)
+			}
+			seen[name] = true
+
+			spec := columnSpec{Name: name}
+			if hasWidth {
+				width, err := strconv.Atoi(strings.TrimSpace(widthStr))
+				if err != nil || width < 4 {
+					return nil, fmt.Errorf("invalid width for column %q: %q (must be a number of at least 4)", name, widthStr)
+				}
+				spec.Width = width
+			}
+			specs = append(specs, spec)
+		}
+	}
+	if len(entries) > 0 && len(specs) == 0 {
+		return nil, fmt.Errorf("no columns given (available: %s)", strings.Join(available, ", "))
+	}
+	return specs, nil
+}
+
+// chooseColumns returns the columns to show: the --columns flag if given,
+// otherwise the configured columns, otherwise defaults.
+func chooseColumns(flag string, configured []string, available []string, defaults []columnSpec) ([]columnSpec, error) {
+	if flag != "" {
+		specs, err := parseColumns([]string{flag}, available)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --columns: %w", err)
+		}
+		return specs, nil
+	}
+	if len(configured) > 0 {
+		specs, err := parseColumns(configured, available)
+		if err != nil {
+			return nil, fmt.Errorf("invalid columns in config: %w", err)
+		}
+		return specs, nil
+	}
+	return defaults, nil
+}
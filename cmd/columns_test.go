@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	available := []string{"id", "name", "cost", "task"}
+
+	specs, err := parseColumns([]string{"ID, name:30,cost", "task"}, available)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []columnSpec{{Name: "id"}, {Name: "name", Width: 30}, {Name: "cost"}, {Name: "task"}}
+	if len(specs) != len(want) {
+		t.Fatalf("got %v, want %v", specs, want)
+	}
+	for i := range want {
+		if specs[i] != want[i] {
+			t.Errorf("column %d = %v, want %v", i, specs[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"id,bogus", "name:wide", "name:2", "id,id", " , "} {
+		if _, err := parseColumns([]string{bad}, available); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+	_, err = parseColumns([]string{"nmae"}, available)
+	if err == nil || !strings.Contains(err.Error(), "available: id, name, cost, task") {
+		t.Errorf("expected the available columns in the error, got %v", err)
+	}
+}
+
+func TestChooseColumns(t *testing.T) {
+	available := []string{"id", "name", "cost"}
+	defaults := []columnSpec{{Name: "id"}, {Name: "name"}}
+
+	specs, err := chooseColumns("", nil, available, defaults)
+	if err != nil || len(specs) != 2 {
+		t.Errorf("expected the defaults, got %v, %v", specs, err)
+	}
+	specs, err = chooseColumns("", []string{"cost"}, available, defaults)
+	if err != nil || len(specs) != 1 || specs[0].Name != "cost" {
+		t.Errorf("expected the configured columns, got %v, %v", specs, err)
+	}
+	specs, err = chooseColumns("name:40", []string{"cost"}, available, defaults)
+	if err != nil || len(specs) != 1 || specs[0] != (columnSpec{Name: "name", Width: 40}) {
+		t.Errorf("expected the flag to win, got %v, %v", specs, err)
+	}
+	if _, err := chooseColumns("", []string{"bogus"}, available, defaults); err == nil || !strings.Contains(err.Error(), "config") {
+		t.Errorf("expected a config error, got %v", err)
+	}
+}
+
+func TestDefaultListColumns(t *testing.T) {
+	names := func(specs []columnSpec) string {
+		var out []string
+		for _, s := range specs {
+			out = append(out, s.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := names(defaultListColumns(false, false)); got != "id,name,parent,prompt,model,status,iteration,started" {
+		t.Errorf("project default = %s", got)
+	}
+	if got := names(defaultListColumns(true, true)); got != "id,name,parent,labels,prompt,model,status,iteration,dir,started" {
+		t.Errorf("global with labels = %s", got)
+	}
+	for _, name := range listColumnNames {
+		if _, ok := listColumns[name]; !ok {
+			t.Errorf("list column %q has no definition", name)
+		}
+	}
+	for _, name := range topColumnNames {
+		if _, ok := topColumns[name]; !ok {
+			t.Errorf("top column %q has no definition", name)
+		}
+	}
+}
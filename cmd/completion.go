@@ -3,7 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/mj1618/swarm-cli/internal/compose"
 	"github.com/mj1618/swarm-cli/internal/prompt"
 	"github.com/mj1618/swarm-cli/internal/scope"
 	"github.com/mj1618/swarm-cli/internal/state"
@@ -187,6 +190,156 @@ func completeModelName(cmd *cobra.Command, args []string, toComplete string) ([]
 	return models, cobra.ShellCompDirectiveNoFileComp
 }
 
+// loadComposeForCompletion loads the compose file named by the command's
+// --file/--compose-file flag (or the default path). Returns nil if it can't be loaded.
+func loadComposeForCompletion(cmd *cobra.Command) *compose.ComposeFile {
+	path := compose.DefaultPath()
+	for _, name := range []string{"file", "compose-file"} {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Value.String() != "" {
+			path = f.Value.String()
+			break
+		}
+	}
+	cf, err := compose.LoadLenient(path)
+	if err != nil {
+		return nil
+	}
+	return cf
+}
+
+// completeComposeTaskNames provides dynamic completion for task names in the compose file.
+func completeComposeTaskNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cf := loadComposeForCompletion(cmd)
+	if cf == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, name := range sortedKeys(cf.Tasks) {
+		if containsString(args, name) {
+			continue
+		}
+		completions = append(completions, fmt.Sprintf("%s\t%s", name, composeTaskDescription(cf.Tasks[name])))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeComposeTarget provides dynamic completion for `swarm up` arguments,
+// which may be task names, pipeline names, or <pipeline>:<task> nodes.
+func completeComposeTarget(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// After "<pipeline>:", complete the pipeline's tasks for running a single node
+	if pipelineName, _, ok := strings.Cut(toComplete, ":"); ok {
+		cf := loadComposeForCompletion(cmd)
+		if cf == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		p, exists := cf.Pipelines[pipelineName]
+		if !exists {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var completions []string
+		for _, name := range p.GetPipelineTasks(cf.Tasks) {
+			completions = append(completions, fmt.Sprintf("%s:%s\t%s", pipelineName, name, composeTaskDescription(cf.Tasks[name])))
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions, directive := completeComposeTaskNames(cmd, args, toComplete)
+	pipelines, _ := completePipelineName(cmd, args, toComplete)
+	return append(pipelines, completions...), directive
+}
+
+// completePipelineName provides dynamic completion for pipeline names in the compose file.
+func completePipelineName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cf := loadComposeForCompletion(cmd)
+	if cf == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, name := range sortedKeys(cf.Pipelines) {
+		if containsString(args, name) {
+			continue
+		}
+		p := cf.Pipelines[name]
+		completions = append(completions, fmt.Sprintf("%s\tPipeline (%d tasks, %d iterations)", name, len(p.Tasks), p.EffectiveIterations()))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// composeTaskDescription describes a compose task for completion output.
+func composeTaskDescription(t compose.Task) string {
+	switch {
+	case t.Prompt != "":
+		return "Task (prompt: " + t.Prompt + ")"
+	case t.PromptFile != "":
+		return "Task (prompt-file: " + t.PromptFile + ")"
+	default:
+		return "Task"
+	}
+}
+
+// completeLabel provides dynamic completion for -l/--label flags using labels
+// of known agents. Before "=" it completes keys; after it completes values.
+func completeLabel(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	mgr, err := state.NewManagerWithScope(scope.ScopeGlobal, "")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	agents, err := mgr.List(false)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	key, _, hasValue := strings.Cut(toComplete, "=")
+	seen := make(map[string]bool)
+	var completions []string
+	for _, agent := range agents {
+		for k, v := range agent.Labels {
+			candidate := k + "="
+			if hasValue {
+				if k != key {
+					continue
+				}
+				candidate = k + "=" + v
+			}
+			if !seen[candidate] {
+				seen[candidate] = true
+				completions = append(completions, candidate)
+			}
+		}
+	}
+	sort.Strings(completions)
+
+	if !hasValue {
+		// Leave the cursor after "=" so the value can be typed or completed
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	rootCmd.AddCommand(completionCmd)
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteComposeTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swarm.yaml")
+	content := `version: "1"
+tasks:
+  planner:
+    prompt: plan
+  coder:
+    prompt-string: write code
+pipelines:
+  dev:
+    iterations: 3
+    tasks: [planner, coder]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("file", path, "")
+
+	completions, directive := completeComposeTarget(cmd, []string{"coder"}, "")
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("unexpected directive: %v", directive)
+	}
+
+	var names []string
+	for _, c := range completions {
+		names = append(names, strings.SplitN(c, "\t", 2)[0])
+	}
+	got := strings.Join(names, ",")
+	if got != "dev,planner" {
+		t.Errorf("completions = %q, want pipelines then tasks excluding already-given args (dev,planner)", got)
+	}
+}
+
+func TestCompleteComposeTargetMissingFile(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("file", filepath.Join(t.TempDir(), "missing.yaml"), "")
+
+	completions, _ := completeComposeTarget(cmd, nil, "")
+	if len(completions) != 0 {
+		t.Errorf("expected no completions without a compose file, got %v", completions)
+	}
+}
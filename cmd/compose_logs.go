@@ -28,6 +28,7 @@ var (
 	composeLogsGrep         []string
 	composeLogsGrepInvert   bool
 	composeLogsGrepCase     bool
+	composeLogsLenient      bool
 )
 
 var composeLogsCmd = &cobra.Command{
@@ -65,7 +66,7 @@ started from the specified compose file are shown.`,
   swarm compose-logs -c custom.yaml`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load compose file
-		cf, err := compose.Load(composeLogsFile)
+		cf, err := loadComposeFile(composeLogsFile, composeLogsLenient)
 		if err != nil {
 			return fmt.Errorf("failed to load compose file %s: %w", composeLogsFile, err)
 		}
@@ -108,7 +109,7 @@ started from the specified compose file are shown.`,
 		// Filter for agents that match our compose file tasks and have log files
 		var matchingAgents []*state.AgentState
 		for _, agent := range agents {
-			if agent.WorkingDir == workingDir && effectiveNames[agent.Name] != "" && agent.LogFile != "" {
+			if agent.StartedFrom(workingDir) && effectiveNames[agent.Name] != "" && agent.LogFile != "" {
 				matchingAgents = append(matchingAgents, agent)
 			}
 		}
@@ -168,6 +169,7 @@ started from the specified compose file are shown.`,
 
 func init() {
 	composeLogsCmd.Flags().StringVarP(&composeLogsFile, "compose-file", "c", compose.DefaultPath(), "Path to compose file")
+	composeLogsCmd.Flags().BoolVar(&composeLogsLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
 	composeLogsCmd.Flags().BoolVarP(&composeLogsFollow, "follow", "f", false, "Follow logs in real-time")
 	composeLogsCmd.Flags().IntVar(&composeLogsTail, "tail", 50, "Number of lines to show per agent")
 	composeLogsCmd.Flags().BoolVarP(&composeLogsPretty, "pretty", "P", false, "Pretty-print log output with colors and formatting")
@@ -176,6 +178,7 @@ func init() {
 	composeLogsCmd.Flags().StringArrayVar(&composeLogsGrep, "grep", nil, "Filter lines matching pattern (regex, case-insensitive by default)")
 	composeLogsCmd.Flags().BoolVar(&composeLogsGrepInvert, "invert", false, "Invert match (show non-matching lines)")
 	composeLogsCmd.Flags().BoolVar(&composeLogsGrepCase, "case-sensitive", false, "Make grep pattern case-sensitive")
+	composeLogsCmd.ValidArgsFunction = completeComposeTaskNames
 	rootCmd.AddCommand(composeLogsCmd)
 }
 
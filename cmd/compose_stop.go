@@ -14,6 +14,7 @@ var (
 	composeStopFile    string
 	composeStopNoWait  bool
 	composeStopTimeout int
+	composeStopLenient bool
 )
 
 var composeStopCmd = &cobra.Command{
@@ -48,7 +49,7 @@ started from the specified compose file are affected.`,
   swarm compose-stop --timeout 60`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load compose file
-		cf, err := compose.Load(composeStopFile)
+		cf, err := loadComposeFile(composeStopFile, composeStopLenient)
 		if err != nil {
 			return fmt.Errorf("failed to load compose file %s: %w", composeStopFile, err)
 		}
@@ -91,7 +92,7 @@ started from the specified compose file are affected.`,
 		// Filter for agents that match our compose file tasks
 		var matchingAgents []*state.AgentState
 		for _, agent := range agents {
-			if agent.WorkingDir == workingDir && effectiveNames[agent.Name] {
+			if agent.StartedFrom(workingDir) && effectiveNames[agent.Name] {
 				matchingAgents = append(matchingAgents, agent)
 			}
 		}
@@ -167,6 +168,8 @@ started from the specified compose file are affected.`,
 
 func init() {
 	composeStopCmd.Flags().StringVarP(&composeStopFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	composeStopCmd.Flags().BoolVar(&composeStopLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	composeStopCmd.ValidArgsFunction = completeComposeTaskNames
 	composeStopCmd.Flags().BoolVar(&composeStopNoWait, "no-wait", false, "Return immediately without waiting for agents to pause")
 	composeStopCmd.Flags().IntVar(&composeStopTimeout, "timeout", 300, "Maximum seconds to wait for agents to pause")
 }
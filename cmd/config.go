@@ -383,6 +383,77 @@ and the backend CLI will report an error if the model is invalid.`,
 	},
 }
 
+var configSetColumnsCmd = &cobra.Command{
+	Use:   "set-columns [list|top] [columns]",
+	Short: "Set the columns shown by swarm list or swarm top",
+	Long: `Set the columns ` + "`swarm list`" + ` or ` + "`swarm top`" + ` shows when --columns isn't given.
+
+Columns are comma-separated; a ":N" suffix sets a column's width. Omit the
+columns to go back to the defaults. By default updates the project config
+(swarm/swarm.toml); pass --global to update the global config instead.`,
+	Example: `  # Show a wider name and the labels in swarm list
+  swarm config set-columns list id,name:40,labels,status,started
+
+  # Choose swarm top's columns everywhere
+  swarm config set-columns top id,name:30,status,cost,task --global
+
+  # Go back to swarm list's default columns
+  swarm config set-columns list`,
+	Args:      cobra.RangeArgs(1, 2),
+	ValidArgs: []string{"list", "top"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var available []string
+		switch args[0] {
+		case "list":
+			available = listColumnNames
+		case "top":
+			available = topColumnNames
+		default:
+			return fmt.Errorf("invalid table %q, valid options: list, top", args[0])
+		}
+
+		var columns []string
+		if len(args) == 2 {
+			specs, err := parseColumns(args[1:], available)
+			if err != nil {
+				return err
+			}
+			for _, spec := range specs {
+				if spec.Width > 0 {
+					columns = append(columns, fmt.Sprintf("%s:%d", spec.Name, spec.Width))
+				} else {
+					columns = append(columns, spec.Name)
+				}
+			}
+		}
+
+		configPath, err := resolveConfigPath(configGlobal)
+		if err != nil {
+			return fmt.Errorf("failed to determine config path: %w", err)
+		}
+		cfg, err := loadOrDefaultConfig(configPath)
+		if err != nil {
+			return err
+		}
+		if args[0] == "list" {
+			cfg.ListColumns = columns
+		} else {
+			cfg.TopColumns = columns
+		}
+		if err := writeConfig(cfg, configPath); err != nil {
+			return err
+		}
+
+		if len(columns) == 0 {
+			fmt.Printf("swarm %s columns reset to the defaults\n", args[0])
+		} else {
+			fmt.Printf("swarm %s columns set to %s\n", args[0], strings.Join(columns, ","))
+		}
+		fmt.Printf("Updated config: %s\n", configPath)
+		return nil
+	},
+}
+
 func init() {
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configPathCmd)
@@ -390,12 +461,14 @@ func init() {
 	configCmd.AddCommand(configSetModelCmd)
 	configCmd.AddCommand(configSetSystemPromptCmd)
 	configCmd.AddCommand(configRemoveSystemPromptCmd)
+	configCmd.AddCommand(configSetColumnsCmd)
 
 	configSetBackendCmd.Flags().BoolVarP(&configGlobal, "global", "g", false, "Update global config instead of project config")
 	configSetModelCmd.Flags().BoolVarP(&configGlobal, "global", "g", false, "Update global config instead of project config")
 	configSetSystemPromptCmd.Flags().BoolVarP(&configGlobal, "global", "g", false, "Update global config instead of project config")
 	configSetSystemPromptCmd.Flags().BoolVarP(&configSystemPromptFile, "file", "f", false, "Treat the positional argument as a path to a file whose contents become the system prompt")
 	configRemoveSystemPromptCmd.Flags().BoolVarP(&configGlobal, "global", "g", false, "Update global config instead of project config")
+	configSetColumnsCmd.Flags().BoolVarP(&configGlobal, "global", "g", false, "Update global config instead of project config")
 
 	rootCmd.AddCommand(configCmd)
 }
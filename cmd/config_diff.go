@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configDiffFile      string
+	configDiffLenient   bool
+	configDiffStrictEnv bool
+)
+
+// Kinds of drift between a compose file and its running agents.
+const (
+	driftMissing = "missing" // in the compose file, not running
+	driftExtra   = "extra"   // running, no longer wanted by the compose file
+	driftChanged = "changed" // running with different settings
+)
+
+// composeDrift is how one agent differs from what the compose file wants.
+type composeDrift struct {
+	kind   string // driftMissing, driftExtra, or driftChanged
+	agent  string // agent name
+	detail string // what changed, for driftChanged, e.g. "model opus → sonnet"
+}
+
+// composeTargetDrift is the drift of one compose task or pipeline.
+type composeTargetDrift struct {
+	target string // "task coder" or "pipeline review"
+	drift  []composeDrift
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how running agents differ from the compose file",
+	Long: `Compare the compose file's standalone tasks and pipelines with the agents
+that are actually running, and print the drift:
+  +  an instance the compose file wants that isn't running
+  -  a running instance the compose file no longer wants (e.g. after
+     lowering parallelism)
+  ~  a running instance whose model or iterations differ from the
+     compose file
+
+'swarm up -d' starts missing instances and stops extra ones. It leaves
+running agents alone, so to apply a changed model or iterations, kill the
+agent and run 'swarm up -d' again (or use 'swarm up --watch').
+
+Only agents named after the compose file's tasks and pipelines are
+compared; agents started with 'swarm run' are ignored.
+
+Exits with status 1 if there is drift, so scripts can check whether a
+reconcile is needed.`,
+	Example: `  # Compare ./swarm/swarm.yaml with the running agents
+  swarm config diff
+
+  # Reconcile only if something drifted
+  swarm config diff > /dev/null || swarm up -d`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cf, err := loadComposeFileWithOptions(configDiffFile, compose.LoadOptions{Lenient: configDiffLenient, StrictEnv: configDiffStrictEnv})
+		if err != nil {
+			return fmt.Errorf("failed to load compose file %s: %w", configDiffFile, err)
+		}
+		if err := cf.Validate(); err != nil {
+			return fmt.Errorf("invalid compose file: %w", err)
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+		running, err := mgr.List(true)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+
+		drift := diffComposeAgents(cf, running, appConfig.Model)
+		if len(drift) == 0 {
+			fmt.Printf("No drift: the running agents match %s\n", configDiffFile)
+			return nil
+		}
+		printComposeDrift(drift)
+		os.Exit(1)
+		return nil
+	},
+}
+
+// diffComposeAgents compares the standalone tasks and pipelines of cf with
+// the running agents, returning the drift of each that has any, tasks first,
+// sorted by name. Tasks without a model are expected to use defaultModel.
+func diffComposeAgents(cf *compose.ComposeFile, running []*state.AgentState, defaultModel string) []composeTargetDrift {
+	var result []composeTargetDrift
+
+	tasks := cf.GetStandaloneTasks()
+	for _, name := range keys(tasks) {
+		task := tasks[name]
+		model := task.Model
+		if model == "" {
+			model = defaultModel
+		}
+		want := make(map[string]bool)
+		for _, agentName := range taskInstanceNames(name, task) {
+			want[agentName] = true
+		}
+		isInstance := func(agentName string) bool {
+			return isTaskInstance(agentName, task.EffectiveName(name))
+		}
+		drift := diffInstances(want, running, isInstance, func(a *state.AgentState) []string {
+			var changes []string
+			if a.Model != model {
+				changes = append(changes, fmt.Sprintf("model %s → %s", a.Model, model))
+			}
+			if iterations := task.EffectiveIterations(); a.Iterations != iterations {
+				changes = append(changes, fmt.Sprintf("iterations %d → %d", a.Iterations, iterations))
+			}
+			return changes
+		})
+		if len(drift) > 0 {
+			result = append(result, composeTargetDrift{target: "task " + name, drift: drift})
+		}
+	}
+
+	for _, name := range keys(cf.Pipelines) {
+		pipeline := cf.Pipelines[name]
+		want := make(map[string]bool)
+		for _, inst := range pipelineInstances(name, &pipeline) {
+			want["pipeline:"+inst.Name] = true
+		}
+		isInstance := func(agentName string) bool {
+			return isPipelineInstance(agentName, name)
+		}
+		drift := diffInstances(want, running, isInstance, func(a *state.AgentState) []string {
+			if iterations := pipeline.EffectiveIterations(); a.Iterations != iterations {
+				return []string{fmt.Sprintf("iterations %d → %d", a.Iterations, iterations)}
+			}
+			return nil
+		})
+		if len(drift) > 0 {
+			result = append(result, composeTargetDrift{target: "pipeline " + name, drift: drift})
+		}
+	}
+	return result
+}
+
+// diffInstances compares the wanted agent names with the running agents
+// isInstance accepts, using compare to list how a wanted, running agent
+// differs. Drift is sorted by agent name.
+func diffInstances(want map[string]bool, running []*state.AgentState, isInstance func(string) bool, compare func(*state.AgentState) []string) []composeDrift {
+	var drift []composeDrift
+	seen := make(map[string]bool)
+	for _, a := range running {
+		if !isInstance(a.Name) {
+			continue
+		}
+		seen[a.Name] = true
+		if !want[a.Name] {
+			drift = append(drift, composeDrift{kind: driftExtra, agent: a.Name})
+			continue
+		}
+		if changes := compare(a); len(changes) > 0 {
+			drift = append(drift, composeDrift{kind: driftChanged, agent: a.Name, detail: strings.Join(changes, ", ")})
+		}
+	}
+	for name := range want {
+		if !seen[name] {
+			drift = append(drift, composeDrift{kind: driftMissing, agent: name})
+		}
+	}
+	sort.SliceStable(drift, func(i, j int) bool {
+		return drift[i].agent < drift[j].agent
+	})
+	return drift
+}
+
+func printComposeDrift(targets []composeTargetDrift) {
+	bold := color.New(color.Bold)
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+
+	var missing, extra, changed int
+	for _, t := range targets {
+		bold.Println(t.target)
+		for _, d := range t.drift {
+			switch d.kind {
+			case driftMissing:
+				green.Printf("  + %s", d.agent)
+				fmt.Println("  not running")
+				missing++
+			case driftExtra:
+				red.Printf("  - %s", d.agent)
+				fmt.Println("  running, but no longer wanted")
+				extra++
+			case driftChanged:
+				yellow.Printf("  ~ %s", d.agent)
+				fmt.Printf("  %s\n", d.detail)
+				changed++
+			}
+		}
+	}
+
+	fmt.Printf("\n%d missing, %d extra, %d changed\n", missing, extra, changed)
+	if missing+extra > 0 {
+		fmt.Println("Run 'swarm up -d' to start missing and stop extra instances.")
+	}
+	if changed > 0 {
+		fmt.Println("Changed agents keep running as they are; kill them and run 'swarm up -d' to apply the compose file.")
+	}
+}
+
+func init() {
+	configDiffCmd.Flags().StringVarP(&configDiffFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	configDiffCmd.Flags().BoolVar(&configDiffLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	configDiffCmd.Flags().BoolVar(&configDiffStrictEnv, "strict-env", false, "Fail if the compose file references an unset ${VAR} without a default")
+	configCmd.AddCommand(configDiffCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestDiffComposeAgents(t *testing.T) {
+	cf := &compose.ComposeFile{
+		Version: "1",
+		Tasks: map[string]compose.Task{
+			"coder":    {Prompt: "coder", Model: "opus", Iterations: 10, Parallelism: 2},
+			"reviewer": {Prompt: "reviewer", Iterations: 5},
+			"docs":     {Prompt: "docs"},
+			"lint":     {Prompt: "lint"},
+			"fix":      {Prompt: "fix", DependsOn: []compose.Dependency{{Task: "lint"}}},
+		},
+		Pipelines: map[string]compose.Pipeline{
+			"ci": {Iterations: 3, Tasks: []string{"lint", "fix"}},
+		},
+	}
+	running := []*state.AgentState{
+		{Name: "coder.1", Model: "opus", Iterations: 10},
+		{Name: "coder.2", Model: "sonnet", Iterations: 20},
+		{Name: "coder.3", Model: "opus", Iterations: 10},
+		{Name: "reviewer", Model: "sonnet", Iterations: 5},
+		{Name: "pipeline:ci", Iterations: 3},
+		{Name: "adhoc", Model: "opus", Iterations: 1},
+	}
+
+	got := diffComposeAgents(cf, running, "sonnet")
+	want := []composeTargetDrift{
+		{target: "task coder", drift: []composeDrift{
+			{kind: driftChanged, agent: "coder.2", detail: "model sonnet → opus, iterations 20 → 10"},
+			{kind: driftExtra, agent: "coder.3"},
+		}},
+		{target: "task docs", drift: []composeDrift{
+			{kind: driftMissing, agent: "docs"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffComposeAgents() = %+v\nwant %+v", got, want)
+	}
+
+	running[4].Iterations = 1
+	running = running[:4]
+	got = diffComposeAgents(cf, append(running, &state.AgentState{Name: "pipeline:ci.2", Iterations: 3}), "sonnet")
+	if len(got) != 3 || got[2].target != "pipeline ci" {
+		t.Fatalf("diffComposeAgents() = %+v, want pipeline ci drift last", got)
+	}
+	wantPipeline := []composeDrift{
+		{kind: driftMissing, agent: "pipeline:ci"},
+		{kind: driftExtra, agent: "pipeline:ci.2"},
+	}
+	if !reflect.DeepEqual(got[2].drift, wantPipeline) {
+		t.Errorf("pipeline drift = %+v, want %+v", got[2].drift, wantPipeline)
+	}
+}
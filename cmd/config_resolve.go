@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configResolveFile      string
+	configResolveLenient   bool
+	configResolveStrictEnv bool
+)
+
+var configResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Print the compose file with environment variables interpolated",
+	Long: `Print the compose file as 'swarm up' sees it, with environment variables
+interpolated.
+
+Task model, prompt-string, prefix, suffix, and env values and pipeline
+environment values may reference environment variables:
+  ${VAR}           the value of VAR ("" if unset)
+  ${VAR:-default}  the value of VAR, or default if VAR is unset or empty
+  $$               a literal $
+
+Variables that aren't set and have no default are listed on stderr. With
+--strict-env they are an error instead, as they are for 'swarm up --strict-env'.`,
+	Example: `  # Show the interpolated ./swarm/swarm.yaml
+  swarm config resolve
+
+  # Check that every variable a compose file needs is set
+  swarm config resolve -f ci.yaml --strict-env > /dev/null`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := compose.LoadOptions{Lenient: configResolveLenient, StrictEnv: configResolveStrictEnv}
+		data, undefined, err := compose.Resolve(configResolveFile, opts)
+		var unknown *compose.UnknownFieldsError
+		if errors.As(err, &unknown) {
+			err = fmt.Errorf("%w\n(use --lenient to ignore unknown fields)", err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load compose file %s: %w", configResolveFile, err)
+		}
+		if len(undefined) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %s not set, using empty values\n", strings.Join(undefined, ", "))
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
+func init() {
+	configResolveCmd.Flags().StringVarP(&configResolveFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	configResolveCmd.Flags().BoolVar(&configResolveLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	configResolveCmd.Flags().BoolVar(&configResolveStrictEnv, "strict-env", false, "Fail if the compose file references an unset ${VAR} without a default")
+	configCmd.AddCommand(configResolveCmd)
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	costByModel bool
+	costFormat  string
+)
+
+// AgentCost is the token usage and cost of one agent.
+type AgentCost struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name,omitempty"`
+	Status       string   `json:"status"`
+	Models       []string `json:"models"`
+	InputTokens  int64    `json:"input_tokens"`
+	OutputTokens int64    `json:"output_tokens"`
+	CostUSD      float64  `json:"cost_usd"`
+}
+
+// ModelCost is the token usage and cost of one model across agents.
+type ModelCost struct {
+	Model        string  `json:"model"`
+	Agents       int     `json:"agents"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+var costCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Show token usage and cost of agents",
+	Long: `Show the token usage and cost of every agent, running or terminated, with
+a total.
+
+Usage is recorded per model, so an agent whose model changed between
+iterations (e.g. with 'swarm update --model'), a pipeline whose tasks use
+different models, or an agent whose CLI fell back to another model is split
+correctly. Use --by-model to total usage by model instead of by agent, or
+'swarm stats usage' to total it by day, label, prompt, or directory.
+
+By default, shows agents started in the current project.
+Use --global to show agents from all directories.`,
+	Example: `  # Show cost per agent
+  swarm cost
+
+  # Show cost per model
+  swarm cost --by-model
+
+  # Across all projects, as JSON
+  swarm cost --by-model --global --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agents, err := mgr.List(false)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+
+		var rows interface{}
+		if costByModel {
+			rows = calculateModelCosts(agents)
+		} else {
+			rows = calculateAgentCosts(agents)
+		}
+
+		if costFormat == "json" {
+			output, err := json.MarshalIndent(rows, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+
+		if len(agents) == 0 {
+			fmt.Println("No agents found")
+			return nil
+		}
+		switch rows := rows.(type) {
+		case []ModelCost:
+			printModelCosts(rows)
+		case []AgentCost:
+			printAgentCosts(rows)
+		}
+		return nil
+	},
+}
+
+func init() {
+	costCmd.Flags().BoolVar(&costByModel, "by-model", false, "Total usage and cost by model instead of by agent")
+	costCmd.Flags().StringVar(&costFormat, "format", "", "Output format: json or table (default)")
+	rootCmd.AddCommand(costCmd)
+}
+
+// calculateAgentCosts returns the usage of each agent, in list order.
+func calculateAgentCosts(agents []*state.AgentState) []AgentCost {
+	costs := make([]AgentCost, 0, len(agents))
+	for _, agent := range agents {
+		models := make([]string, 0, 1)
+		for model := range agent.UsageByModel() {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		costs = append(costs, AgentCost{
+			ID:           agent.ID,
+			Name:         agent.Name,
+			Status:       agent.Status,
+			Models:       models,
+			InputTokens:  agent.InputTokens,
+			OutputTokens: agent.OutputTokens,
+			CostUSD:      agent.TotalCost,
+		})
+	}
+	return costs
+}
+
+// calculateModelCosts totals the usage of agents by model, most expensive
+// first.
+func calculateModelCosts(agents []*state.AgentState) []ModelCost {
+	byModel := make(map[string]*ModelCost)
+	for _, agent := range agents {
+		for model, usage := range agent.UsageByModel() {
+			mc := byModel[model]
+			if mc == nil {
+				mc = &ModelCost{Model: model}
+				byModel[model] = mc
+			}
+			mc.Agents++
+			mc.InputTokens += usage.InputTokens
+			mc.OutputTokens += usage.OutputTokens
+			mc.CostUSD += usage.Cost
+		}
+	}
+
+	costs := make([]ModelCost, 0, len(byModel))
+	for _, mc := range byModel {
+		costs = append(costs, *mc)
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].CostUSD != costs[j].CostUSD {
+			return costs[i].CostUSD > costs[j].CostUSD
+		}
+		return costs[i].Model < costs[j].Model
+	})
+	return costs
+}
+
+func printAgentCosts(costs []AgentCost) {
+	header := color.New(color.Bold)
+	header.Printf("%-10s  %-20s  %-24s  %-8s  %-8s  %s\n", "ID", "NAME", "MODELS", "INPUT", "OUTPUT", "COST")
+	var total AgentCost
+	for _, c := range costs {
+		name := c.Name
+		if len(name) > 20 {
+			name = name[:17] + "..."
+		}
+		models := strings.Join(c.Models, ", ")
+		if len(models) > 24 {
+			models = models[:21] + "..."
+		}
+		fmt.Printf("%-10s  %-20s  %-24s  %-8s  %-8s  $%.2f\n", c.ID, name, models,
+			formatTokenCount(c.InputTokens), formatTokenCount(c.OutputTokens), c.CostUSD)
+		total.InputTokens += c.InputTokens
+		total.OutputTokens += c.OutputTokens
+		total.CostUSD += c.CostUSD
+	}
+	header.Printf("%-10s  %-20s  %-24s  %-8s  %-8s  $%.2f\n", "TOTAL", "", "",
+		formatTokenCount(total.InputTokens), formatTokenCount(total.OutputTokens), total.CostUSD)
+}
+
+func printModelCosts(costs []ModelCost) {
+	header := color.New(color.Bold)
+	header.Printf("%-30s  %-6s  %-8s  %-8s  %s\n", "MODEL", "AGENTS", "INPUT", "OUTPUT", "COST")
+	var total ModelCost
+	for _, c := range costs {
+		model := c.Model
+		if len(model) > 30 {
+			model = model[:27] + "..."
+		}
+		fmt.Printf("%-30s  %-6d  %-8s  %-8s  $%.2f\n", model, c.Agents,
+			formatTokenCount(c.InputTokens), formatTokenCount(c.OutputTokens), c.CostUSD)
+		total.InputTokens += c.InputTokens
+		total.OutputTokens += c.OutputTokens
+		total.CostUSD += c.CostUSD
+	}
+	header.Printf("%-30s  %-6s  %-8s  %-8s  $%.2f\n", "TOTAL", "",
+		formatTokenCount(total.InputTokens), formatTokenCount(total.OutputTokens), total.CostUSD)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestCalculateModelCosts(t *testing.T) {
+	switched := &state.AgentState{ID: "a1", Model: "sonnet"}
+	switched.AddModelUsage("opus", 1000, 100, 5)
+	switched.AddModelUsage("sonnet", 2000, 200, 1)
+	agents := []*state.AgentState{
+		switched,
+		{ID: "a2", Model: "sonnet", InputTokens: 500, OutputTokens: 50, TotalCost: 0.5},
+		{ID: "a3", Model: "haiku"},
+	}
+
+	costs := calculateModelCosts(agents)
+	if len(costs) != 2 {
+		t.Fatalf("calculateModelCosts() = %+v, want opus and sonnet", costs)
+	}
+	if costs[0].Model != "opus" || costs[0].Agents != 1 || costs[0].CostUSD != 5 {
+		t.Errorf("costs[0] = %+v, want opus first", costs[0])
+	}
+	if costs[1].Model != "sonnet" || costs[1].Agents != 2 || costs[1].InputTokens != 2500 || costs[1].CostUSD != 1.5 {
+		t.Errorf("costs[1] = %+v, want sonnet from both agents", costs[1])
+	}
+
+	agentCosts := calculateAgentCosts(agents)
+	if got := agentCosts[0].Models; len(got) != 2 || got[0] != "opus" || got[1] != "sonnet" {
+		t.Errorf("agent models = %v, want [opus sonnet]", got)
+	}
+}
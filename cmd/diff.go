@@ -30,6 +30,8 @@ uncommitted changes.
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 Use -- to pass path filters to git diff.`,
 	Example: `  # Show all changes since agent started
@@ -7,7 +7,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/mj1618/swarm-cli/internal/compose"
@@ -182,6 +181,22 @@ func checkBackend() CheckResult {
 
 	result.Details = append(result.Details, fmt.Sprintf("Command: %s ...", executable))
 
+	// On the Kubernetes job backend the agent CLI lives in the container image,
+	// so only kubectl needs to be available locally.
+	if cfg.Kubernetes.Enabled {
+		result.Details = append(result.Details, fmt.Sprintf("Kubernetes image: %s", cfg.Kubernetes.Image))
+		kubectl := cfg.Kubernetes.KubectlPath()
+		path, err := exec.LookPath(kubectl)
+		if err != nil {
+			result.Status = "fail"
+			result.Details = append(result.Details, fmt.Sprintf("kubectl: %s (NOT FOUND in PATH)", kubectl))
+			result.Suggestions = append(result.Suggestions, "Install kubectl or set kubernetes.kubectl in your config")
+			return result
+		}
+		result.Details = append(result.Details, fmt.Sprintf("kubectl: %s (found)", path))
+		return result
+	}
+
 	// Check if executable exists
 	path, err := exec.LookPath(executable)
 	if err != nil {
@@ -224,19 +239,25 @@ func checkState() CheckResult {
 		return result
 	}
 
-	statePath := filepath.Join(homeDir, ".swarm", "state.json")
-	info, err := os.Stat(statePath)
+	agentsDir := filepath.Join(homeDir, ".swarm", "agents")
+	entries, err := os.ReadDir(agentsDir)
 	if os.IsNotExist(err) {
-		result.Details = append(result.Details, fmt.Sprintf("State file: %s (not found, will be created)", statePath))
+		result.Details = append(result.Details, fmt.Sprintf("State directory: %s (not found, will be created)", agentsDir))
 		return result
 	}
 	if err != nil {
 		result.Status = "fail"
-		result.Details = append(result.Details, fmt.Sprintf("State file error: %v", err))
+		result.Details = append(result.Details, fmt.Sprintf("State directory error: %v", err))
 		return result
 	}
 
-	result.Details = append(result.Details, fmt.Sprintf("State file: %s (%s)", statePath, formatBytes(info.Size())))
+	var stateSize int64
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			stateSize += info.Size()
+		}
+	}
+	result.Details = append(result.Details, fmt.Sprintf("State directory: %s (%d files, %s)", agentsDir, len(entries), formatBytes(stateSize)))
 
 	// Count agents
 	mgr, err := state.NewManagerWithScope(GetScope(), "")
@@ -255,7 +276,7 @@ func checkState() CheckResult {
 	// Count stale (running status but process not actually running)
 	stale := 0
 	for _, agent := range allAgents {
-		if agent.Status == "running" && !doctorIsProcessRunning(agent.PID) {
+		if agent.Status == "running" && !agent.ProcessAlive() {
 			stale++
 		}
 	}
@@ -342,7 +363,7 @@ func checkPrompts() CheckResult {
 
 	// Check compose file for prompt references
 	composePath := compose.DefaultPath()
-	cf, err := compose.Load(composePath)
+	cf, err := compose.LoadLenient(composePath)
 	if err != nil {
 		// No compose file or parse error — skip compose-level checks
 		if !promptsDirExists {
@@ -454,22 +475,6 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// doctorIsProcessRunning checks if a process with the given PID is still running.
-func doctorIsProcessRunning(pid int) bool {
-	if pid <= 0 {
-		return false
-	}
-
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-
-	// On Unix, sending signal 0 checks if process exists
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
-}
-
 func init() {
 	doctorCmd.Flags().StringVar(&doctorFormat, "format", "", "Output format: json or table (default)")
 	doctorCmd.Flags().StringVar(&doctorCheck, "check", "", "Run specific check only (config, backend, state, disk, prompts)")
@@ -5,14 +5,14 @@ import (
 	"time"
 
 	"github.com/mj1618/swarm-cli/internal/compose"
-	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/scope"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
 )
 
 var (
-	downFile string
+	downFile    string
+	downLenient bool
 )
 
 var downCmd = &cobra.Command{
@@ -37,7 +37,7 @@ started from the specified compose file are affected.`,
   swarm down -f custom.yaml`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load compose file
-		cf, err := compose.Load(downFile)
+		cf, err := loadComposeFile(downFile, downLenient)
 		if err != nil {
 			return fmt.Errorf("failed to load compose file %s: %w", downFile, err)
 		}
@@ -105,7 +105,7 @@ started from the specified compose file are affected.`,
 		// Uses pattern matching to handle parallel instances (e.g. "name.1", "pipeline:name.2").
 		var matchingAgents []*state.AgentState
 		for _, agent := range allAgents {
-			if agent.Status != "running" || agent.WorkingDir != workingDir {
+			if agent.Status != "running" || !agent.StartedFrom(workingDir) {
 				continue
 			}
 			// Check if this agent matches any pipeline name (handles .N suffixes)
@@ -154,7 +154,7 @@ started from the specified compose file are affected.`,
 			}
 
 			// Force kill the process immediately (SIGKILL on Unix)
-			if err := process.ForceKill(a.PID); err != nil {
+			if err := a.ForceKill(); err != nil {
 				fmt.Printf("Warning: could not kill process %d: %v\n", a.PID, err)
 			}
 
@@ -175,4 +175,6 @@ started from the specified compose file are affected.`,
 
 func init() {
 	downCmd.Flags().StringVarP(&downFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	downCmd.Flags().BoolVar(&downLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	downCmd.ValidArgsFunction = completeComposeTaskNames
 }
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var execClear bool
+
+var execCmd = &cobra.Command{
+	Use:   "exec [task-id-or-name] [instruction]",
+	Short: "Give a running agent a one-off instruction",
+	Long: `Queue a one-off instruction for a running agent, without restarting it.
+
+The instruction is added to the prompt of the agent's next iteration only,
+after the prompt itself, e.g. to ask for something the prompt forgot. Several
+instructions queued before the next iteration are all added, oldest first.
+The iteration's log shows the instructions it received. For a pipeline, every
+task of its next pipeline iteration gets them.
+
+The agent must have an iteration left to run: an agent running a single
+iteration, on its last iteration, or stopping, can't be given instructions. Without an instruction, the queued
+ones are listed.
+
+The agent can be specified by its ID, name, or special identifier:
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
+	Example: `  # Ask an agent to also update the changelog
+  swarm exec my-agent "also update the changelog"
+
+  # List the instructions waiting for the next iteration
+  swarm exec my-agent
+
+  # Drop the queued instructions
+  swarm exec my-agent --clear`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agent, err := ResolveAgentIdentifier(mgr, args[0])
+		if err != nil {
+			return err
+		}
+
+		instruction := strings.TrimSpace(strings.Join(args[1:], " "))
+
+		if execClear {
+			if instruction != "" {
+				return fmt.Errorf("--clear cannot be combined with an instruction")
+			}
+			messages, err := mgr.TakeMessages(agent.ID)
+			if err != nil {
+				return fmt.Errorf("failed to clear instructions: %w", err)
+			}
+			fmt.Printf("Cleared %d instruction(s) from %s\n", len(messages), agent.ID)
+			return nil
+		}
+
+		if instruction == "" {
+			messages, err := mgr.Messages(agent.ID)
+			if err != nil {
+				return err
+			}
+			if len(messages) == 0 {
+				fmt.Println("No queued instructions")
+				return nil
+			}
+			for _, m := range messages {
+				fmt.Printf("  %s  %s\n", m.QueuedAt.Format(time.DateTime), m.Text)
+			}
+			return nil
+		}
+
+		if err := checkCanExec(agent); err != nil {
+			return err
+		}
+		queued, err := mgr.QueueMessage(agent.ID, instruction)
+		if err != nil {
+			return err
+		}
+		if queued == 1 {
+			fmt.Printf("Queued for the next iteration of %s\n", agent.ID)
+		} else {
+			fmt.Printf("Queued for the next iteration of %s (%d instructions waiting)\n", agent.ID, queued)
+		}
+		return nil
+	},
+}
+
+// checkCanExec returns an error if agent will never start another iteration
+// to deliver an instruction to.
+func checkCanExec(agent *state.AgentState) error {
+	switch {
+	case agent.Status != "running":
+		return fmt.Errorf("agent %s is not running", agent.ID)
+	case agent.TerminateMode != "":
+		return fmt.Errorf("agent %s is stopping", agent.ID)
+	case agent.Iterations == 1:
+		return fmt.Errorf("agent %s runs a single iteration, which has already started", agent.ID)
+	case agent.Iterations != 0 && agent.CurrentIter >= agent.Iterations:
+		return fmt.Errorf("agent %s is on its last iteration (%d/%d); use 'swarm update %s --iterations N' to give it more", agent.ID, agent.CurrentIter, agent.Iterations, agent.ID)
+	}
+	return nil
+}
+
+func init() {
+	execCmd.Flags().BoolVar(&execClear, "clear", false, "Drop the instructions queued for the agent")
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.ValidArgsFunction = completeAgentIdentifier
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestCheckCanExec(t *testing.T) {
+	tests := []struct {
+		name  string
+		agent state.AgentState
+		ok    bool
+	}{
+		{"running loop", state.AgentState{Status: "running", Iterations: 5, CurrentIter: 2}, true},
+		{"unlimited", state.AgentState{Status: "running", Iterations: 0, CurrentIter: 9}, true},
+		{"terminated", state.AgentState{Status: "terminated", Iterations: 5, CurrentIter: 2}, false},
+		{"stopping", state.AgentState{Status: "running", Iterations: 5, CurrentIter: 2, TerminateMode: "after_iteration"}, false},
+		{"last iteration", state.AgentState{Status: "running", Iterations: 5, CurrentIter: 5}, false},
+		{"single iteration", state.AgentState{Status: "running", Iterations: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.agent
+			a.ID = "abc12345"
+			if err := checkCanExec(&a); (err == nil) != tt.ok {
+				t.Errorf("checkCanExec() = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var historyJSON bool
+
+// historyTotals sums an agent's iteration history.
+type historyTotals struct {
+	Iterations   int           `json:"iterations"`
+	Succeeded    int           `json:"succeeded"`
+	Failed       int           `json:"failed"`
+	Duration     time.Duration `json:"duration_ns"`
+	InputTokens  int64         `json:"input_tokens"`
+	OutputTokens int64         `json:"output_tokens"`
+	CostUSD      float64       `json:"cost_usd"`
+}
+
+// historyOutput is the document printed by 'swarm history --json'.
+type historyOutput struct {
+	AgentID    string                  `json:"agent_id"`
+	Name       string                  `json:"name"`
+	Iterations []state.IterationRecord `json:"iterations"`
+	Totals     historyTotals           `json:"totals"`
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [task-id-or-name]",
+	Short: "Show an agent's iterations",
+	Long: `Show an agent's iterations, one row each: when it started, how long it ran,
+whether it succeeded, its tokens and cost, and a summary of its result (the
+agent's exit report summary, or the first line of its result), followed by
+totals.
+
+Each iteration is recorded when it finishes, so a running agent's current
+iteration isn't shown. Pipeline tasks are recorded per run instead (see
+'swarm runs show').
+
+The agent can be specified by its ID, name, or special identifier:
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
+	Example: `  # Show an agent's iterations
+  swarm history my-agent
+
+  # Output as JSON
+  swarm history @last --json
+
+  # The cost of each failed iteration
+  swarm history abc123 --json | jq '.iterations[] | select(.status == "failed") | .cost_usd'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agent, err := ResolveAgentIdentifier(mgr, args[0])
+		if err != nil {
+			return err
+		}
+
+		records, err := mgr.History(agent.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+		totals := sumHistory(records)
+
+		if historyJSON {
+			if records == nil {
+				records = []state.IterationRecord{}
+			}
+			output, err := json.MarshalIndent(historyOutput{AgentID: agent.ID, Name: agent.Name, Iterations: records, Totals: totals}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+
+		if len(records) == 0 {
+			fmt.Printf("No finished iterations recorded for %s\n", agent.ID)
+			return nil
+		}
+
+		bold := color.New(color.Bold)
+		bold.Printf("%-4s  %-19s  %-10s  %-10s  %-8s  %-8s  %-9s  %s\n", "ITER", "STARTED", "DURATION", "STATUS", "IN", "OUT", "COST", "SUMMARY")
+		for _, r := range records {
+			fmt.Printf("%-4d  %-19s  %-10s  ", r.Iteration, r.StartedAt.Local().Format("2006-01-02 15:04:05"), formatTopDuration(r.Duration()))
+			runStatusColor(r.Status).Printf("%-10s", r.Status)
+			fmt.Printf("  %-8s  %-8s  $%-8.4f  %s\n", formatTokenCount(r.InputTokens), formatTokenCount(r.OutputTokens), r.CostUSD, historySummary(r))
+		}
+
+		fmt.Println()
+		fmt.Printf("%d iterations (%d succeeded, %d failed) in %s, %s in / %s out, $%.4f\n",
+			totals.Iterations, totals.Succeeded, totals.Failed, formatTopDuration(totals.Duration),
+			formatTokenCount(totals.InputTokens), formatTokenCount(totals.OutputTokens), totals.CostUSD)
+		return nil
+	},
+}
+
+// sumHistory returns the totals of an agent's iterations.
+func sumHistory(records []state.IterationRecord) historyTotals {
+	var t historyTotals
+	for _, r := range records {
+		t.Iterations++
+		if r.Status == "failed" {
+			t.Failed++
+		} else {
+			t.Succeeded++
+		}
+		t.Duration += r.Duration()
+		t.InputTokens += r.InputTokens
+		t.OutputTokens += r.OutputTokens
+		t.CostUSD += r.CostUSD
+	}
+	return t
+}
+
+// historySummary returns the summary column of an iteration: its result
+// summary, or for a failed iteration without one, its failure class.
+func historySummary(r state.IterationRecord) string {
+	summary := r.Summary
+	if summary == "" && r.ErrorClass != "" {
+		summary = "(" + r.ErrorClass + ")"
+	}
+	return truncateString(summary, 60)
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Output the iterations and totals as JSON")
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.ValidArgsFunction = completeAgentIdentifier
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestSumHistory(t *testing.T) {
+	started := time.Now()
+	records := []state.IterationRecord{
+		{Iteration: 1, StartedAt: started, FinishedAt: started.Add(time.Minute), Status: "succeeded", InputTokens: 1000, OutputTokens: 100, CostUSD: 0.25},
+		{Iteration: 2, StartedAt: started, FinishedAt: started.Add(30 * time.Second), Status: "failed", InputTokens: 500, OutputTokens: 50, CostUSD: 0.125},
+	}
+	got := sumHistory(records)
+	want := historyTotals{Iterations: 2, Succeeded: 1, Failed: 1, Duration: 90 * time.Second, InputTokens: 1500, OutputTokens: 150, CostUSD: 0.375}
+	if got != want {
+		t.Errorf("sumHistory() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHistorySummary(t *testing.T) {
+	if got := historySummary(state.IterationRecord{Summary: "Fixed the login redirect"}); got != "Fixed the login redirect" {
+		t.Errorf("historySummary() = %q", got)
+	}
+	if got := historySummary(state.IterationRecord{Status: "failed", ErrorClass: "timeout"}); got != "(timeout)" {
+		t.Errorf("historySummary() of a failure = %q", got)
+	}
+}
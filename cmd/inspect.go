@@ -3,9 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/dag"
+	"github.com/mj1618/swarm-cli/internal/detach"
 	"github.com/mj1618/swarm-cli/internal/label"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
@@ -19,8 +23,14 @@ var inspectCmd = &cobra.Command{
 	Short:   "Display detailed information about an agent",
 	Long: `Display detailed information about a specific agent including its status, configuration, and logs.
 
+Versions shows what the agent started with: the swarm version, the agent
+CLI's --version, and the git commit (and branch) of its working directory,
+marked dirty if it had uncommitted changes.
+
 The agent can be specified by its ID, name, or special identifier:
-  - @last or _ : the most recently started agent`,
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
 	Example: `  # Inspect by task ID
   swarm inspect abc123
 
@@ -94,6 +104,12 @@ The agent can be specified by its ID, name, or special identifier:
 		}
 		fmt.Print("Status:        ")
 		statusColor.Println(statusStr)
+		if agent.Paused && agent.Approval != "" {
+			fmt.Printf("Approval:      %s (awaiting 'swarm approve %s')\n", agent.Approval, agent.ID)
+		}
+		if agent.Paused && agent.ResumeAt != nil {
+			fmt.Printf("Resumes at:    %s (in %s)\n", agent.ResumeAt.Format(time.RFC3339), time.Until(*agent.ResumeAt).Round(time.Second))
+		}
 
 		fmt.Printf("Started:       %s\n", agent.StartedAt.Format(time.RFC3339))
 		if agent.TerminatedAt != nil {
@@ -108,12 +124,23 @@ The agent can be specified by its ID, name, or special identifier:
 			fmt.Printf("Exit reason:   %s\n", agent.ExitReason)
 		}
 
+		if agent.LastErrorClass != "" {
+			fmt.Printf("Error class:   %s\n", agent.LastErrorClass)
+		}
+		if agent.ContextOverflows > 0 {
+			fmt.Printf("Ctx overflows: %d\n", agent.ContextOverflows)
+		}
+
 		if agent.Iterations == 0 {
 			fmt.Printf("Iteration:     %d (unlimited)\n", agent.CurrentIter)
 		} else {
 			fmt.Printf("Iteration:     %d/%d\n", agent.CurrentIter, agent.Iterations)
 		}
 
+		if agent.LastIteration != nil {
+			fmt.Printf("Last iter:     %s\n", dag.FormatIterationSummary(agent.LastIteration, agent.Iterations))
+		}
+
 		// Show iteration breakdown if there were any iterations
 		if agent.SuccessfulIters > 0 || agent.FailedIters > 0 {
 			fmt.Printf("Successful:    %d\n", agent.SuccessfulIters)
@@ -128,6 +155,55 @@ The agent can be specified by its ID, name, or special identifier:
 		if agent.WorkingDir != "" {
 			fmt.Printf("Directory:     %s\n", agent.WorkingDir)
 		}
+		if agent.ProjectDir != "" {
+			fmt.Printf("Project:       %s\n", agent.ProjectDir)
+		}
+
+		if agent.RunID != "" {
+			fmt.Printf("Run ID:        %s\n", agent.RunID)
+		}
+
+		if agent.Image != "" {
+			fmt.Printf("Image:         %s\n", agent.Image)
+		}
+
+		if len(agent.AgentArgs) > 0 {
+			fmt.Printf("Agent args:    %s\n", strings.Join(agent.AgentArgs, " "))
+		}
+
+		if len(agent.ModelParams) > 0 {
+			fmt.Printf("Params:        %s\n", label.Format(agent.ModelParams))
+		}
+
+		if agent.Manifest != nil {
+			fmt.Printf("Versions:      %s\n", agent.Manifest)
+		}
+
+		if agent.PodName != "" || agent.PodStatus != "" {
+			fmt.Printf("Pod:           %s (%s)\n", agent.PodName, agent.PodStatus)
+		}
+
+		if agent.Health != "" {
+			health := agent.Health
+			if agent.HealthError != "" {
+				health += " (" + agent.HealthError + ")"
+			}
+			if agent.HealthCheckedAt != nil {
+				health += ", checked " + agent.HealthCheckedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("Health:        %s\n", health)
+		}
+
+		if median := agent.MedianIterationDuration(); median > 0 {
+			timing := fmt.Sprintf("median %s over the last %d iterations", median.Round(time.Second), len(agent.IterationDurations))
+			if agent.Status == "running" && agent.IterationStartedAt != nil {
+				timing += fmt.Sprintf(", current running %s", time.Since(*agent.IterationStartedAt).Round(time.Second))
+				if agent.SlowIteration {
+					timing += " (slow)"
+				}
+			}
+			fmt.Printf("Iter time:     %s\n", timing)
+		}
 
 		if agent.TerminateMode != "" {
 			fmt.Printf("Terminate:     %s\n", agent.TerminateMode)
@@ -155,6 +231,39 @@ The agent can be specified by its ID, name, or special identifier:
 			}
 		}
 
+		if len(agent.Notes) > 0 {
+			fmt.Println()
+			bold.Println("Notes")
+			fmt.Println("─────────────────────────────────")
+			printNotes(agent.Notes)
+		}
+
+		if report := agent.ExitReport; report != nil {
+			fmt.Println()
+			bold.Println("Exit Report")
+			fmt.Println("─────────────────────────────────")
+			fmt.Printf("Status:        %s\n", report.Status)
+			if report.Summary != "" {
+				fmt.Printf("Summary:       %s\n", report.Summary)
+			}
+			if report.FollowUp != "" {
+				fmt.Printf("Follow-up:     %s\n", report.FollowUp)
+			}
+		}
+
+		if len(agent.HookRuns) > 0 {
+			fmt.Println()
+			bold.Println("Hooks")
+			fmt.Println("─────────────────────────────────")
+			for _, run := range agent.HookRuns {
+				outcome := "ok"
+				if !run.Succeeded() {
+					outcome = "failed: " + run.Error
+				}
+				fmt.Printf("  on-%-10s %s (%d attempt(s)) %s\n", run.Event, outcome, run.Attempts, run.Command)
+			}
+		}
+
 		if agent.LastError != "" {
 			fmt.Println()
 			bold.Println("Last Error")
@@ -167,11 +276,31 @@ The agent can be specified by its ID, name, or special identifier:
 			fmt.Println(errMsg)
 		}
 
+		if agent.LastStderr != "" {
+			fmt.Println()
+			bold.Println("Last Stderr")
+			fmt.Println("─────────────────────────────────")
+			fmt.Println(agent.LastStderr)
+		}
+
+		if agent.ScratchDir != "" {
+			if _, err := os.Stat(agent.ScratchDir); err == nil {
+				fmt.Println()
+				bold.Println("Scratch Dir")
+				fmt.Println("─────────────────────────────────")
+				fmt.Println(agent.ScratchDir)
+			}
+		}
+
 		if agent.LogFile != "" {
 			fmt.Println()
 			bold.Println("Log File")
 			fmt.Println("─────────────────────────────────")
 			fmt.Println(agent.LogFile)
+			stderrLog := detach.StderrLogPath(agent.LogFile)
+			if _, err := os.Stat(stderrLog); err == nil {
+				fmt.Println(stderrLog)
+			}
 		}
 
 		return nil
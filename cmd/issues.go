@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/github"
+	"github.com/mj1618/swarm-cli/internal/logsummary"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// Labels recording which GitHub issue an agent was started for.
+const (
+	issueNumberLabel = "github.issue"
+	issueRepoLabel   = "github.repo"
+)
+
+var (
+	issuesLabel      string
+	issuesRepo       string
+	issuesPrompt     string
+	issuesPromptFile string
+	issuesModel      string
+	issuesIterations int
+	issuesDryRun     bool
+)
+
+var issuesCmd = &cobra.Command{
+	Use:   "issues",
+	Short: "Run agents for labeled GitHub issues",
+	Long: `Turn labeled GitHub issues into agent tasks and post the agents' reports
+back on the issues.
+
+'swarm issues run' starts a detached agent for each open issue with the label
+(default "swarm") that no agent has been started for yet. The issue's title
+and body are the agent's task: they're appended to --prompt or --prompt-file,
+or used as the whole prompt. When the agent finishes, its report (the result
+of its last iteration) is posted as a comment on the issue.
+
+Run 'swarm issues run' again (e.g. from cron) to pick up newly labeled issues.
+Requires the GitHub CLI (gh), logged in with access to the repository.`,
+	Example: `  # Start agents for open issues labeled "swarm" in this repository
+  swarm issues run
+
+  # Use the coder prompt, with the issue appended
+  swarm issues run -p coder --label agent-ready
+
+  # Issues from another repository
+  swarm issues run --repo org/service -p coder`,
+}
+
+var issuesRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Start agents for open labeled issues",
+	Long: `Start a detached agent for each open GitHub issue with the label that no
+agent has been started for yet.
+
+Agents are named issue-<number> and labeled github.issue=<number> (and
+github.repo=<repo> with --repo), so 'swarm list --label github.issue' shows
+them. Each agent posts its report on its issue when it finishes.`,
+	Example: `  # Start agents for open issues labeled "swarm"
+  swarm issues run
+
+  # Show which issues would be started
+  swarm issues run --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if issuesPrompt != "" && issuesPromptFile != "" {
+			return fmt.Errorf("--prompt and --prompt-file are mutually exclusive")
+		}
+
+		issues, err := github.ListIssues(issuesRepo, issuesLabel)
+		if err != nil {
+			return err
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+		agents, err := mgr.List(false)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+		started := startedIssues(agents, issuesRepo)
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find swarm executable: %w", err)
+		}
+
+		count := 0
+		for _, issue := range issues {
+			if started[issue.Number] {
+				continue
+			}
+			count++
+			if issuesDryRun {
+				fmt.Printf("Would start an agent for #%d: %s\n", issue.Number, issue.Title)
+				continue
+			}
+
+			fmt.Printf("Starting an agent for #%d: %s\n", issue.Number, issue.Title)
+			run := exec.Command(exe, issueRunArgs(issue, exe)...)
+			run.Stdout = os.Stdout
+			run.Stderr = os.Stderr
+			if err := run.Run(); err != nil {
+				return fmt.Errorf("failed to start an agent for #%d: %w", issue.Number, err)
+			}
+		}
+
+		if count == 0 {
+			fmt.Printf("No new open issues labeled %q\n", issuesLabel)
+		}
+		return nil
+	},
+}
+
+var issuesReportCmd = &cobra.Command{
+	Use:   "report [task-id-or-name]",
+	Short: "Post an agent's report on its GitHub issue",
+	Long: `Post an agent's report as a comment on the GitHub issue it was started for.
+
+Agents started by 'swarm issues run' do this when they finish; run it by hand
+to post a report again. Without an argument, reports on $SWARM_AGENT_ID (set
+for on-complete hooks).`,
+	Example: `  # Post the report of issue-42's agent
+  swarm issues report issue-42`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		identifier := os.Getenv("SWARM_AGENT_ID")
+		if len(args) > 0 {
+			identifier = args[0]
+		}
+		if identifier == "" {
+			return fmt.Errorf("no agent given and SWARM_AGENT_ID is not set")
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+		agent, err := ResolveAgentIdentifier(mgr, identifier)
+		if err != nil {
+			return err
+		}
+
+		number, repo, ok := agentIssue(agent)
+		if !ok {
+			return fmt.Errorf("agent %s was not started for a GitHub issue", agent.ID)
+		}
+
+		summary, err := logsummary.Parse(agent)
+		if err != nil {
+			return fmt.Errorf("failed to read agent logs: %w", err)
+		}
+		if err := github.Comment(repo, number, issueReport(agent, summary)); err != nil {
+			return fmt.Errorf("failed to comment on issue #%d: %w", number, err)
+		}
+		fmt.Printf("Posted the report of %s on issue #%d\n", agent.ID, number)
+		return nil
+	},
+}
+
+func init() {
+	issuesRunCmd.Flags().StringVar(&issuesLabel, "label", "swarm", "Issue label that assigns an issue to the swarm")
+	issuesRunCmd.Flags().StringVar(&issuesRepo, "repo", "", "GitHub repository as owner/name (default: the current directory's)")
+	issuesRunCmd.Flags().StringVarP(&issuesPrompt, "prompt", "p", "", "Prompt name (from prompts directory) that the issue is appended to")
+	issuesRunCmd.Flags().StringVarP(&issuesPromptFile, "prompt-file", "f", "", "Prompt file that the issue is appended to")
+	issuesRunCmd.Flags().StringVarP(&issuesModel, "model", "m", "", "Model to use for the agents (overrides config)")
+	issuesRunCmd.Flags().IntVarP(&issuesIterations, "iterations", "n", 1, "Number of iterations each agent runs")
+	issuesRunCmd.Flags().BoolVar(&issuesDryRun, "dry-run", false, "Show which issues would get agents without starting them")
+	issuesRunCmd.RegisterFlagCompletionFunc("prompt", completePromptName)
+	issuesCmd.AddCommand(issuesRunCmd)
+	issuesCmd.AddCommand(issuesReportCmd)
+	rootCmd.AddCommand(issuesCmd)
+}
+
+// startedIssues returns the numbers of the issues in repo that agents have
+// been started for, running or not, so each issue is only picked up once.
+func startedIssues(agents []*state.AgentState, repo string) map[int]bool {
+	started := make(map[int]bool)
+	for _, agent := range agents {
+		if number, agentRepo, ok := agentIssue(agent); ok && agentRepo == repo {
+			started[number] = true
+		}
+	}
+	return started
+}
+
+// agentIssue returns the GitHub issue an agent was started for.
+func agentIssue(agent *state.AgentState) (number int, repo string, ok bool) {
+	number, err := strconv.Atoi(agent.Labels[issueNumberLabel])
+	if err != nil {
+		return 0, "", false
+	}
+	return number, agent.Labels[issueRepoLabel], true
+}
+
+// issueRunArgs returns the 'swarm run' arguments that start a detached agent
+// for issue, which reports back on the issue through an on-complete hook.
+func issueRunArgs(issue github.Issue, exe string) []string {
+	report := shellQuote(exe) + " issues report"
+	if globalFlag {
+		report += " --global"
+	}
+
+	args := []string{"run", "-d",
+		"--name", fmt.Sprintf("issue-%d", issue.Number),
+		"--iterations", strconv.Itoa(issuesIterations),
+		"--label", fmt.Sprintf("%s=%d", issueNumberLabel, issue.Number),
+		"--on-complete", report,
+	}
+	if issuesRepo != "" {
+		args = append(args, "--label", issueRepoLabel+"="+issuesRepo)
+	}
+	if globalFlag {
+		args = append(args, "--global")
+	}
+	if issuesModel != "" {
+		args = append(args, "--model", issuesModel)
+	}
+
+	switch {
+	case issuesPrompt != "":
+		args = append(args, "--prompt", issuesPrompt, "--suffix", "\n"+issue.TaskPrompt())
+	case issuesPromptFile != "":
+		args = append(args, "--prompt-file", issuesPromptFile, "--suffix", "\n"+issue.TaskPrompt())
+	default:
+		args = append(args, "--prompt-string", issue.TaskPrompt())
+	}
+	return args
+}
+
+// issueReport formats an agent's report as an issue comment: how the run
+// ended, followed by the agent's final result (the summary of its exit
+// report, if it wrote one) and any follow-up it left.
+func issueReport(agent *state.AgentState, summary *logsummary.Summary) string {
+	var b strings.Builder
+
+	outcome := agent.ExitReason
+	if outcome == "" {
+		outcome = agent.Status
+	}
+	fmt.Fprintf(&b, "**swarm agent `%s` (%s) finished: %s**\n\n", agent.Name, agent.ID, outcome)
+	fmt.Fprintf(&b, "Iterations: %d/%d · Duration: %s", agent.CurrentIter, agent.Iterations, summary.FormatDuration())
+	if agent.TotalCost > 0 {
+		fmt.Fprintf(&b, " · Cost: $%.2f", agent.TotalCost)
+	}
+	if changed := summary.FilesCreated + summary.FilesModified + summary.FilesDeleted; changed > 0 {
+		fmt.Fprintf(&b, " · Files changed: %d", changed)
+	}
+	b.WriteString("\n\n")
+
+	result := strings.TrimSpace(summary.Result)
+	if agent.ExitReport != nil && agent.ExitReport.Summary != "" {
+		result = agent.ExitReport.Summary
+	}
+	if result != "" {
+		b.WriteString(result)
+		b.WriteString("\n")
+	} else {
+		b.WriteString("_The agent did not report a result._\n")
+	}
+	if agent.ExitReport != nil && agent.ExitReport.FollowUp != "" {
+		fmt.Fprintf(&b, "\n**Follow-up:** %s\n", agent.ExitReport.FollowUp)
+	}
+	if agent.Manifest != nil {
+		fmt.Fprintf(&b, "\n<sub>%s</sub>\n", agent.Manifest)
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/exitreport"
+	"github.com/mj1618/swarm-cli/internal/github"
+	"github.com/mj1618/swarm-cli/internal/logsummary"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestStartedIssues(t *testing.T) {
+	agents := []*state.AgentState{
+		{ID: "a", Labels: map[string]string{issueNumberLabel: "7"}},
+		{ID: "b", Labels: map[string]string{issueNumberLabel: "8", issueRepoLabel: "org/other"}},
+		{ID: "c", Labels: map[string]string{"team": "backend"}},
+		{ID: "d"},
+	}
+
+	started := startedIssues(agents, "")
+	if !started[7] || started[8] || len(started) != 1 {
+		t.Errorf("startedIssues(current repo) = %v, want only 7", started)
+	}
+	started = startedIssues(agents, "org/other")
+	if !started[8] || len(started) != 1 {
+		t.Errorf("startedIssues(org/other) = %v, want only 8", started)
+	}
+}
+
+func TestIssueRunArgs(t *testing.T) {
+	oldPrompt, oldRepo, oldIterations := issuesPrompt, issuesRepo, issuesIterations
+	defer func() { issuesPrompt, issuesRepo, issuesIterations = oldPrompt, oldRepo, oldIterations }()
+
+	issue := github.Issue{Number: 42, Title: "Fix login", Body: "It fails."}
+	issuesIterations = 1
+
+	issuesPrompt, issuesRepo = "", ""
+	args := strings.Join(issueRunArgs(issue, "/usr/local/bin/swarm"), "\x00")
+	for _, want := range []string{
+		"run\x00-d",
+		"--name\x00issue-42",
+		"--label\x00github.issue=42",
+		"--on-complete\x00/usr/local/bin/swarm issues report",
+		"--prompt-string\x00" + issue.TaskPrompt(),
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("issueRunArgs() = %q, missing %q", args, want)
+		}
+	}
+	if strings.Contains(args, issueRepoLabel) {
+		t.Errorf("issueRunArgs() = %q, want no repo label without --repo", args)
+	}
+
+	issuesPrompt, issuesRepo = "coder", "org/service"
+	args = strings.Join(issueRunArgs(issue, "/usr/local/bin/swarm"), "\x00")
+	for _, want := range []string{
+		"--label\x00github.repo=org/service",
+		"--prompt\x00coder\x00--suffix\x00\n" + issue.TaskPrompt(),
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("issueRunArgs() = %q, missing %q", args, want)
+		}
+	}
+}
+
+func TestIssueReport(t *testing.T) {
+	agent := &state.AgentState{ID: "abc123", Name: "issue-42", Status: "terminated", ExitReason: "completed",
+		CurrentIter: 1, Iterations: 1, TotalCost: 0.42}
+	summary := &logsummary.Summary{DurationSeconds: 90, FilesModified: 2, Result: "Fixed the login redirect.\n"}
+
+	got := issueReport(agent, summary)
+	for _, want := range []string{
+		"**swarm agent `issue-42` (abc123) finished: completed**",
+		"Iterations: 1/1",
+		"Cost: $0.42",
+		"Files changed: 2",
+		"\n\nFixed the login redirect.\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("issueReport() = %q, missing %q", got, want)
+		}
+	}
+
+	got = issueReport(agent, &logsummary.Summary{})
+	if !strings.Contains(got, "did not report a result") {
+		t.Errorf("issueReport() without a result = %q", got)
+	}
+
+	agent.ExitReport = &exitreport.Report{Status: exitreport.StatusSuccess, Summary: "Fixed the redirect loop.", FollowUp: "Add a regression test."}
+	got = issueReport(agent, summary)
+	if !strings.Contains(got, "\n\nFixed the redirect loop.\n") || !strings.Contains(got, "**Follow-up:** Add a regression test.") {
+		t.Errorf("issueReport() with an exit report = %q", got)
+	}
+	agent.Manifest = &state.Manifest{SwarmVersion: "v1.2.0", AgentCLI: "claude", AgentVersion: "2.0.14"}
+	if got = issueReport(agent, summary); !strings.Contains(got, "<sub>swarm v1.2.0 · claude 2.0.14</sub>") {
+		t.Errorf("issueReport() with a manifest = %q", got)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/mj1618/swarm-cli/internal/label"
-	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +21,8 @@ var killCmd = &cobra.Command{
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 By default, the agent is terminated immediately. Use --graceful to allow
 the current iteration to complete before terminating.
@@ -31,7 +32,12 @@ sub-agents are also killed by default. Use --no-cascade to kill only the
 specified agent without affecting its sub-agents.
 
 Use --label to kill all running agents matching the specified labels.
-When using --label, the task-id-or-name argument is not required.`,
+When using --label, the task-id-or-name argument is not required.
+
+To stop forgotten agents automatically, set max_agent_age in swarm.toml
+(e.g. max_agent_age = "24h"). Any swarm command then stops agents running
+longer than that after their current iteration, killing them if they're
+still running 30 minutes later. Their exit reason is max_age.`,
 	Example: `  # Terminate immediately (by ID)
   swarm kill abc123
 
@@ -104,7 +110,7 @@ When using --label, the task-id-or-name argument is not required.`,
 						fmt.Printf("Warning: failed to update agent %s: %v\n", agent.ID, err)
 						continue
 					}
-					if err := process.ForceKill(agent.PID); err != nil {
+					if err := agent.ForceKill(); err != nil {
 						fmt.Printf("Warning: could not kill agent %s (PID %d): %v\n", agent.ID, agent.PID, err)
 					}
 					fmt.Printf("Killed agent %s (PID: %d)\n", agent.ID, agent.PID)
@@ -170,7 +176,7 @@ When using --label, the task-id-or-name argument is not required.`,
 				}
 
 				// Force kill the process and its entire process group
-				if err := process.ForceKill(a.PID); err != nil {
+				if err := a.ForceKill(); err != nil {
 					fmt.Printf("Warning: could not kill process %d: %v\n", a.PID, err)
 				}
 
@@ -198,4 +204,5 @@ func init() {
 
 	// Add dynamic completion for agent identifier
 	killCmd.ValidArgsFunction = completeRunningAgentIdentifier
+	killCmd.RegisterFlagCompletionFunc("label", completeLabel)
 }
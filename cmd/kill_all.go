@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/mattn/go-isatty"
-	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/scope"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
@@ -128,7 +127,7 @@ Use --global to terminate all agents across all projects.`,
 				}
 
 				// Force kill the process immediately (SIGKILL on Unix)
-				if err := process.ForceKill(agent.PID); err != nil {
+				if err := agent.ForceKill(); err != nil {
 					fmt.Printf("Warning: could not kill process %d: %v\n", agent.PID, err)
 				}
 			}
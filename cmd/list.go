@@ -25,6 +25,7 @@ var listLast int
 var listLatest bool
 var listLabels []string
 var listShowLabels bool
+var listColumnsFlag string
 
 var listCmd = &cobra.Command{
 	Use:     "list",
@@ -48,6 +49,12 @@ Output options:
   --last, -n      Show only the N most recently started agents
   --latest, -l    Show only the most recently started agent (same as --last 1)
   --show-labels   Show labels column in table output
+  --columns       Columns to show, with optional widths (e.g. id,name:40,cost,task)
+
+Available columns: id, name, parent, labels, prompt, model, status, iteration,
+tokens, cost, budget, task, dir, started. budget is the percentage of the
+agent's cost or token budget used. Set list_columns in swarm.toml (or run
+'swarm config set-columns list ...') to change the default columns.
 
 Multiple filters are combined with AND logic (all conditions must match).`,
 	Example: `  # List running agents in current project
@@ -119,6 +126,9 @@ Multiple filters are combined with AND logic (all conditions must match).`,
   # Show labels column
   swarm list --show-labels
 
+  # Choose columns, with a wider name column
+  swarm list --columns id,name:40,status,cost,dir
+
   # Combine label filter with other filters
   swarm list --label team=frontend --status running --last 5`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -156,6 +166,11 @@ Multiple filters are combined with AND logic (all conditions must match).`,
 			return fmt.Errorf("invalid label filter: %w", err)
 		}
 
+		columns, err := chooseColumns(listColumnsFlag, listConfigColumns(), listColumnNames, defaultListColumns(listShowLabels, GetScope() == scope.ScopeGlobal))
+		if err != nil {
+			return err
+		}
+
 		// Create state manager with scope
 		mgr, err := state.NewManagerWithScope(GetScope(), "")
 		if err != nil {
@@ -244,114 +259,7 @@ Multiple filters are combined with AND logic (all conditions must match).`,
 			return nil
 		}
 
-		// Column widths
-		const (
-			colID        = 10
-			colName      = 15
-			colParent    = 10
-			colPrompt    = 20
-			colModel     = 18
-			colStatus    = 12
-			colIteration = 10
-			colDir       = 30
-			colLabels    = 30
-		)
-
-		// Header - include DIRECTORY column in global mode, LABELS column if --show-labels
-		header := color.New(color.Bold)
-		if GetScope() == scope.ScopeGlobal {
-			if listShowLabels {
-				header.Printf("%-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s\n",
-					colID, "ID", colName, "NAME", colParent, "PARENT", colLabels, "LABELS", colPrompt, "PROMPT", colModel, "MODEL", colStatus, "STATUS", colIteration, "ITERATION", colDir, "DIRECTORY", "STARTED")
-			} else {
-				header.Printf("%-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s\n",
-					colID, "ID", colName, "NAME", colParent, "PARENT", colPrompt, "PROMPT", colModel, "MODEL", colStatus, "STATUS", colIteration, "ITERATION", colDir, "DIRECTORY", "STARTED")
-			}
-		} else {
-			if listShowLabels {
-				header.Printf("%-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s\n",
-					colID, "ID", colName, "NAME", colParent, "PARENT", colLabels, "LABELS", colPrompt, "PROMPT", colModel, "MODEL", colStatus, "STATUS", colIteration, "ITERATION", "STARTED")
-			} else {
-				header.Printf("%-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s\n",
-					colID, "ID", colName, "NAME", colParent, "PARENT", colPrompt, "PROMPT", colModel, "MODEL", colStatus, "STATUS", colIteration, "ITERATION", "STARTED")
-			}
-		}
-
-		for _, a := range agents {
-			statusColor := color.New(color.FgWhite)
-			statusStr := a.Status
-		switch a.Status {
-		case "running":
-			if a.Paused {
-				if a.PausedAt != nil {
-					statusStr = "paused"
-					statusColor = color.New(color.FgYellow)
-				} else {
-					statusStr = "pausing"
-					statusColor = color.New(color.FgYellow)
-				}
-			} else {
-				statusColor = color.New(color.FgGreen)
-			}
-		case "terminated":
-				statusColor = color.New(color.FgRed)
-			}
-
-			duration := time.Since(a.StartedAt).Round(time.Second)
-			var iterStr string
-			if a.Iterations == 0 {
-				iterStr = fmt.Sprintf("%d/∞", a.CurrentIter)
-			} else {
-				iterStr = fmt.Sprintf("%d/%d", a.CurrentIter, a.Iterations)
-			}
-
-			// Truncate prompt if too long
-			prompt := a.Prompt
-			if len(prompt) > colPrompt {
-				prompt = prompt[:colPrompt-3] + "..."
-			}
-
-			// Display name or "-" if not set
-			name := a.Name
-			if name == "" {
-				name = "-"
-			}
-			if len(name) > colName {
-				name = name[:colName-3] + "..."
-			}
-
-			// Format labels for display
-			labelsStr := label.Format(a.Labels)
-			if len(labelsStr) > colLabels {
-				labelsStr = labelsStr[:colLabels-3] + "..."
-			}
-
-			// Format parent for display
-			parent := a.ParentID
-			if parent == "" {
-				parent = "-"
-			}
-			if len(parent) > colParent {
-				parent = parent[:colParent-3] + "..."
-			}
-
-			// Print fixed-width columns, with status colored separately
-			if listShowLabels {
-				fmt.Printf("%-*s  %-*s  %-*s  %-*s  %-*s  %-*s  ", colID, a.ID, colName, name, colParent, parent, colLabels, labelsStr, colPrompt, prompt, colModel, a.Model)
-			} else {
-				fmt.Printf("%-*s  %-*s  %-*s  %-*s  %-*s  ", colID, a.ID, colName, name, colParent, parent, colPrompt, prompt, colModel, a.Model)
-			}
-			statusColor.Printf("%-*s", colStatus, statusStr)
-			if GetScope() == scope.ScopeGlobal {
-				dir := a.WorkingDir
-				if len(dir) > colDir {
-					dir = "..." + dir[len(dir)-colDir+3:]
-				}
-				fmt.Printf("  %-*s  %-*s  %s ago\n", colIteration, iterStr, colDir, dir, duration)
-			} else {
-				fmt.Printf("  %-*s  %s ago\n", colIteration, iterStr, duration)
-			}
-		}
+		printListTable(agents, columns)
 
 		return nil
 	},
@@ -432,5 +340,149 @@ func init() {
 
 	// Label flags
 	listCmd.Flags().StringArrayVarP(&listLabels, "label", "L", nil, "Filter by label (key=value for exact match, key for existence check)")
+	listCmd.RegisterFlagCompletionFunc("label", completeLabel)
 	listCmd.Flags().BoolVar(&listShowLabels, "show-labels", false, "Show labels column in table output")
+	listCmd.Flags().StringVar(&listColumnsFlag, "columns", "", "Comma-separated columns to show, with optional widths (e.g. id,name:30,cost,task); default: list_columns from config")
+}
+
+// listColumn is a column of the `swarm list` table.
+type listColumn struct {
+	header   string
+	width    int
+	truncate bool // shorten values longer than the width
+	keepEnd  bool // truncate from the start, keeping the end (for paths)
+	value    func(a *state.AgentState) string
+}
+
+// listColumnNames are the columns `swarm list --columns` accepts.
+var listColumnNames = []string{"id", "name", "parent", "labels", "prompt", "model", "status", "iteration", "tokens", "cost", "budget", "task", "dir", "started"}
+
+var listColumns = map[string]listColumn{
+	"id":     {header: "ID", width: 10, value: func(a *state.AgentState) string { return a.ID }},
+	"name":   {header: "NAME", width: 15, truncate: true, value: func(a *state.AgentState) string { return orDash(a.Name) }},
+	"parent": {header: "PARENT", width: 10, truncate: true, value: func(a *state.AgentState) string { return orDash(a.ParentID) }},
+	"labels": {header: "LABELS", width: 30, truncate: true, value: func(a *state.AgentState) string { return label.Format(a.Labels) }},
+	"prompt": {header: "PROMPT", width: 20, truncate: true, value: func(a *state.AgentState) string { return a.Prompt }},
+	"model":  {header: "MODEL", width: 18, value: func(a *state.AgentState) string { return a.Model }},
+	"status": {header: "STATUS", width: 12, value: func(a *state.AgentState) string { s, _ := listStatusDisplay(a); return s }},
+	"iteration": {header: "ITERATION", width: 10, value: func(a *state.AgentState) string {
+		if a.Iterations == 0 {
+			return fmt.Sprintf("%d/∞", a.CurrentIter)
+		}
+		return fmt.Sprintf("%d/%d", a.CurrentIter, a.Iterations)
+	}},
+	"tokens": {header: "TOKENS", width: 8, value: func(a *state.AgentState) string { return formatTokenCount(a.InputTokens + a.OutputTokens) }},
+	"cost":   {header: "COST", width: 8, value: func(a *state.AgentState) string { return fmt.Sprintf("$%.2f", a.TotalCost) }},
+	"budget": {header: "BUDGET", width: 7, value: formatBudgetUsed},
+	"task":   {header: "TASK", width: 30, truncate: true, value: func(a *state.AgentState) string { return orDash(a.CurrentTask) }},
+	"dir":    {header: "DIRECTORY", width: 30, truncate: true, keepEnd: true, value: func(a *state.AgentState) string { return a.WorkingDir }},
+	"started": {header: "STARTED", width: 12, value: func(a *state.AgentState) string {
+		return fmt.Sprintf("%s ago", time.Since(a.StartedAt).Round(time.Second))
+	}},
+}
+
+// defaultListColumns returns the columns shown without --columns or
+// list_columns: labels with --show-labels, and the directory in global scope.
+func defaultListColumns(showLabels, global bool) []columnSpec {
+	names := []string{"id", "name", "parent"}
+	if showLabels {
+		names = append(names, "labels")
+	}
+	names = append(names, "prompt", "model", "status", "iteration")
+	if global {
+		names = append(names, "dir")
+	}
+	names = append(names, "started")
+
+	specs := make([]columnSpec, len(names))
+	for i, name := range names {
+		specs[i] = columnSpec{Name: name}
+	}
+	return specs
+}
+
+// listConfigColumns returns the list_columns preference from config.
+func listConfigColumns() []string {
+	if appConfig == nil {
+		return nil
+	}
+	return appConfig.ListColumns
+}
+
+// printListTable prints agents as a table of the given columns. The last
+// column isn't padded.
+func printListTable(agents []*state.AgentState, specs []columnSpec) {
+	header := color.New(color.Bold)
+	var headers []string
+	for i, spec := range specs {
+		col := listColumns[spec.Name]
+		if i == len(specs)-1 {
+			headers = append(headers, col.header)
+		} else {
+			headers = append(headers, fmt.Sprintf("%-*s", specWidth(spec, col.width), col.header))
+		}
+	}
+	header.Println(strings.Join(headers, "  "))
+
+	for _, a := range agents {
+		for i, spec := range specs {
+			col := listColumns[spec.Name]
+			width := specWidth(spec, col.width)
+			value := col.value(a)
+			if col.truncate && len(value) > width {
+				if col.keepEnd {
+					value = "..." + value[len(value)-width+3:]
+				} else {
+					value = value[:width-3] + "..."
+				}
+			}
+			if i < len(specs)-1 {
+				value = fmt.Sprintf("%-*s", width, value)
+			}
+			if spec.Name == "status" {
+				_, statusColor := listStatusDisplay(a)
+				statusColor.Print(value)
+			} else {
+				fmt.Print(value)
+			}
+			if i < len(specs)-1 {
+				fmt.Print("  ")
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// specWidth returns a column's width, honoring the spec's override.
+func specWidth(spec columnSpec, defaultWidth int) int {
+	if spec.Width > 0 {
+		return spec.Width
+	}
+	return defaultWidth
+}
+
+// listStatusDisplay returns an agent's status as shown by `swarm list` and
+// its color.
+func listStatusDisplay(a *state.AgentState) (string, *color.Color) {
+	switch a.Status {
+	case "running":
+		if a.Paused {
+			if a.PausedAt != nil {
+				return "paused", color.New(color.FgYellow)
+			}
+			return "pausing", color.New(color.FgYellow)
+		}
+		return a.Status, color.New(color.FgGreen)
+	case "terminated":
+		return a.Status, color.New(color.FgRed)
+	}
+	return a.Status, color.New(color.FgWhite)
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
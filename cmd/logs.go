@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
 	"strconv"
@@ -19,6 +22,8 @@ var (
 	logsFollow        bool
 	logsLines         int
 	logsPretty        bool
+	logsJSON          bool
+	logsNoPager       bool
 	logsSince         string
 	logsUntil         string
 	logsGrep          []string // grep patterns (regex)
@@ -27,6 +32,10 @@ var (
 	logsContext       int      // context lines (-C)
 	logsContextBefore int      // lines before match (-B)
 	logsContextAfter  int      // lines after match (-A)
+	logsDedup         bool     // collapse repeated events in pretty output
+	logsIteration     int      // show only this iteration
+	logsBetween       []int    // show only iterations in this range (inclusive)
+	logsSummary       bool     // show only each iteration's result
 )
 
 var logsCmd = &cobra.Command{
@@ -37,9 +46,12 @@ var logsCmd = &cobra.Command{
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 Use -f to follow the output in real-time, or --tail to specify the number
-of lines to show.
+of lines to show. When a followed agent is restarted (e.g. with swarm restart),
+following continues with the new agent's log after a divider.
 
 Use --since and --until to filter logs by timestamp. Supported formats:
 - Relative duration: 30s, 5m, 2h, 1d
@@ -47,9 +59,45 @@ Use --since and --until to filter logs by timestamp. Supported formats:
 - Date-time: 2024-01-28 10:00:00 or 2024-01-28 10:00
 - Date only: 2024-01-28 (interpreted as start of day)
 
+Lines are placed in time by the timestamp_ms of agent events, or a leading
+"2024-01-28 10:00:00" in plain-text lines. Absolute times without an offset
+are read in log_timezone from swarm.toml (e.g. log_timezone = "UTC"; default
+local time), which --json timestamps also use. log_time_format sets the Go
+time layout of timestamps shown by swarm search (default RFC3339).
+
 Use --grep to filter log lines by pattern (regex). The pattern is case-insensitive
 by default. Use --case-sensitive for case-sensitive matching. Multiple --grep
-flags can be specified to match any of the patterns (OR logic).`,
+flags can be specified to match any of the patterns (OR logic). With --pretty,
+patterns match the pretty-printed output of each logical event (e.g. a whole
+merged assistant message) rather than raw JSON lines, and --tail and the context
+flags (-C/-B/-A) count events instead of lines.
+
+When stdout is a terminal, the output is piped through $SWARM_PAGER or $PAGER
+(default less, run with LESS=FRX unless $LESS is set, so output that fits on one
+screen is printed as is). Use --no-pager, or set the pager to cat, to disable
+it. Followed logs are never paged.
+
+Set logs_tail and logs_pretty in swarm.toml to change the defaults of --tail
+and --pretty (e.g. logs_tail = 200, logs_pretty = true); --pretty=false then
+shows the raw log.
+
+Use --json to print one JSON object per event instead, for jq and other
+tools: {"type", "subtype", "tool", "summary", "input_tokens", "output_tokens",
+"cost_usd", "timestamp"}, with empty fields omitted. Events are normalized
+across agent CLIs: every tool call has type tool_use and the tool's name, and
+summaries are those of --pretty. Lines that aren't JSON, such as swarm's own
+messages, have type text. --grep matches the raw log lines, and notices such
+as "--- Following log ---" go to stderr.
+
+Use --dedup (or dedup_logs in swarm.toml) with --pretty to collapse identical
+consecutive events, such as those of an agent stuck in a retry loop, into
+"previous message repeated N times".
+
+Use --iteration N to show a single iteration of a multi-iteration agent, or
+--between-iterations N,M to show iterations N through M. Add --summary to show
+only the result of each iteration (in the range, or of all iterations), e.g. to
+skim what a long-running agent accomplished. The selected iterations are shown
+in full, ignoring --tail; --since, --until and --grep apply within them.`,
 	Example: `  # Show last 50 lines of agent abc123
   swarm logs abc123
 
@@ -60,6 +108,10 @@ flags can be specified to match any of the patterns (OR logic).`,
   swarm logs @last -f
   swarm logs _ -f
 
+  # Follow a pipeline's agent, or the agent labeled team=web
+  swarm logs pipeline:nightly -f
+  swarm logs label:team=web -f
+
   # Show last 100 lines
   swarm logs abc123 --tail 100
 
@@ -91,6 +143,22 @@ flags can be specified to match any of the patterns (OR logic).`,
   # Multiple patterns (OR logic)
   swarm logs abc123 --grep error --grep warning
 
+  # Collapse repeated events
+  swarm logs abc123 --pretty --dedup
+
+  # Show iteration 12, or iterations 10 through 20
+  swarm logs abc123 --iteration 12
+  swarm logs abc123 --between-iterations 10,20
+
+  # Skim the result of every iteration
+  swarm logs abc123 --summary --pretty
+
+  # Print a long log without the pager
+  swarm logs abc123 --tail 1000 --no-pager
+
+  # Count the agent's tool calls by tool
+  swarm logs abc123 --tail 10000 --json | jq -r 'select(.tool) | .tool' | sort | uniq -c
+
   # Combine with other flags
   swarm logs abc123 --grep error --since 30m --pretty`,
 	Args: cobra.ExactArgs(1),
@@ -117,6 +185,21 @@ flags can be specified to match any of the patterns (OR logic).`,
 			return fmt.Errorf("log file not found: %s", agent.LogFile)
 		}
 
+		if !cmd.Flags().Changed("dedup") {
+			logsDedup = configDedupLogs()
+		}
+		if !cmd.Flags().Changed("tail") && !cmd.Flags().Changed("lines") && appConfig != nil && appConfig.LogsTail > 0 {
+			logsLines = appConfig.LogsTail
+		}
+		if logsJSON {
+			if logsPretty && cmd.Flags().Changed("pretty") {
+				return fmt.Errorf("--json and --pretty can't be used together")
+			}
+			logsPretty = false
+		} else if !cmd.Flags().Changed("pretty") && appConfig != nil {
+			logsPretty = appConfig.LogsPretty
+		}
+
 		// Parse time flags
 		var sinceTime, untilTime time.Time
 		if logsSince != "" {
@@ -161,19 +244,37 @@ flags can be specified to match any of the patterns (OR logic).`,
 			contextAfter = logsContextAfter
 		}
 
+		// Select iterations
+		from, to, byIteration, err := logsIterationRange()
+		if err != nil {
+			return err
+		}
+		if byIteration && logsFollow {
+			return fmt.Errorf("--iteration, --between-iterations and --summary can't be used with --follow")
+		}
+
+		// Page output longer than a screen, except when following
+		if !logsFollow && !logsNoPager {
+			defer startPager()()
+		}
+
+		if byIteration {
+			return showIterations(agent.LogFile, from, to, logsSummary, sinceTime, untilTime, grepPatterns, logsGrepInvert, contextBefore, contextAfter)
+		}
+
 		if logsFollow {
 			// Warn if --until is used with --follow
 			if logsUntil != "" {
-				fmt.Println("Warning: --until is ignored when using --follow")
+				logsNotice("Warning: --until is ignored when using --follow")
 				untilTime = time.Time{}
 			}
 			// Warn if context is used with --follow
 			if contextBefore > 0 || contextAfter > 0 {
-				fmt.Println("Warning: context flags (-C/-B/-A) are ignored when using --follow")
+				logsNotice("Warning: context flags (-C/-B/-A) are ignored when using --follow")
 				contextBefore = 0
 				contextAfter = 0
 			}
-			return followFile(agent.LogFile, sinceTime, untilTime, grepPatterns, logsGrepInvert)
+			return followAgent(mgr, agent, sinceTime, untilTime, grepPatterns, logsGrepInvert)
 		}
 
 		return showLogLines(agent.LogFile, logsLines, nil, sinceTime, untilTime, grepPatterns, logsGrepInvert, contextBefore, contextAfter)
@@ -182,10 +283,10 @@ flags can be specified to match any of the patterns (OR logic).`,
 
 func init() {
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow the output in real-time")
-	logsCmd.Flags().IntVar(&logsLines, "tail", 50, "Number of lines to show from the end of the logs")
+	logsCmd.Flags().IntVar(&logsLines, "tail", 50, "Number of lines to show from the end of the logs (default: logs_tail from config, or 50)")
 	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "Number of lines to show (alias for --tail)")
 	logsCmd.Flags().MarkHidden("lines") // Keep -n working but prefer --tail in docs
-	logsCmd.Flags().BoolVarP(&logsPretty, "pretty", "P", false, "Pretty-print log output with colors and formatting")
+	logsCmd.Flags().BoolVarP(&logsPretty, "pretty", "P", false, "Pretty-print log output with colors and formatting (default: logs_pretty from config)")
 	logsCmd.Flags().StringVar(&logsSince, "since", "", "Show logs since timestamp (e.g., 30m, 2h, 2024-01-28 10:00)")
 	logsCmd.Flags().StringVar(&logsUntil, "until", "", "Show logs until timestamp (e.g., 1h, 2024-01-28 12:00)")
 	logsCmd.Flags().StringArrayVar(&logsGrep, "grep", nil, "Filter lines matching pattern (regex, case-insensitive by default)")
@@ -194,14 +295,47 @@ func init() {
 	logsCmd.Flags().IntVarP(&logsContext, "context", "C", 0, "Show N lines of context around matches")
 	logsCmd.Flags().IntVarP(&logsContextBefore, "before", "B", 0, "Show N lines before each match")
 	logsCmd.Flags().IntVarP(&logsContextAfter, "after", "A", 0, "Show N lines after each match")
+	logsCmd.Flags().BoolVar(&logsDedup, "dedup", false, "Collapse identical consecutive events in pretty output (default: dedup_logs from config)")
+	logsCmd.Flags().IntVar(&logsIteration, "iteration", 0, "Show only iteration N")
+	logsCmd.Flags().IntSliceVar(&logsBetween, "between-iterations", nil, "Show only iterations N through M (e.g. 10,20)")
+	logsCmd.Flags().BoolVar(&logsSummary, "summary", false, "Show only the result of each iteration")
+	logsCmd.Flags().BoolVar(&logsNoPager, "no-pager", false, "Don't pipe the output through a pager")
+	logsCmd.Flags().BoolVar(&logsJSON, "json", false, "Print one JSON object per event (type, subtype, tool, summary, tokens, cost, timestamp)")
 	rootCmd.AddCommand(logsCmd)
 
 	// Add dynamic completion for agent identifier
 	logsCmd.ValidArgsFunction = completeAgentIdentifier
 }
 
+// logsIterationRange returns the iterations selected by --iteration,
+// --between-iterations or --summary, and whether any of them was given.
+func logsIterationRange() (from, to int, ok bool, err error) {
+	switch {
+	case logsIteration != 0 && len(logsBetween) > 0:
+		return 0, 0, false, fmt.Errorf("use either --iteration or --between-iterations, not both")
+	case logsIteration != 0:
+		if logsIteration < 1 {
+			return 0, 0, false, fmt.Errorf("--iteration must be at least 1")
+		}
+		return logsIteration, logsIteration, true, nil
+	case len(logsBetween) > 0:
+		if len(logsBetween) != 2 {
+			return 0, 0, false, fmt.Errorf("--between-iterations takes two iterations, e.g. 10,20")
+		}
+		from, to = logsBetween[0], logsBetween[1]
+		if from < 1 || to < from {
+			return 0, 0, false, fmt.Errorf("invalid --between-iterations %d,%d: need 1 <= N <= M", from, to)
+		}
+		return from, to, true, nil
+	case logsSummary:
+		return 1, math.MaxInt, true, nil
+	}
+	return 0, 0, false, nil
+}
+
 // ParseTimeFlag parses a time flag value into a time.Time.
-// It supports relative durations (e.g., "30m", "2h", "1d") and absolute timestamps.
+// It supports relative durations (e.g., "30m", "2h", "1d") and absolute timestamps,
+// which are read in the configured log timezone unless they carry an offset.
 func ParseTimeFlag(value string) (time.Time, error) {
 	if value == "" {
 		return time.Time{}, nil
@@ -224,7 +358,7 @@ func ParseTimeFlag(value string) (time.Time, error) {
 		"2006-01-02",
 	}
 	for _, format := range formats {
-		if t, err := time.ParseInLocation(format, value, time.Local); err == nil {
+		if t, err := time.ParseInLocation(format, value, logparser.TimestampLocation()); err == nil {
 			return t, nil
 		}
 	}
@@ -248,14 +382,19 @@ func ParseDurationWithDays(s string) (time.Duration, error) {
 
 // ExtractTimestamp extracts timestamp from a log line.
 // Returns zero time if no timestamp found.
-// Agent logs typically start with: "2024-01-28 10:15:32 | ..."
+// Agent JSONL events carry epoch milliseconds in timestamp_ms; plain-text
+// logs may instead start with "2024-01-28 10:15:32 | ...", read in the
+// configured log timezone.
 func ExtractTimestamp(line string) time.Time {
+	if ts := logparser.EventTime(line); !ts.IsZero() {
+		return ts
+	}
 	if len(line) < 19 {
 		return time.Time{}
 	}
 
 	// Try parsing first 19 chars as timestamp
-	t, err := time.ParseInLocation("2006-01-02 15:04:05", line[:19], time.Local)
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", line[:19], logparser.TimestampLocation())
 	if err == nil {
 		return t
 	}
@@ -301,6 +440,8 @@ func MatchesGrep(line string, patterns []*regexp.Regexp, invert bool) bool {
 // showLogLines shows the last n lines of a file.
 // If parser is provided, lines are processed through it for pretty-printing.
 // If parser is nil and logsPretty is true, a new parser is created and flushed.
+// With logsPretty and grep patterns, the last n matching events are shown instead
+// (see tailLogEvents) and parser is unused.
 // If since/until are non-zero, only lines within the time range are shown.
 // If grepPatterns is non-empty, only lines matching the patterns are shown.
 // If invert is true, shows lines NOT matching the patterns.
@@ -320,108 +461,64 @@ func showLogLines(filepath string, n int, parser *logparser.Parser, since, until
 
 	fileSize := stat.Size()
 	if fileSize == 0 {
-		fmt.Println("(log file is empty)")
+		logsNotice("(log file is empty)")
 		return nil
 	}
 
 	hasTimeFilter := !since.IsZero() || !until.IsZero()
 	hasGrepFilter := len(grepPatterns) > 0
-	hasContext := contextBefore > 0 || contextAfter > 0
-
-	// Read the file and collect lines
-	scanner := bufio.NewScanner(file)
 
-	// Use a larger buffer for potentially long lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	// For grep with context, we need to track all lines and their match status
-	type lineWithMatch struct {
-		text    string
-		matches bool
-	}
-	var allLines []lineWithMatch
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Apply time filter if specified
-		if hasTimeFilter && !IsLineInTimeRange(line, since, until) {
-			continue
-		}
-
-		if hasGrepFilter {
-			matches := MatchesGrep(line, grepPatterns, invert)
-			allLines = append(allLines, lineWithMatch{text: line, matches: matches})
-		} else {
-			allLines = append(allLines, lineWithMatch{text: line, matches: true})
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading log file: %w", err)
-	}
+	// Pretty output is grepped per event, since that's what users see
+	byEvent := logsPretty && hasGrepFilter
 
-	// Apply grep filter with optional context
 	var filtered []string
-	if hasGrepFilter && hasContext {
-		// Mark lines to include based on proximity to matches
-		include := make([]bool, len(allLines))
-		for i, l := range allLines {
-			if l.matches {
-				// Include this line and context
-				start := i - contextBefore
-				if start < 0 {
-					start = 0
-				}
-				end := i + contextAfter + 1
-				if end > len(allLines) {
-					end = len(allLines)
-				}
-				for j := start; j < end; j++ {
-					include[j] = true
-				}
-			}
-		}
-
-		// Collect included lines, adding separators between non-adjacent groups
-		lastIncluded := -2 // Track last included index for separator logic
-		for i, l := range allLines {
-			if include[i] {
-				// Add separator if there's a gap (non-adjacent)
-				if lastIncluded >= 0 && i > lastIncluded+1 {
-					filtered = append(filtered, "--")
-				}
-				filtered = append(filtered, l.text)
-				lastIncluded = i
-			}
-		}
+	if byEvent {
+		filtered, err = tailLogEvents(file, fileSize, n, since, until, grepPatterns, invert, contextBefore, contextAfter, tailBlockSize)
 	} else {
-		// Simple filter without context
-		for _, l := range allLines {
-			if l.matches {
-				filtered = append(filtered, l.text)
-			}
-		}
+		filtered, err = tailLogLines(file, fileSize, n, since, until, grepPatterns, invert, contextBefore, contextAfter, tailBlockSize)
 	}
-
-	// Keep last n lines
-	if len(filtered) > n {
-		filtered = filtered[len(filtered)-n:]
+	if err != nil {
+		return fmt.Errorf("error reading log file: %w", err)
 	}
 
 	if len(filtered) == 0 {
 		if hasTimeFilter || hasGrepFilter {
-			fmt.Println("(no matching log lines)")
+			logsNotice("(no matching log lines)")
 		}
 		return nil
 	}
 
-	// Print the lines
-	if logsPretty {
+	printLogOutput(filtered, byEvent, parser)
+	return nil
+}
+
+// printLogOutput prints filtered log output: events that are already
+// pretty-printed if byEvent, else lines, pretty-printed through parser (or a
+// parser of its own if nil) with logsPretty.
+func printLogOutput(filtered []string, byEvent bool, parser *logparser.Parser) {
+	if byEvent {
+		// Events are already pretty-printed
+		dedup := newLogDedup()
+		for _, event := range filtered {
+			if event == "--" {
+				printRepeats(dedup)
+				fmt.Println("--")
+			} else {
+				printLogEvent(event, dedup)
+			}
+		}
+		printRepeats(dedup)
+	} else if logsJSON {
+		// Context separators mean nothing in a stream of events
+		for _, line := range filtered {
+			if line != "--" {
+				printJSONEvents(line)
+			}
+		}
+	} else if logsPretty {
 		ownParser := parser == nil
 		if ownParser {
-			parser = logparser.NewParser(os.Stdout)
+			parser = newLogParser()
 		}
 		for _, line := range filtered {
 			// Don't pretty-print the separator
@@ -439,30 +536,119 @@ func showLogLines(filepath string, n int, parser *logparser.Parser, since, until
 			fmt.Println(line)
 		}
 	}
+}
+
+// showIterations shows the lines of iterations from through to (inclusive) of
+// a log file, or with summary only each iteration's result. The time range,
+// grep patterns and context apply within the selected iterations.
+func showIterations(filepath string, from, to int, summary bool, since, until time.Time, grepPatterns []*regexp.Regexp, invert bool, contextBefore, contextAfter int) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		if line := scanner.Text(); IsLineInTimeRange(line, since, until) {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading log file: %w", err)
+	}
 
+	selected := selectIterations(lines, from, to, summary)
+	if len(selected) == 0 {
+		logsNotice("(no matching iterations)")
+		return nil
+	}
+
+	byEvent := logsPretty && len(grepPatterns) > 0
+	var out []logOutputLine
+	if byEvent {
+		out = filterLogEvents(selected, grepPatterns, invert, contextBefore, contextAfter)
+	} else {
+		out = filterLogLines(selected, grepPatterns, invert, contextBefore, contextAfter)
+	}
+	if len(out) == 0 {
+		logsNotice("(no matching log lines)")
+		return nil
+	}
+	printLogOutput(lastLogLines(out, len(out)), byEvent, nil)
 	return nil
 }
 
-// followFile follows a file in real-time.
+// noResultLine stands in for the result of an iteration that logged none in
+// a --summary.
+const noResultLine = "(no result)"
+
+// selectIterations returns the lines of iterations from through to
+// (inclusive), each starting with its iteration marker. With summary, only
+// the marker and result events of each iteration are kept. Lines before the
+// first marker belong to no iteration.
+func selectIterations(lines []string, from, to int, summary bool) []string {
+	var out []string
+	current := 0
+	hasResult := false
+	inRange := func() bool { return current >= from && current <= to }
+	endIteration := func() {
+		if summary && inRange() && !hasResult {
+			out = append(out, noResultLine)
+		}
+	}
+
+	for _, line := range lines {
+		if m := iterationStartRe.FindStringSubmatch(line); m != nil {
+			endIteration()
+			current, _ = strconv.Atoi(m[2])
+			hasResult = false
+			if inRange() {
+				out = append(out, line)
+			}
+			continue
+		}
+		if current == 0 || !inRange() {
+			continue
+		}
+		if summary {
+			event := logparser.ParseEvent(line)
+			if event == nil || event.Type != "result" {
+				continue
+			}
+			hasResult = true
+		}
+		out = append(out, line)
+	}
+	endIteration()
+	return out
+}
+
+// followStateInterval is how often a followed agent's state is checked for a
+// restart while its log has no new output.
+const followStateInterval = time.Second
+
+// followAgent follows an agent's log in real-time. When the agent is
+// restarted (a new agent registered under its name, or a new log file for
+// the same agent), it prints a divider and continues with the new log.
 // If since is non-zero, only shows lines with timestamps after that time.
 // The until parameter is ignored in follow mode (warning already shown to user).
 // If grepPatterns is non-empty, only lines matching the patterns are shown.
 // Context flags are not supported in follow mode (warning already shown to user).
-func followFile(filepath string, since, until time.Time, grepPatterns []*regexp.Regexp, invert bool) error {
-	file, err := os.Open(filepath)
+func followAgent(mgr *state.Manager, agent *state.AgentState, since, until time.Time, grepPatterns []*regexp.Regexp, invert bool) error {
+	file, err := os.Open(agent.LogFile)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
-	defer file.Close()
+	defer func() { file.Close() }()
 
-	// Create parser if pretty mode is enabled - used for both initial lines and follow
-	var parser *logparser.Parser
-	if logsPretty {
-		parser = logparser.NewParser(os.Stdout)
-	}
+	printer := newFollowPrinter(since, grepPatterns, invert)
 
 	// First, show last few lines for context (with time and grep filter applied, no context lines in follow mode)
-	if err := showLogLines(filepath, logsLines, parser, since, until, grepPatterns, invert, 0, 0); err != nil {
+	if err := showLogLines(agent.LogFile, logsLines, printer.parser, since, until, grepPatterns, invert, 0, 0); err != nil {
 		return err
 	}
 
@@ -472,40 +658,439 @@ func followFile(filepath string, since, until time.Time, grepPatterns []*regexp.
 		return fmt.Errorf("failed to seek to end of file: %w", err)
 	}
 
-	fmt.Println("\n--- Following log (Ctrl+C to stop) ---")
+	logsNotice("\n--- Following log (Ctrl+C to stop) ---")
 
 	reader := bufio.NewReader(file)
+	var partial string
+	lastStateCheck := time.Now()
+	waiting := false
 	for {
 		line, err := reader.ReadString('\n')
 		if err != nil {
-			if err == io.EOF {
-				// No new data, wait a bit
-				time.Sleep(100 * time.Millisecond)
-				continue
+			if err != io.EOF {
+				// Flush parser before returning error
+				printer.flush()
+				return fmt.Errorf("error reading log file: %w", err)
 			}
-			// Flush parser before returning error
-			if parser != nil {
-				parser.Flush()
+			// Keep an incomplete last line until the rest of it is written
+			partial += line
+
+			if time.Since(lastStateCheck) >= followStateInterval {
+				lastStateCheck = time.Now()
+				next, stopped := followTarget(mgr, agent)
+				if next != nil {
+					newFile, err := os.Open(next.LogFile)
+					if err == nil {
+						if partial != "" {
+							printer.print(partial + "\n")
+							partial = ""
+						}
+						printer.flush()
+						logsNotice(fmt.Sprintf("\n--- Agent restarted: following %s (PID %d, log %s) ---", next.ID, next.PID, next.LogFile))
+						file.Close()
+						file = newFile
+						reader = bufio.NewReader(file)
+						agent = next
+						waiting = false
+						continue
+					}
+				} else if stopped && !waiting {
+					logsNotice(fmt.Sprintf("\n--- Agent %s stopped; waiting for it to be restarted (Ctrl+C to stop) ---", agent.ID))
+					waiting = true
+				}
 			}
-			return fmt.Errorf("error reading log file: %w", err)
+
+			// No new data, wait a bit
+			time.Sleep(100 * time.Millisecond)
+			continue
 		}
 
-		// Apply time filter for follow mode (only --since matters, --until is ignored)
-		if !since.IsZero() && !IsLineInTimeRange(line, since, time.Time{}) {
+		printer.print(partial + line)
+		partial = ""
+	}
+}
+
+// followTarget checks a followed agent's state for a restart. It returns the
+// agent to follow next if the agent was restarted under a new ID or log file,
+// and whether the followed agent has stopped without being restarted yet.
+func followTarget(mgr *state.Manager, agent *state.AgentState) (next *state.AgentState, stopped bool) {
+	current, err := mgr.Get(agent.ID)
+	if err == nil {
+		if current.LogFile != "" && current.LogFile != agent.LogFile {
+			return current, false
+		}
+		if current.Status == "running" {
+			return nil, false
+		}
+	}
+
+	successor, err := mgr.Successor(agent)
+	if err == nil && successor != nil && successor.LogFile != "" {
+		return successor, false
+	}
+	return nil, true
+}
+
+// followPrinter prints the lines of a followed log that pass the --since and
+// --grep filters. With --pretty and --grep, lines are grouped into events that
+// are printed once complete if they match.
+type followPrinter struct {
+	parser       *logparser.Parser
+	events       *logparser.EventSplitter
+	dedup        *logparser.Dedup // collapses repeated events
+	since        time.Time
+	grepPatterns []*regexp.Regexp
+	invert       bool
+}
+
+func newFollowPrinter(since time.Time, grepPatterns []*regexp.Regexp, invert bool) *followPrinter {
+	fp := &followPrinter{since: since, grepPatterns: grepPatterns, invert: invert}
+	if logsPretty && len(grepPatterns) > 0 {
+		fp.events = logparser.NewEventSplitter()
+		fp.dedup = newLogDedup()
+	} else if logsPretty {
+		fp.parser = newLogParser()
+	}
+	return fp
+}
+
+// print prints a line of the followed log, including its trailing newline.
+func (fp *followPrinter) print(line string) {
+	// Apply time filter for follow mode (only --since matters, --until is ignored)
+	if !fp.since.IsZero() && !IsLineInTimeRange(line, fp.since, time.Time{}) {
+		return
+	}
+
+	switch {
+	case fp.events != nil:
+		fp.printEvents(fp.events.ProcessLine(line))
+	case !MatchesGrep(line, fp.grepPatterns, fp.invert):
+	case fp.parser != nil:
+		// Process through parser (strips the trailing newline itself)
+		fp.parser.ProcessLine(line)
+	case logsJSON:
+		printJSONEvents(line)
+	default:
+		// Print without extra newline since ReadString includes the \n
+		fmt.Print(line)
+	}
+}
+
+// flush prints any output held back waiting for more lines.
+func (fp *followPrinter) flush() {
+	if fp.events != nil {
+		fp.printEvents(fp.events.Flush())
+		printRepeats(fp.dedup)
+	} else if fp.parser != nil {
+		fp.parser.Flush()
+	}
+}
+
+func (fp *followPrinter) printEvents(events []string) {
+	for _, event := range events {
+		if MatchesGrep(stripANSI(event), fp.grepPatterns, fp.invert) {
+			printLogEvent(event, fp.dedup)
+		}
+	}
+}
+
+// printJSONEvents prints the normalized events of a log line as JSON, one
+// object per line.
+func printJSONEvents(line string) {
+	for _, event := range logparser.Normalize(line) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		fmt.Println(string(data))
+	}
+}
+
+// logsNotice prints a message about the log rather than from it, to stderr
+// with --json so the output stays parseable.
+func logsNotice(msg string) {
+	if logsJSON {
+		fmt.Fprintln(os.Stderr, msg)
+		return
+	}
+	fmt.Println(msg)
+}
+
+// configDedupLogs returns the dedup_logs preference from config.
+func configDedupLogs() bool {
+	return appConfig != nil && appConfig.DedupLogs
+}
+
+// newLogParser returns a parser for pretty output to stdout, collapsing
+// repeated events with --dedup.
+func newLogParser() *logparser.Parser {
+	parser := logparser.NewParser(os.Stdout)
+	parser.SetDedup(logsDedup)
+	return parser
+}
+
+// newLogDedup returns the dedup for already pretty-printed events, or nil
+// without --dedup.
+func newLogDedup() *logparser.Dedup {
+	if !logsDedup {
+		return nil
+	}
+	return &logparser.Dedup{}
+}
+
+// printLogEvent prints a pretty-printed event, collapsing it into the
+// previous one if dedup is non-nil and it's a repeat.
+func printLogEvent(event string, dedup *logparser.Dedup) {
+	if dedup != nil {
+		repeats, skip := dedup.Add(event)
+		if skip {
+			return
+		}
+		if repeats > 0 {
+			fmt.Print(logparser.FormatRepeats(repeats))
+		}
+	}
+	fmt.Print(event)
+}
+
+// printRepeats prints how many times the last event was repeated, if it was.
+func printRepeats(dedup *logparser.Dedup) {
+	if dedup == nil {
+		return
+	}
+	if repeats := dedup.Reset(); repeats > 0 {
+		fmt.Print(logparser.FormatRepeats(repeats))
+	}
+}
+
+// tailBlockSize is the first block size read from the end of a log file.
+// Each further block doubles in size, up to tailMaxBlockSize.
+const (
+	tailBlockSize    = 64 * 1024
+	tailMaxBlockSize = 8 * 1024 * 1024
+)
+
+// tailLogLines returns the last n lines of output for a log file, applying
+// the time range, grep patterns, and context like a forward scan would.
+func tailLogLines(file io.ReaderAt, size int64, n int, since, until time.Time, grepPatterns []*regexp.Regexp, invert bool, contextBefore, contextAfter int, blockSize int64) ([]string, error) {
+	// Lines after the first contextAfter of what has been read so far have
+	// every match that could include them, so their output is final.
+	settledFrom := 0
+	if len(grepPatterns) > 0 {
+		settledFrom = contextAfter
+	}
+	return tailLog(file, size, n, since, until, blockSize, settledFrom, func(lines []string) []logOutputLine {
+		return filterLogLines(lines, grepPatterns, invert, contextBefore, contextAfter)
+	})
+}
+
+// tailLogEvents is tailLogLines for pretty output: lines are pretty-printed
+// into logical events and the grep patterns and context apply to events, so
+// it returns the last n output events.
+func tailLogEvents(file io.ReaderAt, size int64, n int, since, until time.Time, grepPatterns []*regexp.Regexp, invert bool, contextBefore, contextAfter int, blockSize int64) ([]string, error) {
+	// The first event read so far may be the end of a run of merged
+	// fragments whose start hasn't been read yet, so it isn't final either.
+	settledFrom := 1
+	if len(grepPatterns) > 0 {
+		settledFrom += contextAfter
+	}
+	return tailLog(file, size, n, since, until, blockSize, settledFrom, func(lines []string) []logOutputLine {
+		return filterLogEvents(lines, grepPatterns, invert, contextBefore, contextAfter)
+	})
+}
+
+// tailLog returns the last n output lines filter produces for a log file's
+// lines within the time range. Output from an input unit (line or event) at
+// index settledFrom or later of what has been read must not depend on the
+// lines before it. The file is read backwards in growing blocks and reading
+// stops once the tail is known, so multi-GB logs don't have to be scanned in
+// full.
+func tailLog(file io.ReaderAt, size int64, n int, since, until time.Time, blockSize int64, settledFrom int, filter func(lines []string) []logOutputLine) ([]string, error) {
+	hasTimeFilter := !since.IsZero() || !until.IsZero()
+
+	rr := &reverseLineReader{r: file, offset: size, blockSize: blockSize}
+	var lines []string
+	for {
+		block, err := rr.prev()
+		if err != nil {
+			return nil, err
+		}
+
+		if hasTimeFilter {
+			kept := block[:0]
+			for _, line := range block {
+				if IsLineInTimeRange(line, since, until) {
+					kept = append(kept, line)
+				}
+			}
+			block = kept
+		}
+		lines = append(block, lines...)
+
+		out := filter(lines)
+		if rr.done() {
+			return lastLogLines(out, n), nil
+		}
+
+		// One settled line beyond n makes sure the first returned line
+		// (possibly a "--" separator) doesn't depend on unread lines.
+		settled := 0
+		for _, l := range out {
+			if l.src >= settledFrom {
+				settled++
+			}
+		}
+		if settled > n {
+			return lastLogLines(out, n), nil
+		}
+	}
+}
+
+// logOutputLine is a line (or event) of filtered log output. src is the index
+// of the input line or event it came from; a "--" separator has the index of
+// the one after it.
+type logOutputLine struct {
+	text string
+	src  int
+}
+
+// filterLogLines applies grep patterns, with optional context, to lines.
+// Non-adjacent groups of context lines are separated by "--".
+func filterLogLines(lines []string, grepPatterns []*regexp.Regexp, invert bool, contextBefore, contextAfter int) []logOutputLine {
+	return filterLogOutput(lines, grepPatterns, invert, contextBefore, contextAfter, func(line string) string { return line })
+}
+
+// filterLogEvents pretty-prints lines and applies grep patterns, with optional
+// context, to the resulting events. Patterns match an event's text without
+// colors.
+func filterLogEvents(lines []string, grepPatterns []*regexp.Regexp, invert bool, contextBefore, contextAfter int) []logOutputLine {
+	return filterLogOutput(logparser.FormatEvents(lines), grepPatterns, invert, contextBefore, contextAfter, stripANSI)
+}
+
+// filterLogOutput applies grep patterns to the text returned by matchText for
+// each of lines, keeping context lines around matches.
+func filterLogOutput(lines []string, grepPatterns []*regexp.Regexp, invert bool, contextBefore, contextAfter int, matchText func(string) string) []logOutputLine {
+	var out []logOutputLine
+	if len(grepPatterns) == 0 {
+		for i, line := range lines {
+			out = append(out, logOutputLine{text: line, src: i})
+		}
+		return out
+	}
+
+	if contextBefore == 0 && contextAfter == 0 {
+		for i, line := range lines {
+			if MatchesGrep(matchText(line), grepPatterns, invert) {
+				out = append(out, logOutputLine{text: line, src: i})
+			}
+		}
+		return out
+	}
+
+	// Mark lines to include based on proximity to matches
+	include := make([]bool, len(lines))
+	for i, line := range lines {
+		if !MatchesGrep(matchText(line), grepPatterns, invert) {
 			continue
 		}
+		start := i - contextBefore
+		if start < 0 {
+			start = 0
+		}
+		end := i + contextAfter + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		for j := start; j < end; j++ {
+			include[j] = true
+		}
+	}
 
-		// Apply grep filter
-		if !MatchesGrep(line, grepPatterns, invert) {
+	// Collect included lines, adding separators between non-adjacent groups
+	lastIncluded := -2
+	for i, line := range lines {
+		if !include[i] {
 			continue
 		}
+		if lastIncluded >= 0 && i > lastIncluded+1 {
+			out = append(out, logOutputLine{text: "--", src: i})
+		}
+		out = append(out, logOutputLine{text: line, src: i})
+		lastIncluded = i
+	}
+	return out
+}
+
+// lastLogLines returns the text of the last n output lines.
+func lastLogLines(out []logOutputLine, n int) []string {
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	lines := make([]string, len(out))
+	for i, l := range out {
+		lines[i] = l.text
+	}
+	return lines
+}
+
+// reverseLineReader reads a file's lines backwards, one block at a time.
+type reverseLineReader struct {
+	r         io.ReaderAt
+	offset    int64  // Start of the data read so far
+	blockSize int64  // Size of the next block to read
+	carry     []byte // Start of a line whose beginning is in an unread block
+	started   bool   // Whether the last block of the file has been read
+}
 
-		if logsPretty && parser != nil {
-			// Process through parser (strips the trailing newline itself)
-			parser.ProcessLine(line)
+// done reports whether the start of the file has been reached.
+func (rr *reverseLineReader) done() bool {
+	return rr.offset == 0 && rr.carry == nil
+}
+
+// prev reads the next block towards the start of the file and returns the
+// complete lines it contains, in file order. Line endings are stripped as
+// bufio.ScanLines would.
+func (rr *reverseLineReader) prev() ([]string, error) {
+	for {
+		size := rr.blockSize
+		if size > rr.offset {
+			size = rr.offset
+		}
+		start := rr.offset - size
+
+		data := make([]byte, size, size+int64(len(rr.carry)))
+		if _, err := rr.r.ReadAt(data, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		data = append(data, rr.carry...)
+		rr.offset = start
+		if rr.blockSize < tailMaxBlockSize {
+			rr.blockSize *= 2
+		}
+
+		// The file's trailing newline doesn't start another line
+		if !rr.started {
+			rr.started = true
+			data = bytes.TrimSuffix(data, []byte("\n"))
+		}
+
+		if start > 0 {
+			idx := bytes.IndexByte(data, '\n')
+			if idx < 0 {
+				// No complete line in this block yet; keep reading
+				rr.carry = data
+				continue
+			}
+			rr.carry = append([]byte(nil), data[:idx]...)
+			data = data[idx+1:]
 		} else {
-			// Print without extra newline since ReadString includes the \n
-			fmt.Print(line)
+			rr.carry = nil
+		}
+
+		parts := strings.Split(string(data), "\n")
+		for i, p := range parts {
+			parts[i] = strings.TrimSuffix(p, "\r")
 		}
+		return parts, nil
 	}
 }
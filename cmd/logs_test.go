@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
 )
 
 func TestMatchesGrep(t *testing.T) {
@@ -316,3 +324,227 @@ func TestExtractTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractTimestampJSONL(t *testing.T) {
+	line := `{"type":"assistant","timestamp_ms":1700000000000,"message":{"role":"assistant"}}`
+	if ts := ExtractTimestamp(line); !ts.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("ExtractTimestamp = %v, want the event's timestamp_ms", ts)
+	}
+
+	at := time.UnixMilli(1700000000000)
+	if IsLineInTimeRange(line, at.Add(time.Minute), time.Time{}) {
+		t.Error("event before --since should be filtered out")
+	}
+	if IsLineInTimeRange(line, time.Time{}, at.Add(-time.Minute)) {
+		t.Error("event after --until should be filtered out")
+	}
+	if !IsLineInTimeRange(line, at.Add(-time.Minute), at.Add(time.Minute)) {
+		t.Error("event within the range should be kept")
+	}
+}
+
+func TestTailLogLinesMatchesForwardScan(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 500; i++ {
+		switch {
+		case i%37 == 0:
+			fmt.Fprintf(&sb, "line %d ERROR something failed\r\n", i)
+		case i%11 == 0:
+			fmt.Fprintf(&sb, "line %d %s\n", i, strings.Repeat("long ", 40))
+		default:
+			fmt.Fprintf(&sb, "line %d ok\n", i)
+		}
+	}
+	content := sb.String()
+
+	// Forward scan, as bufio.Scanner would split the file
+	var all []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		all = append(all, scanner.Text())
+	}
+
+	errorRe := []*regexp.Regexp{regexp.MustCompile("ERROR")}
+	tests := []struct {
+		name          string
+		n             int
+		patterns      []*regexp.Regexp
+		invert        bool
+		before, after int
+	}{
+		{"plain tail", 50, nil, false, 0, 0},
+		{"whole file", 1000, nil, false, 0, 0},
+		{"grep", 5, errorRe, false, 0, 0},
+		{"grep invert", 20, errorRe, true, 0, 0},
+		{"grep context", 12, errorRe, false, 2, 3},
+		{"grep context all", 1000, errorRe, false, 1, 1},
+	}
+
+	for _, tt := range tests {
+		for _, blockSize := range []int64{7, 100, 4096, tailBlockSize} {
+			t.Run(fmt.Sprintf("%s/block%d", tt.name, blockSize), func(t *testing.T) {
+				want := lastLogLines(filterLogLines(all, tt.patterns, tt.invert, tt.before, tt.after), tt.n)
+				got, err := tailLogLines(strings.NewReader(content), int64(len(content)), tt.n, time.Time{}, time.Time{}, tt.patterns, tt.invert, tt.before, tt.after, blockSize)
+				if err != nil {
+					t.Fatalf("tailLogLines failed: %v", err)
+				}
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("tail mismatch:\ngot  %q\nwant %q", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestTailLogLinesNoTrailingNewline(t *testing.T) {
+	content := "first\nsecond\nthird"
+	got, err := tailLogLines(strings.NewReader(content), int64(len(content)), 2, time.Time{}, time.Time{}, nil, false, 0, 0, 4)
+	if err != nil {
+		t.Fatalf("tailLogLines failed: %v", err)
+	}
+	if strings.Join(got, ",") != "second,third" {
+		t.Errorf("got %q, want [second third]", got)
+	}
+}
+
+func TestTailLogEvents(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		switch {
+		case i%13 == 0:
+			// A thinking run split across lines, only matchable as a whole
+			fmt.Fprintf(&sb, "{\"type\": \"thinking\", \"text\": \"run %d tests \"}\n", i)
+			sb.WriteString("{\"type\": \"thinking\", \"text\": \"FAILED badly\"}\n")
+		default:
+			fmt.Fprintf(&sb, "{\"type\": \"result\", \"result\": \"step %d\"}\n", i)
+		}
+	}
+	content := sb.String()
+
+	var all []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		all = append(all, scanner.Text())
+	}
+
+	failedRe := []*regexp.Regexp{regexp.MustCompile("tests FAILED")}
+	if got := filterLogLines(all, failedRe, false, 0, 0); len(got) != 0 {
+		t.Fatalf("raw lines unexpectedly matched: %v", got)
+	}
+	if got := filterLogEvents(all, failedRe, false, 0, 0); len(got) != 16 {
+		t.Fatalf("got %d matching events, want 16", len(got))
+	}
+
+	tests := []struct {
+		name          string
+		n             int
+		invert        bool
+		before, after int
+	}{
+		{"grep", 5, false, 0, 0},
+		{"grep invert", 20, true, 0, 0},
+		{"grep context", 12, false, 2, 3},
+		{"grep context all", 1000, false, 1, 1},
+	}
+
+	for _, tt := range tests {
+		for _, blockSize := range []int64{7, 100, 4096, tailBlockSize} {
+			t.Run(fmt.Sprintf("%s/block%d", tt.name, blockSize), func(t *testing.T) {
+				want := lastLogLines(filterLogEvents(all, failedRe, tt.invert, tt.before, tt.after), tt.n)
+				got, err := tailLogEvents(strings.NewReader(content), int64(len(content)), tt.n, time.Time{}, time.Time{}, failedRe, tt.invert, tt.before, tt.after, blockSize)
+				if err != nil {
+					t.Fatalf("tailLogEvents failed: %v", err)
+				}
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("tail mismatch:\ngot  %q\nwant %q", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestSelectIterations(t *testing.T) {
+	lines := []string{
+		"[swarm] Starting agent",
+		"",
+		"[swarm] === Iteration 1/3 ===",
+		`{"type": "assistant", "message": {"content": [{"type": "text", "text": "one"}]}}`,
+		`{"type": "result", "result": "did one"}`,
+		"[swarm] === Iteration 2/3 ===",
+		`{"type": "assistant", "message": {"content": [{"type": "text", "text": "two"}]}}`,
+		"[swarm] === Iteration 3/3 ===",
+		`{"type": "result", "result": "did three"}`,
+	}
+
+	tests := []struct {
+		name     string
+		from, to int
+		summary  bool
+		want     []string
+	}{
+		{"single", 2, 2, false, lines[5:7]},
+		{"range", 2, 3, false, lines[5:]},
+		{"beyond the log", 4, 10, false, nil},
+		{"summary", 1, 3, true, []string{lines[2], lines[4], lines[5], noResultLine, lines[7], lines[8]}},
+		{"summary range", 3, 3, true, lines[7:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectIterations(lines, tt.from, tt.to, tt.summary)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("selectIterations(%d, %d, %v) =\n%q\nwant\n%q", tt.from, tt.to, tt.summary, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	mgr, err := state.NewManagerWithScope(scope.ScopeGlobal, "")
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+
+	started := time.Now().Add(-time.Hour)
+	followed := &state.AgentState{
+		ID: "follow01", Name: "coder", PID: os.Getpid(), Status: "running",
+		StartedAt: started, WorkingDir: tmpDir, LogFile: filepath.Join(tmpDir, "follow01.log"),
+	}
+	if err := mgr.Register(followed); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if next, stopped := followTarget(mgr, followed); next != nil || stopped {
+		t.Errorf("running agent: followTarget() = %v, %v; want nil, false", next, stopped)
+	}
+
+	followed.Status = "terminated"
+	if err := mgr.Update(followed); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if next, stopped := followTarget(mgr, followed); next != nil || !stopped {
+		t.Errorf("stopped agent: followTarget() = %v, %v; want nil, true", next, stopped)
+	}
+
+	restarted := &state.AgentState{
+		ID: "follow02", Name: "coder", PID: os.Getpid(), Status: "running",
+		StartedAt: started.Add(time.Minute), WorkingDir: tmpDir, LogFile: filepath.Join(tmpDir, "follow02.log"),
+	}
+	if err := mgr.Register(restarted); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if next, _ := followTarget(mgr, followed); next == nil || next.ID != "follow02" {
+		t.Errorf("restarted agent: followTarget() = %v; want follow02", next)
+	}
+
+	// The same agent writing to a new log file is followed there
+	moved := *restarted
+	moved.LogFile = filepath.Join(tmpDir, "follow02-2.log")
+	if err := mgr.Update(&moved); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if next, _ := followTarget(mgr, restarted); next == nil || next.LogFile != moved.LogFile {
+		t.Errorf("new log file: followTarget() = %v; want %s", next, moved.LogFile)
+	}
+}
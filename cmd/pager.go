@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// defaultPager is the pager used when neither $SWARM_PAGER nor $PAGER is set.
+const defaultPager = "less"
+
+// pagerCommand returns the pager command line from $SWARM_PAGER or $PAGER
+// (default less), or nil when paging is disabled by setting it to "" or cat.
+func pagerCommand() []string {
+	pager, ok := os.LookupEnv("SWARM_PAGER")
+	if !ok {
+		pager, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		pager = defaultPager
+	}
+	fields := strings.Fields(pager)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return nil
+	}
+	return fields
+}
+
+// startPager pipes everything written to os.Stdout through a pager, like git
+// does, when stdout is a terminal. less is run with LESS=FRX unless $LESS is
+// set, so it exits straight away when the output fits on one screen and keeps
+// colors. The returned function must be called once the output is written: it
+// waits for the user to quit the pager.
+func startPager() func() {
+	args := pagerCommand()
+	if args == nil || !(isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())) {
+		return func() {}
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return func() {}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	pager := exec.Command(path, args[1:]...)
+	pager.Stdin = r
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	pager.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		pager.Env = append(pager.Env, "LESS=FRX")
+	}
+	if err := pager.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to start pager %s: %v\n", args[0], err)
+		r.Close()
+		w.Close()
+		return func() {}
+	}
+	r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	return func() {
+		os.Stdout = stdout
+		w.Close()
+		_ = pager.Wait()
+	}
+}
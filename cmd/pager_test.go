@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPagerCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		swarmPager *string
+		pager      *string
+		want       string
+	}{
+		{name: "default", want: "less"},
+		{name: "PAGER", pager: strPtr("most -s"), want: "most -s"},
+		{name: "SWARM_PAGER wins", swarmPager: strPtr("less -S"), pager: strPtr("most"), want: "less -S"},
+		{name: "cat disables", pager: strPtr("cat"), want: ""},
+		{name: "empty disables", swarmPager: strPtr(""), pager: strPtr("less"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setOrUnsetEnv(t, "SWARM_PAGER", tt.swarmPager)
+			setOrUnsetEnv(t, "PAGER", tt.pager)
+			if got := strings.Join(pagerCommand(), " "); got != tt.want {
+				t.Errorf("pagerCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+// setOrUnsetEnv sets key to *value for the test, or unsets it when nil.
+func setOrUnsetEnv(t *testing.T, key string, value *string) {
+	t.Helper()
+	t.Setenv(key, "")
+	if value == nil {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, *value)
+	}
+}
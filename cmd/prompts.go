@@ -8,8 +8,9 @@ import (
 )
 
 var promptsCmd = &cobra.Command{
-	Use:   "prompts",
-	Short: "Manage prompt files",
+	Use:     "prompts",
+	Aliases: []string{"prompt"},
+	Short:   "Manage prompt files",
 	Long: `Manage prompt files used by agents.
 
 Prompts are markdown files stored in:
@@ -24,7 +25,10 @@ When called without a subcommand, lists available prompts.`,
   swarm prompts -g
 
   # Show content of a prompt
-  swarm prompts show coder`,
+  swarm prompts show coder
+
+  # Check the prompts used by the compose file
+  swarm prompt lint`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Default to list behavior for backward compatibility
 		return runPromptsList()
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+// defaultLintMaxTokens is the prompt size flagged by 'swarm prompts lint'
+// when neither --max-tokens nor max_prompt_tokens is set.
+const defaultLintMaxTokens = 50000
+
+var (
+	promptsLintFile      string
+	promptsLintMaxTokens int
+	promptsLintStrict    bool
+	promptsLintLenient   bool
+)
+
+var promptsLintCmd = &cobra.Command{
+	Use:   "lint [task...]",
+	Short: "Check the prompts used by the compose file for common problems",
+	Long: `Check the prompts referenced by tasks in the compose file for common problems:
+
+  - unresolved {{...}} placeholders and malformed {{output:...}} references
+  - {{output:task}} and {{artifact:task/path}} references to tasks that don't
+    exist, or aren't upstream dependencies of the task (warning)
+  - prompts that never mention SWARM_TASK_ID (warning)
+  - prompts longer than --max-tokens (default: max_prompt_tokens from config,
+    or 50000)
+  - instructions that conflict with the task's prefix or suffix, such as
+    "Never commit" in the prefix and "Commit your changes" in the prompt
+    (warning)
+
+Exits non-zero if any prompt has errors, or warnings with --strict, so it can
+gate CI.`,
+	Example: `  # Lint the prompts of every task in swarm/swarm.yaml
+  swarm prompts lint
+
+  # Lint specific tasks, failing on warnings too
+  swarm prompt lint coder reviewer --strict`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cf, err := loadComposeFile(promptsLintFile, promptsLintLenient)
+		if err != nil {
+			return fmt.Errorf("failed to load compose file %s: %w", promptsLintFile, err)
+		}
+		tasks, err := cf.GetTasks(args)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			fmt.Printf("No tasks found in %s\n", promptsLintFile)
+			return nil
+		}
+
+		promptsDir, err := GetPromptsDir()
+		if err != nil {
+			return fmt.Errorf("failed to get prompts directory: %w", err)
+		}
+
+		maxTokens := promptsLintMaxTokens
+		if maxTokens == 0 && appConfig != nil {
+			maxTokens = appConfig.MaxPromptTokens
+		}
+		if maxTokens == 0 {
+			maxTokens = defaultLintMaxTokens
+		}
+
+		names := make([]string, 0, len(tasks))
+		for name := range tasks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		green := color.New(color.FgGreen)
+		yellow := color.New(color.FgYellow)
+		red := color.New(color.FgRed)
+		dim := color.New(color.Faint)
+
+		var errorCount, warningCount int
+		for _, name := range names {
+			task := tasks[name]
+			label, issues := lintTaskPrompt(cf, name, task, promptsDir, maxTokens)
+
+			var taskErrors int
+			for _, issue := range issues {
+				if issue.Warning {
+					warningCount++
+				} else {
+					taskErrors++
+				}
+			}
+			errorCount += taskErrors
+
+			switch {
+			case taskErrors > 0:
+				red.Printf("✗ %s", name)
+			case len(issues) > 0:
+				yellow.Printf("⚠ %s", name)
+			default:
+				green.Printf("✓ %s", name)
+			}
+			dim.Printf(" (%s)\n", label)
+			for _, issue := range issues {
+				if issue.Warning {
+					yellow.Print("    warning: ")
+				} else {
+					red.Print("    error: ")
+				}
+				fmt.Println(issue.Message)
+			}
+		}
+
+		if errorCount > 0 || (promptsLintStrict && warningCount > 0) {
+			return fmt.Errorf("prompt lint found %d error(s) and %d warning(s)", errorCount, warningCount)
+		}
+		return nil
+	},
+}
+
+// lintTaskPrompt loads a task's prompt and lints it, including checks of its
+// {{output:...}} and {{artifact:...}} references against the compose file. It returns the prompt's
+// label along with the issues found.
+func lintTaskPrompt(cf *compose.ComposeFile, name string, task compose.Task, promptsDir string, maxTokens int) (string, []prompt.LintIssue) {
+	// Load without prefix/suffix, which Lint checks separately
+	content, label, err := loadTaskPrompt(compose.Task{Prompt: task.Prompt, PromptFile: task.PromptFile, PromptString: task.PromptString}, promptsDir)
+	if err != nil {
+		return label, []prompt.LintIssue{{Message: err.Error()}}
+	}
+
+	issues := prompt.Lint(content, prompt.LintOptions{
+		Prefix:    task.Prefix,
+		Suffix:    task.Suffix,
+		MaxTokens: maxTokens,
+	})
+
+	upstream := upstreamTasks(cf, name)
+	for _, ref := range prompt.OutputReferences(content) {
+		if _, exists := cf.Tasks[ref]; !exists {
+			issues = append(issues, prompt.LintIssue{Message: fmt.Sprintf("{{output:%s}} refers to an unknown task", ref)})
+		} else if !upstream[ref] {
+			issues = append(issues, prompt.LintIssue{Warning: true, Message: fmt.Sprintf("{{output:%s}} refers to a task that is not an upstream dependency, so its output may be missing or stale", ref)})
+		}
+	}
+	for _, ref := range prompt.ArtifactReferences(content) {
+		if _, exists := cf.Tasks[ref]; !exists {
+			issues = append(issues, prompt.LintIssue{Message: fmt.Sprintf("{{artifact:%s/...}} refers to an unknown task", ref)})
+		} else if !upstream[ref] {
+			issues = append(issues, prompt.LintIssue{Warning: true, Message: fmt.Sprintf("{{artifact:%s/...}} refers to a task that is not an upstream dependency, so the artifact may be missing or stale", ref)})
+		}
+	}
+	return label, issues
+}
+
+// upstreamTasks returns the tasks that name depends on, directly or
+// transitively.
+func upstreamTasks(cf *compose.ComposeFile, name string) map[string]bool {
+	upstream := make(map[string]bool)
+	var visit func(string)
+	visit = func(n string) {
+		for _, dep := range cf.Tasks[n].DependsOn {
+			if !upstream[dep.Task] {
+				upstream[dep.Task] = true
+				visit(dep.Task)
+			}
+		}
+	}
+	visit(name)
+	return upstream
+}
+
+func init() {
+	promptsLintCmd.Flags().StringVarP(&promptsLintFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	promptsLintCmd.Flags().IntVar(&promptsLintMaxTokens, "max-tokens", 0, "Estimated token count above which a prompt is too long (default: max_prompt_tokens or 50000)")
+	promptsLintCmd.Flags().BoolVar(&promptsLintStrict, "strict", false, "Fail on warnings as well as errors")
+	promptsLintCmd.Flags().BoolVar(&promptsLintLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	promptsCmd.AddCommand(promptsLintCmd)
+}
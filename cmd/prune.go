@@ -9,6 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/scratch"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +30,8 @@ This command removes all agents that are no longer running. By default,
 it will prompt for confirmation. Use --force to skip the confirmation.
 
 Use --logs to also delete the log files associated with pruned agents.
+Pruned agents' scratch dirs (SWARM_SCRATCH_DIR) are always deleted, e.g.
+those kept by scratch_retention or left behind by a killed agent.
 
 Use --outputs to clean up pipeline output capture directories (./swarm/outputs/).
 When used with --older-than, only output dirs older than the threshold are removed.
@@ -147,6 +152,12 @@ Supported duration formats: 30s, 5m, 2h, 1d (days), 7d.`,
 				} else {
 					logsRemoved++
 				}
+				os.Remove(detach.StderrLogPath(agent.LogFile))
+				os.Remove(logparser.CheckpointPath(agent.LogFile))
+			}
+
+			if err := scratch.Remove(agent.ID); err != nil {
+				fmt.Printf("Warning: failed to remove scratch dir of %s: %v\n", agent.ID, err)
 			}
 
 			fmt.Println(agent.ID)
@@ -25,6 +25,8 @@ var replayCmd = &cobra.Command{
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 By default, the replay inherits the original agent's:
   - Prompt
@@ -134,6 +136,13 @@ a unique ID and a name based on the original (e.g., "my-agent-replay-1").`,
 		runArgs = append(runArgs, "-m", model)
 		runArgs = append(runArgs, "-n", strconv.Itoa(iterations))
 		runArgs = append(runArgs, "-N", name)
+		if agent.Image != "" {
+			runArgs = append(runArgs, "--image", agent.Image)
+		}
+		for _, a := range agent.AgentArgs {
+			runArgs = append(runArgs, "--agent-arg="+a)
+		}
+		runArgs = append(runArgs, paramArgs(agent.ModelParams)...)
 		if detached {
 			runArgs = append(runArgs, "-d")
 		}
@@ -215,6 +224,8 @@ a unique ID and a name based on the original (e.g., "my-agent-replay-1").`,
 		runTimeout = ""
 		runIterTimeout = ""
 		runOnComplete = ""
+		runOnFailure = ""
+		runOnTimeout = ""
 
 		return runCmd.RunE(cmd, []string{})
 	},
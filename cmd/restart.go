@@ -38,6 +38,8 @@ var restartCmd = &cobra.Command{
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 If the original name is taken by a running agent, a number suffix (-2, -3, etc.)
 will be appended automatically to make the name unique.
@@ -45,7 +47,8 @@ will be appended automatically to make the name unique.
 You can optionally override the model, iterations, or name.
 
 Use --continue to resume from where the agent left off instead of starting
-from iteration 1.
+from iteration 1. To pick the agent itself back up, keeping its ID, history,
+and usage totals, use 'swarm run --continue'.
 
 Labels from the original agent are preserved. Use --label to add or override
 labels on the restarted agent.`,
@@ -203,6 +206,7 @@ labels on the restarted agent.`,
 				effectiveLabels = label.Merge(effectiveLabels, newLabels)
 			}
 		}
+		effectiveLabels = appConfig.WithDefaultLabels(effectiveLabels)
 
 		// Parse and expand environment variables (does not preserve original env vars)
 		var expandedEnv []string
@@ -259,6 +263,13 @@ labels on the restarted agent.`,
 			if effectiveName != "" {
 				detachedArgs = append(detachedArgs, "--name", effectiveName)
 			}
+			if oldAgent.Image != "" {
+				detachedArgs = append(detachedArgs, "--image", oldAgent.Image)
+			}
+			for _, a := range oldAgent.AgentArgs {
+				detachedArgs = append(detachedArgs, "--agent-arg="+a)
+			}
+			detachedArgs = append(detachedArgs, paramArgs(oldAgent.ModelParams)...)
 			// Pass starting iteration if --continue was used
 			if restartContinue {
 				detachedArgs = append(detachedArgs, "--_internal-start-iter", strconv.Itoa(startingIteration))
@@ -297,6 +308,9 @@ labels on the restarted agent.`,
 				LogFile:     logFile,
 				WorkingDir:  effectiveWorkingDir,
 				EnvNames:    envNames,
+				Image:       oldAgent.Image,
+				AgentArgs:   oldAgent.AgentArgs,
+				ModelParams: oldAgent.ModelParams,
 				OnComplete:  restartOnComplete,
 			}
 
@@ -330,8 +344,9 @@ labels on the restarted agent.`,
 			cfg := agent.Config{
 				Model:   effectiveModel,
 				Prompt:  iterationPrompt,
-				Command: appConfig.AgentCommand(),
+				Command: appConfig.AgentCommand().WithImage(oldAgent.Image).WithExtraArgs(oldAgent.AgentArgs).WithParams(oldAgent.ModelParams),
 				Env:     expandedEnv,
+				Run:     agent.RunInfo{Iteration: 1, TotalIterations: 1, TaskName: effectiveName},
 			}
 
 			runner := agent.NewRunner(cfg)
@@ -352,6 +367,9 @@ labels on the restarted agent.`,
 			Status:      "running",
 			WorkingDir:  effectiveWorkingDir,
 			EnvNames:    envNames,
+			Image:       oldAgent.Image,
+			AgentArgs:   oldAgent.AgentArgs,
+			ModelParams: oldAgent.ModelParams,
 			OnComplete:  restartOnComplete,
 		}
 
@@ -371,7 +389,7 @@ labels on the restarted agent.`,
 			Manager:           mgr,
 			AgentState:        agentState,
 			PromptContent:     promptContent,
-			Command:           appConfig.AgentCommand(),
+			Command:           appConfig.AgentCommand().WithImage(oldAgent.Image).WithExtraArgs(oldAgent.AgentArgs).WithParams(oldAgent.ModelParams),
 			Config:            appConfig,
 			Env:               expandedEnv,
 			Output:            os.Stdout,
@@ -401,4 +419,5 @@ func init() {
 	// Add dynamic completion for agent identifier and model flag
 	restartCmd.ValidArgsFunction = completeAgentIdentifier
 	restartCmd.RegisterFlagCompletionFunc("model", completeModelName)
+	restartCmd.RegisterFlagCompletionFunc("label", completeLabel)
 }
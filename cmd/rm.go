@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/mj1618/swarm-cli/internal/process"
+	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/logparser"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
 )
@@ -23,7 +24,9 @@ running agents (this will also terminate them).
 Use --logs to also delete the log files associated with removed agents.
 
 The agents can be specified by their IDs, names, or special identifiers:
-  - @last or _ : the most recently started agent`,
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
 	Example: `  # Remove a terminated agent by ID
   swarm rm abc123
 
@@ -72,7 +75,7 @@ The agents can be specified by their IDs, names, or special identifiers:
 				}
 
 				// Force terminate the running agent
-				if err := process.Kill(agent.PID); err != nil {
+				if err := agent.Kill(); err != nil {
 					fmt.Printf("Warning: could not send signal to process %d: %v\n", agent.PID, err)
 				}
 			}
@@ -92,6 +95,8 @@ The agents can be specified by their IDs, names, or special identifiers:
 				} else {
 					logsRemoved++
 				}
+				os.Remove(detach.StderrLogPath(agent.LogFile))
+				os.Remove(logparser.CheckpointPath(agent.LogFile))
 			}
 
 			fmt.Println(agent.ID)
@@ -2,9 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/label"
+	"github.com/mj1618/swarm-cli/internal/logparser"
 	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/simulate"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/mj1618/swarm-cli/internal/version"
 	"github.com/spf13/cobra"
@@ -19,6 +26,9 @@ var globalFlag bool
 // appScope holds the current scope (project or global)
 var appScope scope.Scope
 
+// envFiles holds the --env-file paths
+var envFiles []string
+
 var rootCmd = &cobra.Command{
 	Use:   "swarm",
 	Short: "Swarm CLI - Manage AI agents",
@@ -50,20 +60,59 @@ Use --global to operate across all projects.`,
 			appScope = scope.ScopeProject
 		}
 
+		// Load swarm/.env and --env-file before anything reads the environment
+		envFileVars, err := config.LoadEnvFiles(envFiles)
+		if err != nil {
+			return err
+		}
+
 		// Skip config loading for config subcommand (it handles its own loading)
 		if cmd.Name() == "config" || (cmd.Parent() != nil && cmd.Parent().Name() == "config") {
 			return nil
 		}
 
-		var err error
 		appConfig, err = config.Load()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		appConfig.EnvFileVars = envFileVars
+		logparser.SetTimestampFormat(appConfig.LogLocation(), appConfig.LogTimeFormat)
+		if err := applySimulation(); err != nil {
+			return err
+		}
+		if err := checkVersionPins(cmd, appConfig); err != nil {
+			return err
+		}
+		stopOldAgents(appConfig)
 		return nil
 	},
 }
 
+// stopOldAgents stops the agents in scope running longer than max_agent_age,
+// so an agent forgotten for weeks is caught by the next swarm command. Errors
+// are only warned about.
+func stopOldAgents(cfg *config.Config) {
+	maxAge := cfg.MaxAgentAgeDuration()
+	if maxAge <= 0 {
+		return
+	}
+	mgr, err := state.NewManagerWithScope(GetScope(), "")
+	if err != nil {
+		return
+	}
+	stopped, err := mgr.StopAgentsOlderThan(maxAge, time.Now())
+	for _, a := range stopped {
+		action := "stopping after its current iteration"
+		if a.TerminateMode == "immediate" {
+			action = "killed"
+		}
+		fmt.Fprintf(os.Stderr, "Agent %s (%s) has run longer than max_agent_age (%s): %s\n", a.ID, a.Name, maxAge, action)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to stop agents older than max_agent_age: %v\n", err)
+	}
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -71,6 +120,13 @@ func Execute() error {
 func init() {
 	// Add global flag as persistent (available to all subcommands)
 	rootCmd.PersistentFlags().BoolVarP(&globalFlag, "global", "g", false, "Operate globally instead of project-scoped")
+	rootCmd.PersistentFlags().StringArrayVar(&envFiles, "env-file", nil, "Load environment variables from a file (swarm/.env is loaded automatically)")
+
+	// --simulate runs a scripted fake agent instead of the agent CLI (see
+	// simulate-agent), for testing and demos without spending tokens
+	rootCmd.PersistentFlags().StringVar(&simulateScript, "simulate", "", "Simulate agents with a script instead of calling the agent CLI")
+	rootCmd.PersistentFlags().Lookup("simulate").NoOptDefVal = simulate.DefaultScriptName
+	_ = rootCmd.PersistentFlags().MarkHidden("simulate")
 
 	// Set version for --version flag
 	rootCmd.Version = version.Version
@@ -117,8 +173,26 @@ func IsLastIdentifier(identifier string) bool {
 	return identifier == "@last" || identifier == "_"
 }
 
-// ResolveAgentIdentifier resolves an agent identifier to an AgentState.
-// Handles special identifiers like "@last" and "_" which refer to the most recently started agent.
+// labelIdentifierPrefix starts an identifier that selects an agent by its
+// labels, e.g. "label:team=backend,env=staging".
+const labelIdentifierPrefix = "label:"
+
+// pipelineIdentifierPrefix starts the name of a pipeline's agent, e.g.
+// "pipeline:nightly".
+const pipelineIdentifierPrefix = "pipeline:"
+
+// ResolveAgentIdentifier resolves an agent identifier to an AgentState. Every
+// command that takes an agent accepts the same identifiers:
+//   - @last or _: the most recently started agent
+//   - an agent ID
+//   - an agent name; when several agents share it (e.g. after restarts), a
+//     running one is preferred, then the most recently started
+//   - pipeline:<name>: a pipeline's agent, or its only running instance when
+//     it runs with parallelism (instances are pipeline:<name>.1, ...)
+//   - label:<key=value,...>: the agent with all the labels, a running one
+//     preferred
+//
+// Selectors matching several running agents are an error rather than a guess.
 func ResolveAgentIdentifier(mgr *state.Manager, identifier string) (*state.AgentState, error) {
 	if IsLastIdentifier(identifier) {
 		agent, err := mgr.GetLast()
@@ -127,5 +201,58 @@ func ResolveAgentIdentifier(mgr *state.Manager, identifier string) (*state.Agent
 		}
 		return agent, nil
 	}
-	return mgr.GetByNameOrID(identifier)
+
+	if selector, ok := strings.CutPrefix(identifier, labelIdentifierPrefix); ok {
+		filters, err := label.ParseMultiple(strings.Split(selector, ","))
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", identifier, err)
+		}
+		return selectAgent(mgr, identifier, func(a *state.AgentState) bool {
+			return label.Match(a.Labels, filters)
+		})
+	}
+
+	agent, err := mgr.GetByNameOrID(identifier)
+	if err != nil {
+		if pipelineName, ok := strings.CutPrefix(identifier, pipelineIdentifierPrefix); ok && pipelineName != "" {
+			return selectAgent(mgr, identifier, func(a *state.AgentState) bool {
+				return isPipelineInstance(a.Name, pipelineName)
+			})
+		}
+	}
+	return agent, err
+}
+
+// selectAgent returns the agent in scope selected by match: the only running
+// match, or else the most recently started one.
+func selectAgent(mgr *state.Manager, identifier string, match func(a *state.AgentState) bool) (*state.AgentState, error) {
+	agents, err := mgr.List(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	var running []string
+	var selected *state.AgentState
+	for _, a := range agents {
+		if !match(a) {
+			continue
+		}
+		if a.Status == "running" {
+			if len(running) == 0 {
+				selected = a
+			}
+			running = append(running, a.ID)
+		} else if selected == nil || (selected.Status != "running" && a.StartedAt.After(selected.StartedAt)) {
+			selected = a
+		}
+	}
+
+	if len(running) > 1 {
+		sort.Strings(running)
+		return nil, fmt.Errorf("%s matches %d running agents (%s); use an ID", identifier, len(running), strings.Join(running, ", "))
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("agent not found: %s", identifier)
+	}
+	return selected, nil
 }
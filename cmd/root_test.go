@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mj1618/swarm-cli/internal/scope"
 	"github.com/mj1618/swarm-cli/internal/state"
@@ -302,3 +303,50 @@ func TestResolveAgentIdentifierErrorMessage(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveAgentIdentifierSelectors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := state.NewManagerWithScope(scope.ScopeGlobal, "")
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	now := time.Now()
+	for _, a := range []*state.AgentState{
+		{ID: "web00001", Name: "web", Status: "terminated", StartedAt: now.Add(-2 * time.Hour), Labels: map[string]string{"team": "web"}},
+		{ID: "web00002", Name: "web", Status: "running", StartedAt: now.Add(-time.Hour), Labels: map[string]string{"team": "web", "env": "prod"}},
+		{ID: "api00001", Name: "api", Status: "running", StartedAt: now, Labels: map[string]string{"team": "api", "env": "prod"}},
+		{ID: "nightly1", Name: "pipeline:nightly.1", Status: "running", StartedAt: now},
+		{ID: "nightly2", Name: "pipeline:nightly.2", Status: "terminated", StartedAt: now},
+	} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		identifier string
+		want       string // empty for an error
+	}{
+		{"web00001", "web00001"},
+		{"web", "web00002"},
+		{"label:team=web", "web00002"},
+		{"label:team=api,env=prod", "api00001"},
+		{"label:env=prod", ""}, // two running agents
+		{"label:team=none", ""},
+		{"label:Bad Key", ""},
+		{"pipeline:nightly", "nightly1"},
+		{"pipeline:nightly.2", "nightly2"},
+		{"pipeline:other", ""},
+	}
+	for _, tt := range tests {
+		got, err := ResolveAgentIdentifier(mgr, tt.identifier)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("%s: expected an error, got %s", tt.identifier, got.ID)
+		case tt.want != "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.identifier, err)
+		case tt.want != "" && got.ID != tt.want:
+			t.Errorf("%s: got %s, want %s", tt.identifier, got.ID, tt.want)
+		}
+	}
+}
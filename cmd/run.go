@@ -1,20 +1,31 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/config"
 	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/exitreport"
 	"github.com/mj1618/swarm-cli/internal/label"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/notify"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
 	"github.com/mj1618/swarm-cli/internal/prompt"
 	"github.com/mj1618/swarm-cli/internal/runner"
 	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/scratch"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
+	"github.com/mj1618/swarm-cli/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +51,8 @@ var (
 	runWorkingDir          string
 	runInternalStartIter   int
 	runOnComplete          string
+	runOnFailure           string
+	runOnTimeout           string
 	runInternalOnComplete  string
 	runLabels              []string
 	runInternalLabels      []string
@@ -52,6 +65,56 @@ var (
 	runSystemPrompt        string
 	runSystemPromptFile    string
 	runSystemPromptGlobal  bool
+	runImage               string
+	runAgentArgs           []string
+	runParams              []string
+)
+
+// runInternalComposeStdin is the stdin captured by 'swarm up --stdin', which
+// fills {{stdin}} in the prompt of a detached compose task.
+var runInternalComposeStdin string
+
+// runEnvSummary prepends a host environment summary to the prompt.
+var runEnvSummary bool
+
+// runFailOnSubtype and runFailOnResultRegex fail an iteration based on the
+// agent's result event even when the agent CLI exits 0.
+var (
+	runFailOnSubtype     string
+	runFailOnResultRegex string
+)
+
+// runAllowedPaths and runDeniedPaths restrict the files the agent may edit;
+// runReadOnly allows it to edit none.
+var (
+	runAllowedPaths []string
+	runDeniedPaths  []string
+	runReadOnly     bool
+)
+
+// runWorktree runs the agent in its own git worktree and branch; with
+// runMergeBack the branch is merged back after each successful iteration.
+var (
+	runWorktree  bool
+	runMergeBack bool
+)
+
+// runBudgetUSD and runBudgetTokens cap the agent's cumulative cost and
+// tokens (0 = no cap).
+var (
+	runBudgetUSD    float64
+	runBudgetTokens int64
+)
+
+// runMaxPending holds the agent back while more than this many tasks wait in
+// the todo queue (0 = no limit).
+var runMaxPending int
+
+// runHealthCmd is a liveness probe run every runHealthInterval while the
+// agent is active.
+var (
+	runHealthCmd      string
+	runHealthInterval time.Duration
 )
 
 var runCmd = &cobra.Command{
@@ -62,8 +125,102 @@ var runCmd = &cobra.Command{
 By default, runs a single iteration. Use -n to run multiple iterations.
 When running multiple iterations, agent failures do not stop the run.
 
+When an iteration overflows the agent's context window, it is retried once:
+with the prompt's compact variant if one exists (e.g. my-prompt.compact.md
+next to my-prompt.md), otherwise with a fresh session when the agent args
+continue an earlier one (--continue, --resume, --session-id).
+
+Set max_prompt_tokens in swarm.toml to check each iteration's estimated
+prompt size (including prefix/suffix and stdin) before the agent starts;
+prompt_limit_action = "fail" fails oversized iterations instead of warning.
+
+--health-cmd runs a liveness probe (e.g. curl -fs localhost:3000/health) every
+--health-interval while the agent is active. A failing check marks the agent
+UNHEALTHY in 'swarm top' and 'swarm inspect' and is logged; the agent keeps
+running.
+
+--allowed-path and --denied-path restrict the files the agent may edit to glob
+patterns relative to its working directory ("**" matches any number of
+directories, e.g. --allowed-path 'docs/**'). Edits are checked as the agent
+makes them, and files changed in a git working tree are checked after each
+iteration. An edit outside the allowed paths kills the agent and stops the
+run with exit reason path_violation.
+
+--read-only is for reviewer and analyst agents that must never modify code:
+any edit in the working directory, or a working tree changed after an
+iteration, is a path violation. The agent CLI also gets the command's
+read_only_args from swarm.toml (by default, Claude Code's edit tools are
+disallowed and Codex runs in its read-only sandbox), and with --image the
+working directory is mounted read-only.
+
+--param tunes the model with key=value params: temperature (0 to 2),
+max_output_tokens, and thinking_budget, e.g. --param temperature=0 for a
+deterministic reviewer. The backend passes them to the agent CLI as flags or
+environment variables, set by the command's param_args and param_env in
+swarm.toml (by default Claude Code gets max_output_tokens and
+thinking_budget, and Codex max_output_tokens). Params the backend can't pass
+are ignored with a warning.
+
+--worktree runs the agent in its own git worktree under ~/.swarm/worktrees, on
+a new swarm/<agent> branch from HEAD, so agents running in parallel in one
+repository don't clobber each other's edits. The agent's changes are committed
+to the branch after each iteration, and with --merge-back the branch is merged
+into the original checkout after each successful iteration (a conflicting
+merge is aborted, keeping the changes on the branch). The worktree is removed
+when the agent finishes; a branch with unmerged changes is kept, see
+'swarm worktree'.
+
+--budget-usd and --budget-tokens cap the agent's cumulative cost and tokens
+(input plus output). Once the agent reaches its budget it's stopped after the
+current iteration, with exit reason budget; an iteration still running two
+minutes after the budget was reached is killed. 'swarm list' and 'swarm top'
+show how much of the budget has been used.
+
+Hooks run when the agent terminates: --on-complete always, then --on-failure
+if it ended in failure (its final iteration failed, it crashed, or it ran out
+of budget) or --on-timeout if it timed out. Each hook gets a JSON payload on
+stdin with the event, the agent's full state, iteration counts, duration, and
+usage and cost, plus SWARM_HOOK_EVENT and SWARM_AGENT_* environment variables.
+A hook's output goes to the agent's log. Set hook_retries in swarm.toml to
+retry a failing hook, waiting hook_retry_delay (default 5s) and doubling it
+between attempts; each hook's outcome is saved in the agent's state
+('swarm inspect' shows it), and a hook that fails on every attempt
+sends a hook_failed notification.
+
+Each agent gets a scratch dir for temporary files, exported as
+$SWARM_SCRATCH_DIR, so analysis notes and intermediate output stay out of the
+repo. It's removed when the agent terminates; set scratch_retention in
+swarm.toml (e.g. "24h") to keep it for a while afterwards.
+
+The agent process also gets SWARM_ITERATION, SWARM_TOTAL_ITERATIONS (0 when
+unlimited), SWARM_TASK_NAME (the agent's name), and SWARM_RUN_ID (the agent's
+ID), so scripts it runs can branch on the run as the agent does on its prompt.
+A looping agent also gets SWARM_CONTROL_ID, the ID 'swarm stop', 'swarm exec'
+and 'swarm approve' take for it.
+
+Before finishing, an agent may write an exit report to $SWARM_EXIT_FILE: a JSON
+object {"status": "success" or "failure", "summary": "...", "follow_up": "..."}.
+A failure status fails the iteration even when the agent CLI exits 0. The report
+is kept in the agent's state, shown by 'swarm inspect', and its summary becomes
+the agent's current task.
+
+--max-pending applies backpressure to a planner that writes the todo queue
+(todo_dir in swarm.toml, default swarm/todos): before each iteration, while more
+than N task files wait for a doer, the agent waits, checking every 10 seconds,
+so it can't pile up work faster than the doers drain it.
+
+--continue resumes a terminated multi-iteration agent, such as one that
+crashed or was killed, under the same ID: it runs again from the iteration
+after the last one it finished, with its original prompt, model, and agent
+settings, and its history, log, and token and cost totals carry on where they
+left off. It may be combined with -n/--forever, -m, -d, -e, --timeout, and
+--iter-timeout. Environment values, --prefix/--suffix, and path restrictions
+aren't stored, so they aren't restored.
+
 Labels can be attached to agents for categorization and filtering using the
---label (-l) flag. Labels are key-value pairs in the format key=value.`,
+--label (-l) flag. Labels are key-value pairs in the format key=value.
+Labels every agent in a project should carry (e.g. repo=payments, owner=alice)
+can be set once in swarm.toml under [default_labels]; --label overrides them.`,
 	Example: `  # Interactive prompt selection (single iteration)
   swarm run
 
@@ -109,9 +266,37 @@ Labels can be attached to agents for categorization and filtering using the
   # Run with multiple labels
   swarm run -p task -l env=staging -l ticket=PROJ-123 -d
 
+  # Run the agent inside a container image
+  swarm run -p coder --image golang:1.22
+
+  # Only let the agent edit the docs
+  swarm run -p doc-writer --allowed-path 'docs/**' --allowed-path '*.md'
+
+  # A reviewer that must not touch the code
+  git diff main | swarm run --stdin -p code-reviewer --read-only
+
+  # Give the agent a bigger thinking budget
+  swarm run -p planner --param thinking_budget=16000
+
+  # Post a failed agent's state to a webhook
+  swarm run -p coder -n 10 -d --on-failure 'curl -s -d @- https://hooks.example.com/swarm'
+
+  # Stop the agent once it has cost $5
+  swarm run -p coder -n 50 -d --budget-usd 5
+
+  # Parallel coders on their own branches, merged back as they go
+  swarm run -p coder -n 5 -d --worktree --merge-back
+
+  # Pick up a crashed agent after its last completed iteration
+  swarm run --continue my-agent -d
+
   # Add prefix/suffix to the prompt
   swarm run -p coder --prefix "Focus on security best practices." --suffix "Output only the code, no explanations."`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if runContinue != "" {
+			return runContinueAgent(cmd, runContinue)
+		}
+
 		// Get working directory (from flag or current)
 		var workingDir string
 		var err error
@@ -280,6 +465,19 @@ Labels can be attached to agents for categorization and filtering using the
 			}
 		}
 
+		// Load the compact variant of a named prompt, used to retry an iteration
+		// that overflows the agent's context window
+		var compactContent string
+		switch {
+		case runPromptFile != "":
+			compactContent, err = prompt.LoadCompactPromptFromFile(runPromptFile)
+		case runPrompt != "" && !runStdin && runPromptString == "":
+			compactContent, err = prompt.LoadCompactPrompt(promptsDir, runPrompt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load compact prompt variant: %w", err)
+		}
+
 		// Store raw prompt content for -s/--stdin so clone/replay can reconstruct
 		var storedPromptContent string
 		if runPromptString != "" {
@@ -301,6 +499,21 @@ Labels can be attached to agents for categorization and filtering using the
 			}
 		}
 		promptContent = prompt.ApplyPrefixSuffix(promptContent, effectivePrefix, effectiveSuffix)
+		if compactContent != "" {
+			compactContent = prompt.ApplyPrefixSuffix(compactContent, effectivePrefix, effectiveSuffix)
+		}
+
+		// Fill {{stdin}} with the stdin captured by 'swarm up --stdin'
+		promptContent = prompt.InjectStdin(promptContent, runInternalComposeStdin)
+		compactContent = prompt.InjectStdin(compactContent, runInternalComposeStdin)
+
+		if runEnvSummary {
+			summary := prompt.EnvSummary(workingDir)
+			promptContent = prompt.InjectEnvSummary(promptContent, summary)
+			if compactContent != "" {
+				compactContent = prompt.InjectEnvSummary(compactContent, summary)
+			}
+		}
 
 		// Generate task ID early so it can be injected into prompt
 		// If running as detached child, use the task ID passed from parent
@@ -311,6 +524,9 @@ Labels can be attached to agents for categorization and filtering using the
 
 		// Inject task ID into prompt content
 		promptContent = prompt.InjectTaskID(promptContent, taskID)
+		if compactContent != "" {
+			compactContent = prompt.InjectTaskID(compactContent, taskID)
+		}
 
 		// Determine effective model (CLI flag overrides config)
 		effectiveModel := appConfig.Model
@@ -422,6 +638,49 @@ Labels can be attached to agents for categorization and filtering using the
 			}
 		}
 
+		resultCriteria, err := agent.NewResultCriteria(runFailOnSubtype, runFailOnResultRegex)
+		if err != nil {
+			return fmt.Errorf("invalid --fail-on-result-regex: %w", err)
+		}
+
+		modelParams, err := config.ParseParams(runParams)
+		if err != nil {
+			return fmt.Errorf("invalid --param: %w", err)
+		}
+		if !runInternalDetached {
+			warnUnsupportedParams(appConfig, modelParams)
+		}
+
+		pathGuard, err := pathguard.New(runAllowedPaths, runDeniedPaths, workingDir)
+		if err != nil {
+			return err
+		}
+		if runReadOnly {
+			if pathGuard != nil {
+				return fmt.Errorf("--read-only can't be combined with --allowed-path or --denied-path")
+			}
+			pathGuard = pathguard.NewReadOnly(workingDir)
+		}
+
+		if runMergeBack && !runWorktree {
+			return fmt.Errorf("--merge-back requires --worktree")
+		}
+		if runBudgetUSD < 0 || runBudgetTokens < 0 {
+			return fmt.Errorf("budget cannot be negative")
+		}
+		var budget *state.Budget
+		if runBudgetUSD > 0 || runBudgetTokens > 0 {
+			budget = &state.Budget{USD: runBudgetUSD, Tokens: runBudgetTokens}
+		}
+
+		var healthCheck *agent.HealthCheck
+		if runHealthCmd != "" {
+			if runHealthInterval < 0 {
+				return fmt.Errorf("health-interval cannot be negative: %s", runHealthInterval)
+			}
+			healthCheck = &agent.HealthCheck{Command: runHealthCmd, Interval: runHealthInterval}
+		}
+
 		// Determine effective on-complete hook
 		// For detached child, use value passed from parent
 		effectiveOnComplete := runOnComplete
@@ -443,6 +702,7 @@ Labels can be attached to agents for categorization and filtering using the
 				return fmt.Errorf("invalid label: %w", err)
 			}
 		}
+		labels = appConfig.WithDefaultLabels(labels)
 
 		// Determine effective parent ID
 		// For detached child, use value passed from parent process
@@ -454,6 +714,9 @@ Labels can be attached to agents for categorization and filtering using the
 		// If this is a sub-agent, inject restriction to prevent spawning more sub-agents
 		if effectiveParentID != "" {
 			promptContent = prompt.InjectSubAgentRestriction(promptContent, effectiveParentID)
+			if compactContent != "" {
+				compactContent = prompt.InjectSubAgentRestriction(compactContent, effectiveParentID)
+			}
 		}
 
 		// Handle detached mode
@@ -493,6 +756,13 @@ Labels can be attached to agents for categorization and filtering using the
 			if runName != "" {
 				detachedArgs = append(detachedArgs, "--name", runName)
 			}
+			if runImage != "" {
+				detachedArgs = append(detachedArgs, "--image", runImage)
+			}
+			for _, a := range runAgentArgs {
+				detachedArgs = append(detachedArgs, "--agent-arg="+a)
+			}
+			detachedArgs = append(detachedArgs, paramArgs(modelParams)...)
 			// Pass expanded env vars to child (already expanded in parent)
 			for _, e := range expandedEnv {
 				detachedArgs = append(detachedArgs, "--_internal-env", e)
@@ -512,10 +782,49 @@ Labels can be attached to agents for categorization and filtering using the
 			if runOnComplete != "" {
 				detachedArgs = append(detachedArgs, "--_internal-on-complete", runOnComplete)
 			}
+			if runOnFailure != "" {
+				detachedArgs = append(detachedArgs, "--on-failure", runOnFailure)
+			}
+			if runOnTimeout != "" {
+				detachedArgs = append(detachedArgs, "--on-timeout", runOnTimeout)
+			}
 			// Pass labels to child
 			for _, l := range runLabels {
 				detachedArgs = append(detachedArgs, "--_internal-label", l)
 			}
+			if runEnvSummary {
+				detachedArgs = append(detachedArgs, "--env-summary")
+			}
+			if runFailOnSubtype != "" {
+				detachedArgs = append(detachedArgs, "--fail-on-subtype", runFailOnSubtype)
+			}
+			if runFailOnResultRegex != "" {
+				detachedArgs = append(detachedArgs, "--fail-on-result-regex", runFailOnResultRegex)
+			}
+			if runReadOnly {
+				detachedArgs = append(detachedArgs, "--read-only")
+			}
+			if runWorktree {
+				detachedArgs = append(detachedArgs, "--worktree")
+			}
+			if runMergeBack {
+				detachedArgs = append(detachedArgs, "--merge-back")
+			}
+			for _, p := range runAllowedPaths {
+				detachedArgs = append(detachedArgs, "--allowed-path", p)
+			}
+			for _, p := range runDeniedPaths {
+				detachedArgs = append(detachedArgs, "--denied-path", p)
+			}
+			if runMaxPending > 0 {
+				detachedArgs = append(detachedArgs, "--max-pending", strconv.Itoa(runMaxPending))
+			}
+			if runHealthCmd != "" {
+				detachedArgs = append(detachedArgs, "--health-cmd", runHealthCmd)
+				if runHealthInterval > 0 {
+					detachedArgs = append(detachedArgs, "--health-interval", runHealthInterval.String())
+				}
+			}
 			// Pass prefix/suffix to child
 			if runPrefix != "" {
 				detachedArgs = append(detachedArgs, "--_internal-prefix", runPrefix)
@@ -558,8 +867,14 @@ Labels can be attached to agents for categorization and filtering using the
 				LogFile:       logFile,
 				WorkingDir:    workingDir,
 				EnvNames:      envNames,
+				Image:         runImage,
+				AgentArgs:     runAgentArgs,
+				ModelParams:   modelParams,
 				TimeoutAt:     timeoutAt,
 				OnComplete:    runOnComplete,
+				OnFailure:     runOnFailure,
+				OnTimeout:     runOnTimeout,
+				Budget:        budget,
 			}
 
 			if err := mgr.Register(agentState); err != nil {
@@ -597,6 +912,41 @@ Labels can be attached to agents for categorization and filtering using the
 			return nil
 		}
 
+		// With --worktree the agent works on its own branch, so agents running
+		// in parallel in one repository don't clobber each other's edits
+		agentDir := ""
+		var wt *worktree.Worktree
+		if runWorktree {
+			wt, err = worktree.Create(workingDir, effectiveName+"-"+taskID)
+			if err != nil {
+				return err
+			}
+			agentDir = wt.Dir
+			if runReadOnly {
+				pathGuard = pathguard.NewReadOnly(agentDir)
+			} else if pathGuard, err = pathguard.New(runAllowedPaths, runDeniedPaths, agentDir); err != nil {
+				return err
+			}
+			if healthCheck != nil {
+				healthCheck.Dir = agentDir
+			}
+			fmt.Printf("Working in worktree %s (branch %s)\n", wt.Path, wt.Branch)
+		}
+		// Called explicitly before the os.Exit of a timeout, which skips defers
+		removeWorktree := func() {
+			if wt == nil {
+				return
+			}
+			kept, err := wt.Remove("swarm: final changes")
+			if err != nil {
+				fmt.Printf("[swarm] Warning: failed to clean up worktree: %v\n", err)
+			} else if kept {
+				fmt.Printf("[swarm] Changes kept on branch %s\n", wt.Branch)
+			}
+			wt = nil
+		}
+		defer removeWorktree()
+
 		// For single iteration, run with state tracking but simpler flow (no loop/pause/signal handling)
 		if effectiveIterations == 1 {
 			// Create state manager with scope
@@ -644,8 +994,14 @@ Labels can be attached to agents for categorization and filtering using the
 					Status:        "running",
 					WorkingDir:    workingDir,
 					EnvNames:      envNames,
+					Image:         runImage,
+					AgentArgs:     runAgentArgs,
+					ModelParams:   modelParams,
 					TimeoutAt:     timeoutAt,
 					OnComplete:    effectiveOnComplete,
+					OnFailure:     runOnFailure,
+					OnTimeout:     runOnTimeout,
+					Budget:        budget,
 				}
 
 				if err := mgr.Register(agentState); err != nil {
@@ -653,11 +1009,32 @@ Labels can be attached to agents for categorization and filtering using the
 				}
 			}
 
+			// Give the agent a scratch dir for throwaway files
+			singleEnv := expandedEnv
+			exitFile := ""
+			if dir, err := scratch.Create(taskID); err != nil {
+				fmt.Printf("[swarm] Warning: %v\n", err)
+			} else {
+				agentState.ScratchDir = dir
+				_ = mgr.MergeUpdate(agentState)
+				singleEnv = append(append([]string(nil), expandedEnv...), scratch.Env(dir))
+				exitFile = exitreport.Path(dir)
+			}
+
+			// Record the versions this run starts with
+			agentState.Manifest = agent.NewManifest(appConfig.AgentCommand().WithImage(runImage), workingDir)
+			_ = mgr.MergeUpdate(agentState)
+
 			// Track if we timed out for proper exit code
 			timedOut := false
 
+			// Guards agentState against the health check and pod status
+			// watchers, which update it from their own goroutines
+			var stateMu sync.Mutex
+
 			// Ensure cleanup on exit
 			defer func() {
+				stateMu.Lock()
 				if timedOut {
 					agentState.TimeoutReason = "total"
 				}
@@ -668,25 +1045,67 @@ Labels can be attached to agents for categorization and filtering using the
 					agentState.ExitReason = "completed"
 				}
 				_ = mgr.Update(agentState)
+				stateMu.Unlock()
+
+				// Execute on-complete/on-failure/on-timeout hooks
+				runAgentHooks(mgr, agentState)
 
-				// Execute on-complete hook
-				if agentState.OnComplete != "" {
-					if err := agent.ExecuteOnCompleteHook(agentState); err != nil {
-						fmt.Printf("[swarm] Warning: on-complete hook failed: %v\n", err)
+				// After the hook, which may still read the scratch dir
+				if agentState.ScratchDir != "" {
+					if err := scratch.Finish(taskID, appConfig.ScratchRetentionDuration()); err != nil {
+						fmt.Printf("[swarm] Warning: failed to clean up scratch dir: %v\n", err)
 					}
 				}
 
 				if timedOut {
+					removeWorktree()
 					os.Exit(124) // Exit code 124 matches GNU timeout convention
 				}
 			}()
 
+			// Waiting for todo-queue room counts towards the total timeout
+			waitCtx := context.Background()
+			if totalTimeout > 0 {
+				var cancelWait context.CancelFunc
+				waitCtx, cancelWait = context.WithTimeout(waitCtx, totalTimeout)
+				defer cancelWait()
+			}
+			if runMaxPending > 0 && !waitForTodoRoom(waitCtx, mgr, agentState, workingDir) {
+				if waitCtx.Err() != nil {
+					timedOut = true
+					fmt.Printf("\n[swarm] Timed out after %v waiting for the todo queue\n", totalTimeout)
+				}
+				return nil
+			}
+
 			fmt.Printf("Running agent with prompt: %s, model: %s\n", promptName, effectiveModel)
 
+			if healthCheck != nil {
+				healthCheck.OnUnhealthy = func(err error) {
+					notify.Send(appConfig, notify.UnhealthyEvent(healthCheck.Command, err).ForAgent(agentState), os.Stdout)
+				}
+				stopHealth := healthCheck.Start(os.Stdout, func(err error) {
+					stateMu.Lock()
+					agentState.RecordHealth(err, time.Now())
+					_ = mgr.MergeUpdate(agentState)
+					stateMu.Unlock()
+				})
+				defer stopHealth()
+			}
+
 			// Use iter-timeout for single iteration, or total timeout if only that is set
+			// (less any time spent waiting for the todo queue)
 			singleIterTimeout := iterTimeout
-			if singleIterTimeout == 0 && totalTimeout > 0 {
-				singleIterTimeout = totalTimeout
+			if deadline, ok := waitCtx.Deadline(); ok {
+				remaining := time.Until(deadline)
+				if remaining <= 0 {
+					timedOut = true
+					fmt.Printf("\n[swarm] Timed out after %v waiting for the todo queue\n", totalTimeout)
+					return nil
+				}
+				if singleIterTimeout == 0 || remaining < singleIterTimeout {
+					singleIterTimeout = remaining
+				}
 			}
 
 			// Generate a per-iteration agent ID and inject it into the prompt.
@@ -695,18 +1114,110 @@ Labels can be attached to agents for categorization and filtering using the
 			iterationPrompt = prompt.InjectIteration(iterationPrompt, 1, 1)
 
 			cfg := agent.Config{
-				Model:   effectiveModel,
-				Prompt:  iterationPrompt,
-				Command: appConfig.AgentCommand(),
-				Env:     expandedEnv,
-				Timeout: singleIterTimeout,
+				Model:    effectiveModel,
+				Prompt:   iterationPrompt,
+				Command:  appConfig.AgentCommand().WithImage(runImage).WithExtraArgs(runAgentArgs).WithParams(modelParams).WithReadOnly(runReadOnly),
+				Env:      singleEnv,
+				ExitFile: exitFile,
+				Run:      agent.RunInfo{Iteration: 1, TotalIterations: 1, TaskName: effectiveName, RunID: taskID},
+				Timeout:  singleIterTimeout,
+				// Keep the agent CLI's stderr out of the JSONL log
+				StderrFile:     detach.StderrLogPath(agentState.LogFile),
+				ResultCriteria: resultCriteria,
+				PathGuard:      pathGuard,
+				Dir:            agentDir,
+			}
+
+			// How to retry if the agent's context overflows
+			mitigation, retryPrompt, retryCommand := runner.ContextOverflowMitigation(promptContent, compactContent, cfg.Command)
+
+			// An iteration over budget may finish, but not run on for long
+			budgetCtx, cancelBudget := context.WithCancel(context.Background())
+			defer cancelBudget()
+			budgetGuard := agent.NewBudgetGuard(cancelBudget)
+			watchPodStatus := func(r *agent.Runner) {
+				r.SetPodStatusCallback(func(podName, phase string) {
+					stateMu.Lock()
+					agentState.PodName = podName
+					agentState.PodStatus = phase
+					_ = mgr.MergeUpdate(agentState)
+					stateMu.Unlock()
+				})
+			}
+			watchBudget := func(r *agent.Runner) {
+				if agentState.Budget == nil {
+					return
+				}
+				r.SetUsageCallback(func(stats logparser.UsageStats) {
+					cost := stats.TotalCostUSD
+					if cost == 0 {
+						cost = appConfig.GetPricing(effectiveModel).CalculateCost(stats.InputTokens, stats.OutputTokens)
+					}
+					if reason := agentState.Budget.Exceeded(cost, stats.InputTokens+stats.OutputTokens); reason != "" && budgetGuard.Exceeded(reason) {
+						fmt.Printf("\n[swarm] %s, stopping the agent in %v unless it finishes\n", reason, agent.BudgetGracePeriod)
+					}
+				})
 			}
 
 			runner := agent.NewRunner(cfg)
-			err = runner.Run(os.Stdout)
+			watchPodStatus(runner)
+			watchBudget(runner)
+			guard := prompt.SizeGuard{MaxTokens: appConfig.MaxPromptTokens, Fail: appConfig.FailOnPromptLimit()}
+			runGuarded := func() error {
+				if sizeErr := guard.Check(cfg.Prompt); sizeErr != nil {
+					if guard.Fail {
+						return sizeErr
+					}
+					fmt.Printf("[swarm] Warning: %v\n", sizeErr)
+				}
+				err := runner.RunWithContext(budgetCtx, os.Stdout)
+				if budgetErr := budgetGuard.Stop(); err != nil && budgetErr != nil {
+					return budgetErr
+				}
+				return err
+			}
+			err = runGuarded()
+			if err != nil && runner.ClassifyFailure(err) == agent.FailureContextOverflow {
+				// Retry once with a mitigation when the agent's context overflowed
+				stateMu.Lock()
+				agentState.ContextOverflows++
+				stateMu.Unlock()
+				if mitigation != "" {
+					fmt.Printf("\n[swarm] Context overflow, retrying with %s\n", mitigation)
+					cfg.Prompt = prompt.InjectIteration(prompt.InjectAgentID(retryPrompt, iterationAgentID), 1, 1)
+					cfg.Command = retryCommand
+					runner = agent.NewRunner(cfg)
+					watchPodStatus(runner)
+					watchBudget(runner)
+					err = runGuarded()
+				} else {
+					fmt.Println("\n[swarm] Context overflow (add a compact prompt variant to retry automatically)")
+				}
+			}
+			if wt != nil {
+				if wtErr := wt.FinishIteration(1, runMergeBack && err == nil); wtErr != nil {
+					fmt.Printf("[swarm] Warning: %v\n", wtErr)
+				}
+			}
+			stateMu.Lock()
+			agentState.RecordExitReport(runner.ExitReport())
+			if err := mgr.AppendHistory(agentState.ID, runner.IterationRecord(1, agentState.StartedAt, err, appConfig)); err != nil {
+				fmt.Printf("[swarm] Warning: failed to record iteration history: %v\n", err)
+			}
 			if err != nil {
 				agentState.FailedIters = 1
 				agentState.LastError = err.Error()
+				agentState.LastErrorClass = runner.ClassifyFailure(err)
+				agentState.ExitReason = agentState.LastErrorClass
+				if tail := runner.StderrTail(); len(tail) > 0 {
+					agentState.LastStderr = strings.Join(tail, "\n")
+				}
+			} else {
+				agentState.SuccessfulIters = 1
+			}
+			stateMu.Unlock()
+			if err != nil {
+				runner.PrintStderrTail(os.Stdout)
 				if strings.Contains(err.Error(), "timed out") {
 					timedOut = true
 					fmt.Printf("\n[swarm] %v\n", err)
@@ -714,7 +1225,6 @@ Labels can be attached to agents for categorization and filtering using the
 				}
 				return err
 			}
-			agentState.SuccessfulIters = 1
 			return nil
 		}
 
@@ -761,8 +1271,14 @@ Labels can be attached to agents for categorization and filtering using the
 				Status:        "running",
 				WorkingDir:    workingDir,
 				EnvNames:      envNames,
+				Image:         runImage,
+				AgentArgs:     runAgentArgs,
+				ModelParams:   modelParams,
 				TimeoutAt:     timeoutAt,
 				OnComplete:    effectiveOnComplete,
+				OnFailure:     runOnFailure,
+				OnTimeout:     runOnTimeout,
+				Budget:        budget,
 			}
 
 			if err := mgr.Register(agentState); err != nil {
@@ -785,16 +1301,32 @@ Labels can be attached to agents for categorization and filtering using the
 
 		// Run the multi-iteration loop
 		loopCfg := runner.LoopConfig{
-			Manager:           mgr,
-			AgentState:        agentState,
-			PromptContent:     promptContent,
-			Command:           appConfig.AgentCommand(),
-			Config:            appConfig,
-			Env:               expandedEnv,
-			Output:            os.Stdout,
-			StartingIteration: startingIteration,
-			TotalTimeout:      totalTimeout,
-			IterTimeout:       iterTimeout,
+			Manager:              mgr,
+			AgentState:           agentState,
+			PromptContent:        promptContent,
+			CompactPromptContent: compactContent,
+			Command:              appConfig.AgentCommand().WithImage(runImage).WithExtraArgs(runAgentArgs).WithParams(modelParams).WithReadOnly(runReadOnly),
+			Config:               appConfig,
+			Env:                  expandedEnv,
+			Output:               os.Stdout,
+			StartingIteration:    startingIteration,
+			TotalTimeout:         totalTimeout,
+			IterTimeout:          iterTimeout,
+			ResultCriteria:       resultCriteria,
+			PathGuard:            pathGuard,
+			HealthCheck:          healthCheck,
+			Dir:                  agentDir,
+		}
+		if runMaxPending > 0 {
+			loopCfg.MaxPending = runMaxPending
+			loopCfg.TodoDir, _ = appConfig.TodoQueueDirs(workingDir)
+		}
+		if wt != nil {
+			loopCfg.AfterIteration = func(iteration int, failed bool) {
+				if err := wt.FinishIteration(iteration, runMergeBack && !failed); err != nil {
+					fmt.Printf("[swarm] Warning: %v\n", err)
+				}
+			}
 		}
 
 		result, err := runner.RunLoop(loopCfg)
@@ -804,6 +1336,7 @@ Labels can be attached to agents for categorization and filtering using the
 
 		// Exit with timeout code if timed out
 		if result.TimedOut {
+			removeWorktree()
 			os.Exit(124) // Exit code 124 matches GNU timeout convention
 		}
 
@@ -811,6 +1344,67 @@ Labels can be attached to agents for categorization and filtering using the
 	},
 }
 
+// runAgentHooks runs a terminated agent's hooks, notifies about any that
+// failed on every attempt, and saves their outcomes to the agent's state.
+func runAgentHooks(mgr *state.Manager, agentState *state.AgentState) {
+	if err := agent.ExecuteHooks(agentState, agent.NewHookOptions(appConfig, os.Stdout)); err != nil {
+		fmt.Printf("[swarm] Warning: %v\n", err)
+		for _, run := range agentState.FailedHooks() {
+			notify.Send(appConfig, notify.HookFailedEvent(run).ForAgent(agentState), os.Stdout)
+		}
+	}
+	if len(agentState.HookRuns) > 0 {
+		_ = mgr.Update(agentState)
+	}
+}
+
+// waitForTodoRoom holds a single-iteration agent back while more than
+// --max-pending tasks wait in the todo queue. It returns false if ctx is done
+// (e.g. on the total timeout) or, with the agent's exit reason set, if the
+// agent is terminated while waiting.
+func waitForTodoRoom(ctx context.Context, mgr *state.Manager, agentState *state.AgentState, workingDir string) bool {
+	todoDir, _ := appConfig.TodoQueueDirs(workingDir)
+	waited := false
+	hasRoom := todoqueue.WaitForRoom(ctx, todoDir, runMaxPending, func(pending int) {
+		waited = true
+		fmt.Printf("[swarm] %d tasks pending (max_pending %d), waiting for doers to catch up...\n", pending, runMaxPending)
+	}, func() bool {
+		current, err := mgr.Get(agentState.ID)
+		if err != nil || current == nil || current.TerminateMode == "" {
+			return false
+		}
+		agentState.ExitReason = current.TerminationExitReason()
+		return true
+	})
+	if hasRoom && waited {
+		fmt.Println("[swarm] Todo queue has room, continuing")
+	}
+	return hasRoom
+}
+
+// paramArgs returns the --param flags that pass model params to a child
+// 'swarm run', sorted by name.
+func paramArgs(params map[string]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names))
+	for _, name := range names {
+		args = append(args, "--param="+name+"="+params[name])
+	}
+	return args
+}
+
+// warnUnsupportedParams warns about model params the backend can't pass to
+// its agent CLI.
+func warnUnsupportedParams(cfg *config.Config, params map[string]string) {
+	for _, name := range cfg.AgentCommand().UnsupportedParams(params) {
+		fmt.Fprintf(os.Stderr, "Warning: the %s backend can't pass the %s param to its agent CLI; ignoring it (set command.param_args or command.param_env in swarm.toml)\n", cfg.Backend, name)
+	}
+}
+
 func init() {
 	runCmd.Flags().StringVarP(&runModel, "model", "m", "", "Model to use for the agent (overrides config)")
 	runCmd.Flags().StringVarP(&runPrompt, "prompt", "p", "", "Prompt name (from prompts directory)")
@@ -830,16 +1424,21 @@ func init() {
 	runCmd.Flags().MarkHidden("_internal-task-id")
 	runCmd.Flags().StringVar(&runInternalStdin, "_internal-stdin", "", "Internal flag for passing stdin content to detached child")
 	runCmd.Flags().MarkHidden("_internal-stdin")
+	runCmd.Flags().StringVar(&runInternalComposeStdin, "_internal-compose-stdin", "", "Internal flag for passing 'swarm up --stdin' content to detached child")
+	runCmd.Flags().MarkHidden("_internal-compose-stdin")
 	runCmd.Flags().StringArrayVar(&runInternalEnv, "_internal-env", nil, "Internal flag for passing env vars to detached child")
 	runCmd.Flags().MarkHidden("_internal-env")
 	runCmd.Flags().StringVar(&runInternalTimeout, "_internal-timeout", "", "Internal flag for passing timeout to detached child")
 	runCmd.Flags().MarkHidden("_internal-timeout")
 	runCmd.Flags().StringVar(&runInternalIterTimeout, "_internal-iter-timeout", "", "Internal flag for passing iter-timeout to detached child")
 	runCmd.Flags().MarkHidden("_internal-iter-timeout")
+	runCmd.Flags().StringVar(&runContinue, "continue", "", "Continue a terminated agent after its last completed iteration")
 	runCmd.Flags().IntVar(&runInternalStartIter, "_internal-start-iter", 0, "Internal flag for passing start iteration to detached child")
 	runCmd.Flags().MarkHidden("_internal-start-iter")
 	runCmd.Flags().StringVarP(&runWorkingDir, "working-dir", "C", "", "Run agent in specified directory")
 	runCmd.Flags().StringVar(&runOnComplete, "on-complete", "", "Command to run when agent completes")
+	runCmd.Flags().StringVar(&runOnFailure, "on-failure", "", "Command to run when agent ends in failure (failed final iteration, crash, or budget)")
+	runCmd.Flags().StringVar(&runOnTimeout, "on-timeout", "", "Command to run when agent times out")
 	runCmd.Flags().StringVar(&runInternalOnComplete, "_internal-on-complete", "", "Internal flag for passing on-complete to detached child")
 	runCmd.Flags().MarkHidden("_internal-on-complete")
 	runCmd.Flags().StringArrayVarP(&runLabels, "label", "l", nil, "Label to attach (key=value format, can be repeated)")
@@ -847,10 +1446,26 @@ func init() {
 	runCmd.Flags().MarkHidden("_internal-label")
 	runCmd.Flags().StringVar(&runPrefix, "prefix", "", "Content to prepend to the prompt")
 	runCmd.Flags().StringVar(&runSuffix, "suffix", "", "Content to append to the prompt")
+	runCmd.Flags().BoolVar(&runEnvSummary, "env-summary", false, "Prepend a summary of the host environment (OS, tools, repo languages) to the prompt")
+	runCmd.Flags().StringVar(&runFailOnSubtype, "fail-on-subtype", "", "Fail an iteration whose result event has one of these subtypes, comma-separated (e.g. error matches error_max_turns)")
+	runCmd.Flags().StringVar(&runFailOnResultRegex, "fail-on-result-regex", "", "Fail an iteration whose result text matches this regex")
+	runCmd.Flags().StringArrayVar(&runAllowedPaths, "allowed-path", nil, "Glob of files the agent may edit, relative to its working directory (repeatable, e.g. 'docs/**')")
+	runCmd.Flags().StringArrayVar(&runDeniedPaths, "denied-path", nil, "Glob of files the agent may not edit (repeatable)")
+	runCmd.Flags().BoolVar(&runReadOnly, "read-only", false, "Fail and stop the agent if it changes any file in its working directory")
+	runCmd.Flags().BoolVar(&runWorktree, "worktree", false, "Run the agent in its own git worktree and swarm/<agent> branch")
+	runCmd.Flags().BoolVar(&runMergeBack, "merge-back", false, "With --worktree, merge the agent's branch back after each successful iteration")
+	runCmd.Flags().Float64Var(&runBudgetUSD, "budget-usd", 0, "Stop the agent once it has cost this much (USD)")
+	runCmd.Flags().Int64Var(&runBudgetTokens, "budget-tokens", 0, "Stop the agent once it has used this many tokens")
+	runCmd.Flags().IntVar(&runMaxPending, "max-pending", 0, "Wait before each iteration while more than this many tasks wait in the todo queue")
+	runCmd.Flags().StringVar(&runHealthCmd, "health-cmd", "", "Shell command run periodically while the agent is active; the agent is marked unhealthy when it fails")
+	runCmd.Flags().DurationVar(&runHealthInterval, "health-interval", 0, "Time between health checks (default 30s)")
 	runCmd.Flags().StringVar(&runInternalPrefix, "_internal-prefix", "", "Internal flag for passing prefix to detached child")
 	runCmd.Flags().MarkHidden("_internal-prefix")
 	runCmd.Flags().StringVar(&runInternalSuffix, "_internal-suffix", "", "Internal flag for passing suffix to detached child")
 	runCmd.Flags().MarkHidden("_internal-suffix")
+	runCmd.Flags().StringVar(&runImage, "image", "", "Run the agent inside this container image (e.g., golang:1.22)")
+	runCmd.Flags().StringArrayVar(&runAgentArgs, "agent-arg", nil, "Extra argument to pass through to the agent CLI (can be repeated, e.g. --agent-arg=--max-turns --agent-arg=5)")
+	runCmd.Flags().StringArrayVar(&runParams, "param", nil, "Model param in key=value format: temperature, max_output_tokens, or thinking_budget (can be repeated)")
 	runCmd.Flags().StringVarP(&runParent, "parent", "P", "", "Parent task ID (for creating sub-agents)")
 	runCmd.Flags().StringVar(&runInternalParent, "_internal-parent", "", "Internal flag for passing parent ID to detached child")
 	runCmd.Flags().MarkHidden("_internal-parent")
@@ -861,4 +1476,6 @@ func init() {
 	// Add dynamic completion for prompt and model flags
 	runCmd.RegisterFlagCompletionFunc("prompt", completePromptName)
 	runCmd.RegisterFlagCompletionFunc("model", completeModelName)
+	runCmd.RegisterFlagCompletionFunc("label", completeLabel)
+	runCmd.RegisterFlagCompletionFunc("continue", completeAgentIdentifier)
 }
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/prompt"
+	"github.com/mj1618/swarm-cli/internal/runner"
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runContinue is the terminated agent that 'swarm run --continue' resumes.
+var runContinue string
+
+// continueFlags are the run flags that may be combined with --continue; the
+// rest of the agent's configuration comes from its state.
+var continueFlags = map[string]bool{
+	"continue":     true,
+	"iterations":   true,
+	"forever":      true,
+	"model":        true,
+	"detach":       true,
+	"env":          true,
+	"timeout":      true,
+	"iter-timeout": true,
+}
+
+// runContinueAgent resumes a terminated multi-iteration agent after the last
+// iteration it finished, under the same ID, with its original prompt and
+// its usage so far.
+func runContinueAgent(cmd *cobra.Command, identifier string) error {
+	var badFlag string
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed && !continueFlags[f.Name] && !strings.HasPrefix(f.Name, "_internal-") && badFlag == "" {
+			badFlag = f.Name
+		}
+	})
+	if badFlag != "" {
+		return fmt.Errorf("--%s can't be combined with --continue, which reuses the agent's configuration", badFlag)
+	}
+
+	workingDir, err := scope.CurrentWorkingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	mgr, err := state.NewManagerWithScope(GetScope(), workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize state manager: %w", err)
+	}
+	agentState, err := ResolveAgentIdentifier(mgr, identifier)
+	if err != nil {
+		return err
+	}
+
+	effectiveIterations := agentState.Iterations
+	if runForever {
+		effectiveIterations = 0
+	} else if cmd.Flags().Changed("iterations") {
+		effectiveIterations = runIterations
+	}
+	if runForever && cmd.Flags().Changed("iterations") && runIterations > 0 {
+		return fmt.Errorf("cannot use --forever with --iterations (use -n 0 for unlimited)")
+	}
+
+	// The detached child continues the agent its parent already revived
+	startingIteration := runInternalStartIter
+	if !runInternalDetached {
+		if agentState.Status != "terminated" {
+			return fmt.Errorf("agent %s is not terminated (status: %s)", agentState.ID, agentState.Status)
+		}
+		history, err := mgr.History(agentState.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read iteration history: %w", err)
+		}
+		startingIteration = lastCompletedIteration(agentState, history) + 1
+		if effectiveIterations > 0 && startingIteration > effectiveIterations {
+			return fmt.Errorf("agent %s already completed all %d iterations; use --iterations to run more", agentState.ID, agentState.Iterations)
+		}
+	}
+
+	promptsDir, err := GetPromptsDir()
+	if err != nil {
+		return fmt.Errorf("failed to get prompts directory: %w", err)
+	}
+	if GetScope() == scope.ScopeProject && agentState.WorkingDir != "" {
+		promptsDir = filepath.Join(agentState.WorkingDir, "swarm", "prompts")
+	}
+	promptContent, compactContent, err := continuePrompt(agentState, promptsDir)
+	if err != nil {
+		return err
+	}
+	promptContent = prompt.InjectTaskID(promptContent, agentState.ID)
+	if compactContent != "" {
+		compactContent = prompt.InjectTaskID(compactContent, agentState.ID)
+	}
+	if agentState.ParentID != "" {
+		promptContent = prompt.InjectSubAgentRestriction(promptContent, agentState.ParentID)
+		if compactContent != "" {
+			compactContent = prompt.InjectSubAgentRestriction(compactContent, agentState.ParentID)
+		}
+	}
+
+	// Environment values aren't stored, so they must be passed again
+	expandedEnv := runInternalEnv
+	if !runInternalDetached {
+		expandedEnv, err = expandEnvFlags(runEnv)
+		if err != nil {
+			return err
+		}
+		if len(runEnv) == 0 && len(agentState.EnvNames) > 0 {
+			fmt.Printf("Note: environment values aren't stored; pass %s again with -e\n", strings.Join(agentState.EnvNames, ", "))
+		}
+	}
+
+	effectiveTimeout, effectiveIterTimeout := runTimeout, runIterTimeout
+	if runInternalDetached {
+		effectiveTimeout, effectiveIterTimeout = runInternalTimeout, runInternalIterTimeout
+	} else {
+		if effectiveTimeout == "" {
+			effectiveTimeout = appConfig.Timeout
+		}
+		if effectiveIterTimeout == "" {
+			effectiveIterTimeout = appConfig.IterTimeout
+		}
+	}
+	var totalTimeout, iterTimeout time.Duration
+	if effectiveTimeout != "" {
+		if totalTimeout, err = time.ParseDuration(effectiveTimeout); err != nil || totalTimeout < 0 {
+			return fmt.Errorf("invalid timeout %q", effectiveTimeout)
+		}
+	}
+	if effectiveIterTimeout != "" {
+		if iterTimeout, err = time.ParseDuration(effectiveIterTimeout); err != nil || iterTimeout < 0 {
+			return fmt.Errorf("invalid iter-timeout %q", effectiveIterTimeout)
+		}
+	}
+
+	// Revive the agent: the run restarts the clock for timeouts and
+	// max_agent_age, and keeps the history, log, and usage so far
+	if !runInternalDetached {
+		agentState.Status = "running"
+		agentState.TerminatedAt = nil
+		agentState.ExitReason = ""
+		agentState.TerminateMode = ""
+		agentState.TimeoutReason = ""
+		agentState.Paused = false
+		agentState.PausedAt = nil
+		agentState.ResumeAt = nil
+		agentState.MaxAgeExceededAt = nil
+		agentState.HookRuns = nil
+		agentState.StartedAt = time.Now()
+		agentState.Iterations = effectiveIterations
+		agentState.CurrentIter = startingIteration - 1
+		if cmd.Flags().Changed("model") {
+			agentState.Model = runModel
+		}
+		if len(runEnv) > 0 {
+			agentState.EnvNames = envNames(expandedEnv)
+		}
+		agentState.TimeoutAt = nil
+		if totalTimeout > 0 {
+			t := time.Now().Add(totalTimeout)
+			agentState.TimeoutAt = &t
+		}
+	}
+
+	if runDetach && !runInternalDetached {
+		logFile := agentState.LogFile
+		if logFile == "" {
+			if logFile, err = detach.LogFilePath(agentState.ID); err != nil {
+				return fmt.Errorf("failed to create log file path: %w", err)
+			}
+		}
+		detachedArgs := []string{"run", "--continue", agentState.ID, "--_internal-detached",
+			"--_internal-start-iter", strconv.Itoa(startingIteration)}
+		if globalFlag {
+			detachedArgs = append(detachedArgs, "--global")
+		}
+		for _, e := range expandedEnv {
+			detachedArgs = append(detachedArgs, "--_internal-env", e)
+		}
+		if effectiveTimeout != "" {
+			detachedArgs = append(detachedArgs, "--_internal-timeout", effectiveTimeout)
+		}
+		if effectiveIterTimeout != "" {
+			detachedArgs = append(detachedArgs, "--_internal-iter-timeout", effectiveIterTimeout)
+		}
+
+		// Update state before starting the child, which reads it
+		agentState.PID = 0
+		agentState.LogFile = logFile
+		if err := mgr.Update(agentState); err != nil {
+			return fmt.Errorf("failed to update agent state: %w", err)
+		}
+		pid, err := detach.StartDetached(detachedArgs, logFile, agentState.WorkingDir)
+		if err != nil {
+			agentState.Status = "terminated"
+			now := time.Now()
+			agentState.TerminatedAt = &now
+			agentState.ExitReason = "crashed"
+			_ = mgr.Update(agentState)
+			return fmt.Errorf("failed to start detached process: %w", err)
+		}
+		if err := mgr.SetPID(agentState.ID, pid); err != nil {
+			return fmt.Errorf("failed to update agent PID: %w", err)
+		}
+
+		fmt.Printf("Continuing detached agent: %s (PID: %d)\n", agentState.ID, pid)
+		fmt.Printf("Name: %s\n", agentState.Name)
+		fmt.Printf("Iterations: %s (starting from %d)\n", iterationsLabel(effectiveIterations), startingIteration)
+		fmt.Printf("Log file: %s\n", logFile)
+		return nil
+	}
+
+	agentState.PID = os.Getpid()
+	if err := mgr.Update(agentState); err != nil {
+		return fmt.Errorf("failed to update agent state: %w", err)
+	}
+
+	fmt.Printf("Continuing agent '%s' from iteration %d, prompt: %s, model: %s, iterations: %s\n",
+		agentState.Name, startingIteration, agentState.Prompt, agentState.Model, iterationsLabel(effectiveIterations))
+
+	result, err := runner.RunLoop(runner.LoopConfig{
+		Manager:              mgr,
+		AgentState:           agentState,
+		PromptContent:        promptContent,
+		CompactPromptContent: compactContent,
+		Command:              appConfig.AgentCommand().WithImage(agentState.Image).WithExtraArgs(agentState.AgentArgs).WithParams(agentState.ModelParams),
+		Config:               appConfig,
+		Env:                  expandedEnv,
+		Output:               os.Stdout,
+		StartingIteration:    startingIteration,
+		TotalTimeout:         totalTimeout,
+		IterTimeout:          iterTimeout,
+		Dir:                  agentState.WorkingDir,
+	})
+	if err != nil {
+		return err
+	}
+	if result.TimedOut {
+		os.Exit(124) // Exit code 124 matches GNU timeout convention
+	}
+	return nil
+}
+
+// lastCompletedIteration returns the last iteration an agent finished,
+// successfully or not: the latest in its history, or for an agent without
+// one, the number of iterations it finished.
+func lastCompletedIteration(a *state.AgentState, history []state.IterationRecord) int {
+	last := 0
+	for _, record := range history {
+		if record.Iteration > last {
+			last = record.Iteration
+		}
+	}
+	if last == 0 {
+		last = a.SuccessfulIters + a.FailedIters
+	}
+	return last
+}
+
+// continuePrompt loads an agent's original prompt and its compact variant
+// (empty for inline and stdin prompts, which have none).
+func continuePrompt(a *state.AgentState, promptsDir string) (string, string, error) {
+	name := a.Prompt
+	stored := name == "<string>" || name == "<stdin>" || strings.HasSuffix(name, "+stdin")
+	if stored && a.PromptContent == "" {
+		return "", "", fmt.Errorf("cannot continue agent with prompt source %q (prompt content not stored)", name)
+	}
+
+	switch {
+	case name == "<string>":
+		return prompt.WrapPromptString(a.PromptContent), "", nil
+	case name == "<stdin>":
+		return a.PromptContent, "", nil
+	case strings.HasSuffix(name, "+stdin"):
+		base, err := prompt.LoadPrompt(promptsDir, strings.TrimSuffix(name, "+stdin"))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load prompt: %w", err)
+		}
+		return prompt.CombinePrompts(base, a.PromptContent), "", nil
+	case strings.Contains(name, "/"):
+		path := name
+		if !filepath.IsAbs(path) && a.WorkingDir != "" {
+			path = filepath.Join(a.WorkingDir, path)
+		}
+		content, err := prompt.LoadPromptFromFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load prompt file: %w", err)
+		}
+		compact, err := prompt.LoadCompactPromptFromFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load compact prompt variant: %w", err)
+		}
+		return content, compact, nil
+	default:
+		content, err := prompt.LoadPrompt(promptsDir, name)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load prompt: %w", err)
+		}
+		compact, err := prompt.LoadCompactPrompt(promptsDir, name)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load compact prompt variant: %w", err)
+		}
+		return content, compact, nil
+	}
+}
+
+// expandEnvFlags expands -e flags (KEY=VALUE, or KEY to pass from the shell)
+// into KEY=VALUE pairs.
+func expandEnvFlags(specs []string) ([]string, error) {
+	env := make([]string, 0, len(specs))
+	for _, e := range specs {
+		if strings.Contains(e, "=") {
+			env = append(env, e)
+		} else if val, ok := os.LookupEnv(e); ok {
+			env = append(env, fmt.Sprintf("%s=%s", e, val))
+		} else {
+			return nil, fmt.Errorf("environment variable %s not set", e)
+		}
+	}
+	return env, nil
+}
+
+// envNames returns the names of KEY=VALUE pairs.
+func envNames(env []string) []string {
+	var names []string
+	for _, e := range env {
+		if idx := strings.Index(e, "="); idx > 0 {
+			names = append(names, e[:idx])
+		}
+	}
+	return names
+}
+
+// iterationsLabel returns an iteration count for display.
+func iterationsLabel(iterations int) string {
+	if iterations == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(iterations)
+}
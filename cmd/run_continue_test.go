@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestLastCompletedIteration(t *testing.T) {
+	tests := []struct {
+		name    string
+		agent   state.AgentState
+		history []state.IterationRecord
+		want    int
+	}{
+		{"no iterations", state.AgentState{CurrentIter: 1}, nil, 0},
+		{"crashed mid-iteration", state.AgentState{CurrentIter: 4, SuccessfulIters: 2, FailedIters: 1},
+			[]state.IterationRecord{{Iteration: 1}, {Iteration: 2}, {Iteration: 3}}, 3},
+		{"continued before", state.AgentState{CurrentIter: 8, SuccessfulIters: 3},
+			[]state.IterationRecord{{Iteration: 5}, {Iteration: 6}, {Iteration: 7}}, 7},
+		{"no history", state.AgentState{CurrentIter: 3, SuccessfulIters: 1, FailedIters: 1}, nil, 2},
+	}
+	for _, tt := range tests {
+		if got := lastCompletedIteration(&tt.agent, tt.history); got != tt.want {
+			t.Errorf("%s: lastCompletedIteration = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContinuePrompt(t *testing.T) {
+	promptsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(promptsDir, "reviewer.md"), []byte("Review the diff."), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _, err := continuePrompt(&state.AgentState{Prompt: "<string>", PromptContent: "Fix the bug"}, promptsDir)
+	if err != nil || !strings.Contains(content, "Fix the bug") {
+		t.Errorf("inline prompt = %q, %v", content, err)
+	}
+
+	content, _, err = continuePrompt(&state.AgentState{Prompt: "reviewer+stdin", PromptContent: "diff --git"}, promptsDir)
+	if err != nil || !strings.Contains(content, "Review the diff.") || !strings.Contains(content, "diff --git") {
+		t.Errorf("stdin with named prompt = %q, %v", content, err)
+	}
+
+	content, _, err = continuePrompt(&state.AgentState{Prompt: "reviewer"}, promptsDir)
+	if err != nil || !strings.Contains(content, "Review the diff.") {
+		t.Errorf("named prompt = %q, %v", content, err)
+	}
+
+	if _, _, err := continuePrompt(&state.AgentState{Prompt: "<stdin>"}, promptsDir); err == nil {
+		t.Error("expected an error for a stdin prompt without stored content")
+	}
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var runsFormat string
+var runsLast int
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "List and inspect pipeline runs",
+	Long: `List and inspect pipeline runs.
+
+Each execution of a pipeline gets a run ID, recorded in state together with
+the outcome, duration, and usage of every task it ran and the output
+directory of each iteration. Detached pipeline agents are tagged with the
+run ID (see 'swarm inspect').`,
+	Example: `  # List pipeline runs in the current project
+  swarm runs list
+
+  # Show the tasks, outputs, and costs of a run
+  swarm runs show 3f2a9c1b`,
+}
+
+var runsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List pipeline runs",
+	Long: `List pipeline runs, oldest first.
+
+By default, lists runs started in the current project.
+Use --global to list runs from all directories.`,
+	Example: `  # List runs in the current project
+  swarm runs list
+
+  # Show the 5 most recent runs
+  swarm runs list -n 5
+
+  # Output as JSON
+  swarm runs list --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		runs, err := mgr.ListRuns()
+		if err != nil {
+			return fmt.Errorf("failed to list runs: %w", err)
+		}
+		if runsLast > 0 && len(runs) > runsLast {
+			runs = runs[len(runs)-runsLast:]
+		}
+
+		if runsFormat == "json" {
+			if runs == nil {
+				runs = []*state.RunState{}
+			}
+			output, err := json.MarshalIndent(runs, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+
+		if len(runs) == 0 {
+			fmt.Println("No pipeline runs found")
+			return nil
+		}
+
+		header := color.New(color.Bold)
+		header.Printf("%-10s  %-20s  %-10s  %-10s  %-6s  %-8s  %-10s  %s\n",
+			"RUN ID", "PIPELINE", "STATUS", "ITERATION", "TASKS", "COST", "DURATION", "STARTED")
+		for _, run := range runs {
+			pipeline := run.Pipeline
+			if len(pipeline) > 20 {
+				pipeline = pipeline[:17] + "..."
+			}
+			fmt.Printf("%-10s  %-20s  ", run.ID, pipeline)
+			runStatusColor(run.Status).Printf("%-10s", run.Status)
+			fmt.Printf("  %-10s  %-6d  %-8s  %-10s  %s ago\n",
+				formatRunIterations(run), len(run.Tasks), fmt.Sprintf("$%.2f", run.TotalCost),
+				formatTopDuration(runDuration(run)), formatTopDuration(time.Since(run.StartedAt)))
+		}
+		return nil
+	},
+}
+
+var runsShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show details of a pipeline run",
+	Long: `Show details of a pipeline run: its outcome, every task execution with
+duration and usage, the output directory of each iteration, and the agents
+that executed it.
+
+The run ID may be abbreviated to a unique prefix.`,
+	Example: `  # Show a run
+  swarm runs show 3f2a9c1b
+
+  # Output as JSON
+  swarm runs show 3f2a --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		run, err := mgr.GetRun(args[0])
+		if err != nil {
+			return err
+		}
+
+		if runsFormat == "json" {
+			output, err := json.MarshalIndent(run, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+
+		bold := color.New(color.Bold)
+		bold.Println("Run Details")
+		fmt.Println("─────────────────────────────────")
+		fmt.Printf("Run ID:        %s\n", run.ID)
+		if run.Pipeline != "" {
+			fmt.Printf("Pipeline:      %s\n", run.Pipeline)
+		}
+		fmt.Print("Status:        ")
+		runStatusColor(run.Status).Println(run.Status)
+		if run.Error != "" {
+			fmt.Printf("Error:         %s\n", run.Error)
+		}
+		fmt.Printf("Started:       %s\n", run.StartedAt.Format(time.RFC3339))
+		if run.FinishedAt != nil {
+			fmt.Printf("Finished:      %s\n", run.FinishedAt.Format(time.RFC3339))
+		}
+		fmt.Printf("Duration:      %s\n", formatTopDuration(runDuration(run)))
+		fmt.Printf("Iterations:    %s\n", formatRunIterations(run))
+		if run.WorkingDir != "" {
+			fmt.Printf("Directory:     %s\n", run.WorkingDir)
+		}
+		if run.AgentID != "" {
+			fmt.Printf("Agent:         %s\n", run.AgentID)
+		}
+		fmt.Printf("Tokens:        %s in / %s out\n", formatTokenCount(run.InputTokens), formatTokenCount(run.OutputTokens))
+		fmt.Printf("Cost:          $%.4f\n", run.TotalCost)
+
+		if len(run.Tasks) > 0 {
+			fmt.Println()
+			bold.Println("Tasks")
+			fmt.Printf("  %-4s  %-20s  %-10s  %-10s  %-8s  %-8s  %s\n", "ITER", "TASK", "STATUS", "DURATION", "IN", "OUT", "COST")
+			for _, t := range run.Tasks {
+				fmt.Printf("  %-4d  %-20s  ", t.Iteration, t.Task)
+				runStatusColor(t.Status).Printf("%-10s", t.Status)
+				fmt.Printf("  %-10s  %-8s  %-8s  $%.4f\n", formatTopDuration(t.Duration),
+					formatTokenCount(t.InputTokens), formatTokenCount(t.OutputTokens), t.TotalCost)
+				if t.Mitigation != "" {
+					fmt.Printf("        context overflow, retried with %s\n", t.Mitigation)
+				}
+				if t.Error != "" {
+					fmt.Printf("        %s\n", t.Error)
+				}
+			}
+		}
+
+		if len(run.OutputDirs) > 0 {
+			fmt.Println()
+			bold.Println("Outputs")
+			for i, dir := range run.OutputDirs {
+				fmt.Printf("  %d: %s\n", i+1, dir)
+			}
+		}
+
+		agents, err := mgr.List(false)
+		if err == nil {
+			var runAgents []*state.AgentState
+			for _, a := range agents {
+				if a.RunID == run.ID {
+					runAgents = append(runAgents, a)
+				}
+			}
+			if len(runAgents) > 0 {
+				fmt.Println()
+				bold.Println("Agents")
+				for _, a := range runAgents {
+					fmt.Printf("  %s  %s  %s\n", a.ID, a.Name, a.LogFile)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// runDuration returns how long a run took, or has been running so far.
+func runDuration(run *state.RunState) time.Duration {
+	if run.FinishedAt != nil {
+		return run.FinishedAt.Sub(run.StartedAt)
+	}
+	return time.Since(run.StartedAt)
+}
+
+// formatRunIterations formats a run's completed and planned iterations.
+func formatRunIterations(run *state.RunState) string {
+	if run.Iterations == 0 {
+		return fmt.Sprintf("%d/∞", run.CompletedIterations)
+	}
+	return fmt.Sprintf("%d/%d", run.CompletedIterations, run.Iterations)
+}
+
+// runStatusColor returns the color for a run or task status.
+func runStatusColor(status string) *color.Color {
+	switch status {
+	case "running":
+		return color.New(color.FgGreen)
+	case "completed", "succeeded":
+		return color.New(color.FgCyan)
+	case "failed", "crashed":
+		return color.New(color.FgRed)
+	default:
+		return color.New(color.FgYellow)
+	}
+}
+
+func init() {
+	runsCmd.PersistentFlags().StringVar(&runsFormat, "format", "", "Output format: json or table (default)")
+	runsListCmd.Flags().IntVarP(&runsLast, "last", "n", 0, "Show only the N most recent runs")
+	runsCmd.AddCommand(runsListCmd)
+	runsCmd.AddCommand(runsShowCmd)
+	rootCmd.AddCommand(runsCmd)
+}
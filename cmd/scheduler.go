@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schedulerFile    string
+	schedulerList    bool
+	schedulerLenient bool
+)
+
+var schedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Start pipelines and tasks on their cron schedules",
+	Long: `Run in the foreground and start the compose file's pipelines and tasks
+at the times given by their schedule field, a cron expression:
+
+  pipelines:
+    nightly-refactor:
+      schedule: "0 2 * * *"      # 02:00 every day
+      iterations: 5
+  tasks:
+    weekly-report:
+      prompt: report
+      schedule: "0 9 * * mon"    # 09:00 every Monday
+
+Schedules have five fields (minute, hour, day of month, month, day of week)
+with *, lists (1,15), ranges (mon-fri), and steps (*/15), or a macro:
+@hourly, @daily, @weekly, @monthly, @yearly. Times are local.
+
+Each run is started like 'swarm up -d <name>', so it shows up in 'swarm list'
+and 'swarm top' as usual. Runs of a schedule never overlap, whatever the
+task's concurrency: 'swarm up -d' doesn't start a pipeline or task whose agents
+are still running, so if the previous run is still going when the schedule
+fires again, that run is skipped and recorded as such. Runs missed while the
+scheduler wasn't running are not made up.
+
+The compose file is re-read every minute, so edited schedules apply without a
+restart. Each schedule's last run, its result (started, skipped, or failed),
+and its next run are kept in swarm's state; --list shows them.
+
+The scheduler stops on Ctrl+C. To keep it running, start it from a service
+manager (e.g. a systemd user unit) or a terminal multiplexer.`,
+	Example: `  # Run the scheduler for ./swarm/swarm.yaml
+  swarm scheduler
+
+  # Show schedules with their last and next runs
+  swarm scheduler --list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workingDir, err := scope.CurrentWorkingDir()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		file, err := filepath.Abs(schedulerFile)
+		if err != nil {
+			return err
+		}
+		cf, err := loadComposeFileWithOptions(file, compose.LoadOptions{Lenient: schedulerLenient})
+		if err != nil {
+			return err
+		}
+		if err := cf.Validate(); err != nil {
+			return fmt.Errorf("invalid compose file: %w", err)
+		}
+		mgr, err := state.NewManagerWithScope(GetScope(), workingDir)
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		if schedulerList {
+			return printSchedules(mgr, workingDir, cf.GetScheduled(), time.Now())
+		}
+		if len(cf.GetScheduled()) == 0 {
+			return fmt.Errorf("no pipelines or tasks in %s have a schedule", schedulerFile)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		s := &scheduler{mgr: mgr, workingDir: workingDir, file: file, cf: cf,
+			next: make(map[string]time.Time), exprs: make(map[string]string)}
+		fmt.Printf("Scheduler started for %s (Ctrl+C to stop)\n", schedulerFile)
+		s.run(ctx)
+		fmt.Println("Scheduler stopped")
+		return nil
+	},
+}
+
+// scheduler starts a compose file's scheduled pipelines and tasks.
+type scheduler struct {
+	mgr        *state.Manager
+	workingDir string
+	file       string
+	cf         *compose.ComposeFile
+
+	// next is when each schedule, by key, fires next
+	next map[string]time.Time
+	// exprs is the expression each next time was computed from
+	exprs map[string]string
+}
+
+// run checks the schedules every minute until ctx is done.
+func (s *scheduler) run(ctx context.Context) {
+	for {
+		now := time.Now()
+		s.tick(now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(now.Truncate(time.Minute).Add(time.Minute))):
+		}
+	}
+}
+
+// tick reloads the compose file and starts the runs that are due at now.
+func (s *scheduler) tick(now time.Time) {
+	if cf, err := loadComposeFileWithOptions(s.file, compose.LoadOptions{Lenient: schedulerLenient}); err != nil {
+		s.logf(now, "Warning: keeping the previous schedules: %v", err)
+	} else if err := cf.Validate(); err != nil {
+		s.logf(now, "Warning: keeping the previous schedules: invalid compose file: %v", err)
+	} else {
+		s.cf = cf
+	}
+
+	records, err := s.mgr.Schedules(s.workingDir)
+	if err != nil {
+		s.logf(now, "Warning: %v", err)
+		records = map[string]state.ScheduleRecord{}
+	}
+
+	for _, sc := range s.cf.GetScheduled() {
+		key := sc.Key()
+		record := records[key]
+		next, known := s.next[key]
+		switch {
+		case !known || s.exprs[key] != sc.Schedule.String():
+			// New or changed schedule: wait for its next time
+		case !next.After(now):
+			s.trigger(sc, &record, now)
+		default:
+			continue
+		}
+
+		next = sc.Schedule.Next(now)
+		s.next[key], s.exprs[key] = next, sc.Schedule.String()
+		record.Schedule = sc.Schedule.String()
+		record.NextRun = nil
+		if !next.IsZero() {
+			record.NextRun = &next
+		}
+		if err := s.mgr.UpdateSchedule(s.workingDir, key, record); err != nil {
+			s.logf(now, "Warning: failed to record schedule of %s: %v", key, err)
+		}
+	}
+}
+
+// trigger starts a run of sc unless its previous run is still going, and
+// records the outcome. 'swarm up -d' wouldn't start the run's agents while
+// they're running anyway, so the check makes the skip visible in the record.
+func (s *scheduler) trigger(sc compose.Scheduled, record *state.ScheduleRecord, now time.Time) {
+	record.LastRun = &now
+	record.LastError = ""
+
+	if running := s.runningAgent(sc); running != nil {
+		record.LastResult = state.ScheduleSkipped
+		record.LastError = fmt.Sprintf("previous run still running (%s)", running.ID)
+		s.logf(now, "Skipped %s: %s", sc.Key(), record.LastError)
+		return
+	}
+
+	args := []string{"up", "--detach", "--file", s.file}
+	if sc.Kind == "pipeline" {
+		args = append(args, "--pipeline", sc.Name)
+	} else {
+		args = append(args, sc.Name)
+	}
+	if globalFlag {
+		args = append(args, "--global")
+	}
+	if schedulerLenient {
+		args = append(args, "--lenient")
+	}
+
+	output, err := s.runSwarm(args)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines {
+		if line != "" {
+			s.logf(now, "  %s", line)
+		}
+	}
+	if err != nil {
+		record.LastResult = state.ScheduleFailed
+		record.LastError = err.Error()
+		if last := lines[len(lines)-1]; last != "" {
+			record.LastError = last
+		}
+		s.logf(now, "Failed to start %s: %v", sc.Key(), err)
+		return
+	}
+	record.LastResult = state.ScheduleStarted
+	s.logf(now, "Started %s", sc.Key())
+}
+
+// runSwarm runs this swarm binary with args in the working directory.
+func (s *scheduler) runSwarm(args []string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	c := exec.Command(exe, args...)
+	c.Dir = s.workingDir
+	out, err := c.CombinedOutput()
+	return string(out), err
+}
+
+// runningAgent returns a running agent of a previous run of sc, or nil.
+func (s *scheduler) runningAgent(sc compose.Scheduled) *state.AgentState {
+	agents, err := s.mgr.List(true)
+	if err != nil {
+		return nil
+	}
+	names := make(map[string]bool)
+	if sc.Kind == "task" {
+		if selected, err := s.cf.WithDependencies([]string{sc.Name}); err == nil && hasTaskDependencies(s.cf, selected) {
+			names[taskSelectionAgentName([]string{sc.Name})] = true
+		} else {
+			for _, name := range taskInstanceNames(sc.Name, s.cf.Tasks[sc.Name]) {
+				names[name] = true
+			}
+		}
+	}
+	for _, a := range agents {
+		if sc.Kind == "pipeline" && isPipelineInstance(a.Name, sc.Name) || names[a.Name] {
+			return a
+		}
+	}
+	return nil
+}
+
+// logf prints a scheduler message prefixed with the time it's about.
+func (s *scheduler) logf(now time.Time, format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", now.Format(time.DateTime), fmt.Sprintf(format, args...))
+}
+
+// printSchedules lists scheduled pipelines and tasks with their last and
+// next runs.
+func printSchedules(mgr *state.Manager, workingDir string, scheduled []compose.Scheduled, now time.Time) error {
+	if len(scheduled) == 0 {
+		fmt.Println("No pipelines or tasks have a schedule")
+		return nil
+	}
+	records, err := mgr.Schedules(workingDir)
+	if err != nil {
+		return err
+	}
+
+	header := color.New(color.Bold)
+	header.Printf("%-28s  %-16s  %-19s  %-8s  %s\n", "NAME", "SCHEDULE", "LAST RUN", "RESULT", "NEXT RUN")
+	for _, sc := range scheduled {
+		record := records[sc.Key()]
+		lastRun, result := "-", "-"
+		if record.LastRun != nil {
+			lastRun = record.LastRun.Format(time.DateTime)
+			result = record.LastResult
+		}
+		nextRun := "never"
+		if next := sc.Schedule.Next(now); !next.IsZero() {
+			nextRun = next.Format(time.DateTime)
+		}
+		fmt.Printf("%-28s  %-16s  %-19s  ", sc.Key(), sc.Schedule.String(), lastRun)
+		scheduleResultColor(result).Printf("%-8s", result)
+		fmt.Printf("  %s\n", nextRun)
+		if record.LastError != "" {
+			fmt.Printf("  %s\n", record.LastError)
+		}
+	}
+	return nil
+}
+
+// scheduleResultColor returns the color a schedule's last result is shown in.
+func scheduleResultColor(result string) *color.Color {
+	switch result {
+	case state.ScheduleStarted:
+		return color.New(color.FgGreen)
+	case state.ScheduleSkipped:
+		return color.New(color.FgYellow)
+	case state.ScheduleFailed:
+		return color.New(color.FgRed)
+	}
+	return color.New()
+}
+
+func init() {
+	schedulerCmd.Flags().StringVarP(&schedulerFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	schedulerCmd.Flags().BoolVar(&schedulerLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	schedulerCmd.Flags().BoolVar(&schedulerList, "list", false, "List schedules with their last and next runs, then exit")
+	rootCmd.AddCommand(schedulerCmd)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutput string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of swarm.yaml",
+	Long: `Print a JSON Schema describing the compose file (swarm.yaml): tasks,
+pipelines, dependencies, and all of their fields.
+
+Editors with a YAML language server (e.g. VS Code with the YAML extension)
+use it to validate and autocomplete compose files. Reference the schema from
+the top of swarm.yaml:
+
+  # yaml-language-server: $schema=./swarm.schema.json`,
+	Example: `  # Print the schema
+  swarm schema
+
+  # Write the schema next to the compose file
+  swarm schema -o swarm/swarm.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := compose.JSONSchema()
+		if err != nil {
+			return fmt.Errorf("failed to generate schema: %w", err)
+		}
+		schema = append(schema, '\n')
+
+		if schemaOutput == "" {
+			_, err = os.Stdout.Write(schema)
+			return err
+		}
+		if err := os.WriteFile(schemaOutput, schema, 0644); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		fmt.Printf("Wrote compose schema to %s\n", schemaOutput)
+		return nil
+	},
+}
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/label"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchSince    string
+	searchLabels   []string
+	searchCase     bool
+	searchMaxCount int
+)
+
+// searchSnippetWidth is the maximum number of characters shown for a match.
+const searchSnippetWidth = 160
+
+var searchCmd = &cobra.Command{
+	Use:   "search <regex>",
+	Short: "Search the logs of all agents in scope",
+	Long: `Search the log files of all agents in scope (including terminated agents)
+for a regular expression. Logs are searched in parallel and matches are printed
+grouped by agent, with the line number and timestamp where available.
+
+The pattern is case-insensitive by default. Use --case-sensitive for
+case-sensitive matching.
+
+Use --since to skip agents that finished before the given time and log lines
+timestamped before it. Supported formats are the same as 'swarm logs --since'.`,
+	Example: `  # Search all agent logs in this project
+  swarm search "rate limit"
+
+  # Search logs from the last day across all projects
+  swarm search "panic|fatal" --since 1d --global
+
+  # Only search agents with a label
+  swarm search "TODO" --label team=backend
+
+  # Show at most 3 matches per agent
+  swarm search error -m 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := ""
+		if !searchCase {
+			flags = "(?i)"
+		}
+		re, err := regexp.Compile(flags + args[0])
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", args[0], err)
+		}
+
+		var sinceTime time.Time
+		if searchSince != "" {
+			sinceTime, err = ParseTimeFlag(searchSince)
+			if err != nil {
+				return fmt.Errorf("invalid --since format: %w", err)
+			}
+		}
+
+		labelFilters, err := label.ParseMultiple(searchLabels)
+		if err != nil {
+			return err
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agents, err := mgr.List(false)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+
+		agents = filterSearchAgents(agents, sinceTime, labelFilters)
+		if len(agents) == 0 {
+			fmt.Println("No agent logs to search")
+			return nil
+		}
+
+		results := searchAgentLogs(agents, re, sinceTime, searchMaxCount)
+		printSearchResults(results, args[0])
+		return nil
+	},
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchSince, "since", "", "Only search logs since timestamp (e.g., 30m, 2h, 1d, 2024-01-28 10:00)")
+	searchCmd.Flags().StringArrayVarP(&searchLabels, "label", "l", nil, "Only search agents with this label (key=value or key, can be repeated)")
+	searchCmd.Flags().BoolVar(&searchCase, "case-sensitive", false, "Make the pattern case-sensitive")
+	searchCmd.Flags().IntVarP(&searchMaxCount, "max-count", "m", 0, "Maximum matches to show per agent (0 = unlimited)")
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.RegisterFlagCompletionFunc("label", completeLabel)
+}
+
+// searchMatch is a single matching log line.
+type searchMatch struct {
+	File      string
+	Line      int
+	Timestamp time.Time
+	Text      string
+}
+
+// searchResult holds the matches found in one agent's logs.
+type searchResult struct {
+	Agent     *state.AgentState
+	Matches   []searchMatch
+	Truncated bool // More matches were found than --max-count allows
+	Err       error
+}
+
+// filterSearchAgents keeps agents with a log file that match the labels and
+// were still running at or after since.
+func filterSearchAgents(agents []*state.AgentState, since time.Time, labelFilters map[string]string) []*state.AgentState {
+	var filtered []*state.AgentState
+	for _, a := range agents {
+		if a.LogFile == "" {
+			continue
+		}
+		if len(labelFilters) > 0 && !label.Match(a.Labels, labelFilters) {
+			continue
+		}
+		if !since.IsZero() && a.TerminatedAt != nil && a.TerminatedAt.Before(since) {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
+
+// searchAgentLogs searches each agent's log file (and stderr sidecar) in
+// parallel. Results are returned in the same order as agents.
+func searchAgentLogs(agents []*state.AgentState, re *regexp.Regexp, since time.Time, maxCount int) []searchResult {
+	results := make([]searchResult, len(agents))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+
+	for i, a := range agents {
+		wg.Add(1)
+		go func(i int, a *state.AgentState) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := searchResult{Agent: a}
+			for _, path := range []string{a.LogFile, detach.StderrLogPath(a.LogFile)} {
+				matches, truncated, err := searchLogFile(path, re, since, maxCount-len(result.Matches), maxCount > 0)
+				if err != nil {
+					if !os.IsNotExist(err) {
+						result.Err = err
+					}
+					continue
+				}
+				result.Matches = append(result.Matches, matches...)
+				if truncated {
+					result.Truncated = true
+					break
+				}
+			}
+			results[i] = result
+		}(i, a)
+	}
+	wg.Wait()
+	return results
+}
+
+// searchLogFile returns the lines of path matching re. When limited, at most
+// limit matches are returned and truncated reports whether more were found.
+func searchLogFile(path string, re *regexp.Regexp, since time.Time, limit int, limited bool) ([]searchMatch, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	var matches []searchMatch
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		ts := ExtractTimestamp(line)
+		if !since.IsZero() && !ts.IsZero() && ts.Before(since) {
+			continue
+		}
+		if limited && len(matches) >= limit {
+			return matches, true, nil
+		}
+		matches = append(matches, searchMatch{
+			File:      path,
+			Line:      lineNum,
+			Timestamp: ts,
+			Text:      searchSnippet(line, loc[0], loc[1]),
+		})
+	}
+	return matches, false, scanner.Err()
+}
+
+// searchSnippet trims line to at most searchSnippetWidth characters centred
+// on the match at [start, end).
+func searchSnippet(line string, start, end int) string {
+	line = strings.TrimRight(line, "\r")
+	if len(line) <= searchSnippetWidth {
+		return line
+	}
+
+	pad := (searchSnippetWidth - (end - start)) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	from := start - pad
+	if from < 0 {
+		from = 0
+	}
+	to := from + searchSnippetWidth
+	if to > len(line) {
+		to = len(line)
+		from = to - searchSnippetWidth
+		if from < 0 {
+			from = 0
+		}
+	}
+
+	snippet := strings.ToValidUTF8(line[from:to], "")
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(line) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// printSearchResults prints matches grouped by agent, followed by a hint for
+// opening each agent's logs.
+func printSearchResults(results []searchResult, pattern string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Agent.StartedAt.Before(results[j].Agent.StartedAt)
+	})
+
+	bold := color.New(color.Bold)
+	dim := color.New(color.FgHiBlack)
+	cyan := color.New(color.FgCyan)
+
+	totalMatches := 0
+	agentsWithMatches := 0
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to search logs of %s: %v\n", r.Agent.ID, r.Err)
+		}
+		if len(r.Matches) == 0 {
+			continue
+		}
+		agentsWithMatches++
+		totalMatches += len(r.Matches)
+
+		name := r.Agent.Name
+		if name == "" {
+			name = r.Agent.ID
+		}
+		bold.Printf("%s", name)
+		dim.Printf(" (%s, %s)\n", r.Agent.ID, r.Agent.Status)
+
+		for _, m := range r.Matches {
+			ts := ""
+			if !m.Timestamp.IsZero() {
+				ts = logparser.FormatTimestamp(m.Timestamp) + " "
+			}
+			source := ""
+			if m.File != r.Agent.LogFile {
+				source = "stderr:"
+			}
+			cyan.Printf("  %s%d", source, m.Line)
+			dim.Printf(" %s", ts)
+			fmt.Println(m.Text)
+		}
+		if r.Truncated {
+			dim.Println("  … more matches not shown")
+		}
+		dim.Printf("  → swarm logs %s --grep %s\n\n", r.Agent.ID, shellQuote(pattern))
+	}
+
+	if totalMatches == 0 {
+		fmt.Printf("No matches in %d agent log(s)\n", len(results))
+		return
+	}
+	fmt.Printf("%d match(es) in %d of %d agent log(s)\n", totalMatches, agentsWithMatches, len(results))
+}
+
+// shellQuote quotes s for display in a copy-pasteable shell command.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestSearchAgentLogs(t *testing.T) {
+	dir := t.TempDir()
+	logA := filepath.Join(dir, "a.log")
+	logB := filepath.Join(dir, "b.log")
+	os.WriteFile(logA, []byte("starting\nrate limit hit\nretrying\nRate Limit again\n"), 0644)
+	os.WriteFile(logB, []byte("all good\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.stderr.log"), []byte("error: rate limit exceeded\n"), 0644)
+
+	agents := []*state.AgentState{
+		{ID: "a", LogFile: logA},
+		{ID: "b", LogFile: logB},
+	}
+	re := regexp.MustCompile("(?i)rate limit")
+
+	results := searchAgentLogs(agents, re, time.Time{}, 0)
+	if len(results[0].Matches) != 2 {
+		t.Fatalf("expected 2 matches in a.log, got %d", len(results[0].Matches))
+	}
+	if results[0].Matches[0].Line != 2 || results[0].Matches[1].Line != 4 {
+		t.Errorf("unexpected match lines: %+v", results[0].Matches)
+	}
+	if len(results[1].Matches) != 1 || results[1].Matches[0].File != filepath.Join(dir, "b.stderr.log") {
+		t.Errorf("expected a match in b's stderr log, got %+v", results[1].Matches)
+	}
+
+	limited := searchAgentLogs(agents[:1], re, time.Time{}, 1)
+	if len(limited[0].Matches) != 1 || !limited[0].Truncated {
+		t.Errorf("expected 1 truncated match with max-count 1, got %+v", limited[0])
+	}
+}
+
+func TestSearchSinceSkipsOldLines(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "a.log")
+	old := time.Now().Add(-48 * time.Hour).Format("2006-01-02 15:04:05")
+	recent := time.Now().Add(-time.Minute).Format("2006-01-02 15:04:05")
+	os.WriteFile(logFile, []byte(old+" | error one\n"+recent+" | error two\n"), 0644)
+
+	since := time.Now().Add(-24 * time.Hour)
+	matches, _, err := searchLogFile(logFile, regexp.MustCompile("error"), since, 0, false)
+	if err != nil {
+		t.Fatalf("searchLogFile failed: %v", err)
+	}
+	if len(matches) != 1 || !strings.Contains(matches[0].Text, "error two") {
+		t.Errorf("expected only the recent match, got %+v", matches)
+	}
+}
+
+func TestFilterSearchAgents(t *testing.T) {
+	longAgo := time.Now().Add(-72 * time.Hour)
+	agents := []*state.AgentState{
+		{ID: "nolog"},
+		{ID: "old", LogFile: "old.log", TerminatedAt: &longAgo},
+		{ID: "frontend", LogFile: "f.log", Labels: map[string]string{"team": "frontend"}},
+		{ID: "backend", LogFile: "b.log", Labels: map[string]string{"team": "backend"}},
+	}
+
+	got := filterSearchAgents(agents, time.Now().Add(-24*time.Hour), map[string]string{"team": "backend"})
+	if len(got) != 1 || got[0].ID != "backend" {
+		t.Errorf("filterSearchAgents() = %v, want [backend]", got)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	short := "short line with match"
+	if got := searchSnippet(short, 16, 21); got != short {
+		t.Errorf("short line changed: %q", got)
+	}
+
+	long := strings.Repeat("a", 300) + "MATCH" + strings.Repeat("b", 300)
+	got := searchSnippet(long, 300, 305)
+	if !strings.Contains(got, "MATCH") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet should be centred on the match with ellipses, got %q", got)
+	}
+	if n := len(strings.Trim(got, "…")); n != searchSnippetWidth {
+		t.Errorf("snippet width = %d, want %d", n, searchSnippetWidth)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/metrics"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveMetrics  string
+	serveInterval time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve Prometheus metrics for monitoring swarms",
+	Long: `Serve Prometheus metrics about agents on /metrics, so long-running swarms
+can be monitored and alerted on in Prometheus and Grafana.
+
+Agent state is read every --interval and the latest reading is served, so
+scrapes are cheap. Metrics:
+  swarm_agents{status}                   agents by status (running, paused, terminated)
+  swarm_iterations_total{result}         iterations succeeded and failed
+  swarm_tokens_total{direction}          input and output tokens
+  swarm_cost_usd_total                   cost in US dollars
+  swarm_agent_failures{class}            agents by class of their last failure
+  swarm_agent_iteration{id,name,model}   current iteration of each running agent
+  swarm_agent_tokens{id,name,model,direction}
+  swarm_agent_cost_usd{id,name,model}    usage of each running agent
+
+Totals cover the agents in state, so they drop when agents are removed
+with 'swarm prune'; Prometheus treats that as a counter reset.
+
+The address comes from --metrics, or metrics_addr in swarm.toml.
+
+By default, serves metrics of agents started in the current project.
+Use --global to serve metrics of agents from all directories.`,
+	Example: `  # Serve metrics on port 9900
+  swarm serve --metrics :9900
+
+  # Metrics of every project, only on localhost
+  swarm serve --global --metrics 127.0.0.1:9900`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr := serveMetrics
+		if addr == "" {
+			addr = appConfig.MetricsAddr
+		}
+		if addr == "" {
+			return fmt.Errorf("no metrics address: use --metrics (e.g. --metrics :9900) or set metrics_addr in swarm.toml")
+		}
+		if serveInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		collector := metrics.NewCollector(mgr, serveInterval)
+		go collector.Run(ctx)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", collector)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintln(w, "swarm metrics: /metrics")
+		})
+		server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- server.ListenAndServe()
+		}()
+		fmt.Printf("Serving metrics on http://%s/metrics (Ctrl+C to stop)\n", displayAddr(addr))
+
+		select {
+		case err := <-errc:
+			return fmt.Errorf("metrics server failed: %w", err)
+		case <-ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// displayAddr returns a browsable form of a listen address, e.g.
+// "localhost:9900" for ":9900".
+func displayAddr(addr string) string {
+	if len(addr) > 0 && addr[0] == ':' {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveMetrics, "metrics", "", "Address to serve Prometheus metrics on, e.g. :9900 (default metrics_addr from swarm.toml)")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 15*time.Second, "How often agent state is read")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/simulate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateScript string
+	simulateModel  string
+)
+
+// simulateAgentCmd is the agent CLI of the simulate backend: the swarm
+// binary runs itself in place of claude, codex, or cursor.
+var simulateAgentCmd = &cobra.Command{
+	Use:    "simulate-agent [prompt]",
+	Short:  "Play a scripted agent run (used by --simulate)",
+	Hidden: true,
+	Long: `Write the synthetic stream-json events of one scripted agent iteration to
+stdout, as the agent CLI of the simulate backend.
+
+The script comes from SWARM_SIMULATE: "default" for a built-in script, or
+the path of a YAML file:
+
+  step_delay: 500ms              # pause before each step without a delay
+  steps:
+    - text: Looking at the failing test
+      input_tokens: 1200
+      output_tokens: 80
+    - tool: Bash
+      input: {command: go test ./...}
+      delay: 3s
+    - fail: "API Error: 429 rate_limit_error"   # end the iteration here
+    - hang: true                 # stop producing output until killed
+  result: Fixed the flaky test
+  cost_usd: 0.05                 # reported cost (omit to price the tokens)
+  fail_iterations: [3]           # iterations (SWARM_ITERATION) that fail
+  fail_rate: 0.1                 # chance any other iteration fails
+  failure: simulated failure     # error of failing iterations
+  hang_iterations: [5]           # iterations that never finish`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		script, err := simulate.Load(os.Getenv(simulate.EnvVar))
+		if err != nil {
+			return err
+		}
+		iteration, _ := strconv.Atoi(os.Getenv("SWARM_ITERATION"))
+		run := &simulate.Run{
+			Script:    script,
+			Model:     simulateModel,
+			Prompt:    strings.Join(args, " "),
+			Iteration: iteration,
+		}
+		if err := run.Play(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// applySimulation switches appConfig to the simulate backend when --simulate
+// is given or inherited from a parent swarm through SWARM_SIMULATE, so
+// detached agents and pipeline tasks simulate too.
+func applySimulation() error {
+	if simulateScript != "" {
+		script := simulateScript
+		if script != simulate.DefaultScriptName {
+			abs, err := filepath.Abs(script)
+			if err != nil {
+				return err
+			}
+			if _, err := simulate.Load(abs); err != nil {
+				return err
+			}
+			script = abs
+		}
+		if err := os.Setenv(simulate.EnvVar, script); err != nil {
+			return err
+		}
+	}
+	if os.Getenv(simulate.EnvVar) == "" {
+		return nil
+	}
+	return appConfig.SetBackend(config.BackendSimulate)
+}
+
+func init() {
+	simulateAgentCmd.Flags().StringVar(&simulateModel, "model", "simulated", "Model to report")
+	rootCmd.AddCommand(simulateAgentCmd)
+}
@@ -7,18 +7,33 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	startLabels []string
+	startAll    bool
+)
+
 func init() {
+	startCmd.Flags().StringArrayVarP(&startLabels, "label", "l", nil, "Resume agents matching label (can be repeated for AND logic)")
+	startCmd.Flags().BoolVar(&startAll, "all", false, "Resume all paused agents in scope")
+
 	// Add dynamic completion for agent identifier
 	startCmd.ValidArgsFunction = completeRunningAgentIdentifier
+	startCmd.RegisterFlagCompletionFunc("label", completeLabel)
 }
 
 var startCmd = &cobra.Command{
-	Use:   "start [task-id-or-name]",
-	Short: "Resume a paused agent",
-	Long: `Resume a paused agent.
+	Use:     "start [task-id-or-name...]",
+	Aliases: []string{"resume"},
+	Short:   "Resume paused agents",
+	Long: `Resume paused agents.
 
-The agent can be specified by its ID, name, or special identifier:
+Agents can be specified by their IDs, names, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
+
+Several agents, --label selectors, and --all (every paused agent in scope)
+can be combined; all matching agents are resumed in one state update.
 
 The agent will continue from the next iteration after being resumed.`,
 	Example: `  # Resume an agent by ID
@@ -29,10 +44,20 @@ The agent will continue from the next iteration after being resumed.`,
 
   # Resume the most recent agent
   swarm start @last
-  swarm start _`,
-	Args: cobra.ExactArgs(1),
+  swarm start _
+
+  # Resume several agents at once
+  swarm resume frontend backend
+
+  # Resume every paused agent with a label
+  swarm resume --label team=backend
+
+  # Resume every paused agent in scope
+  swarm resume --all`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		agentIdentifier := args[0]
+		if len(args) == 0 && len(startLabels) == 0 && !startAll {
+			return fmt.Errorf("task-id-or-name is required (or use --label or --all for batch operations)")
+		}
 
 		// Create state manager with scope
 		mgr, err := state.NewManagerWithScope(GetScope(), "")
@@ -40,7 +65,46 @@ The agent will continue from the next iteration after being resumed.`,
 			return fmt.Errorf("failed to initialize state manager: %w", err)
 		}
 
-		agent, err := ResolveAgentIdentifier(mgr, agentIdentifier)
+		// Handle batch resume of several agents, labels, or --all
+		if startAll || len(startLabels) > 0 || len(args) > 1 {
+			agents, err := resolveControlTargets(mgr, args, startLabels, startAll)
+			if err != nil {
+				return err
+			}
+
+			// Skip agents that aren't running or aren't paused
+			var ids []string
+			for _, agent := range agents {
+				switch {
+				case agent.Status != "running":
+					fmt.Printf("Skipping %s: agent is not running (status: %s)\n", agent.ID, agent.Status)
+				case !agent.Paused:
+					// --all and labels select every running agent; only mention explicit targets
+					if len(args) > 0 {
+						fmt.Printf("Agent %s is not paused\n", agent.ID)
+					}
+				default:
+					ids = append(ids, agent.ID)
+				}
+			}
+
+			if len(ids) == 0 {
+				fmt.Println("No paused agents to resume")
+				return nil
+			}
+
+			if err := mgr.SetPausedMany(ids, false, nil); err != nil {
+				return fmt.Errorf("failed to update agent state: %w", err)
+			}
+			for _, id := range ids {
+				fmt.Printf("Agent %s resumed\n", id)
+			}
+
+			fmt.Printf("Resumed %d agent(s)\n", len(ids))
+			return nil
+		}
+
+		agent, err := ResolveAgentIdentifier(mgr, args[0])
 		if err != nil {
 			return err
 		}
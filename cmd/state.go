@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var stateImportMode string
+var stateImportForce bool
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Back up and restore agent state",
+	Long: `Back up and restore agent state.
+
+Use export to write agent state as JSON, and import to load it back,
+for example to move agents between machines or to recover after pruning.`,
+	Example: `  # Back up agents in the current project
+  swarm state export > backup.json
+
+  # Back up all agents
+  swarm state export -g > backup.json
+
+  # Restore agents missing from the current state
+  swarm state import backup.json`,
+}
+
+var stateExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export agent state as JSON",
+	Long: `Export agent state as JSON to stdout.
+
+By default, exports agents started in the current project.
+Use --global to export all agents.`,
+	Example: `  # Export project agents to a file
+  swarm state export > backup.json
+
+  # Export all agents
+  swarm state export -g > backup.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		agents, err := mgr.List(false)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+
+		backup := state.State{Agents: make(map[string]*state.AgentState, len(agents))}
+		for _, agent := range agents {
+			backup.Agents[agent.ID] = agent
+		}
+
+		data, err := json.MarshalIndent(backup, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode state: %w", err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	},
+}
+
+var stateImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import agent state from a JSON backup",
+	Long: `Import agent state from a JSON backup created by 'swarm state export'.
+Reads from stdin when no file is given or the file is "-".
+
+Modes:
+  merge    Add agents that don't already exist (default)
+  replace  Remove existing agents in scope, then import all agents
+
+Replace mode asks for confirmation unless --force is given, and is refused
+while agents in scope are running. Reading the backup from stdin leaves
+nothing to answer the prompt with, so it needs --force.
+
+A state.json file from older versions of swarm can also be imported.`,
+	Example: `  # Restore agents missing from the current state
+  swarm state import backup.json
+
+  # Replace all agents with the backup
+  swarm state import backup.json --mode replace -g
+
+  # Import from stdin
+  cat backup.json | swarm state import
+
+  # Replace from stdin (no confirmation prompt)
+  cat backup.json | swarm state import --mode replace --force`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		replace := false
+		switch stateImportMode {
+		case "merge":
+		case "replace":
+			replace = true
+		default:
+			return fmt.Errorf("invalid --mode %q (use merge or replace)", stateImportMode)
+		}
+
+		fromStdin := len(args) == 0 || args[0] == "-"
+		if replace && fromStdin && !stateImportForce {
+			return fmt.Errorf("--mode replace can't ask for confirmation when the backup is read from stdin; use --force")
+		}
+
+		var data []byte
+		var err error
+		if fromStdin {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		var backup state.State
+		if err := json.Unmarshal(data, &backup); err != nil {
+			return fmt.Errorf("failed to parse backup: %w", err)
+		}
+
+		agents := make([]*state.AgentState, 0, len(backup.Agents))
+		for id, agent := range backup.Agents {
+			if agent != nil && agent.ID == "" {
+				agent.ID = id
+			}
+			agents = append(agents, agent)
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+
+		if replace && !stateImportForce {
+			existing, err := mgr.List(false)
+			if err != nil {
+				return fmt.Errorf("failed to list agents: %w", err)
+			}
+			if len(existing) > 0 {
+				fmt.Printf("This will remove %d existing agent(s) before importing. Are you sure? [y/N] ", len(existing))
+				reader := bufio.NewReader(os.Stdin)
+				response, err := reader.ReadString('\n')
+				if err != nil {
+					return fmt.Errorf("failed to read response: %w", err)
+				}
+
+				response = strings.TrimSpace(strings.ToLower(response))
+				if response != "y" && response != "yes" {
+					fmt.Println("Aborted.")
+					return nil
+				}
+			}
+		}
+
+		imported, err := mgr.Import(agents, replace)
+		if err != nil {
+			return fmt.Errorf("failed to import state: %w", err)
+		}
+
+		skipped := len(agents) - imported
+		if skipped > 0 {
+			fmt.Printf("Imported %d agent(s), skipped %d already present.\n", imported, skipped)
+		} else {
+			fmt.Printf("Imported %d agent(s).\n", imported)
+		}
+		return nil
+	},
+}
+
+func init() {
+	stateImportCmd.Flags().StringVar(&stateImportMode, "mode", "merge", "Import mode: merge or replace")
+	stateImportCmd.Flags().BoolVarP(&stateImportForce, "force", "f", false, "Do not prompt for confirmation in replace mode")
+	stateCmd.AddCommand(stateExportCmd)
+	stateCmd.AddCommand(stateImportCmd)
+	rootCmd.AddCommand(stateCmd)
+}
@@ -3,15 +3,21 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
 	"github.com/spf13/cobra"
 )
 
-var statsFormat string
+var (
+	statsFormat   string
+	statsLive     bool
+	statsInterval time.Duration
+)
 
 // Stats represents aggregate statistics about agents.
 type Stats struct {
@@ -26,11 +32,15 @@ type Stats struct {
 	IterationsFailed     int     `json:"iterations_failed"`
 	SuccessRate          float64 `json:"success_rate"`
 
-	PromptStats []PromptStat `json:"prompt_stats"`
-	ModelStats  []ModelStat  `json:"model_stats"`
+	PromptStats  []PromptStat  `json:"prompt_stats"`
+	ModelStats   []ModelStat   `json:"model_stats"`
+	FailureStats []FailureStat `json:"failure_stats,omitempty"`
 
 	TotalRuntimeSeconds   int64 `json:"total_runtime_seconds"`
 	AverageRuntimeSeconds int64 `json:"average_runtime_seconds"`
+
+	// TodoQueue is the current directory's todo-file queue (nil if unused)
+	TodoQueue *todoqueue.Metrics `json:"todo_queue,omitempty"`
 }
 
 // PromptStat represents statistics for a single prompt.
@@ -40,6 +50,12 @@ type PromptStat struct {
 	Iterations int    `json:"iterations"`
 }
 
+// FailureStat counts agents whose last failure had a given class.
+type FailureStat struct {
+	Class string `json:"class"`
+	Count int    `json:"count"`
+}
+
 // ModelStat represents statistics for a single model.
 type ModelStat struct {
 	Name  string `json:"name"`
@@ -52,7 +68,23 @@ var statsCmd = &cobra.Command{
 	Long: `Display aggregate statistics about agent usage.
 
 Shows counts of running/paused/terminated agents, iteration totals,
-prompt usage frequency, and model distribution.`,
+prompt usage frequency, failure causes, and model distribution.
+
+If the current directory uses the todo-file queue (planners write
+swarm/todos/<name>.todo.md, doers rename one to .processing.md and move it to
+swarm/done when finished; set todo_dir and done_dir in swarm.toml for other
+paths), stats also shows the queue's depth, the age of the oldest claim, and
+how many tasks were created and done per hour over the last hour. Agents
+sample the queue after every iteration; a doer falling behind shows up as
+created/h above done/h while the queue grows.
+
+Use 'swarm stats usage' to total token usage and cost by day, label, prompt,
+model, or working directory.
+
+Use --live to print a continuously-updating one-line summary of running
+agents, token throughput, and spend rate instead, e.g. for a tmux status
+pane. Rates are averaged over the last minute. When output is not a
+terminal, each update is printed on its own line.`,
 	Example: `  # Show stats for current project
   swarm stats
 
@@ -60,13 +92,20 @@ prompt usage frequency, and model distribution.`,
   swarm stats --global
 
   # Output as JSON
-  swarm stats --format json`,
+  swarm stats --format json
+
+  # Stream live totals, updating every 5 seconds
+  swarm stats --live --interval 5s`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		mgr, err := state.NewManagerWithScope(GetScope(), "")
 		if err != nil {
 			return fmt.Errorf("failed to initialize state manager: %w", err)
 		}
 
+		if statsLive {
+			return runStatsLive(mgr, statsInterval, statsFormat)
+		}
+
 		// Get all agents (not just running)
 		agents, err := mgr.List(false)
 		if err != nil {
@@ -74,6 +113,12 @@ prompt usage frequency, and model distribution.`,
 		}
 
 		stats := calculateStats(agents)
+		if cwd, err := os.Getwd(); err == nil {
+			stats.TodoQueue, err = mgr.SampleTodoQueue(appConfig.TodoQueueDirs(cwd))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to sample todo queue: %v\n", err)
+			}
+		}
 
 		if statsFormat == "json" {
 			output, err := json.MarshalIndent(stats, "", "  ")
@@ -93,6 +138,7 @@ func calculateStats(agents []*state.AgentState) Stats {
 	stats := Stats{}
 	promptMap := make(map[string]*PromptStat)
 	modelMap := make(map[string]int)
+	failureMap := make(map[string]int)
 
 	now := time.Now()
 
@@ -116,6 +162,9 @@ func calculateStats(agents []*state.AgentState) Stats {
 		stats.IterationsTotal += agent.Iterations
 		stats.IterationsSuccessful += agent.SuccessfulIters
 		stats.IterationsFailed += agent.FailedIters
+		if agent.LastErrorClass != "" {
+			failureMap[agent.LastErrorClass]++
+		}
 
 		// Prompt stats
 		promptName := agent.Prompt
@@ -176,6 +225,16 @@ func calculateStats(agents []*state.AgentState) Stats {
 		return stats.ModelStats[i].Count > stats.ModelStats[j].Count
 	})
 
+	for class, count := range failureMap {
+		stats.FailureStats = append(stats.FailureStats, FailureStat{Class: class, Count: count})
+	}
+	sort.Slice(stats.FailureStats, func(i, j int) bool {
+		if stats.FailureStats[i].Count != stats.FailureStats[j].Count {
+			return stats.FailureStats[i].Count > stats.FailureStats[j].Count
+		}
+		return stats.FailureStats[i].Class < stats.FailureStats[j].Class
+	})
+
 	// Calculate average
 	if stats.Total > 0 {
 		stats.AverageRuntimeSeconds = stats.TotalRuntimeSeconds / int64(stats.Total)
@@ -234,6 +293,14 @@ func printStats(stats Stats) {
 		fmt.Println()
 	}
 
+	if len(stats.FailureStats) > 0 {
+		bold.Println("Failure Causes (by agent)")
+		for _, fs := range stats.FailureStats {
+			fmt.Printf("  %-16s %d agents\n", fs.Class, fs.Count)
+		}
+		fmt.Println()
+	}
+
 	if len(stats.ModelStats) > 0 {
 		bold.Println("Models Used")
 		for _, ms := range stats.ModelStats {
@@ -245,6 +312,25 @@ func printStats(stats Stats) {
 	bold.Println("Runtime")
 	fmt.Printf("  Total:   %s\n", formatStatsDuration(time.Duration(stats.TotalRuntimeSeconds)*time.Second))
 	fmt.Printf("  Average: %s per agent\n", formatStatsDuration(time.Duration(stats.AverageRuntimeSeconds)*time.Second))
+
+	if q := stats.TodoQueue; q != nil {
+		fmt.Println()
+		bold.Println("Todo Queue")
+		fmt.Printf("  Queued:       %d\n", q.Queued)
+		fmt.Printf("  Processing:   %d\n", q.Processing)
+		if q.Processing > 0 {
+			fmt.Printf("  Oldest claim: %s\n", formatStatsDuration(q.OldestClaim))
+		}
+		if q.HasRates {
+			fmt.Printf("  Created:      %.1f/h\n", q.CreatedPerHour)
+			fmt.Printf("  Done:         %.1f/h\n", q.CompletedPerHour)
+		} else {
+			fmt.Println("  Throughput:   not enough samples yet")
+		}
+		if q.PlannersAhead() {
+			color.New(color.FgYellow).Println("  Planners are creating tasks faster than doers finish them")
+		}
+	}
 }
 
 func formatStatsDuration(d time.Duration) string {
@@ -262,5 +348,7 @@ func formatStatsDuration(d time.Duration) string {
 
 func init() {
 	statsCmd.Flags().StringVar(&statsFormat, "format", "", "Output format: json or table (default)")
+	statsCmd.Flags().BoolVar(&statsLive, "live", false, "Stream a one-line summary of running agents until interrupted")
+	statsCmd.Flags().DurationVar(&statsInterval, "interval", 2*time.Second, "Update interval for --live")
 	rootCmd.AddCommand(statsCmd)
 }
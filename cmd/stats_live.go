@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// liveRateWindow is how far back usage deltas are averaged for live rates.
+const liveRateWindow = time.Minute
+
+// liveSummary is one update of `swarm stats --live`.
+type liveSummary struct {
+	Running        int     `json:"running"`
+	Paused         int     `json:"paused"`
+	Tokens         int64   `json:"tokens"`
+	CostUSD        float64 `json:"cost_usd"`
+	TokensPerMin   float64 `json:"tokens_per_minute"`
+	CostPerHourUSD float64 `json:"cost_per_hour_usd"`
+}
+
+// liveSample is the usage added between two polls.
+type liveSample struct {
+	at     time.Time
+	tokens int64
+	cost   float64
+}
+
+// agentUsage is an agent's cumulative usage at the last poll.
+type agentUsage struct {
+	tokens int64
+	cost   float64
+}
+
+// liveStatsTracker turns successive snapshots of agent state into totals and
+// rates. Rates come from per-agent usage deltas, so agents finishing or being
+// pruned between polls don't make them go negative.
+type liveStatsTracker struct {
+	started  time.Time
+	lastPoll time.Time
+	usage    map[string]agentUsage
+	samples  []liveSample
+}
+
+func newLiveStatsTracker(now time.Time) *liveStatsTracker {
+	return &liveStatsTracker{
+		started: now,
+		usage:   make(map[string]agentUsage),
+	}
+}
+
+// update records a snapshot of agents taken at now and returns the summary.
+func (t *liveStatsTracker) update(agents []*state.AgentState, now time.Time) liveSummary {
+	var summary liveSummary
+	var sample liveSample
+	seen := make(map[string]agentUsage, len(agents))
+
+	for _, a := range agents {
+		cur := agentUsage{tokens: a.InputTokens + a.OutputTokens, cost: a.TotalCost}
+		seen[a.ID] = cur
+
+		if prev, ok := t.usage[a.ID]; ok {
+			if d := cur.tokens - prev.tokens; d > 0 {
+				sample.tokens += d
+			}
+			if d := cur.cost - prev.cost; d > 0 {
+				sample.cost += d
+			}
+		} else if !t.lastPoll.IsZero() && a.StartedAt.After(t.lastPoll) {
+			// Started since the last poll, so all its usage is new
+			sample.tokens += cur.tokens
+			sample.cost += cur.cost
+		}
+
+		if a.Status != "running" {
+			continue
+		}
+		if a.Paused {
+			summary.Paused++
+		} else {
+			summary.Running++
+		}
+		summary.Tokens += cur.tokens
+		summary.CostUSD += cur.cost
+	}
+	t.usage = seen
+
+	if !t.lastPoll.IsZero() {
+		sample.at = now
+		t.samples = append(t.samples, sample)
+	}
+	t.lastPoll = now
+
+	// Drop samples outside the window
+	cutoff := now.Add(-liveRateWindow)
+	for len(t.samples) > 0 && !t.samples[0].at.After(cutoff) {
+		t.samples = t.samples[1:]
+	}
+
+	window := liveRateWindow
+	if elapsed := now.Sub(t.started); elapsed < window {
+		window = elapsed
+	}
+	if window > 0 {
+		var tokens int64
+		var cost float64
+		for _, s := range t.samples {
+			tokens += s.tokens
+			cost += s.cost
+		}
+		summary.TokensPerMin = float64(tokens) / window.Minutes()
+		summary.CostPerHourUSD = cost / window.Hours()
+	}
+	return summary
+}
+
+// formatLiveSummary renders a summary as a single compact line.
+func formatLiveSummary(s liveSummary) string {
+	agents := fmt.Sprintf("%d running", s.Running)
+	if s.Paused > 0 {
+		agents += fmt.Sprintf(", %d paused", s.Paused)
+	}
+	rate := "0"
+	if s.TokensPerMin >= 1 {
+		rate = formatTokenCount(int64(s.TokensPerMin))
+	}
+	return fmt.Sprintf("swarm: %s | %s tok/min | $%.2f/h | %s tok, $%.2f",
+		agents, rate, s.CostPerHourUSD, formatTokenCount(s.Tokens), s.CostUSD)
+}
+
+// runStatsLive polls agent state every interval and prints a one-line summary
+// until interrupted. On a terminal the line is rewritten in place; otherwise
+// each update is printed on its own line so it can be piped or tailed.
+func runStatsLive(mgr *state.Manager, interval time.Duration, format string) error {
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	inPlace := format != "json" && (isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()))
+	tracker := newLiveStatsTracker(time.Now())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		agents, err := mgr.List(false)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+		summary := tracker.update(agents, time.Now())
+
+		switch {
+		case format == "json":
+			data, err := json.Marshal(summary)
+			if err != nil {
+				return fmt.Errorf("failed to marshal stats: %w", err)
+			}
+			fmt.Println(string(data))
+		case inPlace:
+			fmt.Printf("\r\033[K%s", formatLiveSummary(summary))
+		default:
+			fmt.Println(formatLiveSummary(summary))
+		}
+
+		select {
+		case <-ctx.Done():
+			if inPlace {
+				fmt.Println()
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
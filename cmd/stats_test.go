@@ -214,3 +214,143 @@ func TestFormatStatsDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateStats_FailureStats(t *testing.T) {
+	now := time.Now()
+
+	agents := []*state.AgentState{
+		{ID: "1", Status: "terminated", StartedAt: now, LastErrorClass: "rate_limited"},
+		{ID: "2", Status: "terminated", StartedAt: now, LastErrorClass: "auth_error"},
+		{ID: "3", Status: "terminated", StartedAt: now, LastErrorClass: "rate_limited"},
+		{ID: "4", Status: "terminated", StartedAt: now},
+	}
+
+	stats := calculateStats(agents)
+
+	if len(stats.FailureStats) != 2 {
+		t.Fatalf("expected 2 failure stats, got %d", len(stats.FailureStats))
+	}
+	if stats.FailureStats[0].Class != "rate_limited" || stats.FailureStats[0].Count != 2 {
+		t.Errorf("expected rate_limited=2 first, got %+v", stats.FailureStats[0])
+	}
+	if stats.FailureStats[1].Class != "auth_error" || stats.FailureStats[1].Count != 1 {
+		t.Errorf("expected auth_error=1 second, got %+v", stats.FailureStats[1])
+	}
+}
+
+func TestLiveStatsTracker(t *testing.T) {
+	start := time.Now()
+	tracker := newLiveStatsTracker(start)
+
+	a := &state.AgentState{ID: "a", Status: "running", StartedAt: start.Add(-time.Hour), InputTokens: 1000, OutputTokens: 500, TotalCost: 1.0}
+	b := &state.AgentState{ID: "b", Status: "running", Paused: true, StartedAt: start.Add(-time.Hour), InputTokens: 200, TotalCost: 0.5}
+
+	// First poll only establishes a baseline
+	s := tracker.update([]*state.AgentState{a, b}, start)
+	if s.Running != 1 || s.Paused != 1 {
+		t.Errorf("expected 1 running and 1 paused, got %d and %d", s.Running, s.Paused)
+	}
+	if s.Tokens != 1700 {
+		t.Errorf("expected 1700 tokens, got %d", s.Tokens)
+	}
+	if s.TokensPerMin != 0 || s.CostPerHourUSD != 0 {
+		t.Errorf("expected zero rates on first poll, got %v tok/min, $%v/h", s.TokensPerMin, s.CostPerHourUSD)
+	}
+
+	// 30s later: a used 600 tokens and $0.25, c started and used 400 tokens,
+	// b finished and no longer counts towards totals
+	now := start.Add(30 * time.Second)
+	a2 := *a
+	a2.OutputTokens += 600
+	a2.TotalCost += 0.25
+	b2 := *b
+	b2.Status = "terminated"
+	c := &state.AgentState{ID: "c", Status: "running", StartedAt: start.Add(10 * time.Second), InputTokens: 400}
+
+	s = tracker.update([]*state.AgentState{&a2, &b2, c}, now)
+	if s.Running != 2 || s.Paused != 0 {
+		t.Errorf("expected 2 running and 0 paused, got %d and %d", s.Running, s.Paused)
+	}
+	if s.TokensPerMin != 2000 {
+		t.Errorf("expected 2000 tok/min, got %v", s.TokensPerMin)
+	}
+	if s.CostPerHourUSD < 29.99 || s.CostPerHourUSD > 30.01 {
+		t.Errorf("expected $30/h, got %v", s.CostPerHourUSD)
+	}
+
+	// Two minutes later with no new usage, the rate decays to zero
+	s = tracker.update([]*state.AgentState{&a2, c}, now.Add(2*time.Minute))
+	if s.TokensPerMin != 0 {
+		t.Errorf("expected rate to decay to 0, got %v", s.TokensPerMin)
+	}
+}
+
+func TestFormatLiveSummary(t *testing.T) {
+	line := formatLiveSummary(liveSummary{Running: 3, Paused: 1, Tokens: 1500000, CostUSD: 4.5, TokensPerMin: 12000, CostPerHourUSD: 2.25})
+	expected := "swarm: 3 running, 1 paused | 12.0K tok/min | $2.25/h | 1.5M tok, $4.50"
+	if line != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+}
+
+func TestParseUsageGroupBy(t *testing.T) {
+	groupBy, err := parseUsageGroupBy("day, label:team,day")
+	if err != nil {
+		t.Fatalf("parseUsageGroupBy: %v", err)
+	}
+	if len(groupBy) != 2 || groupBy[0] != "day" || groupBy[1] != "label:team" {
+		t.Errorf("groupBy = %v, want [day label:team]", groupBy)
+	}
+	for _, bad := range []string{"week", "label:", ""} {
+		if _, err := parseUsageGroupBy(bad); err == nil {
+			t.Errorf("parseUsageGroupBy(%q) should fail", bad)
+		}
+	}
+}
+
+func TestAggregateUsage(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+
+	infra := &state.AgentState{ID: "a", Model: "opus", Labels: map[string]string{"team": "infra"},
+		InputTokens: 300, OutputTokens: 30, TotalCost: 3.5}
+	web := &state.AgentState{ID: "b", Model: "sonnet", InputTokens: 100, OutputTokens: 10, TotalCost: 1}
+
+	var entries []usageEntry
+	entries = append(entries, agentUsageEntries(infra, []state.IterationRecord{
+		{StartedAt: day1, InputTokens: 100, OutputTokens: 10, CostUSD: 1},
+		{StartedAt: day2, Model: "sonnet", InputTokens: 100, OutputTokens: 10, CostUSD: 1.5},
+	})...)
+	entries = append(entries, agentUsageEntries(web, []state.IterationRecord{
+		{StartedAt: day1, InputTokens: 100, OutputTokens: 10, CostUSD: 1},
+	})...)
+
+	// infra's third iteration is in its totals but not its history yet
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+
+	byDay := aggregateUsage(entries, []string{"day"})
+	if len(byDay) != 2 || byDay[0].Group["day"] != "2026-03-01" {
+		t.Fatalf("by day = %+v", byDay)
+	}
+	if byDay[0].Agents != 2 || byDay[0].Iterations != 2 || byDay[0].CostUSD != 2 {
+		t.Errorf("day 1 = %+v, want 2 agents, 2 iterations, $2", byDay[0])
+	}
+	if byDay[1].Iterations != 1 || byDay[1].CostUSD != 2.5 || byDay[1].InputTokens != 200 {
+		t.Errorf("day 2 = %+v, want 1 iteration, $2.50, 200 input tokens", byDay[1])
+	}
+
+	byTeam := aggregateUsage(entries, []string{"label:team"})
+	if len(byTeam) != 2 || byTeam[0].Group["label:team"] != "infra" || byTeam[0].CostUSD != 3.5 {
+		t.Errorf("by team = %+v, want infra first with $3.50", byTeam)
+	}
+	if byTeam[1].Group["label:team"] != usageNone {
+		t.Errorf("unlabeled group = %q, want %q", byTeam[1].Group["label:team"], usageNone)
+	}
+
+	byModel := aggregateUsage(entries, []string{"model"})
+	if len(byModel) != 2 || byModel[0].Group["model"] != "sonnet" || byModel[0].CostUSD != 2.5 || byModel[1].CostUSD != 2 {
+		t.Errorf("by model = %+v, want sonnet $2.50, opus $2", byModel)
+	}
+}
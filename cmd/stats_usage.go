@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsUsageSince   string
+	statsUsageGroupBy string
+	statsUsageFormat  string
+)
+
+// Dimensions usage can be grouped by; labels are grouped by "label:<key>".
+const (
+	usageByDay    = "day"
+	usageByModel  = "model"
+	usageByPrompt = "prompt"
+	usageByDir    = "dir"
+	usageByAgent  = "agent"
+	usageByLabel  = "label:"
+)
+
+// usageNone is the group value of an agent without the grouped label.
+const usageNone = "(none)"
+
+// UsageGroup is the token usage and cost of one group of iterations.
+type UsageGroup struct {
+	Group        map[string]string `json:"group"` // dimension -> value
+	Agents       int               `json:"agents"`
+	Iterations   int               `json:"iterations"`
+	InputTokens  int64             `json:"input_tokens"`
+	OutputTokens int64             `json:"output_tokens"`
+	CostUSD      float64           `json:"cost_usd"`
+}
+
+// usageEntry is usage attributed to an agent at a point in time: a finished
+// iteration, or usage the agent's history doesn't cover.
+type usageEntry struct {
+	agent        *state.AgentState
+	at           time.Time
+	model        string
+	iterations   int
+	inputTokens  int64
+	outputTokens int64
+	costUSD      float64
+}
+
+var statsUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Aggregate token usage and cost by day, label, prompt, model, or directory",
+	Long: `Total the token usage and cost of agents' iterations, grouped by one or
+more dimensions:
+  day          the day an iteration started (the default)
+  model        the model the agent CLI reported using
+  prompt       the agent's prompt
+  dir          the agent's working directory
+  agent        the agent's name (or ID)
+  label:<key>  the value of the agent's <key> label, e.g. label:team
+
+Combine dimensions with commas, e.g. --group-by day,model.
+
+Usage comes from each agent's iteration history. Usage the history doesn't
+cover, such as an iteration in progress or cut short by a crash, is read from
+the agent's state and log file and attributed to the agent's latest
+iteration, so totals match 'swarm cost'.
+
+By default, shows agents started in the current project.
+Use --global to aggregate agents from all directories.`,
+	Example: `  # Daily cost over the last week
+  swarm stats usage --since 7d
+
+  # Cost per team label across all projects
+  swarm stats usage --global --group-by label:team
+
+  # Daily cost per model, as CSV for a spreadsheet
+  swarm stats usage --group-by day,model --format csv > usage.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, err := ParseTimeFlag(statsUsageSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		groupBy, err := parseUsageGroupBy(statsUsageGroupBy)
+		if err != nil {
+			return err
+		}
+		switch statsUsageFormat {
+		case "", "table", "json", "csv":
+		default:
+			return fmt.Errorf("invalid --format %q (use table, json, or csv)", statsUsageFormat)
+		}
+
+		mgr, err := state.NewManagerWithScope(GetScope(), "")
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+		agents, err := mgr.List(false)
+		if err != nil {
+			return fmt.Errorf("failed to list agents: %w", err)
+		}
+
+		var entries []usageEntry
+		for _, agent := range agents {
+			history, err := mgr.History(agent.ID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to read history of agent %s: %v\n", agent.ID, err)
+			}
+			for _, e := range agentUsageEntries(agent, history) {
+				if !e.at.Before(since) {
+					entries = append(entries, e)
+				}
+			}
+		}
+		groups := aggregateUsage(entries, groupBy)
+
+		switch statsUsageFormat {
+		case "json":
+			output, err := json.MarshalIndent(groups, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(output))
+		case "csv":
+			return writeUsageCSV(groups, groupBy)
+		default:
+			if len(groups) == 0 {
+				fmt.Println("No usage found")
+				return nil
+			}
+			printUsageGroups(groups, groupBy)
+		}
+		return nil
+	},
+}
+
+// parseUsageGroupBy parses a comma-separated --group-by value.
+func parseUsageGroupBy(value string) ([]string, error) {
+	var groupBy []string
+	seen := make(map[string]bool)
+	for _, dim := range strings.Split(value, ",") {
+		dim = strings.TrimSpace(dim)
+		switch {
+		case dim == usageByDay, dim == usageByModel, dim == usageByPrompt, dim == usageByDir, dim == usageByAgent:
+		case strings.HasPrefix(dim, usageByLabel) && len(dim) > len(usageByLabel):
+		default:
+			return nil, fmt.Errorf("invalid --group-by %q (use day, model, prompt, dir, agent, or label:<key>)", dim)
+		}
+		if !seen[dim] {
+			seen[dim] = true
+			groupBy = append(groupBy, dim)
+		}
+	}
+	return groupBy, nil
+}
+
+// agentUsageEntries returns an agent's usage: one entry per iteration in its
+// history, plus one for usage recorded in its state or log file beyond what
+// the history covers.
+func agentUsageEntries(agent *state.AgentState, history []state.IterationRecord) []usageEntry {
+	var entries []usageEntry
+	var input, output int64
+	var cost float64
+	at := agent.StartedAt
+	for _, r := range history {
+		model := r.Model
+		if model == "" {
+			model = agent.Model
+		}
+		entries = append(entries, usageEntry{
+			agent:        agent,
+			at:           r.StartedAt,
+			model:        model,
+			iterations:   1,
+			inputTokens:  r.InputTokens,
+			outputTokens: r.OutputTokens,
+			costUSD:      r.CostUSD,
+		})
+		input += r.InputTokens
+		output += r.OutputTokens
+		cost += r.CostUSD
+		at = r.StartedAt
+	}
+	if agent.IterationStartedAt != nil {
+		at = *agent.IterationStartedAt
+	}
+
+	// The agent's totals include its current iteration and any the runner
+	// died in before recording; the log has them too if state fell behind
+	total := state.ModelUsage{InputTokens: agent.InputTokens, OutputTokens: agent.OutputTokens, Cost: agent.TotalCost}
+	model := agent.Model
+	if agent.LogFile != "" {
+		if stats, err := logparser.ScanLogFileCached(agent.LogFile); err == nil {
+			total.InputTokens = max(total.InputTokens, stats.InputTokens)
+			total.OutputTokens = max(total.OutputTokens, stats.OutputTokens)
+			total.Cost = max(total.Cost, stats.TotalCostUSD)
+			if stats.Model != "" {
+				model = stats.Model
+			}
+		}
+	}
+	rest := usageEntry{
+		agent:        agent,
+		at:           at,
+		model:        model,
+		inputTokens:  max(total.InputTokens-input, 0),
+		outputTokens: max(total.OutputTokens-output, 0),
+		costUSD:      max(total.Cost-cost, 0),
+	}
+	if rest.inputTokens > 0 || rest.outputTokens > 0 || rest.costUSD > 0.000001 {
+		entries = append(entries, rest)
+	}
+	return entries
+}
+
+// usageGroupValue returns the value of dimension dim for an entry.
+func usageGroupValue(e usageEntry, dim string) string {
+	var value string
+	switch dim {
+	case usageByDay:
+		value = e.at.Local().Format("2006-01-02")
+	case usageByModel:
+		value = e.model
+	case usageByPrompt:
+		value = e.agent.Prompt
+	case usageByDir:
+		value = e.agent.WorkingDir
+	case usageByAgent:
+		value = e.agent.Name
+		if value == "" {
+			value = e.agent.ID
+		}
+	default:
+		value = e.agent.Labels[strings.TrimPrefix(dim, usageByLabel)]
+	}
+	if value == "" {
+		return usageNone
+	}
+	return value
+}
+
+// aggregateUsage totals entries by the groupBy dimensions. Groups are sorted
+// by their values when the first dimension is the day, so a report reads as a
+// time series, and most expensive first otherwise.
+func aggregateUsage(entries []usageEntry, groupBy []string) []UsageGroup {
+	type group struct {
+		values []string
+		agents map[string]bool
+		usage  UsageGroup
+	}
+	groups := make(map[string]*group)
+	for _, e := range entries {
+		values := make([]string, len(groupBy))
+		for i, dim := range groupBy {
+			values[i] = usageGroupValue(e, dim)
+		}
+		key := strings.Join(values, "\x00")
+		g := groups[key]
+		if g == nil {
+			g = &group{values: values, agents: make(map[string]bool)}
+			groups[key] = g
+		}
+		g.agents[e.agent.ID] = true
+		g.usage.Iterations += e.iterations
+		g.usage.InputTokens += e.inputTokens
+		g.usage.OutputTokens += e.outputTokens
+		g.usage.CostUSD += e.costUSD
+	}
+
+	result := make([]UsageGroup, 0, len(groups))
+	for _, g := range groups {
+		g.usage.Agents = len(g.agents)
+		g.usage.Group = make(map[string]string, len(groupBy))
+		for i, dim := range groupBy {
+			g.usage.Group[dim] = g.values[i]
+		}
+		result = append(result, g.usage)
+	}
+	less := func(a, b UsageGroup) bool {
+		for _, dim := range groupBy {
+			if a.Group[dim] != b.Group[dim] {
+				return a.Group[dim] < b.Group[dim]
+			}
+		}
+		return false
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if groupBy[0] != usageByDay && result[i].CostUSD != result[j].CostUSD {
+			return result[i].CostUSD > result[j].CostUSD
+		}
+		return less(result[i], result[j])
+	})
+	return result
+}
+
+func printUsageGroups(groups []UsageGroup, groupBy []string) {
+	widths := make([]int, len(groupBy))
+	for i, dim := range groupBy {
+		widths[i] = len(dim)
+		for _, g := range groups {
+			widths[i] = max(widths[i], min(len(g.Group[dim]), 40))
+		}
+	}
+	row := func(values []string, agents, iterations, input, output, cost string) string {
+		var b strings.Builder
+		for i, v := range values {
+			if len(v) > 40 {
+				v = "..." + v[len(v)-37:]
+			}
+			fmt.Fprintf(&b, "%-*s  ", widths[i], v)
+		}
+		fmt.Fprintf(&b, "%-6s  %-5s  %-8s  %-8s  %s\n", agents, iterations, input, output, cost)
+		return b.String()
+	}
+
+	header := color.New(color.Bold)
+	names := make([]string, len(groupBy))
+	for i, dim := range groupBy {
+		names[i] = strings.ToUpper(dim)
+	}
+	header.Print(row(names, "AGENTS", "ITERS", "INPUT", "OUTPUT", "COST"))
+
+	var total UsageGroup
+	for _, g := range groups {
+		values := make([]string, len(groupBy))
+		for i, dim := range groupBy {
+			values[i] = g.Group[dim]
+		}
+		fmt.Print(row(values, strconv.Itoa(g.Agents), strconv.Itoa(g.Iterations),
+			formatTokenCount(g.InputTokens), formatTokenCount(g.OutputTokens), fmt.Sprintf("$%.2f", g.CostUSD)))
+		total.Iterations += g.Iterations
+		total.InputTokens += g.InputTokens
+		total.OutputTokens += g.OutputTokens
+		total.CostUSD += g.CostUSD
+	}
+	totalValues := make([]string, len(groupBy))
+	totalValues[0] = "TOTAL"
+	header.Print(row(totalValues, "", strconv.Itoa(total.Iterations),
+		formatTokenCount(total.InputTokens), formatTokenCount(total.OutputTokens), fmt.Sprintf("$%.2f", total.CostUSD)))
+}
+
+func writeUsageCSV(groups []UsageGroup, groupBy []string) error {
+	w := csv.NewWriter(os.Stdout)
+	header := append(append([]string{}, groupBy...), "agents", "iterations", "input_tokens", "output_tokens", "cost_usd")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, g := range groups {
+		record := make([]string, 0, len(header))
+		for _, dim := range groupBy {
+			record = append(record, g.Group[dim])
+		}
+		record = append(record,
+			strconv.Itoa(g.Agents),
+			strconv.Itoa(g.Iterations),
+			strconv.FormatInt(g.InputTokens, 10),
+			strconv.FormatInt(g.OutputTokens, 10),
+			strconv.FormatFloat(g.CostUSD, 'f', 4, 64),
+		)
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func init() {
+	statsUsageCmd.Flags().StringVar(&statsUsageSince, "since", "", "Only count iterations started since (e.g., 24h, 7d, 2024-01-28)")
+	statsUsageCmd.Flags().StringVar(&statsUsageGroupBy, "group-by", usageByDay, "Dimensions to group by: day, model, prompt, dir, agent, label:<key> (comma-separated)")
+	statsUsageCmd.Flags().StringVar(&statsUsageFormat, "format", "", "Output format: table (default), json, or csv")
+	statsCmd.AddCommand(statsUsageCmd)
+}
@@ -13,24 +13,49 @@ var (
 	stopNoWait  bool
 	stopTimeout int
 	stopLabels  []string
+	stopAll     bool
+	stopFor     time.Duration
+	stopAfter   int
+	stopApprove string
 )
 
 var stopCmd = &cobra.Command{
-	Use:   "stop [task-id-or-name]",
-	Short: "Pause a running agent",
-	Long: `Pause a running agent after the current iteration completes.
+	Use:     "stop [task-id-or-name...]",
+	Aliases: []string{"pause"},
+	Short:   "Pause running agents",
+	Long: `Pause running agents after their current iteration completes.
 
-The agent can be specified by its ID, name, or special identifier:
+Agents can be specified by their IDs, names, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 The agent will finish its current iteration and then wait until resumed
 with the 'start' command. Use 'kill' to terminate a paused agent.
 
-By default, the command waits until the agent has finished its current
-iteration and entered the paused state. Use --no-wait to return immediately.
+Use --for to resume the agent automatically once the duration has elapsed
+(e.g. --for 2h). The resume time is recorded in state and honored by the
+runner, so nobody has to remember to run 'start' later.
 
-Use --label to stop all running agents matching the specified labels.
-When using --label, the task-id-or-name argument is not required.`,
+A paused agent sends a paused notification (notify_desktop, slack_webhook_url,
+notify_cmd) pointing at 'swarm inspect' and 'swarm resume' for it.
+
+Use --await-approval to make the pause an approval gate: the agent sends an
+approval_requested notification saying what needs approving, with a
+'swarm approve' link, and waits until someone approves it. An agent that needs
+sign-off or human input can gate itself with 'swarm stop $SWARM_CONTROL_ID
+--no-wait --await-approval "..."'.
+
+When a single agent is given, the command waits until it has finished its
+current iteration and entered the paused state. Use --no-wait to return
+immediately.
+
+Use --after N to let an agent finish its current iteration and N more, then
+terminate instead of pausing (e.g. --after 3). It lowers the agent's iteration
+count, so an agent that would stop sooner anyway is unaffected.
+
+Several agents, --label selectors, and --all (every running agent in scope)
+can be combined; all matching agents are paused in one state update.`,
 	Example: `  # Stop an agent by ID (waits for pause)
   swarm stop abc123
 
@@ -44,15 +69,29 @@ When using --label, the task-id-or-name argument is not required.`,
   # Return immediately without waiting
   swarm stop my-agent --no-wait
 
+  # Pause for two hours, then resume automatically
+  swarm pause my-agent --for 2h
+
+  # Wait for approval before the next iteration
+  swarm stop my-agent --await-approval "review the schema change"
+
+  # Finish the current iteration and 3 more, then exit
+  swarm stop my-agent --after 3
+
   # Custom timeout (default 300 seconds)
   swarm stop my-agent --timeout 60
 
+  # Pause several agents at once
+  swarm pause frontend backend
+
+  # Pause every running agent in scope
+  swarm pause --all
+
   # Stop all agents with a specific label
   swarm stop --label team=backend
 
   # Stop all agents with multiple labels (AND logic)
   swarm stop --label env=staging --label priority=low`,
-	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Create state manager with scope
 		mgr, err := state.NewManagerWithScope(GetScope(), "")
@@ -60,50 +99,71 @@ When using --label, the task-id-or-name argument is not required.`,
 			return fmt.Errorf("failed to initialize state manager: %w", err)
 		}
 
-		// Handle label-based batch stop
-		if len(stopLabels) > 0 {
-			labelFilters, err := label.ParseMultiple(stopLabels)
+		if stopFor < 0 {
+			return fmt.Errorf("--for must be a positive duration")
+		}
+		if stopAfter < 0 {
+			return fmt.Errorf("--after must be a positive number of iterations")
+		}
+		if stopApprove != "" && (stopFor > 0 || stopAfter > 0) {
+			return fmt.Errorf("--await-approval waits for 'swarm approve' and can't be combined with --for or --after")
+		}
+		if stopAfter > 0 {
+			if stopFor > 0 {
+				return fmt.Errorf("--after stops agents for good and can't be combined with --for")
+			}
+			if !stopAll && len(stopLabels) == 0 && len(args) == 0 {
+				return fmt.Errorf("task-id-or-name is required (or use --label or --all for batch operations)")
+			}
+			agents, err := resolveControlTargets(mgr, args, stopLabels, stopAll)
 			if err != nil {
-				return fmt.Errorf("invalid label filter: %w", err)
+				return err
 			}
+			return stopAgentsAfter(mgr, agents, stopAfter)
+		}
 
-			// Get all running agents
-			agents, err := mgr.List(true) // true = only running
+		// Handle batch stop of several agents, labels, or --all
+		if stopAll || len(stopLabels) > 0 || len(args) > 1 {
+			agents, err := resolveControlTargets(mgr, args, stopLabels, stopAll)
 			if err != nil {
-				return fmt.Errorf("failed to list agents: %w", err)
+				return err
 			}
 
-			// Filter by labels and not already paused
-			var matched []*state.AgentState
+			// Skip agents that aren't running or are already paused
+			var ids []string
 			for _, agent := range agents {
-				if label.Match(agent.Labels, labelFilters) && !agent.Paused {
-					matched = append(matched, agent)
+				switch {
+				case agent.Status != "running":
+					fmt.Printf("Skipping %s: agent is not running (status: %s)\n", agent.ID, agent.Status)
+				case agent.Paused:
+					// --all and labels select every running agent; only mention explicit targets
+					if len(args) > 0 {
+						fmt.Printf("Agent %s is already paused\n", agent.ID)
+					}
+				default:
+					ids = append(ids, agent.ID)
 				}
 			}
 
-			if len(matched) == 0 {
-				fmt.Println("No running agents found matching the specified labels (or all matching agents are already paused)")
+			if len(ids) == 0 {
+				fmt.Println("No running agents to pause")
 				return nil
 			}
 
-			// Stop all matching agents (use atomic method for control field)
-			stopped := 0
-			for _, agent := range matched {
-				if err := mgr.SetPaused(agent.ID, true); err != nil {
-					fmt.Printf("Warning: failed to update agent %s: %v\n", agent.ID, err)
-					continue
-				}
-				fmt.Printf("Agent %s will pause after current iteration\n", agent.ID)
-				stopped++
+			if err := pauseAgents(mgr, ids); err != nil {
+				return fmt.Errorf("failed to update agent state: %w", err)
+			}
+			for _, id := range ids {
+				fmt.Printf("Agent %s will pause after current iteration%s\n", id, pauseUntilSuffix())
 			}
 
-			fmt.Printf("Stopped %d agent(s)\n", stopped)
+			fmt.Printf("Stopped %d agent(s)\n", len(ids))
 			return nil
 		}
 
 		// Single agent mode - require argument
 		if len(args) == 0 {
-			return fmt.Errorf("task-id-or-name is required (or use --label for batch operations)")
+			return fmt.Errorf("task-id-or-name is required (or use --label or --all for batch operations)")
 		}
 
 		agentIdentifier := args[0]
@@ -123,11 +183,11 @@ When using --label, the task-id-or-name argument is not required.`,
 
 		agentID := agent.ID
 		// Use atomic method for control field to avoid race conditions
-		if err := mgr.SetPaused(agentID, true); err != nil {
+		if err := pauseAgents(mgr, []string{agentID}); err != nil {
 			return fmt.Errorf("failed to update agent state: %w", err)
 		}
 
-		fmt.Printf("Agent %s will pause after current iteration\n", agentID)
+		fmt.Printf("Agent %s will pause after current iteration%s\n", agentID, pauseUntilSuffix())
 		if agent.Name != "" {
 			fmt.Printf("Name: %s\n", agent.Name)
 		}
@@ -166,11 +226,102 @@ When using --label, the task-id-or-name argument is not required.`,
 	},
 }
 
+// stopAgentsAfter lets each running agent finish its current iteration and
+// n more before it exits.
+func stopAgentsAfter(mgr *state.Manager, agents []*state.AgentState, n int) error {
+	stopped := 0
+	for _, agent := range agents {
+		if agent.Status != "running" {
+			fmt.Printf("Skipping %s: agent is not running (status: %s)\n", agent.ID, agent.Status)
+			continue
+		}
+		iterations, err := mgr.LimitRemainingIterations(agent.ID, n)
+		if err != nil {
+			return fmt.Errorf("failed to update agent state: %w", err)
+		}
+		fmt.Printf("Agent %s will stop after iteration %d\n", agent.ID, iterations)
+		stopped++
+	}
+	if len(agents) > 1 {
+		fmt.Printf("Limited %d agent(s)\n", stopped)
+	}
+	return nil
+}
+
+// pauseAgents pauses agents in one state update, with an automatic resume
+// time when --for is set, or until approved when --await-approval is.
+func pauseAgents(mgr *state.Manager, ids []string) error {
+	if stopApprove != "" {
+		return mgr.AwaitApproval(ids, stopApprove)
+	}
+	if stopFor > 0 {
+		resumeAt := time.Now().Add(stopFor)
+		return mgr.SetPausedMany(ids, true, &resumeAt)
+	}
+	return mgr.SetPausedMany(ids, true, nil)
+}
+
+// pauseUntilSuffix describes when a --for or --await-approval pause ends.
+func pauseUntilSuffix() string {
+	if stopApprove != "" {
+		return " and wait for approval"
+	}
+	if stopFor <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" and resume at %s", time.Now().Add(stopFor).Format(time.DateTime))
+}
+
+// resolveControlTargets returns the agents selected by identifiers, label
+// filters, and --all (every running agent in scope), without duplicates.
+func resolveControlTargets(mgr *state.Manager, identifiers, labels []string, all bool) ([]*state.AgentState, error) {
+	var targets []*state.AgentState
+	seen := make(map[string]bool)
+	add := func(agent *state.AgentState) {
+		if !seen[agent.ID] {
+			seen[agent.ID] = true
+			targets = append(targets, agent)
+		}
+	}
+
+	for _, identifier := range identifiers {
+		agent, err := ResolveAgentIdentifier(mgr, identifier)
+		if err != nil {
+			return nil, err
+		}
+		add(agent)
+	}
+
+	if all || len(labels) > 0 {
+		labelFilters, err := label.ParseMultiple(labels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label filter: %w", err)
+		}
+
+		agents, err := mgr.List(true) // true = only running
+		if err != nil {
+			return nil, fmt.Errorf("failed to list agents: %w", err)
+		}
+		for _, agent := range agents {
+			if label.Match(agent.Labels, labelFilters) {
+				add(agent)
+			}
+		}
+	}
+
+	return targets, nil
+}
+
 func init() {
+	stopCmd.Flags().DurationVar(&stopFor, "for", 0, "Resume automatically after this duration (e.g. 30m, 2h)")
+	stopCmd.Flags().StringVar(&stopApprove, "await-approval", "", "Wait for 'swarm approve' instead of a resume, saying what needs approving")
+	stopCmd.Flags().IntVar(&stopAfter, "after", 0, "Exit after the current iteration and this many more, instead of pausing")
 	stopCmd.Flags().BoolVar(&stopNoWait, "no-wait", false, "Return immediately without waiting for agent to pause")
 	stopCmd.Flags().IntVar(&stopTimeout, "timeout", 300, "Maximum seconds to wait for agent to pause")
 	stopCmd.Flags().StringArrayVarP(&stopLabels, "label", "l", nil, "Stop agents matching label (can be repeated for AND logic)")
+	stopCmd.Flags().BoolVar(&stopAll, "all", false, "Stop all running agents in scope")
 
 	// Add dynamic completion for agent identifier
 	stopCmd.ValidArgsFunction = completeRunningAgentIdentifier
+	stopCmd.RegisterFlagCompletionFunc("label", completeLabel)
 }
@@ -17,7 +17,7 @@ var (
 
 var summaryCmd = &cobra.Command{
 	Use:   "summary [task-id-or-name]",
-	Short: "Show a summary of an agent's run",
+	Short: "Show a summary of an agent's run, or a digest of recent runs",
 	Long: `Display a concise summary of what an agent accomplished.
 
 Parses agent logs to extract key information including:
@@ -27,7 +27,15 @@ Parses agent logs to extract key information including:
 - Key milestones and events
 
 The agent can be specified by its ID, name, or special identifier:
-  - @last or _ : the most recently started agent`,
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
+
+Without an agent, prints a digest of the agents active in the last 24 hours
+(or since --since), like a daily standup of the swarm: agents run, iterations,
+successes and failures, cost, files changed, and notable errors. With
+--notify the digest is also sent to the configured notification backends
+(notify_desktop, slack_webhook_url, notify_cmd), e.g. from a daily cron job.`,
 	Example: `  # Summary of agent by ID
   swarm summary abc123
 
@@ -41,16 +49,28 @@ The agent can be specified by its ID, name, or special identifier:
   swarm summary my-agent --format json
 
   # More detailed output
-  swarm summary my-agent --verbose`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		agentIdentifier := args[0]
+  swarm summary my-agent --verbose
+
+  # Digest of the last 24 hours
+  swarm summary
 
+  # Digest of the past week across all projects, posted to Slack
+  swarm summary --since 7d --global --notify`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		mgr, err := state.NewManagerWithScope(GetScope(), "")
 		if err != nil {
 			return fmt.Errorf("failed to initialize state manager: %w", err)
 		}
 
+		if len(args) == 0 {
+			return runDigest(mgr)
+		}
+		if cmd.Flags().Changed("since") || summaryNotify {
+			return fmt.Errorf("--since and --notify are for the digest, which takes no agent")
+		}
+		agentIdentifier := args[0]
+
 		agent, err := ResolveAgentIdentifier(mgr, agentIdentifier)
 		if err != nil {
 			return err
@@ -165,6 +185,13 @@ func printSummary(agent *state.AgentState, s *logsummary.Summary, verbose bool)
 		fmt.Println()
 	}
 
+	// Notes recorded by humans
+	if len(agent.Notes) > 0 {
+		bold.Println("Notes:")
+		printNotes(agent.Notes)
+		fmt.Println()
+	}
+
 	// Final state
 	if s.LastAction != "" {
 		bold.Println("Final State:")
@@ -175,6 +202,8 @@ func printSummary(agent *state.AgentState, s *logsummary.Summary, verbose bool)
 func init() {
 	summaryCmd.Flags().StringVar(&summaryFormat, "format", "", "Output format: json or table (default)")
 	summaryCmd.Flags().BoolVarP(&summaryVerbose, "verbose", "v", false, "Show more detailed output")
+	summaryCmd.Flags().StringVar(&summarySince, "since", digestDefaultSince, "Period the digest covers (e.g., 24h, 7d, 2024-01-28)")
+	summaryCmd.Flags().BoolVar(&summaryNotify, "notify", false, "Also send the digest to the configured notification backends")
 
 	// Add completion
 	summaryCmd.ValidArgsFunction = completeAgentIdentifier
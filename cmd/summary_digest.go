@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/logsummary"
+	"github.com/mj1618/swarm-cli/internal/notify"
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// digestDefaultSince is the period a digest covers without --since.
+const digestDefaultSince = "24h"
+
+// digestErrorLimit is the number of notable errors a digest lists.
+const digestErrorLimit = 5
+
+var (
+	summarySince  string
+	summaryNotify bool
+)
+
+// Digest summarizes the agents active in a period, e.g. as a daily standup
+// of the swarm.
+type Digest struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+
+	Agents    int `json:"agents"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Stopped   int `json:"stopped"`
+
+	Iterations           int `json:"iterations"`
+	IterationsSuccessful int `json:"iterations_successful"`
+	IterationsFailed     int `json:"iterations_failed"`
+
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+
+	FilesCreated  int `json:"files_created"`
+	FilesModified int `json:"files_modified"`
+	FilesDeleted  int `json:"files_deleted"`
+
+	// Errors are the last errors of agents with failed iterations, most
+	// recent first
+	Errors []DigestError `json:"errors,omitempty"`
+}
+
+// DigestError is the last error of an agent in a digest.
+type DigestError struct {
+	Agent   string `json:"agent"`
+	Class   string `json:"class,omitempty"`
+	Message string `json:"message"`
+}
+
+// digestAgents returns the agents active between since and until: started in
+// the period, or still running or terminated after since.
+func digestAgents(agents []*state.AgentState, since, until time.Time) []*state.AgentState {
+	var active []*state.AgentState
+	for _, agent := range agents {
+		if agent.StartedAt.After(until) {
+			continue
+		}
+		if agent.TerminatedAt != nil && agent.TerminatedAt.Before(since) {
+			continue
+		}
+		if agent.TerminatedAt == nil && agent.Status != "running" && agent.StartedAt.Before(since) {
+			continue
+		}
+		active = append(active, agent)
+	}
+	return active
+}
+
+// buildDigest summarizes the agents active between since and until. Each
+// agent counts with its whole run. parse reads an agent's log summary for the
+// files it changed; agents whose logs can't be read count without them.
+func buildDigest(agents []*state.AgentState, since, until time.Time, parse func(*state.AgentState) (*logsummary.Summary, error)) *Digest {
+	d := &Digest{Since: since, Until: until}
+
+	type agentError struct {
+		at  time.Time
+		err DigestError
+	}
+	var errs []agentError
+
+	for _, agent := range digestAgents(agents, since, until) {
+		d.Agents++
+		switch {
+		case agent.Status == "running":
+			d.Running++
+		case agent.ExitReason == "completed":
+			d.Succeeded++
+		case agent.ExitReason == "killed" || agent.ExitReason == "signal":
+			d.Stopped++
+		default:
+			d.Failed++
+		}
+
+		d.Iterations += agent.SuccessfulIters + agent.FailedIters
+		d.IterationsSuccessful += agent.SuccessfulIters
+		d.IterationsFailed += agent.FailedIters
+		d.InputTokens += agent.InputTokens
+		d.OutputTokens += agent.OutputTokens
+		d.CostUSD += agent.TotalCost
+
+		if parse != nil {
+			if summary, err := parse(agent); err == nil {
+				d.FilesCreated += summary.FilesCreated
+				d.FilesModified += summary.FilesModified
+				d.FilesDeleted += summary.FilesDeleted
+			}
+		}
+
+		if agent.FailedIters > 0 && agent.LastError != "" {
+			at := agent.StartedAt
+			if agent.TerminatedAt != nil {
+				at = *agent.TerminatedAt
+			}
+			message, _, _ := strings.Cut(strings.TrimSpace(agent.LastError), "\n")
+			errs = append(errs, agentError{at: at, err: DigestError{
+				Agent:   agentLabel(agent),
+				Class:   agent.LastErrorClass,
+				Message: message,
+			}})
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].at.After(errs[j].at) })
+	for i, e := range errs {
+		if i == digestErrorLimit {
+			break
+		}
+		d.Errors = append(d.Errors, e.err)
+	}
+	return d
+}
+
+// agentLabel names an agent by its name and ID.
+func agentLabel(agent *state.AgentState) string {
+	if agent.Name == "" {
+		return agent.ID
+	}
+	return fmt.Sprintf("%s (%s)", agent.Name, agent.ID)
+}
+
+// Format renders the digest as plain text, for the terminal and for
+// notifications.
+func (d *Digest) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Swarm digest: %s – %s\n", d.Since.Format("2006-01-02 15:04"), d.Until.Format("2006-01-02 15:04"))
+	if d.Agents == 0 {
+		b.WriteString("No agents ran.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Agents:      %d (%d succeeded, %d failed, %d stopped, %d running)\n", d.Agents, d.Succeeded, d.Failed, d.Stopped, d.Running)
+	fmt.Fprintf(&b, "Iterations:  %d (%d succeeded, %d failed)\n", d.Iterations, d.IterationsSuccessful, d.IterationsFailed)
+	fmt.Fprintf(&b, "Cost:        $%.2f (%s in, %s out)\n", d.CostUSD, formatTokenCount(d.InputTokens), formatTokenCount(d.OutputTokens))
+	fmt.Fprintf(&b, "Files:       %d created, %d modified, %d deleted\n", d.FilesCreated, d.FilesModified, d.FilesDeleted)
+
+	if len(d.Errors) > 0 {
+		b.WriteString("\nNotable errors:\n")
+		for _, e := range d.Errors {
+			message := e.Message
+			if len(message) > 120 {
+				message = message[:117] + "..."
+			}
+			if e.Class != "" {
+				fmt.Fprintf(&b, "  - %s [%s]: %s\n", e.Agent, e.Class, message)
+			} else {
+				fmt.Fprintf(&b, "  - %s: %s\n", e.Agent, message)
+			}
+		}
+	}
+	return b.String()
+}
+
+// runDigest prints (and with --notify, sends) a digest of the agents active
+// since --since.
+func runDigest(mgr *state.Manager) error {
+	since, err := ParseTimeFlag(summarySince)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	agents, err := mgr.List(false)
+	if err != nil {
+		return fmt.Errorf("failed to list agents: %w", err)
+	}
+	digest := buildDigest(agents, since, time.Now(), logsummary.Parse)
+
+	if summaryFormat == "json" {
+		output, err := json.MarshalIndent(digest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal digest: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		fmt.Print(digest.Format())
+	}
+
+	if summaryNotify {
+		notifier := notify.FromConfig(appConfig)
+		if !notifier.Enabled() {
+			return fmt.Errorf("no notification backend configured (set notify_desktop, slack_webhook_url, or notify_cmd)")
+		}
+		project := ""
+		if GetScope() != scope.ScopeGlobal {
+			project, _ = os.Getwd()
+		}
+		if err := notifier.Send(notify.DigestEvent(project, digest.Format())); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Sent the digest to the configured notification backends")
+	}
+	return nil
+}
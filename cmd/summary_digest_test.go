@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/logsummary"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestBuildDigest(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	agents := []*state.AgentState{
+		// Finished before the period
+		{ID: "old", Status: "terminated", StartedAt: now.Add(-48 * time.Hour), TerminatedAt: at(-30 * time.Hour), ExitReason: "completed", TotalCost: 100},
+		// Started before the period, finished in it
+		{ID: "a1", Name: "coder", Status: "terminated", StartedAt: now.Add(-26 * time.Hour), TerminatedAt: at(-20 * time.Hour),
+			ExitReason: "completed", SuccessfulIters: 3, InputTokens: 1000, OutputTokens: 100, TotalCost: 1.5},
+		{ID: "a2", Name: "tester", Status: "terminated", StartedAt: now.Add(-5 * time.Hour), TerminatedAt: at(-4 * time.Hour),
+			ExitReason: "rate_limited", SuccessfulIters: 1, FailedIters: 2, LastError: "429 too many requests\nretry later", LastErrorClass: "rate_limited", TotalCost: 0.5},
+		{ID: "a3", Status: "terminated", StartedAt: now.Add(-3 * time.Hour), TerminatedAt: at(-2 * time.Hour),
+			ExitReason: "killed", SuccessfulIters: 1},
+		{ID: "a4", Name: "reviewer", Status: "running", StartedAt: now.Add(-time.Hour), SuccessfulIters: 1, FailedIters: 1,
+			LastError: "iteration timed out after 10m0s", LastErrorClass: "timeout", TotalCost: 0.25},
+	}
+
+	parse := func(agent *state.AgentState) (*logsummary.Summary, error) {
+		if agent.ID == "a3" {
+			return nil, errors.New("no log file")
+		}
+		return &logsummary.Summary{FilesCreated: 1, FilesModified: 2}, nil
+	}
+
+	d := buildDigest(agents, since, now, parse)
+
+	if d.Agents != 4 || d.Succeeded != 1 || d.Failed != 1 || d.Stopped != 1 || d.Running != 1 {
+		t.Errorf("agents = %d (%d succeeded, %d failed, %d stopped, %d running), want 4 (1, 1, 1, 1)",
+			d.Agents, d.Succeeded, d.Failed, d.Stopped, d.Running)
+	}
+	if d.Iterations != 9 || d.IterationsSuccessful != 6 || d.IterationsFailed != 3 {
+		t.Errorf("iterations = %d (%d ok, %d failed), want 9 (6, 3)", d.Iterations, d.IterationsSuccessful, d.IterationsFailed)
+	}
+	if d.CostUSD != 2.25 {
+		t.Errorf("CostUSD = %v, want 2.25", d.CostUSD)
+	}
+	if d.FilesCreated != 3 || d.FilesModified != 6 {
+		t.Errorf("files = %d created, %d modified, want 3, 6", d.FilesCreated, d.FilesModified)
+	}
+
+	// Most recent first, first line only
+	if len(d.Errors) != 2 {
+		t.Fatalf("got %d errors, want 2: %+v", len(d.Errors), d.Errors)
+	}
+	if d.Errors[0].Agent != "reviewer (a4)" || d.Errors[0].Class != "timeout" {
+		t.Errorf("Errors[0] = %+v, want the reviewer's timeout", d.Errors[0])
+	}
+	if d.Errors[1].Message != "429 too many requests" {
+		t.Errorf("Errors[1].Message = %q, want the first line", d.Errors[1].Message)
+	}
+
+	text := d.Format()
+	for _, want := range []string{"Agents:      4 (1 succeeded, 1 failed, 1 stopped, 1 running)", "Cost:        $2.25", "Notable errors:", "tester (a2) [rate_limited]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Format() missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestBuildDigestEmpty(t *testing.T) {
+	now := time.Now()
+	d := buildDigest(nil, now.Add(-24*time.Hour), now, nil)
+	if d.Agents != 0 {
+		t.Errorf("Agents = %d, want 0", d.Agents)
+	}
+	if !strings.Contains(d.Format(), "No agents ran.") {
+		t.Errorf("Format() = %q, want no agents", d.Format())
+	}
+}
@@ -8,21 +8,27 @@ import (
 	"os/exec"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/label"
 	"github.com/mj1618/swarm-cli/internal/logparser"
 	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/scope"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
 	"github.com/spf13/cobra"
 )
 
 var (
-	topInterval time.Duration
-	topAll      bool
+	topInterval    time.Duration
+	topAll         bool
+	topPipeline    string
+	topLabels      []string
+	topColumnsFlag string
 )
 
 var topCmd = &cobra.Command{
@@ -30,11 +36,44 @@ var topCmd = &cobra.Command{
 	Short: "Real-time agent monitoring dashboard",
 	Long: `Display a real-time TUI dashboard showing all running agents.
 
-The dashboard shows agent status, iterations, token usage, costs, current task,
-and optionally streaming logs for the selected agent.
+The dashboard shows agent status, iterations, token usage, costs, CPU and memory,
+current task, and optionally streaming logs for the selected agent.
+
+In global mode, agents are grouped by project (git repository or working
+directory) with per-project agent counts and cost.
 
 Use arrow keys or j/k to navigate between agents. Press Enter to attach
-to the selected agent, or use keyboard shortcuts for quick actions.`,
+to the selected agent, or use keyboard shortcuts for quick actions.
+
+Use --columns (or top_columns in swarm.toml) to pick the table's columns and
+their widths, e.g. --columns id,name:30,status,cost,task. Available columns:
+id, name, parent, status, iter, tokens, cost, budget, cpu, mem, task, model,
+prompt, labels, dir. The budget column shows how much of the agent's
+--budget-usd/--budget-tokens (or budget_usd/budget_tokens in swarm.yaml) it
+has used.
+
+Use --pipeline and --label to start the dashboard filtered to one pipeline's
+agents (and their sub-agents) or to agents with matching labels, e.g. on a
+shared machine where --global shows many unrelated agents.
+
+An agent whose current iteration has run slow_iteration_factor (default 3)
+times longer than its median iteration is shown as "slow"; it may be stuck
+in a loop.
+
+When alert_cost_usd is set in swarm.toml, a banner is shown under the header
+once a project's agents have cost more than it in total.
+
+If the current directory uses the todo-file queue (swarm/todos, or todo_dir in
+swarm.toml), a line under the header shows its depth, how long the oldest
+claim has been processing, and the hourly rates tasks are created and done,
+flagging when planners are outpacing doers.
+
+With dedup_logs = true in swarm.toml, identical consecutive lines in the log
+panel (e.g. from an agent stuck in a retry loop) are collapsed into "previous
+message repeated N times".
+
+The log panel grows with the terminal; set top_log_lines in swarm.toml to
+show a fixed number of lines instead.`,
 	Example: `  # Monitor agents in current project
   swarm top
 
@@ -45,10 +84,31 @@ to the selected agent, or use keyboard shortcuts for quick actions.`,
   swarm top --all
 
   # Faster refresh rate
-  swarm top --interval 1s`,
+  swarm top --interval 1s
+
+  # Choose columns, with a wider name
+  swarm top --columns id,name:30,status,cost,task
+
+  # Only the agents of the main pipeline
+  swarm top --global --pipeline main
+
+  # Only agents labeled team=frontend
+  swarm top --global --label team=frontend`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := tea.NewProgram(initialTopModel(), tea.WithAltScreen())
-		_, err := p.Run()
+		labelFilters, err := label.ParseMultiple(topLabels)
+		if err != nil {
+			return fmt.Errorf("invalid label filter: %w", err)
+		}
+
+		m := initialTopModel()
+		m.pipeline = topPipeline
+		m.labels = labelFilters
+		m.columns, err = chooseColumns(topColumnsFlag, topConfigColumns(m.cfg), topColumnNames, defaultTopColumns)
+		if err != nil {
+			return err
+		}
+		p := tea.NewProgram(m, tea.WithAltScreen())
+		_, err = p.Run()
 		return err
 	},
 }
@@ -72,6 +132,19 @@ var (
 	terminatedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196"))
 
+	unhealthyStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("196"))
+
+	slowStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("208"))
+
+	alertStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("231")).
+			Background(lipgloss.Color("160"))
+
 	dimStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("245"))
 
@@ -91,12 +164,25 @@ var (
 
 	taskStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("147"))
+
+	projectStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("75"))
 )
 
 type tickMsg time.Time
 type logLineMsg string
 type logLinesMsg []string
 
+// usageMsg carries process tree resource usage keyed by agent ID.
+type usageMsg map[string]process.Usage
+
+// costAlertsMsg carries the cost alerts that have fired for projects in scope.
+type costAlertsMsg []state.CostAlertRecord
+
+// todoQueueMsg carries the todo-file queue's metrics (nil if it isn't in use).
+type todoQueueMsg struct{ metrics *todoqueue.Metrics }
+
 type topModel struct {
 	mgr           *state.Manager
 	cfg           *config.Config
@@ -106,6 +192,9 @@ type topModel struct {
 	height        int
 	showAll       bool
 	global        bool
+	pipeline      string            // only show this pipeline's agents (empty = all)
+	labels        map[string]string // only show agents matching these labels
+	columns       []columnSpec
 	interval      time.Duration
 	err           error
 	showLogs      bool
@@ -114,6 +203,12 @@ type topModel struct {
 	logWatcherID  string // ID of agent whose logs we're watching
 	logFile       *os.File
 	logFileReader *bufio.Reader
+	logDedup      *logparser.Dedup // collapses repeated log lines (dedup_logs)
+	sampler       *process.Sampler
+	usage         map[string]process.Usage
+	costAlerts    []state.CostAlertRecord
+	todoQueue     *todoqueue.Metrics
+	projects      *topProjectCache // project of each working directory (global view)
 }
 
 func initialTopModel() topModel {
@@ -123,23 +218,35 @@ func initialTopModel() topModel {
 	mgr, err := state.NewManagerWithScope(s, "")
 	cfg, _ := config.Load()
 
+	maxLogLines := 15
+	if cfg != nil && cfg.TopLogLines > 0 {
+		maxLogLines = cfg.TopLogLines
+	}
+
 	return topModel{
 		mgr:         mgr,
 		cfg:         cfg,
 		cursor:      0,
 		showAll:     topAll,
+		columns:     defaultTopColumns,
 		global:      global,
 		interval:    topInterval,
 		err:         err,
 		showLogs:    true,
 		logLines:    make([]string, 0),
-		maxLogLines: 15,
+		maxLogLines: maxLogLines,
+		sampler:     process.NewSampler(),
+		usage:       make(map[string]process.Usage),
+		projects:    newTopProjectCache(),
 	}
 }
 
 func (m topModel) Init() tea.Cmd {
 	return tea.Batch(
 		m.refreshAgentsCmd(),
+		m.refreshCostAlertsCmd(),
+		m.refreshTodoQueueCmd(),
+		m.sampleUsageCmd(),
 		m.tickCmd(),
 	)
 }
@@ -160,9 +267,22 @@ func (m topModel) refreshAgentsCmd() tea.Cmd {
 		if err != nil {
 			return err
 		}
+		agents = filterTopAgents(agents, m.pipeline, m.labels)
+
+		// In global view, agents are grouped by project so each group renders contiguously
+		var projects map[string]string
+		if m.global {
+			projects = make(map[string]string, len(agents))
+			for _, a := range agents {
+				projects[a.ID] = m.projects.key(a)
+			}
+		}
 
 		// Sort: running > paused > terminated, then by start time (newest first within category)
 		sort.Slice(agents, func(i, j int) bool {
+			if projects != nil && projects[agents[i].ID] != projects[agents[j].ID] {
+				return projects[agents[i].ID] < projects[agents[j].ID]
+			}
 			orderI := getStatusOrder(agents[i])
 			orderJ := getStatusOrder(agents[j])
 			if orderI != orderJ {
@@ -175,6 +295,160 @@ func (m topModel) refreshAgentsCmd() tea.Cmd {
 	}
 }
 
+// refreshCostAlertsCmd loads the cost alerts (alert_cost_usd) that have fired
+// for projects in scope, shown as a banner under the header.
+func (m topModel) refreshCostAlertsCmd() tea.Cmd {
+	return func() tea.Msg {
+		if m.mgr == nil || m.cfg == nil || m.cfg.AlertCostUSD <= 0 {
+			return costAlertsMsg(nil)
+		}
+		alerts, err := m.mgr.CostAlerts()
+		if err != nil {
+			return nil
+		}
+		return costAlertsMsg(alerts)
+	}
+}
+
+// refreshTodoQueueCmd samples the todo-file queue of the current directory,
+// shown as a line under the header.
+func (m topModel) refreshTodoQueueCmd() tea.Cmd {
+	return func() tea.Msg {
+		if m.mgr == nil {
+			return todoQueueMsg{}
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil
+		}
+		metrics, err := m.mgr.SampleTodoQueue(m.cfg.TodoQueueDirs(cwd))
+		if err != nil {
+			return nil
+		}
+		return todoQueueMsg{metrics}
+	}
+}
+
+// sampleUsageCmd samples CPU and memory for the process tree of each running agent.
+func (m topModel) sampleUsageCmd() tea.Cmd {
+	return func() tea.Msg {
+		if m.sampler == nil {
+			return nil
+		}
+
+		pidToID := make(map[int]string)
+		var pids []int
+		for _, a := range m.agents {
+			if a.Status == "running" && a.PID > 0 {
+				pidToID[a.PID] = a.ID
+				pids = append(pids, a.PID)
+			}
+		}
+
+		samples, err := m.sampler.Sample(pids)
+		if err != nil {
+			// Sampling is best-effort; leave the columns blank
+			return usageMsg(nil)
+		}
+
+		usage := make(usageMsg, len(samples))
+		for pid, u := range samples {
+			usage[pidToID[pid]] = u
+		}
+		return usage
+	}
+}
+
+// filterTopAgents keeps the agents of pipeline (its instances and their
+// descendants) that match the label filters. An empty pipeline and no
+// labels keep every agent.
+func filterTopAgents(agents []*state.AgentState, pipeline string, labels map[string]string) []*state.AgentState {
+	if pipeline == "" && len(labels) == 0 {
+		return agents
+	}
+
+	byID := make(map[string]*state.AgentState, len(agents))
+	for _, a := range agents {
+		byID[a.ID] = a
+	}
+	inPipeline := func(a *state.AgentState) bool {
+		// Walk up to the root agent, guarding against parent cycles
+		for seen := 0; a != nil && seen <= len(agents); seen++ {
+			if isPipelineInstance(a.Name, pipeline) {
+				return true
+			}
+			a = byID[a.ParentID]
+		}
+		return false
+	}
+
+	var filtered []*state.AgentState
+	for _, a := range agents {
+		if pipeline != "" && !inPipeline(a) {
+			continue
+		}
+		if !label.Match(a.Labels, labels) {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
+
+// topProjectCache remembers the project of each working directory, so the
+// filesystem walk to find it isn't repeated for every agent on every refresh.
+// It is shared by the refresh commands and View, hence the lock.
+type topProjectCache struct {
+	mu    sync.Mutex
+	roots map[string]string
+}
+
+func newTopProjectCache() *topProjectCache {
+	return &topProjectCache{roots: make(map[string]string)}
+}
+
+// key returns the project an agent belongs to: the git repository root of
+// its working directory, or the working directory itself.
+func (c *topProjectCache) key(a *state.AgentState) string {
+	if a.WorkingDir == "" {
+		return "(unknown)"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if root, ok := c.roots[a.WorkingDir]; ok {
+		return root
+	}
+	root, ok := scope.ProjectRoot(a.WorkingDir)
+	if !ok {
+		root = a.WorkingDir
+	}
+	c.roots[a.WorkingDir] = root
+	return root
+}
+
+// shortenHome replaces the home directory prefix of path with ~.
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if strings.HasPrefix(path, home+string(os.PathSeparator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+// topProjectRollup summarizes the agents in one project group.
+type topProjectRollup struct {
+	count   int
+	running int
+	cost    float64
+}
+
 func getStatusOrder(a *state.AgentState) int {
 	if a.Status == "terminated" {
 		return 2
@@ -399,7 +673,7 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.mgr = mgr
 			m.cursor = 0
 			m.closeLogFile()
-			return m, m.refreshAgentsCmd()
+			return m, tea.Batch(m.refreshAgentsCmd(), m.refreshCostAlertsCmd())
 		}
 
 	case []*state.AgentState:
@@ -416,15 +690,24 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tickMsg:
 		var cmds []tea.Cmd
-		cmds = append(cmds, m.refreshAgentsCmd(), m.tickCmd())
+		cmds = append(cmds, m.refreshAgentsCmd(), m.refreshCostAlertsCmd(), m.refreshTodoQueueCmd(), m.sampleUsageCmd(), m.tickCmd())
 		if m.showLogs && m.logFile != nil {
 			cmds = append(cmds, m.readNewLogLines())
 		}
 		return m, tea.Batch(cmds...)
 
+	case usageMsg:
+		m.usage = msg
+
+	case costAlertsMsg:
+		m.costAlerts = msg
+
+	case todoQueueMsg:
+		m.todoQueue = msg.metrics
+
 	case logLinesMsg:
 		for _, line := range msg {
-			m.logLines = append(m.logLines, line)
+			m.appendLogLine(line)
 		}
 		// Trim to max lines
 		if len(m.logLines) > m.maxLogLines*2 {
@@ -434,8 +717,10 @@ func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Adjust max log lines based on height
-		if m.height > 30 {
+		// Adjust max log lines based on height, unless top_log_lines fixes it
+		if m.cfg != nil && m.cfg.TopLogLines > 0 {
+			m.maxLogLines = m.cfg.TopLogLines
+		} else if m.height > 30 {
 			m.maxLogLines = (m.height - 20) / 2
 		} else {
 			m.maxLogLines = 8
@@ -460,6 +745,10 @@ func (m *topModel) closeLogFile() {
 func (m *topModel) switchLogFile() {
 	m.closeLogFile()
 	m.logLines = nil
+	m.logDedup = nil
+	if m.cfg != nil && m.cfg.DedupLogs {
+		m.logDedup = &logparser.Dedup{}
+	}
 
 	if !m.showLogs || len(m.agents) == 0 || m.cursor >= len(m.agents) {
 		return
@@ -498,7 +787,7 @@ func (m *topModel) switchLogFile() {
 		}
 		formatted := formatLogLine(line)
 		if formatted != "" {
-			m.logLines = append(m.logLines, formatted)
+			m.appendLogLine(formatted)
 		}
 	}
 
@@ -508,6 +797,23 @@ func (m *topModel) switchLogFile() {
 	}
 }
 
+// appendLogLine adds a formatted line to the log panel. With dedup_logs,
+// repeats of the previous line are counted on a line of their own instead.
+func (m *topModel) appendLogLine(line string) {
+	if m.logDedup != nil {
+		if _, skip := m.logDedup.Add(line); skip {
+			summary := "(" + logparser.RepeatedMessage(m.logDedup.Repeats()) + ")"
+			if m.logDedup.Repeats() > 1 && len(m.logLines) > 0 {
+				m.logLines[len(m.logLines)-1] = summary
+			} else {
+				m.logLines = append(m.logLines, summary)
+			}
+			return
+		}
+	}
+	m.logLines = append(m.logLines, line)
+}
+
 func (m topModel) View() string {
 	if m.err != nil {
 		return fmt.Sprintf("Error: %v\n\nPress q to quit.", m.err)
@@ -517,7 +823,16 @@ func (m topModel) View() string {
 
 	// Header
 	b.WriteString(m.renderHeader())
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	for _, alert := range m.costAlerts {
+		b.WriteString(renderCostAlert(alert))
+		b.WriteString("\n")
+	}
+	if m.todoQueue != nil {
+		b.WriteString(renderTodoQueue(m.todoQueue))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	// Agent table
 	b.WriteString(m.renderTable())
@@ -562,6 +877,12 @@ func (m topModel) renderHeader() string {
 	if m.showAll {
 		allIndicator = " +all"
 	}
+	if m.pipeline != "" {
+		allIndicator += " pipeline=" + m.pipeline
+	}
+	if len(m.labels) > 0 {
+		allIndicator += " " + label.Format(m.labels)
+	}
 
 	tokensStr := formatTokenCount(totalTokens)
 	costStr := fmt.Sprintf("$%.2f", totalCost)
@@ -618,96 +939,109 @@ func (m topModel) renderHeader() string {
 	return headerStyle.Render(b.String())
 }
 
+// renderCostAlert renders the banner for a fired cost alert.
+func renderCostAlert(alert state.CostAlertRecord) string {
+	return alertStyle.Render(fmt.Sprintf(" COST ALERT: agents in %s crossed $%.2f ($%.2f at %s) ",
+		shortenHome(alert.Project), alert.Threshold, alert.CostUSD, alert.FiredAt.Format("Jan 2 15:04")))
+}
+
+// renderTodoQueue renders the todo-file queue's metrics, flagging planners
+// that are outpacing doers.
+func renderTodoQueue(metrics *todoqueue.Metrics) string {
+	line := dimStyle.Render("Todo queue: ") + metrics.String()
+	if metrics.PlannersAhead() {
+		line += slowStyle.Render("  (planners ahead of doers)")
+	}
+	return line
+}
+
 func (m topModel) renderTable() string {
 	if len(m.agents) == 0 {
+		if m.pipeline != "" || len(m.labels) > 0 {
+			return dimStyle.Render("  No agents match the --pipeline/--label filters.")
+		}
 		return dimStyle.Render("  No agents found. Start one with: swarm run -p <prompt>")
 	}
 
 	var b strings.Builder
 
-	// Column widths
-	const (
-		colID     = 8
-		colName   = 14
-		colParent = 10
-		colStatus = 10
-		colIter   = 7
-		colTokens = 8
-		colCost   = 7
-		colTask   = 30
-	)
-
 	// Header - build with exact spacing
-	header := fmt.Sprintf("  %-*s %-*s %-*s %-*s %-*s %-*s %-*s %s",
-		colID, "ID",
-		colName, "NAME",
-		colParent, "PARENT",
-		colStatus, "STATUS",
-		colIter, "ITER",
-		colTokens, "TOKENS",
-		colCost, "COST",
-		"TASK",
-	)
+	header := "  "
+	ruleWidth := len(m.columns) + 4
+	for i, spec := range m.columns {
+		col := topColumns[spec.Name]
+		width := specWidth(spec, col.width)
+		ruleWidth += width
+		if i == len(m.columns)-1 {
+			header += col.header
+		} else {
+			header += fmt.Sprintf("%-*s ", width, col.header)
+		}
+	}
 	b.WriteString(dimStyle.Render(header))
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  " + strings.Repeat("─", colID+colName+colParent+colStatus+colIter+colTokens+colCost+colTask+12)))
+	b.WriteString(dimStyle.Render("  " + strings.Repeat("─", ruleWidth)))
 	b.WriteString("\n")
 
-	for i, a := range m.agents {
-		prefix := "  "
-		if i == m.cursor {
-			prefix = "▸ "
-		}
-
-		name := a.Name
-		if name == "" {
-			name = "-"
-		}
-
-		parent := a.ParentID
-		if parent == "" {
-			parent = "-"
+	// In global view, compute per-project rollups for the group headers
+	var projectKeys []string
+	rollups := make(map[string]*topProjectRollup)
+	if m.global {
+		projectKeys = make([]string, len(m.agents))
+		for i, a := range m.agents {
+			key := m.projects.key(a)
+			projectKeys[i] = key
+			r, ok := rollups[key]
+			if !ok {
+				r = &topProjectRollup{}
+				rollups[key] = r
+			}
+			r.count++
+			if a.Status == "running" {
+				r.running++
+			}
+			r.cost += a.TotalCost
 		}
+	}
 
-		statusStr, statusSty := getStatusDisplay(a)
-
-		iterStr := fmt.Sprintf("%d/%d", a.CurrentIter, a.Iterations)
-		if a.Iterations == 0 {
-			iterStr = fmt.Sprintf("%d/∞", a.CurrentIter)
+	for i, a := range m.agents {
+		if m.global && (i == 0 || projectKeys[i] != projectKeys[i-1]) {
+			r := rollups[projectKeys[i]]
+			b.WriteString(projectStyle.Render("  " + shortenHome(projectKeys[i])))
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  %d agent(s), %d running, ", r.count, r.running)))
+			b.WriteString(costStyle.Render(fmt.Sprintf("$%.2f", r.cost)))
+			b.WriteString("\n")
 		}
 
-		tokens := a.InputTokens + a.OutputTokens
-		tokensStr := formatTokenCount(tokens)
-
-		costStr := fmt.Sprintf("$%.2f", a.TotalCost)
-
-		task := a.CurrentTask
-		if task == "" {
-			task = "-"
-		}
-		if len(task) > colTask {
-			task = task[:colTask-3] + "..."
+		prefix := "  "
+		if i == m.cursor {
+			prefix = "▸ "
 		}
 
 		// Build line with proper padding for each column
 		// Apply style to content, then pad to column width
 		var line strings.Builder
 		line.WriteString(prefix)
-		line.WriteString(padRight(truncateTop(a.ID, colID-1), colID))
-		line.WriteString(" ")
-		line.WriteString(padRight(truncateTop(name, colName-1), colName))
-		line.WriteString(" ")
-		line.WriteString(padRight(truncateTop(parent, colParent-1), colParent))
-		line.WriteString(" ")
-		line.WriteString(statusSty.Render(padRight(statusStr, colStatus)))
-		line.WriteString(" ")
-		line.WriteString(padRight(iterStr, colIter))
-		line.WriteString(" ")
-		line.WriteString(tokenStyle.Render(padLeft(tokensStr, colTokens)))
-		line.WriteString(" ")
-		line.WriteString(costStyle.Render(padLeft(costStr, colCost)))
-		line.WriteString(" ")
-		line.WriteString(taskStyle.Render(task))
+		for j, spec := range m.columns {
+			col := topColumns[spec.Name]
+			width := specWidth(spec, col.width)
+			value := col.value(m, a)
+			switch {
+			case j == len(m.columns)-1:
+				value = truncateTop(value, width)
+			case col.alignRight:
+				value = padLeft(value, width)
+			default:
+				value = padRight(truncateTop(value, width-1), width)
+			}
+			if col.style != nil {
+				value = col.style(a).Render(value)
+			}
+			line.WriteString(value)
+			if j < len(m.columns)-1 {
+				line.WriteString(" ")
+			}
+		}
 
 		if i == m.cursor {
 			b.WriteString(selectedStyle.Render(line.String()))
@@ -736,14 +1070,99 @@ func padLeft(s string, width int) string {
 	return strings.Repeat(" ", width-visualWidth) + s
 }
 
+// topColumn is a column of the `swarm top` agent table.
+type topColumn struct {
+	header     string
+	width      int
+	alignRight bool                                     // right-align and never truncate (numbers)
+	style      func(a *state.AgentState) lipgloss.Style // nil = unstyled
+	value      func(m topModel, a *state.AgentState) string
+}
+
+// topColumnNames are the columns `swarm top --columns` accepts.
+var topColumnNames = []string{"id", "name", "parent", "status", "iter", "tokens", "cost", "budget", "cpu", "mem", "task", "model", "prompt", "labels", "dir"}
+
+// defaultTopColumns are shown without --columns or top_columns.
+var defaultTopColumns = []columnSpec{
+	{Name: "id"}, {Name: "name"}, {Name: "parent"}, {Name: "status"}, {Name: "iter"},
+	{Name: "tokens"}, {Name: "cost"}, {Name: "budget"}, {Name: "cpu"}, {Name: "mem"}, {Name: "task"},
+}
+
+var topColumns = map[string]topColumn{
+	"id":     {header: "ID", width: 8, value: func(m topModel, a *state.AgentState) string { return a.ID }},
+	"name":   {header: "NAME", width: 14, value: func(m topModel, a *state.AgentState) string { return orDash(a.Name) }},
+	"parent": {header: "PARENT", width: 10, value: func(m topModel, a *state.AgentState) string { return orDash(a.ParentID) }},
+	"status": {
+		header: "STATUS",
+		width:  10,
+		style:  func(a *state.AgentState) lipgloss.Style { _, sty := getStatusDisplay(a); return sty },
+		value:  func(m topModel, a *state.AgentState) string { str, _ := getStatusDisplay(a); return str },
+	},
+	"iter": {header: "ITER", width: 7, value: func(m topModel, a *state.AgentState) string {
+		if a.Iterations == 0 {
+			return fmt.Sprintf("%d/∞", a.CurrentIter)
+		}
+		return fmt.Sprintf("%d/%d", a.CurrentIter, a.Iterations)
+	}},
+	"tokens": {
+		header:     "TOKENS",
+		width:      8,
+		alignRight: true,
+		style:      func(*state.AgentState) lipgloss.Style { return tokenStyle },
+		value:      func(m topModel, a *state.AgentState) string { return formatTokenCount(a.InputTokens + a.OutputTokens) },
+	},
+	"cost": {
+		header:     "COST",
+		width:      7,
+		alignRight: true,
+		style:      func(*state.AgentState) lipgloss.Style { return costStyle },
+		value:      func(m topModel, a *state.AgentState) string { return fmt.Sprintf("$%.2f", a.TotalCost) },
+	},
+	"budget": {header: "BUDGET", width: 6, alignRight: true, value: func(m topModel, a *state.AgentState) string { return formatBudgetUsed(a) }},
+	"cpu": {header: "CPU", width: 6, alignRight: true, value: func(m topModel, a *state.AgentState) string {
+		if u, ok := m.usage[a.ID]; ok && u.CPUPercent >= 0 {
+			return fmt.Sprintf("%.0f%%", u.CPUPercent)
+		}
+		return "-"
+	}},
+	"mem": {header: "MEM", width: 7, alignRight: true, value: func(m topModel, a *state.AgentState) string {
+		if u, ok := m.usage[a.ID]; ok {
+			return formatTopMemory(u.RSSBytes)
+		}
+		return "-"
+	}},
+	"task": {
+		header: "TASK",
+		width:  30,
+		style:  func(*state.AgentState) lipgloss.Style { return taskStyle },
+		value:  func(m topModel, a *state.AgentState) string { return orDash(a.CurrentTask) },
+	},
+	"model":  {header: "MODEL", width: 18, value: func(m topModel, a *state.AgentState) string { return a.Model }},
+	"prompt": {header: "PROMPT", width: 20, value: func(m topModel, a *state.AgentState) string { return a.Prompt }},
+	"labels": {header: "LABELS", width: 24, value: func(m topModel, a *state.AgentState) string { return label.Format(a.Labels) }},
+	"dir":    {header: "DIRECTORY", width: 30, value: func(m topModel, a *state.AgentState) string { return shortenHome(a.WorkingDir) }},
+}
+
+// topConfigColumns returns the top_columns preference from cfg.
+func topConfigColumns(cfg *config.Config) []string {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.TopColumns
+}
+
 func getStatusDisplay(a *state.AgentState) (string, lipgloss.Style) {
 	switch {
 	case a.Status == "terminated":
 		return "terminated", terminatedStyle
+	case a.Unhealthy():
+		return "UNHEALTHY", unhealthyStyle
 	case a.Paused && a.PausedAt != nil:
 		return "paused", pausedStyle
 	case a.Paused:
 		return "pausing", pausedStyle
+	case a.Slow():
+		return "slow", slowStyle
 	default:
 		return "running", runningStyle
 	}
@@ -902,7 +1321,7 @@ func (m topModel) killSelected() tea.Cmd {
 		}
 		m.mgr.SetTerminateMode(agent.ID, "immediate")
 		// Send kill signal
-		process.Kill(agent.PID)
+		agent.Kill()
 		return m.refreshAgentsCmd()()
 	}
 }
@@ -976,6 +1395,36 @@ func formatTokenCount(tokens int64) string {
 	return fmt.Sprintf("%d", tokens)
 }
 
+// formatBudgetUsed formats the percentage of its budget an agent has used,
+// e.g. "42%", or "-" if it has no budget.
+func formatBudgetUsed(a *state.AgentState) string {
+	used, ok := a.BudgetUsed()
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", used*100)
+}
+
+// formatTopMemory formats a byte count compactly for the top table (e.g. 512M, 1.2G).
+func formatTopMemory(b int64) string {
+	const (
+		kb = 1024
+		mb = 1024 * kb
+		gb = 1024 * mb
+	)
+	switch {
+	case b <= 0:
+		return "-"
+	case b >= gb:
+		return fmt.Sprintf("%.1fG", float64(b)/gb)
+	case b >= mb:
+		return fmt.Sprintf("%.0fM", float64(b)/mb)
+	case b >= kb:
+		return fmt.Sprintf("%.0fK", float64(b)/kb)
+	}
+	return fmt.Sprintf("%dB", b)
+}
+
 func formatTopDuration(d time.Duration) string {
 	h := int(d.Hours())
 	min := int(d.Minutes()) % 60
@@ -993,4 +1442,9 @@ func formatTopDuration(d time.Duration) string {
 func init() {
 	topCmd.Flags().DurationVarP(&topInterval, "interval", "i", 2*time.Second, "Refresh interval")
 	topCmd.Flags().BoolVarP(&topAll, "all", "a", false, "Show all agents including terminated")
+	topCmd.Flags().StringVarP(&topPipeline, "pipeline", "p", "", "Only show the agents of this pipeline")
+	topCmd.RegisterFlagCompletionFunc("pipeline", completePipelineName)
+	topCmd.Flags().StringArrayVarP(&topLabels, "label", "L", nil, "Only show agents with this label (key=value for exact match, key for existence check)")
+	topCmd.RegisterFlagCompletionFunc("label", completeLabel)
+	topCmd.Flags().StringVar(&topColumnsFlag, "columns", "", "Comma-separated columns to show, with optional widths (e.g. id,name:30,cost,task); default: top_columns from config")
 }
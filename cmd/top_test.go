@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestFilterTopAgents(t *testing.T) {
+	agents := []*state.AgentState{
+		{ID: "p1", Name: "pipeline:main", Labels: map[string]string{"team": "frontend"}},
+		{ID: "p2", Name: "pipeline:main.2"},
+		{ID: "sub", Name: "reviewer", ParentID: "p1"},
+		{ID: "nested", Name: "helper", ParentID: "sub", Labels: map[string]string{"team": "frontend"}},
+		{ID: "other", Name: "pipeline:nightly", Labels: map[string]string{"team": "frontend"}},
+		{ID: "solo", Name: "coder", Labels: map[string]string{"team": "backend"}},
+		{ID: "loop1", Name: "a", ParentID: "loop2"},
+		{ID: "loop2", Name: "b", ParentID: "loop1"},
+	}
+	ids := func(filtered []*state.AgentState) []string {
+		var out []string
+		for _, a := range filtered {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		pipeline string
+		labels   map[string]string
+		want     []string
+	}{
+		{"no filters", "", nil, []string{"p1", "p2", "sub", "nested", "other", "solo", "loop1", "loop2"}},
+		{"pipeline", "main", nil, []string{"p1", "p2", "sub", "nested"}},
+		{"label", "", map[string]string{"team": "frontend"}, []string{"p1", "nested", "other"}},
+		{"pipeline and label", "main", map[string]string{"team": "frontend"}, []string{"p1", "nested"}},
+		{"unknown pipeline", "missing", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(filterTopAgents(agents, tt.pipeline, tt.labels))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTopAppendLogLineDedup(t *testing.T) {
+	m := topModel{logDedup: &logparser.Dedup{}}
+	for _, line := range []string{"[tool] Shell: npm test", "[result] error", "[result] error", "[result] error", "[assistant] fixed"} {
+		m.appendLogLine(line)
+	}
+
+	want := []string{"[tool] Shell: npm test", "[result] error", "(previous message repeated 2 times)", "[assistant] fixed"}
+	if strings.Join(m.logLines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logLines = %q, want %q", m.logLines, want)
+	}
+}
+
+func TestTopProjectCache(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTopProjectCache()
+	a := &state.AgentState{WorkingDir: sub}
+	if got := c.key(a); got != root {
+		t.Fatalf("key() = %q, want the repository root %q", got, root)
+	}
+
+	// The root is remembered rather than looked up again
+	os.RemoveAll(filepath.Join(root, ".git"))
+	if got := c.key(a); got != root {
+		t.Errorf("key() = %q after the first lookup, want the cached %q", got, root)
+	}
+
+	if got := c.key(&state.AgentState{}); got != "(unknown)" {
+		t.Errorf("key() = %q without a working directory, want (unknown)", got)
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,12 +17,18 @@ import (
 	"github.com/mj1618/swarm-cli/internal/compose"
 	"github.com/mj1618/swarm-cli/internal/dag"
 	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/exitreport"
 	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/notify"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
 	"github.com/mj1618/swarm-cli/internal/output"
-	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/prompt"
+	"github.com/mj1618/swarm-cli/internal/repo"
+	"github.com/mj1618/swarm-cli/internal/scratch"
 	"github.com/mj1618/swarm-cli/internal/scope"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
+	"github.com/mj1618/swarm-cli/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -29,8 +38,17 @@ var (
 	upPipeline          string
 	upInternalDetached  bool
 	upInternalTaskID    string
+	upInternalInstance  string
+	upStdin             bool
+	upInternalStdin     string
+	upLenient           bool
+	upStrictEnv         bool
 )
 
+// upStdinContent is the stdin captured once by --stdin, which fills {{stdin}}
+// in the prompts of every task in the compose run.
+var upStdinContent string
+
 var upCmd = &cobra.Command{
 	Use:   "up [task...]",
 	Short: "Run tasks defined in a compose file",
@@ -40,8 +58,14 @@ By default, 'swarm up' runs:
   1. All defined pipelines (in DAG order with iterations)
   2. All standalone tasks (tasks not in pipelines and without dependencies)
 
+On a terminal with more than one of these, 'swarm up' first asks which to
+start (all are selected; space toggles one, enter starts). Use --all to start
+everything without asking.
+
 Tasks that are part of a pipeline or have dependencies are only run via their
-pipeline - they won't run as standalone parallel tasks.
+pipeline - they won't run as standalone parallel tasks. Naming such a task
+(e.g. 'swarm up tester') runs it once together with everything it transitively
+depends on, in DAG order.
 
 Each task can specify:
   - prompt: Name of a prompt from the prompts directory
@@ -50,39 +74,217 @@ Each task can specify:
   - model: Model to use (optional, overrides config)
   - iterations: Number of iterations (for standalone tasks)
   - name: Custom agent name (optional, defaults to task name)
-  - depends_on: Task dependencies with optional conditions
+  - extra_args: Extra flags passed through to the agent CLI (e.g. ["--max-turns", "20"])
+  - params: Model params for the agent: temperature (0 to 2), max_output_tokens, and
+    thinking_budget (e.g. {temperature: 0} for a deterministic reviewer); passed to
+    the agent CLI per the command's param_args and param_env in swarm.toml, like
+    'swarm run --param'
+  - repo: Repository the agent works in, a path like ../other-service or a git URL
+    (cloned into ~/.swarm/repos on first use); agents show up in 'swarm list'
+    from both the project and the repo
+  - inject_env_summary: Prepend the host OS, tools on PATH, and repo languages to the prompt
+  - depends_on: Task dependencies with optional conditions; "when: <field> == <value>"
+    additionally gates on a field of the dependency's structured output
+  - output_schema: Schema the task's structured output must match
+  - max_injected_bytes: Cap on each {{output:...}} injected into the prompt (keeps the end)
+  - labels: Labels placing the task in concurrency pools (e.g. pool: gpu-heavy)
+  - on_failure_prompt: Prompt for a triage agent run after on_failure_after (default 1)
+    consecutive failures in a pipeline; verify_command output is included
+  - fail_on_subtype / fail_on_result_regex: Fail an iteration from its result event
+    (e.g. fail_on_subtype: error) even when the agent CLI exits 0
+  - health_cmd: Liveness probe (e.g. curl -fs localhost:3000/health) run every
+    health_interval (default 30s) while the agent is active; failures mark the
+    agent UNHEALTHY in 'swarm top' and are logged
+  - allowed_paths / denied_paths: Globs of files the agent may / may not edit, relative
+    to its working directory (e.g. allowed_paths: ["docs/**"]); an edit outside them
+    kills the agent and fails the task with a path violation
+  - read_only: true for reviewer/analyst agents: any edit, or a working tree changed
+    after an iteration, is a path violation (the working tree isn't checked while
+    pipeline tasks running alongside edit it); the agent CLI also gets the command's
+    read_only_args (e.g. --sandbox read-only for codex) and containers mount the
+    working directory read-only
+  - env: Environment variables for the agent (e.g. API_URL: http://localhost:8080)
+  - env_file: A .env file of environment variables for the agent; env overrides them
+  - isolation: worktree gives each agent of the task (each parallel instance, and each
+    pipeline run) its own git worktree and swarm/<agent> branch under ~/.swarm/worktrees,
+    so instances don't clobber each other's edits; changes are committed after each
+    iteration, and the worktree is removed when the agent finishes (branches with
+    unmerged changes are kept)
+  - merge_back: true merges a worktree agent's branch into the original checkout after
+    each successful iteration (a conflicting merge is aborted, keeping the branch)
+  - budget_usd / budget_tokens: Cap on the cost and tokens of each of the task's agents;
+    an agent over budget stops after its current iteration (exit reason budget), and
+    is killed if that runs on two minutes past the budget
+  - schedule: Cron expression (e.g. "0 2 * * *") at which 'swarm scheduler' starts the task
+  - max_pending: Backpressure for a planner that writes the todo queue (todo_dir in
+    swarm.toml, default swarm/todos): while more than this many task files wait for
+    a doer, the task is skipped in pipeline iterations and otherwise waits before
+    its next iteration, so a fast planner can't build a backlog the doers never drain;
+    doers that depend on it need condition: always to run when it's skipped
 
 Pipelines define DAG workflows with iteration cycles:
   - Each iteration runs the entire DAG to completion before the next
-  - Tasks can have conditional dependencies (success, failure, any, always)`,
+  - Tasks can have conditional dependencies (success, failure, any, always)
+  - iterations: 0 runs the pipeline until stopped or a stop_when condition is met
+  - stop_when: file_exists, command, and/or budget (USD) checked after each iteration
+  - shared_iterations: true makes iterations a total shared by all parallel instances
+  - state_dir: where iteration state dirs (SWARM_STATE_DIR) are created (overrides config state_dir)
+  - on_failure_prompt/on_failure_after: triage defaults for the pipeline's tasks
+  - environment: env vars for all of the pipeline's tasks; a task's env overrides them
+  - matrix: fan out one instance per combination of values (e.g. module: [auth, billing]),
+    named pipeline@auth etc., with {{matrix.module}} in prompts replaced by the value
+  - env_file: a .env file of defaults for the pipeline's tasks, under its environment
+  - budget_usd / budget_tokens: cap on each pipeline instance's cost and tokens; once reached,
+    the running iteration has two minutes to finish before its tasks are killed, and
+    no further iterations start. 'swarm list' and 'swarm top' show budget used
+  - schedule: cron expression at which 'swarm scheduler' starts the pipeline, e.g. nightly
+
+Task model, prompt-string, prefix, suffix, and env values and pipeline
+environment values may use ${VAR} and ${VAR:-default} (used when VAR is unset
+or empty); $$ is a literal $. An unset variable without a default is replaced
+with "" and warned about, or fails the run with --strict-env. See the
+interpolated file with 'swarm config resolve'.
+
+With --watch, 'swarm up' starts every pipeline and standalone task detached,
+then keeps checking the compose file and applies each saved change: added
+tasks and pipelines are started, removed ones killed, changed ones (e.g. a new
+prompt or model) restarted, and ones whose parallelism changed scaled. Ctrl+C
+stops watching; the agents keep running.
+
+After each iteration a one-line summary (task statuses, tokens, cost, duration)
+is logged and stored in the pipeline's state; follow progress with
+'swarm logs <pipeline> --grep "Iteration summary"' or 'swarm inspect <pipeline>'.
+
+A single pipeline node can be run with 'swarm up <pipeline>:<task>'. It uses
+the task's compose settings and resolves {{output:...}} directives against the
+most recent pipeline run, which is useful for debugging one failing stage.
+
+Concurrency pools defined in swarm.toml limit how many labeled pipeline tasks
+run at once across all pipelines on the machine:
+
+  [pools.gpu-heavy]
+  max = 2                    # tasks labeled pool=gpu-heavy
+  [pools.browser]
+  labels = ["needs=browser"] # or select tasks by any labels
+  max = 1
+
+Unknown fields in the compose file (e.g. a typo like depends-on:) are an error
+listing each unknown key; use --lenient to ignore them.
+
+Use --stdin to read stdin once (e.g. a spec) and substitute it for {{stdin}}
+in the prompt of every task in the run, including detached pipelines and tasks.
+
+{{output:<task> | last 200 lines}} (or first/last N lines/bytes) limits how much
+of an upstream output is injected into a prompt.
+
+Pipeline tasks may write a JSON object to $SWARM_STATE_DIR/<task>/swarm-output.json.
+It is validated against the task's output_schema (when declared, it is required),
+and its fields are available downstream as {{output:<task>.<field>}}.
+
+A task's agent may also write an exit report to $SWARM_EXIT_FILE (in pipelines,
+$SWARM_STATE_DIR/<task>/swarm-exit.json): {"status": "success" or "failure",
+"summary": "...", "follow_up": "..."}. A failure status fails the task, and the
+report is available to depends_on conditions as exit.<field>, e.g.
+when: exit.status == failure.
+
+Other files a task writes to $SWARM_STATE_DIR/<task>/ are artifacts, and
+{{artifact:<task>/<path>}} inlines one of them, e.g. {{artifact:planner/plan.md}}.
+Truncation policies apply as for {{output:...}}. A missing artifact fails the
+task that references it.
+
+Every task's agent also gets its own $SWARM_SCRATCH_DIR for temporary files
+that shouldn't be shared or kept (see scratch_retention in swarm.toml).
+Agents also get SWARM_ITERATION, SWARM_TOTAL_ITERATIONS, SWARM_TASK_NAME, and
+SWARM_RUN_ID in their environment, plus SWARM_PIPELINE for pipeline tasks, where
+SWARM_RUN_ID is the pipeline run's ID. Tasks with more than one iteration,
+and the tasks of a detached pipeline, get SWARM_CONTROL_ID: the ID 'swarm stop',
+'swarm exec' and 'swarm approve' take for their agent, which for a pipeline
+task is the pipeline's. Parallel agents (e.g. several planners) can coordinate
+with 'swarm claim <key>', which succeeds for only one of them.
+
+When a task's triage agent runs, it gets the task's recent output and the output
+of its verify_command, and writes a diagnosis to <task>.triage.md in the state
+dir. The diagnosis is prepended to the task's prompt until it succeeds again.
+
+A task whose agent overflows its context window is retried once, with the
+prompt's compact variant (<prompt>.compact.md) if it exists, otherwise with
+injected {{output:...}} content truncated, otherwise with a fresh session.
+
+--ci makes a run suitable for CI jobs such as GitHub Actions:
+  - colors are off and nothing prompts (git clones of task repos fail
+    instead of asking for credentials)
+  - --fail-fast is on: the first failed task (or failed iteration of a
+    standalone task) kills the running agents, stops the run, and makes
+    'swarm up' exit non-zero
+  - progress is written to stdout as one JSON object per line whenever the
+    run, a pipeline, or a task changes state ({"time", "event", "status",
+    "pipeline", "task", "iteration", "error"}); all other output goes to stderr
+  - the run has a total --timeout (default 1h); when it's reached the agents
+    are killed and 'swarm up' exits with code 124`,
 	Example: `  # Run all pipelines and standalone tasks
   swarm up
 
   # Run specific tasks only
   swarm up frontend backend
 
+  # Run a task after the tasks it depends on
+  swarm up tester
+
   # Run a specific pipeline by name
   swarm up development
 
   # Mix tasks and pipelines
   swarm up development frontend
 
+  # Run one node of a pipeline, using outputs from the latest pipeline run
+  swarm up development:reviewer
+
   # Run in detached mode
   swarm up -d
 
+  # Run detached and apply edits to swarm.yaml as they're saved
+  swarm up --watch
+
+  # Give every task the same spec as {{stdin}}
+  swarm up -d --stdin < spec.md
+
   # Use a custom compose file
-  swarm up -f custom.yaml`,
-	RunE: func(cmd *cobra.Command, args []string) error {
+  swarm up -f custom.yaml
+
+  # Run in CI, keeping the JSON progress
+  swarm up --ci --timeout 30m > progress.jsonl`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if upWatch {
+			if len(args) > 0 || upPipeline != "" {
+				return fmt.Errorf("--watch runs every pipeline and standalone task and can't be combined with task names or --pipeline")
+			}
+			upDetach = true
+		}
+		endRun, err := beginUpRun(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = endRun(err) }()
+
 		// Load compose file
-		cf, err := compose.Load(upFile)
+		cf, err := loadComposeFileWithOptions(upFile, compose.LoadOptions{Lenient: upLenient, StrictEnv: upStrictEnv})
 		if err != nil {
 			return fmt.Errorf("failed to load compose file %s: %w", upFile, err)
 		}
+		if names := cf.UndefinedVariables(); len(names) > 0 && !upInternalDetached {
+			fmt.Fprintf(os.Stderr, "Warning: %s not set, using empty values (use --strict-env to fail instead)\n", strings.Join(names, ", "))
+		}
 
 		// Validate compose file
 		if err := cf.Validate(); err != nil {
 			return fmt.Errorf("invalid compose file: %w", err)
 		}
+		if err := cf.LoadEnvFiles(); err != nil {
+			return fmt.Errorf("invalid compose file: %w", err)
+		}
+		if !upInternalDetached {
+			warnUnsupportedTaskParams(cf)
+		}
 
 		// Get prompts directory based on scope
 		promptsDir, err := GetPromptsDir()
@@ -96,6 +298,30 @@ Pipelines define DAG workflows with iteration cycles:
 			return fmt.Errorf("failed to get working directory: %w", err)
 		}
 
+		// Resolve the repos tasks run in, cloning any git URLs
+		if err := resolveTaskRepos(cf, workingDir); err != nil {
+			return err
+		}
+
+		// Capture stdin once for all tasks
+		if upStdin {
+			if upInternalDetached && upInternalStdin != "" {
+				upStdinContent = upInternalStdin
+			} else {
+				if !prompt.IsStdinPiped() {
+					return fmt.Errorf("--stdin specified but no input piped (use a pipe or redirect)")
+				}
+				upStdinContent, err = prompt.LoadPromptFromStdin()
+				if err != nil {
+					return fmt.Errorf("failed to read stdin: %w", err)
+				}
+			}
+		}
+
+		if upWatch {
+			return watchCompose(cf, promptsDir, workingDir)
+		}
+
 		// If running as a detached child, run the pipeline directly
 		if upInternalDetached && upPipeline != "" {
 			return runPipeline(cf, upPipeline, promptsDir, workingDir)
@@ -111,17 +337,31 @@ Pipelines define DAG workflows with iteration cycles:
 
 		// If specific tasks/pipelines are requested via args, run them
 		if len(args) > 0 {
-			// Separate args into task names and pipeline names
+			// Separate args into task names, pipeline names, and pipeline nodes (pipeline:task)
 			var taskArgs []string
 			var pipelineArgNames []string
+			var nodeArgs []string
 			for _, arg := range args {
 				if _, exists := cf.Pipelines[arg]; exists {
 					pipelineArgNames = append(pipelineArgNames, arg)
+				} else if isPipelineNodeArg(cf, arg) {
+					nodeArgs = append(nodeArgs, arg)
 				} else {
 					taskArgs = append(taskArgs, arg)
 				}
 			}
 
+			// Run requested pipeline nodes
+			for _, nodeArg := range nodeArgs {
+				if upDetach {
+					return fmt.Errorf("running a single pipeline node (%s) is not supported with --detach", nodeArg)
+				}
+				pipelineName, taskName, _ := strings.Cut(nodeArg, ":")
+				if err := runPipelineNode(cf, pipelineName, taskName, promptsDir, workingDir); err != nil {
+					return fmt.Errorf("pipeline node %q failed: %w", nodeArg, err)
+				}
+			}
+
 			// Run requested pipelines
 			for _, pipelineName := range pipelineArgNames {
 				if upDetach {
@@ -137,6 +377,19 @@ Pipelines define DAG workflows with iteration cycles:
 
 			// Run requested tasks
 			if len(taskArgs) > 0 {
+				// Tasks with depends_on run as an implicit pipeline together
+				// with everything they transitively depend on
+				selected, err := cf.WithDependencies(taskArgs)
+				if err != nil {
+					return err
+				}
+				if hasTaskDependencies(cf, selected) {
+					if upDetach {
+						return runTaskSelectionDetached(taskArgs, selected, workingDir)
+					}
+					return runTaskSelection(cf, taskArgs, selected, promptsDir, workingDir)
+				}
+
 				tasks, err := cf.GetTasks(taskArgs)
 				if err != nil {
 					return err
@@ -165,12 +418,26 @@ Pipelines define DAG workflows with iteration cycles:
 
 func init() {
 	upCmd.Flags().StringVarP(&upFile, "file", "f", compose.DefaultPath(), "Path to compose file")
+	upCmd.Flags().BoolVar(&upLenient, "lenient", false, "Ignore unknown fields in the compose file instead of failing")
+	upCmd.Flags().BoolVar(&upStrictEnv, "strict-env", false, "Fail if the compose file references an unset ${VAR} without a default")
 	upCmd.Flags().BoolVarP(&upDetach, "detach", "d", false, "Run all tasks in background")
+	upCmd.Flags().BoolVarP(&upWatch, "watch", "w", false, "Run detached, then restart agents as the compose file changes")
+	upCmd.Flags().BoolVar(&upAll, "all", false, "Start every pipeline and standalone task without asking which")
 	upCmd.Flags().StringVarP(&upPipeline, "pipeline", "p", "", "Run a named pipeline (DAG with iterations)")
 	upCmd.Flags().BoolVar(&upInternalDetached, "_internal-detached", false, "Internal flag for detached execution")
 	upCmd.Flags().MarkHidden("_internal-detached")
 	upCmd.Flags().StringVar(&upInternalTaskID, "_internal-task-id", "", "Internal flag for passing task ID to detached child")
 	upCmd.Flags().MarkHidden("_internal-task-id")
+	upCmd.Flags().StringVar(&upInternalInstance, "_internal-instance", "", "Internal flag for passing a pipeline matrix instance to detached child")
+	upCmd.Flags().MarkHidden("_internal-instance")
+	upCmd.Flags().BoolVarP(&upStdin, "stdin", "i", false, "Read stdin once and substitute it for {{stdin}} in every task's prompt")
+	upCmd.Flags().StringVar(&upInternalStdin, "_internal-stdin", "", "Internal flag for passing stdin content to detached child")
+	upCmd.Flags().MarkHidden("_internal-stdin")
+	upCmd.Flags().BoolVar(&upCI, "ci", false, "Run non-interactively for CI: no colors, fail fast, JSON progress on stdout, and a total timeout")
+	upCmd.Flags().BoolVar(&upFailFast, "fail-fast", false, "Stop the run and exit non-zero as soon as a task fails (default with --ci)")
+	upCmd.Flags().StringVar(&upTimeout, "timeout", "", "Total timeout for the run, e.g. 30m (default 1h with --ci)")
+	upCmd.ValidArgsFunction = completeComposeTarget
+	upCmd.RegisterFlagCompletionFunc("pipeline", completePipelineName)
 }
 
 // runPipeline runs a named pipeline using the DAG executor.
@@ -191,17 +458,45 @@ func runPipeline(cf *compose.ComposeFile, pipelineName, promptsDir, workingDir s
 		return fmt.Errorf("%w\nNo pipelines defined in compose file", err)
 	}
 
+	if len(pipeline.Matrix) > 0 {
+		return runMatrixPipeline(cf, pipelineName, *pipeline, promptsDir, workingDir)
+	}
+
 	parallelism := pipeline.EffectiveParallelism()
 
+	useSharedCounter := pipeline.SharedIterations && parallelism > 1
+
 	// Detached children are already a single instance — don't re-expand
 	if parallelism <= 1 || upInternalDetached {
 		fmt.Printf("Running pipeline %q from %s\n", pipelineName, upFile)
-		return runSinglePipelineInstance(cf, pipelineName, *pipeline, promptsDir, workingDir, os.Stdout)
+		var counter dag.IterationCounter
+		if useSharedCounter {
+			// The parent that spawned this instance has already reset the counter
+			c, err := sharedIterationCounter(pipeline, pipelineName, workingDir)
+			if err != nil {
+				return err
+			}
+			counter = c
+		}
+		return runSinglePipelineInstance(cf, pipelineName, *pipeline, promptsDir, workingDir, os.Stdout, counter, nil)
 	}
 
 	// Multiple parallel instances
 	fmt.Printf("Running pipeline %q from %s (parallelism: %d)\n", pipelineName, upFile, parallelism)
 
+	var counter dag.IterationCounter
+	if useSharedCounter {
+		c, err := sharedIterationCounter(pipeline, pipelineName, workingDir)
+		if err != nil {
+			return err
+		}
+		if err := c.Reset(); err != nil {
+			return fmt.Errorf("failed to reset shared iteration counter: %w", err)
+		}
+		fmt.Printf("Sharing %d iteration(s) across %d instances\n", c.Limit(), parallelism)
+		counter = c
+	}
+
 	var instanceNames []string
 	for i := 1; i <= parallelism; i++ {
 		instanceNames = append(instanceNames, fmt.Sprintf("%s.%d", pipelineName, i))
@@ -221,7 +516,7 @@ func runPipeline(cf *compose.ComposeFile, pipelineName, promptsDir, workingDir s
 			defer wg.Done()
 			defer out.Flush()
 
-			if err := runSinglePipelineInstance(cf, name, *pipeline, promptsDir, workingDir, out); err != nil {
+			if err := runSinglePipelineInstance(cf, name, *pipeline, promptsDir, workingDir, out, counter, nil); err != nil {
 				mu.Lock()
 				errors = append(errors, fmt.Errorf("%s: %w", name, err))
 				mu.Unlock()
@@ -237,29 +532,295 @@ func runPipeline(cf *compose.ComposeFile, pipelineName, promptsDir, workingDir s
 	return nil
 }
 
+// runMatrixPipeline runs one instance of a pipeline per combination of its
+// matrix values, concurrently. A detached child runs only the instance named
+// by --_internal-instance.
+func runMatrixPipeline(cf *compose.ComposeFile, pipelineName string, pipeline compose.Pipeline, promptsDir, workingDir string) error {
+	instances := pipeline.MatrixInstances(pipelineName)
+
+	if upInternalDetached {
+		for _, inst := range instances {
+			if inst.Name == upInternalInstance {
+				fmt.Printf("Running pipeline %q from %s\n", inst.Name, upFile)
+				return runSinglePipelineInstance(cf, inst.Name, pipeline, promptsDir, workingDir, os.Stdout, nil, inst.Values)
+			}
+		}
+		return fmt.Errorf("pipeline %q has no matrix instance %q", pipelineName, upInternalInstance)
+	}
+
+	fmt.Printf("Running pipeline %q from %s (matrix: %d instances)\n", pipelineName, upFile, len(instances))
+
+	instanceNames := make([]string, len(instances))
+	for i, inst := range instances {
+		instanceNames[i] = inst.Name
+	}
+	writers := output.NewWriterGroup(os.Stdout, instanceNames)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed int
+
+	for _, inst := range instances {
+		writer := writers.Get(inst.Name)
+
+		wg.Add(1)
+		go func(inst compose.MatrixInstance, out *output.PrefixedWriter) {
+			defer wg.Done()
+			defer out.Flush()
+
+			if err := runSinglePipelineInstance(cf, inst.Name, pipeline, promptsDir, workingDir, out, nil, inst.Values); err != nil {
+				fmt.Fprintf(out, "Error: %v\n", err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}(inst, writer)
+	}
+
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("%d pipeline instance(s) failed", failed)
+	}
+	return nil
+}
+
 // runSinglePipelineInstance runs a single instance of a pipeline using the DAG executor.
-func runSinglePipelineInstance(cf *compose.ComposeFile, name string, pipeline compose.Pipeline, promptsDir, workingDir string, out io.Writer) error {
+// counter is non-nil when the instance shares its iterations with other instances;
+// matrix holds the values of a matrix instance.
+func runSinglePipelineInstance(cf *compose.ComposeFile, name string, pipeline compose.Pipeline, promptsDir, workingDir string, out io.Writer, counter dag.IterationCounter, matrix map[string]string) error {
 	execCfg := dag.ExecutorConfig{
-		AppConfig:  appConfig,
-		PromptsDir: promptsDir,
-		WorkingDir: workingDir,
-		Output:     out,
+		AppConfig:        appConfig,
+		PromptsDir:       promptsDir,
+		WorkingDir:       workingDir,
+		Output:           out,
+		SharedIterations: counter,
+		PipelineName:     name,
+		StateDir:         pipelineStateDir(&pipeline),
+		Stdin:            upStdinContent,
+		Matrix:           matrix,
+		Context:          upCtx,
+		FailFast:         upFailFast,
+		OnTaskStatus: func(task string, iteration int, status dag.TaskStatus, err error) {
+			upProgress.task(name, task, iteration, string(status), err)
+			if status == dag.TaskFailed {
+				failFast()
+			}
+		},
 	}
 
-	// If running as a detached child, set up state tracking
-	if upInternalTaskID != "" {
-		mgr, err := state.NewManagerWithScope(GetScope(), workingDir)
-		if err == nil {
-			execCfg.StateManager = mgr
-			execCfg.TaskID = upInternalTaskID
-		}
+	// Record the run in state, and if running as a detached child, track
+	// progress on the pipeline's agent
+	if mgr, err := state.NewManagerWithScope(GetScope(), workingDir); err == nil {
+		execCfg.StateManager = mgr
+		execCfg.TaskID = upInternalTaskID
+		execCfg.StderrFile = detachedStderrFile(mgr)
 	}
 
 	// Create the executor
 	executor := dag.NewExecutor(execCfg)
 
 	// Run the pipeline
-	return executor.RunPipeline(pipeline, cf.Tasks)
+	upProgress.pipeline(name, "started", nil)
+	err := executor.RunPipeline(pipeline, cf.Tasks)
+	upProgress.pipeline(name, outcomeStatus(err), err)
+	return err
+}
+
+// sharedIterationCounter returns the state-backed counter that the parallel
+// instances of a pipeline with shared_iterations draw their iterations from.
+func sharedIterationCounter(pipeline *compose.Pipeline, pipelineName, workingDir string) (*state.Counter, error) {
+	mgr, err := state.NewManagerWithScope(GetScope(), workingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize state manager: %w", err)
+	}
+	return mgr.Counter(pipelineCounterKey(workingDir, pipelineName), pipeline.EffectiveIterations()), nil
+}
+
+// pipelineStateDir returns where a pipeline's iteration state directories are
+// created: the pipeline's state_dir, falling back to the configured state_dir.
+func pipelineStateDir(pipeline *compose.Pipeline) string {
+	if pipeline.StateDir != "" {
+		return pipeline.StateDir
+	}
+	return appConfig.StateDir
+}
+
+// pipelineCounterKey identifies a pipeline's shared iteration counter.
+func pipelineCounterKey(workingDir, pipelineName string) string {
+	return state.CounterKey("pipeline", workingDir, pipelineName)
+}
+
+// isPipelineNodeArg reports whether arg has the form <pipeline>:<task> for a defined pipeline.
+func isPipelineNodeArg(cf *compose.ComposeFile, arg string) bool {
+	pipelineName, taskName, ok := strings.Cut(arg, ":")
+	if !ok || taskName == "" {
+		return false
+	}
+	_, exists := cf.Pipelines[pipelineName]
+	return exists
+}
+
+// runPipelineNode runs one task of a pipeline in the foreground, resolving
+// {{output:...}} directives against the most recent run of the pipeline.
+func runPipelineNode(cf *compose.ComposeFile, pipelineName, taskName, promptsDir, workingDir string) error {
+	pipeline, err := cf.GetPipeline(pipelineName)
+	if err != nil {
+		return err
+	}
+
+	if len(pipeline.Matrix) > 0 {
+		return fmt.Errorf("pipeline %q has a matrix; run the whole pipeline instead of one node", pipelineName)
+	}
+
+	pipelineTasks := pipeline.GetPipelineTasks(cf.Tasks)
+	inPipeline := false
+	for _, name := range pipelineTasks {
+		if name == taskName {
+			inPipeline = true
+			break
+		}
+	}
+	if !inPipeline {
+		return fmt.Errorf("task %q is not part of pipeline %q\nPipeline tasks: %v", taskName, pipelineName, pipelineTasks)
+	}
+
+	stateDir := pipelineStateDir(pipeline)
+	sourceDir, err := dag.LatestOutputDirIn(dag.ResolveOutputsRoot(stateDir, workingDir), pipelineName, workingDir, pipelineTasks)
+	if err != nil {
+		return fmt.Errorf("failed to find pipeline outputs: %w", err)
+	}
+	if sourceDir != "" {
+		fmt.Printf("Running %s:%s with outputs from %s\n", pipelineName, taskName, sourceDir)
+	} else {
+		fmt.Printf("Running %s:%s (no previous pipeline outputs found)\n", pipelineName, taskName)
+	}
+
+	executor := dag.NewExecutor(dag.ExecutorConfig{
+		AppConfig:    appConfig,
+		PromptsDir:   promptsDir,
+		WorkingDir:   workingDir,
+		Output:       os.Stdout,
+		PipelineName: pipelineName,
+		StateDir:     stateDir,
+		Stdin:        upStdinContent,
+		Context:      upCtx,
+	})
+	return executor.RunNode(taskName, pipeline.WithEnvironment(cf.Tasks)[taskName], sourceDir)
+}
+
+// hasTaskDependencies reports whether any of the named tasks has depends_on.
+func hasTaskDependencies(cf *compose.ComposeFile, taskNames []string) bool {
+	for _, name := range taskNames {
+		if len(cf.Tasks[name].DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// taskSelectionAgentName is the agent name used for a detached run of the
+// requested tasks and their dependencies.
+func taskSelectionAgentName(taskArgs []string) string {
+	return fmt.Sprintf("pipeline:%s", strings.Join(taskArgs, "+"))
+}
+
+// runTaskSelection runs the requested tasks and their transitive dependencies
+// (selected) once, in DAG order, using an implicit single-iteration pipeline.
+func runTaskSelection(cf *compose.ComposeFile, taskArgs, selected []string, promptsDir, workingDir string) error {
+	if len(selected) > len(taskArgs) {
+		fmt.Printf("Running %v with dependencies %v from %s\n", taskArgs, selected, upFile)
+	} else {
+		fmt.Printf("Running %v in dependency order from %s\n", taskArgs, upFile)
+	}
+	pipeline := compose.Pipeline{Iterations: 1, Tasks: selected}
+	return runSinglePipelineInstance(cf, strings.Join(taskArgs, "+"), pipeline, promptsDir, workingDir, os.Stdout, nil, nil)
+}
+
+// runTaskSelectionDetached starts a detached process that runs the requested
+// tasks and their dependencies in DAG order. The child re-runs 'swarm up'
+// with the same task arguments.
+func runTaskSelectionDetached(taskArgs, selected []string, workingDir string) error {
+	mgr, err := state.NewManagerWithScope(GetScope(), workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize state manager: %w", err)
+	}
+
+	agentName := taskSelectionAgentName(taskArgs)
+	runningAgents, _ := mgr.List(true)
+	for _, a := range runningAgents {
+		if a.Name == agentName {
+			fmt.Printf("Tasks %v already running (ID: %s), skipping\n", taskArgs, a.ID)
+			return nil
+		}
+	}
+
+	taskID := state.GenerateID()
+	logFile, err := detach.LogFilePath(taskID)
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	// Build args for the detached process
+	detachedArgs := []string{"up", "--_internal-detached", "--_internal-task-id", taskID}
+	if globalFlag {
+		detachedArgs = append(detachedArgs, "--global")
+	}
+	if upFile != compose.DefaultPath() {
+		detachedArgs = append(detachedArgs, "--file", upFile)
+	}
+	if upLenient {
+		detachedArgs = append(detachedArgs, "--lenient")
+	}
+	if upStrictEnv {
+		detachedArgs = append(detachedArgs, "--strict-env")
+	}
+	detachedArgs = appendUpStdinArgs(detachedArgs)
+	detachedArgs = append(detachedArgs, taskArgs...)
+
+	agentState := &state.AgentState{
+		ID:          taskID,
+		Name:        agentName,
+		Prompt:      fmt.Sprintf("tasks:%s", strings.Join(selected, ",")),
+		Model:       appConfig.Model,
+		StartedAt:   time.Now(),
+		Iterations:  1,
+		CurrentIter: 0,
+		Status:      "running",
+		LogFile:     logFile,
+		WorkingDir:  workingDir,
+		Labels:      appConfig.WithDefaultLabels(nil),
+	}
+
+	// Reserve the name before starting, so a concurrent 'swarm up -d' can't
+	// start the same tasks too
+	running, err := mgr.Reserve(agentState)
+	if err != nil {
+		return fmt.Errorf("failed to register state: %w", err)
+	}
+	if running != nil {
+		fmt.Printf("Tasks %v already running (ID: %s), skipping\n", taskArgs, running.ID)
+		return nil
+	}
+
+	pid, err := detach.StartDetached(detachedArgs, logFile, workingDir)
+	if err != nil {
+		_ = mgr.Remove(taskID)
+		return fmt.Errorf("failed to start detached process: %w", err)
+	}
+	if err := mgr.SetPID(taskID, pid); err != nil {
+		return fmt.Errorf("failed to register state: %w", err)
+	}
+
+	fmt.Printf("Started %v with dependencies %v in background (ID: %s, PID: %d)\n", taskArgs, selected, taskID, pid)
+	return nil
+}
+
+// appendUpStdinArgs passes stdin captured by --stdin to a detached 'swarm up' child.
+func appendUpStdinArgs(args []string) []string {
+	if upStdinContent == "" {
+		return args
+	}
+	return append(args, "--stdin", "--_internal-stdin", upStdinContent)
 }
 
 // runPipelineDetached spawns a pipeline as a detached background process.
@@ -287,14 +848,10 @@ func runPipelineDetached(cf *compose.ComposeFile, pipelineName, promptsDir, work
 		runningByName[a.Name] = a
 	}
 
-	// Compute desired instance names
+	instances := pipelineInstances(pipelineName, pipeline)
 	desiredNames := make(map[string]bool)
-	for i := 1; i <= parallelism; i++ {
-		instanceName := pipelineName
-		if parallelism > 1 {
-			instanceName = fmt.Sprintf("%s.%d", pipelineName, i)
-		}
-		desiredNames[fmt.Sprintf("pipeline:%s", instanceName)] = true
+	for _, inst := range instances {
+		desiredNames[fmt.Sprintf("pipeline:%s", inst.Name)] = true
 	}
 
 	// Kill excess instances (running instances of this pipeline not in desired set)
@@ -311,13 +868,26 @@ func runPipelineDetached(cf *compose.ComposeFile, pipelineName, promptsDir, work
 
 	effectiveIterations := pipeline.EffectiveIterations()
 
-	var startedCount, skippedCount int
-	for i := 1; i <= parallelism; i++ {
-		instanceName := pipelineName
-		if parallelism > 1 {
-			instanceName = fmt.Sprintf("%s.%d", pipelineName, i)
+	// Start a fresh shared counter unless instances from a previous run are
+	// still drawing from it
+	if pipeline.SharedIterations && parallelism > 1 {
+		stillRunning := false
+		for _, a := range runningAgents {
+			if desiredNames[a.Name] {
+				stillRunning = true
+				break
+			}
+		}
+		if !stillRunning {
+			if err := mgr.Counter(pipelineCounterKey(workingDir, pipelineName), effectiveIterations).Reset(); err != nil {
+				return fmt.Errorf("failed to reset shared iteration counter: %w", err)
+			}
 		}
+	}
 
+	var startedCount, skippedCount int
+	for _, inst := range instances {
+		instanceName := inst.Name
 		agentName := fmt.Sprintf("pipeline:%s", instanceName)
 
 		// Skip if already running
@@ -336,12 +906,22 @@ func runPipelineDetached(cf *compose.ComposeFile, pipelineName, promptsDir, work
 
 		// Build args for the detached process
 		detachedArgs := []string{"up", "--_internal-detached", "--_internal-task-id", taskID, "--pipeline", pipelineName}
+		if inst.Values != nil {
+			detachedArgs = append(detachedArgs, "--_internal-instance", instanceName)
+		}
 		if globalFlag {
 			detachedArgs = append(detachedArgs, "--global")
 		}
 		if upFile != compose.DefaultPath() {
 			detachedArgs = append(detachedArgs, "--file", upFile)
 		}
+		if upLenient {
+			detachedArgs = append(detachedArgs, "--lenient")
+		}
+		if upStrictEnv {
+			detachedArgs = append(detachedArgs, "--strict-env")
+		}
+		detachedArgs = appendUpStdinArgs(detachedArgs)
 
 		agentState := &state.AgentState{
 			ID:          taskID,
@@ -354,16 +934,29 @@ func runPipelineDetached(cf *compose.ComposeFile, pipelineName, promptsDir, work
 			Status:      "running",
 			LogFile:     logFile,
 			WorkingDir:  workingDir,
+			Labels:      appConfig.WithDefaultLabels(nil),
+		Budget:      pipeline.Budget(),
+		}
+
+		// Reserve the instance's name before starting it, so a concurrent
+		// 'swarm up -d' can't start the same instance too
+		running, err := mgr.Reserve(agentState)
+		if err != nil {
+			return fmt.Errorf("failed to register state for %s: %w", instanceName, err)
+		}
+		if running != nil {
+			fmt.Printf("Pipeline %q already running, skipping\n", instanceName)
+			skippedCount++
+			continue
 		}
 
 		// Start detached process
 		pid, err := detach.StartDetached(detachedArgs, logFile, workingDir)
 		if err != nil {
+			_ = mgr.Remove(taskID)
 			return fmt.Errorf("failed to start detached process for %s: %w", instanceName, err)
 		}
-
-		agentState.PID = pid
-		if err := mgr.Register(agentState); err != nil {
+		if err := mgr.SetPID(taskID, pid); err != nil {
 			return fmt.Errorf("failed to register state for %s: %w", instanceName, err)
 		}
 
@@ -398,6 +991,19 @@ func runAllPipelinesAndStandaloneTasks(cf *compose.ComposeFile, promptsDir, work
 	}
 	sort.Strings(standaloneNames)
 
+	// On a terminal, ask which to start rather than starting everything
+	if shouldPickUpTargets(len(pipelineNames) + len(standaloneNames)) {
+		pickedPipelines, pickedTasks, ok, err := pickUpTargets(pipelineNames, standaloneNames)
+		if err != nil {
+			return err
+		}
+		if !ok || len(pickedPipelines)+len(pickedTasks) == 0 {
+			fmt.Println("Nothing started")
+			return nil
+		}
+		pipelineNames, standaloneNames = pickedPipelines, pickedTasks
+	}
+
 	// Report what we're going to run
 	fmt.Printf("From %s:\n", upFile)
 	if len(pipelineNames) > 0 {
@@ -469,20 +1075,11 @@ func runTasksDetached(taskNames []string, tasks map[string]compose.Task, prompts
 		if task.Name != "" {
 			baseName = task.Name
 		}
-		p := task.EffectiveParallelism()
 
 		// Compute desired names for this task
 		desiredNames := make(map[string]bool)
-		if p == 1 {
-			desiredNames[task.EffectiveName(taskName)] = true
-		} else {
-			for j := 1; j <= p; j++ {
-				if task.Name != "" {
-					desiredNames[fmt.Sprintf("%s.%d", task.Name, j)] = true
-				} else {
-					desiredNames[fmt.Sprintf("%s.%d", taskName, j)] = true
-				}
-			}
+		for _, name := range taskInstanceNames(taskName, task) {
+			desiredNames[name] = true
 		}
 
 		// Find and kill excess instances
@@ -573,11 +1170,21 @@ func runTasksDetached(taskNames []string, tasks map[string]compose.Task, prompts
 		if task.Model != "" {
 			detachedArgs = append(detachedArgs, "--model", task.Model)
 		}
-		if task.Prompt != "" {
-			detachedArgs = append(detachedArgs, "--prompt", task.Prompt)
+		// A task with a repo runs there, so it needs absolute prompt paths
+		dir := taskDir(task, workingDir)
+		promptName, promptFile := task.Prompt, task.PromptFile
+		if dir != workingDir {
+			if promptName != "" {
+				promptFile = prompt.GetPromptPath(absPath(promptsDir, workingDir), promptName)
+				promptName = ""
+			}
+			promptFile = absPath(promptFile, workingDir)
+		}
+		if promptName != "" {
+			detachedArgs = append(detachedArgs, "--prompt", promptName)
 		}
-		if task.PromptFile != "" {
-			detachedArgs = append(detachedArgs, "--prompt-file", task.PromptFile)
+		if promptFile != "" {
+			detachedArgs = append(detachedArgs, "--prompt-file", promptFile)
 		}
 		if task.PromptString != "" {
 			detachedArgs = append(detachedArgs, "--prompt-string", task.PromptString)
@@ -595,20 +1202,64 @@ func runTasksDetached(taskNames []string, tasks map[string]compose.Task, prompts
 		if task.Suffix != "" {
 			detachedArgs = append(detachedArgs, "--_internal-suffix", task.Suffix)
 		}
-
-		// Start detached process
-		pid, err := detach.StartDetached(detachedArgs, logFile, workingDir)
-		if err != nil {
-			fmt.Printf("  [%s] Error starting: %v\n", taskName, err)
-			failedTasks = append(failedTasks, taskName)
-			continue
+		if task.Image != "" {
+			detachedArgs = append(detachedArgs, "--image", task.Image)
+		}
+		for _, a := range task.ExtraArgs {
+			detachedArgs = append(detachedArgs, "--agent-arg="+a)
+		}
+		detachedArgs = append(detachedArgs, paramArgs(task.Params)...)
+		if upStdinContent != "" {
+			detachedArgs = append(detachedArgs, "--_internal-compose-stdin", upStdinContent)
+		}
+		if task.InjectEnvSummary {
+			detachedArgs = append(detachedArgs, "--env-summary")
+		}
+		if task.FailOnSubtype != "" {
+			detachedArgs = append(detachedArgs, "--fail-on-subtype", task.FailOnSubtype)
+		}
+		if task.FailOnResultRegex != "" {
+			detachedArgs = append(detachedArgs, "--fail-on-result-regex", task.FailOnResultRegex)
+		}
+		if task.ReadOnly {
+			detachedArgs = append(detachedArgs, "--read-only")
+		}
+		if task.BudgetUSD > 0 {
+			detachedArgs = append(detachedArgs, "--budget-usd", strconv.FormatFloat(task.BudgetUSD, 'f', -1, 64))
+		}
+		if task.BudgetTokens > 0 {
+			detachedArgs = append(detachedArgs, "--budget-tokens", strconv.FormatInt(task.BudgetTokens, 10))
+		}
+		if task.MaxPending > 0 {
+			detachedArgs = append(detachedArgs, "--max-pending", strconv.Itoa(task.MaxPending))
+		}
+		if task.Isolation == compose.IsolationWorktree {
+			detachedArgs = append(detachedArgs, "--worktree")
+			if task.MergeBack {
+				detachedArgs = append(detachedArgs, "--merge-back")
+			}
+		}
+		for _, p := range task.AllowedPaths {
+			detachedArgs = append(detachedArgs, "--allowed-path", p)
+		}
+		for _, p := range task.DeniedPaths {
+			detachedArgs = append(detachedArgs, "--denied-path", p)
+		}
+		for _, e := range task.EnvList() {
+			detachedArgs = append(detachedArgs, "--_internal-env", e)
+		}
+		if task.HealthCmd != "" {
+			detachedArgs = append(detachedArgs, "--health-cmd", task.HealthCmd)
+			if interval := task.EffectiveHealthInterval(); interval > 0 {
+				detachedArgs = append(detachedArgs, "--health-interval", interval.String())
+			}
 		}
 
-		// Register agent state
+		// Reserve the agent's name before starting it, so a concurrent
+		// 'swarm up -d' can't start the same task too
 		agentState := &state.AgentState{
 			ID:          taskID,
 			Name:        effectiveName,
-			PID:         pid,
 			Prompt:      promptLabel,
 			Model:       effectiveModel,
 			StartedAt:   time.Now(),
@@ -616,10 +1267,35 @@ func runTasksDetached(taskNames []string, tasks map[string]compose.Task, prompts
 			CurrentIter: 0,
 			Status:      "running",
 			LogFile:     logFile,
-			WorkingDir:  workingDir,
+			WorkingDir:  dir,
+			ProjectDir:  projectDirFor(dir, workingDir),
+			Image:       task.Image,
+			AgentArgs:   task.ExtraArgs,
+			ModelParams: task.Params,
+			Labels:      appConfig.WithDefaultLabels(nil),
+			Budget:      task.Budget(),
+		}
+		running, err := mgr.Reserve(agentState)
+		if err != nil {
+			fmt.Printf("  [%s] Error registering state: %v\n", taskName, err)
+			failedTasks = append(failedTasks, taskName)
+			continue
+		}
+		if running != nil {
+			fmt.Printf("  [%s] Already running (ID: %s), skipping\n", taskName, running.ID)
+			skippedTasks = append(skippedTasks, taskName)
+			continue
 		}
 
-		if err := mgr.Register(agentState); err != nil {
+		// Start detached process
+		pid, err := detach.StartDetached(detachedArgs, logFile, dir)
+		if err != nil {
+			_ = mgr.Remove(taskID)
+			fmt.Printf("  [%s] Error starting: %v\n", taskName, err)
+			failedTasks = append(failedTasks, taskName)
+			continue
+		}
+		if err := mgr.SetPID(taskID, pid); err != nil {
 			fmt.Printf("  [%s] Error registering state: %v\n", taskName, err)
 			failedTasks = append(failedTasks, taskName)
 			continue
@@ -720,11 +1396,16 @@ func runTasksForeground(taskNames []string, tasks map[string]compose.Task, promp
 			defer wg.Done()
 			defer out.Flush()
 
+			upProgress.task("", name, 0, string(dag.TaskRunning), nil)
 			if err := runSingleTask(name, t, promptsDir, workingDir, out, mgr); err != nil {
 				mu.Lock()
 				failedTasks = append(failedTasks, name)
 				mu.Unlock()
 				fmt.Fprintf(out, "Error: %v\n", err)
+				upProgress.task("", name, 0, string(dag.TaskFailed), err)
+				failFast()
+			} else {
+				upProgress.task("", name, 0, string(dag.TaskSucceeded), nil)
 			}
 		}(taskName, task, writer)
 	}
@@ -756,6 +1437,11 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 		return err
 	}
 
+	dir := taskDir(task, workingDir)
+	if task.InjectEnvSummary {
+		promptContent = prompt.InjectEnvSummary(promptContent, prompt.EnvSummary(dir))
+	}
+
 	// Inject task ID into prompt
 	promptContent = prompt.InjectTaskID(promptContent, taskID)
 
@@ -767,22 +1453,124 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 	effectiveName := task.EffectiveName(taskName)
 	effectiveIterations := task.EffectiveIterations()
 
+	criteria, err := agent.NewResultCriteria(task.FailOnSubtype, task.FailOnResultRegex)
+	if err != nil {
+		return err
+	}
+
+	// With worktree isolation the agent works on its own branch, so
+	// parallel instances don't clobber each other's edits
+	agentDir := dir
+	var wt *worktree.Worktree
+	if task.Isolation == compose.IsolationWorktree {
+		wt, err = worktree.Create(dir, effectiveName+"-"+taskID)
+		if err != nil {
+			return err
+		}
+		agentDir = wt.Dir
+		fmt.Fprintf(out, "Working in worktree %s (branch %s)\n", wt.Path, wt.Branch)
+		defer func() {
+			kept, err := wt.Remove("swarm: final changes")
+			if err != nil {
+				fmt.Fprintf(out, "Warning: failed to clean up worktree: %v\n", err)
+			} else if kept {
+				fmt.Fprintf(out, "Changes kept on branch %s\n", wt.Branch)
+			}
+		}()
+	}
+
+	pathGuard, err := task.PathGuard(agentDir)
+	if err != nil {
+		return err
+	}
+
+	var healthCheck *agent.HealthCheck
+	if task.HealthCmd != "" {
+		healthCheck = &agent.HealthCheck{Command: task.HealthCmd, Interval: task.EffectiveHealthInterval(), Dir: agentDir}
+	}
+
+	// Give the task's agent a scratch dir for throwaway files
+	env := task.EnvList()
+	exitFile := ""
+	scratchDir, err := scratch.Create(taskID)
+	if err != nil {
+		fmt.Fprintf(out, "Warning: %v\n", err)
+	} else {
+		env = append(env, scratch.Env(scratchDir))
+		exitFile = exitreport.Path(scratchDir)
+		defer func() {
+			if err := scratch.Finish(taskID, appConfig.ScratchRetentionDuration()); err != nil {
+				fmt.Fprintf(out, "Warning: failed to clean up scratch dir: %v\n", err)
+			}
+		}()
+	}
+
+	// A detached child's stdout is the JSONL log, so stderr goes to its sidecar
+	stderrFile := detachedStderrFile(mgr)
+
 	fmt.Fprintf(out, "Starting (model: %s, iterations: %d)\n", effectiveModel, effectiveIterations)
 
 	// For single iteration, run directly
 	if effectiveIterations == 1 {
+		if healthCheck != nil {
+			healthCheck.OnUnhealthy = func(err error) {
+				// A single iteration has no state, so the event only names the task
+				taskAgent := &state.AgentState{Name: effectiveName, WorkingDir: dir, ProjectDir: projectDirFor(dir, workingDir)}
+				notify.Send(appConfig, notify.UnhealthyEvent(healthCheck.Command, err).ForAgent(taskAgent), out)
+			}
+			defer healthCheck.Start(out, nil)()
+		}
+
+		// A single iteration has no agent of its own, so it stops with the
+		// detached 'swarm up' running it
+		if !waitForTaskTodoRoom(task, workingDir, out, upTerminated(mgr)) {
+			if err := upCtx.Err(); err != nil {
+				return fmt.Errorf("stopped waiting for the todo queue: %w", err)
+			}
+			fmt.Fprintf(out, "Received termination signal\n")
+			return nil
+		}
+
 		// Generate a per-iteration agent ID and inject it into the prompt.
 		iterationAgentID := state.GenerateID()
 		iterationPrompt := prompt.InjectAgentID(promptContent, iterationAgentID)
 
 		cfg := agent.Config{
-			Model:   effectiveModel,
-			Prompt:  iterationPrompt,
-			Command: appConfig.AgentCommand(),
+			Model:          effectiveModel,
+			Prompt:         iterationPrompt,
+			Command:        appConfig.AgentCommand().WithImage(task.Image).WithExtraArgs(task.ExtraArgs).WithParams(task.Params).WithReadOnly(task.ReadOnly),
+			Env:            env,
+			ExitFile:       exitFile,
+			Run:            agent.RunInfo{Iteration: 1, TotalIterations: 1, TaskName: effectiveName, RunID: taskID},
+			Dir:            agentDir,
+			StderrFile:     stderrFile,
+			ResultCriteria: criteria,
+			PathGuard:      pathGuard,
 		}
+		// An iteration over budget may finish, but not run on for long
+		budgetCtx, cancelBudget := context.WithCancel(upCtx)
+		defer cancelBudget()
+		budgetGuard := agent.NewBudgetGuard(cancelBudget)
 		runner := agent.NewRunner(cfg)
-		if err := runner.Run(out); err != nil {
-			return err
+		if budget := task.Budget(); budget != nil {
+			runner.SetUsageCallback(func(stats logparser.UsageStats) {
+				if reason := budget.Exceeded(stats.TotalCostUSD, stats.InputTokens+stats.OutputTokens); reason != "" && budgetGuard.Exceeded(reason) {
+					fmt.Fprintf(out, "Warning: %s, stopping the agent in %v unless it finishes\n", reason, agent.BudgetGracePeriod)
+				}
+			})
+		}
+		runErr := runner.RunWithContext(budgetCtx, out)
+		if budgetErr := budgetGuard.Stop(); runErr != nil && budgetErr != nil {
+			runErr = budgetErr
+		}
+		if wt != nil {
+			if err := wt.FinishIteration(1, task.MergeBack && runErr == nil); err != nil {
+				fmt.Fprintf(out, "Warning: %v\n", err)
+			}
+		}
+		if runErr != nil {
+			runner.PrintStderrTail(out)
+			return runErr
 		}
 		fmt.Fprintf(out, "Completed\n")
 		return nil
@@ -811,7 +1599,15 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 		Iterations:  effectiveIterations,
 		CurrentIter: 0,
 		Status:      "running",
-		WorkingDir:  workingDir,
+		WorkingDir:  dir,
+		ProjectDir:  projectDirFor(dir, workingDir),
+		Image:       task.Image,
+		AgentArgs:   task.ExtraArgs,
+		ModelParams: task.Params,
+		Labels:      appConfig.WithDefaultLabels(nil),
+		ScratchDir:  scratchDir,
+		Budget:      task.Budget(),
+		Manifest:    agent.NewManifest(appConfig.AgentCommand().WithImage(task.Image), dir),
 	}
 
 	if err := mgr.Register(agentState); err != nil {
@@ -823,8 +1619,23 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 		_ = mgr.Update(agentState)
 	}()
 
+	if healthCheck != nil {
+		healthCheck.OnUnhealthy = func(err error) {
+			notify.Send(appConfig, notify.UnhealthyEvent(healthCheck.Command, err).ForAgent(agentState), out)
+		}
+		stopHealth := healthCheck.Start(out, func(err error) {
+			agentState.RecordHealth(err, time.Now())
+			_ = mgr.MergeUpdate(agentState)
+		})
+		defer stopHealth()
+	}
+
 	// Run iterations
 	for i := 1; i <= agentState.Iterations; i++ {
+		if err := upCtx.Err(); err != nil {
+			return fmt.Errorf("stopped before iteration %d: %w", i, err)
+		}
+
 		// Check for control signals from state
 		currentState, err := mgr.Get(agentState.ID)
 		if err == nil && currentState != nil {
@@ -848,19 +1659,82 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 			}
 		}
 
+		// An agent that has used up its budget is stopped between iterations
+		if reason := agentState.BudgetExceeded(); reason != "" {
+			agentState.ExitReason = agent.FailureBudget
+			fmt.Fprintf(out, "Stopping: %s\n", reason)
+			return nil
+		}
+
+		// A planner waits while the doers have a backlog of its tasks
+		terminated := func() bool {
+			current, err := mgr.Get(agentState.ID)
+			return err == nil && current != nil && current.TerminateMode != ""
+		}
+		if !waitForTaskTodoRoom(task, workingDir, out, terminated) {
+			if err := upCtx.Err(); err != nil {
+				return fmt.Errorf("stopped before iteration %d: %w", i, err)
+			}
+			fmt.Fprintf(out, "Received termination signal\n")
+			return nil
+		}
+
 		agentState.CurrentIter = i
+		iterStartedAt := time.Now()
+		agentState.StartIteration(iterStartedAt)
 		_ = mgr.Update(agentState)
 
 		fmt.Fprintf(out, "=== Iteration %d/%d ===\n", i, agentState.Iterations)
+		upProgress.task("", taskName, i, string(dag.TaskRunning), nil)
+
+		// Flag the iteration if it runs much longer than usual, and with
+		// slow_iteration_action = "fail" stop it
+		median := agentState.MedianIterationDuration()
+		slowLimit := appConfig.SlowIterationLimit(median)
+		slowMessage := agent.SlowIterationMessage(i, slowLimit, median)
+		iterCtx, cancelIter := context.WithCancel(upCtx)
+		stopSlowWatch := agent.WatchSlowIteration(slowLimit, func() {
+			agentState.SlowIteration = true
+			_ = mgr.MergeUpdate(agentState)
+			if appConfig.FailSlowIterations() {
+				fmt.Fprintf(out, "Warning: %s, stopping it\n", slowMessage)
+				cancelIter()
+			} else {
+				fmt.Fprintf(out, "Warning: %s\n", slowMessage)
+			}
+			notify.Send(appConfig, notify.SlowIterationEvent(i, slowMessage).ForAgent(agentState), out)
+		})
+
+		// An iteration that exceeds the budget may finish, but not run on for long
+		budgetGuard := agent.NewBudgetGuard(cancelIter)
+
+		// Instructions queued with `swarm exec` are added to this iteration only
+		var messages []string
+		if queued, err := mgr.TakeMessages(agentState.ID); err != nil {
+			fmt.Fprintf(out, "Warning: %v\n", err)
+		} else {
+			for _, m := range queued {
+				fmt.Fprintf(out, "Instruction added: %s\n", m.Text)
+				messages = append(messages, m.Text)
+			}
+		}
 
 		// Generate a per-iteration agent ID and inject it into the prompt.
 		iterationAgentID := state.GenerateID()
 		iterationPrompt := prompt.InjectAgentID(promptContent, iterationAgentID)
+		iterationPrompt = prompt.InjectMessages(iterationPrompt, messages)
 
 		cfg := agent.Config{
-			Model:   agentState.Model,
-			Prompt:  iterationPrompt,
-			Command: appConfig.AgentCommand(),
+			Model:          agentState.Model,
+			Prompt:         iterationPrompt,
+			Command:        appConfig.AgentCommand().WithImage(task.Image).WithExtraArgs(task.ExtraArgs).WithParams(task.Params).WithReadOnly(task.ReadOnly),
+			Env:            env,
+			ExitFile:       exitFile,
+			Run:            agent.RunInfo{Iteration: i, TotalIterations: agentState.Iterations, TaskName: effectiveName, RunID: taskID, ControlID: taskID},
+			Dir:            agentDir,
+			StderrFile:     stderrFile,
+			ResultCriteria: criteria,
+			PathGuard:      pathGuard,
 		}
 
 		runner := agent.NewRunner(cfg)
@@ -876,12 +1750,36 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 			if stats.TotalCostUSD > 0 {
 				agentState.TotalCost = iterStartCost + stats.TotalCostUSD
 			}
+			if reason := agentState.BudgetExceeded(); reason != "" && budgetGuard.Exceeded(reason) {
+				fmt.Fprintf(out, "Warning: %s, stopping after this iteration (or in %v)\n", reason, agent.BudgetGracePeriod)
+			}
 			_ = mgr.MergeUpdate(agentState)
 		})
 
-		if err := runner.Run(out); err != nil {
-			fmt.Fprintf(out, "Agent error (continuing): %v\n", err)
+		var iterErr error
+		if err := runner.RunWithContext(iterCtx, out); err != nil {
+			if upCtx.Err() != nil {
+				err = upCtx.Err()
+			} else if budgetErr := budgetGuard.Stop(); budgetErr != nil {
+				err = budgetErr
+			} else if iterCtx.Err() != nil {
+				err = fmt.Errorf("%w: %s", agent.ErrSlowIteration, slowMessage)
+			}
+			runner.PrintStderrTail(out)
+			if tail := runner.StderrTail(); len(tail) > 0 {
+				agentState.LastStderr = strings.Join(tail, "\n")
+			}
+			if upFailFast {
+				fmt.Fprintf(out, "Agent error: %v\n", err)
+			} else {
+				fmt.Fprintf(out, "Agent error (continuing): %v\n", err)
+			}
+			iterErr = err
 		}
+		stoppedSlow := stopSlowWatch() && appConfig.FailSlowIterations()
+		_ = budgetGuard.Stop()
+		cancelIter()
+		agentState.FinishIteration(time.Now(), !stoppedSlow)
 
 		// Accumulate final stats from this iteration
 		finalStats := runner.UsageStats()
@@ -890,21 +1788,124 @@ func runSingleTask(taskName string, task compose.Task, promptsDir, workingDir st
 		cumulativeCostUSD += finalStats.TotalCostUSD
 		agentState.InputTokens = cumulativeInputTokens
 		agentState.OutputTokens = cumulativeOutputTokens
+		usageModel := finalStats.Model
+		if usageModel == "" {
+			usageModel = cfg.Model
+		}
+		agentState.AddModelUsage(usageModel, finalStats.InputTokens, finalStats.OutputTokens,
+			appConfig.UsageCost(usageModel, finalStats.InputTokens, finalStats.OutputTokens, finalStats.TotalCostUSD))
 		if cumulativeCostUSD > 0 {
 			agentState.TotalCost = cumulativeCostUSD
 		}
+		agentState.RecordExitReport(runner.ExitReport())
 		_ = mgr.MergeUpdate(agentState)
+		if err := mgr.AppendHistory(agentState.ID, runner.IterationRecord(i, iterStartedAt, iterErr, appConfig)); err != nil {
+			fmt.Fprintf(out, "Warning: failed to record iteration history: %v\n", err)
+		}
+		notify.CheckCostAlert(appConfig, workingDir, agentState, out)
+		if _, err := mgr.SampleTodoQueue(appConfig.TodoQueueDirs(workingDir)); err != nil {
+			fmt.Fprintf(out, "Warning: failed to sample todo queue: %v\n", err)
+		}
+		if wt != nil {
+			if err := wt.FinishIteration(i, task.MergeBack && iterErr == nil); err != nil {
+				fmt.Fprintf(out, "Warning: %v\n", err)
+			}
+		}
+
+		// A path violation also stops the agent
+		if iterErr != nil && (upFailFast || upCtx.Err() != nil || errors.Is(iterErr, pathguard.ErrViolation)) {
+			return fmt.Errorf("iteration %d failed: %w", i, iterErr)
+		}
 	}
 
 	fmt.Fprintf(out, "Completed (%d iterations)\n", agentState.Iterations)
 	return nil
 }
 
+// upTerminated returns a check for whether the agent of a detached 'swarm up'
+// has been told to terminate. Foreground runs have no agent and are stopped
+// through upCtx instead, so the check never reports true for them.
+func upTerminated(mgr *state.Manager) func() bool {
+	return func() bool {
+		if !upInternalDetached || upInternalTaskID == "" || mgr == nil {
+			return false
+		}
+		current, err := mgr.Get(upInternalTaskID)
+		return err == nil && current != nil && current.TerminateMode != ""
+	}
+}
+
+// detachedStderrFile returns the sidecar file for agent stderr when 'swarm up'
+// runs as a detached child, whose stdout is the agent's JSONL log. Returns ""
+// otherwise, leaving stderr on the terminal.
+func detachedStderrFile(mgr *state.Manager) string {
+	if !upInternalDetached || upInternalTaskID == "" || mgr == nil {
+		return ""
+	}
+	agentState, err := mgr.Get(upInternalTaskID)
+	if err != nil || agentState == nil {
+		return ""
+	}
+	return detach.StderrLogPath(agentState.LogFile)
+}
+
+// waitForTaskTodoRoom holds a task with max_pending back while more than that
+// many tasks wait in the todo queue. It returns false if 'swarm up' is
+// stopped, or stop returns true, while waiting.
+func waitForTaskTodoRoom(task compose.Task, workingDir string, out io.Writer, stop func() bool) bool {
+	if task.MaxPending <= 0 {
+		return true
+	}
+	todoDir, _ := appConfig.TodoQueueDirs(workingDir)
+	waited := false
+	hasRoom := todoqueue.WaitForRoom(upCtx, todoDir, task.MaxPending, func(pending int) {
+		waited = true
+		fmt.Fprintf(out, "%d tasks pending (max_pending %d), waiting for doers to catch up...\n", pending, task.MaxPending)
+	}, stop)
+	if hasRoom && waited {
+		fmt.Fprintf(out, "Todo queue has room, continuing\n")
+	}
+	return hasRoom
+}
+
+// pipelineInstances returns a pipeline's desired instances: one per matrix
+// combination, or per parallel instance (name.1 … name.N).
+func pipelineInstances(pipelineName string, pipeline *compose.Pipeline) []compose.MatrixInstance {
+	if instances := pipeline.MatrixInstances(pipelineName); instances != nil {
+		return instances
+	}
+	parallelism := pipeline.EffectiveParallelism()
+	instances := make([]compose.MatrixInstance, 0, parallelism)
+	for i := 1; i <= parallelism; i++ {
+		instanceName := pipelineName
+		if parallelism > 1 {
+			instanceName = fmt.Sprintf("%s.%d", pipelineName, i)
+		}
+		instances = append(instances, compose.MatrixInstance{Name: instanceName})
+	}
+	return instances
+}
+
+// taskInstanceNames returns the agent names of a standalone task's desired
+// instances: its name, or name.1 … name.N with parallelism.
+func taskInstanceNames(taskName string, task compose.Task) []string {
+	p := task.EffectiveParallelism()
+	if p == 1 {
+		return []string{task.EffectiveName(taskName)}
+	}
+	names := make([]string, 0, p)
+	for j := 1; j <= p; j++ {
+		names = append(names, fmt.Sprintf("%s.%d", task.EffectiveName(taskName), j))
+	}
+	return names
+}
+
 // isPipelineInstance returns true if agentName is an instance of the given pipeline.
-// Matches "pipeline:name" (single instance) and "pipeline:name.N" (parallel instances).
+// Matches "pipeline:name" (single instance), "pipeline:name.N" (parallel instances),
+// and "pipeline:name@values" (matrix instances).
 func isPipelineInstance(agentName, pipelineName string) bool {
 	base := fmt.Sprintf("pipeline:%s", pipelineName)
-	if agentName == base {
+	if agentName == base || strings.HasPrefix(agentName, base+"@") {
 		return true
 	}
 	prefix := base + "."
@@ -937,7 +1938,7 @@ func killAgentAndDescendants(mgr *state.Manager, a *state.AgentState) {
 		for _, d := range descendants {
 			if d.Status == "running" {
 				_ = mgr.SetTerminateMode(d.ID, "immediate")
-				_ = process.ForceKill(d.PID)
+				_ = d.ForceKill()
 				now := time.Now()
 				d.Status = "terminated"
 				d.ExitReason = "killed"
@@ -948,7 +1949,7 @@ func killAgentAndDescendants(mgr *state.Manager, a *state.AgentState) {
 	}
 
 	_ = mgr.SetTerminateMode(a.ID, "immediate")
-	_ = process.ForceKill(a.PID)
+	_ = a.ForceKill()
 	now := time.Now()
 	a.Status = "terminated"
 	a.ExitReason = "killed"
@@ -956,6 +1957,77 @@ func killAgentAndDescendants(mgr *state.Manager, a *state.AgentState) {
 	_ = mgr.Update(a)
 }
 
+// warnUnsupportedTaskParams warns once about each model param set by a
+// task that the backend can't pass to its agent CLI.
+func warnUnsupportedTaskParams(cf *compose.ComposeFile) {
+	params := make(map[string]string)
+	for _, task := range cf.Tasks {
+		for name, value := range task.Params {
+			params[name] = value
+		}
+	}
+	warnUnsupportedParams(appConfig, params)
+}
+
+// loadComposeFile loads a compose file. Unknown fields are an error unless
+// lenient is set.
+func loadComposeFile(path string, lenient bool) (*compose.ComposeFile, error) {
+	return loadComposeFileWithOptions(path, compose.LoadOptions{Lenient: lenient})
+}
+
+// loadComposeFileWithOptions loads a compose file as set by opts, pointing
+// at the flags that relax its checks when they fail.
+func loadComposeFileWithOptions(path string, opts compose.LoadOptions) (*compose.ComposeFile, error) {
+	cf, err := compose.LoadWithOptions(path, opts)
+	var unknown *compose.UnknownFieldsError
+	if errors.As(err, &unknown) {
+		return nil, fmt.Errorf("%w\n(use --lenient to ignore unknown fields)", err)
+	}
+	return cf, err
+}
+
+// resolveTaskRepos replaces each task's repo with the absolute directory it
+// resolves to, cloning git URLs into the repo workspace as needed.
+func resolveTaskRepos(cf *compose.ComposeFile, workingDir string) error {
+	for name, task := range cf.Tasks {
+		if task.Repo == "" {
+			continue
+		}
+		dir, err := repo.Resolve(task.Repo, workingDir)
+		if err != nil {
+			return fmt.Errorf("task %q: %w", name, err)
+		}
+		task.Repo = dir
+		cf.Tasks[name] = task
+	}
+	return nil
+}
+
+// taskDir returns the directory a task's agent runs in.
+func taskDir(task compose.Task, workingDir string) string {
+	if task.Repo != "" {
+		return task.Repo
+	}
+	return workingDir
+}
+
+// absPath resolves a relative path against dir. Empty paths stay empty.
+func absPath(path, dir string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// projectDirFor returns the compose project directory to record for an agent
+// running in dir, or "" when it runs in the project itself.
+func projectDirFor(dir, workingDir string) string {
+	if dir == workingDir {
+		return ""
+	}
+	return workingDir
+}
+
 // loadTaskPrompt loads the prompt content for a task.
 // Returns the content and a label for display.
 func loadTaskPrompt(task compose.Task, promptsDir string) (content, label string, err error) {
@@ -977,5 +2049,6 @@ func loadTaskPrompt(task compose.Task, promptsDir string) (content, label string
 	}
 	// Apply prefix/suffix if specified
 	content = prompt.ApplyPrefixSuffix(content, task.Prefix, task.Suffix)
+	content = prompt.InjectStdin(content, upStdinContent)
 	return
 }
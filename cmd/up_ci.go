@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// ciDefaultTimeout is the total timeout of a 'swarm up --ci' run when
+// --timeout isn't given, so a stuck agent can't hold a CI job forever.
+const ciDefaultTimeout = time.Hour
+
+var (
+	upCI       bool
+	upFailFast bool
+	upTimeout  string
+)
+
+// upCtx is done when the run times out (--timeout) or, with --fail-fast, once
+// a task fails; running agents are killed and no more are started.
+var upCtx = context.Background()
+
+// upCancel cancels upCtx.
+var upCancel context.CancelFunc = func() {}
+
+// upProgress writes --ci progress events; it's nil without --ci.
+var upProgress *ciProgress
+
+// ciEvent is one line of --ci progress: a run, pipeline, or task changing
+// state.
+type ciEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Status    string    `json:"status"`
+	Pipeline  string    `json:"pipeline,omitempty"`
+	Task      string    `json:"task,omitempty"`
+	Iteration int       `json:"iteration,omitempty"`
+	Timeout   string    `json:"timeout,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ciProgress writes progress events as JSON lines.
+type ciProgress struct {
+	mu  sync.Mutex
+	out io.Writer
+	now func() time.Time
+}
+
+func newCIProgress(out io.Writer) *ciProgress {
+	return &ciProgress{out: out, now: time.Now}
+}
+
+// emit writes ev, stamped with the current time. It's a no-op on a nil
+// ciProgress, so callers needn't check for --ci.
+func (p *ciProgress) emit(ev ciEvent) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ev.Time = p.now().UTC()
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(p.out, "%s\n", data)
+}
+
+// task emits a task's new status. err is reported for failures.
+func (p *ciProgress) task(pipeline, task string, iteration int, status string, err error) {
+	ev := ciEvent{Event: "task", Status: status, Pipeline: pipeline, Task: task, Iteration: iteration}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	p.emit(ev)
+}
+
+// pipeline emits a pipeline instance starting, or finishing with err.
+func (p *ciProgress) pipeline(name, status string, err error) {
+	ev := ciEvent{Event: "pipeline", Status: status, Pipeline: name}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	p.emit(ev)
+}
+
+// outcomeStatus names how a pipeline or run that returned err ended.
+func outcomeStatus(err error) string {
+	switch {
+	case err == nil:
+		return "succeeded"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed_out"
+	default:
+		return "failed"
+	}
+}
+
+// upRunTimeout returns the total timeout of the run: --timeout, or
+// ciDefaultTimeout with --ci. Zero means no timeout.
+func upRunTimeout(timeout string, ci bool) (time.Duration, error) {
+	if timeout == "" {
+		if ci {
+			return ciDefaultTimeout, nil
+		}
+		return 0, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout format %q: %w", timeout, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive: %s", timeout)
+	}
+	return d, nil
+}
+
+// beginUpRun applies the --ci defaults and starts the run's timeout. The
+// returned func ends the run, given its error: it reports the outcome as
+// progress and exits with code 124 if the run timed out.
+func beginUpRun(cmd *cobra.Command) (func(error) error, error) {
+	timeout, err := upRunTimeout(upTimeout, upCI)
+	if err != nil {
+		return nil, err
+	}
+	if upDetach && (upCI || timeout > 0 || upFailFast) {
+		return nil, fmt.Errorf("--ci, --timeout, and --fail-fast need a foreground run and can't be used with --detach")
+	}
+
+	if upCI {
+		if !cmd.Flags().Changed("fail-fast") {
+			upFailFast = true
+		}
+		// Nothing can answer a prompt in CI, e.g. git asking for
+		// credentials while cloning a task's repo
+		color.NoColor = true
+		os.Setenv("GIT_TERMINAL_PROMPT", "0")
+
+		// Progress is the only output on stdout; everything else goes to
+		// stderr
+		upProgress = newCIProgress(os.Stdout)
+		os.Stdout = os.Stderr
+		color.Output = os.Stderr
+	}
+
+	if timeout > 0 {
+		upCtx, upCancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		upCtx, upCancel = context.WithCancel(context.Background())
+	}
+
+	started := ciEvent{Event: "run", Status: "started"}
+	if timeout > 0 {
+		started.Timeout = timeout.String()
+	}
+	upProgress.emit(started)
+
+	return func(err error) error {
+		timedOut := errors.Is(upCtx.Err(), context.DeadlineExceeded)
+		upCancel()
+		if timedOut {
+			err = fmt.Errorf("run timed out after %s", timeout)
+		}
+
+		finished := ciEvent{Event: "run", Status: outcomeStatus(err)}
+		if timedOut {
+			finished.Status = "timed_out"
+		}
+		if err != nil {
+			finished.Error = err.Error()
+		}
+		upProgress.emit(finished)
+
+		if timedOut {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(124) // Exit code 124 matches GNU timeout convention
+		}
+		return err
+	}, nil
+}
+
+// failFast stops the rest of the run after a task fails, with --fail-fast.
+func failFast() {
+	if upFailFast {
+		upCancel()
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestUpRunTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		ci      bool
+		want    time.Duration
+		wantErr bool
+	}{
+		{timeout: "", ci: false, want: 0},
+		{timeout: "", ci: true, want: ciDefaultTimeout},
+		{timeout: "30m", ci: true, want: 30 * time.Minute},
+		{timeout: "90s", ci: false, want: 90 * time.Second},
+		{timeout: "soon", ci: true, wantErr: true},
+		{timeout: "0s", ci: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := upRunTimeout(tt.timeout, tt.ci)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("upRunTimeout(%q, %v) error = %v, wantErr %v", tt.timeout, tt.ci, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("upRunTimeout(%q, %v) = %v, want %v", tt.timeout, tt.ci, got, tt.want)
+		}
+	}
+}
+
+func TestOutcomeStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "succeeded"},
+		{errors.New("2 task(s) failed"), "failed"},
+		{fmt.Errorf("iteration 1 failed: %w", context.DeadlineExceeded), "timed_out"},
+		{context.Canceled, "failed"},
+	}
+	for _, tt := range tests {
+		if got := outcomeStatus(tt.err); got != tt.want {
+			t.Errorf("outcomeStatus(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCIProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := newCIProgress(&buf)
+	p.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	p.pipeline("build", "started", nil)
+	p.task("build", "coder", 1, "failed", errors.New("exit status 1"))
+	p.task("", "lint", 0, "succeeded", nil)
+
+	want := `{"time":"2026-01-02T03:04:05Z","event":"pipeline","status":"started","pipeline":"build"}
+{"time":"2026-01-02T03:04:05Z","event":"task","status":"failed","pipeline":"build","task":"coder","iteration":1,"error":"exit status 1"}
+{"time":"2026-01-02T03:04:05Z","event":"task","status":"succeeded","task":"lint"}
+`
+	if buf.String() != want {
+		t.Errorf("progress =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// Without --ci there's no progress to write
+	var none *ciProgress
+	none.task("build", "coder", 1, "running", nil)
+}
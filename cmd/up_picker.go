@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+)
+
+// upAll skips the picker and starts every pipeline and standalone task.
+var upAll bool
+
+var pickerHelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+// pickerItem is a pipeline or standalone task offered by the picker.
+type pickerItem struct {
+	pipeline bool
+	name     string
+	selected bool
+}
+
+// upPickerModel lets the user choose which pipelines and standalone tasks
+// 'swarm up' starts. Everything starts selected.
+type upPickerModel struct {
+	items    []pickerItem
+	cursor   int
+	canceled bool
+}
+
+func newUpPickerModel(pipelineNames, taskNames []string) upPickerModel {
+	var m upPickerModel
+	for _, name := range pipelineNames {
+		m.items = append(m.items, pickerItem{pipeline: true, name: name, selected: true})
+	}
+	for _, name := range taskNames {
+		m.items = append(m.items, pickerItem{name: name, selected: true})
+	}
+	return m
+}
+
+func (m upPickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m upPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	case " ", "x":
+		m.items[m.cursor].selected = !m.items[m.cursor].selected
+	case "a":
+		// Select all, or none if all are selected
+		all := true
+		for _, item := range m.items {
+			all = all && item.selected
+		}
+		for i := range m.items {
+			m.items[i].selected = !all
+		}
+	case "enter":
+		return m, tea.Quit
+	case "q", "esc", "ctrl+c":
+		m.canceled = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m upPickerModel) View() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Start from %s:", upFile)))
+	b.WriteString("\n\n")
+	for i, item := range m.items {
+		cursor, check := "  ", "[ ]"
+		if i == m.cursor {
+			cursor = "> "
+		}
+		if item.selected {
+			check = "[x]"
+		}
+		kind := "task    "
+		if item.pipeline {
+			kind = "pipeline"
+		}
+		line := fmt.Sprintf("%s%s %s  %s", cursor, check, kind, item.name)
+		if i == m.cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(pickerHelpStyle.Render("space: toggle · a: all/none · enter: start · q: cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// selection returns the selected pipelines and tasks, or ok false if the
+// picker was canceled.
+func (m upPickerModel) selection() (pipelineNames, taskNames []string, ok bool) {
+	if m.canceled {
+		return nil, nil, false
+	}
+	for _, item := range m.items {
+		switch {
+		case !item.selected:
+		case item.pipeline:
+			pipelineNames = append(pipelineNames, item.name)
+		default:
+			taskNames = append(taskNames, item.name)
+		}
+	}
+	return pipelineNames, taskNames, true
+}
+
+// shouldPickUpTargets reports whether 'swarm up' without arguments asks
+// which of count pipelines and standalone tasks to start: only on a
+// terminal, when there's a choice, and not with --all, --watch, or --ci.
+func shouldPickUpTargets(count int) bool {
+	if count < 2 || upAll || upWatch || upCI || upInternalDetached {
+		return false
+	}
+	isTerminal := func(f *os.File) bool {
+		return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	}
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// pickUpTargets asks which of the pipelines and standalone tasks to start.
+// ok is false if the user canceled.
+func pickUpTargets(pipelineNames, taskNames []string) (pickedPipelines, pickedTasks []string, ok bool, err error) {
+	final, err := tea.NewProgram(newUpPickerModel(pipelineNames, taskNames)).Run()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("task picker failed: %w", err)
+	}
+	pickedPipelines, pickedTasks, ok = final.(upPickerModel).selection()
+	return pickedPipelines, pickedTasks, ok, nil
+}
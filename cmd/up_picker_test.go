@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func pickerKeys(m upPickerModel, keys ...string) upPickerModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		next, _ := m.Update(msg)
+		m = next.(upPickerModel)
+	}
+	return m
+}
+
+func TestUpPickerModel(t *testing.T) {
+	m := newUpPickerModel([]string{"main"}, []string{"docs", "lint"})
+
+	// Everything starts selected
+	pipelines, tasks, ok := m.selection()
+	if !ok || !reflect.DeepEqual(pipelines, []string{"main"}) || !reflect.DeepEqual(tasks, []string{"docs", "lint"}) {
+		t.Fatalf("initial selection = %v, %v, %v", pipelines, tasks, ok)
+	}
+
+	// Deselect the pipeline and the last task
+	m = pickerKeys(m, " ", "down", "down", " ", "down", "enter")
+	pipelines, tasks, ok = m.selection()
+	if !ok || len(pipelines) != 0 || !reflect.DeepEqual(tasks, []string{"docs"}) {
+		t.Errorf("selection = %v, %v, %v, want only docs", pipelines, tasks, ok)
+	}
+
+	// "a" selects all, then none
+	m = pickerKeys(m, "a")
+	if pipelines, tasks, _ = m.selection(); len(pipelines)+len(tasks) != 3 {
+		t.Errorf("after a: %v, %v, want everything", pipelines, tasks)
+	}
+	m = pickerKeys(m, "a")
+	if pipelines, tasks, _ = m.selection(); len(pipelines)+len(tasks) != 0 {
+		t.Errorf("after a a: %v, %v, want nothing", pipelines, tasks)
+	}
+
+	if _, _, ok := pickerKeys(m, "esc").selection(); ok {
+		t.Error("esc should cancel the picker")
+	}
+}
+
+func TestShouldPickUpTargets(t *testing.T) {
+	defer func() { upAll = false }()
+	upAll = true
+	if shouldPickUpTargets(5) {
+		t.Error("--all should skip the picker")
+	}
+	upAll = false
+	if shouldPickUpTargets(1) {
+		t.Error("a single target needs no picker")
+	}
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
 )
 
 func TestLoadTaskPrompt(t *testing.T) {
@@ -478,6 +483,10 @@ func TestIsPipelineInstance(t *testing.T) {
 		{"pipeline:dev.1.2", "dev", false},        // nested dots - suffix "1.2" is not a number
 		{"pipeline:my-pipeline", "my-pipeline", true},
 		{"pipeline:my-pipeline.3", "my-pipeline", true},
+		// Matrix instances
+		{"pipeline:dev@auth", "dev", true},
+		{"pipeline:dev@auth-linux", "dev", true},
+		{"pipeline:devops@auth", "dev", false},
 	}
 
 	for _, tt := range tests {
@@ -875,3 +884,57 @@ tasks: {}
 		})
 	}
 }
+
+func TestIsPipelineNodeArg(t *testing.T) {
+	cf := &compose.ComposeFile{
+		Tasks:     map[string]compose.Task{"planner": {Prompt: "plan"}},
+		Pipelines: map[string]compose.Pipeline{"dev": {}},
+	}
+
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"dev:planner", true},
+		{"dev:", false},
+		{"prod:planner", false},
+		{"planner", false},
+	}
+	for _, tt := range tests {
+		if got := isPipelineNodeArg(cf, tt.arg); got != tt.want {
+			t.Errorf("isPipelineNodeArg(%q) = %v, want %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestWaitForTaskTodoRoomStopsWhenUpTerminated(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	todoDir, _ := appConfig.TodoQueueDirs(workingDir)
+	if err := os.MkdirAll(todoDir, 0755); err != nil {
+		t.Fatalf("failed to create todo dir: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		os.WriteFile(filepath.Join(todoDir, fmt.Sprintf("task-%d.todo.md", i)), []byte("todo"), 0644)
+	}
+
+	mgr, err := state.NewManagerWithScope(scope.ScopeGlobal, "")
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	upAgent := &state.AgentState{ID: "up000001", Name: "pipeline:tasks", PID: os.Getpid(), Status: "running", StartedAt: time.Now(), TerminateMode: "after_iteration"}
+	if err := mgr.Register(upAgent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	oldDetached, oldTaskID, oldPoll := upInternalDetached, upInternalTaskID, todoqueue.PollInterval
+	upInternalDetached, upInternalTaskID, todoqueue.PollInterval = true, upAgent.ID, 10*time.Millisecond
+	defer func() {
+		upInternalDetached, upInternalTaskID, todoqueue.PollInterval = oldDetached, oldTaskID, oldPoll
+	}()
+
+	var out bytes.Buffer
+	if waitForTaskTodoRoom(compose.Task{MaxPending: 1}, workingDir, &out, upTerminated(mgr)) {
+		t.Errorf("expected the wait to stop when the up agent is terminated, output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// composeWatchInterval is how often 'swarm up --watch' checks the compose
+// file for changes.
+const composeWatchInterval = time.Second
+
+var upWatch bool
+
+// composeDiff lists the tasks or pipelines that changed between two versions
+// of a compose file, by name.
+type composeDiff struct {
+	added   []string
+	removed []string
+	changed []string // anything but parallelism changed: restarted
+	scaled  []string // only parallelism changed: instances started or killed
+}
+
+// composeChanges are the changes to a compose file's pipelines and
+// standalone tasks.
+type composeChanges struct {
+	tasks     composeDiff
+	pipelines composeDiff
+}
+
+func (c composeChanges) empty() bool {
+	for _, d := range []composeDiff{c.tasks, c.pipelines} {
+		if len(d.added)+len(d.removed)+len(d.changed)+len(d.scaled) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// diffCompose returns how the pipelines and standalone tasks of old changed
+// in new. A pipeline also counts as changed when one of its tasks did.
+func diffCompose(old, new *compose.ComposeFile) composeChanges {
+	var c composeChanges
+
+	oldTasks, newTasks := old.GetStandaloneTasks(), new.GetStandaloneTasks()
+	c.tasks = diffByName(keys(oldTasks), keys(newTasks), func(name string) (bool, bool) {
+		a, b := oldTasks[name], newTasks[name]
+		scaled := a.EffectiveParallelism() != b.EffectiveParallelism()
+		a.Parallelism, b.Parallelism = 0, 0
+		return !reflect.DeepEqual(a, b), scaled
+	})
+
+	c.pipelines = diffByName(keys(old.Pipelines), keys(new.Pipelines), func(name string) (bool, bool) {
+		a, b := old.Pipelines[name], new.Pipelines[name]
+		scaled := a.EffectiveParallelism() != b.EffectiveParallelism()
+		a.Parallelism, b.Parallelism = 0, 0
+		if !reflect.DeepEqual(a, b) {
+			return true, scaled
+		}
+		for _, task := range b.GetPipelineTasks(new.Tasks) {
+			if !reflect.DeepEqual(old.Tasks[task], new.Tasks[task]) {
+				return true, scaled
+			}
+		}
+		return false, scaled
+	})
+	return c
+}
+
+// diffByName sorts the names in either list into added, removed, changed,
+// and scaled, using compare to tell whether a name in both changed.
+func diffByName(oldNames, newNames []string, compare func(name string) (changed, scaled bool)) composeDiff {
+	var d composeDiff
+	inOld := make(map[string]bool, len(oldNames))
+	for _, name := range oldNames {
+		inOld[name] = true
+	}
+	inNew := make(map[string]bool, len(newNames))
+	for _, name := range newNames {
+		inNew[name] = true
+		if !inOld[name] {
+			d.added = append(d.added, name)
+			continue
+		}
+		switch changed, scaled := compare(name); {
+		case changed:
+			d.changed = append(d.changed, name)
+		case scaled:
+			d.scaled = append(d.scaled, name)
+		}
+	}
+	for _, name := range oldNames {
+		if !inNew[name] {
+			d.removed = append(d.removed, name)
+		}
+	}
+	return d
+}
+
+// keys returns the keys of m, sorted.
+func keys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// watchCompose starts the compose file's pipelines and standalone tasks
+// detached, then reconciles them with the file each time it changes until
+// interrupted: added ones are started, removed ones killed, changed ones
+// restarted, and ones whose parallelism changed scaled. Agents keep running
+// after it returns.
+func watchCompose(cf *compose.ComposeFile, promptsDir, workingDir string) error {
+	data, err := os.ReadFile(upFile)
+	if err != nil {
+		return fmt.Errorf("failed to read compose file: %w", err)
+	}
+	if err := runAllPipelinesAndStandaloneTasks(cf, promptsDir, workingDir); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(composeWatchInterval)
+	defer ticker.Stop()
+
+	fmt.Printf("\nWatching %s for changes (Ctrl+C to stop watching; agents keep running)\n", upFile)
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopped watching. Use 'swarm down' to stop the agents.")
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := os.ReadFile(upFile)
+		if err != nil || bytes.Equal(next, data) {
+			continue
+		}
+		data = next
+
+		newCf, err := reloadComposeFile(workingDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\n%s changed, but %v; keeping the running agents\n", upFile, err)
+			continue
+		}
+		changes := diffCompose(cf, newCf)
+		if changes.empty() {
+			cf = newCf
+			continue
+		}
+		fmt.Printf("\n%s changed at %s\n", upFile, time.Now().Format("15:04:05"))
+		if err := applyComposeChanges(cf, newCf, changes, promptsDir, workingDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		cf = newCf
+	}
+}
+
+// reloadComposeFile loads and validates upFile as 'swarm up' does.
+func reloadComposeFile(workingDir string) (*compose.ComposeFile, error) {
+	cf, err := loadComposeFileWithOptions(upFile, compose.LoadOptions{Lenient: upLenient, StrictEnv: upStrictEnv})
+	if err != nil {
+		return nil, err
+	}
+	if err := cf.Validate(); err != nil {
+		return nil, fmt.Errorf("it's invalid: %w", err)
+	}
+	if err := cf.LoadEnvFiles(); err != nil {
+		return nil, fmt.Errorf("it's invalid: %w", err)
+	}
+	if err := resolveTaskRepos(cf, workingDir); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+// applyComposeChanges kills the agents of removed and changed tasks and
+// pipelines of old, then starts new's added and changed ones and scales
+// those whose parallelism changed.
+func applyComposeChanges(old, new *compose.ComposeFile, changes composeChanges, promptsDir, workingDir string) error {
+	mgr, err := state.NewManagerWithScope(GetScope(), workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize state manager: %w", err)
+	}
+	running, err := mgr.List(true)
+	if err != nil {
+		return fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	oldTasks := old.GetStandaloneTasks()
+	for _, name := range append(append([]string(nil), changes.tasks.removed...), changes.tasks.changed...) {
+		task := oldTasks[name]
+		baseName := name
+		if task.Name != "" {
+			baseName = task.Name
+		}
+		for _, a := range running {
+			if isTaskInstance(a.Name, baseName) {
+				fmt.Printf("  [%s] Stopping (task %s)\n", a.Name, describeChange(changes.tasks, name))
+				killAgentAndDescendants(mgr, a)
+			}
+		}
+	}
+	for _, name := range append(append([]string(nil), changes.pipelines.removed...), changes.pipelines.changed...) {
+		for _, a := range running {
+			if isPipelineInstance(a.Name, name) {
+				fmt.Printf("  [%s] Stopping (pipeline %s)\n", a.Name, describeChange(changes.pipelines, name))
+				killAgentAndDescendants(mgr, a)
+			}
+		}
+	}
+
+	for _, name := range append(append(append([]string(nil), changes.pipelines.added...), changes.pipelines.changed...), changes.pipelines.scaled...) {
+		if err := runPipelineDetached(new, name, promptsDir, workingDir); err != nil {
+			return fmt.Errorf("pipeline %q failed to start: %w", name, err)
+		}
+	}
+
+	taskNames := append(append(append([]string(nil), changes.tasks.added...), changes.tasks.changed...), changes.tasks.scaled...)
+	if len(taskNames) == 0 {
+		return nil
+	}
+	sort.Strings(taskNames)
+	newTasks := new.GetStandaloneTasks()
+	tasks := make(map[string]compose.Task, len(taskNames))
+	for _, name := range taskNames {
+		tasks[name] = newTasks[name]
+	}
+	return runTasksDetached(taskNames, tasks, promptsDir, workingDir)
+}
+
+// describeChange says why a task or pipeline's agents are stopped.
+func describeChange(d composeDiff, name string) string {
+	for _, removed := range d.removed {
+		if removed == name {
+			return "removed"
+		}
+	}
+	return "changed"
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+)
+
+func TestDiffCompose(t *testing.T) {
+	old := &compose.ComposeFile{
+		Tasks: map[string]compose.Task{
+			"lint":    {Prompt: "lint"},
+			"docs":    {Prompt: "docs", Model: "sonnet"},
+			"scale":   {Prompt: "scale", Parallelism: 2},
+			"same":    {Prompt: "same"},
+			"planner": {Prompt: "planner"},
+			"coder":   {Prompt: "coder"},
+		},
+		Pipelines: map[string]compose.Pipeline{
+			"main":  {Tasks: []string{"planner", "coder"}},
+			"extra": {Tasks: []string{"planner"}, Parallelism: 1},
+		},
+	}
+	new := &compose.ComposeFile{
+		Tasks: map[string]compose.Task{
+			"docs":    {Prompt: "docs", Model: "opus"},
+			"scale":   {Prompt: "scale", Parallelism: 4},
+			"same":    {Prompt: "same"},
+			"review":  {Prompt: "review"},
+			"planner": {Prompt: "planner"},
+			"coder":   {Prompt: "coder-v2"},
+		},
+		Pipelines: map[string]compose.Pipeline{
+			"main":  {Tasks: []string{"planner", "coder"}},
+			"extra": {Tasks: []string{"planner"}, Parallelism: 3},
+		},
+	}
+
+	c := diffCompose(old, new)
+	wantTasks := composeDiff{added: []string{"review"}, removed: []string{"lint"}, changed: []string{"docs"}, scaled: []string{"scale"}}
+	if !reflect.DeepEqual(c.tasks, wantTasks) {
+		t.Errorf("tasks = %+v, want %+v", c.tasks, wantTasks)
+	}
+	wantPipelines := composeDiff{changed: []string{"main"}, scaled: []string{"extra"}}
+	if !reflect.DeepEqual(c.pipelines, wantPipelines) {
+		t.Errorf("pipelines = %+v, want %+v", c.pipelines, wantPipelines)
+	}
+
+	if !diffCompose(old, old).empty() {
+		t.Error("diffCompose(old, old) should be empty")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/mj1618/swarm-cli/internal/label"
-	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/state"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +26,8 @@ var updateCmd = &cobra.Command{
 
 The agent can be specified by its ID, name, or special identifier:
   - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)
 
 Use --filter-label to update all agents matching the specified labels.
 When using --filter-label, the task-id-or-name argument is not required.
@@ -208,7 +209,7 @@ Use --set-label to add or update labels on an agent.`,
 			}
 
 			// Send termination signal to the process
-			if err := process.Kill(agent.PID); err != nil {
+			if err := agent.Kill(); err != nil {
 				fmt.Printf("Warning: could not send signal to process %d: %v\n", agent.PID, err)
 			}
 
@@ -267,4 +268,5 @@ func init() {
 	// Add dynamic completion for agent identifier and model flag
 	updateCmd.ValidArgsFunction = completeAgentIdentifier
 	updateCmd.RegisterFlagCompletionFunc("model", completeModelName)
+	updateCmd.RegisterFlagCompletionFunc("set-label", completeLabel)
 }
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCheckOffline bool
+
+// startsAgentsAnnotation marks commands that start agents, which also check
+// the agent CLI against min_agent_version.
+const startsAgentsAnnotation = "swarm/starts-agents"
+
+// versionCheckExempt are the commands that run regardless of the version
+// pins, so an outdated install can still be inspected and upgraded.
+var versionCheckExempt = map[string]bool{
+	"upgrade":       true,
+	"upgrade-check": true,
+	"version":       true,
+	"doctor":        true,
+	"help":          true,
+	"completion":    true,
+}
+
+var upgradeCheckCmd = &cobra.Command{
+	Use:   "upgrade-check",
+	Short: "Check swarm and the agent CLI against the project's version pins",
+	Long: `Check the installed swarm and agent CLI against the versions the project
+pins in swarm.toml, and whether a newer swarm release is available.
+
+A project can pin the oldest versions it works with, so teams sharing compose
+files and prompts don't hit behavior differences across installs:
+
+  min_swarm_version = "1.4.0"   # every command fails on an older swarm
+  min_agent_version = "2.0.0"   # commands that start agents fail on an older agent CLI
+
+The agent CLI's version is the first version number in its --version output.
+Exits non-zero if a pin isn't met. Development builds satisfy any
+min_swarm_version.`,
+	Example: `  # Check versions and look for a newer release
+  swarm upgrade-check
+
+  # Only check the pins, without contacting GitHub
+  swarm upgrade-check --offline`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		green := color.New(color.FgGreen)
+		red := color.New(color.FgRed)
+		ok := true
+
+		report := func(label, current, min string, met bool) {
+			fmt.Printf("%-10s %s", label+":", current)
+			if min == "" {
+				fmt.Println()
+				return
+			}
+			fmt.Printf(" (requires %s or newer) ", min)
+			if met {
+				green.Println("ok")
+			} else {
+				red.Println("too old")
+				ok = false
+			}
+		}
+
+		report("swarm", version.Version, appConfig.MinSwarmVersion, swarmVersionMet(appConfig))
+
+		executable := appConfig.Command.Executable
+		agentVersion := agent.CLIVersion(executable)
+		current := executable + " " + agentVersion
+		if agentVersion == "" {
+			current = executable + " (version unknown)"
+		}
+		report("agent CLI", current, appConfig.MinAgentVersion, agentVersionMet(appConfig, agentVersion))
+
+		if !upgradeCheckOffline {
+			if release, err := fetchLatestRelease(); err != nil {
+				fmt.Printf("%-10s could not check: %v\n", "latest:", err)
+			} else {
+				latest := strings.TrimPrefix(release.TagName, "v")
+				fmt.Printf("%-10s %s", "latest:", latest)
+				if version.IsRelease() && !version.AtLeast(version.Version, latest) {
+					fmt.Print(" (run 'swarm upgrade')")
+				}
+				fmt.Println()
+			}
+		}
+
+		if !ok {
+			return fmt.Errorf("the project's version requirements are not met")
+		}
+		return nil
+	},
+}
+
+// swarmVersionMet reports whether this swarm satisfies cfg's min_swarm_version.
+func swarmVersionMet(cfg *config.Config) bool {
+	return cfg.MinSwarmVersion == "" || version.AtLeast(version.Version, cfg.MinSwarmVersion)
+}
+
+// agentVersionMet reports whether an agent CLI reporting agentVersion
+// satisfies cfg's min_agent_version. An unknown version doesn't.
+func agentVersionMet(cfg *config.Config, agentVersion string) bool {
+	if cfg.MinAgentVersion == "" {
+		return true
+	}
+	if _, ok := version.Parse(agentVersion); !ok {
+		return false
+	}
+	return version.AtLeast(agentVersion, cfg.MinAgentVersion)
+}
+
+// checkVersionPins fails cmd if this swarm, or for a command that starts
+// agents the agent CLI, is older than the project's pinned minimum.
+func checkVersionPins(cmd *cobra.Command, cfg *config.Config) error {
+	if versionCheckExempt[cmd.Name()] {
+		return nil
+	}
+	if !swarmVersionMet(cfg) {
+		return fmt.Errorf("this project requires swarm %s or newer (min_swarm_version), but this is swarm %s; run 'swarm upgrade'",
+			cfg.MinSwarmVersion, version.Version)
+	}
+	if cfg.MinAgentVersion == "" || cmd.Annotations[startsAgentsAnnotation] == "" {
+		return nil
+	}
+	executable := cfg.Command.Executable
+	agentVersion := agent.CLIVersion(executable)
+	if agentVersion == "" {
+		return fmt.Errorf("this project requires %s %s or newer (min_agent_version), but '%s --version' failed", executable, cfg.MinAgentVersion, executable)
+	}
+	if !agentVersionMet(cfg, agentVersion) {
+		return fmt.Errorf("this project requires %s %s or newer (min_agent_version), but %s reports %q; upgrade it, then check with 'swarm upgrade-check'",
+			executable, cfg.MinAgentVersion, executable, agentVersion)
+	}
+	return nil
+}
+
+// markStartsAgents annotates commands that start agents, so they check the
+// agent CLI against min_agent_version before running.
+func markStartsAgents(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+		c.Annotations[startsAgentsAnnotation] = "true"
+	}
+}
+
+func init() {
+	upgradeCheckCmd.Flags().BoolVar(&upgradeCheckOffline, "offline", false, "Only check the version pins, without looking for a newer release")
+	rootCmd.AddCommand(upgradeCheckCmd)
+	markStartsAgents(runCmd, upCmd, cloneCmd, restartCmd, replayCmd)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func TestCheckVersionPins(t *testing.T) {
+	saved := version.Version
+	t.Cleanup(func() { version.Version = saved })
+
+	cli := filepath.Join(t.TempDir(), "fake-agent")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho '2.0.14 (Fake CLI)'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Command.Executable = cli
+
+	plain := &cobra.Command{Use: "list"}
+	starter := &cobra.Command{Use: "run"}
+	markStartsAgents(starter)
+
+	version.Version = "1.3.0"
+	cfg.MinSwarmVersion = "1.4.0"
+	if err := checkVersionPins(plain, cfg); err == nil || !strings.Contains(err.Error(), "requires swarm 1.4.0") {
+		t.Errorf("old swarm: err = %v", err)
+	}
+	if err := checkVersionPins(&cobra.Command{Use: "upgrade"}, cfg); err != nil {
+		t.Errorf("upgrade must run on an old swarm: %v", err)
+	}
+	version.Version = "dev"
+	if err := checkVersionPins(plain, cfg); err != nil {
+		t.Errorf("dev build: %v", err)
+	}
+
+	version.Version = "1.4.2"
+	cfg.MinAgentVersion = "2.1.0"
+	if err := checkVersionPins(plain, cfg); err != nil {
+		t.Errorf("agent CLI checked by a command that doesn't start agents: %v", err)
+	}
+	if err := checkVersionPins(starter, cfg); err == nil || !strings.Contains(err.Error(), `reports "2.0.14 (Fake CLI)"`) {
+		t.Errorf("old agent CLI: err = %v", err)
+	}
+	cfg.MinAgentVersion = "2.0"
+	if err := checkVersionPins(starter, cfg); err != nil {
+		t.Errorf("new enough agent CLI: %v", err)
+	}
+
+	cfg.Command.Executable = filepath.Join(t.TempDir(), "missing")
+	if err := checkVersionPins(starter, cfg); err == nil {
+		t.Error("expected an error when the agent CLI's version can't be determined")
+	}
+}
@@ -26,7 +26,9 @@ Blocks until all specified agents have terminated, or until the timeout
 is reached (if specified). Useful for scripting and orchestration.
 
 The agent can be specified by its ID, name, or special identifier:
-  - @last or _ : the most recently started agent`,
+  - @last or _ : the most recently started agent
+  - pipeline:name : a pipeline's agent (or its only running instance)
+  - label:key=value : the agent with these labels (comma-separated)`,
 	Example: `  # Wait for a single agent
   swarm wait abc123
 
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mj1618/swarm-cli/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreePrune bool
+
+var worktreeCmd = &cobra.Command{
+	Use:     "worktree",
+	Aliases: []string{"worktrees"},
+	Short:   "List the branches of worktree-isolated agents",
+	Long: `List the swarm/ branches of agents run with worktree isolation
+('swarm run --worktree' or 'isolation: worktree' in swarm.yaml) in the
+current repository.
+
+Each such agent works in its own git worktree under ~/.swarm/worktrees on a
+swarm/<agent> branch, so parallel agents don't clobber each other's edits.
+The worktree is removed when the agent finishes; its branch is deleted if
+its changes were merged back (merge_back / --merge-back) and otherwise kept
+for review, e.g. with 'git diff HEAD...swarm/<agent>'.
+
+Use --prune to clean up after agents that were killed before they could:
+git's records of deleted worktrees are dropped and branches without a
+worktree that have nothing left to merge are deleted. Running agents'
+worktrees and branches with unmerged changes are left alone.`,
+	Example: `  # List agents' branches and their unmerged commits
+  swarm worktree
+
+  # Review an agent's changes
+  git diff HEAD...swarm/coder-abc123
+
+  # Clean up leftover worktrees and merged branches
+  swarm worktree --prune`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if worktreePrune {
+			deleted, err := worktree.Prune(".")
+			for _, b := range deleted {
+				fmt.Printf("Deleted %s\n", b)
+			}
+			if err != nil {
+				return err
+			}
+			if len(deleted) == 0 {
+				fmt.Println("Nothing to prune")
+			}
+			return nil
+		}
+
+		branches, err := worktree.List(".")
+		if err != nil {
+			return fmt.Errorf("failed to list worktree branches: %w", err)
+		}
+		if len(branches) == 0 {
+			fmt.Println("No worktree branches")
+			return nil
+		}
+		for _, b := range branches {
+			where := "(worktree removed)"
+			if b.Path != "" {
+				where = b.Path
+			}
+			fmt.Printf("  %s  %d unmerged commit(s)  %s\n", b.Name, b.Unmerged, where)
+		}
+		return nil
+	},
+}
+
+func init() {
+	worktreeCmd.Flags().BoolVar(&worktreePrune, "prune", false, "Remove leftover worktree records and merged branches without a worktree")
+	rootCmd.AddCommand(worktreeCmd)
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is wrapped by the error of an iteration that was stopped
+// because it ran on for BudgetGracePeriod after its agent or pipeline
+// exceeded its budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetGracePeriod is how long an iteration may keep running once its agent
+// or pipeline has exceeded its budget. Agents are stopped gracefully after
+// the current iteration, and forcefully if it hasn't ended by then.
+var BudgetGracePeriod = 2 * time.Minute
+
+// BudgetGuard stops an iteration that runs on too long after exceeding a
+// budget.
+type BudgetGuard struct {
+	cancel func()
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	reason string
+	forced bool
+}
+
+// NewBudgetGuard returns a guard that calls cancel to stop the iteration.
+func NewBudgetGuard(cancel func()) *BudgetGuard {
+	return &BudgetGuard{cancel: cancel}
+}
+
+// Exceeded records that the budget was exceeded, described by reason, and
+// starts the grace period. It returns true only the first time, so callers
+// can report it once.
+func (g *BudgetGuard) Exceeded(reason string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reason != "" {
+		return false
+	}
+	g.reason = reason
+	g.timer = time.AfterFunc(BudgetGracePeriod, func() {
+		g.mu.Lock()
+		g.forced = true
+		g.mu.Unlock()
+		g.cancel()
+	})
+	return true
+}
+
+// Stop ends the grace period. If the iteration was stopped, it returns an
+// error wrapping ErrBudgetExceeded.
+func (g *BudgetGuard) Stop() error {
+	g.mu.Lock()
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+	g.mu.Unlock()
+	return g.Err()
+}
+
+// Err returns an error wrapping ErrBudgetExceeded if the iteration was
+// stopped, or nil.
+func (g *BudgetGuard) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.forced {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, stopped after %v", ErrBudgetExceeded, g.reason, BudgetGracePeriod)
+}
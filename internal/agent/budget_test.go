@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBudgetGuard(t *testing.T) {
+	defer func(d time.Duration) { BudgetGracePeriod = d }(BudgetGracePeriod)
+	BudgetGracePeriod = 10 * time.Millisecond
+
+	var cancels atomic.Int32
+	g := NewBudgetGuard(func() { cancels.Add(1) })
+	if !g.Exceeded("budget reached ($5.00 of $5.00)") {
+		t.Error("first Exceeded should report the budget")
+	}
+	if g.Exceeded("budget reached ($5.10 of $5.00)") {
+		t.Error("later Exceeded calls should not report it again")
+	}
+	time.Sleep(50 * time.Millisecond)
+	err := g.Stop()
+	if cancels.Load() != 1 || !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected the iteration to be stopped, got %d cancels, err %v", cancels.Load(), err)
+	}
+	if class := ClassifyFailure(err, nil); class != FailureBudget {
+		t.Errorf("ClassifyFailure() = %q, want %q", class, FailureBudget)
+	}
+
+	BudgetGracePeriod = time.Hour
+	g = NewBudgetGuard(func() { t.Error("iteration that finished in time was stopped") })
+	g.Exceeded("token budget reached (100 of 100 tokens)")
+	if err := g.Stop(); err != nil {
+		t.Errorf("Stop() = %v, want nil for an iteration that finished in time", err)
+	}
+}
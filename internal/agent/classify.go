@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/mj1618/swarm-cli/internal/exitreport"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
+)
+
+// Failure classes recorded in AgentState.LastErrorClass (and ExitReason when
+// the final iteration failed), so failures can be aggregated by cause.
+const (
+	FailureAuth            = "auth_error"
+	FailureRateLimited     = "rate_limited"
+	FailureContextOverflow = "context_overflow"
+	FailureToolDenied      = "tool_denied"
+	FailureTimeout         = "timeout"
+	FailureCrash           = "crash"
+	FailurePathViolation   = "path_violation"
+	FailureReported        = "reported_failure"
+	FailureBudget          = "budget"
+)
+
+// failurePatterns maps lowercase substrings found in error events, stderr, or
+// the error itself to a failure class. Earlier entries take precedence.
+var failurePatterns = []struct {
+	class    string
+	patterns []string
+}{
+	{FailureAuth, []string{"invalid api key", "invalid_api_key", "authentication", "unauthorized", "not logged in", "please run /login", "login required", "oauth token"}},
+	{FailureRateLimited, []string{"rate limit", "rate_limit", "ratelimit", "too many requests", "overloaded", "quota", "usage limit"}},
+	{FailureContextOverflow, []string{"context length", "context window", "context_length_exceeded", "prompt is too long", "too many tokens", "maximum context", "input is too long"}},
+	{FailureToolDenied, []string{"permission denied for tool", "tool_denied", "requires approval", "not allowed to use", "was blocked", "user denied", "permission to use"}},
+}
+
+// errorEventLimit is the number of error event lines a Runner keeps.
+const errorEventLimit = 5
+
+// errorEvents collects agent CLI output lines that report an error, e.g.
+// Claude Code result events with is_error or Codex error events.
+type errorEvents struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// note records line if it is an error event.
+func (e *errorEvents) note(line string, event *logparser.LogEvent) {
+	if !isErrorEvent(line, event) {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lines = append(e.lines, line)
+	if len(e.lines) > errorEventLimit {
+		e.lines = e.lines[len(e.lines)-errorEventLimit:]
+	}
+}
+
+// Lines returns the recorded error event lines.
+func (e *errorEvents) Lines() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.lines...)
+}
+
+// isErrorEvent reports whether a JSONL line is an error event. Lines that
+// don't decode as a LogEvent (e.g. Codex errors whose "message" is a string)
+// are matched on their type field.
+func isErrorEvent(line string, event *logparser.LogEvent) bool {
+	if event == nil {
+		trimmed := strings.TrimSpace(line)
+		return strings.HasPrefix(trimmed, `{"type":"error"`) || strings.HasPrefix(trimmed, `{"type":"turn.failed"`)
+	}
+	switch event.Type {
+	case "error", "turn.failed":
+		return true
+	case "result":
+		return event.IsError || strings.HasPrefix(event.Subtype, "error")
+	}
+	return false
+}
+
+// ClassifyFailure maps a failed iteration to a failure class using the
+// error returned by the run and any error events or stderr lines the agent
+// CLI produced. Unrecognised failures are classed as crashes.
+func ClassifyFailure(err error, output []string) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, pathguard.ErrViolation) {
+		return FailurePathViolation
+	}
+	if errors.Is(err, exitreport.ErrFailure) {
+		return FailureReported
+	}
+	if errors.Is(err, ErrBudgetExceeded) {
+		return FailureBudget
+	}
+
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "timed out") || errors.Is(err, ErrSlowIteration) {
+		return FailureTimeout
+	}
+
+	text := strings.ToLower(strings.Join(append(output, err.Error()), "\n"))
+	for _, fp := range failurePatterns {
+		for _, p := range fp.patterns {
+			if strings.Contains(text, p) {
+				return fp.class
+			}
+		}
+	}
+
+	// Non-zero exits without a recognisable cause, including processes
+	// killed by a signal, are crashes.
+	return FailureCrash
+}
+
+// ClassifyFailure classifies err, returned by the most recent run, using the
+// error events and stderr the agent produced during that run.
+func (r *Runner) ClassifyFailure(err error) string {
+	return ClassifyFailure(err, append(r.errors.Lines(), r.StderrTail()...))
+}
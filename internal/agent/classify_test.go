@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/logparser"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name   string
+		err    error
+		output []string
+		want   string
+	}{
+		{"no error", nil, nil, ""},
+		{"timeout", errors.New("iteration timed out after 5m0s"), nil, FailureTimeout},
+		{"auth", exitErr, []string{"Invalid API key · Please run /login"}, FailureAuth},
+		{"rate limit event", exitErr, []string{`{"type":"result","subtype":"error_during_execution","is_error":true,"result":"API Error: 429 rate_limit_error"}`}, FailureRateLimited},
+		{"context", exitErr, []string{"Error: prompt is too long: 210000 tokens > 200000 maximum"}, FailureContextOverflow},
+		{"tool denied", exitErr, []string{"Claude requested permission to use Bash, but you haven't granted it yet."}, FailureToolDenied},
+		{"unknown", exitErr, []string{"panic: runtime error: index out of range"}, FailureCrash},
+		{"signal", errors.New("signal: killed"), nil, FailureCrash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.err, tt.output); got != tt.want {
+				t.Errorf("ClassifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsErrorEvent(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`{"type":"result","subtype":"success","is_error":false}`, false},
+		{`{"type":"result","subtype":"error_max_turns"}`, true},
+		{`{"type":"result","subtype":"success","is_error":true}`, true},
+		{`{"type":"error","message":"stream disconnected"}`, true},
+		{`{"type":"turn.failed","error":{"message":"quota exceeded"}}`, true},
+		{`{"type":"assistant","message":{"role":"assistant"}}`, false},
+		{"plain text", false},
+	}
+
+	for _, tt := range tests {
+		if got := isErrorEvent(tt.line, logparser.ParseEvent(tt.line)); got != tt.want {
+			t.Errorf("isErrorEvent(%s) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
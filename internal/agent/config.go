@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
 )
 
 // Config holds the configuration for running an agent.
@@ -17,14 +18,43 @@ type Config struct {
 	// Command holds the command configuration (executable and args template)
 	Command config.CommandConfig
 
+	// Dir is the directory the agent runs in (empty = this process's
+	// working directory)
+	Dir string
+
 	// Env holds environment variables in KEY=VALUE format to pass to the agent process
 	Env []string
 
+	// Run describes the run this iteration belongs to, exported to the agent
+	// process as SWARM_ITERATION, SWARM_TASK_NAME, etc. Env overrides it.
+	Run RunInfo
+
 	// Timeout is the per-iteration timeout (0 means no timeout)
 	Timeout time.Duration
 
+	// StderrFile, when set, receives the agent CLI's stderr instead of this
+	// process's stderr, keeping it out of the agent's JSONL log.
+	StderrFile string
+
 	// ResultGracePeriod is how long to wait after seeing a result event
 	// before force-killing a hung process. 0 uses the default (30s).
 	// Negative values disable this feature.
 	ResultGracePeriod time.Duration
+
+	// ResultCriteria, when set, fails a run whose result event matches it
+	// even if the agent CLI exits 0
+	ResultCriteria *ResultCriteria
+
+	// StateDir is the pipeline iteration's state directory (SWARM_STATE_DIR),
+	// if any. Container agents get it mounted along with the working directory.
+	StateDir string
+
+	// ExitFile is where the agent may write its exit report, exported as
+	// SWARM_EXIT_FILE (empty = no exit report). A report with status
+	// "failure" fails the run.
+	ExitFile string
+
+	// PathGuard, when set, kills the agent and fails the run if it edits a
+	// file the guard doesn't allow
+	PathGuard *pathguard.Guard
 }
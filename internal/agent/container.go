@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/scratch"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// containerRemoveTimeout bounds how long removing a container may take.
+const containerRemoveTimeout = 30 * time.Second
+
+// containerSpec describes a container run of the agent CLI.
+type containerSpec struct {
+	// Name identifies the container, so it can be removed if the run is cancelled
+	Name string
+
+	Image string
+
+	// WorkingDir is bind-mounted at the same path and used as the container's
+	// working directory, so file paths in prompts and tool output stay valid
+	WorkingDir string
+
+	// ReadOnly mounts WorkingDir read-only
+	ReadOnly bool
+
+	// Mounts are further host directories the agent writes to (state,
+	// scratch and exit-file directories), bind-mounted read-write at the
+	// same paths
+	Mounts []string
+
+	// User is the uid:gid to run as, so files the agent creates are owned by
+	// the user running swarm (empty = the image's user)
+	User string
+
+	Executable string
+	Args       []string
+
+	// Env holds KEY=VALUE pairs whose names are forwarded, so the runtime
+	// copies their values from its own environment
+	Env []string
+}
+
+// newContainerName returns a unique container name.
+func newContainerName() string {
+	return "swarm-agent-" + state.GenerateID()
+}
+
+// currentUser returns this process's uid:gid, or "" where there is none
+// (Windows).
+func currentUser() string {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 || gid < 0 {
+		return ""
+	}
+	return strconv.Itoa(uid) + ":" + strconv.Itoa(gid)
+}
+
+// containerArgs builds the container runtime arguments for spec. The
+// container runs with an init process, so the agent CLI gets signals and its
+// children are reaped, and with stdin attached, so it stops along with the
+// runtime client.
+func containerArgs(spec containerSpec) []string {
+	out := []string{"run", "--rm", "-i", "--init"}
+	if spec.Name != "" {
+		out = append(out, "--name", spec.Name)
+	}
+	if spec.User != "" {
+		out = append(out, "--user", spec.User)
+	}
+	if spec.WorkingDir != "" {
+		mount := spec.WorkingDir + ":" + spec.WorkingDir
+		if spec.ReadOnly {
+			mount += ":ro"
+		}
+		out = append(out, "-v", mount, "-w", spec.WorkingDir)
+	}
+	for _, dir := range spec.Mounts {
+		out = append(out, "-v", dir+":"+dir)
+	}
+	for _, e := range spec.Env {
+		if idx := strings.Index(e, "="); idx > 0 {
+			out = append(out, "-e", e[:idx])
+		}
+	}
+	out = append(out, spec.Image, spec.Executable)
+	return append(out, spec.Args...)
+}
+
+// containerMounts returns the directories outside workingDir the agent needs
+// to write to: the pipeline state dir, its scratch dir (from env) and the
+// directory of its exit file. Each is listed once, and not at all if it's
+// inside workingDir or another of them.
+func containerMounts(workingDir, stateDir, exitFile string, env []string) []string {
+	candidates := []string{stateDir}
+	for _, e := range env {
+		if value, ok := strings.CutPrefix(e, scratch.EnvVar+"="); ok {
+			candidates = append(candidates, value)
+		}
+	}
+	if exitFile != "" {
+		candidates = append(candidates, filepath.Dir(exitFile))
+	}
+
+	var mounts []string
+	for _, dir := range candidates {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		covered := workingDir != "" && insideDir(workingDir, dir)
+		for _, m := range mounts {
+			covered = covered || insideDir(m, dir)
+		}
+		if !covered {
+			mounts = append(mounts, dir)
+		}
+	}
+	return mounts
+}
+
+// insideDir reports whether dir is root or a directory inside it.
+func insideDir(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeContainer force-removes the named container. Killing the runtime
+// client doesn't stop its container, so a cancelled, timed-out or failed run
+// removes it rather than leave the agent running.
+func removeContainer(runtime, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerRemoveTimeout)
+	defer cancel()
+	_ = exec.CommandContext(ctx, runtime, "rm", "-f", name).Run()
+}
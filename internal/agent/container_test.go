@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+)
+
+func TestContainerArgs(t *testing.T) {
+	got := containerArgs(containerSpec{
+		Name:       "swarm-agent-abc",
+		Image:      "golang:1.22",
+		WorkingDir: "/work/repo",
+		Mounts:     []string{"/tmp/state"},
+		User:       "1000:1000",
+		Executable: "claude",
+		Args:       []string{"-p", "hello"},
+		Env:        []string{"API_KEY=secret", "BROKEN"},
+	})
+	want := []string{
+		"run", "--rm", "-i", "--init",
+		"--name", "swarm-agent-abc",
+		"--user", "1000:1000",
+		"-v", "/work/repo:/work/repo", "-w", "/work/repo",
+		"-v", "/tmp/state:/tmp/state",
+		"-e", "API_KEY",
+		"golang:1.22", "claude", "-p", "hello",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("containerArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestContainerArgsNoWorkingDir(t *testing.T) {
+	got := containerArgs(containerSpec{Image: "node:20", Executable: "agent"})
+	want := []string{"run", "--rm", "-i", "--init", "node:20", "agent"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("containerArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestContainerArgsReadOnly(t *testing.T) {
+	got := containerArgs(containerSpec{Image: "golang:1.22", WorkingDir: "/work/repo", ReadOnly: true, Mounts: []string{"/tmp/state"}, Executable: "claude"})
+	want := []string{
+		"run", "--rm", "-i", "--init",
+		"-v", "/work/repo:/work/repo:ro", "-w", "/work/repo",
+		"-v", "/tmp/state:/tmp/state",
+		"golang:1.22", "claude",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("containerArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestContainerMounts(t *testing.T) {
+	got := containerMounts("/work/repo", "/tmp/outputs/run1", "/tmp/outputs/run1/coder/exit.json",
+		[]string{"FOO=bar", "SWARM_SCRATCH_DIR=/home/me/.swarm/scratch/abc"})
+	want := []string{"/tmp/outputs/run1", "/home/me/.swarm/scratch/abc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("containerMounts() = %v, want %v", got, want)
+	}
+
+	// Directories inside the working directory are mounted with it
+	got = containerMounts("/work/repo", "/work/repo/.swarm/state", "", nil)
+	if len(got) != 0 {
+		t.Errorf("containerMounts() = %v, want none inside the working directory", got)
+	}
+}
+
+func TestContainerRemovedOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the container runtime")
+	}
+
+	// A fake runtime that records its calls and fails the run
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	docker := filepath.Join(dir, "docker")
+	script := "#!/bin/sh\necho \"$1\" >> " + calls + "\n[ \"$1\" = run ] && exit 1\nexit 0\n"
+	if err := os.WriteFile(docker, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake runtime: %v", err)
+	}
+
+	runner := NewRunner(Config{
+		Prompt: "hello",
+		Dir:    dir,
+		Command: config.CommandConfig{
+			Executable:       "agent",
+			RawOutput:        true,
+			Image:            "agent:latest",
+			ContainerRuntime: docker,
+		},
+	})
+	var out bytes.Buffer
+	if err := runner.Run(&out); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("failed to read runtime calls: %v", err)
+	}
+	if got := strings.Fields(string(data)); strings.Join(got, " ") != "run rm" {
+		t.Errorf("runtime calls = %v, want run, rm", got)
+	}
+}
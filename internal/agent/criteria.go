@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/logparser"
+)
+
+// resultExcerptLen bounds how much of a result event's text is quoted in the
+// error for a run that failed its result criteria.
+const resultExcerptLen = 200
+
+// ResultCriteria marks a run failed based on the agent's final result event,
+// because agent CLIs often exit 0 even when the run errored internally.
+type ResultCriteria struct {
+	// FailOnSubtype lists result subtypes that fail the run. An entry also
+	// matches the subtypes it prefixes, so "error" matches "error_max_turns".
+	FailOnSubtype []string
+
+	// FailOnResultRegex fails the run when the result text matches
+	FailOnResultRegex *regexp.Regexp
+}
+
+// NewResultCriteria builds result criteria from a comma-separated list of
+// subtypes and a regular expression for the result text. It returns nil when
+// both are empty.
+func NewResultCriteria(subtypes, resultRegex string) (*ResultCriteria, error) {
+	c := &ResultCriteria{}
+	for _, s := range strings.Split(subtypes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			c.FailOnSubtype = append(c.FailOnSubtype, s)
+		}
+	}
+	if resultRegex != "" {
+		re, err := regexp.Compile(resultRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result regex %q: %w", resultRegex, err)
+		}
+		c.FailOnResultRegex = re
+	}
+	if len(c.FailOnSubtype) == 0 && c.FailOnResultRegex == nil {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// Check returns an error if the result event fails the criteria.
+func (c *ResultCriteria) Check(event *logparser.LogEvent) error {
+	if c == nil || event == nil {
+		return nil
+	}
+	for _, s := range c.FailOnSubtype {
+		if strings.HasPrefix(event.Subtype, s) {
+			return fmt.Errorf("agent reported a %s result: %s", event.Subtype, resultExcerpt(event.Result))
+		}
+	}
+	if c.FailOnResultRegex != nil && c.FailOnResultRegex.MatchString(event.Result) {
+		return fmt.Errorf("agent result matched %q: %s", c.FailOnResultRegex.String(), resultExcerpt(event.Result))
+	}
+	return nil
+}
+
+// resultExcerpt returns the first line of a result's text, shortened.
+func resultExcerpt(result string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(result), "\n")
+	if len(line) > resultExcerptLen {
+		line = line[:resultExcerptLen] + "..."
+	}
+	if line == "" {
+		return "(no result text)"
+	}
+	return line
+}
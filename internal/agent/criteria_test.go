@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewResultCriteria(t *testing.T) {
+	c, err := NewResultCriteria("", "")
+	if err != nil || c != nil {
+		t.Fatalf("expected nil criteria for empty input, got %+v, %v", c, err)
+	}
+
+	c, err = NewResultCriteria(" error , timeout,", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.FailOnSubtype) != 2 || c.FailOnSubtype[0] != "error" || c.FailOnSubtype[1] != "timeout" {
+		t.Errorf("FailOnSubtype = %q", c.FailOnSubtype)
+	}
+
+	if _, err := NewResultCriteria("", "("); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+// TestRunnerResultCriteria verifies that a run exiting 0 is failed by its
+// result event in both raw and parsed output modes.
+func TestRunnerResultCriteria(t *testing.T) {
+	tests := []struct {
+		name     string
+		subtypes string
+		regex    string
+		result   string
+		wantErr  string
+	}{
+		{"subtype prefix", "error", "", `{"type":"result","subtype":"error_max_turns","result":"ran out of turns"}`, "error_max_turns result: ran out of turns"},
+		{"result regex", "", "(?i)could not", `{"type":"result","subtype":"success","result":"I could not finish"}`, "I could not finish"},
+		{"success passes", "error", "(?i)could not", `{"type":"result","subtype":"success","result":"done"}`, ""},
+	}
+	for _, tt := range tests {
+		for _, raw := range []bool{true, false} {
+			criteria, err := NewResultCriteria(tt.subtypes, tt.regex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := NewRunner(Config{
+				Model:  "test",
+				Prompt: "test",
+				Command: CommandConfig{
+					Executable: "sh",
+					Args:       []string{"-c", `printf '%s\n' '` + tt.result + `'`},
+					RawOutput:  raw,
+				},
+				ResultCriteria: criteria,
+			})
+
+			var buf bytes.Buffer
+			err = runner.Run(&buf)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("%s (raw=%v): unexpected error %v", tt.name, raw, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s (raw=%v): error = %v, want it to contain %q", tt.name, raw, err, tt.wantErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultHealthInterval is how often a health check runs when no interval
+// is configured.
+const DefaultHealthInterval = 30 * time.Second
+
+// HealthCheck is a liveness probe run periodically while an agent is active,
+// e.g. `curl -fs localhost:3000/health` for an agent babysitting a server.
+type HealthCheck struct {
+	// Command is a shell command that exits 0 when healthy
+	Command string
+
+	// Interval is the time between checks (0 = DefaultHealthInterval). A
+	// check that runs longer than the interval fails.
+	Interval time.Duration
+
+	// Dir is the directory the command runs in (empty = this process's
+	// working directory)
+	Dir string
+
+	// OnUnhealthy, when set, is called each time the check starts failing
+	// after passing (or on its first failure), e.g. to send a notification
+	OnUnhealthy func(err error)
+}
+
+// Probe runs the check once, returning an error that includes the tail of
+// the command's output if it fails.
+func (h HealthCheck) Probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.interval())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Dir = h.Dir
+	// Don't wait on children of the shell still holding its output open
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", h.interval())
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if tail := lines[len(lines)-1]; tail != "" {
+		return fmt.Errorf("%v: %s", err, tail)
+	}
+	return err
+}
+
+// Watch runs the check every interval until ctx is done. report is called
+// with the result of every check (nil when healthy), and transitions between
+// healthy and unhealthy are written to out.
+func (h HealthCheck) Watch(ctx context.Context, out io.Writer, report func(err error)) {
+	ticker := time.NewTicker(h.interval())
+	defer ticker.Stop()
+
+	healthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := h.Probe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		switch {
+		case err != nil && healthy:
+			fmt.Fprintf(out, "\n[swarm] UNHEALTHY: health check `%s` failed: %v\n", h.Command, err)
+			if h.OnUnhealthy != nil {
+				h.OnUnhealthy(err)
+			}
+		case err == nil && !healthy:
+			fmt.Fprintf(out, "\n[swarm] Healthy again: health check `%s` passed\n", h.Command)
+		}
+		healthy = err == nil
+		if report != nil {
+			report(err)
+		}
+	}
+}
+
+// Start runs Watch in the background. The returned stop function ends it
+// and waits for any check in progress, so report is not called after stop
+// returns.
+func (h HealthCheck) Start(out io.Writer, report func(err error)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Watch(ctx, out, report)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (h HealthCheck) interval() time.Duration {
+	if h.Interval > 0 {
+		return h.Interval
+	}
+	return DefaultHealthInterval
+}
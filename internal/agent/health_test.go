@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckProbe(t *testing.T) {
+	ctx := context.Background()
+	if err := (HealthCheck{Command: "true"}).Probe(ctx); err != nil {
+		t.Errorf("expected a passing check, got %v", err)
+	}
+
+	err := (HealthCheck{Command: "echo starting; echo connection refused >&2; exit 7"}).Probe(ctx)
+	if err == nil || !strings.Contains(err.Error(), "exit status 7: connection refused") {
+		t.Errorf("expected the exit status and last output line, got %v", err)
+	}
+
+	err = (HealthCheck{Command: "sleep 5", Interval: 50 * time.Millisecond}).Probe(ctx)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestHealthCheckProbe_Dir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ready"), nil, 0644)
+	if err := (HealthCheck{Command: "test -f ready", Dir: dir}).Probe(context.Background()); err != nil {
+		t.Errorf("expected the check to run in %s, got %v", dir, err)
+	}
+}
+
+func TestHealthCheckWatch_Transitions(t *testing.T) {
+	// Fails while the marker file exists
+	marker := filepath.Join(t.TempDir(), "down")
+	check := HealthCheck{Command: "test ! -f " + marker, Interval: 20 * time.Millisecond}
+
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	var results []error
+	var unhealthy int
+	check.OnUnhealthy = func(err error) {
+		mu.Lock()
+		unhealthy++
+		mu.Unlock()
+	}
+	out := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.Write(p)
+	})
+	waitFor := func(want int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			n := len(results)
+			mu.Unlock()
+			if n >= want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %d checks", want)
+	}
+
+	stop := check.Start(out, func(err error) {
+		mu.Lock()
+		results = append(results, err)
+		mu.Unlock()
+	})
+	waitFor(1)
+	os.WriteFile(marker, nil, 0644)
+	mu.Lock()
+	n := len(results)
+	mu.Unlock()
+	waitFor(n + 2)
+	os.Remove(marker)
+	mu.Lock()
+	n = len(results)
+	mu.Unlock()
+	waitFor(n + 2)
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if results[0] != nil {
+		t.Errorf("first check should pass, got %v", results[0])
+	}
+	if got := strings.Count(buf.String(), "[swarm] UNHEALTHY"); got != 1 || unhealthy != 1 {
+		t.Errorf("expected one unhealthy transition, got %d (OnUnhealthy called %d times):\n%s", got, unhealthy, buf.String())
+	}
+	if got := strings.Count(buf.String(), "[swarm] Healthy again"); got != 1 {
+		t.Errorf("expected one recovery, got %d:\n%s", got, buf.String())
+	}
+	if results[len(results)-1] != nil {
+		t.Errorf("last check should pass, got %v", results[len(results)-1])
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
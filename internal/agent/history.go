@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// maxSummaryLength caps the summary kept in an iteration's history record.
+const maxSummaryLength = 200
+
+// Summary returns a one-line summary of the most recent run: the summary of
+// the agent's exit report, or else the first line of its result.
+func (r *Runner) Summary() string {
+	summary := ""
+	if r.exitReport != nil {
+		summary = r.exitReport.Summary
+	}
+	if summary == "" {
+		r.statsMu.Lock()
+		if r.lastResult != nil {
+			summary = r.lastResult.Result
+		}
+		r.statsMu.Unlock()
+	}
+	summary = firstLine(strings.TrimSpace(summary))
+	if runes := []rune(summary); len(runes) > maxSummaryLength {
+		summary = string(runes[:maxSummaryLength-3]) + "..."
+	}
+	return summary
+}
+
+// IterationRecord returns the history record of an iteration run by r that
+// started at startedAt and ended with err. Its cost is what the agent CLI
+// reported, or else the tokens priced with cfg.
+func (r *Runner) IterationRecord(iteration int, startedAt time.Time, err error, cfg *config.Config) state.IterationRecord {
+	stats := r.UsageStats()
+	model := stats.Model
+	if model == "" {
+		model = r.config.Model
+	}
+	record := state.IterationRecord{
+		Iteration:    iteration,
+		StartedAt:    startedAt,
+		FinishedAt:   time.Now(),
+		Status:       "succeeded",
+		Model:        model,
+		InputTokens:  stats.InputTokens,
+		OutputTokens: stats.OutputTokens,
+		CostUSD:      cfg.UsageCost(model, stats.InputTokens, stats.OutputTokens, stats.TotalCostUSD),
+		Summary:      r.Summary(),
+	}
+	if err != nil {
+		record.Status = "failed"
+		record.ErrorClass = r.ClassifyFailure(err)
+		record.Error = err.Error()
+	}
+	return record
+}
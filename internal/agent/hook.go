@@ -1,33 +1,184 @@
 package agent
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"time"
 
+	"github.com/mj1618/swarm-cli/internal/config"
 	"github.com/mj1618/swarm-cli/internal/state"
 )
 
-// ExecuteOnCompleteHook runs the on-complete command for an agent.
-// The command is executed in a shell with agent context as environment variables.
-// Returns nil if no hook is configured, or an error if the hook fails.
-func ExecuteOnCompleteHook(agent *state.AgentState) error {
-	if agent.OnComplete == "" {
+// Hook events, passed to hooks as SWARM_HOOK_EVENT and the payload's event.
+const (
+	HookComplete = "complete" // the agent terminated, however it ended
+	HookFailure  = "failure"  // the agent ended with a failed iteration, a crash, or over budget
+	HookTimeout  = "timeout"  // the agent or its final iteration timed out
+)
+
+// HookPayload is the JSON document hooks receive on stdin.
+type HookPayload struct {
+	Event           string            `json:"event"`
+	DurationSeconds int64             `json:"duration_seconds"`
+	Iterations      HookIterations    `json:"iterations"`
+	Usage           HookUsage         `json:"usage"`
+	Agent           *state.AgentState `json:"agent"`
+}
+
+// HookIterations summarizes the agent's iterations.
+type HookIterations struct {
+	Total         int     `json:"total"` // 0 = unlimited
+	Completed     int     `json:"completed"`
+	Successful    int     `json:"successful"`
+	Failed        int     `json:"failed"`
+	MedianSeconds float64 `json:"median_seconds,omitempty"`
+}
+
+// HookUsage summarizes the agent's tokens and cost.
+type HookUsage struct {
+	InputTokens  int64                        `json:"input_tokens"`
+	OutputTokens int64                        `json:"output_tokens"`
+	CostUSD      float64                      `json:"cost_usd"`
+	ByModel      map[string]*state.ModelUsage `json:"by_model,omitempty"`
+}
+
+// NewHookPayload builds the payload for a hook run on event.
+func NewHookPayload(event string, agent *state.AgentState) HookPayload {
+	end := time.Now()
+	if agent.TerminatedAt != nil {
+		end = *agent.TerminatedAt
+	}
+	return HookPayload{
+		Event:           event,
+		DurationSeconds: int64(end.Sub(agent.StartedAt).Seconds()),
+		Iterations: HookIterations{
+			Total:         agent.Iterations,
+			Completed:     agent.CurrentIter,
+			Successful:    agent.SuccessfulIters,
+			Failed:        agent.FailedIters,
+			MedianSeconds: agent.MedianIterationDuration().Seconds(),
+		},
+		Usage: HookUsage{
+			InputTokens:  agent.InputTokens,
+			OutputTokens: agent.OutputTokens,
+			CostUSD:      agent.TotalCost,
+			ByModel:      agent.ModelUsage,
+		},
+		Agent: agent,
+	}
+}
+
+// HookEvent returns the event besides HookComplete that a terminated agent's
+// hooks fire for: HookTimeout, HookFailure, or "" if it completed or was
+// stopped by the user or for its age.
+func HookEvent(agent *state.AgentState) string {
+	if agent.TimeoutReason == "total" || agent.ExitReason == FailureTimeout {
+		return HookTimeout
+	}
+	switch agent.ExitReason {
+	case "", "completed", "killed", "signal", state.ExitReasonMaxAge:
+		return ""
+	}
+	return HookFailure
+}
+
+// HookOptions controls how ExecuteHooks runs hooks.
+type HookOptions struct {
+	// Retries is how many more times a failing hook is run (hook_retries)
+	Retries int
+
+	// RetryDelay is the wait before a hook's first retry, doubled for each
+	// further retry (hook_retry_delay)
+	RetryDelay time.Duration
+
+	// Output receives the hooks' stdout and stderr and swarm's messages about
+	// them; for a detached agent this is its log. Nil uses os.Stdout.
+	Output io.Writer
+}
+
+// NewHookOptions returns the HookOptions set by cfg, writing to out.
+func NewHookOptions(cfg *config.Config, out io.Writer) HookOptions {
+	opts := HookOptions{RetryDelay: cfg.HookRetryDelayDuration(), Output: out}
+	if cfg != nil {
+		opts.Retries = cfg.HookRetries
+	}
+	return opts
+}
+
+// ExecuteHooks runs a terminated agent's hooks: on-complete, then on-failure
+// or on-timeout if the agent failed or timed out. Each gets a HookPayload on
+// stdin and the agent's context as environment variables, and is retried
+// with backoff per opts if it fails. The outcome of each hook is appended to
+// agent.HookRuns. Returns nil if no hook is configured, or the errors of the
+// hooks that failed on every attempt.
+func ExecuteHooks(agent *state.AgentState, opts HookOptions) error {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	var errs []error
+	if err := retryHook(agent.OnComplete, HookComplete, agent, opts); err != nil {
+		errs = append(errs, fmt.Errorf("on-complete hook: %w", err))
+	}
+	switch event := HookEvent(agent); event {
+	case HookFailure:
+		if err := retryHook(agent.OnFailure, event, agent, opts); err != nil {
+			errs = append(errs, fmt.Errorf("on-failure hook: %w", err))
+		}
+	case HookTimeout:
+		if err := retryHook(agent.OnTimeout, event, agent, opts); err != nil {
+			errs = append(errs, fmt.Errorf("on-timeout hook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retryHook runs command for event until it succeeds or opts.Retries retries
+// have failed, and records the outcome in agent.HookRuns.
+func retryHook(command, event string, agent *state.AgentState, opts HookOptions) error {
+	if command == "" {
 		return nil
 	}
 
-	// Calculate duration
-	var duration int64
-	if agent.TerminatedAt != nil {
-		duration = int64(agent.TerminatedAt.Sub(agent.StartedAt).Seconds())
-	} else {
-		duration = int64(time.Since(agent.StartedAt).Seconds())
+	maxAttempts := opts.Retries + 1
+	delay := opts.RetryDelay
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		fmt.Fprintf(opts.Output, "[swarm] Running on-%s hook: %s\n", event, command)
+		if err = runHook(command, event, agent, opts.Output); err == nil || attempt == maxAttempts {
+			break
+		}
+		fmt.Fprintf(opts.Output, "[swarm] on-%s hook failed: %v, retrying in %s (attempt %d/%d)\n", event, err, delay, attempt+1, maxAttempts)
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	run := state.HookRun{Event: event, Command: command, Attempts: attempt, FinishedAt: time.Now()}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	agent.HookRuns = append(agent.HookRuns, run)
+	return err
+}
+
+// runHook runs command in a shell for event, with the payload on stdin and
+// agent context as environment variables, writing its output to out.
+func runHook(command, event string, agent *state.AgentState, out io.Writer) error {
+	payload := NewHookPayload(event, agent)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook payload: %w", err)
 	}
 
 	// Set up environment with agent context
 	env := os.Environ()
 	env = append(env,
+		"SWARM_HOOK_EVENT="+event,
 		"SWARM_AGENT_ID="+agent.ID,
 		"SWARM_AGENT_NAME="+agent.Name,
 		"SWARM_AGENT_STATUS="+agent.Status,
@@ -36,17 +187,20 @@ func ExecuteOnCompleteHook(agent *state.AgentState) error {
 		"SWARM_AGENT_PROMPT="+agent.Prompt,
 		"SWARM_AGENT_MODEL="+agent.Model,
 		"SWARM_AGENT_LOG_FILE="+agent.LogFile,
-		fmt.Sprintf("SWARM_AGENT_DURATION=%d", duration),
+		fmt.Sprintf("SWARM_AGENT_DURATION=%d", payload.DurationSeconds),
 		"SWARM_AGENT_EXIT_REASON="+agent.ExitReason,
+		"SWARM_AGENT_ERROR_CLASS="+agent.LastErrorClass,
 		fmt.Sprintf("SWARM_AGENT_SUCCESSFUL_ITERS=%d", agent.SuccessfulIters),
 		fmt.Sprintf("SWARM_AGENT_FAILED_ITERS=%d", agent.FailedIters),
+		fmt.Sprintf("SWARM_AGENT_COST_USD=%.4f", agent.TotalCost),
 	)
 
 	// Execute command in shell
-	cmd := exec.Command("sh", "-c", agent.OnComplete)
+	cmd := exec.Command("sh", "-c", command)
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = out
+	cmd.Stderr = out
 
 	// Run in the agent's working directory if available
 	if agent.WorkingDir != "" {
@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestHookEvent(t *testing.T) {
+	for _, tt := range []struct {
+		exitReason, timeoutReason, want string
+	}{
+		{"completed", "", ""},
+		{"killed", "", ""},
+		{"signal", "", ""},
+		{state.ExitReasonMaxAge, "", ""},
+		{"crashed", "", HookFailure},
+		{FailureRateLimited, "", HookFailure},
+		{FailureBudget, "", HookFailure},
+		{FailureTimeout, "", HookTimeout},
+		{"completed", "total", HookTimeout},
+	} {
+		a := &state.AgentState{ExitReason: tt.exitReason, TimeoutReason: tt.timeoutReason}
+		if got := HookEvent(a); got != tt.want {
+			t.Errorf("HookEvent(exit %q, timeout %q) = %q, want %q", tt.exitReason, tt.timeoutReason, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteHooksPayload(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Now().Add(-90 * time.Second)
+	terminated := time.Now()
+	a := &state.AgentState{
+		ID:              "abc123",
+		Name:            "coder",
+		StartedAt:       started,
+		TerminatedAt:    &terminated,
+		Iterations:      5,
+		CurrentIter:     3,
+		SuccessfulIters: 2,
+		FailedIters:     1,
+		ExitReason:      "crash",
+		InputTokens:     1000,
+		OutputTokens:    200,
+		TotalCost:       1.25,
+		WorkingDir:      dir,
+		OnComplete:      "cat > complete.json",
+		OnFailure:       `cat > "$SWARM_HOOK_EVENT.json"`,
+		OnTimeout:       "touch timeout.json",
+	}
+	if err := ExecuteHooks(a, HookOptions{Output: io.Discard}); err != nil {
+		t.Fatalf("ExecuteHooks failed: %v", err)
+	}
+
+	for _, event := range []string{HookComplete, HookFailure} {
+		data, err := os.ReadFile(filepath.Join(dir, event+".json"))
+		if err != nil {
+			t.Fatalf("%s hook didn't run: %v", event, err)
+		}
+		var payload HookPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Fatalf("invalid %s payload: %v", event, err)
+		}
+		if payload.Event != event || payload.Agent == nil || payload.Agent.ID != "abc123" {
+			t.Errorf("%s payload = %+v", event, payload)
+		}
+		if payload.Iterations.Completed != 3 || payload.Iterations.Failed != 1 || payload.Usage.CostUSD != 1.25 || payload.DurationSeconds != 90 {
+			t.Errorf("%s payload stats = %+v %+v %d", event, payload.Iterations, payload.Usage, payload.DurationSeconds)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "timeout.json")); !os.IsNotExist(err) {
+		t.Error("on-timeout hook ran for a failed agent")
+	}
+
+	if len(a.HookRuns) != 2 || a.HookRuns[0].Event != HookComplete || a.HookRuns[1].Event != HookFailure || len(a.FailedHooks()) != 0 {
+		t.Errorf("HookRuns = %+v", a.HookRuns)
+	}
+
+	a.OnComplete = "exit 3"
+	if err := ExecuteHooks(a, HookOptions{Output: io.Discard}); err == nil {
+		t.Error("expected an error from a failing hook")
+	}
+}
+
+func TestExecuteHooksRetries(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	// Fails on the first two attempts and succeeds on the third
+	a := &state.AgentState{
+		ID:         "abc123",
+		ExitReason: "completed",
+		WorkingDir: dir,
+		OnComplete: `echo attempt >> attempts; echo "hook says hi"; echo oops >&2; [ $(wc -l < attempts) -ge 3 ]`,
+	}
+	if err := ExecuteHooks(a, HookOptions{Retries: 3, RetryDelay: time.Millisecond, Output: &out}); err != nil {
+		t.Fatalf("ExecuteHooks failed: %v", err)
+	}
+	if len(a.HookRuns) != 1 || a.HookRuns[0].Attempts != 3 || !a.HookRuns[0].Succeeded() {
+		t.Errorf("HookRuns = %+v, want one successful run after 3 attempts", a.HookRuns)
+	}
+	for _, want := range []string{"hook says hi", "oops", "retrying in 1ms (attempt 2/4)", "retrying in 2ms (attempt 3/4)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	a.HookRuns = nil
+	a.OnComplete = "exit 3"
+	if err := ExecuteHooks(a, HookOptions{Retries: 1, RetryDelay: time.Millisecond, Output: io.Discard}); err == nil {
+		t.Fatal("expected an error from a hook failing on every attempt")
+	}
+	failed := a.FailedHooks()
+	if len(failed) != 1 || failed[0].Attempts != 2 || failed[0].Error != "exit status 3" {
+		t.Errorf("FailedHooks() = %+v", failed)
+	}
+}
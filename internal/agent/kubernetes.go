@@ -0,0 +1,309 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// Pod phases reported through the PodStatusCallback.
+const (
+	PodPending   = "Pending"
+	PodRunning   = "Running"
+	PodSucceeded = "Succeeded"
+	PodFailed    = "Failed"
+)
+
+// podStatusPollInterval is how often the pod phase is polled while a job runs.
+const podStatusPollInterval = 5 * time.Second
+
+// podRunningTimeout bounds how long `kubectl logs -f` waits for the pod to start.
+const podRunningTimeout = "10m"
+
+// jobTTLSeconds is how long finished Jobs are kept before Kubernetes garbage-collects them.
+const jobTTLSeconds = 3600
+
+// workspaceVolume is the name of the volume the configured PVC is mounted from.
+const workspaceVolume = "workspace"
+
+// PodStatusCallback is called when the pod phase of a Kubernetes job changes.
+type PodStatusCallback func(podName, phase string)
+
+// kubernetesJob tracks a Job created for a single agent iteration.
+type kubernetesJob struct {
+	cfg  *config.KubernetesConfig
+	name string
+}
+
+// newKubernetesJobName returns a unique, DNS-compatible Job name.
+func newKubernetesJobName() string {
+	return "swarm-agent-" + state.GenerateID()
+}
+
+// namespaceArgs returns the `-n <namespace>` args, or nil to use the context default.
+func (j *kubernetesJob) namespaceArgs() []string {
+	if j.cfg.Namespace == "" {
+		return nil
+	}
+	return []string{"-n", j.cfg.Namespace}
+}
+
+// kubectl builds a kubectl command scoped to the job's namespace.
+func (j *kubernetesJob) kubectl(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, j.cfg.KubectlPath(), append(args, j.namespaceArgs()...)...)
+}
+
+// buildKubernetesJobManifest renders the Job manifest (as JSON, which kubectl
+// accepts) that runs executable with args inside the configured image.
+// env holds KEY=VALUE pairs that are passed to the container. They may include
+// secrets from .env files, so they aren't written into the Job spec, where
+// anyone who can read Jobs would see them: they come from the Secret of the
+// same name (see buildKubernetesSecretManifest).
+func buildKubernetesJobManifest(k *config.KubernetesConfig, name, executable string, args, env []string) ([]byte, error) {
+	labels := kubernetesLabels(name)
+
+	container := map[string]interface{}{
+		"name":    "agent",
+		"image":   k.Image,
+		"command": []string{executable},
+		"args":    args,
+	}
+
+	var envFrom []map[string]interface{}
+	if len(kubernetesEnvData(env)) > 0 {
+		envFrom = append(envFrom, map[string]interface{}{
+			"secretRef": map[string]string{"name": name},
+		})
+	}
+	for _, secret := range k.Secrets {
+		envFrom = append(envFrom, map[string]interface{}{
+			"secretRef": map[string]string{"name": secret},
+		})
+	}
+	if len(envFrom) > 0 {
+		container["envFrom"] = envFrom
+	}
+
+	resources := map[string]string{}
+	if k.CPU != "" {
+		resources["cpu"] = k.CPU
+	}
+	if k.Memory != "" {
+		resources["memory"] = k.Memory
+	}
+	if len(resources) > 0 {
+		container["resources"] = map[string]interface{}{
+			"requests": resources,
+			"limits":   resources,
+		}
+	}
+
+	// Mount the repository from the PVC; otherwise the image must contain it
+	if mountPath := k.EffectiveMountPath(); mountPath != "" {
+		container["volumeMounts"] = []map[string]string{{"name": workspaceVolume, "mountPath": mountPath}}
+	}
+	if workingDir := k.EffectiveWorkingDir(); workingDir != "" {
+		container["workingDir"] = workingDir
+	}
+
+	podSpec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"containers":    []interface{}{container},
+	}
+	if k.PVC != "" {
+		podSpec["volumes"] = []map[string]interface{}{{
+			"name":                  workspaceVolume,
+			"persistentVolumeClaim": map[string]string{"claimName": k.PVC},
+		}}
+	}
+	if k.ServiceAccount != "" {
+		podSpec["serviceAccountName"] = k.ServiceAccount
+	}
+
+	metadata := map[string]interface{}{
+		"name":   name,
+		"labels": labels,
+	}
+	if k.Namespace != "" {
+		metadata["namespace"] = k.Namespace
+	}
+
+	manifest := map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"backoffLimit":            0,
+			"ttlSecondsAfterFinished": jobTTLSeconds,
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": labels},
+				"spec":     podSpec,
+			},
+		},
+	}
+
+	return json.MarshalIndent(manifest, "", "  ")
+}
+
+// buildKubernetesSecretManifest renders the Secret holding the Job's
+// environment, or nil if env has no variables to pass.
+func buildKubernetesSecretManifest(k *config.KubernetesConfig, name string, env []string) ([]byte, error) {
+	data := kubernetesEnvData(env)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	metadata := map[string]interface{}{
+		"name":   name,
+		"labels": kubernetesLabels(name),
+	}
+	if k.Namespace != "" {
+		metadata["namespace"] = k.Namespace
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   metadata,
+		"type":       "Opaque",
+		"stringData": data,
+	}, "", "  ")
+}
+
+// kubernetesLabels returns the labels of the objects created for a job.
+func kubernetesLabels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/managed-by": "swarm-cli",
+		"swarm-cli/job":                name,
+	}
+}
+
+// kubernetesEnvData turns KEY=VALUE pairs into a map, skipping malformed
+// entries. Later pairs win, as they would in a process environment.
+func kubernetesEnvData(env []string) map[string]string {
+	data := make(map[string]string)
+	for _, e := range env {
+		idx := strings.Index(e, "=")
+		if idx <= 0 {
+			continue
+		}
+		data[e[:idx]] = e[idx+1:]
+	}
+	return data
+}
+
+// create submits the Job, and the Secret holding its environment, in one
+// `kubectl apply`.
+func (j *kubernetesJob) create(ctx context.Context, executable string, args, env []string) error {
+	job, err := buildKubernetesJobManifest(j.cfg, j.name, executable, args, env)
+	if err != nil {
+		return fmt.Errorf("failed to build job manifest: %w", err)
+	}
+	items := []json.RawMessage{job}
+	secret, err := buildKubernetesSecretManifest(j.cfg, j.name, env)
+	if err != nil {
+		return fmt.Errorf("failed to build job secret: %w", err)
+	}
+	if secret != nil {
+		items = []json.RawMessage{secret, job}
+	}
+	manifest, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build job manifest: %w", err)
+	}
+
+	cmd := j.kubectl(ctx, "apply", "-f", "-")
+	cmd.Stdin = bytes.NewReader(manifest)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create kubernetes job: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// logsCommand returns the command that streams the job's pod output.
+// Its stdout is fed into the same parsing pipeline as a local agent process.
+func (j *kubernetesJob) logsCommand(ctx context.Context) *exec.Cmd {
+	return j.kubectl(ctx, "logs", "-f", "job/"+j.name, "--pod-running-timeout="+podRunningTimeout)
+}
+
+// podStatus returns the name and phase of the job's pod.
+func (j *kubernetesJob) podStatus(ctx context.Context) (string, string, error) {
+	out, err := j.kubectl(ctx, "get", "pods", "-l", "job-name="+j.name,
+		"-o", `jsonpath={.items[0].metadata.name} {.items[0].status.phase}`).Output()
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return "", PodPending, nil
+	}
+	return fields[0], fields[1], nil
+}
+
+// watchPodStatus polls the pod phase until ctx is done, invoking cb on every change.
+func (j *kubernetesJob) watchPodStatus(ctx context.Context, cb PodStatusCallback) {
+	var lastPhase string
+	ticker := time.NewTicker(podStatusPollInterval)
+	defer ticker.Stop()
+	for {
+		if podName, phase, err := j.podStatus(ctx); err == nil && phase != lastPhase {
+			lastPhase = phase
+			cb(podName, phase)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// result reports whether the job succeeded once its logs have finished streaming.
+// The job controller may lag behind the pod, so it polls briefly until the job
+// records either a success or a failure.
+func (j *kubernetesJob) result(ctx context.Context) error {
+	for attempt := 0; attempt < 15; attempt++ {
+		out, err := j.kubectl(ctx, "get", "job", j.name,
+			"-o", `jsonpath={.status.succeeded}/{.status.failed}`).Output()
+		if err != nil {
+			return fmt.Errorf("failed to read kubernetes job status: %w", err)
+		}
+		succeeded, failed, _ := strings.Cut(strings.TrimSpace(string(out)), "/")
+		if succeeded != "" && succeeded != "0" {
+			return nil
+		}
+		if failed != "" && failed != "0" {
+			return fmt.Errorf("kubernetes job %s failed", j.name)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	return fmt.Errorf("kubernetes job %s did not report completion", j.name)
+}
+
+// delete removes the Job, its pods and its Secret without waiting for
+// completion. It's called whenever an iteration doesn't succeed, so a killed,
+// timed-out or failed run doesn't leave the Job running in the cluster.
+func (j *kubernetesJob) delete() {
+	_ = j.kubectl(context.Background(), "delete", "job/"+j.name, "secret/"+j.name, "--wait=false", "--ignore-not-found").Run()
+}
+
+// deleteSecret removes the Job's Secret once its pod has finished with it. A
+// succeeded Job is kept for jobTTLSeconds so its logs can still be read.
+func (j *kubernetesJob) deleteSecret() {
+	_ = j.kubectl(context.Background(), "delete", "secret", j.name, "--wait=false", "--ignore-not-found").Run()
+}
@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+)
+
+func TestBuildKubernetesJobManifest(t *testing.T) {
+	k := &config.KubernetesConfig{
+		Enabled:        true,
+		Image:          "agent:latest",
+		Namespace:      "agents",
+		Secrets:        []string{"api-keys"},
+		CPU:            "2",
+		Memory:         "4Gi",
+		ServiceAccount: "swarm",
+	}
+
+	data, err := buildKubernetesJobManifest(k, "swarm-agent-abc", "claude", []string{"-p", "hello"}, []string{"FOO=bar=baz", "INVALID"})
+	if err != nil {
+		t.Fatalf("buildKubernetesJobManifest failed: %v", err)
+	}
+
+	var manifest struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			BackoffLimit int `json:"backoffLimit"`
+			Template     struct {
+				Spec struct {
+					RestartPolicy      string `json:"restartPolicy"`
+					ServiceAccountName string `json:"serviceAccountName"`
+					Containers         []struct {
+						Image   string   `json:"image"`
+						Command []string `json:"command"`
+						Args    []string `json:"args"`
+						Env     []struct {
+							Name  string `json:"name"`
+							Value string `json:"value"`
+						} `json:"env"`
+						EnvFrom []struct {
+							SecretRef struct {
+								Name string `json:"name"`
+							} `json:"secretRef"`
+						} `json:"envFrom"`
+						Resources struct {
+							Limits map[string]string `json:"limits"`
+						} `json:"resources"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+
+	if manifest.Kind != "Job" {
+		t.Errorf("expected kind Job, got %q", manifest.Kind)
+	}
+	if manifest.Metadata.Name != "swarm-agent-abc" || manifest.Metadata.Namespace != "agents" {
+		t.Errorf("unexpected metadata: %+v", manifest.Metadata)
+	}
+	if manifest.Spec.BackoffLimit != 0 {
+		t.Errorf("expected backoffLimit 0, got %d", manifest.Spec.BackoffLimit)
+	}
+
+	podSpec := manifest.Spec.Template.Spec
+	if podSpec.RestartPolicy != "Never" {
+		t.Errorf("expected restartPolicy Never, got %q", podSpec.RestartPolicy)
+	}
+	if podSpec.ServiceAccountName != "swarm" {
+		t.Errorf("expected serviceAccountName swarm, got %q", podSpec.ServiceAccountName)
+	}
+	if len(podSpec.Containers) != 1 {
+		t.Fatalf("expected 1 container, got %d", len(podSpec.Containers))
+	}
+
+	c := podSpec.Containers[0]
+	if c.Image != "agent:latest" || c.Command[0] != "claude" || len(c.Args) != 2 {
+		t.Errorf("unexpected container: %+v", c)
+	}
+	// The environment comes from the job's own Secret, not plaintext values
+	if len(c.Env) != 0 {
+		t.Errorf("env values written into the job spec: %+v", c.Env)
+	}
+	if len(c.EnvFrom) != 2 || c.EnvFrom[0].SecretRef.Name != "swarm-agent-abc" || c.EnvFrom[1].SecretRef.Name != "api-keys" {
+		t.Errorf("unexpected envFrom: %+v", c.EnvFrom)
+	}
+	if c.Resources.Limits["cpu"] != "2" || c.Resources.Limits["memory"] != "4Gi" {
+		t.Errorf("unexpected resource limits: %+v", c.Resources.Limits)
+	}
+}
+
+func TestBuildKubernetesJobManifestMinimal(t *testing.T) {
+	k := &config.KubernetesConfig{Enabled: true, Image: "agent:latest"}
+
+	data, err := buildKubernetesJobManifest(k, "swarm-agent-xyz", "agent", nil, nil)
+	if err != nil {
+		t.Fatalf("buildKubernetesJobManifest failed: %v", err)
+	}
+
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+
+	metadata := manifest["metadata"].(map[string]interface{})
+	if _, ok := metadata["namespace"]; ok {
+		t.Error("namespace should be omitted when not configured")
+	}
+}
+
+func TestBuildKubernetesJobManifestWorkspace(t *testing.T) {
+	k := &config.KubernetesConfig{Enabled: true, Image: "agent:latest", PVC: "repo-claim"}
+
+	data, err := buildKubernetesJobManifest(k, "swarm-agent-abc", "agent", nil, nil)
+	if err != nil {
+		t.Fatalf("buildKubernetesJobManifest failed: %v", err)
+	}
+
+	var manifest struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					Volumes []struct {
+						Name                  string `json:"name"`
+						PersistentVolumeClaim struct {
+							ClaimName string `json:"claimName"`
+						} `json:"persistentVolumeClaim"`
+					} `json:"volumes"`
+					Containers []struct {
+						WorkingDir   string `json:"workingDir"`
+						VolumeMounts []struct {
+							Name      string `json:"name"`
+							MountPath string `json:"mountPath"`
+						} `json:"volumeMounts"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+
+	podSpec := manifest.Spec.Template.Spec
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].PersistentVolumeClaim.ClaimName != "repo-claim" {
+		t.Errorf("unexpected volumes: %+v", podSpec.Volumes)
+	}
+	c := podSpec.Containers[0]
+	if len(c.VolumeMounts) != 1 || c.VolumeMounts[0].Name != podSpec.Volumes[0].Name || c.VolumeMounts[0].MountPath != "/workspace" {
+		t.Errorf("unexpected volume mounts: %+v", c.VolumeMounts)
+	}
+	if c.WorkingDir != "/workspace" {
+		t.Errorf("expected workingDir /workspace, got %q", c.WorkingDir)
+	}
+}
+
+func TestBuildKubernetesSecretManifest(t *testing.T) {
+	k := &config.KubernetesConfig{Namespace: "agents"}
+
+	data, err := buildKubernetesSecretManifest(k, "swarm-agent-abc", []string{"FOO=bar=baz", "INVALID", "TOKEN=old", "TOKEN=new"})
+	if err != nil {
+		t.Fatalf("buildKubernetesSecretManifest failed: %v", err)
+	}
+	var secret struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		StringData map[string]string `json:"stringData"`
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if secret.Kind != "Secret" || secret.Metadata.Name != "swarm-agent-abc" || secret.Metadata.Namespace != "agents" {
+		t.Errorf("unexpected secret: %+v", secret)
+	}
+	want := map[string]string{"FOO": "bar=baz", "TOKEN": "new"}
+	if len(secret.StringData) != len(want) || secret.StringData["FOO"] != want["FOO"] || secret.StringData["TOKEN"] != want["TOKEN"] {
+		t.Errorf("stringData = %v, want %v", secret.StringData, want)
+	}
+
+	if data, _ := buildKubernetesSecretManifest(k, "swarm-agent-abc", nil); data != nil {
+		t.Errorf("expected no secret without env, got %s", data)
+	}
+}
+
+func TestKubernetesJobDeletedOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as kubectl")
+	}
+
+	// A fake kubectl that records its calls and fails to stream logs
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	kubectl := filepath.Join(dir, "kubectl")
+	script := "#!/bin/sh\necho \"$1\" >> " + calls + "\ncase \"$1\" in\n  apply) cat >/dev/null ;;\n  logs) exit 1 ;;\nesac\n"
+	if err := os.WriteFile(kubectl, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+
+	runner := NewRunner(Config{
+		Prompt: "hello",
+		Command: config.CommandConfig{
+			Executable: "agent",
+			RawOutput:  true,
+			Kubernetes: &config.KubernetesConfig{Enabled: true, Image: "agent:latest", Kubectl: kubectl},
+		},
+	})
+	var out bytes.Buffer
+	if err := runner.Run(&out); err == nil {
+		t.Fatal("expected the run to fail when the logs can't be streamed")
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("failed to read kubectl calls: %v", err)
+	}
+	if got := strings.Fields(string(data)); strings.Join(got, " ") != "apply logs delete" {
+		t.Errorf("kubectl calls = %v, want apply, logs, delete", got)
+	}
+}
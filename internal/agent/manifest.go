@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/version"
+)
+
+// manifestProbeTimeout bounds each command NewManifest runs.
+const manifestProbeTimeout = 10 * time.Second
+
+// NewManifest records the versions an agent starts with: swarm's, the agent
+// CLI's (from `<executable> --version`), and the git commit checked out in
+// dir. Anything that can't be determined is left empty; the agent CLI isn't
+// probed when it runs in a container image.
+func NewManifest(command config.CommandConfig, dir string) *state.Manifest {
+	m := &state.Manifest{
+		SwarmVersion: version.Version,
+		AgentCLI:     command.Executable,
+	}
+	if version.Commit != "unknown" {
+		m.SwarmCommit = version.Commit
+	}
+	if command.Executable != "" && command.Image == "" {
+		m.AgentVersion = CLIVersion(command.Executable)
+	}
+	if m.GitCommit = probe(dir, "git", "rev-parse", "HEAD"); m.GitCommit != "" {
+		if branch := probe(dir, "git", "rev-parse", "--abbrev-ref", "HEAD"); branch != "HEAD" {
+			m.GitBranch = branch
+		}
+		m.GitDirty = probe(dir, "git", "status", "--porcelain") != ""
+	}
+	return m
+}
+
+// CLIVersion returns the first line of an agent CLI's --version output, or ""
+// if it can't be run.
+func CLIVersion(executable string) string {
+	return firstLine(probe("", executable, "--version"))
+}
+
+// probe runs name with args in dir and returns its trimmed stdout, or "" if
+// it fails.
+func probe(dir, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), manifestProbeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestNewManifest(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	cli := filepath.Join(t.TempDir(), "fake-agent")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho \"1.2.3 ($1)\"\necho more details\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManifest(config.CommandConfig{Executable: cli}, dir)
+	if m.AgentCLI != cli || m.AgentVersion != "1.2.3 (--version)" {
+		t.Errorf("agent CLI = %q %q, want the first line of its --version output", m.AgentCLI, m.AgentVersion)
+	}
+	if len(m.GitCommit) != 40 || m.GitBranch != "main" || m.GitDirty {
+		t.Errorf("git = %q %q dirty=%v, want a clean commit on main", m.GitCommit, m.GitBranch, m.GitDirty)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m := NewManifest(config.CommandConfig{Executable: cli, Image: "agent:latest"}, dir); !m.GitDirty || m.AgentVersion != "" {
+		t.Errorf("manifest = %+v, want a dirty tree and no probe of a containerized CLI", m)
+	}
+
+	if m := NewManifest(config.CommandConfig{}, t.TempDir()); m.GitCommit != "" || m.SwarmVersion == "" {
+		t.Errorf("manifest outside a repo = %+v", m)
+	}
+}
+
+func TestManifestString(t *testing.T) {
+	m := &state.Manifest{
+		SwarmVersion: "v1.2.0",
+		AgentCLI:     "claude",
+		AgentVersion: "2.0.14 (Claude Code)",
+		GitCommit:    "3f2a9c1b8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a",
+		GitBranch:    "main",
+		GitDirty:     true,
+	}
+	want := "swarm v1.2.0 · claude 2.0.14 (Claude Code) · git main@3f2a9c1 (dirty)"
+	if got := m.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import "strconv"
+
+// RunInfo describes the run an agent iteration belongs to. It's exported to
+// the agent process as SWARM_* environment variables, so scripts the agent
+// runs can branch on it as the agent does on its prompt.
+type RunInfo struct {
+	// Iteration is the 1-based iteration number (0 = not set)
+	Iteration int
+
+	// TotalIterations is the number of iterations in the run (0 = unlimited)
+	TotalIterations int
+
+	// TaskName is the compose task (or for 'swarm run', the agent name)
+	TaskName string
+
+	// Pipeline is the name of the pipeline the task runs in, if any
+	Pipeline string
+
+	// RunID identifies the run: the pipeline run, or the agent for runs
+	// outside pipelines
+	RunID string
+
+	// ControlID is the ID swarm's control commands (stop, exec, approve)
+	// resolve to the agent's state: for a pipeline task, the pipeline's agent
+	ControlID string
+}
+
+// Env returns the run info as KEY=VALUE entries, omitting unset fields.
+func (ri RunInfo) Env() []string {
+	var env []string
+	if ri.Iteration > 0 {
+		env = append(env,
+			"SWARM_ITERATION="+strconv.Itoa(ri.Iteration),
+			"SWARM_TOTAL_ITERATIONS="+strconv.Itoa(ri.TotalIterations),
+		)
+	}
+	if ri.TaskName != "" {
+		env = append(env, "SWARM_TASK_NAME="+ri.TaskName)
+	}
+	if ri.Pipeline != "" {
+		env = append(env, "SWARM_PIPELINE="+ri.Pipeline)
+	}
+	if ri.RunID != "" {
+		env = append(env, "SWARM_RUN_ID="+ri.RunID)
+	}
+	if ri.ControlID != "" {
+		env = append(env, "SWARM_CONTROL_ID="+ri.ControlID)
+	}
+	return env
+}
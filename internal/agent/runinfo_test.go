@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunInfoEnv(t *testing.T) {
+	ri := RunInfo{Iteration: 2, TotalIterations: 5, TaskName: "coder", Pipeline: "main", RunID: "abc123", ControlID: "def456"}
+	want := []string{
+		"SWARM_ITERATION=2",
+		"SWARM_TOTAL_ITERATIONS=5",
+		"SWARM_TASK_NAME=coder",
+		"SWARM_PIPELINE=main",
+		"SWARM_RUN_ID=abc123",
+		"SWARM_CONTROL_ID=def456",
+	}
+	if got := ri.Env(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Env() = %q, want %q", got, want)
+	}
+
+	if got := (RunInfo{}).Env(); got != nil {
+		t.Errorf("empty Env() = %q, want nil", got)
+	}
+}
+
+func TestRunnerEnvOverridesRunInfo(t *testing.T) {
+	r := NewRunner(Config{
+		Env: []string{"SWARM_TASK_NAME=custom"},
+		Run: RunInfo{Iteration: 1, TotalIterations: 0, TaskName: "coder"},
+	})
+	want := []string{"SWARM_ITERATION=1", "SWARM_TOTAL_ITERATIONS=0", "SWARM_TASK_NAME=coder", "SWARM_TASK_NAME=custom"}
+	if got := r.env(); !reflect.DeepEqual(got, want) {
+		t.Errorf("env() = %q, want %q", got, want)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/mj1618/swarm-cli/internal/exitreport"
 	"github.com/mj1618/swarm-cli/internal/logparser"
 	"github.com/mj1618/swarm-cli/internal/process"
 )
@@ -26,11 +28,18 @@ type Runner struct {
 	cmd               *exec.Cmd
 	cmdMu             sync.RWMutex // protects cmd
 	usageCallback     UsageCallback
+	podStatusCallback PodStatusCallback
 	usageStats        logparser.UsageStats
 	statsMu           sync.Mutex
 	resultCh          chan struct{}
 	resultOnce        sync.Once
 	killedAfterResult int32 // atomic: set to 1 if force-killed after result event
+	stderr            *stderrCapture
+	errors            errorEvents
+	lastResult        *logparser.LogEvent // most recent result event (protected by statsMu)
+	pathOnce          sync.Once
+	pathErr           error // first edit the PathGuard rejected (protected by statsMu)
+	exitReport        *exitreport.Report
 }
 
 // NewRunner creates a new agent runner with the given configuration.
@@ -46,6 +55,12 @@ func (r *Runner) SetUsageCallback(cb UsageCallback) {
 	r.usageCallback = cb
 }
 
+// SetPodStatusCallback sets a callback function that is called when the pod
+// phase changes. Only invoked when running on the Kubernetes job backend.
+func (r *Runner) SetPodStatusCallback(cb PodStatusCallback) {
+	r.podStatusCallback = cb
+}
+
 // UsageStats returns the current usage statistics.
 func (r *Runner) UsageStats() logparser.UsageStats {
 	r.statsMu.Lock()
@@ -63,7 +78,7 @@ func (r *Runner) Run(out io.Writer) error {
 // RunWithContext executes the agent with the given context for cancellation/timeout.
 // If RawOutput is false, output is passed through the log parser for pretty printing.
 // If RawOutput is true, output is streamed directly (for Claude Code).
-func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
+func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) (runErr error) {
 	// Set up context with timeout if configured
 	if r.config.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -73,8 +88,67 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 
 	// Expand placeholders in command args
 	args := r.config.Command.ExpandArgs(r.config.Model, r.config.Prompt)
+
+	// On the Kubernetes backend, the agent runs inside a Job and the local
+	// process is `kubectl logs -f`, whose output flows through the same pipeline.
+	var job *kubernetesJob
+	if k := r.config.Command.Kubernetes; k != nil {
+		if r.config.Command.Image != "" {
+			override := *k
+			override.Image = r.config.Command.Image
+			k = &override
+		}
+		job = &kubernetesJob{cfg: k, name: newKubernetesJobName()}
+		if err := job.create(ctx, r.config.Command.Executable, args, r.isolatedEnv()); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "[swarm] Scheduled kubernetes job %s\n", job.name)
+		defer func() {
+			if runErr != nil {
+				job.delete()
+			} else {
+				job.deleteSecret()
+			}
+		}()
+		if r.podStatusCallback != nil {
+			watchCtx, watchCancel := context.WithCancel(ctx)
+			defer watchCancel()
+			go job.watchPodStatus(watchCtx, r.podStatusCallback)
+		}
+	}
+
 	r.cmdMu.Lock()
-	r.cmd = exec.CommandContext(ctx, r.config.Command.Executable, args...)
+	if job != nil {
+		r.cmd = job.logsCommand(ctx)
+	} else if r.config.Command.Image != "" {
+		// Run the agent (and any shell it spawns) inside the task's container image
+		workingDir := r.config.Dir
+		if workingDir == "" {
+			workingDir, _ = os.Getwd()
+		}
+		env := r.isolatedEnv()
+		spec := containerSpec{
+			Name:       newContainerName(),
+			Image:      r.config.Command.Image,
+			WorkingDir: workingDir,
+			ReadOnly:   r.config.Command.ReadOnly,
+			Mounts:     containerMounts(workingDir, r.config.StateDir, r.config.ExitFile, env),
+			User:       currentUser(),
+			Executable: r.config.Command.Executable,
+			Args:       args,
+			Env:        env,
+		}
+		runtime := r.config.Command.ContainerRuntimePath()
+		r.cmd = exec.CommandContext(ctx, runtime, containerArgs(spec)...)
+		defer func() {
+			if runErr != nil {
+				removeContainer(runtime, spec.Name)
+			}
+		}()
+	} else {
+		r.cmd = exec.CommandContext(ctx, r.config.Command.Executable, args...)
+		r.cmd.Dir = r.config.Dir
+	}
 
 	// Set up process attributes for proper process group handling.
 	// This allows ForceKill to terminate the entire process group including child processes.
@@ -83,8 +157,9 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 
 	// Apply custom environment variables if specified
 	// Inherit parent environment and append custom vars (later values override earlier)
-	if len(r.config.Env) > 0 {
-		r.cmd.Env = append(os.Environ(), r.config.Env...)
+	// (Kubernetes jobs receive them through the manifest instead.)
+	if env := r.env(); len(env) > 0 && job == nil {
+		r.cmd.Env = append(os.Environ(), env...)
 	}
 
 	// Set up pipes
@@ -98,6 +173,15 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 		return err
 	}
 
+	// Files that already differ from HEAD aren't the agent's edits
+	worktree := r.config.PathGuard.Snapshot()
+
+	// A report left by an earlier iteration isn't this run's
+	r.exitReport = nil
+	if r.config.ExitFile != "" {
+		os.Remove(r.config.ExitFile)
+	}
+
 	// Start the command
 	if err := r.cmd.Start(); err != nil {
 		return err
@@ -155,6 +239,9 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 			for scanner.Scan() {
 				line := scanner.Text()
 				r.extractUsageFromLine(line)
+				event := logparser.ParseEvent(line)
+				r.errors.note(line, event)
+				r.guardPaths(event, out)
 			}
 		}()
 	} else {
@@ -177,21 +264,27 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 			for scanner.Scan() {
 				line := scanner.Text()
 				parser.ProcessLine(line)
-				if event := logparser.ParseEvent(line); event != nil {
-					if event.Type == "result" || event.Type == "turn.completed" {
-						r.resultOnce.Do(func() { close(r.resultCh) })
-					}
+				event := logparser.ParseEvent(line)
+				if event != nil && (event.Type == "result" || event.Type == "turn.completed") {
+					r.resultOnce.Do(func() { close(r.resultCh) })
+					r.noteResult(event)
 				}
+				r.errors.note(line, event)
+				r.guardPaths(event, out)
 			}
 			parser.Flush()
 		}()
 	}
 
-	// Forward stderr directly
+	// Forward stderr to the sidecar log (or our own stderr), keeping the
+	// last lines for failure reports
+	stderrDest, closeStderr := openStderrDest(r.config.StderrFile)
+	defer closeStderr()
+	r.stderr = &stderrCapture{dest: stderrDest}
 	outputWg.Add(1)
 	go func() {
 		defer outputWg.Done()
-		io.Copy(os.Stderr, stderr)
+		io.Copy(r.stderr, stderr)
 	}()
 
 	// Wait for all output goroutines to finish reading before calling cmd.Wait(),
@@ -200,11 +293,21 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 
 	// Wait for command to complete and release resources
 	err = r.cmd.Wait()
+	r.readExitReport(out)
+
+	// An edit outside the allowed paths fails the run whatever else happened
+	if pathErr := r.pathViolation(); pathErr != nil {
+		return pathErr
+	}
+	if pathErr := r.config.PathGuard.CheckWorktree(worktree); pathErr != nil {
+		fmt.Fprintf(out, "\n[swarm] Agent %v\n", pathErr)
+		return pathErr
+	}
 
 	// If we force-killed after a result event, the agent completed successfully
 	// but had a stuck child process — treat as success.
 	if atomic.LoadInt32(&r.killedAfterResult) == 1 {
-		return nil
+		return r.checkResult()
 	}
 
 	// Check if the error was due to context cancellation/timeout
@@ -215,9 +318,139 @@ func (r *Runner) RunWithContext(ctx context.Context, out io.Writer) error {
 		return fmt.Errorf("iteration was cancelled")
 	}
 
+	// kubectl logs exits cleanly even when the pod failed, so the job status
+	// is the source of truth for the iteration outcome.
+	if job != nil && err == nil {
+		err = job.result(ctx)
+		if r.podStatusCallback != nil {
+			if podName, phase, statusErr := job.podStatus(context.Background()); statusErr == nil {
+				r.podStatusCallback(podName, phase)
+			}
+		}
+	}
+
+	if err == nil {
+		err = r.checkResult()
+	}
 	return err
 }
 
+// noteResult records a result event for checkResult.
+func (r *Runner) noteResult(event *logparser.LogEvent) {
+	if event.Type != "result" {
+		return
+	}
+	r.statsMu.Lock()
+	r.lastResult = event
+	r.statsMu.Unlock()
+}
+
+// checkResult applies the configured result criteria to the run's result
+// event, and fails the run if the agent reported failure.
+func (r *Runner) checkResult() error {
+	r.statsMu.Lock()
+	event := r.lastResult
+	r.statsMu.Unlock()
+	if err := r.config.ResultCriteria.Check(event); err != nil {
+		return err
+	}
+	return r.exitReport.Err()
+}
+
+// guardPaths kills the agent if event edits a file its PathGuard doesn't
+// allow. Only the first violation is reported.
+func (r *Runner) guardPaths(event *logparser.LogEvent, out io.Writer) {
+	if r.config.PathGuard == nil {
+		return
+	}
+	for _, p := range logparser.EditedPaths(event) {
+		err := r.config.PathGuard.Check(p)
+		if err == nil {
+			continue
+		}
+		r.pathOnce.Do(func() {
+			r.statsMu.Lock()
+			r.pathErr = err
+			r.statsMu.Unlock()
+			fmt.Fprintf(out, "\n[swarm] Agent %v — killing it\n", err)
+			r.cmdMu.RLock()
+			if r.cmd != nil && r.cmd.Process != nil {
+				process.ForceKill(r.cmd.Process.Pid)
+			}
+			r.cmdMu.RUnlock()
+		})
+		return
+	}
+}
+
+// pathViolation returns the edit guardPaths rejected, if any.
+func (r *Runner) pathViolation() error {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	return r.pathErr
+}
+
+// env returns the agent's run info variables and its command's variables
+// (e.g. model params), followed by its explicit env.
+func (r *Runner) env() []string {
+	run := r.config.Run.Env()
+	if r.config.ExitFile != "" {
+		run = append(run, exitreport.Env(r.config.ExitFile))
+	}
+	run = append(run, r.config.Command.Env...)
+	if len(run) == 0 {
+		return r.config.Env
+	}
+	return append(run, r.config.Env...)
+}
+
+// readExitReport loads the exit report the agent wrote, if any.
+func (r *Runner) readExitReport(out io.Writer) {
+	if r.config.ExitFile == "" {
+		return
+	}
+	report, err := exitreport.Read(r.config.ExitFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(out, "\n[swarm] Warning: ignoring exit report: %v\n", err)
+		}
+		return
+	}
+	r.exitReport = report
+}
+
+// ExitReport returns the exit report the agent wrote in the most recent run,
+// or nil if it wrote none.
+func (r *Runner) ExitReport() *exitreport.Report {
+	return r.exitReport
+}
+
+// isolatedEnv returns the environment for backends that don't inherit this
+// process's environment (containers and Kubernetes jobs): the agent's explicit
+// env plus variables loaded from env files, which local agents inherit.
+func (r *Runner) isolatedEnv() []string {
+	if len(r.config.Command.PassEnv) == 0 {
+		return r.env()
+	}
+
+	env := append([]string(nil), r.env()...)
+	set := make(map[string]bool, len(env))
+	for _, e := range env {
+		if idx := strings.Index(e, "="); idx > 0 {
+			set[e[:idx]] = true
+		}
+	}
+	for _, name := range r.config.Command.PassEnv {
+		if set[name] {
+			continue
+		}
+		if val, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+val)
+		}
+	}
+	return env
+}
+
 // extractUsageFromLine tries to extract usage info from a raw output line.
 // This is used for Claude Code which outputs raw text.
 func (r *Runner) extractUsageFromLine(line string) {
@@ -228,29 +461,15 @@ func (r *Runner) extractUsageFromLine(line string) {
 
 	if event.Type == "result" || event.Type == "turn.completed" {
 		r.resultOnce.Do(func() { close(r.resultCh) })
+		r.noteResult(event)
 	}
 
 	r.statsMu.Lock()
 
 	updated := false
 
-	// Find usage from the best available location:
-	// 1. Top-level usage (result events, turn.completed)
-	// 2. message.usage (assistant events)
-	usage := event.Usage
-	if usage == nil && event.Message != nil {
-		usage = event.Message.Usage
-	}
-
-	if usage != nil {
-		inputTokens := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens + usage.CachedInputTokens
-		if inputTokens == 0 {
-			inputTokens = usage.PromptTokens
-		}
-		outputTokens := usage.OutputTokens
-		if outputTokens == 0 {
-			outputTokens = usage.CompletionTokens
-		}
+	if usage := logparser.FindUsage(event); usage != nil {
+		inputTokens, outputTokens := usage.Tokens()
 		if inputTokens > 0 || outputTokens > 0 {
 			r.usageStats.InputTokens += inputTokens
 			r.usageStats.OutputTokens += outputTokens
@@ -264,6 +483,11 @@ func (r *Runner) extractUsageFromLine(line string) {
 		updated = true
 	}
 
+	if model := logparser.EventModel(event); model != "" && model != r.usageStats.Model {
+		r.usageStats.Model = model
+		updated = true
+	}
+
 	// Update current task based on event type
 	var newTask string
 	switch event.Type {
@@ -2,12 +2,17 @@ package agent
 
 import (
 	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/exitreport"
 	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
 )
 
 // CommandConfig is an alias for config.CommandConfig
@@ -389,6 +394,90 @@ func TestRunnerForceKillAfterResultParsed(t *testing.T) {
 	}
 }
 
+// TestRunnerPathGuard verifies that an edit outside the allowed paths kills
+// the agent and fails the run.
+func TestRunnerPathGuard(t *testing.T) {
+	script := `printf '{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Edit","input":{"file_path":"docs/a.md"}}]}}\n'
+printf '{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Write","input":{"file_path":"src/main.go"}}]}}\n'
+sleep 120`
+
+	guard, err := pathguard.New([]string{"docs/**"}, nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []bool{true, false} {
+		cfg := Config{
+			Model:  "test",
+			Prompt: "test",
+			Command: CommandConfig{
+				Executable: "sh",
+				Args:       []string{"-c", script},
+				RawOutput:  raw,
+			},
+			Dir:       guard.Dir,
+			PathGuard: guard,
+		}
+
+		runner := NewRunner(cfg)
+		var buf bytes.Buffer
+		start := time.Now()
+		err := runner.Run(&buf)
+
+		if !errors.Is(err, pathguard.ErrViolation) {
+			t.Fatalf("raw=%v: expected ErrViolation, got: %v", raw, err)
+		}
+		if !strings.Contains(err.Error(), "src/main.go") {
+			t.Errorf("raw=%v: error should name the file, got: %v", raw, err)
+		}
+		if elapsed := time.Since(start); elapsed > 15*time.Second {
+			t.Errorf("raw=%v: expected the agent to be killed, took %v", raw, elapsed)
+		}
+		if got := runner.ClassifyFailure(err); got != FailurePathViolation {
+			t.Errorf("raw=%v: ClassifyFailure() = %q, want %q", raw, got, FailurePathViolation)
+		}
+	}
+}
+
+func TestRunnerExitReport(t *testing.T) {
+	exitFile := filepath.Join(t.TempDir(), "swarm-exit.json")
+	// A report left by an earlier run is removed before the agent starts
+	if err := os.WriteFile(exitFile, []byte(`{"status":"success"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := `printf '{"status":"failure","summary":"tests still fail","follow_up":"fix auth"}' > "$SWARM_EXIT_FILE"
+printf '{"type":"result","subtype":"success","result":"done"}\n'`
+	runner := NewRunner(Config{
+		Model:    "test",
+		Prompt:   "test",
+		Command:  CommandConfig{Executable: "sh", Args: []string{"-c", script}, RawOutput: true},
+		ExitFile: exitFile,
+	})
+	var buf bytes.Buffer
+	err := runner.Run(&buf)
+	if !errors.Is(err, exitreport.ErrFailure) || !strings.Contains(err.Error(), "tests still fail") {
+		t.Fatalf("Run() = %v, want a reported failure", err)
+	}
+	if got := runner.ClassifyFailure(err); got != FailureReported {
+		t.Errorf("ClassifyFailure() = %q, want %q", got, FailureReported)
+	}
+	if r := runner.ExitReport(); r == nil || r.FollowUp != "fix auth" {
+		t.Errorf("ExitReport() = %+v", r)
+	}
+
+	// Without a report the run succeeds
+	runner = NewRunner(Config{
+		Command:  CommandConfig{Executable: "sh", Args: []string{"-c", "true"}, RawOutput: true},
+		ExitFile: exitFile,
+	})
+	if err := runner.Run(&buf); err != nil {
+		t.Errorf("Run() without a report = %v", err)
+	}
+	if r := runner.ExitReport(); r != nil {
+		t.Errorf("ExitReport() = %+v, want nil", r)
+	}
+}
+
 // TestRunnerNormalExitNoForceKill verifies that normal process exit is not affected.
 func TestRunnerNormalExitNoForceKill(t *testing.T) {
 	script := `printf '{"type":"result","subtype":"success","result":"done"}\n'`
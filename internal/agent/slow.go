@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSlowIteration is wrapped by the error of an iteration that was stopped
+// for running too long compared to the agent's median iteration
+// (slow_iteration_action = "fail"). It is classed as a timeout.
+var ErrSlowIteration = errors.New("stopped slow iteration")
+
+// WatchSlowIteration calls onSlow once if an iteration is still running
+// after limit (0 = never). The returned stop function must be called when the
+// iteration ends; it waits for an onSlow call in progress and reports whether
+// onSlow was called.
+func WatchSlowIteration(limit time.Duration, onSlow func()) (stop func() bool) {
+	if limit <= 0 {
+		return func() bool { return false }
+	}
+	done := make(chan struct{})
+	timer := time.AfterFunc(limit, func() {
+		defer close(done)
+		onSlow()
+	})
+	return func() bool {
+		if timer.Stop() {
+			return false
+		}
+		<-done
+		return true
+	}
+}
+
+// SlowIterationMessage describes an iteration that has run past limit, where
+// median is the agent's median iteration duration.
+func SlowIterationMessage(iteration int, limit, median time.Duration) string {
+	return fmt.Sprintf("iteration %d is slow: still running after %v (%.1fx the median iteration of %v)",
+		iteration, limit.Round(time.Second), float64(limit)/float64(median), median.Round(time.Second))
+}
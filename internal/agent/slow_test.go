@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchSlowIteration(t *testing.T) {
+	var calls atomic.Int32
+	stop := WatchSlowIteration(10*time.Millisecond, func() { calls.Add(1) })
+	time.Sleep(50 * time.Millisecond)
+	if !stop() || calls.Load() != 1 {
+		t.Errorf("expected onSlow to be called once, got %d", calls.Load())
+	}
+
+	stop = WatchSlowIteration(time.Hour, func() { calls.Add(1) })
+	if stop() || calls.Load() != 1 {
+		t.Error("onSlow should not be called for an iteration that ended in time")
+	}
+
+	if WatchSlowIteration(0, func() { t.Error("onSlow called with no limit") })() {
+		t.Error("expected no slow iteration without a limit")
+	}
+}
+
+func TestSlowIterationClassifiedAsTimeout(t *testing.T) {
+	msg := SlowIterationMessage(4, 9*time.Minute, 3*time.Minute)
+	if !strings.Contains(msg, "iteration 4") || !strings.Contains(msg, "3.0x") {
+		t.Errorf("message = %q", msg)
+	}
+	err := fmt.Errorf("%w: %s", ErrSlowIteration, msg)
+	if class := ClassifyFailure(err, nil); class != FailureTimeout {
+		t.Errorf("ClassifyFailure() = %q, want %q", class, FailureTimeout)
+	}
+}
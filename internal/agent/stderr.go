@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// stderrTailLines is the number of trailing stderr lines kept for failure reports.
+const stderrTailLines = 20
+
+// stderrCapture forwards the agent CLI's stderr to its destination while
+// keeping the last lines, so auth errors and crash traces can be reported
+// when an iteration fails.
+type stderrCapture struct {
+	dest    io.Writer
+	mu      sync.Mutex
+	lines   []string
+	partial string
+}
+
+// Write implements io.Writer.
+func (c *stderrCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	text := c.partial + string(p)
+	parts := strings.Split(text, "\n")
+	c.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		c.appendLine(line)
+	}
+	c.mu.Unlock()
+
+	if c.dest != nil {
+		c.dest.Write(p)
+	}
+	return len(p), nil
+}
+
+// appendLine records a complete line. Callers must hold c.mu.
+func (c *stderrCapture) appendLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	c.lines = append(c.lines, line)
+	if len(c.lines) > stderrTailLines {
+		c.lines = c.lines[len(c.lines)-stderrTailLines:]
+	}
+}
+
+// Tail returns the last captured lines, including an unterminated final line.
+func (c *stderrCapture) Tail() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tail := append([]string(nil), c.lines...)
+	if strings.TrimSpace(c.partial) != "" {
+		tail = append(tail, c.partial)
+		if len(tail) > stderrTailLines {
+			tail = tail[len(tail)-stderrTailLines:]
+		}
+	}
+	return tail
+}
+
+// openStderrDest returns where the agent's stderr should go: the sidecar file
+// when configured, otherwise this process's stderr. The returned close
+// function must be called once the agent has exited.
+func openStderrDest(path string) (io.Writer, func()) {
+	if path == "" {
+		return os.Stderr, func() {}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to open stderr log %s: %v\n", path, err)
+		return os.Stderr, func() {}
+	}
+	return f, func() { f.Close() }
+}
+
+// StderrTail returns the last lines the agent CLI wrote to stderr during the
+// most recent run.
+func (r *Runner) StderrTail() []string {
+	if r.stderr == nil {
+		return nil
+	}
+	return r.stderr.Tail()
+}
+
+// PrintStderrTail writes the last stderr lines of the most recent run to w,
+// for showing alongside an iteration failure. It writes nothing if the agent
+// didn't write to stderr.
+func (r *Runner) PrintStderrTail(w io.Writer) {
+	tail := r.StderrTail()
+	if len(tail) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "[swarm] Agent stderr (last %d lines):\n", len(tail))
+	for _, line := range tail {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStderrCaptureTail(t *testing.T) {
+	var dest bytes.Buffer
+	c := &stderrCapture{dest: &dest}
+
+	// Lines split across writes are joined, blank lines are skipped
+	c.Write([]byte("first li"))
+	c.Write([]byte("ne\n\nsecond line\nunterminated"))
+
+	want := []string{"first line", "second line", "unterminated"}
+	if got := c.Tail(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Tail() = %q, want %q", got, want)
+	}
+	if dest.String() != "first line\n\nsecond line\nunterminated" {
+		t.Errorf("destination got %q", dest.String())
+	}
+
+	for i := 0; i < stderrTailLines+5; i++ {
+		fmt.Fprintf(c, "line %d\n", i)
+	}
+	tail := c.Tail()
+	if len(tail) != stderrTailLines {
+		t.Fatalf("Tail() kept %d lines, want %d", len(tail), stderrTailLines)
+	}
+	if last := fmt.Sprintf("line %d", stderrTailLines+4); tail[len(tail)-1] != last {
+		t.Errorf("last tail line = %q, want %q", tail[len(tail)-1], last)
+	}
+}
+
+func TestRunCapturesStderrToFile(t *testing.T) {
+	stderrFile := filepath.Join(t.TempDir(), "agent.stderr.log")
+	runner := NewRunner(Config{
+		Command: CommandConfig{
+			Executable: "sh",
+			Args:       []string{"-c", "echo out; echo 'auth failed' >&2; exit 1"},
+			RawOutput:  true,
+		},
+		StderrFile:        stderrFile,
+		ResultGracePeriod: -1,
+	})
+
+	var out bytes.Buffer
+	if err := runner.Run(&out); err == nil {
+		t.Fatal("expected error from failing command")
+	}
+
+	if strings.Contains(out.String(), "auth failed") {
+		t.Errorf("stderr leaked into output: %q", out.String())
+	}
+	data, err := os.ReadFile(stderrFile)
+	if err != nil {
+		t.Fatalf("failed to read stderr log: %v", err)
+	}
+	if string(data) != "auth failed\n" {
+		t.Errorf("stderr log = %q, want %q", data, "auth failed\n")
+	}
+	if tail := runner.StderrTail(); len(tail) != 1 || tail[0] != "auth failed" {
+		t.Errorf("StderrTail() = %q", tail)
+	}
+
+	var report bytes.Buffer
+	runner.PrintStderrTail(&report)
+	if !strings.Contains(report.String(), "auth failed") {
+		t.Errorf("PrintStderrTail() = %q", report.String())
+	}
+}
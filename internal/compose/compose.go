@@ -1,9 +1,20 @@
 package compose
 
 import (
+	"bytes"
 	"fmt"
 	"os"
-
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/cron"
+	"github.com/mj1618/swarm-cli/internal/label"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/taskoutput"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,12 +29,16 @@ const (
 	ConditionAlways  = "always"  // Always run after dependency, even if skipped
 )
 
+// IsolationWorktree runs each of a task's agents in its own git worktree.
+const IsolationWorktree = "worktree"
+
 // Dependency represents a task dependency with an optional condition.
 // Supports both simple string form ("depends_on: [task1]") and full form
 // ("depends_on: [{task: task1, condition: success}]").
 type Dependency struct {
 	Task      string `yaml:"task"`      // Name of the task to depend on
 	Condition string `yaml:"condition"` // success, failure, any, always (default: any)
+	When      string `yaml:"when"`      // Optional test on the dependency's structured output, e.g. "status == pass"
 }
 
 // UnmarshalYAML implements custom unmarshaling to support both string and object forms.
@@ -40,6 +55,7 @@ func (d *Dependency) UnmarshalYAML(value *yaml.Node) error {
 		type rawDependency struct {
 			Task      string `yaml:"task"`
 			Condition string `yaml:"condition"`
+			When      string `yaml:"when"`
 		}
 		var raw rawDependency
 		if err := value.Decode(&raw); err != nil {
@@ -47,6 +63,7 @@ func (d *Dependency) UnmarshalYAML(value *yaml.Node) error {
 		}
 		d.Task = raw.Task
 		d.Condition = raw.Condition
+		d.When = raw.When
 		if d.Condition == "" {
 			d.Condition = ConditionAny
 		}
@@ -72,19 +89,126 @@ type Pipeline struct {
 	// Parallelism is the number of concurrent instances to run (default 1)
 	Parallelism int `yaml:"parallelism"`
 
+	// SharedIterations makes Iterations a total shared by all parallel
+	// instances rather than a per-instance count, e.g. to work through a
+	// bounded queue with several instances.
+	SharedIterations bool `yaml:"shared_iterations"`
+
+	// Matrix fans the pipeline out into one instance per combination of its
+	// values (e.g. module: [auth, billing, api]), each with {{matrix.module}}
+	// in its tasks' prompts replaced by that instance's value. It can't be
+	// combined with parallelism.
+	Matrix map[string][]string `yaml:"matrix"`
+
 	// Tasks is an optional list of task names to include in this pipeline.
 	// If empty, all tasks from the compose file are included.
 	Tasks []string `yaml:"tasks"`
+
+	// StopWhen holds conditions checked after each iteration; the pipeline
+	// stops early when any of them is met.
+	StopWhen *StopCondition `yaml:"stop_when"`
+
+	// StateDir overrides where this pipeline's iteration state directories
+	// (SWARM_STATE_DIR) are created. Relative paths are resolved against the
+	// working directory.
+	StateDir string `yaml:"state_dir"`
+
+	// OnFailurePrompt and OnFailureAfter are defaults for tasks in this
+	// pipeline that don't set their own (see Task.OnFailurePrompt).
+	OnFailurePrompt string `yaml:"on_failure_prompt"`
+	OnFailureAfter  int    `yaml:"on_failure_after"`
+
+	// Environment holds environment variables for every task in this
+	// pipeline (e.g. shared API endpoints or feature flags). A task's own
+	// env overrides them.
+	Environment map[string]string `yaml:"environment"`
+
+	// EnvFile is a .env file of defaults for every task in this pipeline,
+	// under its environment. Relative paths are resolved against the
+	// working directory.
+	EnvFile string `yaml:"env_file"`
+
+	// BudgetUSD and BudgetTokens cap the pipeline's total cost and tokens.
+	// Once either is reached the running iteration is given a grace period
+	// to finish, then stopped, and no further iterations start.
+	BudgetUSD    float64 `yaml:"budget_usd"`
+	BudgetTokens int64   `yaml:"budget_tokens"`
+
+	// Schedule is a cron expression (e.g. "0 2 * * *") at which
+	// `swarm scheduler` starts the pipeline (empty = not scheduled).
+	Schedule string `yaml:"schedule"`
+
+	// Unlimited is set when the compose file explicitly specifies
+	// `iterations: 0`, meaning run until stopped or a stop condition is met.
+	// An omitted iterations field still defaults to 1.
+	Unlimited bool `yaml:"-"`
+}
+
+// StopCondition describes when an iterating pipeline should stop early.
+// Any condition that is met stops the pipeline.
+type StopCondition struct {
+	// FileExists stops the pipeline once this path exists (relative to the working directory)
+	FileExists string `yaml:"file_exists"`
+
+	// Command stops the pipeline once this shell command exits successfully
+	Command string `yaml:"command"`
+
+	// Budget stops the pipeline once its total cost in USD reaches this amount
+	Budget float64 `yaml:"budget"`
+}
+
+// UnmarshalYAML implements custom unmarshaling to distinguish an explicit
+// `iterations: 0` (unlimited) from an omitted iterations field.
+func (p *Pipeline) UnmarshalYAML(value *yaml.Node) error {
+	type rawPipeline Pipeline
+	var raw rawPipeline
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	var explicit struct {
+		Iterations *int `yaml:"iterations"`
+	}
+	if err := value.Decode(&explicit); err != nil {
+		return err
+	}
+
+	*p = Pipeline(raw)
+	p.Unlimited = explicit.Iterations != nil && *explicit.Iterations == 0
+	return nil
 }
 
 // EffectiveIterations returns the iterations to use, defaulting to 1.
+// Returns 0 for unlimited pipelines (`iterations: 0`).
 func (p *Pipeline) EffectiveIterations() int {
+	if p.Unlimited {
+		return 0
+	}
 	if p.Iterations <= 0 {
 		return 1
 	}
 	return p.Iterations
 }
 
+// EffectiveHealthInterval returns how often HealthCmd runs, or 0 if
+// HealthInterval is unset (the default interval).
+func (t *Task) EffectiveHealthInterval() time.Duration {
+	interval, err := time.ParseDuration(t.HealthInterval)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// PathGuard returns the guard for the files the task's agent may edit in
+// dir, or nil if it may edit any.
+func (t *Task) PathGuard(dir string) (*pathguard.Guard, error) {
+	if t.ReadOnly {
+		return pathguard.NewReadOnly(dir), nil
+	}
+	return pathguard.New(t.AllowedPaths, t.DeniedPaths, dir)
+}
+
 // EffectiveParallelism returns the parallelism to use, defaulting to 1.
 func (p *Pipeline) EffectiveParallelism() int {
 	if p.Parallelism <= 0 {
@@ -93,6 +217,60 @@ func (p *Pipeline) EffectiveParallelism() int {
 	return p.Parallelism
 }
 
+// matrixKeyRegex matches pipeline matrix keys, which appear in prompts as
+// {{matrix.key}}.
+var matrixKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// matrixValueRegex matches pipeline matrix values, which appear in instance
+// names.
+var matrixValueRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// MatrixInstance is one combination of a pipeline's matrix values.
+type MatrixInstance struct {
+	// Name is the instance's name: the pipeline's name, "@", and the
+	// values in key order joined by "-" (e.g. "review@auth-linux")
+	Name string
+
+	// Values maps each matrix key to the instance's value
+	Values map[string]string
+}
+
+// MatrixInstances returns the instances the matrix of the pipeline called
+// name expands into, one per combination of values: keys in sorted order,
+// each key's values in the order given, the last key varying fastest.
+// Returns nil for a pipeline without a matrix.
+func (p *Pipeline) MatrixInstances(name string) []MatrixInstance {
+	if len(p.Matrix) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(p.Matrix))
+	for k := range p.Matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	combos := [][]string{nil}
+	for _, k := range keys {
+		var next [][]string
+		for _, combo := range combos {
+			for _, v := range p.Matrix[k] {
+				next = append(next, append(append([]string(nil), combo...), v))
+			}
+		}
+		combos = next
+	}
+
+	instances := make([]MatrixInstance, len(combos))
+	for i, combo := range combos {
+		values := make(map[string]string, len(keys))
+		for j, k := range keys {
+			values[k] = combo[j]
+		}
+		instances[i] = MatrixInstance{Name: name + "@" + strings.Join(combo, "-"), Values: values}
+	}
+	return instances
+}
+
 // ComposeFile represents the structure of a swarm compose file.
 type ComposeFile struct {
 	// Version is the compose file format version
@@ -103,6 +281,9 @@ type ComposeFile struct {
 
 	// Pipelines is a map of pipeline name to pipeline configuration
 	Pipelines map[string]Pipeline `yaml:"pipelines"`
+
+	// undefinedVars are the unset variables interpolated as ""
+	undefinedVars []string
 }
 
 // Task represents a single task definition in the compose file.
@@ -129,6 +310,20 @@ type Task struct {
 	// across parallel pipeline instances (0 = unlimited)
 	Concurrency int `yaml:"concurrency"`
 
+	// Labels place the task in concurrency pools defined in config
+	// (e.g. pool: gpu-heavy), whose limits apply across all pipelines
+	Labels map[string]string `yaml:"labels"`
+
+	// Schedule is a cron expression (e.g. "0 2 * * *") at which
+	// `swarm scheduler` starts the task (empty = not scheduled).
+	Schedule string `yaml:"schedule"`
+
+	// MaxPending applies backpressure to a planner that writes the todo
+	// queue: while more than this many task files wait for a doer, the task
+	// is skipped in pipelines and holds its next iteration otherwise
+	// (0 = no limit).
+	MaxPending int `yaml:"max_pending"`
+
 	// Name is a custom name for the agent (optional, defaults to task name)
 	Name string `yaml:"name"`
 
@@ -138,6 +333,119 @@ type Task struct {
 	// Suffix is content appended to the prompt at runtime
 	Suffix string `yaml:"suffix"`
 
+	// Image is a container image to run the agent in (optional, e.g. "golang:1.22").
+	// The agent and any shell it spawns run inside the container as the
+	// current user, with the working directory, SWARM_STATE_DIR and
+	// SWARM_SCRATCH_DIR mounted, keeping host toolchains out of the picture.
+	Image string `yaml:"image"`
+
+	// Repo is the repository the task's agent works in: a directory relative
+	// to the working directory (e.g. "../other-service") or a git URL, cloned
+	// into ~/.swarm/repos on first use. Empty runs in the working directory.
+	Repo string `yaml:"repo"`
+
+	// InjectEnvSummary prepends a summary of the host environment (OS,
+	// available tools, repository languages) to the task's prompt, so the
+	// agent doesn't have to rediscover it every iteration.
+	InjectEnvSummary bool `yaml:"inject_env_summary"`
+
+	// ExtraArgs are extra flags appended to the agent command
+	// (e.g. ["--max-turns", "20"]), for options swarm doesn't model itself.
+	ExtraArgs []string `yaml:"extra_args"`
+
+	// Params tune the model for this task's agent (temperature,
+	// max_output_tokens, thinking_budget), e.g. a low temperature for a
+	// reviewer. The backend passes the ones its agent CLI supports.
+	Params map[string]string `yaml:"params"`
+
+	// MaxInjectedBytes caps how much of each upstream output an
+	// {{output:...}} directive injects into this task's prompt, keeping the
+	// end of the output (0 = no cap).
+	MaxInjectedBytes int `yaml:"max_injected_bytes"`
+
+	// OutputSchema declares the structured output (swarm-output.json) the
+	// task must write in pipelines. The output is validated against it and
+	// its fields are available as {{output:task.field}} and to depends_on
+	// `when:` conditions.
+	OutputSchema *taskoutput.Schema `yaml:"output_schema"`
+
+	// OnFailurePrompt names a prompt for a one-shot triage agent that runs
+	// in pipelines when the task fails OnFailureAfter times in a row. The
+	// agent gets the task's recent output and VerifyCommand's output, and
+	// its diagnosis is given to the task in its next iteration.
+	OnFailurePrompt string `yaml:"on_failure_prompt"`
+
+	// OnFailureAfter is how many consecutive failures trigger the triage
+	// agent (default 1)
+	OnFailureAfter int `yaml:"on_failure_after"`
+
+	// VerifyCommand is a shell command (e.g. "go test ./...") whose output
+	// is given to the triage agent
+	VerifyCommand string `yaml:"verify_command"`
+
+	// FailOnSubtype is a comma-separated list of result event subtypes that
+	// mark an iteration failed even when the agent CLI exits 0. An entry also
+	// matches subtypes it prefixes, so "error" matches "error_max_turns".
+	FailOnSubtype string `yaml:"fail_on_subtype"`
+
+	// FailOnResultRegex marks an iteration failed when the text of the
+	// agent's result event matches it
+	FailOnResultRegex string `yaml:"fail_on_result_regex"`
+
+	// HealthCmd is a shell command (e.g. "curl -fs localhost:3000/health")
+	// run periodically while the task's agent is active. When it fails the
+	// agent is marked unhealthy, e.g. for agents babysitting a server.
+	HealthCmd string `yaml:"health_cmd"`
+
+	// HealthInterval is how often HealthCmd runs, as a duration such as
+	// "30s" (default 30s)
+	HealthInterval string `yaml:"health_interval"`
+
+	// AllowedPaths restricts the files the task's agent may edit to these
+	// glob patterns, relative to its working directory ("**" matches any
+	// number of directories, e.g. "docs/**"). An edit anywhere else kills the
+	// agent and fails its iteration.
+	AllowedPaths []string `yaml:"allowed_paths"`
+
+	// DeniedPaths are glob patterns the task's agent may not edit, even if
+	// AllowedPaths matches them
+	DeniedPaths []string `yaml:"denied_paths"`
+
+	// ReadOnly is for reviewer and analyst agents that must not change the
+	// code: any edit in the working directory, or a working tree changed
+	// after an iteration, kills the agent and fails its iteration. The
+	// working tree isn't checked while pipeline siblings edit it too. The
+	// agent CLI also gets the command's read_only_args.
+	ReadOnly bool `yaml:"read_only"`
+
+	// Env holds environment variables for the task's agent, like
+	// `swarm run -e KEY=VALUE`
+	Env map[string]string `yaml:"env"`
+
+	// EnvFile is a .env file of environment variables for the task's agent,
+	// under its env. Relative paths are resolved against the working
+	// directory.
+	EnvFile string `yaml:"env_file"`
+
+	// Isolation, when "worktree", runs each of the task's agents (each
+	// parallel instance, and each pipeline run of the task) in its own git
+	// worktree on a swarm/<agent> branch, so instances don't clobber each
+	// other's edits. Changes are committed to the branch after each
+	// iteration; the worktree is removed when the agent finishes and the
+	// branch kept if it has unmerged changes.
+	Isolation string `yaml:"isolation"`
+
+	// MergeBack merges a worktree-isolated agent's branch into the original
+	// checkout after each successful iteration. A conflicting merge is
+	// aborted and the changes stay on the branch.
+	MergeBack bool `yaml:"merge_back"`
+
+	// BudgetUSD and BudgetTokens cap the cost and tokens of each of the
+	// task's agents. An agent over budget stops after its current iteration,
+	// or is killed if that runs on past a grace period.
+	BudgetUSD    float64 `yaml:"budget_usd"`
+	BudgetTokens int64   `yaml:"budget_tokens"`
+
 	// DependsOn specifies task dependencies with optional conditions.
 	// Tasks will only run after their dependencies complete (based on condition).
 	DependsOn []Dependency `yaml:"depends_on"`
@@ -148,19 +456,93 @@ func DefaultPath() string {
 	return DefaultFileName
 }
 
-// Load reads and parses a compose file from the given path.
+// LoadOptions controls how a compose file is loaded.
+type LoadOptions struct {
+	// Lenient ignores unknown keys instead of failing
+	Lenient bool
+
+	// StrictEnv fails with an *UndefinedVariableError when a ${VAR} without
+	// a default isn't set, instead of substituting ""
+	StrictEnv bool
+
+	// LookupEnv looks up the variables to interpolate (default os.LookupEnv)
+	LookupEnv func(string) (string, bool)
+}
+
+// Load reads and parses a compose file from the given path. Keys that don't
+// match any field are an error (an *UnknownFieldsError), so typos like
+// `depends-on:` aren't silently ignored.
 func Load(path string) (*ComposeFile, error) {
+	return LoadWithOptions(path, LoadOptions{})
+}
+
+// LoadLenient is like Load but ignores unknown keys.
+func LoadLenient(path string) (*ComposeFile, error) {
+	return LoadWithOptions(path, LoadOptions{Lenient: true})
+}
+
+// LoadWithOptions reads and parses a compose file as set by opts.
+// Environment variables are interpolated into task model, prompt-string,
+// prefix, suffix, and env values and pipeline environment values: ${VAR},
+// ${VAR:-default} (used when VAR is unset or empty), and $$ for a literal $.
+func LoadWithOptions(path string, opts LoadOptions) (*ComposeFile, error) {
+	cf, _, err := load(path, opts)
+	return cf, err
+}
+
+// Resolve loads a compose file like LoadWithOptions and returns it as YAML
+// with its variables interpolated, along with the unset variables that were
+// interpolated as "".
+func Resolve(path string, opts LoadOptions) ([]byte, []string, error) {
+	cf, doc, err := load(path, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode compose file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return b.Bytes(), cf.undefinedVars, nil
+}
+
+func load(path string, opts LoadOptions) (*ComposeFile, *yaml.Node, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read compose file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	if !opts.Lenient {
+		if err := checkKnownFields(&doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse compose file: %w", err)
+		}
+	}
+
+	undefined, err := interpolateDocument(&doc, opts.LookupEnv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to interpolate compose file: %w", err)
+	}
+	if opts.StrictEnv && len(undefined) > 0 {
+		return nil, nil, &UndefinedVariableError{Names: undefined}
 	}
 
 	var cf ComposeFile
-	if err := yaml.Unmarshal(data, &cf); err != nil {
-		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&cf); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse compose file: %w", err)
+		}
 	}
+	cf.undefinedVars = undefined
 
-	return &cf, nil
+	return &cf, &doc, nil
 }
 
 // Validate checks the compose file for errors.
@@ -256,6 +638,84 @@ func (t *Task) Validate(name string) error {
 		return fmt.Errorf("task %q: concurrency cannot be negative", name)
 	}
 
+	if t.MaxPending < 0 {
+		return fmt.Errorf("task %q: max_pending cannot be negative", name)
+	}
+
+	if t.Schedule != "" {
+		if _, err := cron.Parse(t.Schedule); err != nil {
+			return fmt.Errorf("task %q: %w", name, err)
+		}
+	}
+
+	for k, v := range t.Labels {
+		if _, _, err := label.Parse(k + "=" + v); err != nil {
+			return fmt.Errorf("task %q: %w", name, err)
+		}
+	}
+
+	if t.OnFailureAfter < 0 {
+		return fmt.Errorf("task %q: on_failure_after cannot be negative", name)
+	}
+
+	if t.FailOnResultRegex != "" {
+		if _, err := regexp.Compile(t.FailOnResultRegex); err != nil {
+			return fmt.Errorf("task %q: invalid fail_on_result_regex: %w", name, err)
+		}
+	}
+
+	if t.HealthInterval != "" {
+		interval, err := time.ParseDuration(t.HealthInterval)
+		if err != nil {
+			return fmt.Errorf("task %q: invalid health_interval: %w", name, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("task %q: health_interval must be positive", name)
+		}
+	}
+
+	if t.ReadOnly && (len(t.AllowedPaths) > 0 || len(t.DeniedPaths) > 0) {
+		return fmt.Errorf("task %q: read_only can't be combined with allowed_paths or denied_paths", name)
+	}
+	for _, p := range t.AllowedPaths {
+		if err := pathguard.ValidatePattern(p); err != nil {
+			return fmt.Errorf("task %q: allowed_paths: %w", name, err)
+		}
+	}
+	for _, p := range t.DeniedPaths {
+		if err := pathguard.ValidatePattern(p); err != nil {
+			return fmt.Errorf("task %q: denied_paths: %w", name, err)
+		}
+	}
+
+	if err := validateEnvNames(t.Env); err != nil {
+		return fmt.Errorf("task %q: env: %w", name, err)
+	}
+	if t.EnvFile != "" {
+		if _, err := config.ParseEnvFile(t.EnvFile); err != nil {
+			return fmt.Errorf("task %q: env_file: %w", name, err)
+		}
+	}
+
+	if err := config.ValidateParams(t.Params); err != nil {
+		return fmt.Errorf("task %q: params: %w", name, err)
+	}
+
+	if t.Isolation != "" && t.Isolation != IsolationWorktree {
+		return fmt.Errorf("task %q: invalid isolation %q (must be worktree)", name, t.Isolation)
+	}
+	if t.MergeBack && t.Isolation != IsolationWorktree {
+		return fmt.Errorf("task %q: merge_back requires isolation: worktree", name)
+	}
+
+	if t.MaxInjectedBytes < 0 {
+		return fmt.Errorf("task %q: max_injected_bytes cannot be negative", name)
+	}
+
+	if t.BudgetUSD < 0 || t.BudgetTokens < 0 {
+		return fmt.Errorf("task %q: budget_usd and budget_tokens cannot be negative", name)
+	}
+
 	// Validate dependency conditions
 	for i, dep := range t.DependsOn {
 		if dep.Task == "" {
@@ -265,6 +725,15 @@ func (t *Task) Validate(name string) error {
 		if cond != ConditionSuccess && cond != ConditionFailure && cond != ConditionAny && cond != ConditionAlways {
 			return fmt.Errorf("task %q: dependency on %q has invalid condition %q (must be success, failure, any, or always)", name, dep.Task, cond)
 		}
+		if dep.When != "" {
+			if _, err := taskoutput.ParseCondition(dep.When); err != nil {
+				return fmt.Errorf("task %q: dependency on %q: %w", name, dep.Task, err)
+			}
+		}
+	}
+
+	if err := t.OutputSchema.Check(); err != nil {
+		return fmt.Errorf("task %q: %w", name, err)
 	}
 
 	return nil
@@ -280,6 +749,66 @@ func (p *Pipeline) Validate(name string, tasks map[string]Task) error {
 		return fmt.Errorf("pipeline %q: parallelism cannot be negative", name)
 	}
 
+	if p.OnFailureAfter < 0 {
+		return fmt.Errorf("pipeline %q: on_failure_after cannot be negative", name)
+	}
+
+	if p.BudgetUSD < 0 || p.BudgetTokens < 0 {
+		return fmt.Errorf("pipeline %q: budget_usd and budget_tokens cannot be negative", name)
+	}
+
+	if p.Schedule != "" {
+		if _, err := cron.Parse(p.Schedule); err != nil {
+			return fmt.Errorf("pipeline %q: %w", name, err)
+		}
+	}
+
+	if p.SharedIterations && p.Unlimited {
+		return fmt.Errorf("pipeline %q: shared_iterations requires a positive iterations count", name)
+	}
+
+	if len(p.Matrix) > 0 {
+		if p.Parallelism > 1 {
+			return fmt.Errorf("pipeline %q: matrix can't be combined with parallelism", name)
+		}
+		for key, values := range p.Matrix {
+			if !matrixKeyRegex.MatchString(key) {
+				return fmt.Errorf("pipeline %q: invalid matrix key %q (use letters, digits, - and _)", name, key)
+			}
+			if len(values) == 0 {
+				return fmt.Errorf("pipeline %q: matrix.%s has no values", name, key)
+			}
+			seen := make(map[string]bool, len(values))
+			for _, v := range values {
+				if !matrixValueRegex.MatchString(v) {
+					return fmt.Errorf("pipeline %q: invalid matrix.%s value %q (use letters, digits, ., - and _)", name, key, v)
+				}
+				if seen[v] {
+					return fmt.Errorf("pipeline %q: matrix.%s lists %q twice", name, key, v)
+				}
+				seen[v] = true
+			}
+		}
+	}
+
+	if p.StopWhen != nil {
+		if p.StopWhen.Budget < 0 {
+			return fmt.Errorf("pipeline %q: stop_when.budget cannot be negative", name)
+		}
+		if p.StopWhen.FileExists == "" && p.StopWhen.Command == "" && p.StopWhen.Budget == 0 {
+			return fmt.Errorf("pipeline %q: stop_when must set file_exists, command, or budget", name)
+		}
+	}
+
+	if err := validateEnvNames(p.Environment); err != nil {
+		return fmt.Errorf("pipeline %q: environment: %w", name, err)
+	}
+	if p.EnvFile != "" {
+		if _, err := config.ParseEnvFile(p.EnvFile); err != nil {
+			return fmt.Errorf("pipeline %q: env_file: %w", name, err)
+		}
+	}
+
 	// Validate that all specified tasks exist
 	for _, taskName := range p.Tasks {
 		if _, exists := tasks[taskName]; !exists {
@@ -364,6 +893,183 @@ func (t *Task) EffectiveConcurrency() int {
 	return t.Concurrency
 }
 
+// Budget returns the budget of each of the task's agents, or nil if it has
+// none.
+func (t *Task) Budget() *state.Budget {
+	return newBudget(t.BudgetUSD, t.BudgetTokens)
+}
+
+// Budget returns the pipeline's budget, or nil if it has none.
+func (p *Pipeline) Budget() *state.Budget {
+	return newBudget(p.BudgetUSD, p.BudgetTokens)
+}
+
+func newBudget(usd float64, tokens int64) *state.Budget {
+	b := &state.Budget{USD: usd, Tokens: tokens}
+	if b.IsZero() {
+		return nil
+	}
+	return b
+}
+
+// EffectiveOnFailureAfter returns how many consecutive failures trigger the
+// task's triage agent, defaulting to 1.
+func (t *Task) EffectiveOnFailureAfter() int {
+	if t.OnFailureAfter <= 0 {
+		return 1
+	}
+	return t.OnFailureAfter
+}
+
+// WithFailureDefaults returns tasks with the pipeline's on_failure_prompt and
+// on_failure_after applied to tasks that don't set their own.
+func (p *Pipeline) WithFailureDefaults(tasks map[string]Task) map[string]Task {
+	if p.OnFailurePrompt == "" && p.OnFailureAfter == 0 {
+		return tasks
+	}
+	result := make(map[string]Task, len(tasks))
+	for name, task := range tasks {
+		if task.OnFailurePrompt == "" {
+			task.OnFailurePrompt = p.OnFailurePrompt
+		}
+		if task.OnFailureAfter == 0 {
+			task.OnFailureAfter = p.OnFailureAfter
+		}
+		result[name] = task
+	}
+	return result
+}
+
+// WithEnvironment returns tasks with the pipeline's environment merged under
+// each task's own env.
+func (p *Pipeline) WithEnvironment(tasks map[string]Task) map[string]Task {
+	if len(p.Environment) == 0 {
+		return tasks
+	}
+	result := make(map[string]Task, len(tasks))
+	for name, task := range tasks {
+		env := make(map[string]string, len(p.Environment)+len(task.Env))
+		for k, v := range p.Environment {
+			env[k] = v
+		}
+		for k, v := range task.Env {
+			env[k] = v
+		}
+		task.Env = env
+		result[name] = task
+	}
+	return result
+}
+
+// LoadEnvFiles merges the variables of each task's env_file under its env,
+// and of each pipeline's env_file under its environment, so they reach the
+// agents with the rest of their environment. A task's env_file overrides its
+// pipeline's environment.
+func (cf *ComposeFile) LoadEnvFiles() error {
+	for name, task := range cf.Tasks {
+		env, err := withEnvFile(task.EnvFile, task.Env)
+		if err != nil {
+			return fmt.Errorf("task %q: env_file: %w", name, err)
+		}
+		task.Env = env
+		cf.Tasks[name] = task
+	}
+	for name, pipeline := range cf.Pipelines {
+		env, err := withEnvFile(pipeline.EnvFile, pipeline.Environment)
+		if err != nil {
+			return fmt.Errorf("pipeline %q: env_file: %w", name, err)
+		}
+		pipeline.Environment = env
+		cf.Pipelines[name] = pipeline
+	}
+	return nil
+}
+
+// withEnvFile returns env with the variables of the .env file at path merged
+// under it.
+func withEnvFile(path string, env map[string]string) (map[string]string, error) {
+	if path == "" {
+		return env, nil
+	}
+	vars, err := config.ParseEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[string]string, len(vars)+len(env))
+	for _, kv := range vars {
+		k, v, _ := strings.Cut(kv, "=")
+		merged[k] = v
+	}
+	for k, v := range env {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// EnvList returns the task's env as KEY=VALUE entries sorted by key.
+func (t *Task) EnvList() []string {
+	if len(t.Env) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(t.Env))
+	for k := range t.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := make([]string, len(keys))
+	for i, k := range keys {
+		env[i] = k + "=" + t.Env[k]
+	}
+	return env
+}
+
+// validateEnvNames returns an error if env has a key that can't be an
+// environment variable name.
+func validateEnvNames(env map[string]string) error {
+	for k := range env {
+		if k == "" || strings.ContainsAny(k, "= \t\n") {
+			return fmt.Errorf("invalid environment variable name %q", k)
+		}
+	}
+	return nil
+}
+
+// WithDependencies returns the named tasks together with all tasks they
+// transitively depend on, sorted by name.
+func (cf *ComposeFile) WithDependencies(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var visit func(name string) error
+	visit = func(name string) error {
+		if seen[name] {
+			return nil
+		}
+		task, ok := cf.Tasks[name]
+		if !ok {
+			return fmt.Errorf("task %q not found in compose file", name)
+		}
+		seen[name] = true
+		for _, dep := range task.DependsOn {
+			if err := visit(dep.Task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for name := range seen {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
 // HasDependencies returns true if any task has dependencies defined.
 func (cf *ComposeFile) HasDependencies() bool {
 	for _, task := range cf.Tasks {
@@ -420,6 +1126,46 @@ func (cf *ComposeFile) GetStandaloneTasks() map[string]Task {
 	return standalone
 }
 
+// Scheduled is a pipeline or task with a schedule.
+type Scheduled struct {
+	// Kind is "pipeline" or "task"
+	Kind     string
+	Name     string
+	Schedule *cron.Schedule
+}
+
+// Key identifies the scheduled pipeline or task, e.g. "pipeline:nightly".
+func (s Scheduled) Key() string {
+	return s.Kind + ":" + s.Name
+}
+
+// GetScheduled returns the pipelines and tasks that have a schedule,
+// pipelines first, each sorted by name. Schedules that don't parse are
+// skipped; Validate reports them.
+func (cf *ComposeFile) GetScheduled() []Scheduled {
+	var pipelines, tasks []Scheduled
+	for name, pipeline := range cf.Pipelines {
+		if s, err := cron.Parse(pipeline.Schedule); pipeline.Schedule != "" && err == nil {
+			pipelines = append(pipelines, Scheduled{Kind: "pipeline", Name: name, Schedule: s})
+		}
+	}
+	for name, task := range cf.Tasks {
+		if s, err := cron.Parse(task.Schedule); task.Schedule != "" && err == nil {
+			tasks = append(tasks, Scheduled{Kind: "task", Name: name, Schedule: s})
+		}
+	}
+	for _, list := range [][]Scheduled{pipelines, tasks} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return append(pipelines, tasks...)
+}
+
+// UndefinedVariables returns the unset environment variables the compose file
+// references without a default, which were interpolated as "".
+func (cf *ComposeFile) UndefinedVariables() []string {
+	return cf.undefinedVars
+}
+
 // Warnings returns non-fatal warnings about the compose file configuration.
 // These highlight situations that are likely user mistakes but not validation errors.
 func (cf *ComposeFile) Warnings() []string {
@@ -435,7 +1181,7 @@ func (cf *ComposeFile) Warnings() []string {
 		}
 		if len(orphanedTasks) > 0 {
 			warnings = append(warnings, fmt.Sprintf(
-				"tasks %v have depends_on but no pipeline is defined — these tasks will not run. Define a pipelines section to run them in DAG order.",
+				"tasks %v have depends_on but no pipeline is defined — these tasks will not run with a plain 'swarm up'. Define a pipelines section to run them in DAG order, or run them by name (e.g. 'swarm up <task>').",
 				orphanedTasks,
 			))
 		}
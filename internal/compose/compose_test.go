@@ -3,8 +3,13 @@ package compose
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/taskoutput"
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultPath(t *testing.T) {
@@ -287,6 +292,21 @@ func TestTaskValidate(t *testing.T) {
 			task:    Task{Prompt: "test", Iterations: 0},
 			wantErr: false,
 		},
+		{
+			name:    "worktree isolation with merge_back",
+			task:    Task{Prompt: "test", Isolation: IsolationWorktree, MergeBack: true},
+			wantErr: false,
+		},
+		{
+			name:    "unknown isolation",
+			task:    Task{Prompt: "test", Isolation: "container"},
+			wantErr: true,
+		},
+		{
+			name:    "merge_back without isolation",
+			task:    Task{Prompt: "test", MergeBack: true},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -889,6 +909,83 @@ pipelines:
 	}
 }
 
+func TestLoadPipelineUnlimitedWithStopWhen(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	content := `version: "1"
+tasks:
+  coder:
+    prompt: coder
+
+pipelines:
+  forever:
+    iterations: 0
+    stop_when:
+      file_exists: DONE
+      command: "make check"
+      budget: 5
+  default:
+    tasks: [coder]
+`
+	path := filepath.Join(tmpDir, "swarm.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	forever, err := cf.GetPipeline("forever")
+	if err != nil {
+		t.Fatalf("GetPipeline() error = %v", err)
+	}
+	if !forever.Unlimited || forever.EffectiveIterations() != 0 {
+		t.Errorf("explicit iterations: 0 should be unlimited, got Unlimited=%v EffectiveIterations=%d", forever.Unlimited, forever.EffectiveIterations())
+	}
+	if forever.StopWhen == nil {
+		t.Fatal("expected stop_when to be parsed")
+	}
+	if forever.StopWhen.FileExists != "DONE" || forever.StopWhen.Command != "make check" || forever.StopWhen.Budget != 5 {
+		t.Errorf("unexpected stop_when: %+v", *forever.StopWhen)
+	}
+
+	def, err := cf.GetPipeline("default")
+	if err != nil {
+		t.Fatalf("GetPipeline() error = %v", err)
+	}
+	if def.Unlimited || def.EffectiveIterations() != 1 {
+		t.Errorf("omitted iterations should default to 1, got Unlimited=%v EffectiveIterations=%d", def.Unlimited, def.EffectiveIterations())
+	}
+}
+
+func TestPipeline_ValidateStopWhen(t *testing.T) {
+	tasks := map[string]Task{"a": {Prompt: "a"}}
+
+	tests := []struct {
+		name    string
+		cond    *StopCondition
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"file", &StopCondition{FileExists: "DONE"}, false},
+		{"budget", &StopCondition{Budget: 2.5}, false},
+		{"empty", &StopCondition{}, true},
+		{"negative budget", &StopCondition{Budget: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Pipeline{StopWhen: tt.cond}
+			err := p.Validate("main", tasks)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestPipeline_EffectiveIterations(t *testing.T) {
 	tests := []struct {
 		iterations int
@@ -1531,3 +1628,458 @@ tasks:
 		t.Errorf("implementer EffectiveConcurrency() = %d, want 3", implementer.EffectiveConcurrency())
 	}
 }
+
+func TestLoadWithImage(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	content := `version: "1"
+tasks:
+  go-builder:
+    prompt: coder
+    image: golang:1.22
+    extra_args: ["--max-turns", "20"]
+  host-task:
+    prompt: coder
+`
+	path := filepath.Join(tmpDir, "swarm.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cf.Tasks["go-builder"].Image; got != "golang:1.22" {
+		t.Errorf("Image = %q, want %q", got, "golang:1.22")
+	}
+	if got := cf.Tasks["host-task"].Image; got != "" {
+		t.Errorf("Image should be empty, got %q", got)
+	}
+	if got := cf.Tasks["go-builder"].ExtraArgs; len(got) != 2 || got[0] != "--max-turns" || got[1] != "20" {
+		t.Errorf("ExtraArgs = %v, want [--max-turns 20]", got)
+	}
+}
+
+func TestWithDependencies(t *testing.T) {
+	cf := &ComposeFile{
+		Tasks: map[string]Task{
+			"planner":  {Prompt: "p"},
+			"coder":    {Prompt: "c", DependsOn: []Dependency{{Task: "planner"}}},
+			"tester":   {Prompt: "t", DependsOn: []Dependency{{Task: "coder"}}},
+			"reviewer": {Prompt: "r", DependsOn: []Dependency{{Task: "coder"}}},
+			"docs":     {Prompt: "d"},
+		},
+	}
+
+	got, err := cf.WithDependencies([]string{"tester", "docs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"coder", "docs", "planner", "tester"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("WithDependencies() = %v, want %v", got, want)
+	}
+
+	got, err = cf.WithDependencies([]string{"planner"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "planner" {
+		t.Errorf("WithDependencies() = %v, want [planner]", got)
+	}
+
+	if _, err := cf.WithDependencies([]string{"missing"}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestLoadWithOutputSchemaAndWhen(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	content := `version: "1"
+tasks:
+  tester:
+    prompt: tester
+    output_schema:
+      type: object
+      required: [status]
+      properties:
+        status: {type: string, enum: [pass, fail]}
+  fixer:
+    prompt: fixer
+    depends_on:
+      - task: tester
+        condition: success
+        when: status == fail
+pipelines:
+  main:
+    tasks: [tester, fixer]
+`
+	path := filepath.Join(tmpDir, "swarm.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	cf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cf.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	schema := cf.Tasks["tester"].OutputSchema
+	if schema == nil || schema.Type != "object" || schema.Properties["status"] == nil {
+		t.Fatalf("OutputSchema not loaded: %+v", schema)
+	}
+	if got := cf.Tasks["fixer"].DependsOn[0].When; got != "status == fail" {
+		t.Errorf("When = %q, want %q", got, "status == fail")
+	}
+}
+
+func TestValidate_OutputContractErrors(t *testing.T) {
+	bad := Task{Prompt: "p", DependsOn: []Dependency{{Task: "a", When: "status =="}}}
+	if err := bad.Validate("b"); err == nil || !strings.Contains(err.Error(), "invalid condition") {
+		t.Errorf("expected invalid when error, got %v", err)
+	}
+
+	bad = Task{Prompt: "p", OutputSchema: &taskoutput.Schema{Type: "dict"}}
+	if err := bad.Validate("a"); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Errorf("expected unsupported schema type error, got %v", err)
+	}
+}
+
+func TestValidate_MaxInjectedBytes(t *testing.T) {
+	task := Task{Prompt: "p", MaxInjectedBytes: -1}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "max_injected_bytes") {
+		t.Errorf("expected max_injected_bytes error, got %v", err)
+	}
+	task.MaxInjectedBytes = 4096
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_TaskLabels(t *testing.T) {
+	task := Task{Prompt: "p", Labels: map[string]string{"pool": "gpu-heavy"}}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	task.Labels = map[string]string{"pool": "gpu heavy"}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "invalid label value") {
+		t.Errorf("expected invalid label error, got %v", err)
+	}
+}
+
+func TestPipeline_WithFailureDefaults(t *testing.T) {
+	p := Pipeline{OnFailurePrompt: "triage", OnFailureAfter: 3}
+	tasks := map[string]Task{
+		"a": {Prompt: "a"},
+		"b": {Prompt: "b", OnFailurePrompt: "custom", OnFailureAfter: 1},
+	}
+
+	got := p.WithFailureDefaults(tasks)
+	if a := got["a"]; a.OnFailurePrompt != "triage" || a.EffectiveOnFailureAfter() != 3 {
+		t.Errorf("task a = %q after %d, want pipeline defaults", a.OnFailurePrompt, a.EffectiveOnFailureAfter())
+	}
+	if b := got["b"]; b.OnFailurePrompt != "custom" || b.EffectiveOnFailureAfter() != 1 {
+		t.Errorf("task b = %q after %d, want its own settings", b.OnFailurePrompt, b.EffectiveOnFailureAfter())
+	}
+	if tasks["a"].OnFailurePrompt != "" {
+		t.Error("WithFailureDefaults must not modify the input map")
+	}
+
+	task := Task{Prompt: "p", OnFailureAfter: -1}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "on_failure_after") {
+		t.Errorf("expected on_failure_after error, got %v", err)
+	}
+}
+
+func TestPipeline_WithEnvironment(t *testing.T) {
+	content := `version: "1"
+tasks:
+  planner:
+    prompt: planner
+  coder:
+    prompt: coder
+    env:
+      FEATURE_X: "off"
+      CODER_ONLY: "1"
+pipelines:
+  main:
+    environment:
+      API_URL: http://localhost:8080
+      FEATURE_X: "on"
+`
+	path := filepath.Join(t.TempDir(), "swarm.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	cf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cf.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	p := cf.Pipelines["main"]
+	got := p.WithEnvironment(cf.Tasks)
+	planner, coder := got["planner"], got["coder"]
+	if env := planner.EnvList(); strings.Join(env, " ") != "API_URL=http://localhost:8080 FEATURE_X=on" {
+		t.Errorf("planner env = %q, want the pipeline environment", env)
+	}
+	if env := coder.EnvList(); strings.Join(env, " ") != "API_URL=http://localhost:8080 CODER_ONLY=1 FEATURE_X=off" {
+		t.Errorf("coder env = %q, want its own env over the pipeline's", env)
+	}
+	if len(cf.Tasks["planner"].Env) != 0 {
+		t.Error("WithEnvironment must not modify the input map")
+	}
+
+	p.Environment = map[string]string{"BAD=NAME": "x"}
+	if err := p.Validate("main", cf.Tasks); err == nil || !strings.Contains(err.Error(), "environment") {
+		t.Errorf("expected environment error, got %v", err)
+	}
+	task := Task{Prompt: "p", Env: map[string]string{"": "x"}}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "env") {
+		t.Errorf("expected env error, got %v", err)
+	}
+}
+
+func TestValidate_FailOnResultRegex(t *testing.T) {
+	task := Task{Prompt: "p", FailOnSubtype: "error", FailOnResultRegex: "(?i)could not"}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	task.FailOnResultRegex = "("
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "fail_on_result_regex") {
+		t.Errorf("expected fail_on_result_regex error, got %v", err)
+	}
+}
+
+func TestValidate_PathPatterns(t *testing.T) {
+	task := Task{Prompt: "p", AllowedPaths: []string{"docs/**", "*.md"}, DeniedPaths: []string{"docs/private/"}}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	task.AllowedPaths = []string{"/etc/**"}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "allowed_paths") {
+		t.Errorf("expected allowed_paths error, got %v", err)
+	}
+	task.AllowedPaths = nil
+	task.DeniedPaths = []string{"[a"}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "denied_paths") {
+		t.Errorf("expected denied_paths error, got %v", err)
+	}
+}
+
+func TestValidate_ReadOnly(t *testing.T) {
+	task := Task{Prompt: "p", ReadOnly: true}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	guard, err := task.PathGuard("/repo")
+	if err != nil || guard == nil || !guard.ReadOnly {
+		t.Errorf("PathGuard() = %+v, %v, want a read-only guard", guard, err)
+	}
+
+	task.AllowedPaths = []string{"docs/**"}
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "read_only") {
+		t.Errorf("expected read_only error, got %v", err)
+	}
+}
+
+func TestValidate_HealthInterval(t *testing.T) {
+	task := Task{Prompt: "p", HealthCmd: "curl -fs localhost:3000/health", HealthInterval: "10s"}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := task.EffectiveHealthInterval(); got != 10*time.Second {
+		t.Errorf("EffectiveHealthInterval() = %v, want 10s", got)
+	}
+	for _, interval := range []string{"often", "0s", "-5s"} {
+		task.HealthInterval = interval
+		if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "health_interval") {
+			t.Errorf("expected health_interval error for %q, got %v", interval, err)
+		}
+	}
+}
+
+func TestValidate_Budgets(t *testing.T) {
+	task := Task{Prompt: "p", BudgetUSD: 5, BudgetTokens: 100000}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if b := task.Budget(); b == nil || b.USD != 5 || b.Tokens != 100000 {
+		t.Errorf("Budget() = %+v", b)
+	}
+	if b := (&Task{Prompt: "p"}).Budget(); b != nil {
+		t.Errorf("Budget() = %+v, want nil without a budget", b)
+	}
+	task.BudgetTokens = -1
+	if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Errorf("expected budget error, got %v", err)
+	}
+
+	pipeline := Pipeline{BudgetUSD: -2}
+	if err := pipeline.Validate("p", nil); err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Errorf("expected pipeline budget error, got %v", err)
+	}
+}
+
+func TestValidate_Params(t *testing.T) {
+	var task Task
+	if err := yaml.Unmarshal([]byte("prompt: p\nparams: {temperature: 0.2, thinking_budget: 8000}\n"), &task); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]string{"temperature": "0.2", "thinking_budget": "8000"}
+	if !reflect.DeepEqual(task.Params, want) {
+		t.Errorf("Params = %v, want %v", task.Params, want)
+	}
+	if err := task.Validate("a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, params := range []map[string]string{
+		{"temperature": "3"},
+		{"max_output_tokens": "lots"},
+		{"top_k": "40"},
+	} {
+		task.Params = params
+		if err := task.Validate("a"); err == nil || !strings.Contains(err.Error(), "params") {
+			t.Errorf("expected params error for %v, got %v", params, err)
+		}
+	}
+}
+
+func TestComposeFile_LoadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	taskEnv := filepath.Join(dir, "coder.env")
+	pipelineEnv := filepath.Join(dir, "shared.env")
+	if err := os.WriteFile(taskEnv, []byte("FEATURE_X=off\nTOKEN=\"abc\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pipelineEnv, []byte("# shared\nAPI_URL=http://localhost:8080\nREGION=us\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cf := &ComposeFile{
+		Tasks: map[string]Task{
+			"coder": {Prompt: "coder", EnvFile: taskEnv, Env: map[string]string{"TOKEN": "override"}},
+		},
+		Pipelines: map[string]Pipeline{
+			"main": {Tasks: []string{"coder"}, EnvFile: pipelineEnv, Environment: map[string]string{"REGION": "eu", "FEATURE_X": "on"}},
+		},
+	}
+	if err := cf.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := cf.LoadEnvFiles(); err != nil {
+		t.Fatalf("LoadEnvFiles() error = %v", err)
+	}
+
+	p := cf.Pipelines["main"]
+	coder := p.WithEnvironment(cf.Tasks)["coder"]
+	if env := strings.Join(coder.EnvList(), " "); env != "API_URL=http://localhost:8080 FEATURE_X=off REGION=eu TOKEN=override" {
+		t.Errorf("coder env = %q", env)
+	}
+
+	missing := Task{Prompt: "p", EnvFile: filepath.Join(dir, "missing.env")}
+	if err := missing.Validate("a"); err == nil || !strings.Contains(err.Error(), "env_file") {
+		t.Errorf("expected env_file error, got %v", err)
+	}
+	p.EnvFile = filepath.Join(dir, "missing.env")
+	if err := p.Validate("main", cf.Tasks); err == nil || !strings.Contains(err.Error(), "env_file") {
+		t.Errorf("expected pipeline env_file error, got %v", err)
+	}
+}
+
+func TestPipeline_MatrixInstances(t *testing.T) {
+	p := Pipeline{Matrix: map[string][]string{"module": {"auth", "billing"}, "os": {"linux", "mac"}}}
+	tasks := map[string]Task{"review": {Prompt: "review"}}
+	if err := p.Validate("review", tasks); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	var names []string
+	for _, inst := range p.MatrixInstances("review") {
+		names = append(names, inst.Name)
+	}
+	want := []string{"review@auth-linux", "review@auth-mac", "review@billing-linux", "review@billing-mac"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("instances = %v, want %v", names, want)
+	}
+	if v := p.MatrixInstances("review")[1].Values; v["module"] != "auth" || v["os"] != "mac" {
+		t.Errorf("second instance values = %v", v)
+	}
+	if (&Pipeline{}).MatrixInstances("x") != nil {
+		t.Error("a pipeline without a matrix has no matrix instances")
+	}
+
+	for _, bad := range []Pipeline{
+		{Matrix: map[string][]string{"module": {"auth"}}, Parallelism: 2},
+		{Matrix: map[string][]string{"module": {}}},
+		{Matrix: map[string][]string{"module": {"auth", "auth"}}},
+		{Matrix: map[string][]string{"module": {"has space"}}},
+		{Matrix: map[string][]string{"bad.key": {"auth"}}},
+	} {
+		if err := bad.Validate("review", tasks); err == nil || !strings.Contains(err.Error(), "matrix") {
+			t.Errorf("Validate(%v) = %v, want a matrix error", bad.Matrix, err)
+		}
+	}
+}
+
+func TestGetScheduled(t *testing.T) {
+	cf := &ComposeFile{
+		Tasks: map[string]Task{
+			"report": {Prompt: "report", Schedule: "0 9 * * mon"},
+			"coder":  {Prompt: "coder"},
+			"audit":  {Prompt: "audit", Schedule: "@daily"},
+		},
+		Pipelines: map[string]Pipeline{
+			"nightly": {Schedule: "0 2 * * *"},
+			"manual":  {},
+		},
+	}
+	if err := cf.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	var keys []string
+	for _, s := range cf.GetScheduled() {
+		keys = append(keys, s.Key())
+	}
+	want := []string{"pipeline:nightly", "task:audit", "task:report"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("GetScheduled = %v, want %v", keys, want)
+	}
+
+	cf.Tasks["coder"] = Task{Prompt: "coder", Schedule: "every night"}
+	if err := cf.Validate(); err == nil || !strings.Contains(err.Error(), `task "coder"`) {
+		t.Errorf("Validate with a bad task schedule = %v", err)
+	}
+	delete(cf.Tasks, "coder")
+	cf.Pipelines["manual"] = Pipeline{Schedule: "0 25 * * *"}
+	if err := cf.Validate(); err == nil || !strings.Contains(err.Error(), `pipeline "manual"`) {
+		t.Errorf("Validate with a bad pipeline schedule = %v", err)
+	}
+}
+
+func TestValidate_MaxPending(t *testing.T) {
+	cf := &ComposeFile{
+		Version: "1",
+		Tasks: map[string]Task{
+			"planner": {Prompt: "planner", MaxPending: 5},
+		},
+	}
+	if err := cf.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	cf.Tasks["planner"] = Task{Prompt: "planner", MaxPending: -1}
+	if err := cf.Validate(); err == nil || !strings.Contains(err.Error(), "max_pending cannot be negative") {
+		t.Errorf("Validate with negative max_pending = %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// interpolatedTaskKeys are the task keys whose values are interpolated, besides
+// the values of env.
+var interpolatedTaskKeys = map[string]bool{
+	"model":         true,
+	"prompt-string": true,
+	"prefix":        true,
+	"suffix":        true,
+}
+
+// UndefinedVariableError lists the variables a compose file references
+// without a default that aren't set, in strict mode.
+type UndefinedVariableError struct {
+	Names []string
+}
+
+func (e *UndefinedVariableError) Error() string {
+	return fmt.Sprintf("undefined environment variable(s): %s (set them or give a default with ${VAR:-default})", strings.Join(e.Names, ", "))
+}
+
+// interpolator substitutes environment variables into compose file values.
+type interpolator struct {
+	lookup    func(string) (string, bool)
+	undefined map[string]bool // variables referenced without a default that aren't set
+}
+
+// interpolateDocument substitutes ${VAR} and ${VAR:-default} in the values of
+// the document's task model, prompt-string, prefix, suffix, and env, and of
+// its pipelines' environment. Unset variables without a default become "";
+// their names are returned, sorted.
+func interpolateDocument(doc *yaml.Node, lookup func(string) (string, bool)) ([]string, error) {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	in := &interpolator{lookup: lookup, undefined: make(map[string]bool)}
+
+	root := doc
+	for root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	var err error
+	eachMapValue(mappingValue(root, "tasks"), func(name string, task *yaml.Node) {
+		eachMapValue(task, func(key string, value *yaml.Node) {
+			switch {
+			case err != nil:
+			case interpolatedTaskKeys[key]:
+				err = in.node(value, "tasks."+name+"."+key)
+			case key == "env":
+				eachMapValue(value, func(env string, v *yaml.Node) {
+					if err == nil {
+						err = in.node(v, "tasks."+name+".env."+env)
+					}
+				})
+			}
+		})
+	})
+	eachMapValue(mappingValue(root, "pipelines"), func(name string, pipeline *yaml.Node) {
+		eachMapValue(mappingValue(pipeline, "environment"), func(env string, v *yaml.Node) {
+			if err == nil {
+				err = in.node(v, "pipelines."+name+".environment."+env)
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(in.undefined))
+	for name := range in.undefined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// node interpolates a scalar node's value in place; path locates it in
+// errors.
+func (in *interpolator) node(n *yaml.Node, path string) error {
+	if n.Kind != yaml.ScalarNode || !strings.Contains(n.Value, "$") {
+		return nil
+	}
+	value, err := in.expand(n.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %s: %w", n.Line, path, err)
+	}
+	if value != n.Value {
+		n.Value = value
+		// Keep a substituted value a string, e.g. a model "${V}" set to "4"
+		n.Tag = "!!str"
+		if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			n.Style = yaml.DoubleQuotedStyle
+		}
+	}
+	return nil
+}
+
+// expand substitutes the variables in s. "$$" is a literal "$".
+func (in *interpolator) expand(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '$' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference %q", s[i:])
+			}
+			value, err := in.variable(s[i+2 : i+2+end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += 2 + end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// variable returns the value of a reference's body: "VAR" or "VAR:-default".
+// The default is used when VAR is unset or empty.
+func (in *interpolator) variable(body string) (string, error) {
+	name, def, hasDefault := strings.Cut(body, ":-")
+	if !validVariableName(name) {
+		return "", fmt.Errorf("invalid variable reference ${%s}", body)
+	}
+	value, ok := in.lookup(name)
+	if hasDefault {
+		if value == "" {
+			return def, nil
+		}
+		return value, nil
+	}
+	if !ok {
+		in.undefined[name] = true
+	}
+	return value, nil
+}
+
+// validVariableName reports whether name is a valid environment variable name.
+func validVariableName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// eachMapValue calls fn with each key and value of a mapping node.
+func eachMapValue(n *yaml.Node, fn func(key string, value *yaml.Node)) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		fn(n.Content[i].Value, n.Content[i+1])
+	}
+}
@@ -0,0 +1,114 @@
+package compose
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestInterpolatorExpand(t *testing.T) {
+	in := &interpolator{
+		lookup:    lookupFrom(map[string]string{"MODEL": "opus", "EMPTY": ""}),
+		undefined: make(map[string]bool),
+	}
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"${MODEL}", "opus"},
+		{"use ${MODEL}-4", "use opus-4"},
+		{"${MISSING:-sonnet}", "sonnet"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${MODEL:-sonnet}", "opus"},
+		{"${MISSING}", ""},
+		{"costs $$5 and $HOME", "costs $5 and $HOME"},
+		{"$${MODEL}", "${MODEL}"},
+		{"trailing $", "trailing $"},
+	} {
+		got, err := in.expand(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("expand(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(in.undefined, map[string]bool{"MISSING": true}) {
+		t.Errorf("undefined = %v, want only MISSING", in.undefined)
+	}
+
+	for _, bad := range []string{"${MODEL", "${}", "${1X}", "${A B}"} {
+		if _, err := in.expand(bad); err == nil {
+			t.Errorf("expand(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoadInterpolation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.yaml")
+	content := `version: "1"
+tasks:
+  coder:
+    prompt-string: "Fix issues in ${REPO}"
+    model: ${MODEL:-sonnet}
+    prefix: ${PREFIX}
+    extra_args: ["${NOT_INTERPOLATED}"]
+    env:
+      API_URL: http://${HOST:-localhost}:8080
+pipelines:
+  main:
+    tasks: [coder]
+    environment:
+      TOKEN: ${TOKEN}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := lookupFrom(map[string]string{"REPO": "api", "HOST": "db", "TOKEN": "123"})
+
+	cf, err := LoadWithOptions(path, LoadOptions{LookupEnv: env})
+	if err != nil {
+		t.Fatalf("LoadWithOptions failed: %v", err)
+	}
+	task := cf.Tasks["coder"]
+	if task.PromptString != "Fix issues in api" || task.Model != "sonnet" || task.Prefix != "" || task.Env["API_URL"] != "http://db:8080" {
+		t.Errorf("task = %+v", task)
+	}
+	if task.ExtraArgs[0] != "${NOT_INTERPOLATED}" {
+		t.Errorf("extra_args were interpolated: %v", task.ExtraArgs)
+	}
+	// A number substituted into a string field stays a string
+	if got := cf.Pipelines["main"].Environment["TOKEN"]; got != "123" {
+		t.Errorf("pipeline environment TOKEN = %q", got)
+	}
+	if got := cf.UndefinedVariables(); !reflect.DeepEqual(got, []string{"PREFIX"}) {
+		t.Errorf("UndefinedVariables() = %v, want [PREFIX]", got)
+	}
+
+	_, err = LoadWithOptions(path, LoadOptions{StrictEnv: true, LookupEnv: env})
+	var undefined *UndefinedVariableError
+	if !errors.As(err, &undefined) || !reflect.DeepEqual(undefined.Names, []string{"PREFIX"}) {
+		t.Errorf("strict load error = %v, want PREFIX undefined", err)
+	}
+
+	data, names, err := Resolve(path, LoadOptions{LookupEnv: env})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	for _, want := range []string{`prompt-string: "Fix issues in api"`, `model: "sonnet"`, `API_URL: "http://db:8080"`, `TOKEN: "123"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Resolve() missing %q:\n%s", want, data)
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"PREFIX"}) {
+		t.Errorf("Resolve() undefined = %v", names)
+	}
+}
@@ -0,0 +1,104 @@
+package compose
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// schemaID identifies the JSON Schema of swarm compose files.
+const schemaID = "https://github.com/mj1618/swarm-cli/schema/swarm.schema.json"
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing swarm.yaml,
+// for YAML language servers to validate and autocomplete compose files.
+// It is generated from the compose types, so new fields appear automatically.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	root := g.structSchema(reflect.TypeOf(ComposeFile{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = schemaID
+	root["title"] = "swarm compose file"
+	root["$defs"] = g.defs
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// schemaGenerator builds JSON Schemas for Go types, collecting named structs
+// in $defs so recursive types like taskoutput.Schema terminate.
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(Dependency{}) {
+		return g.ref(t, g.dependencySchema)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.ref(t, g.structSchema)
+	}
+	// interface{} and other types accept any value
+	return map[string]interface{}{}
+}
+
+// ref adds the schema of a named type to $defs, once, and returns a
+// reference to it.
+func (g *schemaGenerator) ref(t reflect.Type, build func(reflect.Type) map[string]interface{}) map[string]interface{} {
+	name := t.Name()
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = nil // placeholder for recursive references
+		g.defs[name] = build(t)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// structSchema describes a struct by its YAML fields. Compose types reject
+// unknown keys as Load does; other types, like the JSON Schema of
+// output_schema, allow them.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for name, field := range yamlFields(t) {
+		properties[name] = g.schemaFor(field.Type)
+	}
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": t.PkgPath() != composePkgPath,
+	}
+	if t == reflect.TypeOf(Task{}) {
+		s["oneOf"] = []interface{}{
+			map[string]interface{}{"required": []string{"prompt"}},
+			map[string]interface{}{"required": []string{"prompt-file"}},
+			map[string]interface{}{"required": []string{"prompt-string"}},
+		}
+	}
+	return s
+}
+
+// dependencySchema describes a dependency, which is either a task name or an
+// object with a condition.
+func (g *schemaGenerator) dependencySchema(t reflect.Type) map[string]interface{} {
+	object := g.structSchema(t)
+	object["properties"].(map[string]interface{})["condition"] = map[string]interface{}{
+		"enum": []string{ConditionSuccess, ConditionFailure, ConditionAny, ConditionAlways},
+	}
+	object["required"] = []string{"task"}
+	return map[string]interface{}{
+		"oneOf": []interface{}{map[string]interface{}{"type": "string"}, object},
+	}
+}
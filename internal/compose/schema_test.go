@@ -0,0 +1,57 @@
+package compose
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]interface{} `json:"properties"`
+		Defs       map[string]struct {
+			Properties           map[string]interface{} `json:"properties"`
+			AdditionalProperties bool                   `json:"additionalProperties"`
+			OneOf                []interface{}          `json:"oneOf"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	for _, key := range []string{"version", "tasks", "pipelines"} {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("missing top-level property %q", key)
+		}
+	}
+
+	// Every YAML field of the compose types is described
+	for _, typ := range []reflect.Type{reflect.TypeOf(Task{}), reflect.TypeOf(Pipeline{}), reflect.TypeOf(StopCondition{})} {
+		def, ok := schema.Defs[typ.Name()]
+		if !ok {
+			t.Fatalf("missing $defs entry for %s", typ.Name())
+		}
+		if def.AdditionalProperties {
+			t.Errorf("%s should reject unknown properties", typ.Name())
+		}
+		for name := range yamlFields(typ) {
+			if _, ok := def.Properties[name]; !ok {
+				t.Errorf("%s is missing property %q", typ.Name(), name)
+			}
+		}
+	}
+	if _, ok := schema.Defs["Pipeline"].Properties["Unlimited"]; ok {
+		t.Error("fields tagged yaml:\"-\" should be omitted")
+	}
+
+	if len(schema.Defs["Dependency"].OneOf) != 2 {
+		t.Errorf("Dependency should accept a task name or an object, got %v", schema.Defs["Dependency"].OneOf)
+	}
+	if def, ok := schema.Defs["Schema"]; !ok || !def.AdditionalProperties {
+		t.Error("output_schema should allow JSON Schema keywords swarm ignores")
+	}
+}
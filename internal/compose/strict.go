@@ -0,0 +1,166 @@
+package compose
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownField is a key in a compose file that doesn't match any field.
+type UnknownField struct {
+	Line       int
+	Path       string // where the key appears, e.g. "tasks.coder"
+	Key        string
+	Suggestion string // the closest known key, if any
+}
+
+// UnknownFieldsError lists the unknown keys found in a compose file.
+type UnknownFieldsError struct {
+	Fields []UnknownField
+}
+
+func (e *UnknownFieldsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d unknown field(s):", len(e.Fields))
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "\n  line %d: %q", f.Line, f.Key)
+		if f.Path != "" {
+			fmt.Fprintf(&b, " in %s", f.Path)
+		}
+		if f.Suggestion != "" {
+			fmt.Fprintf(&b, " (did you mean %q?)", f.Suggestion)
+		}
+	}
+	return b.String()
+}
+
+// composePkgPath is the import path of this package. Only its types are
+// checked for unknown keys; others, like the JSON Schema of output_schema,
+// may carry keys swarm ignores.
+var composePkgPath = reflect.TypeOf(ComposeFile{}).PkgPath()
+
+// checkKnownFields returns an *UnknownFieldsError if the document has keys
+// that don't match any field of ComposeFile.
+func checkKnownFields(doc *yaml.Node) error {
+	var unknown []UnknownField
+	walkKnownFields(doc, reflect.TypeOf(ComposeFile{}), "", &unknown)
+	if len(unknown) > 0 {
+		return &UnknownFieldsError{Fields: unknown}
+	}
+	return nil
+}
+
+// walkKnownFields records the keys of node that have no matching field in t,
+// recursing into known fields, map values, and list items.
+func walkKnownFields(node *yaml.Node, t reflect.Type, path string, unknown *[]UnknownField) {
+	for node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		if t.PkgPath() != composePkgPath {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				*unknown = append(*unknown, UnknownField{
+					Line:       key.Line,
+					Path:       path,
+					Key:        key.Value,
+					Suggestion: closestKey(key.Value, fields),
+				})
+				continue
+			}
+			walkKnownFields(value, field.Type, joinPath(path, key.Value), unknown)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkKnownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), unknown)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			walkKnownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of a struct type to their fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closestKey returns the known key most like key, such as "depends_on" for
+// "depends-on" or "iterations" for "iteration", or "" if none is close.
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(s))
+	}
+	best, bestDist := "", 3
+	for name := range fields {
+		if normalize(name) == normalize(key) {
+			return name
+		}
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,78 @@
+package compose
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad_UnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swarm.yaml")
+	content := `version: "1"
+tasks:
+  coder:
+    prompt: coder
+    iteration: 5
+    depends-on: [planner]
+  planner:
+    prompt: planner
+    output_schema:
+      type: object
+      description: JSON Schema keywords swarm ignores are allowed
+pipelines:
+  main:
+    tasks: [planner, coder]
+    stop_when:
+      budgett: 5
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Load() error = %v, want an UnknownFieldsError", err)
+	}
+	want := []UnknownField{
+		{Line: 5, Path: "tasks.coder", Key: "iteration", Suggestion: "iterations"},
+		{Line: 6, Path: "tasks.coder", Key: "depends-on", Suggestion: "depends_on"},
+		{Line: 16, Path: "pipelines.main.stop_when", Key: "budgett", Suggestion: "budget"},
+	}
+	if !reflect.DeepEqual(unknown.Fields, want) {
+		t.Errorf("unknown fields = %+v, want %+v", unknown.Fields, want)
+	}
+
+	cf, err := LoadLenient(path)
+	if err != nil {
+		t.Fatalf("LoadLenient() error = %v", err)
+	}
+	if cf.Tasks["coder"].Iterations != 0 {
+		t.Errorf("expected unknown keys to be ignored, got iterations %d", cf.Tasks["coder"].Iterations)
+	}
+}
+
+func TestLoad_KnownFieldsInDependencies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swarm.yaml")
+	content := `tasks:
+  a:
+    prompt: a
+  b:
+    prompt: b
+    depends_on:
+      - a
+      - task: a
+        condtion: success
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) || len(unknown.Fields) != 1 || unknown.Fields[0].Suggestion != "condition" || unknown.Fields[0].Path != "tasks.b.depends_on[1]" {
+		t.Errorf("Load() error = %v, want unknown field condtion", err)
+	}
+}
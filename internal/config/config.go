@@ -3,10 +3,17 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/mj1618/swarm-cli/internal/label"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
+	"github.com/mj1618/swarm-cli/internal/version"
 )
 
 // Backend constants
@@ -14,6 +21,11 @@ const (
 	BackendCursor     = "cursor"
 	BackendClaudeCode = "claude-code"
 	BackendCodex      = "codex"
+
+	// BackendSimulate plays scripted runs instead of calling an agent CLI
+	// (see package simulate). It's selected with the hidden --simulate flag
+	// and not listed in ValidBackends.
+	BackendSimulate = "simulate"
 )
 
 // Config holds the application configuration.
@@ -44,6 +56,385 @@ type Config struct {
 	// the `--system-prompt` flag). When empty, no `--system-prompt` flag is
 	// added to the agent invocation.
 	SystemPrompt string `toml:"system_prompt"`
+
+	// StateDir is where pipeline iteration state directories (SWARM_STATE_DIR)
+	// are created, e.g. a larger disk or a shared NFS path for multi-host runs.
+	// Relative paths are resolved against the working directory. Empty uses a
+	// directory under the system temp dir.
+	StateDir string `toml:"state_dir"`
+
+	// TodoDir and DoneDir are the todo-file queue's directories: planners
+	// write <name>.todo.md files to TodoDir, doers claim one by renaming it to
+	// .processing.md and move it to DoneDir when finished. Relative paths are
+	// resolved against the working directory. Empty uses swarm/todos and
+	// swarm/done. When TodoDir exists, queue depth, claim age, and throughput
+	// are tracked and shown in top and stats.
+	TodoDir string `toml:"todo_dir"`
+	DoneDir string `toml:"done_dir"`
+
+	// ScratchRetention is how long the scratch dirs (SWARM_SCRATCH_DIR) of
+	// terminated agents are kept, e.g. "24h". Empty removes an agent's
+	// scratch dir when it terminates.
+	ScratchRetention string `toml:"scratch_retention"`
+
+	// MaxAgentAge is the longest an agent may run, e.g. "24h". Older agents
+	// are stopped after their current iteration and flagged (exit reason
+	// max_age), catching forgotten forever-agents. Empty doesn't limit.
+	MaxAgentAge string `toml:"max_agent_age"`
+
+	// MinSwarmVersion and MinAgentVersion pin the oldest swarm and agent CLI
+	// versions a project works with, e.g. "1.4.0". Commands fail on an older
+	// swarm; commands that start agents also fail on an older agent CLI (as
+	// reported by its --version). Empty doesn't check.
+	MinSwarmVersion string `toml:"min_swarm_version"`
+	MinAgentVersion string `toml:"min_agent_version"`
+
+	// HookRetries is how many more times a failing hook (on-complete,
+	// on-failure, on-timeout) is run before giving up. 0 runs it once.
+	HookRetries int `toml:"hook_retries"`
+
+	// HookRetryDelay is how long to wait before retrying a failed hook, e.g.
+	// "10s", doubled for each further retry. Empty uses 5s.
+	HookRetryDelay string `toml:"hook_retry_delay"`
+
+	// MaxPromptTokens is the estimated token count above which a prompt is
+	// reported before an iteration starts, after prefix/suffix, stdin, and
+	// {{output:...}} content have been applied. 0 disables the check.
+	MaxPromptTokens int `toml:"max_prompt_tokens"`
+
+	// PromptLimitAction is what happens when a prompt exceeds MaxPromptTokens:
+	// "warn" (default) starts the iteration anyway, "fail" fails it without
+	// launching the agent.
+	PromptLimitAction string `toml:"prompt_limit_action"`
+
+	// ListColumns and TopColumns are the columns `swarm list` and `swarm top`
+	// show when --columns isn't given, e.g. ["id", "name:30", "cost", "task"]
+	// (":N" sets a column's width). Empty uses the default columns.
+	ListColumns []string `toml:"list_columns"`
+	TopColumns  []string `toml:"top_columns"`
+
+	// DedupLogs collapses identical consecutive log events, e.g. from an agent
+	// stuck in a retry loop, into "previous message repeated N times" in
+	// pretty log output and top's log panel.
+	DedupLogs bool `toml:"dedup_logs"`
+
+	// LogsTail and LogsPretty are the defaults of `swarm logs` --tail (0 = 50
+	// lines) and --pretty, for users who always want the same view.
+	LogsTail   int  `toml:"logs_tail"`
+	LogsPretty bool `toml:"logs_pretty"`
+
+	// LogTimezone and LogTimeFormat control how log timestamps are rendered
+	// and how absolute --since/--until times are read: an IANA zone name such
+	// as "UTC" or "Europe/Berlin" (empty = local time), and a Go time layout
+	// (empty = RFC 3339).
+	LogTimezone   string `toml:"log_timezone"`
+	LogTimeFormat string `toml:"log_time_format"`
+
+	// TopLogLines is how many lines top's log panel shows (0 = sized to the
+	// terminal).
+	TopLogLines int `toml:"top_log_lines"`
+
+	// SlowIterationFactor flags an agent's iteration as slow once it has run
+	// this many times longer than the agent's median iteration (0 = default of
+	// DefaultSlowIterationFactor). Long-tail iterations usually mean the agent
+	// is stuck in a loop.
+	SlowIterationFactor float64 `toml:"slow_iteration_factor"`
+
+	// SlowIterationAction is what happens to a slow iteration: "warn"
+	// (default) flags it in state and top, "fail" also stops the iteration as
+	// if it had timed out, and "off" disables the check.
+	SlowIterationAction string `toml:"slow_iteration_action"`
+
+	// AlertCostUSD is the cumulative cost of a project's agents at which swarm
+	// sends a cost alert notification and top shows a banner. Unlike a
+	// pipeline's stop_when budget nothing is stopped. 0 disables the alert.
+	AlertCostUSD float64 `toml:"alert_cost_usd"`
+
+	// NotifyDesktop sends notifications (e.g. cost alerts) as desktop
+	// notifications via notify-send or osascript.
+	NotifyDesktop bool `toml:"notify_desktop"`
+
+	// SlackWebhookURL is a Slack incoming webhook that notifications are
+	// posted to (empty = no Slack notifications).
+	SlackWebhookURL string `toml:"slack_webhook_url"`
+
+	// NotifyCmd is a shell command run for every notification, with the event
+	// as JSON on stdin, e.g. to forward events to PagerDuty, ntfy, or email
+	// (empty = none).
+	NotifyCmd string `toml:"notify_cmd"`
+
+	// MetricsAddr is the address `swarm serve` exposes Prometheus metrics
+	// on when --metrics isn't given, e.g. ":9900" (empty = no metrics).
+	MetricsAddr string `toml:"metrics_addr"`
+
+	// Kubernetes holds settings for running each agent iteration as a
+	// Kubernetes Job instead of a local process.
+	Kubernetes KubernetesConfig `toml:"kubernetes"`
+
+	// Pools are named concurrency pools (pool name -> pool). A pipeline task
+	// whose labels match a pool takes one of the pool's slots while it runs,
+	// across all pipelines on the machine.
+	Pools map[string]*PoolConfig `toml:"pools"`
+
+	// DefaultLabels are labels applied to every agent started with this
+	// config (e.g. repo=payments, owner=alice), under any given with -l.
+	// Project labels add to or override global ones.
+	DefaultLabels map[string]string `toml:"default_labels"`
+
+	// EnvFileVars holds the names of variables loaded from swarm/.env and
+	// --env-file. It is set by the CLI after loading and never read from TOML.
+	EnvFileVars []string `toml:"-"`
+}
+
+// PoolConfig is a concurrency pool shared by the tasks it selects by label.
+type PoolConfig struct {
+	// Labels selects member tasks ("key=value" or "key"; all must match).
+	// Empty selects tasks labeled pool=<pool name>.
+	Labels []string `toml:"labels"`
+
+	// Max is how many member tasks may run at once
+	Max int `toml:"max"`
+}
+
+// Pool is a concurrency pool a task belongs to.
+type Pool struct {
+	Name string
+	Max  int
+}
+
+// PoolsFor returns the pools whose labels match a task's labels, sorted by
+// name so slots are always acquired in the same order.
+func (c *Config) PoolsFor(labels map[string]string) []Pool {
+	var pools []Pool
+	for name, p := range c.Pools {
+		filters, err := p.filters(name)
+		if err != nil || !label.Match(labels, filters) {
+			continue
+		}
+		pools = append(pools, Pool{Name: name, Max: p.Max})
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	return pools
+}
+
+// filters returns the label filters selecting the pool's member tasks.
+func (p *PoolConfig) filters(name string) (map[string]string, error) {
+	if len(p.Labels) == 0 {
+		return map[string]string{"pool": name}, nil
+	}
+	return label.ParseMultiple(p.Labels)
+}
+
+// Validate checks the pool configuration.
+func (p *PoolConfig) Validate(name string) error {
+	if p.Max <= 0 {
+		return fmt.Errorf("pool %q: max must be positive", name)
+	}
+	if _, err := p.filters(name); err != nil {
+		return fmt.Errorf("pool %q: %w", name, err)
+	}
+	return nil
+}
+
+// KubernetesConfig holds the configuration for the Kubernetes job backend.
+// When Enabled is true, every agent iteration is scheduled as a Job and its
+// pod logs are streamed back through the normal output pipeline.
+type KubernetesConfig struct {
+	// Enabled switches agent execution from local processes to Kubernetes Jobs
+	Enabled bool `toml:"enabled"`
+
+	// Image is the container image that provides the agent CLI
+	Image string `toml:"image"`
+
+	// Namespace is the namespace Jobs are created in (empty uses the kubectl context default)
+	Namespace string `toml:"namespace"`
+
+	// Secrets is a list of Secret names exposed to the agent container via envFrom
+	Secrets []string `toml:"secrets"`
+
+	// CPU is the CPU request and limit for the agent container (e.g., "2")
+	CPU string `toml:"cpu"`
+
+	// Memory is the memory request and limit for the agent container (e.g., "4Gi")
+	Memory string `toml:"memory"`
+
+	// ServiceAccount is the service account the agent pod runs as (optional)
+	ServiceAccount string `toml:"service_account"`
+
+	// PVC is a PersistentVolumeClaim holding the repository the agent works
+	// on, mounted at MountPath (optional). Without it the image must contain
+	// the repository.
+	PVC string `toml:"pvc"`
+
+	// MountPath is where PVC is mounted in the agent container (default "/workspace")
+	MountPath string `toml:"mount_path"`
+
+	// WorkingDir is the agent container's working directory, e.g. the
+	// repository inside the image (default MountPath when PVC is set)
+	WorkingDir string `toml:"working_dir"`
+
+	// Kubectl is the kubectl executable to use (default "kubectl")
+	Kubectl string `toml:"kubectl"`
+}
+
+// KubectlPath returns the kubectl executable, defaulting to "kubectl".
+func (k *KubernetesConfig) KubectlPath() string {
+	if k.Kubectl == "" {
+		return "kubectl"
+	}
+	return k.Kubectl
+}
+
+// DefaultKubernetesMountPath is where the PVC is mounted when mount_path is unset.
+const DefaultKubernetesMountPath = "/workspace"
+
+// EffectiveMountPath returns where PVC is mounted, or "" without a PVC.
+func (k *KubernetesConfig) EffectiveMountPath() string {
+	if k.PVC == "" {
+		return ""
+	}
+	if k.MountPath == "" {
+		return DefaultKubernetesMountPath
+	}
+	return k.MountPath
+}
+
+// EffectiveWorkingDir returns the agent container's working directory, or ""
+// to use the image's default.
+func (k *KubernetesConfig) EffectiveWorkingDir() string {
+	if k.WorkingDir != "" {
+		return k.WorkingDir
+	}
+	return k.EffectiveMountPath()
+}
+
+// Validate checks the Kubernetes configuration for errors.
+func (k *KubernetesConfig) Validate() error {
+	if !k.Enabled {
+		return nil
+	}
+	if k.Image == "" {
+		return fmt.Errorf("kubernetes: image is required when enabled")
+	}
+	if k.MountPath != "" && k.PVC == "" {
+		return fmt.Errorf("kubernetes: mount_path requires pvc")
+	}
+	if k.MountPath != "" && !path.IsAbs(k.MountPath) {
+		return fmt.Errorf("kubernetes: mount_path must be an absolute path, got %q", k.MountPath)
+	}
+	if k.WorkingDir != "" && !path.IsAbs(k.WorkingDir) {
+		return fmt.Errorf("kubernetes: working_dir must be an absolute path, got %q", k.WorkingDir)
+	}
+	return nil
+}
+
+// Values of Config.PromptLimitAction.
+const (
+	PromptLimitWarn = "warn"
+	PromptLimitFail = "fail"
+)
+
+// TodoQueueDirs returns the todo-file queue's todo and done directories,
+// resolved against workingDir.
+func (c *Config) TodoQueueDirs(workingDir string) (todoDir, doneDir string) {
+	todoDir, doneDir = todoqueue.DefaultTodoDir, todoqueue.DefaultDoneDir
+	if c != nil && c.TodoDir != "" {
+		todoDir = c.TodoDir
+	}
+	if c != nil && c.DoneDir != "" {
+		doneDir = c.DoneDir
+	}
+	if !filepath.IsAbs(todoDir) {
+		todoDir = filepath.Join(workingDir, todoDir)
+	}
+	if !filepath.IsAbs(doneDir) {
+		doneDir = filepath.Join(workingDir, doneDir)
+	}
+	return todoDir, doneDir
+}
+
+// LogLocation returns the time zone log timestamps are shown in (local time
+// unless log_timezone is set).
+func (c *Config) LogLocation() *time.Location {
+	if c == nil || c.LogTimezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.LogTimezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ScratchRetentionDuration returns how long terminated agents' scratch dirs
+// are kept (0 = removed on termination).
+func (c *Config) ScratchRetentionDuration() time.Duration {
+	if c == nil || c.ScratchRetention == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(c.ScratchRetention) // validated on load
+	return d
+}
+
+// MaxAgentAgeDuration returns the longest an agent may run (0 = no limit).
+func (c *Config) MaxAgentAgeDuration() time.Duration {
+	if c == nil || c.MaxAgentAge == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(c.MaxAgentAge) // validated on load
+	return d
+}
+
+// DefaultHookRetryDelay is the wait before a hook's first retry when
+// hook_retry_delay isn't set.
+const DefaultHookRetryDelay = 5 * time.Second
+
+// HookRetryDelayDuration returns how long to wait before retrying a failed
+// hook for the first time.
+func (c *Config) HookRetryDelayDuration() time.Duration {
+	if c == nil || c.HookRetryDelay == "" {
+		return DefaultHookRetryDelay
+	}
+	d, _ := time.ParseDuration(c.HookRetryDelay) // validated on load
+	return d
+}
+
+// FailOnPromptLimit reports whether a prompt exceeding MaxPromptTokens should
+// fail the iteration rather than only produce a warning.
+func (c *Config) FailOnPromptLimit() bool {
+	return c.PromptLimitAction == PromptLimitFail
+}
+
+// Values of Config.SlowIterationAction.
+const (
+	SlowIterationWarn = "warn"
+	SlowIterationFail = "fail"
+	SlowIterationOff  = "off"
+)
+
+// DefaultSlowIterationFactor is the multiple of an agent's median iteration
+// duration after which an iteration is flagged as slow.
+const DefaultSlowIterationFactor = 3.0
+
+// SlowIterationLimit returns how long an iteration may run before it is
+// flagged as slow, given the median duration of the agent's previous
+// iterations. It returns 0 (no check) when the check is off or there is no
+// median yet.
+func (c *Config) SlowIterationLimit(median time.Duration) time.Duration {
+	if c == nil || c.SlowIterationAction == SlowIterationOff || median <= 0 {
+		return 0
+	}
+	factor := c.SlowIterationFactor
+	if factor <= 0 {
+		factor = DefaultSlowIterationFactor
+	}
+	return time.Duration(float64(median) * factor)
+}
+
+// FailSlowIterations reports whether a slow iteration should be stopped
+// rather than only flagged.
+func (c *Config) FailSlowIterations() bool {
+	return c != nil && c.SlowIterationAction == SlowIterationFail
 }
 
 // CommandConfig holds the configuration for the agent command.
@@ -57,6 +448,44 @@ type CommandConfig struct {
 	// RawOutput if true, streams output directly without parsing (for claude-code)
 	// If false, output is parsed through the log parser (for cursor)
 	RawOutput bool `toml:"raw_output"`
+
+	// ContainerRuntime is the CLI used to run agents in a container image
+	// (e.g., "docker" or "podman"). Defaults to "docker".
+	ContainerRuntime string `toml:"container_runtime"`
+
+	// ReadOnlyArgs are appended to Args for read-only tasks, e.g. to take
+	// away the agent CLI's file editing tools
+	ReadOnlyArgs []string `toml:"read_only_args"`
+
+	// ParamArgs maps model params (e.g. temperature) to the flags that pass
+	// them to the agent CLI, with {value} replaced by the param's value
+	ParamArgs map[string][]string `toml:"param_args"`
+
+	// ParamEnv maps model params to the environment variables that pass them
+	// to the agent CLI
+	ParamEnv map[string]string `toml:"param_env"`
+
+	// Env holds environment variables for the agent CLI, e.g. from ParamEnv.
+	// It is set per task by WithParams and never read from TOML.
+	Env []string `toml:"-"`
+
+	// ReadOnly, when set, runs the agent with ReadOnlyArgs and mounts the
+	// working directory read-only in containers. It is set per task (compose
+	// `read_only:` or `swarm run --read-only`) and never read from TOML.
+	ReadOnly bool `toml:"-"`
+
+	// Image, when set, runs the agent inside this container image.
+	// It is set per task (compose `image:` or `swarm run --image`) and never read from TOML.
+	Image string `toml:"-"`
+
+	// Kubernetes, when non-nil, runs the command inside a Kubernetes Job.
+	// It is populated by Config.AgentCommand() and never read from TOML.
+	Kubernetes *KubernetesConfig `toml:"-"`
+
+	// PassEnv lists environment variable names forwarded to container and
+	// Kubernetes agents, which don't inherit swarm's environment. It is
+	// populated by Config.AgentCommand() and never read from TOML.
+	PassEnv []string `toml:"-"`
 }
 
 // ModelPricing holds the pricing for a model in USD per million tokens.
@@ -135,6 +564,17 @@ func (c *Config) GetPricing(model string) *ModelPricing {
 	return defaults["default"]
 }
 
+// UsageCost returns the cost of an agent run's tokens on model: reportedCost
+// when the agent CLI reported one (it accounts for cache pricing), otherwise
+// the tokens priced with the model's pricing. A nil config only uses
+// reportedCost.
+func (c *Config) UsageCost(model string, inputTokens, outputTokens int64, reportedCost float64) float64 {
+	if reportedCost > 0 || c == nil {
+		return reportedCost
+	}
+	return c.GetPricing(model).CalculateCost(inputTokens, outputTokens)
+}
+
 // DefaultConfig returns the built-in default configuration (claude-code backend).
 func DefaultConfig() *Config {
 	return ClaudeCodeConfig()
@@ -178,6 +618,11 @@ func ClaudeCodeConfig() *Config {
 				"--dangerously-skip-permissions",
 				"{prompt}",
 			},
+			ReadOnlyArgs: []string{"--disallowedTools", "Write,Edit,MultiEdit,NotebookEdit"},
+			ParamEnv: map[string]string{
+				ParamMaxOutputTokens: "CLAUDE_CODE_MAX_OUTPUT_TOKENS",
+				ParamThinkingBudget:  "MAX_THINKING_TOKENS",
+			},
 			RawOutput: false,
 		},
 	}
@@ -198,6 +643,33 @@ func CodexConfig() *Config {
 				"--sandbox", "danger-full-access",
 				"{prompt}",
 			},
+			ReadOnlyArgs: []string{"--sandbox", "read-only"},
+			ParamArgs: map[string][]string{
+				ParamMaxOutputTokens: {"-c", "model_max_output_tokens={value}"},
+			},
+			RawOutput: false,
+		},
+	}
+}
+
+// SimulateConfig returns the configuration preset for simulated runs: the
+// swarm binary itself plays the agent, emitting Claude Code stream-json.
+func SimulateConfig() *Config {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "swarm"
+	}
+	return &Config{
+		Backend:    BackendSimulate,
+		Model:      "simulated",
+		Iterations: 1,
+		Command: CommandConfig{
+			Executable: executable,
+			Args: []string{
+				"simulate-agent",
+				"--model", "{model}",
+				"{prompt}",
+			},
 			RawOutput: false,
 		},
 	}
@@ -214,6 +686,8 @@ func (c *Config) SetBackend(backend string) error {
 		preset = ClaudeCodeConfig()
 	case BackendCodex:
 		preset = CodexConfig()
+	case BackendSimulate:
+		preset = SimulateConfig()
 	default:
 		return fmt.Errorf("unknown backend: %s (valid options: %s)", backend, strings.Join(ValidBackends(), ", "))
 	}
@@ -283,6 +757,26 @@ func loadConfigFile(path string, cfg *Config) error {
 		Executable string   `toml:"executable"`
 		Args       []string `toml:"args"`
 		RawOutput  *bool    `toml:"raw_output"` // pointer to detect if set
+
+		ContainerRuntime string `toml:"container_runtime"`
+
+		ReadOnlyArgs []string `toml:"read_only_args"`
+
+		ParamArgs map[string][]string `toml:"param_args"`
+		ParamEnv  map[string]string   `toml:"param_env"`
+	}
+	type rawKubernetesConfig struct {
+		Enabled        *bool    `toml:"enabled"`
+		Image          string   `toml:"image"`
+		Namespace      string   `toml:"namespace"`
+		Secrets        []string `toml:"secrets"`
+		CPU            string   `toml:"cpu"`
+		Memory         string   `toml:"memory"`
+		ServiceAccount string   `toml:"service_account"`
+		PVC            string   `toml:"pvc"`
+		MountPath      string   `toml:"mount_path"`
+		WorkingDir     string   `toml:"working_dir"`
+		Kubectl        string   `toml:"kubectl"`
 	}
 	type rawConfig struct {
 		Backend      string                    `toml:"backend"`
@@ -293,6 +787,47 @@ func loadConfigFile(path string, cfg *Config) error {
 		Command      rawCommandConfig          `toml:"command"`
 		Pricing      map[string]*ModelPricing  `toml:"pricing"`
 		SystemPrompt *string                   `toml:"system_prompt"` // pointer to detect explicit removal
+		StateDir     string                    `toml:"state_dir"`
+		TodoDir      string                    `toml:"todo_dir"`
+		DoneDir      string                    `toml:"done_dir"`
+		Kubernetes   *rawKubernetesConfig      `toml:"kubernetes"`
+		Pools        map[string]*PoolConfig    `toml:"pools"`
+
+		DefaultLabels map[string]string `toml:"default_labels"`
+
+		ScratchRetention string `toml:"scratch_retention"`
+
+		MaxAgentAge string `toml:"max_agent_age"`
+
+		MinSwarmVersion string `toml:"min_swarm_version"`
+		MinAgentVersion string `toml:"min_agent_version"`
+
+		HookRetries    int    `toml:"hook_retries"`
+		HookRetryDelay string `toml:"hook_retry_delay"`
+
+		MaxPromptTokens   int    `toml:"max_prompt_tokens"`
+		PromptLimitAction string `toml:"prompt_limit_action"`
+
+		ListColumns []string `toml:"list_columns"`
+		TopColumns  []string `toml:"top_columns"`
+		DedupLogs   *bool    `toml:"dedup_logs"`
+
+		LogsTail    int   `toml:"logs_tail"`
+		LogsPretty  *bool `toml:"logs_pretty"`
+		TopLogLines int   `toml:"top_log_lines"`
+
+		LogTimezone   string `toml:"log_timezone"`
+		LogTimeFormat string `toml:"log_time_format"`
+
+		SlowIterationFactor float64 `toml:"slow_iteration_factor"`
+		SlowIterationAction string  `toml:"slow_iteration_action"`
+
+		AlertCostUSD    float64 `toml:"alert_cost_usd"`
+		NotifyDesktop   *bool   `toml:"notify_desktop"`
+		SlackWebhookURL string  `toml:"slack_webhook_url"`
+		NotifyCmd       string  `toml:"notify_cmd"`
+
+		MetricsAddr string `toml:"metrics_addr"`
 	}
 
 	var fileCfg rawConfig
@@ -320,6 +855,131 @@ func loadConfigFile(path string, cfg *Config) error {
 	if fileCfg.IterTimeout != "" {
 		cfg.IterTimeout = fileCfg.IterTimeout
 	}
+	if fileCfg.StateDir != "" {
+		cfg.StateDir = fileCfg.StateDir
+	}
+	if fileCfg.TodoDir != "" {
+		cfg.TodoDir = fileCfg.TodoDir
+	}
+	if fileCfg.DoneDir != "" {
+		cfg.DoneDir = fileCfg.DoneDir
+	}
+	if fileCfg.ScratchRetention != "" {
+		if d, err := time.ParseDuration(fileCfg.ScratchRetention); err != nil || d < 0 {
+			return fmt.Errorf("invalid scratch_retention %q (use a duration like 24h)", fileCfg.ScratchRetention)
+		}
+		cfg.ScratchRetention = fileCfg.ScratchRetention
+	}
+	if fileCfg.MaxAgentAge != "" {
+		if d, err := time.ParseDuration(fileCfg.MaxAgentAge); err != nil || d <= 0 {
+			return fmt.Errorf("invalid max_agent_age %q (use a duration like 24h)", fileCfg.MaxAgentAge)
+		}
+		cfg.MaxAgentAge = fileCfg.MaxAgentAge
+	}
+	for _, pin := range []struct {
+		key, value string
+		dst        *string
+	}{
+		{"min_swarm_version", fileCfg.MinSwarmVersion, &cfg.MinSwarmVersion},
+		{"min_agent_version", fileCfg.MinAgentVersion, &cfg.MinAgentVersion},
+	} {
+		if pin.value == "" {
+			continue
+		}
+		if _, ok := version.Parse(pin.value); !ok {
+			return fmt.Errorf("invalid %s %q (use a version like 1.4.0)", pin.key, pin.value)
+		}
+		*pin.dst = pin.value
+	}
+	if fileCfg.HookRetries < 0 {
+		return fmt.Errorf("invalid hook_retries %d (must not be negative)", fileCfg.HookRetries)
+	}
+	if fileCfg.HookRetries != 0 {
+		cfg.HookRetries = fileCfg.HookRetries
+	}
+	if fileCfg.HookRetryDelay != "" {
+		if d, err := time.ParseDuration(fileCfg.HookRetryDelay); err != nil || d < 0 {
+			return fmt.Errorf("invalid hook_retry_delay %q (use a duration like 10s)", fileCfg.HookRetryDelay)
+		}
+		cfg.HookRetryDelay = fileCfg.HookRetryDelay
+	}
+	if fileCfg.MaxPromptTokens != 0 {
+		cfg.MaxPromptTokens = fileCfg.MaxPromptTokens
+	}
+	switch fileCfg.PromptLimitAction {
+	case "":
+	case PromptLimitWarn, PromptLimitFail:
+		cfg.PromptLimitAction = fileCfg.PromptLimitAction
+	default:
+		return fmt.Errorf("invalid prompt_limit_action %q (use %q or %q)", fileCfg.PromptLimitAction, PromptLimitWarn, PromptLimitFail)
+	}
+	if len(fileCfg.ListColumns) > 0 {
+		cfg.ListColumns = fileCfg.ListColumns
+	}
+	if len(fileCfg.TopColumns) > 0 {
+		cfg.TopColumns = fileCfg.TopColumns
+	}
+	if fileCfg.DedupLogs != nil {
+		cfg.DedupLogs = *fileCfg.DedupLogs
+	}
+	if fileCfg.LogsTail < 0 {
+		return fmt.Errorf("invalid logs_tail %d (must not be negative)", fileCfg.LogsTail)
+	}
+	if fileCfg.LogsTail != 0 {
+		cfg.LogsTail = fileCfg.LogsTail
+	}
+	if fileCfg.LogsPretty != nil {
+		cfg.LogsPretty = *fileCfg.LogsPretty
+	}
+	if fileCfg.TopLogLines < 0 {
+		return fmt.Errorf("invalid top_log_lines %d (must not be negative)", fileCfg.TopLogLines)
+	}
+	if fileCfg.TopLogLines != 0 {
+		cfg.TopLogLines = fileCfg.TopLogLines
+	}
+	if fileCfg.LogTimezone != "" {
+		if _, err := time.LoadLocation(fileCfg.LogTimezone); err != nil {
+			return fmt.Errorf("invalid log_timezone %q (use a zone name like UTC or Europe/Berlin)", fileCfg.LogTimezone)
+		}
+		cfg.LogTimezone = fileCfg.LogTimezone
+	}
+	if fileCfg.LogTimeFormat != "" {
+		if fileCfg.LogTimeFormat == time.Unix(0, 0).UTC().Format(fileCfg.LogTimeFormat) {
+			return fmt.Errorf("invalid log_time_format %q (use a Go time layout like 2006-01-02 15:04:05)", fileCfg.LogTimeFormat)
+		}
+		cfg.LogTimeFormat = fileCfg.LogTimeFormat
+	}
+	if fileCfg.SlowIterationFactor < 0 || (fileCfg.SlowIterationFactor > 0 && fileCfg.SlowIterationFactor <= 1) {
+		return fmt.Errorf("invalid slow_iteration_factor %v (must be greater than 1)", fileCfg.SlowIterationFactor)
+	}
+	if fileCfg.SlowIterationFactor != 0 {
+		cfg.SlowIterationFactor = fileCfg.SlowIterationFactor
+	}
+	switch fileCfg.SlowIterationAction {
+	case "":
+	case SlowIterationWarn, SlowIterationFail, SlowIterationOff:
+		cfg.SlowIterationAction = fileCfg.SlowIterationAction
+	default:
+		return fmt.Errorf("invalid slow_iteration_action %q (use %q, %q, or %q)", fileCfg.SlowIterationAction, SlowIterationWarn, SlowIterationFail, SlowIterationOff)
+	}
+	if fileCfg.AlertCostUSD < 0 {
+		return fmt.Errorf("invalid alert_cost_usd %v (must not be negative)", fileCfg.AlertCostUSD)
+	}
+	if fileCfg.AlertCostUSD != 0 {
+		cfg.AlertCostUSD = fileCfg.AlertCostUSD
+	}
+	if fileCfg.NotifyDesktop != nil {
+		cfg.NotifyDesktop = *fileCfg.NotifyDesktop
+	}
+	if fileCfg.SlackWebhookURL != "" {
+		cfg.SlackWebhookURL = fileCfg.SlackWebhookURL
+	}
+	if fileCfg.NotifyCmd != "" {
+		cfg.NotifyCmd = fileCfg.NotifyCmd
+	}
+	if fileCfg.MetricsAddr != "" {
+		cfg.MetricsAddr = fileCfg.MetricsAddr
+	}
 	if fileCfg.Command.Executable != "" {
 		cfg.Command.Executable = fileCfg.Command.Executable
 	}
@@ -329,12 +989,96 @@ func loadConfigFile(path string, cfg *Config) error {
 	if fileCfg.Command.RawOutput != nil {
 		cfg.Command.RawOutput = *fileCfg.Command.RawOutput
 	}
+	if fileCfg.Command.ContainerRuntime != "" {
+		cfg.Command.ContainerRuntime = fileCfg.Command.ContainerRuntime
+	}
+	if fileCfg.Command.ReadOnlyArgs != nil {
+		cfg.Command.ReadOnlyArgs = fileCfg.Command.ReadOnlyArgs
+	}
+	if fileCfg.Command.ParamArgs != nil {
+		cfg.Command.ParamArgs = fileCfg.Command.ParamArgs
+	}
+	if fileCfg.Command.ParamEnv != nil {
+		cfg.Command.ParamEnv = fileCfg.Command.ParamEnv
+	}
+	for name := range fileCfg.Command.ParamArgs {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("invalid command.param_args: %w", err)
+		}
+	}
+	for name := range fileCfg.Command.ParamEnv {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("invalid command.param_env: %w", err)
+		}
+	}
 
 	// Merge system prompt (project file overrides global; empty string explicitly clears it)
 	if fileCfg.SystemPrompt != nil {
 		cfg.SystemPrompt = *fileCfg.SystemPrompt
 	}
 
+	// Merge kubernetes settings (individual keys override)
+	if k := fileCfg.Kubernetes; k != nil {
+		if k.Enabled != nil {
+			cfg.Kubernetes.Enabled = *k.Enabled
+		}
+		if k.Image != "" {
+			cfg.Kubernetes.Image = k.Image
+		}
+		if k.Namespace != "" {
+			cfg.Kubernetes.Namespace = k.Namespace
+		}
+		if len(k.Secrets) > 0 {
+			cfg.Kubernetes.Secrets = k.Secrets
+		}
+		if k.CPU != "" {
+			cfg.Kubernetes.CPU = k.CPU
+		}
+		if k.Memory != "" {
+			cfg.Kubernetes.Memory = k.Memory
+		}
+		if k.ServiceAccount != "" {
+			cfg.Kubernetes.ServiceAccount = k.ServiceAccount
+		}
+		if k.PVC != "" {
+			cfg.Kubernetes.PVC = k.PVC
+		}
+		if k.MountPath != "" {
+			cfg.Kubernetes.MountPath = k.MountPath
+		}
+		if k.WorkingDir != "" {
+			cfg.Kubernetes.WorkingDir = k.WorkingDir
+		}
+		if k.Kubectl != "" {
+			cfg.Kubernetes.Kubectl = k.Kubectl
+		}
+		if err := cfg.Kubernetes.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Merge pools (add/override individual pools)
+	for name, pool := range fileCfg.Pools {
+		if err := pool.Validate(name); err != nil {
+			return err
+		}
+		if cfg.Pools == nil {
+			cfg.Pools = make(map[string]*PoolConfig)
+		}
+		cfg.Pools[name] = pool
+	}
+
+	// Merge default labels (add/override individual labels)
+	for k, v := range fileCfg.DefaultLabels {
+		if _, _, err := label.Parse(k + "=" + v); err != nil {
+			return fmt.Errorf("invalid default_labels: %w", err)
+		}
+		if cfg.DefaultLabels == nil {
+			cfg.DefaultLabels = make(map[string]string)
+		}
+		cfg.DefaultLabels[k] = v
+	}
+
 	// Merge pricing (add/override individual models)
 	if len(fileCfg.Pricing) > 0 {
 		if cfg.Pricing == nil {
@@ -348,6 +1092,72 @@ func loadConfigFile(path string, cfg *Config) error {
 	return nil
 }
 
+// WithImage returns a copy of the command config that runs the agent inside
+// the given container image. An empty image returns the config unchanged.
+func (c CommandConfig) WithImage(image string) CommandConfig {
+	if image != "" {
+		c.Image = image
+	}
+	return c
+}
+
+// WithExtraArgs returns a copy of the command config with args appended to the
+// agent command, for flags swarm doesn't model itself (e.g. --max-turns).
+func (c CommandConfig) WithExtraArgs(args []string) CommandConfig {
+	if len(args) > 0 {
+		c.Args = append(append([]string{}, c.Args...), args...)
+	}
+	return c
+}
+
+// WithReadOnly returns a copy of the command config for a read-only task when
+// readOnly is set: ReadOnlyArgs are appended to the agent command.
+func (c CommandConfig) WithReadOnly(readOnly bool) CommandConfig {
+	if readOnly {
+		c.ReadOnly = true
+		c.Args = append(append([]string{}, c.Args...), c.ReadOnlyArgs...)
+	}
+	return c
+}
+
+// sessionArgs are agent CLI flags that continue an earlier session, mapped to
+// whether they take a value.
+var sessionArgs = map[string]bool{
+	"--continue":   false,
+	"--resume":     true,
+	"--session-id": true,
+}
+
+// WithoutSessionArgs returns a copy of the command config with flags that
+// continue an earlier agent session removed, so the agent starts with a fresh
+// context. It reports whether any flags were removed.
+func (c CommandConfig) WithoutSessionArgs() (CommandConfig, bool) {
+	var args []string
+	removed := false
+	for i := 0; i < len(c.Args); i++ {
+		name, _, hasValue := strings.Cut(c.Args[i], "=")
+		takesValue, ok := sessionArgs[name]
+		if !ok {
+			args = append(args, c.Args[i])
+			continue
+		}
+		removed = true
+		if takesValue && !hasValue && i+1 < len(c.Args) {
+			i++
+		}
+	}
+	c.Args = args
+	return c, removed
+}
+
+// ContainerRuntimePath returns the container runtime CLI, defaulting to "docker".
+func (c *CommandConfig) ContainerRuntimePath() string {
+	if c.ContainerRuntime == "" {
+		return "docker"
+	}
+	return c.ContainerRuntime
+}
+
 // ExpandArgs expands {model} and {prompt} placeholders in the command args.
 func (c *CommandConfig) ExpandArgs(model, prompt string) []string {
 	result := make([]string, len(c.Args))
@@ -411,6 +1221,194 @@ func (c *Config) ToTOML() string {
 		sb.WriteString("\n\n")
 	}
 
+	sb.WriteString("# Directory for pipeline iteration state (SWARM_STATE_DIR), e.g. a shared path\n")
+	sb.WriteString("# for multi-host runs. Relative paths are resolved against the working directory.\n")
+	sb.WriteString("# Set to \"\" or omit to use the system temp dir\n")
+	if c.StateDir == "" {
+		sb.WriteString("# state_dir = \"\"\n\n")
+	} else {
+		writeTOMLString(&sb, "state_dir", c.StateDir)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Todo-file queue: planners write <name>.todo.md files to todo_dir, doers rename\n")
+	sb.WriteString("# one to .processing.md and move it to done_dir when finished. Its depth, claim\n")
+	sb.WriteString("# age, and throughput are shown in top and stats. Relative paths are resolved\n")
+	sb.WriteString("# against the working directory\n")
+	if c.TodoDir == "" {
+		sb.WriteString("# todo_dir = \"swarm/todos\"\n")
+	} else {
+		writeTOMLString(&sb, "todo_dir", c.TodoDir)
+	}
+	if c.DoneDir == "" {
+		sb.WriteString("# done_dir = \"swarm/done\"\n\n")
+	} else {
+		writeTOMLString(&sb, "done_dir", c.DoneDir)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# How long terminated agents' scratch dirs (SWARM_SCRATCH_DIR) are kept, e.g. \"24h\".\n")
+	sb.WriteString("# Omit to remove an agent's scratch dir when it terminates\n")
+	if c.ScratchRetention == "" {
+		sb.WriteString("# scratch_retention = \"24h\"\n\n")
+	} else {
+		writeTOMLString(&sb, "scratch_retention", c.ScratchRetention)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Longest an agent may run, e.g. \"24h\"; older agents are stopped after their\n")
+	sb.WriteString("# current iteration (exit reason max_age). Omit for no limit\n")
+	if c.MaxAgentAge == "" {
+		sb.WriteString("# max_agent_age = \"24h\"\n\n")
+	} else {
+		writeTOMLString(&sb, "max_agent_age", c.MaxAgentAge)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Oldest swarm and agent CLI versions this project works with; older installs\n")
+	sb.WriteString("# fail with a request to upgrade (see 'swarm upgrade-check')\n")
+	if c.MinSwarmVersion == "" {
+		sb.WriteString("# min_swarm_version = \"1.4.0\"\n")
+	} else {
+		writeTOMLString(&sb, "min_swarm_version", c.MinSwarmVersion)
+	}
+	if c.MinAgentVersion == "" {
+		sb.WriteString("# min_agent_version = \"2.0.0\"\n\n")
+	} else {
+		writeTOMLString(&sb, "min_agent_version", c.MinAgentVersion)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# How many times a failing on-complete/on-failure/on-timeout hook is retried,\n")
+	sb.WriteString("# waiting hook_retry_delay (default 5s) before the first retry and doubling it after\n")
+	if c.HookRetries == 0 {
+		sb.WriteString("# hook_retries = 3\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("hook_retries = %d\n", c.HookRetries))
+	}
+	if c.HookRetryDelay == "" {
+		sb.WriteString("# hook_retry_delay = \"5s\"\n\n")
+	} else {
+		writeTOMLString(&sb, "hook_retry_delay", c.HookRetryDelay)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Estimated prompt size (tokens) above which an iteration is reported before it\n")
+	sb.WriteString("# starts; includes prefix/suffix, stdin, and {{output:...}} content. 0 disables.\n")
+	sb.WriteString("# prompt_limit_action is \"warn\" (start anyway) or \"fail\" (fail the iteration)\n")
+	if c.MaxPromptTokens == 0 {
+		sb.WriteString("# max_prompt_tokens = 150000\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("max_prompt_tokens = %d\n", c.MaxPromptTokens))
+	}
+	if c.PromptLimitAction == "" {
+		sb.WriteString("# prompt_limit_action = \"warn\"\n\n")
+	} else {
+		writeTOMLString(&sb, "prompt_limit_action", c.PromptLimitAction)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Columns shown by `swarm list` and `swarm top` (see their --columns flag);\n")
+	sb.WriteString("# a \":N\" suffix sets a column's width. Omit for the default columns.\n")
+	if len(c.ListColumns) == 0 && len(c.TopColumns) == 0 {
+		sb.WriteString("# list_columns = [\"id\", \"name:30\", \"status\", \"cost\", \"started\"]\n\n")
+	} else {
+		writeTOMLStrings(&sb, "list_columns", c.ListColumns)
+		writeTOMLStrings(&sb, "top_columns", c.TopColumns)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Collapse identical consecutive log events (e.g. an agent stuck in a retry loop)\n")
+	sb.WriteString("# into \"previous message repeated N times\" in pretty logs and top\n")
+	if c.DedupLogs {
+		sb.WriteString("dedup_logs = true\n\n")
+	} else {
+		sb.WriteString("# dedup_logs = true\n\n")
+	}
+
+	sb.WriteString("# Defaults for `swarm logs` --tail and --pretty, and the number of lines in\n")
+	sb.WriteString("# top's log panel (0 sizes it to the terminal)\n")
+	if c.LogsTail == 0 {
+		sb.WriteString("# logs_tail = 200\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("logs_tail = %d\n", c.LogsTail))
+	}
+	if c.LogsPretty {
+		sb.WriteString("logs_pretty = true\n")
+	} else {
+		sb.WriteString("# logs_pretty = true\n")
+	}
+	if c.TopLogLines == 0 {
+		sb.WriteString("# top_log_lines = 30\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("top_log_lines = %d\n\n", c.TopLogLines))
+	}
+
+	sb.WriteString("# Time zone and Go time layout log timestamps are shown in; absolute\n")
+	sb.WriteString("# --since/--until times are read in the same zone (default local time, RFC 3339)\n")
+	if c.LogTimezone == "" {
+		sb.WriteString("# log_timezone = \"UTC\"\n")
+	} else {
+		writeTOMLString(&sb, "log_timezone", c.LogTimezone)
+	}
+	if c.LogTimeFormat == "" {
+		sb.WriteString("# log_time_format = \"2006-01-02 15:04:05\"\n\n")
+	} else {
+		writeTOMLString(&sb, "log_time_format", c.LogTimeFormat)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Flag an iteration as slow once it runs slow_iteration_factor times longer than the\n")
+	sb.WriteString("# agent's median iteration. slow_iteration_action is \"warn\" (flag it in top),\n")
+	sb.WriteString("# \"fail\" (also stop the iteration as if it timed out), or \"off\"\n")
+	if c.SlowIterationFactor == 0 {
+		sb.WriteString("# slow_iteration_factor = 3\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("slow_iteration_factor = %s\n", strconv.FormatFloat(c.SlowIterationFactor, 'f', -1, 64)))
+	}
+	if c.SlowIterationAction == "" {
+		sb.WriteString("# slow_iteration_action = \"warn\"\n\n")
+	} else {
+		writeTOMLString(&sb, "slow_iteration_action", c.SlowIterationAction)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Send a cost alert once the project's agents have cost this much in total (USD);\n")
+	sb.WriteString("# agents keep running. Alerts go to the agent's output, top, and the notifiers below\n")
+	if c.AlertCostUSD == 0 {
+		sb.WriteString("# alert_cost_usd = 25\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("alert_cost_usd = %s\n\n", strconv.FormatFloat(c.AlertCostUSD, 'f', -1, 64)))
+	}
+
+	sb.WriteString("# Notifiers for cost alerts, slow iterations, and failing health checks.\n")
+	sb.WriteString("# notify_cmd is run with the event as JSON on stdin (type, title, message, ...)\n")
+	if c.NotifyDesktop {
+		sb.WriteString("notify_desktop = true\n")
+	} else {
+		sb.WriteString("# notify_desktop = true\n")
+	}
+	if c.SlackWebhookURL == "" {
+		sb.WriteString("# slack_webhook_url = \"https://hooks.slack.com/services/...\"\n")
+	} else {
+		writeTOMLString(&sb, "slack_webhook_url", c.SlackWebhookURL)
+	}
+	if c.NotifyCmd == "" {
+		sb.WriteString("# notify_cmd = \"curl -s -d @- ntfy.sh/my-swarm\"\n\n")
+	} else {
+		writeTOMLString(&sb, "notify_cmd", c.NotifyCmd)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Address 'swarm serve' exposes Prometheus metrics on (running agents,\n")
+	sb.WriteString("# iterations, tokens, cost, and failures)\n")
+	if c.MetricsAddr == "" {
+		sb.WriteString("# metrics_addr = \":9900\"\n\n")
+	} else {
+		writeTOMLString(&sb, "metrics_addr", c.MetricsAddr)
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("# Agent command configuration\n")
 	sb.WriteString("[command]\n")
 	sb.WriteString("# The base command to run (e.g., \"agent\" for cursor, \"claude\" for claude-code, \"codex\" for codex)\n")
@@ -441,9 +1439,149 @@ func (c *Config) ToTOML() string {
 	}
 	sb.WriteString("\n")
 
+	if c.Command.ContainerRuntime != "" {
+		sb.WriteString("\n# Container runtime used for tasks with an `image:` (e.g., \"docker\", \"podman\")\n")
+		writeTOMLString(&sb, "container_runtime", c.Command.ContainerRuntime)
+	}
+
+	if len(c.Command.ReadOnlyArgs) > 0 {
+		sb.WriteString("\n# Arguments appended for read-only tasks (compose `read_only: true`, swarm run --read-only)\n")
+		writeTOMLStrings(&sb, "read_only_args", c.Command.ReadOnlyArgs)
+	}
+
+	if len(c.Command.ParamArgs) > 0 {
+		sb.WriteString("\n# Flags passing model params (compose `params:`, swarm run --param) to the agent CLI; {value} is the param's value\n")
+		sb.WriteString("[command.param_args]\n")
+		for _, name := range sortedKeys(c.Command.ParamArgs) {
+			writeTOMLStrings(&sb, tomlKey(name), c.Command.ParamArgs[name])
+		}
+	}
+
+	if len(c.Command.ParamEnv) > 0 {
+		sb.WriteString("\n# Environment variables passing model params to the agent CLI\n")
+		sb.WriteString("[command.param_env]\n")
+		for _, name := range sortedKeys(c.Command.ParamEnv) {
+			writeTOMLString(&sb, tomlKey(name), c.Command.ParamEnv[name])
+		}
+	}
+
+	if c.Kubernetes.Enabled || c.Kubernetes.Image != "" {
+		sb.WriteString("\n# Run each agent iteration as a Kubernetes Job\n")
+		sb.WriteString("[kubernetes]\n")
+		sb.WriteString("enabled = ")
+		if c.Kubernetes.Enabled {
+			sb.WriteString("true\n")
+		} else {
+			sb.WriteString("false\n")
+		}
+		writeTOMLString(&sb, "image", c.Kubernetes.Image)
+		writeTOMLString(&sb, "namespace", c.Kubernetes.Namespace)
+		if len(c.Kubernetes.Secrets) > 0 {
+			sb.WriteString("secrets = [")
+			for i, s := range c.Kubernetes.Secrets {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(tomlQuoteMultiline(s))
+			}
+			sb.WriteString("]\n")
+		}
+		writeTOMLString(&sb, "cpu", c.Kubernetes.CPU)
+		writeTOMLString(&sb, "memory", c.Kubernetes.Memory)
+		writeTOMLString(&sb, "service_account", c.Kubernetes.ServiceAccount)
+		writeTOMLString(&sb, "pvc", c.Kubernetes.PVC)
+		writeTOMLString(&sb, "mount_path", c.Kubernetes.MountPath)
+		writeTOMLString(&sb, "working_dir", c.Kubernetes.WorkingDir)
+		writeTOMLString(&sb, "kubectl", c.Kubernetes.Kubectl)
+	}
+
+	if len(c.Pools) > 0 {
+		sb.WriteString("\n# Concurrency pools shared by pipeline tasks with matching labels\n")
+		names := make([]string, 0, len(c.Pools))
+		for name := range c.Pools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			pool := c.Pools[name]
+			fmt.Fprintf(&sb, "[pools.%s]\n", tomlKey(name))
+			if len(pool.Labels) > 0 {
+				sb.WriteString("labels = [")
+				for i, l := range pool.Labels {
+					if i > 0 {
+						sb.WriteString(", ")
+					}
+					sb.WriteString(tomlQuoteMultiline(l))
+				}
+				sb.WriteString("]\n")
+			}
+			fmt.Fprintf(&sb, "max = %d\n", pool.Max)
+		}
+	}
+
+	sb.WriteString("\n# Labels applied to every agent started in this project (-l overrides them)\n")
+	if len(c.DefaultLabels) == 0 {
+		sb.WriteString("# [default_labels]\n")
+		sb.WriteString("# repo = \"payments\"\n")
+		sb.WriteString("# owner = \"alice\"\n")
+	} else {
+		sb.WriteString("[default_labels]\n")
+		keys := make([]string, 0, len(c.DefaultLabels))
+		for k := range c.DefaultLabels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "%s = %s\n", tomlKey(k), tomlQuoteMultiline(c.DefaultLabels[k]))
+		}
+	}
+
 	return sb.String()
 }
 
+// WithDefaultLabels returns labels with the config's default labels merged
+// under them.
+func (c *Config) WithDefaultLabels(labels map[string]string) map[string]string {
+	return label.Merge(c.DefaultLabels, labels)
+}
+
+// tomlKey returns name as a TOML key, quoting it unless it is a bare key.
+func tomlKey(name string) string {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return tomlQuoteMultiline(name)
+		}
+	}
+	return name
+}
+
+// writeTOMLStrings writes `key = ["a", "b"]` when values is non-empty.
+func writeTOMLStrings(sb *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	sb.WriteString(key)
+	sb.WriteString(" = [")
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(tomlQuoteMultiline(v))
+	}
+	sb.WriteString("]\n")
+}
+
+// writeTOMLString writes `key = "value"` when value is non-empty.
+func writeTOMLString(sb *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	sb.WriteString(key)
+	sb.WriteString(" = ")
+	sb.WriteString(tomlQuoteMultiline(value))
+	sb.WriteString("\n")
+}
+
 // tomlQuoteMultiline returns a TOML-safe representation of s, preferring a
 // triple-quoted multiline string for content containing newlines and falling
 // back to a basic quoted string otherwise.
@@ -464,12 +1602,20 @@ func tomlQuoteMultiline(s string) string {
 // before the `{prompt}` placeholder when both are set and the active backend
 // is claude-code (the only backend that currently supports the flag).
 //
+// When the Kubernetes job backend is enabled, the returned CommandConfig also
+// carries a copy of the Kubernetes settings so the runner schedules a Job.
+//
 // Callers should use this in place of c.Command when constructing agent.Config
 // so that a configured system prompt is honored uniformly across `swarm run`,
 // `swarm up`, `swarm restart`, `swarm clone`, the DAG executor, and the
 // multi-iteration loop runner.
 func (c *Config) AgentCommand() CommandConfig {
 	cmd := c.Command
+	cmd.PassEnv = c.EnvFileVars
+	if c.Kubernetes.Enabled {
+		k := c.Kubernetes
+		cmd.Kubernetes = &k
+	}
 	if c.SystemPrompt == "" || c.Backend != BackendClaudeCode {
 		return cmd
 	}
@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -303,6 +306,43 @@ func TestAgentCommandNoOpWhenEmpty(t *testing.T) {
 	}
 }
 
+func TestWithExtraArgs(t *testing.T) {
+	cfg := ClaudeCodeConfig()
+	base := cfg.AgentCommand()
+	baseLen := len(base.Args)
+
+	got := base.WithExtraArgs([]string{"--max-turns", "20"})
+	if len(got.Args) != baseLen+2 || got.Args[baseLen] != "--max-turns" || got.Args[baseLen+1] != "20" {
+		t.Errorf("WithExtraArgs() args = %v, want base args followed by --max-turns 20", got.Args)
+	}
+	if len(base.Args) != baseLen {
+		t.Errorf("WithExtraArgs() modified the original args: %v", base.Args)
+	}
+	if len(base.WithExtraArgs(nil).Args) != baseLen {
+		t.Error("WithExtraArgs(nil) should leave args unchanged")
+	}
+}
+
+func TestWithoutSessionArgs(t *testing.T) {
+	base := CommandConfig{Args: []string{"-p", "--resume", "abc", "--model", "{model}", "--continue", "--session-id=xyz", "{prompt}"}}
+
+	got, removed := base.WithoutSessionArgs()
+	if !removed {
+		t.Fatal("expected session args to be removed")
+	}
+	want := []string{"-p", "--model", "{model}", "{prompt}"}
+	if strings.Join(got.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("WithoutSessionArgs() args = %v, want %v", got.Args, want)
+	}
+	if len(base.Args) != 8 {
+		t.Errorf("WithoutSessionArgs() modified the original args: %v", base.Args)
+	}
+
+	if _, removed := ClaudeCodeConfig().AgentCommand().WithoutSessionArgs(); removed {
+		t.Error("default claude-code args have no session flags")
+	}
+}
+
 func TestSystemPromptRoundTrip(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "swarm.toml")
@@ -335,3 +375,577 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestLoadConfigFileKubernetes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `
+[kubernetes]
+enabled = true
+image = "ghcr.io/example/agent:latest"
+namespace = "agents"
+secrets = ["anthropic-key"]
+memory = "4Gi"
+`
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := loadConfigFile(configPath, cfg); err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+
+	if !cfg.Kubernetes.Enabled {
+		t.Error("expected kubernetes to be enabled")
+	}
+	if cfg.Kubernetes.Namespace != "agents" {
+		t.Errorf("expected namespace 'agents', got '%s'", cfg.Kubernetes.Namespace)
+	}
+	if cfg.Kubernetes.KubectlPath() != "kubectl" {
+		t.Errorf("expected default kubectl, got '%s'", cfg.Kubernetes.KubectlPath())
+	}
+
+	cmd := cfg.AgentCommand()
+	if cmd.Kubernetes == nil || cmd.Kubernetes.Image != "ghcr.io/example/agent:latest" {
+		t.Errorf("expected AgentCommand to carry kubernetes settings, got %+v", cmd.Kubernetes)
+	}
+	if cfg.Command.Kubernetes != nil {
+		t.Error("AgentCommand should not mutate the base command config")
+	}
+}
+
+func TestLoadConfigFileKubernetesRequiresImage(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[kubernetes]\nenabled = true\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	if err := loadConfigFile(configPath, DefaultConfig()); err == nil {
+		t.Error("expected error when kubernetes is enabled without an image")
+	}
+}
+
+func TestKubernetesConfigWorkspaceValidation(t *testing.T) {
+	tests := []struct {
+		cfg     KubernetesConfig
+		wantErr bool
+	}{
+		{KubernetesConfig{PVC: "repo"}, false},
+		{KubernetesConfig{PVC: "repo", MountPath: "/src", WorkingDir: "/src/app"}, false},
+		{KubernetesConfig{WorkingDir: "/repo"}, false},
+		{KubernetesConfig{MountPath: "/src"}, true},
+		{KubernetesConfig{PVC: "repo", MountPath: "src"}, true},
+		{KubernetesConfig{WorkingDir: "repo"}, true},
+	}
+	for _, tt := range tests {
+		tt.cfg.Enabled = true
+		tt.cfg.Image = "agent:latest"
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+
+	k := KubernetesConfig{PVC: "repo"}
+	if got := k.EffectiveWorkingDir(); got != DefaultKubernetesMountPath {
+		t.Errorf("EffectiveWorkingDir() = %q, want %q", got, DefaultKubernetesMountPath)
+	}
+}
+
+func TestStateDirRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.StateDir = "/mnt/shared/swarm-state"
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.StateDir != cfg.StateDir {
+		t.Errorf("StateDir = %q, want %q", loaded.StateDir, cfg.StateDir)
+	}
+	if loaded.Command.Executable != cfg.Command.Executable {
+		t.Errorf("state_dir must not shift later keys into another table, executable = %q", loaded.Command.Executable)
+	}
+}
+
+func TestTodoQueueDirsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	if todoDir, doneDir := cfg.TodoQueueDirs("/work"); todoDir != filepath.Join("/work", "swarm/todos") || doneDir != filepath.Join("/work", "swarm/done") {
+		t.Errorf("default TodoQueueDirs = %q, %q", todoDir, doneDir)
+	}
+
+	cfg.TodoDir = "swarm/todo"
+	cfg.DoneDir = filepath.Join(dir, "done")
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.TodoDir != cfg.TodoDir || loaded.DoneDir != cfg.DoneDir {
+		t.Errorf("TodoDir, DoneDir = %q, %q, want %q, %q", loaded.TodoDir, loaded.DoneDir, cfg.TodoDir, cfg.DoneDir)
+	}
+	if todoDir, doneDir := loaded.TodoQueueDirs("/work"); todoDir != filepath.Join("/work", "swarm/todo") || doneDir != cfg.DoneDir {
+		t.Errorf("TodoQueueDirs = %q, %q", todoDir, doneDir)
+	}
+}
+
+func TestScratchRetentionRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.ScratchRetention = "24h"
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := loaded.ScratchRetentionDuration(); got != 24*time.Hour {
+		t.Errorf("ScratchRetentionDuration() = %v, want 24h", got)
+	}
+
+	if err := os.WriteFile(path, []byte("scratch_retention = \"a day\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected error for an invalid scratch_retention")
+	}
+}
+
+func TestReadOnlyArgsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := CursorConfig()
+	cfg.Command.ReadOnlyArgs = []string{"--mode", "ask"}
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Command.ReadOnlyArgs, cfg.Command.ReadOnlyArgs) {
+		t.Errorf("ReadOnlyArgs = %q, want %q", loaded.Command.ReadOnlyArgs, cfg.Command.ReadOnlyArgs)
+	}
+
+	cmd := loaded.Command.WithReadOnly(true)
+	if !cmd.ReadOnly || !reflect.DeepEqual(cmd.Args[len(cmd.Args)-2:], []string{"--mode", "ask"}) {
+		t.Errorf("WithReadOnly(true) = %+v, want ReadOnly with the read-only args appended", cmd)
+	}
+	if len(loaded.Command.Args) != len(cfg.Command.Args) {
+		t.Errorf("WithReadOnly mutated the source Args: %q", loaded.Command.Args)
+	}
+	if cmd := loaded.Command.WithReadOnly(false); cmd.ReadOnly || len(cmd.Args) != len(cfg.Command.Args) {
+		t.Errorf("WithReadOnly(false) = %+v, want the command unchanged", cmd)
+	}
+}
+
+func TestPromptLimitRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.MaxPromptTokens = 120000
+	cfg.PromptLimitAction = PromptLimitFail
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.MaxPromptTokens != 120000 || !loaded.FailOnPromptLimit() {
+		t.Errorf("MaxPromptTokens = %d, PromptLimitAction = %q; want 120000, fail", loaded.MaxPromptTokens, loaded.PromptLimitAction)
+	}
+
+	if err := os.WriteFile(path, []byte("prompt_limit_action = \"abort\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected error for invalid prompt_limit_action")
+	}
+}
+
+func TestPoolsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.Pools = map[string]*PoolConfig{
+		"gpu-heavy": {Max: 2},
+		"browser":   {Labels: []string{"needs=browser"}, Max: 1},
+	}
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded.Pools) != 2 || loaded.Pools["gpu-heavy"].Max != 2 || loaded.Pools["browser"].Labels[0] != "needs=browser" {
+		t.Fatalf("unexpected pools: %+v", loaded.Pools)
+	}
+
+	pools := loaded.PoolsFor(map[string]string{"pool": "gpu-heavy", "needs": "browser"})
+	if len(pools) != 2 || pools[0].Name != "browser" || pools[1].Name != "gpu-heavy" {
+		t.Errorf("PoolsFor = %+v, want browser and gpu-heavy", pools)
+	}
+	if pools := loaded.PoolsFor(map[string]string{"pool": "other"}); len(pools) != 0 {
+		t.Errorf("PoolsFor = %+v, want none", pools)
+	}
+
+	if err := os.WriteFile(path, []byte("[pools.gpu]\nmax = 0\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected error for pool without a positive max")
+	}
+}
+
+func TestColumnsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.ListColumns = []string{"id", "name:40", "labels"}
+	cfg.TopColumns = []string{"name", "status", "task"}
+	cfg.DedupLogs = true
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if strings.Join(loaded.ListColumns, ",") != "id,name:40,labels" || strings.Join(loaded.TopColumns, ",") != "name,status,task" {
+		t.Errorf("ListColumns = %v, TopColumns = %v", loaded.ListColumns, loaded.TopColumns)
+	}
+	if !loaded.DedupLogs {
+		t.Error("DedupLogs should round-trip")
+	}
+	if loaded.Command.Executable != cfg.Command.Executable {
+		t.Errorf("columns must not shift later keys into another table, executable = %q", loaded.Command.Executable)
+	}
+}
+
+func TestLogDefaultsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.LogsTail = 200
+	cfg.LogsPretty = true
+	cfg.TopLogLines = 30
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.LogsTail != 200 || !loaded.LogsPretty || loaded.TopLogLines != 30 {
+		t.Errorf("LogsTail = %d, LogsPretty = %v, TopLogLines = %d", loaded.LogsTail, loaded.LogsPretty, loaded.TopLogLines)
+	}
+
+	if err := os.WriteFile(path, []byte("logs_tail = -1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected error for negative logs_tail")
+	}
+}
+
+func TestMetricsAddrRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.MetricsAddr = "127.0.0.1:9900"
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.MetricsAddr != cfg.MetricsAddr {
+		t.Errorf("MetricsAddr = %q, want %q", loaded.MetricsAddr, cfg.MetricsAddr)
+	}
+	if loaded.Command.Executable != cfg.Command.Executable {
+		t.Errorf("executable = %q", loaded.Command.Executable)
+	}
+}
+
+func TestLogTimestampRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.LogTimezone = "UTC"
+	cfg.LogTimeFormat = "2006-01-02 15:04:05"
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.LogTimezone != "UTC" || loaded.LogTimeFormat != cfg.LogTimeFormat {
+		t.Errorf("loaded %q, %q", loaded.LogTimezone, loaded.LogTimeFormat)
+	}
+	if loaded.LogLocation() != time.UTC {
+		t.Errorf("LogLocation = %v, want UTC", loaded.LogLocation())
+	}
+	if (*Config)(nil).LogLocation() != time.Local {
+		t.Error("nil config should use local time")
+	}
+
+	for _, content := range []string{
+		"log_timezone = \"Mars/Olympus\"\n",
+		"log_time_format = \"hh:mm\"\n",
+	} {
+		os.WriteFile(path, []byte(content), 0644)
+		if err := loadConfigFile(path, DefaultConfig()); err == nil {
+			t.Errorf("loading %q should fail", content)
+		}
+	}
+}
+
+func TestCostAlertRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.AlertCostUSD = 12.5
+	cfg.NotifyDesktop = true
+	cfg.SlackWebhookURL = "https://hooks.slack.com/services/T/B/X"
+	cfg.NotifyCmd = "ntfy publish swarm"
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.AlertCostUSD != 12.5 || !loaded.NotifyDesktop || loaded.SlackWebhookURL != cfg.SlackWebhookURL || loaded.NotifyCmd != cfg.NotifyCmd {
+		t.Errorf("loaded alert config = %v, %v, %q, %q", loaded.AlertCostUSD, loaded.NotifyDesktop, loaded.SlackWebhookURL, loaded.NotifyCmd)
+	}
+	if loaded.Command.Executable != cfg.Command.Executable {
+		t.Errorf("executable = %q", loaded.Command.Executable)
+	}
+
+	if err := os.WriteFile(path, []byte("alert_cost_usd = -1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected an error for a negative alert_cost_usd")
+	}
+}
+
+func TestSlowIterationLimit(t *testing.T) {
+	var nilCfg *Config
+	if nilCfg.SlowIterationLimit(time.Minute) != 0 || nilCfg.FailSlowIterations() {
+		t.Error("a nil config should not check iterations")
+	}
+
+	cfg := DefaultConfig()
+	if got := cfg.SlowIterationLimit(time.Minute); got != 3*time.Minute {
+		t.Errorf("default limit = %v, want 3m", got)
+	}
+	if got := cfg.SlowIterationLimit(0); got != 0 {
+		t.Errorf("limit without a median = %v, want 0", got)
+	}
+	cfg.SlowIterationFactor = 1.5
+	if got := cfg.SlowIterationLimit(time.Minute); got != 90*time.Second {
+		t.Errorf("limit = %v, want 1m30s", got)
+	}
+	cfg.SlowIterationAction = SlowIterationOff
+	if got := cfg.SlowIterationLimit(time.Minute); got != 0 {
+		t.Errorf("limit when off = %v, want 0", got)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+	cfg = ClaudeCodeConfig()
+	cfg.SlowIterationFactor = 4
+	cfg.SlowIterationAction = SlowIterationFail
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.SlowIterationFactor != 4 || !loaded.FailSlowIterations() {
+		t.Errorf("loaded %v, %q", loaded.SlowIterationFactor, loaded.SlowIterationAction)
+	}
+
+	for _, bad := range []string{"slow_iteration_factor = 0.5\n", "slow_iteration_action = \"kill\"\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := loadConfigFile(path, DefaultConfig()); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestHookRetriesRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	if got := DefaultConfig().HookRetryDelayDuration(); got != DefaultHookRetryDelay {
+		t.Errorf("default HookRetryDelayDuration() = %v, want %v", got, DefaultHookRetryDelay)
+	}
+
+	cfg := ClaudeCodeConfig()
+	cfg.HookRetries = 3
+	cfg.HookRetryDelay = "10s"
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.HookRetries != 3 {
+		t.Errorf("HookRetries = %d, want 3", loaded.HookRetries)
+	}
+	if got := loaded.HookRetryDelayDuration(); got != 10*time.Second {
+		t.Errorf("HookRetryDelayDuration() = %v, want 10s", got)
+	}
+
+	for _, content := range []string{"hook_retries = -1\n", "hook_retry_delay = \"soon\"\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := loadConfigFile(path, DefaultConfig()); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestVersionPinsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.MinSwarmVersion = "1.4.0"
+	cfg.MinAgentVersion = "2.0.0"
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.MinSwarmVersion != "1.4.0" || loaded.MinAgentVersion != "2.0.0" {
+		t.Errorf("pins = %q, %q", loaded.MinSwarmVersion, loaded.MinAgentVersion)
+	}
+
+	if err := os.WriteFile(path, []byte("min_swarm_version = \"latest\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected error for an invalid min_swarm_version")
+	}
+}
+
+func TestMaxAgentAgeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.MaxAgentAge = "24h"
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := loaded.MaxAgentAgeDuration(); got != 24*time.Hour {
+		t.Errorf("MaxAgentAgeDuration() = %v, want 24h", got)
+	}
+
+	for _, content := range []string{"max_agent_age = \"forever\"\n", "max_agent_age = \"0s\"\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := loadConfigFile(path, DefaultConfig()); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestDefaultLabelsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := ClaudeCodeConfig()
+	cfg.DefaultLabels = map[string]string{"repo": "payments", "team.name": "billing", "audited": ""}
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// Project labels add to and override the global ones
+	loaded := DefaultConfig()
+	loaded.DefaultLabels = map[string]string{"owner": "alice", "repo": "global"}
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := map[string]string{"owner": "alice", "repo": "payments", "team.name": "billing", "audited": ""}
+	if !reflect.DeepEqual(loaded.DefaultLabels, want) {
+		t.Errorf("DefaultLabels = %v, want %v", loaded.DefaultLabels, want)
+	}
+
+	got := loaded.WithDefaultLabels(map[string]string{"owner": "bob"})
+	if got["owner"] != "bob" || got["repo"] != "payments" {
+		t.Errorf("WithDefaultLabels() = %v, want -l labels over the defaults", got)
+	}
+
+	if err := os.WriteFile(path, []byte("[default_labels]\nrepo = \"has space\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil {
+		t.Error("expected error for an invalid label value")
+	}
+}
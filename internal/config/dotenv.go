@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectEnvFilePath returns the path to the project .env file.
+func ProjectEnvFilePath() string {
+	return filepath.Join("swarm", ".env")
+}
+
+// ParseEnvFile reads a .env file and returns its variables as KEY=VALUE pairs
+// in file order. Supported syntax: blank lines, # comments, an optional
+// `export ` prefix, and single- or double-quoted values. Double-quoted values
+// expand \n, \t, \" and \\ escapes; unquoted values have trailing " #" comments
+// stripped.
+func ParseEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var vars []string
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNum)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		vars = append(vars, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// parseEnvValue unquotes a .env value.
+func parseEnvValue(v string) (string, error) {
+	if len(v) == 0 {
+		return "", nil
+	}
+
+	switch v[0] {
+	case '\'':
+		end := strings.IndexByte(v[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return v[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(v); i++ {
+			c := v[i]
+			if c == '"' {
+				return b.String(), nil
+			}
+			if c == '\\' && i+1 < len(v) {
+				i++
+				switch v[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(v[i])
+				}
+				continue
+			}
+			b.WriteByte(c)
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	if idx := strings.Index(v, " #"); idx >= 0 {
+		v = strings.TrimSpace(v[:idx])
+	}
+	return v, nil
+}
+
+// EnvFileVarsEnv is the environment variable listing the names of the
+// variables loaded from env files, for child swarm processes to pick up.
+const EnvFileVarsEnv = "SWARM_ENV_FILE_VARS"
+
+// LoadEnvFiles loads variables from the project .env file (if present) and the
+// given env files (which must exist) into the process environment, so they are
+// visible to swarm itself and inherited by agents. Variables already set in the
+// environment are never overridden, and earlier files in paths take precedence
+// over later ones and over the project .env file.
+// Returns the names of all variables the files define, including those already
+// set, plus those listed in EnvFileVarsEnv. The result is exported as
+// EnvFileVarsEnv: a detached child inherits the variables its parent loaded,
+// but not its --env-file flags, and still needs their names to forward them to
+// container and Kubernetes agents.
+func LoadEnvFiles(paths []string) ([]string, error) {
+	files := append([]string(nil), paths...)
+	if _, err := os.Stat(ProjectEnvFilePath()); err == nil {
+		files = append(files, ProjectEnvFilePath())
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, path := range files {
+		vars, err := ParseEnvFile(path)
+		if err != nil {
+			return names, fmt.Errorf("failed to load env file: %w", err)
+		}
+		for _, kv := range vars {
+			key, value, _ := strings.Cut(kv, "=")
+			if !seen[key] {
+				seen[key] = true
+				names = append(names, key)
+			}
+			if _, exists := os.LookupEnv(key); exists {
+				continue
+			}
+			if err := os.Setenv(key, value); err != nil {
+				return names, err
+			}
+		}
+	}
+
+	for _, key := range strings.Split(os.Getenv(EnvFileVarsEnv), ",") {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			names = append(names, key)
+		}
+	}
+	if len(names) > 0 {
+		if err := os.Setenv(EnvFileVarsEnv, strings.Join(names, ",")); err != nil {
+			return names, err
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# secrets
+ANTHROPIC_API_KEY=sk-123
+export MODEL=opus
+
+SINGLE='raw \n value'
+DOUBLE="line1\nline2 \"quoted\""
+INLINE=value # trailing comment
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := ParseEnvFile(path)
+	if err != nil {
+		t.Fatalf("ParseEnvFile failed: %v", err)
+	}
+
+	want := []string{
+		"ANTHROPIC_API_KEY=sk-123",
+		"MODEL=opus",
+		"SINGLE=raw \\n value",
+		"DOUBLE=line1\nline2 \"quoted\"",
+		"INLINE=value",
+		"EMPTY=",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("ParseEnvFile() = %q, want %q", vars, want)
+	}
+}
+
+func TestParseEnvFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("NOT A VAR\n"), 0644)
+
+	if _, err := ParseEnvFile(path); err == nil {
+		t.Error("expected error for line without '='")
+	}
+}
+
+func TestLoadEnvFilesDoesNotOverride(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	os.WriteFile(first, []byte("SWARM_TEST_A=first\n"), 0644)
+	os.WriteFile(second, []byte("SWARM_TEST_A=second\nSWARM_TEST_B=second\nSWARM_TEST_SHELL=file\n"), 0644)
+
+	t.Setenv("SWARM_TEST_SHELL", "shell")
+	t.Setenv(EnvFileVarsEnv, "")
+	t.Setenv("SWARM_TEST_A", "")
+	os.Unsetenv("SWARM_TEST_A")
+	t.Setenv("SWARM_TEST_B", "")
+	os.Unsetenv("SWARM_TEST_B")
+
+	names, err := LoadEnvFiles([]string{first, second})
+	if err != nil {
+		t.Fatalf("LoadEnvFiles failed: %v", err)
+	}
+
+	if got := os.Getenv("SWARM_TEST_A"); got != "first" {
+		t.Errorf("SWARM_TEST_A = %q, want first (earlier file wins)", got)
+	}
+	if got := os.Getenv("SWARM_TEST_B"); got != "second" {
+		t.Errorf("SWARM_TEST_B = %q, want second", got)
+	}
+	if got := os.Getenv("SWARM_TEST_SHELL"); got != "shell" {
+		t.Errorf("SWARM_TEST_SHELL = %q, want shell (environment wins)", got)
+	}
+	// Names include variables the environment already had
+	if !reflect.DeepEqual(names, []string{"SWARM_TEST_A", "SWARM_TEST_B", "SWARM_TEST_SHELL"}) {
+		t.Errorf("names = %v", names)
+	}
+
+	// A detached child inherits the loaded variables and their names, without
+	// the --env-file flags
+	names, err = LoadEnvFiles(nil)
+	if err != nil {
+		t.Fatalf("LoadEnvFiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"SWARM_TEST_A", "SWARM_TEST_B", "SWARM_TEST_SHELL"}) {
+		t.Errorf("names in a child = %v", names)
+	}
+
+	if _, err := LoadEnvFiles([]string{filepath.Join(dir, "missing.env")}); err == nil {
+		t.Error("expected error for missing --env-file")
+	}
+}
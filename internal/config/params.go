@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Model params tune the model per task (compose `params:`, swarm run
+// --param). Each backend passes the ones its agent CLI supports as flags
+// (CommandConfig.ParamArgs) or environment variables (CommandConfig.ParamEnv).
+const (
+	ParamTemperature     = "temperature"
+	ParamMaxOutputTokens = "max_output_tokens"
+	ParamThinkingBudget  = "thinking_budget"
+)
+
+// ValidParams returns the names of the model params.
+func ValidParams() []string {
+	return []string{ParamTemperature, ParamMaxOutputTokens, ParamThinkingBudget}
+}
+
+// ValidateParam checks a model param's name and value.
+func ValidateParam(name, value string) error {
+	switch name {
+	case ParamTemperature:
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t < 0 || t > 2 {
+			return fmt.Errorf("%s must be a number from 0 to 2, got %q", name, value)
+		}
+	case ParamMaxOutputTokens, ParamThinkingBudget:
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("%s must be a positive number of tokens, got %q", name, value)
+		}
+	default:
+		return validateParamName(name)
+	}
+	return nil
+}
+
+// validateParamName returns an error unless name is a model param.
+func validateParamName(name string) error {
+	for _, valid := range ValidParams() {
+		if name == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown param %q (valid params: %s)", name, strings.Join(ValidParams(), ", "))
+}
+
+// ValidateParams checks each of params with ValidateParam.
+func ValidateParams(params map[string]string) error {
+	for _, name := range sortedKeys(params) {
+		if err := ValidateParam(name, params[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseParams parses and validates model params in key=value form.
+func ParseParams(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	params := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid param %q: expected key=value", v)
+		}
+		if err := ValidateParam(name, value); err != nil {
+			return nil, err
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// WithParams returns a copy of the command config that passes params to the
+// agent CLI, appending their ParamArgs with {value} replaced and setting
+// their ParamEnv variables. Params the backend has neither for are left out
+// (see UnsupportedParams).
+func (c CommandConfig) WithParams(params map[string]string) CommandConfig {
+	if len(params) == 0 {
+		return c
+	}
+	args := append([]string{}, c.Args...)
+	env := append([]string(nil), c.Env...)
+	for _, name := range sortedKeys(params) {
+		value := params[name]
+		for _, arg := range c.ParamArgs[name] {
+			args = append(args, strings.ReplaceAll(arg, "{value}", value))
+		}
+		if variable, ok := c.ParamEnv[name]; ok {
+			env = append(env, variable+"="+value)
+		}
+	}
+	c.Args, c.Env = args, env
+	return c
+}
+
+// UnsupportedParams returns the names of the params the backend can't pass
+// to its agent CLI, sorted.
+func (c CommandConfig) UnsupportedParams(params map[string]string) []string {
+	var unsupported []string
+	for _, name := range sortedKeys(params) {
+		_, hasArgs := c.ParamArgs[name]
+		_, hasEnv := c.ParamEnv[name]
+		if !hasArgs && !hasEnv {
+			unsupported = append(unsupported, name)
+		}
+	}
+	return unsupported
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
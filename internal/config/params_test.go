@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams([]string{"temperature=0", "max_output_tokens=4096"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"temperature": "0", "max_output_tokens": "4096"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("ParseParams() = %v, want %v", params, want)
+	}
+
+	for _, bad := range []string{"temperature", "temperature=-1", "temperature=hot", "thinking_budget=0", "top_p=0.9"} {
+		if _, err := ParseParams([]string{bad}); err == nil {
+			t.Errorf("ParseParams(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestWithParams(t *testing.T) {
+	cmd := CommandConfig{
+		Args:      []string{"--model", "{model}", "{prompt}"},
+		ParamArgs: map[string][]string{ParamTemperature: {"--temperature", "{value}"}},
+		ParamEnv:  map[string]string{ParamThinkingBudget: "MAX_THINKING_TOKENS"},
+	}
+	params := map[string]string{
+		ParamTemperature:     "0.2",
+		ParamThinkingBudget:  "8000",
+		ParamMaxOutputTokens: "4096",
+	}
+
+	got := cmd.WithParams(params)
+	wantArgs := []string{"--model", "{model}", "{prompt}", "--temperature", "0.2"}
+	if !reflect.DeepEqual(got.Args, wantArgs) {
+		t.Errorf("Args = %q, want %q", got.Args, wantArgs)
+	}
+	if !reflect.DeepEqual(got.Env, []string{"MAX_THINKING_TOKENS=8000"}) {
+		t.Errorf("Env = %q", got.Env)
+	}
+	if len(cmd.Args) != 3 || cmd.Env != nil {
+		t.Errorf("WithParams modified the original config: %+v", cmd)
+	}
+	if unsupported := cmd.UnsupportedParams(params); !reflect.DeepEqual(unsupported, []string{ParamMaxOutputTokens}) {
+		t.Errorf("UnsupportedParams() = %q, want [max_output_tokens]", unsupported)
+	}
+
+	if got := cmd.WithParams(nil); !reflect.DeepEqual(got, cmd) {
+		t.Errorf("WithParams(nil) = %+v, want the config unchanged", got)
+	}
+}
+
+func TestParamCommandRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swarm.toml")
+
+	cfg := CodexConfig()
+	cfg.Command.ParamArgs[ParamTemperature] = []string{"-c", "temperature={value}"}
+	cfg.Command.ParamEnv = map[string]string{ParamThinkingBudget: "THINKING"}
+
+	if err := os.WriteFile(path, []byte(cfg.ToTOML()), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded := DefaultConfig()
+	if err := loadConfigFile(path, loaded); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Command.ParamArgs, cfg.Command.ParamArgs) {
+		t.Errorf("ParamArgs = %q, want %q", loaded.Command.ParamArgs, cfg.Command.ParamArgs)
+	}
+	if !reflect.DeepEqual(loaded.Command.ParamEnv, cfg.Command.ParamEnv) {
+		t.Errorf("ParamEnv = %q, want %q", loaded.Command.ParamEnv, cfg.Command.ParamEnv)
+	}
+
+	if err := os.WriteFile(path, []byte("[command.param_env]\ntop_k = \"TOP_K\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := loadConfigFile(path, DefaultConfig()); err == nil || !strings.Contains(err.Error(), "param_env") {
+		t.Errorf("expected param_env error for an unknown param, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package dag
+
+import (
+	"fmt"
+
+	"github.com/mj1618/swarm-cli/internal/output"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
+)
+
+// skipBackedUpTasks skips the ready tasks with a max_pending that the todo
+// queue exceeds, so a planner doesn't add to a backlog the doers can't
+// drain, and returns the tasks left to run.
+func (e *Executor) skipBackedUpTasks(graph *Graph, ready []string, tracker *StateTracker, writers *output.WriterGroup, iteration int) []string {
+	todoDir, _ := e.cfg.AppConfig.TodoQueueDirs(e.cfg.WorkingDir)
+	run := ready[:0:0]
+	for _, name := range ready {
+		task, _ := graph.GetTask(name)
+		if task.MaxPending > 0 {
+			if pending, err := todoqueue.Pending(todoDir); err == nil && pending > task.MaxPending {
+				tracker.SetSkipped(name)
+				e.taskStatusChanged(name, iteration, TaskSkipped, nil)
+				writer := writers.Get(name)
+				fmt.Fprintf(writer, "Skipped (%d tasks pending, max_pending %d)\n", pending, task.MaxPending)
+				writer.Flush()
+				continue
+			}
+		}
+		run = append(run, name)
+	}
+	return run
+}
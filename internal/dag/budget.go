@@ -0,0 +1,99 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// startIterationBudget gives an iteration of a pipeline with a budget the
+// context its tasks' agents run in, which is cancelled if the iteration runs
+// on for agent.BudgetGracePeriod after the budget is exceeded. The returned
+// function ends the iteration's grace period.
+func (e *Executor) startIterationBudget() (finish func()) {
+	if e.budget == nil {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(e.context())
+	guard := agent.NewBudgetGuard(cancel)
+	e.mu.Lock()
+	e.iterCtx = ctx
+	e.budgetGuard = guard
+	e.mu.Unlock()
+	return func() {
+		_ = guard.Stop()
+		cancel()
+		e.mu.Lock()
+		e.iterCtx = nil
+		e.budgetGuard = nil
+		e.mu.Unlock()
+	}
+}
+
+// taskContext returns the context tasks' agents run in: the pipeline's,
+// cancelled early when an iteration over budget runs on too long.
+// Must be called with e.mu held.
+func (e *Executor) taskContext() context.Context {
+	if e.iterCtx != nil {
+		return e.iterCtx
+	}
+	return e.context()
+}
+
+// budgetExceeded describes the pipeline budget cap reached by the completed
+// and running tasks' usage, or returns "" if it's within the budget.
+// Must be called with e.mu held.
+func (e *Executor) budgetExceeded() string {
+	if e.budget == nil {
+		return ""
+	}
+	input, output, cost := e.inputTokens, e.outputTokens, e.totalCostUSD
+	for _, s := range e.taskStats {
+		input += s.InputTokens
+		output += s.OutputTokens
+		cost += s.TotalCostUSD
+	}
+	// Estimate the cost as persistUsageState does if the agents report none
+	if cost == 0 && e.cfg.AppConfig != nil {
+		cost = e.cfg.AppConfig.GetPricing(e.cfg.AppConfig.Model).CalculateCost(input, output)
+	}
+	return e.budget.Exceeded(cost, input+output)
+}
+
+// checkBudget starts the running iteration's grace period once the pipeline
+// exceeds its budget. Must be called with e.mu held.
+func (e *Executor) checkBudget() {
+	if e.budgetGuard == nil {
+		return
+	}
+	if reason := e.budgetExceeded(); reason != "" && e.budgetGuard.Exceeded(reason) {
+		fmt.Fprintf(e.cfg.Output, "\n[swarm] Pipeline %s, stopping after this iteration (or in %v)\n", reason, agent.BudgetGracePeriod)
+	}
+}
+
+// budgetErr returns the error for a task whose agent was stopped because the
+// iteration ran on too long over budget, or nil.
+func (e *Executor) budgetErr() error {
+	e.mu.Lock()
+	guard := e.budgetGuard
+	e.mu.Unlock()
+	if guard == nil {
+		return nil
+	}
+	return guard.Err()
+}
+
+// setPipelineBudget records the pipeline's budget on its agent, so 'swarm
+// list' and 'swarm top' show how much of it has been used.
+func (e *Executor) setPipelineBudget(budget *state.Budget) {
+	e.budget = budget
+	if budget == nil || e.cfg.StateManager == nil || e.cfg.TaskID == "" {
+		return
+	}
+	if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+		agentState.Budget = budget
+		_ = e.cfg.StateManager.MergeUpdate(agentState)
+	}
+}
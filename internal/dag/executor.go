@@ -1,20 +1,28 @@
 package dag
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mj1618/swarm-cli/internal/agent"
 	"github.com/mj1618/swarm-cli/internal/compose"
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/exitreport"
 	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/notify"
 	"github.com/mj1618/swarm-cli/internal/output"
 	"github.com/mj1618/swarm-cli/internal/prompt"
+	"github.com/mj1618/swarm-cli/internal/scratch"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/worktree"
 )
 
 // ExecutorConfig holds the configuration for running a pipeline.
@@ -31,6 +39,11 @@ type ExecutorConfig struct {
 	// Output is the writer for pipeline output (defaults to os.Stdout)
 	Output io.Writer
 
+	// StderrFile receives the agent CLIs' stderr (optional). Detached runs
+	// set it so stderr doesn't end up in the JSONL log; empty uses this
+	// process's stderr.
+	StderrFile string
+
 	// Verbose enables verbose output
 	Verbose bool
 
@@ -39,6 +52,52 @@ type ExecutorConfig struct {
 
 	// TaskID is the agent state ID to update during execution (optional)
 	TaskID string
+
+	// PipelineName is the name recorded for the run (optional)
+	PipelineName string
+
+	// StateDir is where per-iteration output directories (SWARM_STATE_DIR)
+	// are created. Relative paths are resolved against WorkingDir; empty
+	// uses OutputsRoot.
+	StateDir string
+
+	// RunID identifies the run in state. A new ID is generated when empty.
+	// Runs are only recorded when StateManager is set.
+	RunID string
+
+	// SharedIterations hands out iteration numbers shared with other instances
+	// of the same pipeline (optional). When set, the pipeline keeps running
+	// until the counter's limit is reached instead of counting on its own.
+	SharedIterations IterationCounter
+
+	// Stdin is content read once from stdin (`swarm up --stdin`) that
+	// replaces {{stdin}} in every task's prompt.
+	Stdin string
+
+	// Matrix holds the values of the pipeline matrix instance being run,
+	// which replace {{matrix.key}} in every task's prompt (optional).
+	Matrix map[string]string
+
+	// Context stops the pipeline and kills its running agents when it's
+	// done, e.g. on a total timeout (optional).
+	Context context.Context
+
+	// FailFast stops the pipeline with an error as soon as a task fails,
+	// instead of continuing with the tasks that don't depend on it.
+	FailFast bool
+
+	// OnTaskStatus is called when a task starts running, succeeds, fails, or
+	// is skipped (optional). err is set for failures.
+	OnTaskStatus func(task string, iteration int, status TaskStatus, err error)
+}
+
+// IterationCounter is a counter shared between parallel pipeline instances.
+type IterationCounter interface {
+	// Claim reserves the next iteration, returning false once none are left.
+	Claim() (int, bool, error)
+
+	// Limit returns the total number of iterations across all instances.
+	Limit() int
 }
 
 // Executor runs pipelines with DAG-ordered task execution.
@@ -51,6 +110,22 @@ type Executor struct {
 	outputTokens int64
 	totalCostUSD float64
 	taskStats    map[string]logparser.UsageStats // running tasks' current stats
+	taskModels   map[string]string               // running tasks' requested models
+	modelUsage   map[string]*state.ModelUsage    // completed tasks' usage per model
+	run          *state.RunState                 // run being recorded, if any
+
+	budget      *state.Budget      // the pipeline's budget, if any
+	iterCtx     context.Context    // running iteration's context with a budget (protected by mu)
+	budgetGuard *agent.BudgetGuard // running iteration's budget guard (protected by mu)
+
+	envSummaryOnce sync.Once
+	envSummary     string // host environment summary for inject_env_summary tasks
+
+	failureStreaks map[string]int    // consecutive failures per task (protected by mu)
+	diagnoses      map[string]string // task -> pending triage diagnosis file (protected by mu)
+	unhealthy      map[string]string // task -> failing health check (protected by mu)
+
+	messages []string // `swarm exec` instructions for the running iteration (protected by mu)
 }
 
 // NewExecutor creates a new pipeline executor.
@@ -59,13 +134,32 @@ func NewExecutor(cfg ExecutorConfig) *Executor {
 		cfg.Output = os.Stdout
 	}
 	return &Executor{
-		cfg:       cfg,
-		taskStats: make(map[string]logparser.UsageStats),
+		cfg:            cfg,
+		taskStats:      make(map[string]logparser.UsageStats),
+		taskModels:     make(map[string]string),
+		failureStreaks: make(map[string]int),
+		diagnoses:      make(map[string]string),
+		unhealthy:      make(map[string]string),
+	}
+}
+
+// context returns the context the pipeline runs in.
+func (e *Executor) context() context.Context {
+	if e.cfg.Context != nil {
+		return e.cfg.Context
+	}
+	return context.Background()
+}
+
+// taskStatusChanged reports a task's new status to OnTaskStatus.
+func (e *Executor) taskStatusChanged(task string, iteration int, status TaskStatus, err error) {
+	if e.cfg.OnTaskStatus != nil {
+		e.cfg.OnTaskStatus(task, iteration, status, err)
 	}
 }
 
 // RunPipeline runs a pipeline with the given tasks for the specified iterations.
-func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compose.Task) error {
+func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compose.Task) (err error) {
 	// Initialize cumulative stats from persisted state (so costs persist between iterations)
 	if e.cfg.StateManager != nil && e.cfg.TaskID != "" {
 		if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
@@ -75,6 +169,9 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 		}
 	}
 
+	tasks = pipeline.WithEnvironment(pipeline.WithFailureDefaults(tasks))
+	e.setPipelineBudget(pipeline.Budget())
+
 	// Get task names for this pipeline
 	taskNames := pipeline.GetPipelineTasks(tasks)
 
@@ -85,24 +182,60 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 	}
 
 	iterations := pipeline.EffectiveIterations()
-	fmt.Fprintf(e.cfg.Output, "Running pipeline with %d iteration(s) and %d task(s)\n", iterations, len(taskNames))
+	shared := e.cfg.SharedIterations
+	if shared != nil {
+		iterations = shared.Limit()
+		fmt.Fprintf(e.cfg.Output, "Running pipeline with %d shared iteration(s) and %d task(s)\n", iterations, len(taskNames))
+	} else if iterations == 0 {
+		fmt.Fprintf(e.cfg.Output, "Running pipeline with unlimited iterations and %d task(s)\n", len(taskNames))
+	} else {
+		fmt.Fprintf(e.cfg.Output, "Running pipeline with %d iteration(s) and %d task(s)\n", iterations, len(taskNames))
+	}
 
 	terminated := false
+	overBudget := false
+	completed := 0
 
-	// Run each iteration
-	for i := 1; i <= iterations; i++ {
+	e.startRun(pipeline, iterations)
+	defer func() { e.finishRun(completed, terminated, err) }()
+
+	// Run each iteration (iterations == 0 means run until stopped). With a
+	// shared counter, keep claiming iterations until the counter runs out.
+	for i := 1; shared != nil || iterations == 0 || i <= iterations; i++ {
 		// Check for pause/terminate between iterations
 		if e.checkPipelineControl() {
 			terminated = true
 			break
 		}
+		if err := e.context().Err(); err != nil {
+			return fmt.Errorf("pipeline stopped: %w", err)
+		}
+		e.mu.Lock()
+		reason := e.budgetExceeded()
+		e.mu.Unlock()
+		if reason != "" {
+			fmt.Fprintf(e.cfg.Output, "[swarm] Stopping pipeline: %s\n", reason)
+			overBudget = true
+			break
+		}
+
+		if shared != nil {
+			n, ok, err := shared.Claim()
+			if err != nil {
+				return fmt.Errorf("failed to claim shared iteration: %w", err)
+			}
+			if !ok {
+				break
+			}
+			i = n
+		}
 
 		// Create a unique, time-sortable output directory per iteration
-		runID := time.Now().Format("20060102-150405") + "-" + state.GenerateID()
-		outputDir := filepath.Join(os.TempDir(), "swarm", "outputs", runID)
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return fmt.Errorf("failed to create output directory: %w", err)
+		outputDir, err := newOutputDir(e.outputsRoot(), e.cfg.PipelineName, e.cfg.WorkingDir)
+		if err != nil {
+			return err
 		}
+		e.recordRunIteration(outputDir)
 
 		// Update state with current iteration and check for iteration limit changes
 		if e.cfg.StateManager != nil && e.cfg.TaskID != "" {
@@ -111,7 +244,8 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 				_ = e.cfg.StateManager.MergeUpdate(agentState)
 
 				// Re-read state to pick up externally changed iteration limit
-				if updated, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+				// (a shared counter owns the limit, so it can't change here)
+				if updated, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil && shared == nil {
 					if updated.Iterations != 0 && updated.Iterations != iterations {
 						iterations = updated.Iterations
 						if i > iterations {
@@ -122,9 +256,17 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 			}
 		}
 
-		fmt.Fprintf(e.cfg.Output, "\n=== Pipeline Iteration %d/%d ===\n", i, iterations)
+		fmt.Fprintf(e.cfg.Output, "\n=== Pipeline Iteration %d/%s ===\n", i, formatIterationLimit(iterations))
+		e.takeMessages()
 
-		dagTerminated, err := e.runDAG(graph, taskNames, i, iterations, outputDir)
+		iterStarted := time.Now()
+		usageBefore := e.snapshotUsage()
+
+		stopSlowWatch := e.watchSlowIteration(i, iterStarted)
+		finishBudget := e.startIterationBudget()
+		states, dagTerminated, err := e.runDAG(graph, taskNames, i, iterations, outputDir)
+		finishBudget()
+		stopSlowWatch()
 		if err != nil {
 			return fmt.Errorf("iteration %d failed: %w", i, err)
 		}
@@ -133,7 +275,14 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 			break
 		}
 
+		e.recordIterationSummary(e.newIterationSummary(i, states, usageBefore, iterStarted), iterations)
 		fmt.Fprintf(e.cfg.Output, "--- Iteration %d complete ---\n", i)
+		completed++
+
+		if stop, reason := e.checkStopConditions(pipeline.StopWhen); stop {
+			fmt.Fprintf(e.cfg.Output, "[swarm] Stop condition met: %s\n", reason)
+			break
+		}
 	}
 
 	// Mark pipeline as terminated on completion
@@ -144,6 +293,8 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 			agentState.TerminatedAt = &now
 			if terminated {
 				agentState.ExitReason = "killed"
+			} else if overBudget {
+				agentState.ExitReason = agent.FailureBudget
 			} else {
 				agentState.ExitReason = "completed"
 			}
@@ -154,11 +305,37 @@ func (e *Executor) RunPipeline(pipeline compose.Pipeline, tasks map[string]compo
 	if terminated {
 		fmt.Fprintf(e.cfg.Output, "\nPipeline terminated\n")
 	} else {
-		fmt.Fprintf(e.cfg.Output, "\nPipeline completed successfully (%d iterations)\n", iterations)
+		fmt.Fprintf(e.cfg.Output, "\nPipeline completed successfully (%d iterations)\n", completed)
 	}
 	return nil
 }
 
+// RunNode runs a single pipeline task on its own, e.g. to debug one failing
+// stage. {{output:...}} and {{artifact:...}} directives resolve against
+// copies of the outputs in sourceDir (typically LatestOutputDirIn), so the
+// original run is left untouched.
+// An empty sourceDir runs the task with missing-output placeholders.
+func (e *Executor) RunNode(taskName string, task compose.Task, sourceDir string) error {
+	outputDir, err := newOutputDir(e.outputsRoot(), e.cfg.PipelineName, e.cfg.WorkingDir)
+	if err != nil {
+		return err
+	}
+	if sourceDir != "" {
+		if err := copyOutputs(sourceDir, outputDir); err != nil {
+			return fmt.Errorf("failed to copy outputs from %s: %w", sourceDir, err)
+		}
+	}
+
+	_, err = e.runTask(taskName, task, e.cfg.Output, 1, 1, outputDir, false)
+	fmt.Fprintf(e.cfg.Output, "\nOutputs: %s\n", outputDir)
+	return err
+}
+
+// outputsRoot returns the directory per-iteration output directories are created in.
+func (e *Executor) outputsRoot() string {
+	return ResolveOutputsRoot(e.cfg.StateDir, e.cfg.WorkingDir)
+}
+
 // checkPipelineControl checks for pause/terminate signals from state.
 // If paused, it blocks until resumed or terminated.
 // Returns true if the pipeline should be terminated.
@@ -183,14 +360,22 @@ func (e *Executor) checkPipelineControl() bool {
 	}
 
 	// Enter pause state — set PausedAt to acknowledge
-	fmt.Fprintf(e.cfg.Output, "\n[swarm] Pipeline paused, waiting for resume...\n")
+	if agentState.Approval != "" {
+		fmt.Fprintf(e.cfg.Output, "\n[swarm] Pipeline paused, waiting for approval: %s\n", agentState.Approval)
+	} else if agentState.ResumeAt != nil {
+		fmt.Fprintf(e.cfg.Output, "\n[swarm] Pipeline paused until %s, waiting for resume...\n", agentState.ResumeAt.Format(time.DateTime))
+	} else {
+		fmt.Fprintf(e.cfg.Output, "\n[swarm] Pipeline paused, waiting for resume...\n")
+	}
 	now := time.Now()
 	agentState.PausedAt = &now
 	_ = e.cfg.StateManager.MergeUpdate(agentState)
+	e.notify(notify.PauseEvent(e.cfg.TaskID, agentState), e.cfg.Output)
 
 	// Sleep loop until resumed or terminated
 	for {
 		time.Sleep(1 * time.Second)
+		_, _ = e.cfg.StateManager.ResumeIfDue(e.cfg.TaskID)
 		agentState, err = e.cfg.StateManager.Get(e.cfg.TaskID)
 		if err != nil {
 			break
@@ -220,8 +405,9 @@ func (e *Executor) checkPipelineControl() bool {
 }
 
 // runDAG executes a single DAG iteration.
-// Returns (terminated, error) where terminated is true if a terminate signal was received.
-func (e *Executor) runDAG(graph *Graph, taskNames []string, iteration, totalIterations int, outputDir string) (bool, error) {
+// Returns (states, terminated, error) where states holds the final task states
+// and terminated is true if a terminate signal was received.
+func (e *Executor) runDAG(graph *Graph, taskNames []string, iteration, totalIterations int, outputDir string) (*StateTracker, bool, error) {
 	// Initialize state tracker
 	states := NewStateTracker(taskNames)
 
@@ -231,17 +417,28 @@ func (e *Executor) runDAG(graph *Graph, taskNames []string, iteration, totalIter
 	for {
 		// Check for pause/terminate before scheduling new tasks
 		if e.checkPipelineControl() {
-			return true, nil
+			return states, true, nil
+		}
+		if err := e.context().Err(); err != nil {
+			return states, false, err
 		}
 
 		// Get current states
 		currentStates := states.GetAll()
 
 		// Check for tasks that should be skipped
-		e.skipBlockedTasks(graph, states, currentStates, writers)
+		e.skipBlockedTasks(graph, states, currentStates, writers, iteration)
 
-		// Find tasks ready to run
+		// Find tasks ready to run, skipping planners whose todo queue is
+		// backed up (max_pending)
 		readyTasks := graph.FindReadyTasks(currentStates)
+		if len(readyTasks) > 0 {
+			readyTasks = e.skipBackedUpTasks(graph, readyTasks, states, writers, iteration)
+			if len(readyTasks) == 0 {
+				// All were skipped: their dependents may be ready or blocked now
+				continue
+			}
+		}
 
 		if len(readyTasks) == 0 {
 			// No more ready tasks - check if we're done
@@ -252,13 +449,16 @@ func (e *Executor) runDAG(graph *Graph, taskNames []string, iteration, totalIter
 			// If there are pending tasks but none ready, there might be a deadlock
 			summary := states.GetSummary()
 			if summary.Pending > 0 {
-				return false, fmt.Errorf("deadlock: %d pending task(s) but none ready", summary.Pending)
+				return states, false, fmt.Errorf("deadlock: %d pending task(s) but none ready", summary.Pending)
 			}
 			break
 		}
 
 		// Execute ready tasks in parallel
 		if err := e.executeTasks(graph, readyTasks, states, writers, iteration, totalIterations, outputDir); err != nil {
+			if e.cfg.FailFast {
+				return states, false, err
+			}
 			// Log error but continue - individual task failures don't stop the DAG
 			fmt.Fprintf(e.cfg.Output, "Warning: task execution error: %v\n", err)
 		}
@@ -269,11 +469,11 @@ func (e *Executor) runDAG(graph *Graph, taskNames []string, iteration, totalIter
 	fmt.Fprintf(e.cfg.Output, "Tasks: %d succeeded, %d failed, %d skipped\n",
 		summary.Succeeded, summary.Failed, summary.Skipped)
 
-	return false, nil
+	return states, false, nil
 }
 
 // skipBlockedTasks marks tasks as skipped if their dependency conditions can't be met.
-func (e *Executor) skipBlockedTasks(graph *Graph, tracker *StateTracker, currentStates map[string]*TaskState, writers *output.WriterGroup) {
+func (e *Executor) skipBlockedTasks(graph *Graph, tracker *StateTracker, currentStates map[string]*TaskState, writers *output.WriterGroup, iteration int) {
 	for _, task := range graph.GetNodes() {
 		state := currentStates[task]
 		if state == nil || state.Status != TaskPending {
@@ -282,6 +482,7 @@ func (e *Executor) skipBlockedTasks(graph *Graph, tracker *StateTracker, current
 
 		if graph.ShouldSkip(task, currentStates) {
 			tracker.SetSkipped(task)
+			e.taskStatusChanged(task, iteration, TaskSkipped, nil)
 			writer := writers.Get(task)
 			fmt.Fprintf(writer, "Skipped (dependency condition not met)\n")
 			writer.Flush()
@@ -289,12 +490,20 @@ func (e *Executor) skipBlockedTasks(graph *Graph, tracker *StateTracker, current
 	}
 }
 
+// poolLockName returns the lock name for a concurrency pool's slots, kept
+// apart from task names so a pool and a task with the same name don't share slots.
+func poolLockName(pool string) string {
+	return "pool@" + pool
+}
+
 // executeTasks runs multiple tasks in parallel.
 func (e *Executor) executeTasks(graph *Graph, taskNames []string, tracker *StateTracker, writers *output.WriterGroup, iteration, totalIterations int, outputDir string) error {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var errors []error
 
+	shared := sharedTreeTasks(graph, taskNames, e.cfg.WorkingDir)
+
 	for i, taskName := range taskNames {
 		task, ok := graph.GetTask(taskName)
 		if !ok {
@@ -308,6 +517,7 @@ func (e *Executor) executeTasks(graph *Graph, taskNames []string, tracker *State
 
 		writer := writers.Get(taskName)
 		tracker.SetRunning(taskName)
+		e.taskStatusChanged(taskName, iteration, TaskRunning, nil)
 
 		wg.Add(1)
 		go func(name string, t compose.Task, out *output.PrefixedWriter) {
@@ -322,17 +532,47 @@ func (e *Executor) executeTasks(graph *Graph, taskNames []string, tracker *State
 			AcquireTaskSlot(name, concurrencyLimit)
 			defer ReleaseTaskSlot(name, concurrencyLimit)
 
+			// Acquire a slot in each of the task's pools, in name order so
+			// tasks sharing several pools can't deadlock
+			if e.cfg.AppConfig != nil && len(t.Labels) > 0 {
+				for _, pool := range e.cfg.AppConfig.PoolsFor(t.Labels) {
+					fmt.Fprintf(out, "Waiting for slot in pool %s...\n", pool.Name)
+					AcquireTaskSlot(poolLockName(pool.Name), pool.Max)
+					defer ReleaseTaskSlot(poolLockName(pool.Name), pool.Max)
+				}
+			}
+
 			fmt.Fprintf(out, "Starting (iteration %d)\n", iteration)
 
-			err := e.runTask(name, t, out, iteration, totalIterations, outputDir)
+			// Keep the end of the task's output for its triage agent
+			var taskOut io.Writer = out
+			var logTail *tailBuffer
+			if t.OnFailurePrompt != "" {
+				logTail = newTailBuffer(triageLogBytes)
+				taskOut = io.MultiWriter(out, logTail)
+			}
+
+			structured, err := e.runTask(name, t, taskOut, iteration, totalIterations, outputDir, shared[name])
 			if err != nil {
-				tracker.SetFailed(name, err)
 				fmt.Fprintf(out, "Failed: %v\n", err)
+				var tail string
+				if logTail != nil {
+					tail = logTail.String()
+				}
+				e.triageIfDue(name, t, err, tail, out, iteration, outputDir)
+				if structured != nil {
+					tracker.SetOutput(name, structured)
+				}
+				tracker.SetFailed(name, err)
+				e.taskStatusChanged(name, iteration, TaskFailed, err)
 				mu.Lock()
 				errors = append(errors, fmt.Errorf("%s: %w", name, err))
 				mu.Unlock()
 			} else {
+				e.recordTaskOutcome(name, false)
+				tracker.SetOutput(name, structured)
 				tracker.SetSucceeded(name)
+				e.taskStatusChanged(name, iteration, TaskSucceeded, nil)
 				fmt.Fprintf(out, "Completed\n")
 			}
 		}(taskName, task, writer)
@@ -346,73 +586,371 @@ func (e *Executor) executeTasks(graph *Graph, taskNames []string, tracker *State
 	return nil
 }
 
-// runTask executes a single task.
-func (e *Executor) runTask(taskName string, task compose.Task, out io.Writer, iteration, totalIterations int, outputDir string) error {
-	// Generate task ID
-	taskID := state.GenerateID()
+// sharedTreeTasks returns the tasks of a batch run in parallel that share
+// their working directory with another of them. Tasks in their own worktree
+// share it with none.
+func sharedTreeTasks(graph *Graph, taskNames []string, workingDir string) map[string]bool {
+	byDir := make(map[string][]string)
+	for _, name := range taskNames {
+		task, ok := graph.GetTask(name)
+		if !ok || task.Isolation == compose.IsolationWorktree {
+			continue
+		}
+		dir := task.Repo
+		if dir == "" {
+			dir = workingDir
+		}
+		dir = filepath.Clean(dir)
+		byDir[dir] = append(byDir[dir], name)
+	}
 
-	// Load prompt content
-	promptContent, _, err := e.loadTaskPrompt(task)
-	if err != nil {
-		return err
+	shared := make(map[string]bool)
+	for _, names := range byDir {
+		if len(names) < 2 {
+			continue
+		}
+		for _, name := range names {
+			shared[name] = true
+		}
 	}
+	return shared
+}
 
-	// Process {{output:task_name}} directives before other injections
-	promptContent, err = prompt.ProcessOutputDirectives(promptContent, outputDir)
+// runTask executes a single task and returns its structured output, if any.
+// sharedTree is set when tasks running alongside it edit the same working
+// directory.
+func (e *Executor) runTask(taskName string, task compose.Task, out io.Writer, iteration, totalIterations int, outputDir string, sharedTree bool) (map[string]interface{}, error) {
+	promptContent, err := e.buildTaskPrompt(taskName, task, iteration, totalIterations, outputDir, taskPromptFull)
 	if err != nil {
-		return fmt.Errorf("failed to process output directives: %w", err)
+		return nil, err
 	}
 
-	// Inject task ID into prompt
-	promptContent = prompt.InjectTaskID(promptContent, taskID)
-
 	// Determine effective model
 	effectiveModel := e.cfg.AppConfig.Model
 	if task.Model != "" {
 		effectiveModel = task.Model
 	}
 
-	// Generate agent ID and inject it
-	agentID := state.GenerateID()
-	promptContent = prompt.InjectAgentID(promptContent, agentID)
-	promptContent = prompt.InjectIteration(promptContent, iteration, totalIterations)
+	criteria, err := agent.NewResultCriteria(task.FailOnSubtype, task.FailOnResultRegex)
+	if err != nil {
+		return nil, err
+	}
 
-	// Inject the output directory so the agent can write its own state
-	promptContent = prompt.InjectOutputDir(promptContent, outputDir, taskName)
+	// With worktree isolation the task's agent works on its own branch, so
+	// parallel pipeline instances don't clobber each other's edits
+	if task.Isolation == compose.IsolationWorktree {
+		baseDir := task.Repo
+		if baseDir == "" {
+			baseDir = e.cfg.WorkingDir
+		}
+		wt, wtErr := worktree.Create(baseDir, taskName+"-"+state.GenerateID())
+		if wtErr != nil {
+			return nil, wtErr
+		}
+		task.Repo = wt.Dir
+		fmt.Fprintf(out, "[swarm] Task %s working in worktree %s (branch %s)\n", taskName, wt.Path, wt.Branch)
+		defer func() {
+			if wtErr := wt.FinishIteration(iteration, task.MergeBack && err == nil); wtErr != nil {
+				fmt.Fprintf(out, "[swarm] Warning: %v\n", wtErr)
+			}
+			kept, wtErr := wt.Remove("swarm: final changes")
+			if wtErr != nil {
+				fmt.Fprintf(out, "[swarm] Warning: failed to clean up worktree: %v\n", wtErr)
+			} else if kept {
+				fmt.Fprintf(out, "[swarm] Task %s changes kept on branch %s\n", taskName, wt.Branch)
+			}
+		}()
+	}
+
+	pathGuard, err := task.PathGuard(task.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if pathGuard != nil && task.Isolation != compose.IsolationWorktree {
+		// Sibling tasks' edits would show up as this task's in git
+		pathGuard.SharedTree = sharedTree
+	}
 
 	// Create and run the agent
 	cfg := agent.Config{
-		Model:   effectiveModel,
-		Prompt:  promptContent,
-		Command: e.cfg.AppConfig.AgentCommand(),
+		Model:          effectiveModel,
+		Prompt:         promptContent,
+		Command:        e.cfg.AppConfig.AgentCommand().WithImage(task.Image).WithExtraArgs(task.ExtraArgs).WithParams(task.Params).WithReadOnly(task.ReadOnly),
+		Env:            task.EnvList(),
+		Run:            agent.RunInfo{Iteration: iteration, TotalIterations: totalIterations, TaskName: taskName, Pipeline: e.cfg.PipelineName, RunID: e.RunID(), ControlID: e.cfg.TaskID},
+		Dir:            task.Repo,
+		StateDir:       outputDir,
+		StderrFile:     e.cfg.StderrFile,
+		ResultCriteria: criteria,
+		PathGuard:      pathGuard,
+	}
+
+	// The agent may leave an exit report next to its structured output
+	cfg.ExitFile = exitreport.Path(filepath.Join(outputDir, taskName))
+	if err := os.MkdirAll(filepath.Dir(cfg.ExitFile), 0755); err != nil {
+		fmt.Fprintf(out, "[swarm] Warning: %v\n", err)
 	}
 
+	// Give the task's agent a scratch dir for throwaway files
+	scratchID := state.GenerateID()
+	if dir, err := scratch.Create(scratchID); err != nil {
+		fmt.Fprintf(out, "[swarm] Warning: %v\n", err)
+	} else {
+		cfg.Env = append(cfg.Env, scratch.Env(dir))
+		defer func() {
+			if err := scratch.Finish(scratchID, e.cfg.AppConfig.ScratchRetentionDuration()); err != nil {
+				fmt.Fprintf(out, "[swarm] Warning: failed to clean up scratch dir: %v\n", err)
+			}
+		}()
+	}
+
+	stopHealth := e.startHealthCheck(taskName, task, out)
+	defer stopHealth()
+
+	started := time.Now()
+	var stats logparser.UsageStats
+	mitigation := ""
+	for {
+		var attemptStats logparser.UsageStats
+		attemptStats, err = e.runTaskAttempt(taskName, cfg, out)
+		stats.InputTokens += attemptStats.InputTokens
+		stats.OutputTokens += attemptStats.OutputTokens
+		stats.TotalCostUSD += attemptStats.TotalCostUSD
+
+		if err == nil || mitigation != "" || !errors.Is(err, errContextOverflow) {
+			break
+		}
+
+		// Retry once with a mitigation when the agent's context overflowed
+		e.recordContextOverflow()
+		var retryErr error
+		mitigation, cfg, retryErr = e.contextOverflowRetry(taskName, task, iteration, totalIterations, outputDir, cfg)
+		if retryErr != nil {
+			return nil, retryErr
+		}
+		if mitigation == "" {
+			fmt.Fprintf(out, "\n[swarm] Context overflow in task %s (add a compact prompt variant to retry automatically)\n", taskName)
+			break
+		}
+		fmt.Fprintf(out, "\n[swarm] Context overflow in task %s, retrying with %s\n", taskName, mitigation)
+	}
+
+	var structured map[string]interface{}
+	if err == nil {
+		structured, err = loadStructuredOutput(out, outputDir, taskName, task.OutputSchema)
+	}
+	structured = withExitReport(structured, cfg.ExitFile)
+
+	e.recordRunTask(taskName, iteration, started, stats, mitigation, err)
+	return structured, err
+}
+
+// errContextOverflow wraps agent errors classed as context overflows.
+var errContextOverflow = errors.New("context overflow")
+
+// runTaskAttempt runs the agent for a task once and returns the usage of the
+// attempt. Errors classed as context overflows wrap errContextOverflow.
+func (e *Executor) runTaskAttempt(taskName string, cfg agent.Config, out io.Writer) (logparser.UsageStats, error) {
 	runner := agent.NewRunner(cfg)
 
 	// Set up real-time usage callback
 	runner.SetUsageCallback(func(stats logparser.UsageStats) {
 		e.mu.Lock()
 		e.taskStats[taskName] = stats
+		e.taskModels[taskName] = cfg.Model
 		e.persistUsageState()
+		e.checkBudget()
 		e.mu.Unlock()
 	})
 
-	err = runner.Run(out)
+	guard := prompt.SizeGuard{MaxTokens: e.cfg.AppConfig.MaxPromptTokens, Fail: e.cfg.AppConfig.FailOnPromptLimit()}
+	var err error
+	if sizeErr := guard.Check(cfg.Prompt); sizeErr != nil && guard.Fail {
+		err = sizeErr
+	} else {
+		if sizeErr != nil {
+			fmt.Fprintf(out, "[swarm] Warning: %v\n", sizeErr)
+		}
+		e.mu.Lock()
+		ctx := e.taskContext()
+		e.mu.Unlock()
+		err = runner.RunWithContext(ctx, out)
+		if budgetErr := e.budgetErr(); err != nil && budgetErr != nil {
+			err = budgetErr
+		}
+	}
+	if err != nil {
+		runner.PrintStderrTail(out)
+		if runner.ClassifyFailure(err) == agent.FailureContextOverflow {
+			err = fmt.Errorf("%w: %w", errContextOverflow, err)
+		}
+	}
 
 	// Move this task's final stats from running to completed
 	stats := runner.UsageStats()
 	e.mu.Lock()
 	delete(e.taskStats, taskName)
+	delete(e.taskModels, taskName)
 	e.inputTokens += stats.InputTokens
 	e.outputTokens += stats.OutputTokens
 	e.totalCostUSD += stats.TotalCostUSD
+	// Attribute the usage to the model the CLI reported using, which
+	// differs from the task's model after a fallback
+	usageModel := stats.Model
+	if usageModel == "" {
+		usageModel = cfg.Model
+	}
+	e.modelUsage = state.AddModelUsage(e.modelUsage, usageModel, stats.InputTokens, stats.OutputTokens,
+		e.cfg.AppConfig.UsageCost(usageModel, stats.InputTokens, stats.OutputTokens, stats.TotalCostUSD))
 	e.persistUsageState()
+	e.checkBudget()
 	e.mu.Unlock()
+	e.checkCostAlert(out)
+	e.sampleTodoQueue(out)
 
-	return err
+	return stats, err
+}
+
+// taskPromptVariant selects how buildTaskPrompt builds a task's prompt.
+type taskPromptVariant int
+
+const (
+	taskPromptFull             taskPromptVariant = iota // the task's prompt as configured
+	taskPromptCompact                                   // the compact variant of the task's prompt
+	taskPromptTruncatedOutputs                          // the prompt with injected outputs truncated
+)
+
+// truncatedOutputBytes is how much of each injected output is kept when a
+// task is retried with truncated outputs after a context overflow.
+const truncatedOutputBytes = 8 * 1024
+
+// buildTaskPrompt loads a task's prompt and injects outputs, IDs, iteration,
+// and output directory. It returns an empty prompt if the task has no compact
+// variant and taskPromptCompact is requested.
+func (e *Executor) buildTaskPrompt(taskName string, task compose.Task, iteration, totalIterations int, outputDir string, variant taskPromptVariant) (string, error) {
+	// Load prompt content
+	var promptContent string
+	var err error
+	if variant == taskPromptCompact {
+		promptContent, err = e.loadCompactTaskPrompt(task)
+		if err != nil || promptContent == "" {
+			return "", err
+		}
+	} else {
+		promptContent, _, err = e.loadTaskPrompt(task)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Process {{output:task_name}} and {{artifact:task/path}} directives
+	// before other injections
+	maxInjected := task.MaxInjectedBytes
+	if variant == taskPromptTruncatedOutputs && (maxInjected == 0 || maxInjected > truncatedOutputBytes) {
+		maxInjected = truncatedOutputBytes
+	}
+	promptContent, err = prompt.ProcessOutputDirectivesTruncated(promptContent, outputDir, maxInjected)
+	if err != nil {
+		return "", fmt.Errorf("failed to process output directives: %w", err)
+	}
+	promptContent, err = prompt.ProcessArtifactDirectives(promptContent, outputDir, maxInjected)
+	if err != nil {
+		return "", fmt.Errorf("failed to process artifact directives: %w", err)
+	}
+
+	if task.InjectEnvSummary {
+		summary := e.hostEnvSummary()
+		if task.Repo != "" {
+			summary = prompt.EnvSummary(task.Repo)
+		}
+		promptContent = prompt.InjectEnvSummary(promptContent, summary)
+	}
+	promptContent = e.injectDiagnosis(taskName, promptContent)
+
+	// Inject task ID into prompt
+	promptContent = prompt.InjectTaskID(promptContent, state.GenerateID())
+
+	// Generate agent ID and inject it
+	promptContent = prompt.InjectAgentID(promptContent, state.GenerateID())
+	promptContent = prompt.InjectIteration(promptContent, iteration, totalIterations)
+
+	// Inject the output directory so the agent can write its own state
+	promptContent = prompt.InjectOutputDir(promptContent, outputDir, taskName)
+	promptContent = prompt.InjectStdin(promptContent, e.cfg.Stdin)
+	promptContent = prompt.InjectMatrix(promptContent, e.cfg.Matrix)
+	if task.OutputSchema != nil {
+		promptContent, err = injectOutputSchema(promptContent, outputDir, taskName, task.OutputSchema)
+		if err != nil {
+			return "", err
+		}
+	}
+	return prompt.InjectMessages(promptContent, e.iterationMessages()), nil
+}
+
+// hostEnvSummary returns the environment summary for the working directory,
+// detected once per executor.
+func (e *Executor) hostEnvSummary() string {
+	e.envSummaryOnce.Do(func() {
+		e.envSummary = prompt.EnvSummary(e.cfg.WorkingDir)
+	})
+	return e.envSummary
 }
 
-// persistUsageState writes the current total usage (completed + running tasks) to pipeline state.
+// contextOverflowRetry picks how to retry a task whose agent overflowed its
+// context window: the compact prompt variant if there is one, otherwise the
+// prompt with injected outputs truncated, otherwise a fresh session. It
+// returns the agent config for the retry, or an empty mitigation if none
+// applies.
+func (e *Executor) contextOverflowRetry(taskName string, task compose.Task, iteration, totalIterations int, outputDir string, cfg agent.Config) (string, agent.Config, error) {
+	compact, err := e.buildTaskPrompt(taskName, task, iteration, totalIterations, outputDir, taskPromptCompact)
+	if err != nil {
+		return "", cfg, err
+	}
+	if compact != "" {
+		cfg.Prompt = compact
+		return "compact prompt", cfg, nil
+	}
+
+	raw, _, err := e.loadTaskPrompt(task)
+	if err != nil {
+		return "", cfg, err
+	}
+	if prompt.HasOutputDirectives(raw) || prompt.HasArtifactDirectives(raw) {
+		truncated, err := e.buildTaskPrompt(taskName, task, iteration, totalIterations, outputDir, taskPromptTruncatedOutputs)
+		if err != nil {
+			return "", cfg, err
+		}
+		if len(truncated) < len(cfg.Prompt) {
+			cfg.Prompt = truncated
+			return "truncated outputs", cfg, nil
+		}
+	}
+
+	if fresh, ok := cfg.Command.WithoutSessionArgs(); ok {
+		cfg.Command = fresh
+		return "session reset", cfg, nil
+	}
+	return "", cfg, nil
+}
+
+// recordContextOverflow counts a context overflow on the pipeline's agent.
+func (e *Executor) recordContextOverflow() {
+	if e.cfg.StateManager == nil || e.cfg.TaskID == "" {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID)
+	if err != nil {
+		return
+	}
+	agentState.ContextOverflows++
+	_ = e.cfg.StateManager.MergeUpdate(agentState)
+}
+
+// persistUsageState writes the current total usage (completed + running tasks) to pipeline state,
+// along with what the running tasks are doing, so the pipeline's agent shows
+// live pipeline-level numbers (e.g. in swarm top).
 // Must be called with e.mu held.
 func (e *Executor) persistUsageState() {
 	if e.cfg.StateManager == nil || e.cfg.TaskID == "" {
@@ -423,27 +961,101 @@ func (e *Executor) persistUsageState() {
 		return
 	}
 
-	// Sum completed + all running tasks
+	// Sum completed + all running tasks, attributing each running task's
+	// usage to its model like a completed task's
 	totalInput := e.inputTokens
 	totalOutput := e.outputTokens
 	totalCost := e.totalCostUSD
-	for _, s := range e.taskStats {
+	modelUsage := make(map[string]*state.ModelUsage, len(e.modelUsage))
+	for model, usage := range e.modelUsage {
+		u := *usage
+		modelUsage[model] = &u
+	}
+	for taskName, s := range e.taskStats {
 		totalInput += s.InputTokens
 		totalOutput += s.OutputTokens
 		totalCost += s.TotalCostUSD
+		usageModel := s.Model
+		if usageModel == "" {
+			usageModel = e.taskModels[taskName]
+		}
+		modelUsage = state.AddModelUsage(modelUsage, usageModel, s.InputTokens, s.OutputTokens,
+			e.cfg.AppConfig.UsageCost(usageModel, s.InputTokens, s.OutputTokens, s.TotalCostUSD))
 	}
 
 	agentState.InputTokens = totalInput
 	agentState.OutputTokens = totalOutput
+	if len(modelUsage) > 0 {
+		agentState.ModelUsage = modelUsage
+	}
 	if totalCost > 0 {
 		agentState.TotalCost = totalCost
 	} else if e.cfg.AppConfig != nil {
 		pricing := e.cfg.AppConfig.GetPricing(agentState.Model)
 		agentState.TotalCost = pricing.CalculateCost(totalInput, totalOutput)
 	}
+	if current := runningTasksSummary(e.taskStats); current != "" {
+		agentState.CurrentTask = current
+	}
 	_ = e.cfg.StateManager.MergeUpdate(agentState)
 }
 
+// runningTasksSummary describes what the running tasks are doing, e.g.
+// "coder: Editing main.go; tester: Running go test", in task name order.
+func runningTasksSummary(taskStats map[string]logparser.UsageStats) string {
+	var names []string
+	for name, s := range taskStats {
+		if s.CurrentTask != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + taskStats[name].CurrentTask
+	}
+	return strings.Join(parts, "; ")
+}
+
+// checkCostAlert sends a cost alert if the project's agents, including this
+// pipeline, have crossed alert_cost_usd.
+func (e *Executor) checkCostAlert(out io.Writer) {
+	if e.cfg.AppConfig == nil || e.cfg.AppConfig.AlertCostUSD <= 0 {
+		return
+	}
+	var agentState *state.AgentState
+	if e.cfg.StateManager != nil && e.cfg.TaskID != "" {
+		agentState, _ = e.cfg.StateManager.Get(e.cfg.TaskID)
+	}
+	notify.CheckCostAlert(e.cfg.AppConfig, e.cfg.WorkingDir, agentState, out)
+}
+
+// sampleTodoQueue records the todo-file queue's depth after a task ran.
+func (e *Executor) sampleTodoQueue(out io.Writer) {
+	if e.cfg.StateManager == nil {
+		return
+	}
+	if _, err := e.cfg.StateManager.SampleTodoQueue(e.cfg.AppConfig.TodoQueueDirs(e.cfg.WorkingDir)); err != nil {
+		fmt.Fprintf(out, "Warning: failed to sample todo queue: %v\n", err)
+	}
+}
+
+// notify sends a notification about the pipeline to the configured notifiers.
+func (e *Executor) notify(ev notify.Event, out io.Writer) {
+	if e.cfg.AppConfig == nil {
+		return
+	}
+	if e.cfg.StateManager != nil && e.cfg.TaskID != "" {
+		if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+			ev = ev.ForAgent(agentState)
+		}
+	}
+	if ev.Project == "" {
+		ev.Project = e.cfg.WorkingDir
+	}
+	notify.Send(e.cfg.AppConfig, ev, out)
+}
+
 // loadTaskPrompt loads the prompt content for a task.
 func (e *Executor) loadTaskPrompt(task compose.Task) (content, label string, err error) {
 	switch {
@@ -467,6 +1079,26 @@ func (e *Executor) loadTaskPrompt(task compose.Task) (content, label string, err
 	return
 }
 
+// loadCompactTaskPrompt loads the compact variant of a task's prompt, with
+// the task's prefix and suffix applied. It returns an empty string if the
+// prompt has no compact variant.
+func (e *Executor) loadCompactTaskPrompt(task compose.Task) (string, error) {
+	var content string
+	var err error
+	switch {
+	case task.PromptFile != "":
+		content, err = prompt.LoadCompactPromptFromFile(task.PromptFile)
+	case task.PromptString != "":
+		return "", nil
+	case task.Prompt != "":
+		content, err = prompt.LoadCompactPrompt(e.cfg.PromptsDir, task.Prompt)
+	}
+	if err != nil || content == "" {
+		return "", err
+	}
+	return prompt.ApplyPrefixSuffix(content, task.Prefix, task.Suffix), nil
+}
+
 // PipelineResult holds the results of a pipeline execution.
 type PipelineResult struct {
 	// Iterations is the number of iterations completed
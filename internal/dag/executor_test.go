@@ -2,11 +2,23 @@ package dag
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mj1618/swarm-cli/internal/compose"
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
 )
 
 // testConfig returns a config that uses /bin/echo as the command backend.
@@ -233,6 +245,77 @@ func TestExecutor_RunPipeline_ConditionalSkip(t *testing.T) {
 	}
 }
 
+func TestExecutor_RunPipeline_FailFast(t *testing.T) {
+	failConfig := &config.Config{
+		Backend: "test",
+		Model:   "test-model",
+		Command: config.CommandConfig{
+			Executable: "/bin/sh",
+			Args:       []string{"-c", "exit 1"},
+			RawOutput:  true,
+		},
+	}
+
+	tasks := map[string]compose.Task{
+		"failing": {PromptString: "this-will-fail"},
+		"on_failure": {PromptString: "run-on-failure", DependsOn: []compose.Dependency{
+			{Task: "failing", Condition: compose.ConditionFailure},
+		}},
+	}
+	pipeline := compose.Pipeline{Iterations: 2, Tasks: []string{"failing", "on_failure"}}
+
+	var mu sync.Mutex
+	var statuses []string
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  failConfig,
+		PromptsDir: t.TempDir(),
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+		FailFast:   true,
+		OnTaskStatus: func(task string, iteration int, status TaskStatus, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, fmt.Sprintf("%s/%d/%s/%v", task, iteration, status, err != nil))
+		},
+	})
+
+	err := executor.RunPipeline(pipeline, tasks)
+	if err == nil || !strings.Contains(err.Error(), "iteration 1 failed") {
+		t.Fatalf("expected iteration 1 to fail the pipeline, got %v", err)
+	}
+	want := []string{"failing/1/running/false", "failing/1/failed/true"}
+	if strings.Join(statuses, " ") != strings.Join(want, " ") {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if strings.Contains(buf.String(), "on_failure | Starting") {
+		t.Errorf("expected on_failure not to start, output:\n%s", buf.String())
+	}
+}
+
+func TestExecutor_RunPipeline_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  testConfig(),
+		PromptsDir: t.TempDir(),
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+		Context:    ctx,
+	})
+
+	tasks := map[string]compose.Task{"a": {PromptString: "a"}}
+	err := executor.RunPipeline(compose.Pipeline{Iterations: 1, Tasks: []string{"a"}}, tasks)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if strings.Contains(buf.String(), "a | Starting") {
+		t.Errorf("expected no task to start, output:\n%s", buf.String())
+	}
+}
+
 func TestExecutor_RunPipeline_DefaultIterations(t *testing.T) {
 	// Test that a pipeline with 0 iterations defaults to 1
 	tasks := map[string]compose.Task{
@@ -293,3 +376,938 @@ func TestExecutor_RunPipeline_CycleDetection(t *testing.T) {
 		t.Errorf("expected cycle error, got: %v", err)
 	}
 }
+
+func TestExecutor_RunPipeline_UnlimitedStopsOnCondition(t *testing.T) {
+	// An unlimited pipeline must stop once its stop_when condition is met
+	tasks := map[string]compose.Task{
+		"a": {PromptString: "step-a"},
+	}
+
+	workDir := t.TempDir()
+	pipeline := compose.Pipeline{
+		Unlimited: true,
+		StopWhen:  &compose.StopCondition{Command: "test -f marker || { touch marker; exit 1; }"},
+	}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  testConfig(),
+		PromptsDir: t.TempDir(),
+		WorkingDir: workDir,
+		Output:     &buf,
+	})
+
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Stop condition met") {
+		t.Errorf("expected stop condition message, output:\n%s", output)
+	}
+	if !strings.Contains(output, "Pipeline Iteration 2/∞") {
+		t.Errorf("expected a second iteration before stopping, output:\n%s", output)
+	}
+	if strings.Contains(output, "Pipeline Iteration 3/∞") {
+		t.Errorf("expected pipeline to stop after iteration 2, output:\n%s", output)
+	}
+}
+
+// fakeCounter is an in-memory IterationCounter.
+type fakeCounter struct {
+	mu      sync.Mutex
+	claimed int
+	limit   int
+}
+
+func (c *fakeCounter) Claim() (int, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.claimed >= c.limit {
+		return c.claimed, false, nil
+	}
+	c.claimed++
+	return c.claimed, true, nil
+}
+
+func (c *fakeCounter) Limit() int { return c.limit }
+
+func TestExecutor_RunPipeline_SharedIterations(t *testing.T) {
+	// Two instances sharing a counter run 5 iterations in total
+	tasks := map[string]compose.Task{
+		"a": {PromptString: "step-a"},
+	}
+	pipeline := compose.Pipeline{Iterations: 5, Parallelism: 2, SharedIterations: true}
+	counter := &fakeCounter{limit: 5}
+
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, 2)
+	for i := range outputs {
+		wg.Add(1)
+		go func(buf *bytes.Buffer) {
+			defer wg.Done()
+			executor := NewExecutor(ExecutorConfig{
+				AppConfig:        testConfig(),
+				PromptsDir:       t.TempDir(),
+				WorkingDir:       t.TempDir(),
+				Output:           buf,
+				SharedIterations: counter,
+			})
+			if err := executor.RunPipeline(pipeline, tasks); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(&outputs[i])
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range outputs {
+		total += strings.Count(outputs[i].String(), "=== Pipeline Iteration")
+	}
+	if total != 5 {
+		t.Errorf("expected 5 iterations across instances, got %d", total)
+	}
+}
+
+func TestExecutor_RunPipeline_RecordsRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	mgr, err := state.NewManagerWithScope(scope.ScopeProject, workingDir)
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+
+	tasks := map[string]compose.Task{
+		"a": {PromptString: "step-a"},
+		"b": {PromptString: "step-b", DependsOn: []compose.Dependency{{Task: "a"}}},
+	}
+	pipeline := compose.Pipeline{Iterations: 2, Tasks: []string{"a", "b"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:    testConfig(),
+		PromptsDir:   t.TempDir(),
+		WorkingDir:   workingDir,
+		Output:       &buf,
+		StateManager: mgr,
+		PipelineName: "main",
+		RunID:        "run12345",
+	})
+
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Run ID: run12345") {
+		t.Errorf("expected run ID in output, got:\n%s", buf.String())
+	}
+
+	run, err := mgr.GetRun("run12345")
+	if err != nil {
+		t.Fatalf("run not recorded: %v", err)
+	}
+	if run.Pipeline != "main" || run.Status != "completed" {
+		t.Errorf("unexpected run: pipeline=%q status=%q", run.Pipeline, run.Status)
+	}
+	if run.CompletedIterations != 2 || len(run.OutputDirs) != 2 {
+		t.Errorf("expected 2 iterations and output dirs, got %d and %d", run.CompletedIterations, len(run.OutputDirs))
+	}
+	if len(run.Tasks) != 4 {
+		t.Fatalf("expected 4 task executions, got %d", len(run.Tasks))
+	}
+	if run.Tasks[0].Task != "a" || run.Tasks[0].Iteration != 1 || run.Tasks[3].Task != "b" || run.Tasks[3].Iteration != 2 {
+		t.Errorf("unexpected task order: %+v", run.Tasks)
+	}
+	if run.FinishedAt == nil {
+		t.Error("expected FinishedAt to be set")
+	}
+}
+
+func TestExecutor_RunPipeline_StateDir(t *testing.T) {
+	workingDir := t.TempDir()
+	tasks := map[string]compose.Task{
+		"a": {PromptString: "step-a"},
+	}
+	pipeline := compose.Pipeline{Iterations: 2, Tasks: []string{"a"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  testConfig(),
+		PromptsDir: t.TempDir(),
+		WorkingDir: workingDir,
+		Output:     &buf,
+		StateDir:   "state",
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(workingDir, "state"))
+	if err != nil {
+		t.Fatalf("expected state dir to be created: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 iteration dirs, got %d", len(entries))
+	}
+}
+
+func TestExecutor_RunPipeline_ContextOverflowRetry(t *testing.T) {
+	promptsDir := t.TempDir()
+	os.WriteFile(filepath.Join(promptsDir, "big.md"), []byte("full prompt"), 0644)
+	os.WriteFile(filepath.Join(promptsDir, "big.compact.md"), []byte("COMPACT prompt"), 0644)
+	os.WriteFile(filepath.Join(promptsDir, "plain.md"), []byte("full prompt"), 0644)
+
+	// Fails with a context overflow unless given the compact prompt
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args:       []string{"-c", `case "$1" in *COMPACT*) echo ok ;; *) echo "prompt is too long" >&2; exit 1 ;; esac`, "sh", "{prompt}"},
+		RawOutput:  true,
+	}
+
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"big", "1 succeeded"},
+		{"plain", "1 failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.task, func(t *testing.T) {
+			var buf bytes.Buffer
+			executor := NewExecutor(ExecutorConfig{
+				AppConfig:  cfg,
+				PromptsDir: promptsDir,
+				WorkingDir: t.TempDir(),
+				Output:     &buf,
+			})
+			tasks := map[string]compose.Task{tt.task: {Prompt: tt.task}}
+			if err := executor.RunPipeline(compose.Pipeline{Iterations: 1, Tasks: []string{tt.task}}, tasks); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("expected %q, got:\n%s", tt.want, buf.String())
+			}
+			if !strings.Contains(buf.String(), "Context overflow in task "+tt.task) {
+				t.Errorf("expected context overflow to be reported, got:\n%s", buf.String())
+			}
+			if tt.task == "big" && !strings.Contains(buf.String(), "retrying with compact prompt") {
+				t.Errorf("expected retry with compact prompt, got:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestExecutor_RunPipeline_PromptSizeGuard(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxPromptTokens = 5
+	tasks := map[string]compose.Task{
+		"a": {PromptString: strings.Repeat("long prompt ", 10)},
+	}
+	pipeline := compose.Pipeline{Iterations: 1, Tasks: []string{"a"}}
+
+	for _, action := range []string{config.PromptLimitWarn, config.PromptLimitFail} {
+		t.Run(action, func(t *testing.T) {
+			cfg.PromptLimitAction = action
+			var buf bytes.Buffer
+			executor := NewExecutor(ExecutorConfig{
+				AppConfig:  cfg,
+				PromptsDir: t.TempDir(),
+				WorkingDir: t.TempDir(),
+				Output:     &buf,
+			})
+			if err := executor.RunPipeline(pipeline, tasks); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			output := buf.String()
+			if !strings.Contains(output, "max_prompt_tokens is 5") {
+				t.Errorf("expected prompt size to be reported, got:\n%s", output)
+			}
+			want := "1 succeeded"
+			if action == config.PromptLimitFail {
+				want = "1 failed"
+			}
+			if !strings.Contains(output, want) {
+				t.Errorf("expected %q, got:\n%s", want, output)
+			}
+		})
+	}
+}
+
+func TestExecutor_RunPipeline_IterationSummary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	mgr, err := state.NewManagerWithScope(scope.ScopeProject, workingDir)
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+	if err := mgr.Register(&state.AgentState{ID: "pipe1", Status: "running", WorkingDir: workingDir}); err != nil {
+		t.Fatalf("failed to register pipeline agent: %v", err)
+	}
+
+	tasks := map[string]compose.Task{
+		"a": {PromptString: "step-a"},
+		"b": {PromptString: "step-b", DependsOn: []compose.Dependency{{Task: "a", Condition: "failure"}}},
+	}
+	pipeline := compose.Pipeline{Iterations: 2, Tasks: []string{"a", "b"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:    testConfig(),
+		PromptsDir:   t.TempDir(),
+		WorkingDir:   workingDir,
+		Output:       &buf,
+		StateManager: mgr,
+		TaskID:       "pipe1",
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"[swarm] Iteration summary: iteration 1/2: succeeded=1 failed=0 skipped=1",
+		"[swarm] Iteration summary: iteration 2/2: succeeded=1 failed=0 skipped=1",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	agent, err := mgr.Get("pipe1")
+	if err != nil {
+		t.Fatalf("failed to get pipeline agent: %v", err)
+	}
+	last := agent.LastIteration
+	if last == nil {
+		t.Fatal("expected last iteration summary in state")
+	}
+	if last.Iteration != 2 || last.Succeeded != 1 || last.Skipped != 1 {
+		t.Errorf("unexpected summary: %+v", last)
+	}
+	if last.Tasks["a"] != "succeeded" || last.Tasks["b"] != "skipped" {
+		t.Errorf("unexpected task statuses: %v", last.Tasks)
+	}
+}
+
+func TestExecutor_RunPipeline_PoolLimit(t *testing.T) {
+	ResetTaskSemaphores()
+	defer ResetTaskSemaphores()
+
+	cfg := testConfig()
+	cfg.Pools = map[string]*config.PoolConfig{"test-pool-limit": {Max: 1}}
+
+	tasks := map[string]compose.Task{
+		"gpu": {PromptString: "step", Labels: map[string]string{"pool": "test-pool-limit"}},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"gpu"}}
+
+	// Another pipeline holds the pool's only slot
+	AcquireTaskSlot(poolLockName("test-pool-limit"), 1)
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+	})
+	done := make(chan error, 1)
+	go func() { done <- executor.RunPipeline(pipeline, tasks) }()
+
+	select {
+	case <-done:
+		t.Fatal("pipeline ran while the pool was full")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	ReleaseTaskSlot(poolLockName("test-pool-limit"), 1)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline didn't run after the pool slot was released")
+	}
+
+	if !strings.Contains(buf.String(), "Waiting for slot in pool test-pool-limit") {
+		t.Errorf("expected pool wait message, output:\n%s", buf.String())
+	}
+}
+
+func TestExecutor_RunPipeline_Stdin(t *testing.T) {
+	workingDir := t.TempDir()
+	cfg := testConfig()
+	cfg.Command.Args = []string{"{prompt}"}
+
+	tasks := map[string]compose.Task{
+		"plan":  {PromptString: "plan from: {{stdin}}"},
+		"build": {PromptString: "build from: {{stdin}}", DependsOn: []compose.Dependency{{Task: "plan"}}},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"plan", "build"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		WorkingDir: workingDir,
+		Output:     &buf,
+		Stdin:      "the-spec",
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"plan from: the-spec", "build from: the-spec"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestExecutor_RunPipeline_InjectEnvSummary(t *testing.T) {
+	cfg := testConfig()
+	cfg.Command.Args = []string{"{prompt}"}
+
+	tasks := map[string]compose.Task{
+		"plain": {PromptString: "plain-task"},
+		"env":   {PromptString: "env-task", InjectEnvSummary: true, DependsOn: []compose.Dependency{{Task: "plain"}}},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"plain", "env"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if count := strings.Count(buf.String(), "Environment summary (detected by swarm)"); count != 1 {
+		t.Errorf("expected the environment summary in one prompt, got %d, output:\n%s", count, buf.String())
+	}
+}
+
+func TestExecutor_RunPipeline_FailureTriage(t *testing.T) {
+	promptsDir := t.TempDir()
+	os.WriteFile(filepath.Join(promptsDir, "triage.md"), []byte("TRIAGE the failure"), 0644)
+
+	// The task fails until its prompt carries the triage diagnosis; the
+	// triage agent echoes its prompt and writes the diagnosis
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args: []string{"-c", `case "$1" in
+*TRIAGE*) echo "$1"; path=$(printf '%s\n' "$1" | sed -n 's/.* to \([^ ]*\.triage\.md\)\..*/\1/p'); echo "missing import" > "$path" ;;
+*"missing import"*) echo fixed ;;
+*) echo "build broke"; exit 1 ;;
+esac`, "sh", "{prompt}"},
+		RawOutput: true,
+	}
+
+	tasks := map[string]compose.Task{
+		"build": {PromptString: "build it", VerifyCommand: "echo verify-ran"},
+	}
+	pipeline := compose.Pipeline{Iterations: 3, Tasks: []string{"build"}, OnFailurePrompt: "triage", OnFailureAfter: 2}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: promptsDir,
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	if count := strings.Count(output, "running triage agent (triage)"); count != 1 {
+		t.Errorf("expected one triage run after the second failure, got %d, output:\n%s", count, output)
+	}
+	for _, want := range []string{"Task \"build\" failed 2 time(s) in a row", "build broke", "verify-ran", "Triage diagnosis for task build written to", "fixed"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestExecutor_RunPipeline_TaskRepo(t *testing.T) {
+	repoDir := t.TempDir()
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args:       []string{"-c", `echo "ran in $(pwd)"`},
+		RawOutput:  true,
+	}
+
+	tasks := map[string]compose.Task{
+		"svc": {PromptString: "work on the service", Repo: repoDir},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"svc"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, _ := filepath.EvalSymlinks(repoDir)
+	if !strings.Contains(buf.String(), "ran in "+want) {
+		t.Errorf("expected the task to run in %s, output:\n%s", want, buf.String())
+	}
+}
+
+func TestExecutor_RunPipeline_ArtifactReference(t *testing.T) {
+	cfg := testConfig()
+	// The planner writes an artifact to its directory in SWARM_STATE_DIR; the
+	// coder echoes its prompt
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args: []string{"-c", `case "$1" in
+*make-plan*)
+	dir=$(printf '%s\n' "$1" | sed -n 's/^Your SWARM_STATE_DIR is \(.*\)\. Read here.*/\1/p')
+	mkdir -p "$dir/planner" && echo "step 1: write tests" > "$dir/planner/plan.md" ;;
+*) printf '%s\n' "$1" ;;
+esac`, "sh", "{prompt}"},
+		RawOutput: true,
+	}
+
+	tasks := map[string]compose.Task{
+		"planner": {PromptString: "make-plan"},
+		"coder":   {PromptString: "Follow {{artifact:planner/plan.md}}", DependsOn: []compose.Dependency{{Task: "planner"}}},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"planner", "coder"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		StateDir:   t.TempDir(),
+		Output:     &buf,
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "step 1: write tests") {
+		t.Errorf("expected the artifact to be inlined in the coder's prompt, output:\n%s", buf.String())
+	}
+
+	tasks["coder"] = compose.Task{PromptString: "Follow {{artifact:planner/missing.md}}", DependsOn: []compose.Dependency{{Task: "planner"}}}
+	buf.Reset()
+	executor.RunPipeline(pipeline, tasks)
+	if !strings.Contains(buf.String(), `artifact "planner/missing.md" not found`) {
+		t.Errorf("expected a missing-artifact error, output:\n%s", buf.String())
+	}
+}
+
+func TestExecutor_RunPipeline_ExitReport(t *testing.T) {
+	cfg := testConfig()
+	// The reviewer exits 0 but reports failure; the others echo their prompt
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args: []string{"-c", `case "$1" in
+*review*) printf '{"status":"failure","summary":"found a race"}' > "$SWARM_EXIT_FILE" ;;
+*) printf '%s\n' "$1" ;;
+esac`, "sh", "{prompt}"},
+		RawOutput: true,
+	}
+
+	tasks := map[string]compose.Task{
+		"reviewer": {PromptString: "review"},
+		"fixer": {PromptString: "fix", DependsOn: []compose.Dependency{
+			{Task: "reviewer", Condition: compose.ConditionFailure, When: "exit.status == failure"},
+		}},
+		"shipper": {PromptString: "ship", DependsOn: []compose.Dependency{
+			{Task: "reviewer", Condition: compose.ConditionSuccess},
+		}},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"reviewer", "fixer", "shipper"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		StateDir:   t.TempDir(),
+		Output:     &buf,
+	})
+	executor.RunPipeline(pipeline, tasks)
+
+	output := buf.String()
+	if !strings.Contains(output, "agent reported failure: found a race") {
+		t.Errorf("expected the reviewer to fail with its report, output:\n%s", output)
+	}
+	if !regexp.MustCompile(`fixer +\| Starting`).MatchString(output) {
+		t.Errorf("expected fixer to run, output:\n%s", output)
+	}
+	if !regexp.MustCompile(`shipper +\| Skipped`).MatchString(output) {
+		t.Errorf("expected shipper to be skipped, output:\n%s", output)
+	}
+}
+
+func TestExecutor_RunPipeline_HealthCheck(t *testing.T) {
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args:       []string{"-c", "sleep 0.3"},
+		RawOutput:  true,
+	}
+
+	tasks := map[string]compose.Task{
+		"server": {PromptString: "babysit the server", HealthCmd: "echo refused; exit 1", HealthInterval: "20ms"},
+	}
+	pipeline := compose.Pipeline{Tasks: []string{"server"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		WorkingDir: t.TempDir(),
+		Output:     &buf,
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Count(buf.String(), "[swarm] UNHEALTHY"); got != 1 {
+		t.Errorf("expected one unhealthy event, got %d in output:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "exit status 1: refused") {
+		t.Errorf("expected the health check's failure in output:\n%s", buf.String())
+	}
+}
+
+func TestExecutor_RecordTaskHealth(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	mgr, err := state.NewManagerWithScope(scope.ScopeProject, workingDir)
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+	if err := mgr.Register(&state.AgentState{ID: "pipe0001", Status: "running", WorkingDir: workingDir}); err != nil {
+		t.Fatal(err)
+	}
+	executor := NewExecutor(ExecutorConfig{AppConfig: testConfig(), StateManager: mgr, TaskID: "pipe0001", WorkingDir: workingDir})
+
+	health := func() *state.AgentState {
+		t.Helper()
+		a, err := mgr.Get("pipe0001")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	executor.recordTaskHealth("web", errors.New("exit status 7"), true)
+	executor.recordTaskHealth("api", nil, true)
+	if a := health(); !a.Unhealthy() || a.HealthError != "web: exit status 7" {
+		t.Errorf("expected the pipeline unhealthy from web, got %q (%s)", a.Health, a.HealthError)
+	}
+
+	executor.recordTaskHealth("api", errors.New("timed out"), true)
+	if a := health(); a.HealthError != "api: timed out; web: exit status 7" {
+		t.Errorf("unexpected health error %q", a.HealthError)
+	}
+
+	// Tasks finishing clear their failures
+	executor.recordTaskHealth("api", nil, false)
+	executor.recordTaskHealth("web", nil, false)
+	if a := health(); a.Unhealthy() || a.Health != "healthy" {
+		t.Errorf("expected the pipeline healthy once its tasks finish, got %q (%s)", a.Health, a.HealthError)
+	}
+}
+
+func TestExecutor_RunPipeline_Messages(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	mgr, err := state.NewManagerWithScope(scope.ScopeProject, workingDir)
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+	if err := mgr.Register(&state.AgentState{ID: "pipe0001", Status: "running", WorkingDir: workingDir}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.QueueMessage("pipe0001", "also update the changelog"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.Command.Args = []string{"{prompt}"}
+	tasks := map[string]compose.Task{
+		"plan":  {PromptString: "plan-task"},
+		"build": {PromptString: "build-task", DependsOn: []compose.Dependency{{Task: "plan"}}},
+	}
+	pipeline := compose.Pipeline{Iterations: 2, Tasks: []string{"plan", "build"}}
+
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:    cfg,
+		PromptsDir:   t.TempDir(),
+		WorkingDir:   workingDir,
+		Output:       &buf,
+		StateManager: mgr,
+		TaskID:       "pipe0001",
+	})
+	if err := executor.RunPipeline(pipeline, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Both tasks of the first iteration get the instruction; the second
+	// iteration doesn't
+	output := buf.String()
+	if count := strings.Count(output, "| - also update the changelog"); count != 2 {
+		t.Errorf("expected the instruction in 2 prompts, got %d, output:\n%s", count, output)
+	}
+	second := output[strings.Index(output, "Pipeline Iteration 2/2"):]
+	if strings.Contains(second, "also update the changelog") {
+		t.Errorf("expected no instruction in the second iteration, output:\n%s", second)
+	}
+	if messages, err := mgr.Messages("pipe0001"); err != nil || len(messages) != 0 {
+		t.Errorf("expected the queue emptied, got %v (%v)", messages, err)
+	}
+}
+
+func TestExecutor_PersistUsageState_RunningTasks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workingDir := t.TempDir()
+	mgr, err := state.NewManagerWithScope(scope.ScopeProject, workingDir)
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+	if err := mgr.Register(&state.AgentState{ID: "pipe0001", Status: "running", WorkingDir: workingDir}); err != nil {
+		t.Fatal(err)
+	}
+	executor := NewExecutor(ExecutorConfig{AppConfig: testConfig(), StateManager: mgr, TaskID: "pipe0001", WorkingDir: workingDir})
+
+	// A completed task plus two running ones
+	executor.inputTokens, executor.outputTokens, executor.totalCostUSD = 100, 10, 0.5
+	executor.modelUsage = state.AddModelUsage(nil, "opus", 100, 10, 0.5)
+	executor.taskStats["tester"] = logparser.UsageStats{InputTokens: 20, OutputTokens: 2, TotalCostUSD: 0.25, CurrentTask: "Running go test"}
+	executor.taskModels["tester"] = "sonnet"
+	executor.taskStats["coder"] = logparser.UsageStats{InputTokens: 30, OutputTokens: 3, TotalCostUSD: 0.25, CurrentTask: "Editing main.go", Model: "opus"}
+	executor.taskModels["coder"] = "sonnet"
+	executor.persistUsageState()
+
+	a, err := mgr.Get("pipe0001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.InputTokens != 150 || a.OutputTokens != 15 || a.TotalCost != 1 {
+		t.Errorf("got tokens %d/%d cost %v, want 150/15 cost 1", a.InputTokens, a.OutputTokens, a.TotalCost)
+	}
+	if got := a.ModelUsage["opus"]; got == nil || got.InputTokens != 130 || got.Cost != 0.75 {
+		t.Errorf("unexpected opus usage: %+v", got)
+	}
+	if got := a.ModelUsage["sonnet"]; got == nil || got.InputTokens != 20 {
+		t.Errorf("unexpected sonnet usage: %+v", got)
+	}
+	if executor.modelUsage["opus"].InputTokens != 100 {
+		t.Errorf("running tasks' usage leaked into the completed usage: %+v", executor.modelUsage["opus"])
+	}
+	if want := "coder: Editing main.go; tester: Running go test"; a.CurrentTask != want {
+		t.Errorf("CurrentTask = %q, want %q", a.CurrentTask, want)
+	}
+}
+
+func TestExecutor_RunPipeline_MaxPending(t *testing.T) {
+	workingDir := t.TempDir()
+	todoDir := filepath.Join(workingDir, "swarm", "todos")
+	if err := os.MkdirAll(todoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.todo.md", "b.todo.md", "c.processing.md"} {
+		if err := os.WriteFile(filepath.Join(todoDir, name), []byte("task"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(maxPending int) string {
+		tasks := map[string]compose.Task{
+			"planner": {PromptString: "plan", MaxPending: maxPending},
+			"doer": {PromptString: "do", DependsOn: []compose.Dependency{
+				{Task: "planner", Condition: compose.ConditionAlways},
+			}},
+		}
+		pipeline := compose.Pipeline{Iterations: 1, Tasks: []string{"planner", "doer"}}
+
+		var buf bytes.Buffer
+		executor := NewExecutor(ExecutorConfig{
+			AppConfig:  testConfig(),
+			PromptsDir: t.TempDir(),
+			WorkingDir: workingDir,
+			Output:     &buf,
+		})
+		if err := executor.RunPipeline(pipeline, tasks); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return buf.String()
+	}
+
+	// Two unclaimed tasks are more than the planner allows
+	output := run(1)
+	if !strings.Contains(output, "planner | Skipped (2 tasks pending, max_pending 1)") {
+		t.Errorf("expected the planner to be skipped, output:\n%s", output)
+	}
+	if !strings.Contains(output, "doer    | Starting") {
+		t.Errorf("expected the doer to run, output:\n%s", output)
+	}
+
+	output = run(2)
+	if !strings.Contains(output, "planner | Starting") {
+		t.Errorf("expected the planner to run, output:\n%s", output)
+	}
+}
+
+func TestExecutor_RunPipeline_StderrFile(t *testing.T) {
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args:       []string{"-c", `echo '{"type":"result"}'; echo "auth failed" >&2; exit 1`},
+		RawOutput:  true,
+	}
+
+	tasks := map[string]compose.Task{"coder": {PromptString: "code"}}
+	pipeline := compose.Pipeline{Iterations: 1, Tasks: []string{"coder"}}
+
+	stderrFile := filepath.Join(t.TempDir(), "agent.stderr.log")
+	var buf bytes.Buffer
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		StateDir:   t.TempDir(),
+		Output:     &buf,
+		StderrFile: stderrFile,
+	})
+	executor.RunPipeline(pipeline, tasks)
+
+	data, err := os.ReadFile(stderrFile)
+	if err != nil {
+		t.Fatalf("failed to read stderr file: %v", err)
+	}
+	if string(data) != "auth failed\n" {
+		t.Errorf("stderr file = %q, want %q", data, "auth failed\n")
+	}
+
+	// The log only shows the stderr tail in the failure report
+	output := buf.String()
+	if !strings.Contains(output, "Agent stderr (last 1 lines):") {
+		t.Errorf("expected the stderr tail in the failure output, got:\n%s", output)
+	}
+	if n := strings.Count(output, "auth failed"); n != 1 {
+		t.Errorf("expected stderr in the log only once, in the tail, got %d times:\n%s", n, output)
+	}
+}
+
+// initGitRepo makes dir a git repository with main.go committed.
+func initGitRepo(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+		{"add", "."},
+		{"commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestExecutor_PathGuardIgnoresSiblingEdits(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	// The guarded task starts first (ready tasks start in name order) and
+	// waits for its sibling to edit main.go before finishing
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args: []string{"-c", `if [ "$SWARM_TASK_NAME" = coder ]; then echo changed >> main.go; exit 0; fi
+for i in $(seq 150); do grep -q changed main.go && exit 0; sleep 0.1; done; exit 1`},
+		RawOutput: true,
+	}
+
+	tasks := map[string]compose.Task{
+		"coder":    {PromptString: "code", Repo: dir},
+		"api-docs": {PromptString: "docs", Repo: dir, AllowedPaths: []string{"docs/**"}},
+	}
+	pipeline := compose.Pipeline{Iterations: 1, Tasks: []string{"api-docs", "coder"}}
+
+	var mu sync.Mutex
+	statuses := make(map[string]TaskStatus)
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		StateDir:   t.TempDir(),
+		WorkingDir: dir,
+		Output:     &bytes.Buffer{},
+		OnTaskStatus: func(task string, iteration int, status TaskStatus, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[task] = status
+		},
+	})
+	executor.RunPipeline(pipeline, tasks)
+
+	for _, name := range []string{"api-docs", "coder"} {
+		if statuses[name] != TaskSucceeded {
+			t.Errorf("task %s = %s, want succeeded: a sibling's edit is not the guarded task's", name, statuses[name])
+		}
+	}
+}
+
+func TestExecutor_ReadOnlyIgnoresSiblingEdits(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	// The reviewer starts first and waits for its sibling to edit main.go
+	cfg := testConfig()
+	cfg.Command = config.CommandConfig{
+		Executable: "/bin/sh",
+		Args: []string{"-c", `if [ "$SWARM_TASK_NAME" = coder ]; then echo changed >> main.go; exit 0; fi
+for i in $(seq 150); do grep -q changed main.go && exit 0; sleep 0.1; done; exit 1`},
+		RawOutput: true,
+	}
+
+	tasks := map[string]compose.Task{
+		"analyst": {PromptString: "review", Repo: dir, ReadOnly: true},
+		"coder":   {PromptString: "code", Repo: dir},
+	}
+	pipeline := compose.Pipeline{Iterations: 1, Tasks: []string{"analyst", "coder"}}
+
+	var mu sync.Mutex
+	statuses := make(map[string]TaskStatus)
+	executor := NewExecutor(ExecutorConfig{
+		AppConfig:  cfg,
+		PromptsDir: t.TempDir(),
+		StateDir:   t.TempDir(),
+		WorkingDir: dir,
+		Output:     &bytes.Buffer{},
+		OnTaskStatus: func(task string, iteration int, status TaskStatus, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[task] = status
+		},
+	})
+	executor.RunPipeline(pipeline, tasks)
+
+	if statuses["analyst"] != TaskSucceeded {
+		t.Errorf("read-only task = %s, want succeeded: a sibling's edit is not the reviewer's", statuses["analyst"])
+	}
+}
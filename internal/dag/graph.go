@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/taskoutput"
 )
 
 // Graph represents a directed acyclic graph of task dependencies.
@@ -234,11 +235,28 @@ func (g *Graph) canRun(task string, states map[string]*TaskState) bool {
 				return false
 			}
 		}
+
+		if !whenHolds(dep, depState) {
+			return false
+		}
 	}
 
 	return true
 }
 
+// whenHolds reports whether a dependency's `when` condition, if any, holds
+// for the dependency's structured output.
+func whenHolds(dep compose.Dependency, depState *TaskState) bool {
+	if dep.When == "" {
+		return true
+	}
+	cond, err := taskoutput.ParseCondition(dep.When)
+	if err != nil {
+		return false
+	}
+	return cond.Eval(depState.Output)
+}
+
 // ShouldSkip determines if a task should be skipped based on its dependencies.
 // A task is skipped if its dependency conditions can never be satisfied.
 func (g *Graph) ShouldSkip(task string, states map[string]*TaskState) bool {
@@ -264,7 +282,17 @@ func (g *Graph) ShouldSkip(task string, states map[string]*TaskState) bool {
 			if depState.Status == TaskSucceeded || depState.Status == TaskSkipped {
 				return true
 			}
-		// ConditionAny and ConditionAlways don't cause skipping
+		case compose.ConditionAny:
+			// A skipped dependency never completes, so this task never could
+			if depState.Status == TaskSkipped {
+				return true
+			}
+		// ConditionAlways doesn't cause skipping
+		}
+
+		// A finished dependency whose output fails the when condition never will pass
+		if depState.IsTerminal() && !whenHolds(dep, depState) {
+			return true
 		}
 	}
 
@@ -265,6 +265,27 @@ func TestShouldSkip_SuccessConditionWithFailure(t *testing.T) {
 	}
 }
 
+func TestShouldSkip_AnyConditionWithSkipped(t *testing.T) {
+	tasks := map[string]compose.Task{
+		"a": {Prompt: "a"},
+		"b": {Prompt: "b", DependsOn: []compose.Dependency{
+			{Task: "a", Condition: compose.ConditionAny},
+		}},
+	}
+
+	graph := NewGraph(tasks, []string{"a", "b"})
+
+	// A skipped dependency never completes, so b can never run
+	states := map[string]*TaskState{
+		"a": {Name: "a", Status: TaskSkipped},
+		"b": {Name: "b", Status: TaskPending},
+	}
+
+	if !graph.ShouldSkip("b", states) {
+		t.Error("expected 'b' to be skipped when dependency was skipped (needs any)")
+	}
+}
+
 func TestConditionalBranching(t *testing.T) {
 	// Test the classic pattern: coder -> tester -> (fixer OR reviewer)
 	tasks := map[string]compose.Task{
@@ -305,3 +326,35 @@ func TestConditionalBranching(t *testing.T) {
 		t.Error("expected reviewer to be skipped when tester failed")
 	}
 }
+
+func TestFindReadyTasks_WhenCondition(t *testing.T) {
+	tasks := map[string]compose.Task{
+		"tester": {Prompt: "t"},
+		"fixer": {Prompt: "f", DependsOn: []compose.Dependency{
+			{Task: "tester", Condition: compose.ConditionSuccess, When: "result.status == fail"},
+		}},
+		"deployer": {Prompt: "d", DependsOn: []compose.Dependency{
+			{Task: "tester", Condition: compose.ConditionSuccess, When: "result.status == pass"},
+		}},
+	}
+
+	graph := NewGraph(tasks, []string{"tester", "fixer", "deployer"})
+	states := map[string]*TaskState{
+		"tester": {Name: "tester", Status: TaskSucceeded, Output: map[string]interface{}{
+			"result": map[string]interface{}{"status": "fail"},
+		}},
+		"fixer":    {Name: "fixer", Status: TaskPending},
+		"deployer": {Name: "deployer", Status: TaskPending},
+	}
+
+	ready := graph.FindReadyTasks(states)
+	if len(ready) != 1 || ready[0] != "fixer" {
+		t.Errorf("expected only fixer ready, got %v", ready)
+	}
+	if graph.ShouldSkip("fixer", states) {
+		t.Error("fixer should not be skipped")
+	}
+	if !graph.ShouldSkip("deployer", states) {
+		t.Error("expected deployer to be skipped when status is not pass")
+	}
+}
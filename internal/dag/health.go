@@ -0,0 +1,81 @@
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/notify"
+)
+
+// startHealthCheck starts a task's health_cmd, if it has one, recording its
+// results in the pipeline's state. The returned function stops the check.
+func (e *Executor) startHealthCheck(taskName string, task compose.Task, out io.Writer) (stop func()) {
+	if task.HealthCmd == "" {
+		return func() {}
+	}
+	check := agent.HealthCheck{Command: task.HealthCmd, Interval: task.EffectiveHealthInterval(), Dir: task.Repo}
+	if check.Dir == "" {
+		check.Dir = e.cfg.WorkingDir
+	}
+	check.OnUnhealthy = func(err error) {
+		ev := notify.UnhealthyEvent(check.Command, err)
+		ev.Message = fmt.Sprintf("task %s: %s", taskName, ev.Message)
+		e.notify(ev, out)
+	}
+	stopCheck := check.Start(out, func(err error) {
+		e.recordTaskHealth(taskName, err, true)
+	})
+	return func() {
+		stopCheck()
+		// The task's health is no longer known once its agent is done
+		e.recordTaskHealth(taskName, nil, false)
+	}
+}
+
+// recordTaskHealth records the result of a task's health check (nil when
+// healthy). The pipeline is unhealthy while any of its tasks' checks fail.
+// checked is false when the task's failure is only being cleared.
+func (e *Executor) recordTaskHealth(taskName string, err error, checked bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, wasUnhealthy := e.unhealthy[taskName]
+	if err != nil {
+		e.unhealthy[taskName] = err.Error()
+	} else {
+		delete(e.unhealthy, taskName)
+	}
+	if !checked && !wasUnhealthy {
+		return
+	}
+
+	if e.cfg.StateManager == nil || e.cfg.TaskID == "" {
+		return
+	}
+	agentState, getErr := e.cfg.StateManager.Get(e.cfg.TaskID)
+	if getErr != nil {
+		return
+	}
+
+	var failing error
+	if len(e.unhealthy) > 0 {
+		names := make([]string, 0, len(e.unhealthy))
+		for name := range e.unhealthy {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%s: %s", name, e.unhealthy[name])
+		}
+		failing = errors.New(strings.Join(parts, "; "))
+	}
+	agentState.RecordHealth(failing, time.Now())
+	_ = e.cfg.StateManager.MergeUpdate(agentState)
+}
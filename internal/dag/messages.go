@@ -0,0 +1,30 @@
+package dag
+
+import "fmt"
+
+// takeMessages takes the instructions queued with `swarm exec` for the
+// pipeline's agent, so every task of the iteration about to start gets them.
+func (e *Executor) takeMessages() {
+	var messages []string
+	if e.cfg.StateManager != nil && e.cfg.TaskID != "" {
+		queued, err := e.cfg.StateManager.TakeMessages(e.cfg.TaskID)
+		if err != nil {
+			fmt.Fprintf(e.cfg.Output, "[swarm] Warning: %v\n", err)
+		}
+		for _, m := range queued {
+			fmt.Fprintf(e.cfg.Output, "[swarm] Instruction added: %s\n", m.Text)
+			messages = append(messages, m.Text)
+		}
+	}
+
+	e.mu.Lock()
+	e.messages = messages
+	e.mu.Unlock()
+}
+
+// iterationMessages returns the instructions for the running iteration.
+func (e *Executor) iterationMessages() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.messages
+}
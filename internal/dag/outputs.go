@@ -0,0 +1,185 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/taskoutput"
+)
+
+// OutputsRoot returns the default directory that holds per-iteration pipeline output directories.
+func OutputsRoot() string {
+	return filepath.Join(os.TempDir(), "swarm", "outputs")
+}
+
+// ResolveOutputsRoot returns the directory that holds per-iteration output
+// directories for a configured state_dir. A leading ~ is expanded and relative
+// paths are resolved against workingDir. An empty dir uses OutputsRoot.
+func ResolveOutputsRoot(dir, workingDir string) string {
+	if dir == "" {
+		return OutputsRoot()
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workingDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// runMarkerFile is written to each output directory to record the pipeline
+// and working directory that produced it, since the outputs root is shared
+// between projects.
+const runMarkerFile = ".swarm-run.json"
+
+// runMarker is the content of runMarkerFile.
+type runMarker struct {
+	Pipeline   string `json:"pipeline"`
+	WorkingDir string `json:"working_dir"`
+}
+
+// newOutputDir creates a unique, time-sortable output directory for one
+// pipeline iteration under root, marked as produced by pipeline in workingDir.
+func newOutputDir(root, pipeline, workingDir string) (string, error) {
+	runID := time.Now().Format("20060102-150405") + "-" + state.GenerateID()
+	outputDir := filepath.Join(root, runID)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	data, err := json.Marshal(runMarker{Pipeline: pipeline, WorkingDir: workingDir})
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, runMarkerFile), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to mark output directory: %w", err)
+	}
+	return outputDir, nil
+}
+
+// LatestOutputDirIn returns the most recent output directory under root that
+// pipeline produced in workingDir with output from any of taskNames, or "" if
+// no such run has produced output for them.
+func LatestOutputDirIn(root, pipeline, workingDir string, taskNames []string) (string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// Directory names start with a timestamp, so reverse name order is newest first
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+
+	for _, d := range dirs {
+		dir := filepath.Join(root, d)
+		if !producedBy(dir, pipeline, workingDir) {
+			continue
+		}
+		for _, name := range taskNames {
+			if _, err := os.Stat(filepath.Join(dir, name+".txt")); err == nil {
+				return dir, nil
+			}
+			if _, err := os.Stat(taskoutput.Path(dir, name)); err == nil {
+				return dir, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// producedBy reports whether the output directory dir was produced by
+// pipeline, or one of its parallel (name.N) or matrix (name@values)
+// instances, running in workingDir.
+func producedBy(dir, pipeline, workingDir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, runMarkerFile))
+	if err != nil {
+		return false
+	}
+	var marker runMarker
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return false
+	}
+	if marker.WorkingDir != workingDir {
+		return false
+	}
+	if marker.Pipeline == pipeline || strings.HasPrefix(marker.Pipeline, pipeline+"@") {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(marker.Pipeline, pipeline+".")
+	if !ok {
+		return false
+	}
+	_, err = strconv.Atoi(suffix)
+	return err == nil
+}
+
+// copyOutputs copies task output files, including structured outputs and
+// the artifacts in each task's directory, from src into dst.
+func copyOutputs(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			if err := copyDir(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasSuffix(e.Name(), ".txt") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dst, e.Name()), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDir copies the regular files under src into dst, recreating its
+// directory structure.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
@@ -0,0 +1,126 @@
+package dag
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeOutputDir creates an output directory under root as a run of pipeline
+// in workingDir would, containing the given files.
+func writeOutputDir(t *testing.T, root, name, pipeline, workingDir string, files ...string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	os.MkdirAll(dir, 0755)
+	data, _ := json.Marshal(runMarker{Pipeline: pipeline, WorkingDir: workingDir})
+	os.WriteFile(filepath.Join(dir, runMarkerFile), data, 0644)
+	for _, f := range files {
+		os.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+	}
+}
+
+func TestLatestOutputDir(t *testing.T) {
+	root := t.TempDir()
+
+	writeOutputDir(t, root, "20250101-100000-aaaaaaaa", "main", "/work/repo", "planner.txt", "coder.txt")
+	writeOutputDir(t, root, "20250102-100000-bbbbbbbb", "main", "/work/repo", "planner.txt")
+	writeOutputDir(t, root, "20250103-100000-cccccccc", "main", "/work/repo", "other.txt")
+
+	got, err := LatestOutputDirIn(root, "main", "/work/repo", []string{"planner", "coder"})
+	if err != nil {
+		t.Fatalf("LatestOutputDirIn failed: %v", err)
+	}
+	want := filepath.Join(root, "20250102-100000-bbbbbbbb")
+	if got != want {
+		t.Errorf("LatestOutputDirIn() = %q, want %q", got, want)
+	}
+
+	got, _ = LatestOutputDirIn(root, "main", "/work/repo", []string{"missing"})
+	if got != "" {
+		t.Errorf("expected no dir for unknown tasks, got %q", got)
+	}
+}
+
+func TestLatestOutputDirScopedToPipelineAndProject(t *testing.T) {
+	root := t.TempDir()
+
+	// Newer runs from another project, another pipeline, and an unmarked
+	// directory all have a coder task too
+	writeOutputDir(t, root, "20250101-100000-aaaaaaaa", "main", "/work/a", "coder.txt")
+	writeOutputDir(t, root, "20250101-110000-bbbbbbbb", "main.2", "/work/a", "coder.txt")
+	writeOutputDir(t, root, "20250102-100000-cccccccc", "main", "/work/b", "coder.txt")
+	writeOutputDir(t, root, "20250103-100000-dddddddd", "release", "/work/a", "coder.txt")
+	os.MkdirAll(filepath.Join(root, "20250104-100000-eeeeeeee"), 0755)
+	os.WriteFile(filepath.Join(root, "20250104-100000-eeeeeeee", "coder.txt"), []byte("x"), 0644)
+
+	tests := []struct {
+		pipeline, workingDir, want string
+	}{
+		{"main", "/work/a", "20250101-110000-bbbbbbbb"},
+		{"main", "/work/b", "20250102-100000-cccccccc"},
+		{"release", "/work/a", "20250103-100000-dddddddd"},
+		{"release", "/work/b", ""},
+		{"mai", "/work/a", ""},
+	}
+	for _, tt := range tests {
+		got, err := LatestOutputDirIn(root, tt.pipeline, tt.workingDir, []string{"coder"})
+		if err != nil {
+			t.Fatalf("LatestOutputDirIn failed: %v", err)
+		}
+		want := ""
+		if tt.want != "" {
+			want = filepath.Join(root, tt.want)
+		}
+		if got != want {
+			t.Errorf("LatestOutputDirIn(%q, %q) = %q, want %q", tt.pipeline, tt.workingDir, got, want)
+		}
+	}
+}
+
+func TestCopyOutputs(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	os.WriteFile(filepath.Join(src, "planner.txt"), []byte("plan"), 0644)
+	os.WriteFile(filepath.Join(src, "notes.md"), []byte("skip"), 0644)
+	os.MkdirAll(filepath.Join(src, "planner", "docs"), 0755)
+	os.WriteFile(filepath.Join(src, "planner", "docs", "plan.md"), []byte("# Plan"), 0644)
+
+	if err := copyOutputs(src, dst); err != nil {
+		t.Fatalf("copyOutputs failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "planner.txt"))
+	if err != nil || string(data) != "plan" {
+		t.Errorf("planner.txt = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "notes.md")); !os.IsNotExist(err) {
+		t.Error("non-output files should not be copied")
+	}
+	data, err = os.ReadFile(filepath.Join(dst, "planner", "docs", "plan.md"))
+	if err != nil || string(data) != "# Plan" {
+		t.Errorf("planner/docs/plan.md = %q, %v", data, err)
+	}
+}
+
+func TestResolveOutputsRoot(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"", OutputsRoot()},
+		{"/mnt/shared/state", "/mnt/shared/state"},
+		{"state", "/work/repo/state"},
+		{"../state/", "/work/state"},
+		{"~/swarm-state", filepath.Join(home, "swarm-state")},
+	}
+	for _, tt := range tests {
+		if got := ResolveOutputsRoot(tt.dir, "/work/repo"); got != tt.want {
+			t.Errorf("ResolveOutputsRoot(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package dag
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// startRun begins recording a pipeline run in state and tags the pipeline's
+// agent with the run ID. It does nothing without a state manager.
+func (e *Executor) startRun(pipeline compose.Pipeline, iterations int) {
+	if e.cfg.StateManager == nil {
+		return
+	}
+
+	runID := e.cfg.RunID
+	if runID == "" {
+		runID = state.GenerateID()
+	}
+	run := &state.RunState{
+		ID:         runID,
+		Pipeline:   e.cfg.PipelineName,
+		AgentID:    e.cfg.TaskID,
+		PID:        os.Getpid(),
+		WorkingDir: e.cfg.WorkingDir,
+		StartedAt:  time.Now(),
+		Status:     "running",
+		Iterations: iterations,
+	}
+
+	e.mu.Lock()
+	e.run = run
+	e.saveRun()
+	e.mu.Unlock()
+
+	if e.cfg.TaskID != "" {
+		if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+			agentState.RunID = runID
+			_ = e.cfg.StateManager.MergeUpdate(agentState)
+		}
+	}
+
+	fmt.Fprintf(e.cfg.Output, "Run ID: %s\n", runID)
+}
+
+// RunID returns the ID of the run being recorded, or "" if runs aren't recorded.
+func (e *Executor) RunID() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.run == nil {
+		return ""
+	}
+	return e.run.ID
+}
+
+// recordRunIteration notes that an iteration writing to outputDir has started.
+func (e *Executor) recordRunIteration(outputDir string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.run == nil {
+		return
+	}
+	e.run.OutputDirs = append(e.run.OutputDirs, outputDir)
+	e.saveRun()
+}
+
+// recordRunTask adds the outcome of one task execution to the run.
+// mitigation names how a context overflow was retried, if one was.
+func (e *Executor) recordRunTask(taskName string, iteration int, started time.Time, stats logparser.UsageStats, mitigation string, taskErr error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.run == nil {
+		return
+	}
+
+	rt := state.RunTask{
+		Task:         taskName,
+		Iteration:    iteration,
+		Status:       "succeeded",
+		StartedAt:    started,
+		Duration:     time.Since(started),
+		InputTokens:  stats.InputTokens,
+		OutputTokens: stats.OutputTokens,
+		TotalCost:    stats.TotalCostUSD,
+		Mitigation:   mitigation,
+	}
+	if taskErr != nil {
+		rt.Status = "failed"
+		rt.Error = taskErr.Error()
+	}
+	e.run.Tasks = append(e.run.Tasks, rt)
+	e.run.InputTokens += stats.InputTokens
+	e.run.OutputTokens += stats.OutputTokens
+	e.run.TotalCost += stats.TotalCostUSD
+	e.saveRun()
+}
+
+// finishRun records the outcome of the run.
+func (e *Executor) finishRun(completed int, terminated bool, runErr error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.run == nil {
+		return
+	}
+
+	now := time.Now()
+	e.run.FinishedAt = &now
+	e.run.CompletedIterations = completed
+	switch {
+	case runErr != nil:
+		e.run.Status = "failed"
+		e.run.Error = runErr.Error()
+	case terminated:
+		e.run.Status = "terminated"
+	default:
+		e.run.Status = "completed"
+	}
+	e.saveRun()
+}
+
+// saveRun persists the run. Must be called with e.mu held.
+func (e *Executor) saveRun() {
+	if err := e.cfg.StateManager.SaveRun(e.run); err != nil {
+		fmt.Fprintf(e.cfg.Output, "Warning: failed to save run state: %v\n", err)
+	}
+}
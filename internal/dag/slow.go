@@ -0,0 +1,52 @@
+package dag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/notify"
+)
+
+// watchSlowIteration records the start of a pipeline iteration and flags it
+// in the pipeline's state if it runs slow_iteration_factor times longer than
+// the median iteration. Slow pipeline iterations are only flagged, never
+// stopped: each task has its own timeout. The returned function records the
+// iteration's duration and must be called when it ends.
+func (e *Executor) watchSlowIteration(iteration int, started time.Time) (finish func()) {
+	if e.cfg.StateManager == nil || e.cfg.TaskID == "" {
+		return func() {}
+	}
+
+	e.mu.Lock()
+	var median time.Duration
+	if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+		agentState.StartIteration(started)
+		median = agentState.MedianIterationDuration()
+		_ = e.cfg.StateManager.MergeUpdate(agentState)
+	}
+	e.mu.Unlock()
+
+	limit := e.cfg.AppConfig.SlowIterationLimit(median)
+	stop := agent.WatchSlowIteration(limit, func() {
+		e.mu.Lock()
+		if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+			agentState.SlowIteration = true
+			_ = e.cfg.StateManager.MergeUpdate(agentState)
+		}
+		e.mu.Unlock()
+		message := "pipeline " + agent.SlowIterationMessage(iteration, limit, median)
+		fmt.Fprintf(e.cfg.Output, "[swarm] Warning: %s\n", message)
+		e.notify(notify.SlowIterationEvent(iteration, message), e.cfg.Output)
+	})
+
+	return func() {
+		stop()
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+			agentState.FinishIteration(time.Now(), true)
+			_ = e.cfg.StateManager.MergeUpdate(agentState)
+		}
+	}
+}
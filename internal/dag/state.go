@@ -41,6 +41,9 @@ type TaskState struct {
 
 	// CompletedAt is when the task finished (success, failure, or skipped)
 	CompletedAt time.Time
+
+	// Output is the task's structured output (swarm-output.json), if any
+	Output map[string]interface{}
 }
 
 // IsTerminal returns true if the task is in a terminal state (not pending or running).
@@ -131,6 +134,16 @@ func (st *StateTracker) SetSucceeded(name string) {
 	}
 }
 
+// SetOutput records a task's structured output.
+func (st *StateTracker) SetOutput(name string, output map[string]interface{}) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if state, ok := st.states[name]; ok {
+		state.Output = output
+	}
+}
+
 // SetFailed marks a task as failed with an error.
 func (st *StateTracker) SetFailed(name string, err error) {
 	st.mu.Lock()
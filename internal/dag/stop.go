@@ -0,0 +1,71 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/compose"
+)
+
+// stopCommandTimeout bounds how long a stop_when command may run; one that
+// times out counts as not met. A var so tests can shorten it.
+var stopCommandTimeout = 5 * time.Minute
+
+// formatIterationLimit formats an iteration limit for display, where 0 means unlimited.
+func formatIterationLimit(iterations int) string {
+	if iterations == 0 {
+		return "∞"
+	}
+	return strconv.Itoa(iterations)
+}
+
+// checkStopConditions evaluates a pipeline's stop_when conditions after an
+// iteration. Returns true and a description when any condition is met.
+func (e *Executor) checkStopConditions(cond *compose.StopCondition) (bool, string) {
+	if cond == nil {
+		return false, ""
+	}
+
+	if cond.Budget > 0 {
+		e.mu.Lock()
+		cost := e.totalCostUSD
+		e.mu.Unlock()
+		if cost >= cond.Budget {
+			return true, fmt.Sprintf("budget reached ($%.2f of $%.2f)", cost, cond.Budget)
+		}
+	}
+
+	if cond.FileExists != "" {
+		path := cond.FileExists
+		if !filepath.IsAbs(path) && e.cfg.WorkingDir != "" {
+			path = filepath.Join(e.cfg.WorkingDir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true, fmt.Sprintf("file %s exists", cond.FileExists)
+		}
+	}
+
+	if cond.Command != "" {
+		ctx, cancel := context.WithTimeout(e.context(), stopCommandTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", cond.Command)
+		cmd.Dir = e.cfg.WorkingDir
+		// Don't wait on children of the shell still holding its output open
+		cmd.WaitDelay = time.Second
+		err := cmd.Run()
+		if err == nil {
+			return true, fmt.Sprintf("command succeeded: %s", cond.Command)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			fmt.Fprintf(e.cfg.Output, "[swarm] Warning: stop_when command timed out after %v: %s\n", stopCommandTimeout, cond.Command)
+		}
+	}
+
+	return false, ""
+}
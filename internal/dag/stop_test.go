@@ -0,0 +1,97 @@
+package dag
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/compose"
+	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestCheckStopConditions(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExecutor(ExecutorConfig{AppConfig: testConfig(), WorkingDir: dir})
+
+	if stop, _ := e.checkStopConditions(nil); stop {
+		t.Error("nil condition should not stop")
+	}
+
+	fileCond := &compose.StopCondition{FileExists: "DONE"}
+	if stop, _ := e.checkStopConditions(fileCond); stop {
+		t.Error("file_exists should not stop before the file exists")
+	}
+	os.WriteFile(filepath.Join(dir, "DONE"), nil, 0644)
+	if stop, _ := e.checkStopConditions(fileCond); !stop {
+		t.Error("file_exists should stop once the file exists")
+	}
+
+	if stop, _ := e.checkStopConditions(&compose.StopCondition{Command: "false"}); stop {
+		t.Error("failing command should not stop")
+	}
+	if stop, _ := e.checkStopConditions(&compose.StopCondition{Command: "true"}); !stop {
+		t.Error("succeeding command should stop")
+	}
+
+	budgetCond := &compose.StopCondition{Budget: 1.5}
+	if stop, _ := e.checkStopConditions(budgetCond); stop {
+		t.Error("budget should not stop before it is reached")
+	}
+	e.totalCostUSD = 2
+	if stop, _ := e.checkStopConditions(budgetCond); !stop {
+		t.Error("budget should stop once reached")
+	}
+}
+
+func TestCheckStopConditionsCommandTimeout(t *testing.T) {
+	defer func(d time.Duration) { stopCommandTimeout = d }(stopCommandTimeout)
+	stopCommandTimeout = 100 * time.Millisecond
+
+	e := NewExecutor(ExecutorConfig{AppConfig: testConfig(), WorkingDir: t.TempDir(), Output: io.Discard})
+	start := time.Now()
+	if stop, _ := e.checkStopConditions(&compose.StopCondition{Command: "sleep 30"}); stop {
+		t.Error("a command that times out should not stop")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stop_when command ran for %v, want it killed after the timeout", elapsed)
+	}
+}
+
+func TestPipelineBudget(t *testing.T) {
+	e := NewExecutor(ExecutorConfig{AppConfig: testConfig(), Output: io.Discard})
+	if reason := e.budgetExceeded(); reason != "" {
+		t.Errorf("budgetExceeded() = %q without a budget", reason)
+	}
+
+	e.setPipelineBudget(&state.Budget{USD: 1})
+	e.totalCostUSD = 0.6
+	if reason := e.budgetExceeded(); reason != "" {
+		t.Errorf("budgetExceeded() = %q within the budget", reason)
+	}
+	// A running task's usage counts towards the budget
+	e.taskStats["coder"] = logparser.UsageStats{TotalCostUSD: 0.5}
+	if reason := e.budgetExceeded(); reason == "" {
+		t.Error("expected the budget to be exceeded")
+	}
+
+	defer func(d time.Duration) { agent.BudgetGracePeriod = d }(agent.BudgetGracePeriod)
+	agent.BudgetGracePeriod = 10 * time.Millisecond
+	finish := e.startIterationBudget()
+	e.mu.Lock()
+	ctx := e.taskContext()
+	e.checkBudget()
+	e.mu.Unlock()
+	<-ctx.Done()
+	if err := e.budgetErr(); !errors.Is(err, agent.ErrBudgetExceeded) {
+		t.Errorf("budgetErr() = %v, want ErrBudgetExceeded", err)
+	}
+	finish()
+	if e.budgetErr() != nil {
+		t.Error("budget guard outlived its iteration")
+	}
+}
@@ -0,0 +1,70 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mj1618/swarm-cli/internal/exitreport"
+	"github.com/mj1618/swarm-cli/internal/prompt"
+	"github.com/mj1618/swarm-cli/internal/taskoutput"
+)
+
+// injectOutputSchema tells the agent where to write its structured output
+// and the schema it must match, creating the task's output directory.
+func injectOutputSchema(promptContent, outputDir, taskName string, schema *taskoutput.Schema) (string, error) {
+	path := taskoutput.Path(outputDir, taskName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create structured output directory: %w", err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode output_schema: %w", err)
+	}
+	return prompt.InjectOutputSchema(promptContent, path, string(data)), nil
+}
+
+// loadStructuredOutput reads the structured output a task wrote and validates
+// it against the task's schema. A task with a schema must write valid output;
+// for other tasks, structured output is optional and unreadable output is
+// reported and ignored.
+func loadStructuredOutput(out io.Writer, outputDir, taskName string, schema *taskoutput.Schema) (map[string]interface{}, error) {
+	structured, err := taskoutput.Read(outputDir, taskName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if schema != nil {
+				return nil, fmt.Errorf("task declares output_schema but wrote no %s", taskoutput.Path(outputDir, taskName))
+			}
+			return nil, nil
+		}
+		if schema != nil {
+			return nil, fmt.Errorf("invalid structured output: %w", err)
+		}
+		fmt.Fprintf(out, "Warning: ignoring structured output: %v\n", err)
+		return nil, nil
+	}
+
+	if err := schema.Validate(structured); err != nil {
+		return nil, fmt.Errorf("structured output does not match output_schema: %w", err)
+	}
+	return structured, nil
+}
+
+// withExitReport adds the exit report the task's agent wrote to path, if any,
+// to its structured output as "exit", for depends_on conditions such as
+// "exit.status == failure". A structured output field named exit wins.
+func withExitReport(structured map[string]interface{}, path string) map[string]interface{} {
+	report, err := exitreport.Read(path)
+	if err != nil {
+		return structured
+	}
+	if structured == nil {
+		structured = make(map[string]interface{})
+	}
+	if _, ok := structured["exit"]; !ok {
+		structured["exit"] = report.Fields()
+	}
+	return structured
+}
@@ -0,0 +1,96 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// usageSnapshot is the executor's cumulative usage at a point in time.
+type usageSnapshot struct {
+	inputTokens  int64
+	outputTokens int64
+	costUSD      float64
+}
+
+// snapshotUsage returns the cumulative usage of all completed tasks.
+func (e *Executor) snapshotUsage() usageSnapshot {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return usageSnapshot{
+		inputTokens:  e.inputTokens,
+		outputTokens: e.outputTokens,
+		costUSD:      e.totalCostUSD,
+	}
+}
+
+// newIterationSummary builds the summary of an iteration from its final task
+// states and the usage accumulated since before.
+func (e *Executor) newIterationSummary(iteration int, states *StateTracker, before usageSnapshot, started time.Time) *state.IterationSummary {
+	after := e.snapshotUsage()
+	summary := states.GetSummary()
+
+	tasks := make(map[string]string)
+	for name, ts := range states.GetAll() {
+		tasks[name] = string(ts.Status)
+	}
+
+	s := &state.IterationSummary{
+		Iteration:    iteration,
+		Tasks:        tasks,
+		Succeeded:    summary.Succeeded,
+		Failed:       summary.Failed,
+		Skipped:      summary.Skipped,
+		InputTokens:  after.inputTokens - before.inputTokens,
+		OutputTokens: after.outputTokens - before.outputTokens,
+		Cost:         after.costUSD - before.costUSD,
+		Duration:     time.Since(started),
+		FinishedAt:   time.Now(),
+	}
+	if s.Cost <= 0 && e.cfg.AppConfig != nil && e.cfg.StateManager != nil && e.cfg.TaskID != "" {
+		// The agent reported no cost, so estimate it as persistUsageState does
+		if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+			s.Cost = e.cfg.AppConfig.GetPricing(agentState.Model).CalculateCost(s.InputTokens, s.OutputTokens)
+		}
+	}
+	return s
+}
+
+// recordIterationSummary logs an iteration summary and stores it in the
+// pipeline agent's state, so `swarm logs <pipeline> --grep "Iteration summary"`
+// reads as a progress narrative.
+func (e *Executor) recordIterationSummary(s *state.IterationSummary, totalIterations int) {
+	fmt.Fprintf(e.cfg.Output, "[swarm] Iteration summary: %s\n", FormatIterationSummary(s, totalIterations))
+
+	if e.cfg.StateManager == nil || e.cfg.TaskID == "" {
+		return
+	}
+	if agentState, err := e.cfg.StateManager.Get(e.cfg.TaskID); err == nil {
+		agentState.LastIteration = s
+		_ = e.cfg.StateManager.MergeUpdate(agentState)
+	}
+}
+
+// FormatIterationSummary renders an iteration summary on one line, e.g.
+// "iteration 2/5: succeeded=3 failed=1 [review] skipped=0 tokens=12000/800 cost=$0.42 duration=4m12s".
+func FormatIterationSummary(s *state.IterationSummary, totalIterations int) string {
+	var failed []string
+	for name, status := range s.Tasks {
+		if status == string(TaskFailed) {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "iteration %d/%s: succeeded=%d failed=%d", s.Iteration, formatIterationLimit(totalIterations), s.Succeeded, s.Failed)
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, " [%s]", strings.Join(failed, ", "))
+	}
+	fmt.Fprintf(&b, " skipped=%d tokens=%d/%d cost=$%.2f duration=%s",
+		s.Skipped, s.InputTokens, s.OutputTokens, s.Cost, s.Duration.Round(time.Second))
+	return b.String()
+}
@@ -0,0 +1,181 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/agent"
+	"github.com/mj1618/swarm-cli/internal/compose"
+)
+
+const (
+	// triageLogBytes is how much of a failing task's output is kept for its
+	// triage agent.
+	triageLogBytes = 64 * 1024
+
+	// triageExcerptLines is how many lines of the task's output and of the
+	// verify command's output are given to the triage agent.
+	triageExcerptLines = 200
+
+	// verifyCommandTimeout bounds how long a task's verify_command may run.
+	verifyCommandTimeout = 10 * time.Minute
+)
+
+// tailBuffer is an io.Writer that keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	max int
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = append([]byte(nil), t.buf[len(t.buf)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// recordTaskOutcome updates a task's consecutive failure count and returns it.
+// A success also drops any pending triage diagnosis for the task.
+func (e *Executor) recordTaskOutcome(taskName string, failed bool) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !failed {
+		delete(e.failureStreaks, taskName)
+		delete(e.diagnoses, taskName)
+		return 0
+	}
+	e.failureStreaks[taskName]++
+	return e.failureStreaks[taskName]
+}
+
+// triageIfDue runs the task's triage agent once the task has failed
+// on_failure_after times in a row, then starts counting failures again.
+func (e *Executor) triageIfDue(taskName string, task compose.Task, taskErr error, logTail string, out io.Writer, iteration int, outputDir string) {
+	streak := e.recordTaskOutcome(taskName, true)
+	if task.OnFailurePrompt == "" || streak < task.EffectiveOnFailureAfter() {
+		return
+	}
+	e.mu.Lock()
+	e.failureStreaks[taskName] = 0
+	e.mu.Unlock()
+
+	fmt.Fprintf(out, "\n[swarm] Task %s failed %d time(s) in a row, running triage agent (%s)\n", taskName, streak, task.OnFailurePrompt)
+	path, err := e.runTriage(taskName, task, streak, taskErr, logTail, out, iteration, outputDir)
+	if err != nil {
+		fmt.Fprintf(out, "[swarm] Triage of task %s failed: %v\n", taskName, err)
+		return
+	}
+	fmt.Fprintf(out, "[swarm] Triage diagnosis for task %s written to %s\n", taskName, path)
+}
+
+// runTriage runs a one-shot triage agent for a failing task and returns the
+// path of the diagnosis it wrote.
+func (e *Executor) runTriage(taskName string, task compose.Task, streak int, taskErr error, logTail string, out io.Writer, iteration int, outputDir string) (string, error) {
+	content, _, err := e.loadTaskPrompt(compose.Task{Prompt: task.OnFailurePrompt})
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(outputDir, taskName+".triage.md")
+	var b strings.Builder
+	b.WriteString(content)
+	b.WriteString("\n\n---\n\n")
+	fmt.Fprintf(&b, "Task %q failed %d time(s) in a row (latest: iteration %d).\n", taskName, streak, iteration)
+	fmt.Fprintf(&b, "Error: %v\n", taskErr)
+	fmt.Fprintf(&b, "\nRecent output from the task:\n```\n%s\n```\n", lastLines(logTail, triageExcerptLines))
+	if task.VerifyCommand != "" {
+		result := e.runVerifyCommand(task.VerifyCommand, task.Repo)
+		fmt.Fprintf(&b, "\nOutput of verify command `%s`:\n```\n%s\n```\n", task.VerifyCommand, result)
+	}
+	fmt.Fprintf(&b, "\nWrite your diagnosis of the failure and how to fix it to %s. It will be given to the task in its next iteration.", path)
+
+	model := e.cfg.AppConfig.Model
+	if task.Model != "" {
+		model = task.Model
+	}
+	cfg := agent.Config{
+		Model:      model,
+		Prompt:     b.String(),
+		Command:    e.cfg.AppConfig.AgentCommand().WithImage(task.Image),
+		Dir:        task.Repo,
+		StateDir:   outputDir,
+		StderrFile: e.cfg.StderrFile,
+	}
+	if _, err := e.runTaskAttempt(taskName+".triage", cfg, out); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("triage agent did not write %s", path)
+	}
+	e.mu.Lock()
+	e.diagnoses[taskName] = path
+	e.mu.Unlock()
+	return path, nil
+}
+
+// runVerifyCommand runs a task's verify_command in dir (or the working
+// directory if empty) and returns the tail of its output along with its exit
+// status.
+func (e *Executor) runVerifyCommand(command, dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = e.cfg.WorkingDir
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	output, err := cmd.CombinedOutput()
+	status := "exit status 0"
+	if err != nil {
+		status = err.Error()
+	}
+	return fmt.Sprintf("%s\n(%s)", lastLines(strings.TrimRight(string(output), "\n"), triageExcerptLines), status)
+}
+
+// injectDiagnosis prepends the pending triage diagnosis for a task, if any,
+// to its prompt.
+func (e *Executor) injectDiagnosis(taskName, promptContent string) string {
+	e.mu.Lock()
+	path := e.diagnoses[taskName]
+	e.mu.Unlock()
+	if path == "" {
+		return promptContent
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return promptContent
+	}
+	return fmt.Sprintf("A triage agent diagnosed your previous failure:\n\n%s\n\n---\n\n%s", strings.TrimSpace(string(data)), promptContent)
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -33,3 +34,12 @@ func LogFilePath(id string) (string, error) {
 	filename := fmt.Sprintf("%s-%s.log", timestamp, id)
 	return filepath.Join(logsDir, filename), nil
 }
+
+// StderrLogPath returns the sidecar file that captures the agent CLI's stderr
+// for the given agent log file. Returns "" when logFile is empty.
+func StderrLogPath(logFile string) string {
+	if logFile == "" {
+		return ""
+	}
+	return strings.TrimSuffix(logFile, ".log") + ".stderr.log"
+}
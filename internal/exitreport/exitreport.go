@@ -0,0 +1,97 @@
+// Package exitreport implements the optional exit report: a JSON object an
+// agent may write to $SWARM_EXIT_FILE before it finishes, e.g.
+//
+//	{"status": "failure", "summary": "tests still fail in auth", "follow_up": "fix the token refresh"}
+//
+// Swarm records it in the agent's state and uses its status for the
+// iteration's outcome, rather than inferring everything from the logs.
+package exitreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of an exit report file.
+const FileName = "swarm-exit.json"
+
+// EnvVar is the environment variable telling the agent where to write its
+// exit report.
+const EnvVar = "SWARM_EXIT_FILE"
+
+// Report statuses
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// ErrFailure is wrapped by the error of an iteration whose agent reported
+// failure.
+var ErrFailure = errors.New("agent reported failure")
+
+// Report is an agent's account of how its iteration went.
+type Report struct {
+	// Status is "success" or "failure"
+	Status string `json:"status"`
+
+	// Summary says what the agent did, or why it failed
+	Summary string `json:"summary,omitempty"`
+
+	// FollowUp is work the agent left for later
+	FollowUp string `json:"follow_up,omitempty"`
+}
+
+// Path returns the exit report path within dir.
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Env returns the environment variable pointing an agent at path.
+func Env(path string) string {
+	return EnvVar + "=" + path
+}
+
+// Read loads the exit report at path. The returned error satisfies
+// os.IsNotExist when the agent wrote no report.
+func Read(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s is not a valid exit report: %w", FileName, err)
+	}
+	r.Status = strings.ToLower(strings.TrimSpace(r.Status))
+	switch r.Status {
+	case StatusSuccess, StatusFailure:
+	default:
+		return nil, fmt.Errorf("%s: status must be %q or %q, got %q", FileName, StatusSuccess, StatusFailure, r.Status)
+	}
+	return &r, nil
+}
+
+// Err returns an error wrapping ErrFailure if the report is a failure.
+func (r *Report) Err() error {
+	if r == nil || r.Status != StatusFailure {
+		return nil
+	}
+	if r.Summary == "" {
+		return ErrFailure
+	}
+	return fmt.Errorf("%w: %s", ErrFailure, r.Summary)
+}
+
+// Fields returns the report as a structured output object, for depends_on
+// `when:` conditions such as "exit.status == failure".
+func (r *Report) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"status":    r.Status,
+		"summary":   r.Summary,
+		"follow_up": r.FollowUp,
+	}
+}
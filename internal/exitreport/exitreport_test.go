@@ -0,0 +1,57 @@
+package exitreport
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	path := Path(dir)
+
+	if _, err := Read(path); !os.IsNotExist(err) {
+		t.Errorf("Read() without a report = %v, want a not-exist error", err)
+	}
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"status": "Failure", "summary": "tests still fail", "follow_up": "fix auth"}`)
+	r, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if r.Status != StatusFailure || r.Summary != "tests still fail" || r.FollowUp != "fix auth" {
+		t.Errorf("Read() = %+v", r)
+	}
+	if err := r.Err(); !errors.Is(err, ErrFailure) || !strings.Contains(err.Error(), "tests still fail") {
+		t.Errorf("Err() = %v, want ErrFailure with the summary", err)
+	}
+
+	write(`{"status": "success"}`)
+	if r, err := Read(path); err != nil || r.Err() != nil {
+		t.Errorf("Read() success = %+v, %v", r, err)
+	}
+
+	for _, content := range []string{`{"status": "done"}`, `not json`} {
+		write(content)
+		if _, err := Read(path); err == nil || os.IsNotExist(err) {
+			t.Errorf("Read(%q) expected an error", content)
+		}
+	}
+
+	if got := Env(path); got != "SWARM_EXIT_FILE="+filepath.Join(dir, FileName) {
+		t.Errorf("Env() = %q", got)
+	}
+	var nilReport *Report
+	if err := nilReport.Err(); err != nil {
+		t.Errorf("nil Err() = %v", err)
+	}
+}
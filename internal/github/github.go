@@ -0,0 +1,89 @@
+// Package github reads and comments on GitHub issues through the gh CLI, so
+// labeled issues can be handed to agents and their reports posted back.
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// issueListLimit caps how many issues ListIssues fetches.
+const issueListLimit = 100
+
+// Issue is an open GitHub issue.
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	URL    string `json:"url"`
+}
+
+// runGH runs gh with args and stdin, returning its stdout. Tests replace it.
+var runGH = func(stdin string, args ...string) ([]byte, error) {
+	cmd := exec.Command("gh", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, lookErr := exec.LookPath("gh"); lookErr != nil {
+			return nil, fmt.Errorf("the GitHub CLI (gh) is required: %w", lookErr)
+		}
+		return nil, fmt.Errorf("gh %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// repoArgs returns the --repo flag for repo, or none to use the repository
+// of the current directory.
+func repoArgs(repo string) []string {
+	if repo == "" {
+		return nil
+	}
+	return []string{"--repo", repo}
+}
+
+// ListIssues returns the open issues in repo that have label, oldest first.
+// An empty repo means the repository of the current directory.
+func ListIssues(repo, label string) ([]Issue, error) {
+	args := []string{"issue", "list", "--state", "open", "--label", label,
+		"--limit", strconv.Itoa(issueListLimit), "--json", "number,title,body,url"}
+	out, err := runGH("", append(args, repoArgs(repo)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("failed to parse gh issue list output: %w", err)
+	}
+	// gh lists the newest issues first
+	for i, j := 0, len(issues)-1; i < j; i, j = i+1, j-1 {
+		issues[i], issues[j] = issues[j], issues[i]
+	}
+	return issues, nil
+}
+
+// Comment posts body as a comment on issue number in repo.
+func Comment(repo string, number int, body string) error {
+	args := []string{"issue", "comment", strconv.Itoa(number), "--body-file", "-"}
+	_, err := runGH(body, append(args, repoArgs(repo)...)...)
+	return err
+}
+
+// TaskPrompt returns the task an issue describes, for an agent's prompt.
+func (i Issue) TaskPrompt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# GitHub issue #%d: %s\n", i.Number, i.Title)
+	if i.URL != "" {
+		fmt.Fprintf(&b, "\n%s\n", i.URL)
+	}
+	if body := strings.TrimSpace(i.Body); body != "" {
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+	return b.String()
+}
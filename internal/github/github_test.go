@@ -0,0 +1,68 @@
+package github
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeGH replaces runGH for the test, recording the args and stdin of each
+// call and returning output.
+func fakeGH(t *testing.T, output string) *[][]string {
+	t.Helper()
+	var calls [][]string
+	orig := runGH
+	runGH = func(stdin string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{stdin}, args...))
+		return []byte(output), nil
+	}
+	t.Cleanup(func() { runGH = orig })
+	return &calls
+}
+
+func TestListIssues(t *testing.T) {
+	calls := fakeGH(t, `[
+		{"number": 12, "title": "Newer", "body": "b", "url": "https://github.com/o/r/issues/12"},
+		{"number": 7, "title": "Older", "body": "", "url": "https://github.com/o/r/issues/7"}
+	]`)
+
+	issues, err := ListIssues("o/r", "swarm")
+	if err != nil {
+		t.Fatalf("ListIssues() error = %v", err)
+	}
+	if len(issues) != 2 || issues[0].Number != 7 || issues[1].Number != 12 {
+		t.Errorf("ListIssues() = %+v, want issues 7 then 12", issues)
+	}
+
+	want := []string{"", "issue", "list", "--state", "open", "--label", "swarm",
+		"--limit", "100", "--json", "number,title,body,url", "--repo", "o/r"}
+	if !reflect.DeepEqual((*calls)[0], want) {
+		t.Errorf("gh call = %v, want %v", (*calls)[0], want)
+	}
+}
+
+func TestComment(t *testing.T) {
+	calls := fakeGH(t, "")
+
+	if err := Comment("", 7, "Done."); err != nil {
+		t.Fatalf("Comment() error = %v", err)
+	}
+	want := []string{"Done.", "issue", "comment", "7", "--body-file", "-"}
+	if !reflect.DeepEqual((*calls)[0], want) {
+		t.Errorf("gh call = %v, want %v", (*calls)[0], want)
+	}
+}
+
+func TestTaskPrompt(t *testing.T) {
+	issue := Issue{Number: 7, Title: "Fix login", Body: "Login fails on Safari.\n", URL: "https://github.com/o/r/issues/7"}
+	got := issue.TaskPrompt()
+	for _, want := range []string{"# GitHub issue #7: Fix login\n", "https://github.com/o/r/issues/7\n", "\nLogin fails on Safari.\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("TaskPrompt() = %q, missing %q", got, want)
+		}
+	}
+
+	if got := (Issue{Number: 1, Title: "T"}).TaskPrompt(); got != "# GitHub issue #1: T\n" {
+		t.Errorf("TaskPrompt() without body = %q", got)
+	}
+}
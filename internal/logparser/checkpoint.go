@@ -0,0 +1,94 @@
+package logparser
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// checkpointSuffix is appended to a log file's path for its usage checkpoint.
+const checkpointSuffix = ".usage.json"
+
+// usageCheckpoint records the usage accumulated from the first Offset bytes
+// of a log file, so later scans only need to read what was appended since.
+type usageCheckpoint struct {
+	Offset int64      `json:"offset"`
+	Stats  UsageStats `json:"stats"`
+}
+
+// CheckpointPath returns the path of the usage checkpoint for a log file.
+func CheckpointPath(logFile string) string {
+	return logFile + checkpointSuffix
+}
+
+// ScanLogFileFrom continues a usage scan: it reads complete lines from r,
+// adding their usage to stats, and returns the updated stats together with
+// the number of bytes consumed. A trailing partial line (one still being
+// written) is not consumed, so the next scan picks it up once complete.
+func ScanLogFileFrom(r io.Reader, stats UsageStats) (UsageStats, int64, error) {
+	sp := NewStreamingParser(io.Discard, nil)
+	sp.stats = stats
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var consumed int64
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return sp.stats, consumed, nil
+		}
+		if err != nil {
+			return sp.stats, consumed, err
+		}
+		consumed += int64(len(line))
+		sp.extractUsage(line)
+	}
+}
+
+// ScanLogFileCached returns the accumulated usage stats of a log file,
+// reading only what was appended since the last call. Progress is persisted
+// in a checkpoint file next to the log. If the log is shorter than the
+// checkpoint (e.g. it was truncated), it is rescanned from the start.
+func ScanLogFileCached(logFile string) (UsageStats, error) {
+	f, err := os.Open(logFile)
+	if err != nil {
+		return UsageStats{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return UsageStats{}, err
+	}
+
+	var cp usageCheckpoint
+	cpPath := CheckpointPath(logFile)
+	if data, err := os.ReadFile(cpPath); err == nil {
+		if json.Unmarshal(data, &cp) != nil || cp.Offset > info.Size() || cp.Offset < 0 {
+			cp = usageCheckpoint{}
+		}
+	}
+
+	if cp.Offset == info.Size() {
+		return cp.Stats, nil
+	}
+
+	if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+		return UsageStats{}, err
+	}
+	stats, n, err := ScanLogFileFrom(f, cp.Stats)
+	if err != nil {
+		return UsageStats{}, err
+	}
+
+	cp.Offset += n
+	cp.Stats = stats
+	if data, err := json.Marshal(cp); err == nil {
+		// Best effort: a missing checkpoint only costs a full rescan next time
+		tmp := cpPath + ".tmp"
+		if os.WriteFile(tmp, data, 0644) == nil {
+			os.Rename(tmp, cpPath)
+		}
+	}
+	return stats, nil
+}
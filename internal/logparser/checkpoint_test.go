@@ -0,0 +1,69 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanLogFileCachedIsIncremental(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "agent.log")
+	first := `{"type":"assistant","message":{"role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}` + "\n"
+	os.WriteFile(logFile, []byte(first), 0644)
+
+	stats, err := ScanLogFileCached(logFile)
+	if err != nil {
+		t.Fatalf("ScanLogFileCached failed: %v", err)
+	}
+	if stats.InputTokens != 100 || stats.OutputTokens != 10 {
+		t.Fatalf("first scan = %+v, want 100/10", stats)
+	}
+
+	// Append a complete line and a partial one still being written
+	f, _ := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"type":"result","total_cost_usd":0.5,"usage":{"input_tokens":50,"output_tokens":5}}` + "\n")
+	f.WriteString(`{"type":"assistant","message":{"usage":{"input_tokens":7`)
+	f.Close()
+
+	stats, err = ScanLogFileCached(logFile)
+	if err != nil {
+		t.Fatalf("ScanLogFileCached failed: %v", err)
+	}
+	if stats.InputTokens != 150 || stats.OutputTokens != 15 || stats.TotalCostUSD != 0.5 {
+		t.Fatalf("second scan = %+v, want 150/15/$0.5", stats)
+	}
+
+	// The checkpoint stops before the partial line
+	data, err := os.ReadFile(CheckpointPath(logFile))
+	if err != nil {
+		t.Fatalf("checkpoint not written: %v", err)
+	}
+	if !strings.Contains(string(data), `"offset":`) {
+		t.Errorf("unexpected checkpoint: %s", data)
+	}
+
+	// Finishing the partial line counts it exactly once
+	f, _ = os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`,"output_tokens":1}}}` + "\n")
+	f.Close()
+
+	stats, _ = ScanLogFileCached(logFile)
+	if stats.InputTokens != 157 || stats.OutputTokens != 16 {
+		t.Errorf("third scan = %+v, want 157/16", stats)
+	}
+}
+
+func TestScanLogFileCachedRescansTruncatedLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "agent.log")
+	line := `{"type":"assistant","message":{"usage":{"input_tokens":100,"output_tokens":10}}}` + "\n"
+	os.WriteFile(logFile, []byte(line+line), 0644)
+	if stats, _ := ScanLogFileCached(logFile); stats.InputTokens != 200 {
+		t.Fatalf("first scan input = %d, want 200", stats.InputTokens)
+	}
+
+	os.WriteFile(logFile, []byte(line), 0644)
+	if stats, _ := ScanLogFileCached(logFile); stats.InputTokens != 100 {
+		t.Errorf("scan after truncation input = %d, want 100", stats.InputTokens)
+	}
+}
@@ -0,0 +1,55 @@
+package logparser
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+// Dedup collapses runs of identical consecutive events, such as those of an
+// agent stuck in a tight retry loop, into the first event and a count of
+// its repeats.
+type Dedup struct {
+	last    string
+	seen    bool
+	repeats int
+}
+
+// Add records event. It reports whether event repeats the previous one and
+// should be skipped; otherwise it returns how many times the previous event
+// was repeated (0 if it wasn't), for the caller to report before event.
+func (d *Dedup) Add(event string) (repeats int, skip bool) {
+	if d.seen && event == d.last {
+		d.repeats++
+		return 0, true
+	}
+	repeats = d.repeats
+	d.last, d.seen, d.repeats = event, true, 0
+	return repeats, false
+}
+
+// Repeats returns how many times the last event has been repeated so far.
+func (d *Dedup) Repeats() int {
+	return d.repeats
+}
+
+// Reset forgets the last event, returning how many times it was repeated.
+// It's called when other output separates the events.
+func (d *Dedup) Reset() int {
+	repeats := d.repeats
+	d.last, d.seen, d.repeats = "", false, 0
+	return repeats
+}
+
+// RepeatedMessage describes an event repeated n times.
+func RepeatedMessage(n int) string {
+	if n == 1 {
+		return "previous message repeated once"
+	}
+	return fmt.Sprintf("previous message repeated %d times", n)
+}
+
+// FormatRepeats formats RepeatedMessage(n) as pretty output.
+func FormatRepeats(n int) string {
+	return color.New(color.Faint).Sprintf("(%s)", RepeatedMessage(n)) + "\n\n"
+}
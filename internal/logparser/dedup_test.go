@@ -0,0 +1,69 @@
+package logparser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDedup(t *testing.T) {
+	var d Dedup
+	for i, tc := range []struct {
+		event   string
+		repeats int
+		skip    bool
+	}{
+		{"a", 0, false},
+		{"a", 0, true},
+		{"a", 0, true},
+		{"b", 2, false},
+		{"a", 0, false},
+	} {
+		repeats, skip := d.Add(tc.event)
+		if repeats != tc.repeats || skip != tc.skip {
+			t.Errorf("Add #%d (%q) = %d, %v; want %d, %v", i, tc.event, repeats, skip, tc.repeats, tc.skip)
+		}
+	}
+	d.Add("a")
+	if got := d.Reset(); got != 1 {
+		t.Errorf("Reset() = %d, want 1", got)
+	}
+	if _, skip := d.Add("a"); skip {
+		t.Error("event after Reset should not be a repeat")
+	}
+}
+
+func TestParserDedup(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewParser(&buf)
+	p.SetDedup(true)
+
+	for i := 0; i < 48; i++ {
+		p.ProcessLine(`{"type": "result", "subtype": "error", "result": "rate limited"}`)
+	}
+	p.ProcessLine(`{"type": "result", "result": "done"}`)
+	p.ProcessLine(`{"type": "thinking", "text": "retry"}`)
+	p.ProcessLine(`{"type": "thinking", "text": "retry"}`)
+	p.ProcessLine("not json")
+	p.ProcessLine("not json")
+	p.Flush()
+
+	output := buf.String()
+	if n := strings.Count(output, "rate limited"); n != 1 {
+		t.Errorf("repeated event printed %d times, want 1: %q", n, output)
+	}
+	if !strings.Contains(output, "previous message repeated 47 times") {
+		t.Errorf("missing repeat count: %q", output)
+	}
+	if !strings.Contains(output, "done") {
+		t.Errorf("missing event after repeats: %q", output)
+	}
+	// Merged fragments are never collapsed
+	if !strings.Contains(output, "retryretry") {
+		t.Errorf("thinking run should be merged, not deduped: %q", output)
+	}
+	// Pending repeats are reported on Flush
+	if !strings.Contains(output, "previous message repeated once") {
+		t.Errorf("missing repeat count on flush: %q", output)
+	}
+}
@@ -0,0 +1,83 @@
+package logparser
+
+// claudeEditTools maps the Claude Code tools that change files to the input
+// field naming the file.
+var claudeEditTools = map[string]string{
+	"Write":        "file_path",
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+// cursorEditTools are the Cursor tool calls that change files; their args
+// name the file in "path" or "file_path".
+var cursorEditTools = map[string]bool{
+	"writeToolCall":      true,
+	"Write":              true,
+	"StrReplace":         true,
+	"strReplaceToolCall": true,
+	"editToolCall":       true,
+	"Edit":               true,
+	"deleteToolCall":     true,
+	"Delete":             true,
+}
+
+// EditedPaths returns the files an event's tool calls write, edit, or
+// delete, as given by the agent (absolute or relative to its working
+// directory). Files changed by shell commands aren't reported.
+func EditedPaths(event *LogEvent) []string {
+	if event == nil {
+		return nil
+	}
+
+	var paths []string
+	addClaude := func(name string, input map[string]interface{}) {
+		if field, ok := claudeEditTools[name]; ok {
+			if p := stringField(input, field); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	switch event.Type {
+	case "assistant":
+		if event.Message != nil {
+			for _, item := range event.Message.Content {
+				if item.Type == "tool_use" {
+					addClaude(item.Name, item.Input)
+				}
+			}
+		}
+	case "tool_use":
+		name := event.ToolName
+		if name == "" {
+			name = event.Name
+		}
+		addClaude(name, event.Input)
+	case "tool_call":
+		for name, data := range event.ToolCall {
+			if !cursorEditTools[name] {
+				continue
+			}
+			inner, _ := data.(map[string]interface{})
+			args, ok := inner["args"].(map[string]interface{})
+			if !ok {
+				args = inner
+			}
+			if p := stringField(args, "path", "file_path"); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+// stringField returns the first of keys that is a string in m.
+func stringField(m map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package logparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditedPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "claude assistant tool_use",
+			line: `{"type":"assistant","message":{"content":[{"type":"text","text":"Editing"},{"type":"tool_use","name":"Edit","input":{"file_path":"/repo/docs/a.md"}},{"type":"tool_use","name":"Write","input":{"file_path":"b.go"}}]}}`,
+			want: []string{"/repo/docs/a.md", "b.go"},
+		},
+		{
+			name: "claude read is not an edit",
+			line: `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read","input":{"file_path":"/repo/main.go"}}]}}`,
+		},
+		{
+			name: "claude notebook edit",
+			line: `{"type":"tool_use","name":"NotebookEdit","input":{"notebook_path":"nb.ipynb"}}`,
+			want: []string{"nb.ipynb"},
+		},
+		{
+			name: "cursor write",
+			line: `{"type":"tool_call","subtype":"started","tool_call":{"writeToolCall":{"args":{"path":"src/main.go","fileText":"x"}}}}`,
+			want: []string{"src/main.go"},
+		},
+		{
+			name: "cursor delete with top-level args",
+			line: `{"type":"tool_call","tool_call":{"deleteToolCall":{"path":"old.txt"}}}`,
+			want: []string{"old.txt"},
+		},
+		{
+			name: "cursor read",
+			line: `{"type":"tool_call","tool_call":{"readToolCall":{"args":{"path":"src/main.go"}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EditedPaths(ParseEvent(tt.line))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EditedPaths() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := EditedPaths(nil); got != nil {
+		t.Errorf("EditedPaths(nil) = %q, want nil", got)
+	}
+}
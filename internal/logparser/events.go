@@ -0,0 +1,61 @@
+package logparser
+
+import "bytes"
+
+// EventSplitter pretty-prints log lines like Parser, but returns the output
+// split into logical events instead of writing it: one per printed event or
+// raw line, and one per run of merged message or thinking fragments. Every
+// event starts with its own header, so events can be filtered (e.g. by
+// swarm logs --grep) and shown on their own.
+type EventSplitter struct {
+	parser *Parser
+	buf    bytes.Buffer
+	events []string
+}
+
+// NewEventSplitter creates a new event splitter.
+func NewEventSplitter() *EventSplitter {
+	s := &EventSplitter{}
+	s.parser = NewParser(&s.buf)
+	s.parser.onEventEnd = s.endEvent
+	return s
+}
+
+// ProcessLine processes a single log line and returns the events it
+// completed. A run of merged fragments is only complete once a line that
+// doesn't continue it is processed, or on Flush.
+func (s *EventSplitter) ProcessLine(line string) []string {
+	s.parser.ProcessLine(line)
+	return s.take()
+}
+
+// Flush completes any open run of fragments and returns it.
+func (s *EventSplitter) Flush() []string {
+	s.parser.Flush()
+	return s.take()
+}
+
+func (s *EventSplitter) endEvent() {
+	if s.buf.Len() > 0 {
+		s.events = append(s.events, s.buf.String())
+		s.buf.Reset()
+	}
+	// Repeat the header for the next event, even if it's the same
+	s.parser.lastHeader = ""
+}
+
+func (s *EventSplitter) take() []string {
+	events := s.events
+	s.events = nil
+	return events
+}
+
+// FormatEvents pretty-prints lines and returns the output as logical events.
+func FormatEvents(lines []string) []string {
+	s := NewEventSplitter()
+	var events []string
+	for _, line := range lines {
+		events = append(events, s.ProcessLine(line)...)
+	}
+	return append(events, s.Flush()...)
+}
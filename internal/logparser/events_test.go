@@ -0,0 +1,57 @@
+package logparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatEvents(t *testing.T) {
+	lines := []string{
+		`{"type": "thinking", "text": "First thought "}`,
+		`{"type": "thinking", "text": "second thought."}`,
+		`{"type": "result", "result": "first"}`,
+		`{"type": "result", "result": "second"}`,
+		`not json`,
+		`{"type": "assistant", "message": {"role": "assistant", "content": [{"type": "text", "text": "All done"}]}}`,
+	}
+
+	events := FormatEvents(lines)
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5: %q", len(events), events)
+	}
+
+	// A merged run is one event, so it can be matched as a whole
+	if !strings.Contains(events[0], "[thinking]") || !strings.Contains(events[0], "First thought second thought.") {
+		t.Errorf("events[0] = %q, want the merged thinking run", events[0])
+	}
+
+	// Every event has its own header, even when it repeats
+	for i, want := range []string{"first", "second"} {
+		event := events[1+i]
+		if !strings.Contains(event, "[result]") || !strings.Contains(event, want) {
+			t.Errorf("events[%d] = %q, want a result header and %q", 1+i, event, want)
+		}
+	}
+
+	if events[3] != "not json\n\n" {
+		t.Errorf("events[3] = %q, want the raw line", events[3])
+	}
+	if !strings.Contains(events[4], "[assistant]") || !strings.Contains(events[4], "All done") {
+		t.Errorf("events[4] = %q, want the assistant message", events[4])
+	}
+}
+
+func TestEventSplitterHoldsOpenRun(t *testing.T) {
+	s := NewEventSplitter()
+
+	if events := s.ProcessLine(`{"type": "thinking", "text": "hmm"}`); len(events) != 0 {
+		t.Errorf("open run returned events %q", events)
+	}
+	events := s.ProcessLine(`{"type": "result", "result": "done"}`)
+	if len(events) != 2 || !strings.Contains(events[0], "hmm") || !strings.Contains(events[1], "done") {
+		t.Errorf("got %q, want the thinking run then the result", events)
+	}
+	if events := s.Flush(); len(events) != 0 {
+		t.Errorf("Flush returned %q, want nothing", events)
+	}
+}
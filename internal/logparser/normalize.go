@@ -0,0 +1,101 @@
+package logparser
+
+import (
+	"strings"
+	"time"
+)
+
+// NormalizedEvent is a log event in a shape common to all agent CLIs, as
+// printed by swarm logs --json.
+type NormalizedEvent struct {
+	Type         string  `json:"type"`
+	Subtype      string  `json:"subtype,omitempty"`
+	Tool         string  `json:"tool,omitempty"`
+	Summary      string  `json:"summary"`
+	InputTokens  int64   `json:"input_tokens,omitempty"`
+	OutputTokens int64   `json:"output_tokens,omitempty"`
+	CostUSD      float64 `json:"cost_usd,omitempty"`
+	Timestamp    string  `json:"timestamp,omitempty"`
+}
+
+// Normalize returns the normalized events of a log line, summarized as they
+// are pretty-printed. A message with tool_use blocks gives a "tool_use" event
+// per block, after an event for its text if it has any. A line that isn't
+// JSON, such as swarm's own [swarm] messages, gives one "text" event. Empty
+// lines give none.
+func Normalize(line string) []NormalizedEvent {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil
+	}
+	event := ParseEvent(trimmed)
+	if event == nil {
+		return []NormalizedEvent{{Type: "text", Summary: trimmed}}
+	}
+
+	var p Parser
+	base := NormalizedEvent{Type: event.Type, Subtype: event.Subtype}
+	if event.TimestampMs > 0 {
+		base.Timestamp = time.UnixMilli(event.TimestampMs).In(TimestampLocation()).Format(time.RFC3339)
+	}
+	if usage := FindUsage(event); usage != nil {
+		base.InputTokens, base.OutputTokens = usage.Tokens()
+	}
+	if event.TotalCostUSD != nil {
+		base.CostUSD = *event.TotalCostUSD
+	}
+
+	// Claude Code messages carry tool calls as content blocks
+	if event.Message != nil {
+		var events []NormalizedEvent
+		if text := p.pickTextFromContent(event.Message.Content); text != "" {
+			e := base
+			e.Summary = text
+			events = append(events, e)
+		}
+		for _, item := range event.Message.Content {
+			if item.Type != "tool_use" {
+				continue
+			}
+			e := base
+			e.Type = "tool_use"
+			e.Tool = item.Name
+			e.Summary = p.summarizeClaudeToolUse(item.Name, item.Input)
+			// Usage is reported once per message
+			if len(events) > 0 {
+				e.InputTokens, e.OutputTokens, e.CostUSD = 0, 0, 0
+			}
+			events = append(events, e)
+		}
+		if len(events) > 0 {
+			return events
+		}
+	}
+
+	base.Tool = eventTool(event)
+	base.Summary = p.bodyFor(event)
+	return []NormalizedEvent{base}
+}
+
+// eventTool returns the tool a non-message event calls, or "".
+func eventTool(event *LogEvent) string {
+	switch {
+	case event.Type == "tool_use":
+		if event.ToolName != "" {
+			return event.ToolName
+		}
+		return event.Name
+	case event.Type == "tool_call":
+		// Cursor: the tool name is the tool_call's only key
+		for name := range event.ToolCall {
+			return name
+		}
+	case event.Item != nil:
+		switch event.Item.Type {
+		case "agent_message", "reasoning":
+			return ""
+		}
+		return event.Item.Type
+	}
+	return ""
+}
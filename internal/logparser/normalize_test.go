@@ -0,0 +1,44 @@
+package logparser
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	if events := Normalize("   "); events != nil {
+		t.Errorf("Normalize(blank) = %+v, want none", events)
+	}
+
+	events := Normalize("[swarm] === Iteration 2/5 ===")
+	if len(events) != 1 || events[0].Type != "text" || events[0].Summary != "[swarm] === Iteration 2/5 ===" {
+		t.Errorf("Normalize(text) = %+v", events)
+	}
+
+	events = Normalize(`{"type": "assistant", "timestamp_ms": 1700000000000, "message": {"role": "assistant", "usage": {"input_tokens": 100, "output_tokens": 20}, "content": [` +
+		`{"type": "text", "text": "Let me look"}, {"type": "tool_use", "name": "Read", "input": {"file_path": "main.go"}}, {"type": "tool_use", "name": "Bash", "input": {"command": "go test"}}]}}`)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if e := events[0]; e.Type != "assistant" || e.Summary != "Let me look" || e.InputTokens != 100 || e.OutputTokens != 20 || e.Timestamp == "" {
+		t.Errorf("text event = %+v", e)
+	}
+	if e := events[1]; e.Type != "tool_use" || e.Tool != "Read" || e.Summary != "Read file: main.go" || e.InputTokens != 0 {
+		t.Errorf("first tool event = %+v", e)
+	}
+	if e := events[2]; e.Tool != "Bash" || e.Summary != "Shell: go test" {
+		t.Errorf("second tool event = %+v", e)
+	}
+
+	events = Normalize(`{"type": "result", "subtype": "success", "result": "Done", "total_cost_usd": 0.42, "usage": {"input_tokens": 5, "output_tokens": 7}}`)
+	if len(events) != 1 || events[0].Subtype != "success" || events[0].CostUSD != 0.42 || events[0].OutputTokens != 7 || events[0].Summary != "Result (success): Done" {
+		t.Errorf("Normalize(result) = %+v", events)
+	}
+
+	events = Normalize(`{"type": "tool_call", "subtype": "started", "tool_call": {"shellToolCall": {"args": {"command": "ls"}}}}`)
+	if len(events) != 1 || events[0].Tool != "shellToolCall" || events[0].Summary != "Shell: ls" {
+		t.Errorf("Normalize(cursor tool call) = %+v", events)
+	}
+
+	events = Normalize(`{"type": "item.completed", "item": {"type": "command_execution", "command": "make"}}`)
+	if len(events) != 1 || events[0].Tool != "command_execution" || events[0].Summary != "Shell (completed): make" {
+		t.Errorf("Normalize(codex item) = %+v", events)
+	}
+}
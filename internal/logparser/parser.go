@@ -18,6 +18,13 @@ type Parser struct {
 	out        io.Writer
 	openRun    *openRun
 	lastHeader string
+
+	// onEventEnd, if set, is called each time a logical event has been
+	// fully written (see EventSplitter)
+	onEventEnd func()
+
+	// dedup, if set, collapses repeats of identical events (see SetDedup)
+	dedup *Dedup
 }
 
 type openRun struct {
@@ -37,6 +44,7 @@ type LogEvent struct {
 	SessionID   string                 `json:"session_id"`
 	ToolCall    map[string]interface{} `json:"tool_call"`
 	Result      string                 `json:"result"`
+	IsError     bool                   `json:"is_error"`
 	DurationMs  int64                  `json:"duration_ms"`
 	// Usage fields (may be present in API response events)
 	Usage *Usage `json:"usage,omitempty"`
@@ -50,6 +58,10 @@ type LogEvent struct {
 	// Codex CLI fields
 	Item     *CodexItem `json:"item,omitempty"`
 	ThreadID string     `json:"thread_id,omitempty"`
+	// OpenAI-compatible response and final summary payloads, decoded on
+	// demand since their shapes vary between CLIs (see FindUsage)
+	Response json.RawMessage `json:"response,omitempty"`
+	Summary  json.RawMessage `json:"summary,omitempty"`
 }
 
 // CodexItem represents an item in a Codex CLI JSONL event.
@@ -80,11 +92,15 @@ type UsageStats struct {
 	OutputTokens int64
 	TotalCostUSD float64
 	CurrentTask  string
+	// Model is the model the agent CLI last reported using (empty if it
+	// didn't), which can differ from the requested one after a fallback
+	Model string
 }
 
 // Message represents a user or assistant message.
 type Message struct {
 	Role    string        `json:"role"`
+	Model   string        `json:"model,omitempty"`
 	Content []ContentItem `json:"content"`
 	Usage   *Usage        `json:"usage,omitempty"`
 }
@@ -150,23 +166,8 @@ func (sp *StreamingParser) extractUsage(line string) {
 
 	updated := false
 
-	// Find usage from the best available location:
-	// 1. Top-level usage (result events)
-	// 2. message.usage (assistant events)
-	usage := event.Usage
-	if usage == nil && event.Message != nil {
-		usage = event.Message.Usage
-	}
-
-	if usage != nil {
-		inputTokens := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens + usage.CachedInputTokens
-		if inputTokens == 0 {
-			inputTokens = usage.PromptTokens
-		}
-		outputTokens := usage.OutputTokens
-		if outputTokens == 0 {
-			outputTokens = usage.CompletionTokens
-		}
+	if usage := FindUsage(&event); usage != nil {
+		inputTokens, outputTokens := usage.Tokens()
 		if inputTokens > 0 || outputTokens > 0 {
 			sp.stats.InputTokens += inputTokens
 			sp.stats.OutputTokens += outputTokens
@@ -180,6 +181,11 @@ func (sp *StreamingParser) extractUsage(line string) {
 		updated = true
 	}
 
+	if model := EventModel(&event); model != "" && model != sp.stats.Model {
+		sp.stats.Model = model
+		updated = true
+	}
+
 	// Update current task based on event type
 	taskUpdated := sp.updateCurrentTask(&event)
 	if taskUpdated {
@@ -422,6 +428,7 @@ func (p *Parser) ProcessLine(line string) {
 		// Recover from any panics - just output raw line
 		if r := recover(); r != nil {
 			p.safeWrite(line + "\n\n")
+			p.endEvent()
 		}
 	}()
 
@@ -434,7 +441,7 @@ func (p *Parser) ProcessLine(line string) {
 	if err := json.Unmarshal([]byte(trimmed), &event); err != nil {
 		// Not valid JSON - output raw
 		p.flushRun()
-		p.safeWrite(trimmed + "\n\n")
+		p.writeEvent("", trimmed+"\n\n")
 		return
 	}
 
@@ -461,8 +468,7 @@ func (p *Parser) ProcessLine(line string) {
 				switch item.Type {
 				case "tool_use":
 					summary := p.summarizeClaudeToolUse(item.Name, item.Input)
-					p.maybePrintHeader("[tool_use]")
-					p.safeWrite(summary + "\n\n")
+					p.writeEvent("[tool_use]", summary+"\n\n")
 				case "text":
 					if text := p.sanitizeSingleLine(item.Text); text != "" {
 						p.startOrAppendRun(role, fmt.Sprintf("[%s]", role), text)
@@ -498,13 +504,50 @@ func (p *Parser) ProcessLine(line string) {
 
 	// Non-mergeable event: flush and print
 	p.flushRun()
-	p.maybePrintHeader(header)
-	p.safeWrite(p.bodyFor(&event) + "\n\n")
+	p.writeEvent(header, p.bodyFor(&event)+"\n\n")
 }
 
 // Flush ensures any buffered content is written.
 func (p *Parser) Flush() {
 	p.flushRun()
+	if p.dedup != nil {
+		p.writeRepeats(p.dedup.Reset())
+	}
+}
+
+// SetDedup enables or disables collapsing identical consecutive events, e.g.
+// from an agent stuck in a retry loop, into the first event followed by a
+// "previous message repeated N times" line. Runs of merged fragments are never
+// collapsed.
+func (p *Parser) SetDedup(enabled bool) {
+	if enabled {
+		p.dedup = &Dedup{}
+	} else {
+		p.dedup = nil
+	}
+}
+
+// writeEvent writes a complete, non-mergeable event.
+func (p *Parser) writeEvent(header, body string) {
+	if p.dedup != nil {
+		repeats, skip := p.dedup.Add(header + "\x00" + body)
+		if skip {
+			return
+		}
+		p.writeRepeats(repeats)
+	}
+	p.maybePrintHeader(header)
+	p.safeWrite(body)
+	p.endEvent()
+}
+
+// writeRepeats reports that the previous event was repeated n times.
+func (p *Parser) writeRepeats(n int) {
+	if n == 0 {
+		return
+	}
+	p.safeWrite(FormatRepeats(n))
+	p.endEvent()
 }
 
 func (p *Parser) safeWrite(s string) {
@@ -518,6 +561,14 @@ func (p *Parser) flushRun() {
 	}
 	p.safeWrite("\n\n")
 	p.openRun = nil
+	p.endEvent()
+}
+
+// endEvent marks the end of a logical event in the output.
+func (p *Parser) endEvent() {
+	if p.onEventEnd != nil {
+		p.onEventEnd()
+	}
 }
 
 func (p *Parser) maybePrintHeader(header string) {
@@ -539,6 +590,9 @@ func (p *Parser) startOrAppendRun(kind, header, fragment string) {
 
 	if p.openRun == nil || p.openRun.kind != kind {
 		p.flushRun()
+		if p.dedup != nil {
+			p.writeRepeats(p.dedup.Reset())
+		}
 		p.maybePrintHeader(header)
 		p.openRun = &openRun{kind: kind}
 	}
@@ -568,8 +622,7 @@ func (p *Parser) fmtPrefix(event *LogEvent) string {
 
 	// Format timestamp
 	if event.TimestampMs > 0 {
-		t := time.UnixMilli(event.TimestampMs)
-		pieces = append(pieces, t.Format(time.RFC3339))
+		pieces = append(pieces, FormatTimestamp(time.UnixMilli(event.TimestampMs)))
 	}
 
 	if event.Type != "" {
@@ -0,0 +1,60 @@
+package logparser
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimestampFormat is the layout event timestamps are rendered in
+// unless SetTimestampFormat overrides it.
+const DefaultTimestampFormat = time.RFC3339
+
+var (
+	timestampMu     sync.RWMutex
+	timestampLoc    = time.Local
+	timestampLayout = DefaultTimestampFormat
+)
+
+// SetTimestampFormat sets the time zone and layout event timestamps are
+// rendered in. A nil loc means local time and an empty layout means
+// DefaultTimestampFormat.
+func SetTimestampFormat(loc *time.Location, layout string) {
+	if loc == nil {
+		loc = time.Local
+	}
+	if layout == "" {
+		layout = DefaultTimestampFormat
+	}
+	timestampMu.Lock()
+	defer timestampMu.Unlock()
+	timestampLoc, timestampLayout = loc, layout
+}
+
+// TimestampLocation returns the time zone event timestamps are rendered in.
+func TimestampLocation() *time.Location {
+	timestampMu.RLock()
+	defer timestampMu.RUnlock()
+	return timestampLoc
+}
+
+// FormatTimestamp renders t in the configured time zone and layout.
+func FormatTimestamp(t time.Time) string {
+	timestampMu.RLock()
+	defer timestampMu.RUnlock()
+	return t.In(timestampLoc).Format(timestampLayout)
+}
+
+// EventTime returns when a JSONL log line was written, from its
+// timestamp_ms field. It returns the zero time for lines that are not
+// events or carry no timestamp.
+func EventTime(line string) time.Time {
+	// Cheap check first: most callers filter many lines
+	if !strings.Contains(line, `"timestamp_ms"`) {
+		return time.Time{}
+	}
+	if event := ParseEvent(line); event != nil && event.TimestampMs > 0 {
+		return time.UnixMilli(event.TimestampMs)
+	}
+	return time.Time{}
+}
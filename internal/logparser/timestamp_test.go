@@ -0,0 +1,46 @@
+package logparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventTime(t *testing.T) {
+	if got := EventTime(`{"type":"assistant","timestamp_ms":1700000000123}`); !got.Equal(time.UnixMilli(1700000000123)) {
+		t.Errorf("EventTime = %v", got)
+	}
+	for _, line := range []string{
+		`{"type":"assistant"}`,
+		`{"type":"assistant","timestamp_ms":0}`,
+		`not json "timestamp_ms"`,
+		"",
+	} {
+		if got := EventTime(line); !got.IsZero() {
+			t.Errorf("EventTime(%q) = %v, want zero", line, got)
+		}
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	defer SetTimestampFormat(nil, "")
+
+	ts := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	SetTimestampFormat(tokyo, "2006-01-02 15:04")
+	if got := FormatTimestamp(ts); got != "2026-03-01 21:30" {
+		t.Errorf("FormatTimestamp = %q, want 2026-03-01 21:30", got)
+	}
+	if TimestampLocation() != tokyo {
+		t.Errorf("TimestampLocation = %v", TimestampLocation())
+	}
+
+	events := Normalize(`{"type":"system","subtype":"init","timestamp_ms":1772368200000}`)
+	if len(events) != 1 || events[0].Timestamp != "2026-03-01T21:30:00+09:00" {
+		t.Errorf("normalized = %+v, want an RFC 3339 timestamp in the configured zone", events)
+	}
+
+	SetTimestampFormat(time.UTC, "")
+	if got := FormatTimestamp(ts); got != "2026-03-01T12:30:00Z" {
+		t.Errorf("default format = %q", got)
+	}
+}
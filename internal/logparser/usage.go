@@ -0,0 +1,105 @@
+package logparser
+
+import "encoding/json"
+
+// A usageDialect finds token usage in the events of one family of agent CLI
+// log formats.
+type usageDialect struct {
+	name string
+
+	// usage returns the event's usage, or nil if the event doesn't report
+	// usage in this dialect's shape
+	usage func(event *LogEvent) *Usage
+}
+
+// usageDialects are the known places usage is reported, tried in order; the
+// first dialect that finds usage in an event wins.
+var usageDialects = []usageDialect{
+	// Claude result events, Codex turn.completed
+	{name: "top-level", usage: func(event *LogEvent) *Usage {
+		return event.Usage
+	}},
+	// Claude assistant messages
+	{name: "message", usage: func(event *LogEvent) *Usage {
+		if event.Message == nil {
+			return nil
+		}
+		return event.Message.Usage
+	}},
+	// OpenAI-compatible streams: {"type": "response.completed", "response": {"usage": {...}}}
+	{name: "response", usage: func(event *LogEvent) *Usage {
+		var response struct {
+			Usage *Usage `json:"usage"`
+		}
+		if !decodeRaw(event.Response, &response) {
+			return nil
+		}
+		return response.Usage
+	}},
+	// A final {"type": "summary", "summary": {"usage": {...}}} event, or one
+	// with the token counts directly in "summary"
+	{name: "summary", usage: func(event *LogEvent) *Usage {
+		if event.Type != "summary" {
+			return nil
+		}
+		var summary struct {
+			Usage *Usage `json:"usage"`
+		}
+		if !decodeRaw(event.Summary, &summary) {
+			return nil
+		}
+		if summary.Usage != nil {
+			return summary.Usage
+		}
+		var usage Usage
+		if !decodeRaw(event.Summary, &usage) {
+			return nil
+		}
+		return &usage
+	}},
+}
+
+// FindUsage returns the token usage an event reports, in any known dialect,
+// or nil if it reports none.
+func FindUsage(event *LogEvent) *Usage {
+	for _, dialect := range usageDialects {
+		if usage := dialect.usage(event); usage != nil {
+			return usage
+		}
+	}
+	return nil
+}
+
+// EventModel returns the model an event reports the agent CLI is using, from
+// a system init event or an assistant message, or "" if it reports none.
+func EventModel(event *LogEvent) string {
+	if event.Model != "" {
+		return event.Model
+	}
+	if event.Message != nil {
+		return event.Message.Model
+	}
+	return ""
+}
+
+// Tokens returns the input tokens (including cache reads and writes) and
+// output tokens of u, whichever field names its dialect uses.
+func (u *Usage) Tokens() (input, output int64) {
+	input = u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens + u.CachedInputTokens
+	if input == 0 {
+		input = u.PromptTokens
+	}
+	output = u.OutputTokens
+	if output == 0 {
+		output = u.CompletionTokens
+	}
+	return input, output
+}
+
+// decodeRaw decodes a raw JSON object into v, reporting whether it was one.
+func decodeRaw(raw json.RawMessage, v interface{}) bool {
+	if len(raw) == 0 || raw[0] != '{' {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
@@ -32,6 +32,9 @@ type Summary struct {
 	Events []LogEvent `json:"events,omitempty"`
 
 	LastAction string `json:"last_action,omitempty"`
+
+	// Result is the text of the agent's last result event, its final report
+	Result string `json:"result,omitempty"`
 }
 
 // LogError represents an error found in logs.
@@ -210,6 +213,10 @@ func Parse(agent *state.AgentState) (*Summary, error) {
 			}
 		}
 
+		if entry.Type == "result" && entry.Result != "" {
+			summary.Result = entry.Result
+		}
+
 		// Track errors from result entries
 		if entry.Type == "result" && entry.Subtype == "error" {
 			errMsg := entry.Result
@@ -157,6 +157,10 @@ func TestParseErrors(t *testing.T) {
 	if len(summary.Errors) > 0 && summary.Errors[0].Iteration != 1 {
 		t.Errorf("Expected first error on iteration 1, got %d", summary.Errors[0].Iteration)
 	}
+
+	if summary.Result != "Connection failed" {
+		t.Errorf("Expected the last result %q, got %q", "Connection failed", summary.Result)
+	}
 }
 
 func TestParseGitCommitEvents(t *testing.T) {
@@ -0,0 +1,215 @@
+// Package metrics exposes agent state as Prometheus metrics. A Collector
+// reads the state manager periodically and serves the latest snapshot in the
+// Prometheus text exposition format, so scrapes never wait on the state lock.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// Snapshot is the metrics of the agents in scope at one moment.
+type Snapshot struct {
+	Time time.Time
+
+	Running    int
+	Paused     int
+	Terminated int
+
+	IterationsSucceeded int
+	IterationsFailed    int
+	InputTokens         int64
+	OutputTokens        int64
+	CostUSD             float64
+
+	// FailuresByClass counts agents by the class of their last failure
+	FailuresByClass map[string]int
+
+	// Active are the running and paused agents, sorted by ID
+	Active []*state.AgentState
+}
+
+// Collect builds a snapshot of agents at now.
+func Collect(agents []*state.AgentState, now time.Time) *Snapshot {
+	s := &Snapshot{Time: now, FailuresByClass: make(map[string]int)}
+	for _, a := range agents {
+		switch {
+		case a.Status == "terminated":
+			s.Terminated++
+		case a.Paused:
+			s.Paused++
+			s.Active = append(s.Active, a)
+		default:
+			s.Running++
+			s.Active = append(s.Active, a)
+		}
+		s.IterationsSucceeded += a.SuccessfulIters
+		s.IterationsFailed += a.FailedIters
+		s.InputTokens += a.InputTokens
+		s.OutputTokens += a.OutputTokens
+		s.CostUSD += a.TotalCost
+		if a.LastErrorClass != "" {
+			s.FailuresByClass[a.LastErrorClass]++
+		}
+	}
+	sort.Slice(s.Active, func(i, j int) bool { return s.Active[i].ID < s.Active[j].ID })
+	return s
+}
+
+// WriteText writes the snapshot in the Prometheus text exposition format.
+func (s *Snapshot) WriteText(w io.Writer) error {
+	mw := &metricWriter{w: w}
+
+	mw.family("swarm_agents", "gauge", "Agents by status.")
+	mw.sample("swarm_agents", labels("status", "running"), float64(s.Running))
+	mw.sample("swarm_agents", labels("status", "paused"), float64(s.Paused))
+	mw.sample("swarm_agents", labels("status", "terminated"), float64(s.Terminated))
+
+	mw.family("swarm_iterations_total", "counter", "Iterations completed by agents in state, by result.")
+	mw.sample("swarm_iterations_total", labels("result", "succeeded"), float64(s.IterationsSucceeded))
+	mw.sample("swarm_iterations_total", labels("result", "failed"), float64(s.IterationsFailed))
+
+	mw.family("swarm_tokens_total", "counter", "Tokens used by agents in state.")
+	mw.sample("swarm_tokens_total", labels("direction", "input"), float64(s.InputTokens))
+	mw.sample("swarm_tokens_total", labels("direction", "output"), float64(s.OutputTokens))
+
+	mw.family("swarm_cost_usd_total", "counter", "Cost of agents in state, in US dollars.")
+	mw.sample("swarm_cost_usd_total", "", s.CostUSD)
+
+	mw.family("swarm_agent_failures", "gauge", "Agents by the failure class of their last failed iteration.")
+	classes := make([]string, 0, len(s.FailuresByClass))
+	for class := range s.FailuresByClass {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		mw.sample("swarm_agent_failures", labels("class", class), float64(s.FailuresByClass[class]))
+	}
+
+	mw.family("swarm_agent_iteration", "gauge", "Current iteration of each running or paused agent.")
+	for _, a := range s.Active {
+		mw.sample("swarm_agent_iteration", agentLabels(a), float64(a.CurrentIter))
+	}
+	mw.family("swarm_agent_tokens", "gauge", "Tokens used by each running or paused agent.")
+	for _, a := range s.Active {
+		l := agentLabels(a)
+		mw.sample("swarm_agent_tokens", l+`,direction="input"`, float64(a.InputTokens))
+		mw.sample("swarm_agent_tokens", l+`,direction="output"`, float64(a.OutputTokens))
+	}
+	mw.family("swarm_agent_cost_usd", "gauge", "Cost of each running or paused agent, in US dollars.")
+	for _, a := range s.Active {
+		mw.sample("swarm_agent_cost_usd", agentLabels(a), a.TotalCost)
+	}
+
+	mw.family("swarm_metrics_collected_timestamp_seconds", "gauge", "When agent state was last read.")
+	mw.sample("swarm_metrics_collected_timestamp_seconds", "", float64(s.Time.Unix()))
+	return mw.err
+}
+
+// labelEscaper escapes label values as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func agentLabels(a *state.AgentState) string {
+	return labels("id", a.ID, "name", a.Name, "model", a.Model)
+}
+
+// labels formats label pairs, e.g. labels("status", "running") is
+// `status="running"`.
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+// metricWriter writes metric families, keeping the first error.
+type metricWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (mw *metricWriter) family(name, kind, help string) {
+	mw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (mw *metricWriter) sample(name, labels string, value float64) {
+	if labels != "" {
+		name += "{" + labels + "}"
+	}
+	mw.printf("%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (mw *metricWriter) printf(format string, args ...interface{}) {
+	if mw.err == nil {
+		_, mw.err = fmt.Fprintf(mw.w, format, args...)
+	}
+}
+
+// Collector periodically snapshots the agents a state manager lists.
+type Collector struct {
+	mgr      *state.Manager
+	interval time.Duration
+
+	mu       sync.Mutex
+	snapshot *Snapshot
+	err      error
+}
+
+// NewCollector returns a collector reading mgr every interval.
+func NewCollector(mgr *state.Manager, interval time.Duration) *Collector {
+	return &Collector{mgr: mgr, interval: interval}
+}
+
+// Run collects until ctx is done, starting immediately.
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.collect()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Collector) collect() {
+	agents, err := c.mgr.List(false)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.err = err
+		return
+	}
+	c.snapshot, c.err = Collect(agents, time.Now()), nil
+}
+
+// ServeHTTP serves the latest snapshot. It fails with 503 until the first
+// collection succeeds or if the last one failed.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	snapshot, err := c.snapshot, c.err
+	c.mu.Unlock()
+
+	if err != nil || snapshot == nil {
+		msg := "no metrics collected yet"
+		if err != nil {
+			msg = fmt.Sprintf("failed to read agent state: %v", err)
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = snapshot.WriteText(w)
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestSnapshotWriteText(t *testing.T) {
+	agents := []*state.AgentState{
+		{ID: "b2", Name: `say "hi"`, Model: "opus", Status: "running", CurrentIter: 3,
+			SuccessfulIters: 2, InputTokens: 1000, OutputTokens: 100, TotalCost: 1.5},
+		{ID: "a1", Name: "paused", Model: "sonnet", Status: "running", Paused: true, CurrentIter: 1},
+		{ID: "c3", Name: "done", Status: "terminated", SuccessfulIters: 4, FailedIters: 1,
+			LastErrorClass: "rate_limited", InputTokens: 500, OutputTokens: 50, TotalCost: 0.25},
+	}
+	snapshot := Collect(agents, time.Unix(1700000000, 0))
+
+	var out bytes.Buffer
+	if err := snapshot.WriteText(&out); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"# TYPE swarm_agents gauge\n",
+		`swarm_agents{status="running"} 1` + "\n",
+		`swarm_agents{status="paused"} 1` + "\n",
+		`swarm_agents{status="terminated"} 1` + "\n",
+		`swarm_iterations_total{result="succeeded"} 6` + "\n",
+		`swarm_iterations_total{result="failed"} 1` + "\n",
+		`swarm_tokens_total{direction="input"} 1500` + "\n",
+		"swarm_cost_usd_total 1.75\n",
+		`swarm_agent_failures{class="rate_limited"} 1` + "\n",
+		`swarm_agent_cost_usd{id="b2",name="say \"hi\"",model="opus"} 1.5` + "\n",
+		`swarm_agent_tokens{id="b2",name="say \"hi\"",model="opus",direction="output"} 100` + "\n",
+		"swarm_metrics_collected_timestamp_seconds 1.7e+09\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, `id="c3"`) {
+		t.Error("terminated agents should not get per-agent metrics")
+	}
+	if strings.Index(text, `id="a1"`) > strings.Index(text, `id="b2"`) {
+		t.Error("per-agent metrics should be sorted by ID")
+	}
+}
+
+func TestCollectorServeHTTP(t *testing.T) {
+	c := &Collector{}
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status before collecting = %d, want 503", rec.Code)
+	}
+
+	c.snapshot = Collect(nil, time.Now())
+	rec = httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "swarm_agents") {
+		t.Errorf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/scope"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// CheckCostAlert checks whether the cumulative cost of the agents in the
+// project containing workingDir has crossed cfg.AlertCostUSD and, the first
+// time it has, writes the alert to out and sends it to the configured
+// notifiers. agent is the agent whose usage was just recorded (nil if it has
+// no state); an agent a compose project started in another repo counts toward
+// that project. It is a no-op when no alert is configured. Failures are
+// reported to out, never returned, since an alert must not stop an agent.
+func CheckCostAlert(cfg *config.Config, workingDir string, agent *state.AgentState, out io.Writer) {
+	if cfg == nil || cfg.AlertCostUSD <= 0 {
+		return
+	}
+	if agent != nil && agent.ProjectDir != "" {
+		workingDir = agent.ProjectDir
+	}
+	mgr, err := state.NewManagerWithScope(scope.ScopeProject, workingDir)
+	if err != nil {
+		fmt.Fprintf(out, "\n[swarm] Warning: failed to check cost alert: %v\n", err)
+		return
+	}
+	record, crossed, err := mgr.CheckCostAlert(cfg.AlertCostUSD)
+	if err != nil {
+		fmt.Fprintf(out, "\n[swarm] Warning: failed to check cost alert: %v\n", err)
+		return
+	}
+	if !crossed {
+		return
+	}
+
+	ev := CostAlertEvent(record).ForAgent(agent)
+	fmt.Fprintf(out, "\n[swarm] COST ALERT: %s\n", ev.Message)
+	Send(cfg, ev, out)
+}
+
+// CostAlertEvent returns the event for a fired cost alert.
+func CostAlertEvent(record *state.CostAlertRecord) Event {
+	return Event{
+		Type:         EventCostAlert,
+		Title:        "swarm: cost alert",
+		Message:      fmt.Sprintf("agents in %s have cost $%.2f, over the $%.2f alert threshold", record.Project, record.CostUSD, record.Threshold),
+		Time:         record.FiredAt,
+		Project:      record.Project,
+		CostUSD:      record.CostUSD,
+		ThresholdUSD: record.Threshold,
+	}
+}
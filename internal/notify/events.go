@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// SlowIterationEvent returns the event for an iteration that has run far
+// longer than the agent's median iteration; message describes it (see
+// agent.SlowIterationMessage).
+func SlowIterationEvent(iteration int, message string) Event {
+	return Event{
+		Type:      EventSlowIteration,
+		Title:     "swarm: slow iteration",
+		Message:   message,
+		Time:      time.Now(),
+		Iteration: iteration,
+	}
+}
+
+// UnhealthyEvent returns the event for a health check that started failing.
+func UnhealthyEvent(command string, err error) Event {
+	return Event{
+		Type:    EventUnhealthy,
+		Title:   "swarm: agent unhealthy",
+		Message: fmt.Sprintf("health check `%s` failed: %v", command, err),
+		Time:    time.Now(),
+	}
+}
+
+// DigestEvent returns the event for a digest of the agents active in a
+// period (see 'swarm summary'); message is the formatted digest.
+func DigestEvent(project, message string) Event {
+	return Event{
+		Type:    EventDigest,
+		Title:   "swarm: digest",
+		Message: message,
+		Time:    time.Now(),
+		Project: project,
+	}
+}
+
+// HookFailedEvent returns the event for a hook that failed on every attempt.
+func HookFailedEvent(run state.HookRun) Event {
+	return Event{
+		Type:    EventHookFailed,
+		Title:   "swarm: hook failed",
+		Message: fmt.Sprintf("on-%s hook `%s` failed after %d attempt(s): %s", run.Event, run.Command, run.Attempts, run.Error),
+		Time:    run.FinishedAt,
+	}
+}
+
+// PausedEvent returns the event for agent id pausing to wait for a resume;
+// resumeAt is when it resumes on its own, if set. The message points at
+// 'swarm inspect' and 'swarm resume' for the agent.
+func PausedEvent(id string, resumeAt *time.Time) Event {
+	message := fmt.Sprintf("waiting for input: see `swarm inspect %s`, then run `swarm resume %s`", id, id)
+	if resumeAt != nil {
+		message = fmt.Sprintf("paused until %s: see `swarm inspect %s`, or run `swarm resume %s` to resume now", resumeAt.Format(time.DateTime), id, id)
+	}
+	return Event{
+		Type:    EventPaused,
+		Title:   "swarm: agent paused",
+		Message: message,
+		Time:    time.Now(),
+		Action:  "swarm resume " + id,
+	}
+}
+
+// ApprovalRequestedEvent returns the event for agent id pausing until it's
+// approved; request says what needs approving. The message points at
+// 'swarm inspect' and 'swarm approve' for the agent.
+func ApprovalRequestedEvent(id, request string) Event {
+	return Event{
+		Type:    EventApprovalRequested,
+		Title:   "swarm: approval requested",
+		Message: fmt.Sprintf("%s: see `swarm inspect %s`, then run `swarm approve %s`", request, id, id),
+		Time:    time.Now(),
+		Action:  "swarm approve " + id,
+	}
+}
+
+// PauseEvent returns the event for agent id entering the pause its state
+// asks for: an approval request if it awaits approval, otherwise a paused
+// event.
+func PauseEvent(id string, agent *state.AgentState) Event {
+	if agent.Approval != "" {
+		return ApprovalRequestedEvent(id, agent.Approval)
+	}
+	return PausedEvent(id, agent.ResumeAt)
+}
@@ -0,0 +1,232 @@
+// Package notify delivers notifications about agents (e.g. cost alerts) to
+// the desktop, to Slack, and to a user-provided command (notify_cmd).
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+// Event types.
+const (
+	// EventCostAlert is sent when a project's cumulative cost crosses
+	// alert_cost_usd.
+	EventCostAlert = "cost_alert"
+
+	// EventSlowIteration is sent when an iteration runs far longer than the
+	// agent's median iteration (slow_iteration_factor).
+	EventSlowIteration = "slow_iteration"
+
+	// EventUnhealthy is sent when an agent's health check starts failing.
+	EventUnhealthy = "unhealthy"
+
+	// EventDigest is sent by 'swarm summary --notify' with a digest of the
+	// swarm's recent activity.
+	EventDigest = "digest"
+
+	// EventHookFailed is sent when one of a terminated agent's hooks fails
+	// on every attempt (hook_retries).
+	EventHookFailed = "hook_failed"
+
+	// EventPaused is sent when an agent pauses and waits for someone to
+	// resume it, e.g. after pausing itself for human input.
+	EventPaused = "paused"
+
+	// EventApprovalRequested is sent when an agent pauses at an approval gate
+	// (`swarm stop --await-approval`) and waits for `swarm approve`.
+	EventApprovalRequested = "approval_requested"
+)
+
+// sendTimeout bounds how long a single backend may take to deliver an event.
+const sendTimeout = 10 * time.Second
+
+// Event is a notification-worthy event.
+type Event struct {
+	Type    string    `json:"type"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+
+	// Project is the directory of the project the event is about
+	Project string `json:"project,omitempty"`
+
+	// AgentID and AgentName identify the agent that raised the event
+	AgentID   string `json:"agent_id,omitempty"`
+	AgentName string `json:"agent_name,omitempty"`
+
+	// CostUSD and ThresholdUSD are set for cost alerts
+	CostUSD      float64 `json:"cost_usd,omitempty"`
+	ThresholdUSD float64 `json:"threshold_usd,omitempty"`
+
+	// Iteration is the agent's iteration, for slow iterations
+	Iteration int `json:"iteration,omitempty"`
+
+	// Action is the command that acts on the event, e.g. `swarm resume <id>`
+	// for a paused agent or `swarm approve <id>` for an approval request
+	Action string `json:"action,omitempty"`
+}
+
+// ForAgent sets the event's agent and project from the agent's state.
+func (ev Event) ForAgent(a *state.AgentState) Event {
+	if a == nil {
+		return ev
+	}
+	ev.AgentID = a.ID
+	ev.AgentName = a.Name
+	if ev.Project == "" {
+		ev.Project = a.WorkingDir
+		if a.ProjectDir != "" {
+			ev.Project = a.ProjectDir
+		}
+	}
+	return ev
+}
+
+// heading is the event's title, naming its agent if it has one.
+func (ev Event) heading() string {
+	if ev.AgentName != "" {
+		return fmt.Sprintf("%s (%s)", ev.Title, ev.AgentName)
+	}
+	return ev.Title
+}
+
+// Notifier sends events to the configured backends.
+type Notifier struct {
+	// Desktop shows events as desktop notifications
+	Desktop bool
+
+	// SlackWebhookURL is a Slack incoming webhook events are posted to
+	SlackWebhookURL string
+
+	// Command is a shell command run with each event as JSON on stdin
+	Command string
+}
+
+// FromConfig returns the notifier configured by notify_desktop,
+// slack_webhook_url, and notify_cmd.
+func FromConfig(cfg *config.Config) Notifier {
+	if cfg == nil {
+		return Notifier{}
+	}
+	return Notifier{Desktop: cfg.NotifyDesktop, SlackWebhookURL: cfg.SlackWebhookURL, Command: cfg.NotifyCmd}
+}
+
+// Enabled reports whether any backend is configured.
+func (n Notifier) Enabled() bool {
+	return n.Desktop || n.SlackWebhookURL != "" || n.Command != ""
+}
+
+// Send delivers ev to the notifiers configured in cfg, writing any failures
+// to out as warnings. A failed notification never stops an agent.
+func Send(cfg *config.Config, ev Event, out io.Writer) {
+	if err := FromConfig(cfg).Send(ev); err != nil {
+		fmt.Fprintf(out, "[swarm] Warning: %v\n", err)
+	}
+}
+
+// Send delivers ev to every configured backend, returning the errors of the
+// backends that failed.
+func (n Notifier) Send(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	var errs []error
+	if n.Desktop {
+		if err := sendDesktop(ctx, ev); err != nil {
+			errs = append(errs, fmt.Errorf("desktop notification: %w", err))
+		}
+	}
+	if n.SlackWebhookURL != "" {
+		if err := sendSlack(ctx, n.SlackWebhookURL, ev); err != nil {
+			errs = append(errs, fmt.Errorf("slack notification: %w", err))
+		}
+	}
+	if n.Command != "" {
+		if err := sendCommand(ctx, n.Command, ev); err != nil {
+			errs = append(errs, fmt.Errorf("notify_cmd: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendDesktop shows ev with notify-send (Linux) or osascript (macOS).
+func sendDesktop(ctx context.Context, ev Event) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", ev.Message, ev.heading())
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=swarm", ev.heading(), ev.Message)
+	default:
+		return fmt.Errorf("not supported on %s", runtime.GOOS)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if len(bytes.TrimSpace(output)) > 0 {
+			return fmt.Errorf("%v: %s", err, bytes.TrimSpace(output))
+		}
+		return err
+	}
+	return nil
+}
+
+// sendSlack posts ev to a Slack incoming webhook.
+func sendSlack(ctx context.Context, url string, ev Event) error {
+	body, err := json.Marshal(map[string]string{"text": fmt.Sprintf("*%s*\n%s", ev.heading(), ev.Message)})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sendCommand runs command with ev as JSON on stdin. SWARM_EVENT_TYPE is set
+// so simple scripts can filter events without parsing JSON.
+func sendCommand(ctx context.Context, command string, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "SWARM_EVENT_TYPE="+ev.Type)
+	// Don't wait on children of the shell still holding its output open
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if tail := lines[len(lines)-1]; tail != "" {
+			return fmt.Errorf("%v: %s", err, tail)
+		}
+		return err
+	}
+	return nil
+}
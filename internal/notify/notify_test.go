@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/state"
+)
+
+func TestFromConfig(t *testing.T) {
+	if FromConfig(nil).Enabled() || FromConfig(config.DefaultConfig()).Enabled() {
+		t.Error("expected no backends by default")
+	}
+	cfg := config.DefaultConfig()
+	cfg.SlackWebhookURL = "https://hooks.example.com/x"
+	if n := FromConfig(cfg); !n.Enabled() || n.Desktop || n.SlackWebhookURL != cfg.SlackWebhookURL {
+		t.Errorf("FromConfig() = %+v", n)
+	}
+}
+
+func TestSendSlack(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	ev := CostAlertEvent(&state.CostAlertRecord{Project: "/repo", Threshold: 25, CostUSD: 27.5, FiredAt: time.Now()})
+	if err := (Notifier{SlackWebhookURL: server.URL}).Send(ev); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if !strings.Contains(got["text"], "cost alert") || !strings.Contains(got["text"], "$27.50") {
+		t.Errorf("text = %q", got["text"])
+	}
+}
+
+func TestSendSlackError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := (Notifier{SlackWebhookURL: server.URL}).Send(Event{Type: EventCostAlert, Title: "t", Message: "m"})
+	if err == nil || !strings.Contains(err.Error(), "slack notification") || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected a slack error, got %v", err)
+	}
+}
+
+func TestSendCommand(t *testing.T) {
+	dir := t.TempDir()
+	payload := filepath.Join(dir, "event.json")
+	n := Notifier{Command: fmt.Sprintf("cat > %s && echo \"$SWARM_EVENT_TYPE\" > %s.type", payload, payload)}
+
+	ev := SlowIterationEvent(4, "iteration 4 is slow").ForAgent(&state.AgentState{ID: "abc123", Name: "coder", WorkingDir: "/repo"})
+	if err := n.Send(ev); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	data, err := os.ReadFile(payload)
+	if err != nil {
+		t.Fatalf("notify_cmd did not receive the event: %v", err)
+	}
+	var got Event
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid event JSON %q: %v", data, err)
+	}
+	if got.Type != EventSlowIteration || got.AgentName != "coder" || got.Project != "/repo" || got.Iteration != 4 {
+		t.Errorf("event = %+v", got)
+	}
+	if typ, _ := os.ReadFile(payload + ".type"); strings.TrimSpace(string(typ)) != EventSlowIteration {
+		t.Errorf("SWARM_EVENT_TYPE = %q", typ)
+	}
+
+	err = Notifier{Command: "echo 'no route to pager' >&2; exit 3"}.Send(ev)
+	if err == nil || !strings.Contains(err.Error(), "notify_cmd") || !strings.Contains(err.Error(), "no route to pager") {
+		t.Errorf("expected the command's error output, got %v", err)
+	}
+}
+
+func TestPausedEvent(t *testing.T) {
+	ev := PausedEvent("abc123", nil)
+	if ev.Type != EventPaused || ev.Action != "swarm resume abc123" {
+		t.Errorf("event = %+v", ev)
+	}
+	if !strings.Contains(ev.Message, "swarm inspect abc123") {
+		t.Errorf("expected a link to inspect the agent, got %q", ev.Message)
+	}
+
+	resumeAt := time.Date(2026, 1, 2, 15, 4, 0, 0, time.Local)
+	if ev := PausedEvent("abc123", &resumeAt); !strings.Contains(ev.Message, "paused until 2026-01-02 15:04:00") {
+		t.Errorf("expected the resume time, got %q", ev.Message)
+	}
+}
+
+func TestPauseEvent(t *testing.T) {
+	ev := PauseEvent("abc123", &state.AgentState{Paused: true, Approval: "merge the migration"})
+	if ev.Type != EventApprovalRequested || ev.Action != "swarm approve abc123" {
+		t.Errorf("event = %+v", ev)
+	}
+	if !strings.Contains(ev.Message, "merge the migration") {
+		t.Errorf("expected the approval request, got %q", ev.Message)
+	}
+
+	if ev := PauseEvent("abc123", &state.AgentState{Paused: true}); ev.Type != EventPaused {
+		t.Errorf("expected a paused event without an approval request, got %+v", ev)
+	}
+}
@@ -0,0 +1,278 @@
+// Package pathguard restricts which files an agent may edit to sets of glob
+// patterns, e.g. allowed_paths: ["docs/**"] for a docs-only agent.
+package pathguard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrViolation is wrapped by the error of an agent that edited a file
+// outside its allowed paths.
+var ErrViolation = errors.New("edited a file outside its allowed paths")
+
+// Guard checks the files an agent edits against glob patterns relative to
+// the agent's working directory. A path is allowed when it matches no Denied
+// pattern and, if there are Allowed patterns, matches one of them. Files
+// outside the working directory (e.g. the scratch dir) aren't checked.
+type Guard struct {
+	Allowed []string
+	Denied  []string
+
+	// ReadOnly allows no edits at all in the working directory
+	ReadOnly bool
+
+	// Dir is the agent's working directory (empty = this process's)
+	Dir string
+
+	// SharedTree marks a working directory other agents edit at the same
+	// time, e.g. parallel pipeline tasks. Their changes can't be told apart
+	// from the agent's in git, so only the edits seen in its tool calls are
+	// checked.
+	SharedTree bool
+}
+
+// NewReadOnly returns a guard that allows no edits in dir.
+func NewReadOnly(dir string) *Guard {
+	return &Guard{ReadOnly: true, Dir: dir}
+}
+
+// New returns a guard for the patterns, or nil if there are none.
+func New(allowed, denied []string, dir string) (*Guard, error) {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil, nil
+	}
+	for _, p := range append(append([]string(nil), allowed...), denied...) {
+		if err := ValidatePattern(p); err != nil {
+			return nil, err
+		}
+	}
+	return &Guard{Allowed: allowed, Denied: denied, Dir: dir}, nil
+}
+
+// ValidatePattern returns an error if p isn't a valid path pattern: a
+// relative, slash-separated glob where "**" matches any number of
+// directories and a trailing "/" matches everything under a directory.
+func ValidatePattern(p string) error {
+	if p == "" {
+		return fmt.Errorf("empty path pattern")
+	}
+	if path.IsAbs(p) || filepath.IsAbs(p) {
+		return fmt.Errorf("path pattern %q must be relative to the working directory", p)
+	}
+	for _, seg := range strings.Split(strings.TrimSuffix(p, "/"), "/") {
+		if seg == ".." {
+			return fmt.Errorf("path pattern %q must not contain ..", p)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Check returns an error wrapping ErrViolation if the guard doesn't allow
+// editing name, which is absolute or relative to the working directory. A nil
+// guard allows everything.
+func (g *Guard) Check(name string) error {
+	if g == nil {
+		return nil
+	}
+	rel, ok := g.relative(name)
+	if !ok || g.allows(rel) {
+		return nil
+	}
+	return g.violation(rel)
+}
+
+// violation returns the error for edits to names.
+func (g *Guard) violation(names ...string) error {
+	if g.ReadOnly {
+		return fmt.Errorf("%w (read-only): %s", ErrViolation, strings.Join(names, ", "))
+	}
+	return fmt.Errorf("%w: %s", ErrViolation, strings.Join(names, ", "))
+}
+
+// allows reports whether the guard allows editing rel, a slash-separated
+// path relative to the working directory.
+func (g *Guard) allows(rel string) bool {
+	if g.ReadOnly {
+		return false
+	}
+	for _, p := range g.Denied {
+		if Match(p, rel) {
+			return false
+		}
+	}
+	if len(g.Allowed) == 0 {
+		return true
+	}
+	for _, p := range g.Allowed {
+		if Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// relative returns name relative to the working directory, slash-separated,
+// or false if it's outside it.
+func (g *Guard) relative(name string) (string, bool) {
+	dir := g.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	rel, err := filepath.Rel(dir, name)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Match reports whether the slash-separated path name matches pattern (see
+// ValidatePattern). Invalid patterns match nothing.
+func Match(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Snapshot is the state of a git working tree before an agent runs, so that
+// only the files the agent changes are checked afterwards.
+type Snapshot struct {
+	head string
+
+	// changed holds the files that already differed from head, with their
+	// size and modification time so later edits to them are noticed too
+	changed map[string]fileStamp
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime int64
+}
+
+// Snapshot records the working tree's HEAD and the files that already differ
+// from it. It returns nil if the working directory isn't in a git repository,
+// or is a SharedTree, so CheckWorktree checks nothing.
+func (g *Guard) Snapshot() *Snapshot {
+	if g == nil || g.SharedTree {
+		return nil
+	}
+	head, err := g.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil
+	}
+	s := &Snapshot{head: strings.TrimSpace(string(head))}
+	changed, err := g.changedFiles(s.head)
+	if err != nil {
+		return nil
+	}
+	s.changed = make(map[string]fileStamp, len(changed))
+	for name := range changed {
+		s.changed[name] = g.stamp(name)
+	}
+	return s
+}
+
+// stamp returns the current version of name, relative to the working
+// directory.
+func (g *Guard) stamp(name string) fileStamp {
+	info, err := os.Lstat(filepath.Join(g.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime().UnixNano()}
+}
+
+// CheckWorktree returns an error wrapping ErrViolation naming the files
+// changed since s, committed or not, that the guard doesn't allow. Tool calls
+// are checked as the agent makes them; this also catches files changed by
+// shell commands.
+func (g *Guard) CheckWorktree(s *Snapshot) error {
+	if g == nil || s == nil {
+		return nil
+	}
+	changed, err := g.changedFiles(s.head)
+	if err != nil {
+		return nil
+	}
+	var violations []string
+	for name := range changed {
+		if before, ok := s.changed[name]; ok && before == g.stamp(name) {
+			continue
+		}
+		if !g.allows(name) {
+			violations = append(violations, name)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return g.violation(violations...)
+}
+
+// changedFiles returns the files under the working directory that differ
+// from rev, including untracked files, relative to the working directory.
+func (g *Guard) changedFiles(rev string) (map[string]bool, error) {
+	diff, err := g.git("diff", "--name-only", "--relative", "-z", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := g.git("ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for _, name := range bytes.Split(append(diff, untracked...), []byte{0}) {
+		if len(name) > 0 {
+			changed[string(name)] = true
+		}
+	}
+	return changed, nil
+}
+
+func (g *Guard) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Dir
+	return cmd.Output()
+}
@@ -0,0 +1,214 @@
+package pathguard
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"docs/**", "docs/index.md", true},
+		{"docs/**", "docs/guide/setup.md", true},
+		{"docs/**", "src/main.go", false},
+		{"docs/", "docs/guide/setup.md", true},
+		{"docs", "docs/index.md", false},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/index.md", false},
+		{"**/*.md", "docs/guide/setup.md", true},
+		{"**/*.md", "README.md", true},
+		{"src/**/*_test.go", "src/a/b/x_test.go", true},
+		{"src/**/*_test.go", "src/x_test.go", true},
+		{"src/**/*_test.go", "src/a/x.go", false},
+		{"go.mod", "go.mod", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, p := range []string{"docs/**", "*.md", "docs/"} {
+		if err := ValidatePattern(p); err != nil {
+			t.Errorf("ValidatePattern(%q) error = %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "/etc/**", "../other/**", "docs/[a"} {
+		if err := ValidatePattern(p); err == nil {
+			t.Errorf("ValidatePattern(%q) expected error", p)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	g, err := New(nil, nil, "")
+	if err != nil || g != nil {
+		t.Errorf("New() without patterns = %v, %v, want nil, nil", g, err)
+	}
+	if _, err := New([]string{"/abs"}, nil, ""); err == nil {
+		t.Error("New() with an absolute pattern expected error")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New([]string{"docs/**"}, []string{"docs/secrets/**"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := []string{
+		"docs/index.md",
+		filepath.Join(dir, "docs", "guide.md"),
+		"/tmp/elsewhere/notes.md", // outside the working directory
+	}
+	for _, name := range allowed {
+		if err := g.Check(name); err != nil {
+			t.Errorf("Check(%q) error = %v", name, err)
+		}
+	}
+
+	denied := []string{
+		"src/main.go",
+		filepath.Join(dir, "go.mod"),
+		"docs/secrets/key.md",
+	}
+	for _, name := range denied {
+		if err := g.Check(name); !errors.Is(err, ErrViolation) {
+			t.Errorf("Check(%q) = %v, want ErrViolation", name, err)
+		}
+	}
+
+	var nilGuard *Guard
+	if err := nilGuard.Check("anything"); err != nil {
+		t.Errorf("nil guard Check() error = %v", err)
+	}
+}
+
+// testRepo creates a git repository with main.go and docs/index.md
+// committed, returning its dir and helpers to run git and write files in it.
+func testRepo(t *testing.T) (dir string, git func(...string), write func(name, content string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir = t.TempDir()
+	git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write = func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	write("main.go", "package main\n")
+	write("docs/index.md", "# Docs\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	return dir, git, write
+}
+
+func TestCheckWorktree(t *testing.T) {
+	dir, git, write := testRepo(t)
+
+	// Already changed before the agent ran
+	write("notes.txt", "mine\n")
+
+	g, err := New([]string{"docs/**"}, nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := g.Snapshot()
+	if snap == nil {
+		t.Fatal("Snapshot() = nil in a git repository")
+	}
+
+	write("docs/index.md", "# Docs\nMore\n")
+	write("docs/new.md", "new\n")
+	if err := g.CheckWorktree(snap); err != nil {
+		t.Errorf("CheckWorktree() after allowed edits error = %v", err)
+	}
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	git("commit", "-q", "-am", "agent commit")
+	err = g.CheckWorktree(snap)
+	if !errors.Is(err, ErrViolation) {
+		t.Fatalf("CheckWorktree() = %v, want ErrViolation", err)
+	}
+	if want := ErrViolation.Error() + ": main.go"; err.Error() != want {
+		t.Errorf("CheckWorktree() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestCheckWorktreeSharedTree(t *testing.T) {
+	dir, _, write := testRepo(t)
+
+	g, err := New([]string{"docs/**"}, nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SharedTree = true
+	snap := g.Snapshot()
+
+	// Another agent's edit in the same tree isn't this agent's violation
+	write("main.go", "package main\n\nfunc main() {}\n")
+	if err := g.CheckWorktree(snap); err != nil {
+		t.Errorf("CheckWorktree() in a shared tree error = %v", err)
+	}
+	if err := g.Check("main.go"); !errors.Is(err, ErrViolation) {
+		t.Errorf("Check(main.go) in a shared tree = %v, want ErrViolation", err)
+	}
+}
+
+func TestCheckWorktreeReadOnly(t *testing.T) {
+	dir, _, write := testRepo(t)
+	write("notes.txt", "mine\n")
+
+	g := NewReadOnly(dir)
+	snap := g.Snapshot()
+	if err := g.CheckWorktree(snap); err != nil {
+		t.Errorf("CheckWorktree() without changes error = %v", err)
+	}
+
+	// Editing a file that was already changed is noticed too
+	write("notes.txt", "mine, and the agent's\n")
+	err := g.CheckWorktree(snap)
+	if !errors.Is(err, ErrViolation) || !strings.Contains(err.Error(), "read-only") || !strings.Contains(err.Error(), "notes.txt") {
+		t.Errorf("CheckWorktree() = %v, want a read-only violation for notes.txt", err)
+	}
+
+	if err := g.Check("docs/index.md"); !errors.Is(err, ErrViolation) {
+		t.Errorf("Check() = %v, want ErrViolation", err)
+	}
+}
+
+func TestSnapshotOutsideRepo(t *testing.T) {
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	g := &Guard{Allowed: []string{"docs/**"}, Dir: t.TempDir()}
+	if snap := g.Snapshot(); snap != nil {
+		t.Errorf("Snapshot() outside a repository = %+v, want nil", snap)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build linux
+
+package process
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForceKillDescendants(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 60 & echo $!; wait")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ForceKill(cmd.Process.Pid); err != nil {
+		t.Fatalf("ForceKill failed: %v", err)
+	}
+	_ = cmd.Wait()
+
+	// The orphaned child may linger as a zombie until it's reaped, but it
+	// mustn't be left running or stopped
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state := procState(child)
+		if state == "" || state == "Z" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child %d still alive (state %s)", child, state)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// procState returns pid's state letter from /proc, or "" if it's gone.
+func procState(pid int) string {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -19,7 +19,12 @@ func Kill(pid int) error {
 // and all descendant processes. This ensures child processes (like agent CLIs)
 // are killed even if they created their own process groups.
 func ForceKill(pid int) error {
-	// First, recursively find and kill all descendant processes.
+	// Stop the process first so it can't fork a child we'd miss between
+	// listing its children and killing it (the child would be orphaned,
+	// keeping the agent's output pipes open).
+	_ = syscall.Kill(pid, syscall.SIGSTOP)
+
+	// Then recursively find and kill all descendant processes.
 	// This handles cases where child processes (e.g., claude/cursor CLIs)
 	// have created their own process groups and wouldn't be killed by
 	// a process group signal alone.
@@ -30,7 +35,7 @@ func ForceKill(pid int) error {
 	_ = syscall.Kill(-pid, syscall.SIGKILL)
 
 	// Kill the process itself
-	return syscall.Kill(pid, syscall.SIGKILL)
+	return killStopped(pid)
 }
 
 // killDescendants recursively finds and kills all descendant processes of the
@@ -39,11 +44,22 @@ func ForceKill(pid int) error {
 func killDescendants(pid int) {
 	children := findChildPIDs(pid)
 	for _, child := range children {
+		_ = syscall.Kill(child, syscall.SIGSTOP)
 		killDescendants(child)
 		// Kill the child's process group first (in case it's a group leader)
 		_ = syscall.Kill(-child, syscall.SIGKILL)
-		_ = syscall.Kill(child, syscall.SIGKILL)
+		_ = killStopped(child)
+	}
+}
+
+// killStopped sends SIGKILL to a process ForceKill stopped. If that fails the
+// process is continued, so it isn't left stopped.
+func killStopped(pid int) error {
+	err := syscall.Kill(pid, syscall.SIGKILL)
+	if err != nil {
+		_ = syscall.Kill(pid, syscall.SIGCONT)
 	}
+	return err
 }
 
 // findChildPIDs returns the PIDs of all direct child processes of the given PID.
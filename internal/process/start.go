@@ -0,0 +1,16 @@
+package process
+
+// SameProcess reports whether pid still belongs to the process that had the
+// given start (from StartTime) when it was recorded, rather than to an
+// unrelated process the PID was reused for. An unknown start, either not
+// recorded or not readable now, isn't held against the process.
+func SameProcess(pid int, start string) bool {
+	if start == "" {
+		return true
+	}
+	current, err := StartTime(pid)
+	if err != nil {
+		return true
+	}
+	return current == start
+}
@@ -0,0 +1,37 @@
+//go:build linux
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StartTime returns an opaque token identifying when a process started, which
+// together with its PID identifies the process across PID reuse. On Linux it
+// is the start time in clock ticks since boot from /proc/<pid>/stat.
+func StartTime(pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return "", err
+	}
+	return parseStatStartTime(string(data))
+}
+
+// parseStatStartTime returns the starttime field (22) of a /proc/<pid>/stat
+// line, reading fields after the command name like parseProcStat.
+func parseStatStartTime(line string) (string, error) {
+	end := strings.LastIndex(line, ")")
+	if end < 0 {
+		return "", fmt.Errorf("malformed stat line")
+	}
+	// Fields after the command name start at field 3 (state)
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 20 {
+		return "", fmt.Errorf("malformed stat line: %d fields", len(fields))
+	}
+	return fields[19], nil
+}
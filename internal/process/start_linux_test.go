@@ -0,0 +1,40 @@
+//go:build linux
+
+package process
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseStatStartTime(t *testing.T) {
+	line := "1234 (my (weird) cmd) S 42 1234 1234 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 1 0 100 1000000 300 18446744073709551615"
+	start, err := parseStatStartTime(line)
+	if err != nil {
+		t.Fatalf("parseStatStartTime failed: %v", err)
+	}
+	if start != "100" {
+		t.Errorf("start = %q, want 100", start)
+	}
+
+	if _, err := parseStatStartTime("1 (cmd) S 0"); err == nil {
+		t.Error("expected error for truncated line")
+	}
+}
+
+func TestSameProcess(t *testing.T) {
+	pid := os.Getpid()
+	start, err := StartTime(pid)
+	if err != nil {
+		t.Fatalf("StartTime failed: %v", err)
+	}
+	if !SameProcess(pid, start) {
+		t.Error("expected the current process to match its own start")
+	}
+	if SameProcess(pid, start+"0") {
+		t.Error("expected a different start to be detected as PID reuse")
+	}
+	if !SameProcess(pid, "") {
+		t.Error("an unrecorded start shouldn't count against the process")
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !windows
+
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// StartTime returns an opaque token identifying when a process started, which
+// together with its PID identifies the process across PID reuse. macOS and
+// BSDs have no /proc, so it is the start time ps reports.
+func StartTime(pid int) (string, error) {
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run ps: %w", err)
+	}
+	start := strings.TrimSpace(string(out))
+	if start == "" {
+		return "", fmt.Errorf("process %d not found", pid)
+	}
+	return start, nil
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package process
+
+import "fmt"
+
+// StartTime is not supported on Windows; agents there are identified by PID
+// alone.
+func StartTime(pid int) (string, error) {
+	return "", fmt.Errorf("process start times are not supported on windows")
+}
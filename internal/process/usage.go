@@ -0,0 +1,118 @@
+package process
+
+import (
+	"sync"
+	"time"
+)
+
+// Usage is a resource usage sample for a process and all of its descendants.
+type Usage struct {
+	// CPUPercent is the CPU utilization since the previous sample, where 100
+	// means one fully busy core. Negative when no previous sample exists yet.
+	CPUPercent float64
+
+	// RSSBytes is the total resident set size of the process tree.
+	RSSBytes int64
+}
+
+// procInfo is a single process entry from a process table snapshot.
+type procInfo struct {
+	pid     int
+	ppid    int
+	cpuTime time.Duration // cumulative user+system CPU time
+	rss     int64         // resident set size in bytes
+}
+
+// cpuSample records the cumulative CPU time of a process tree at a point in time.
+type cpuSample struct {
+	cpuTime time.Duration
+	at      time.Time
+}
+
+// Sampler computes CPU% and RSS for process trees. CPU% is derived from the
+// change in cumulative CPU time between consecutive calls, so a Sampler should
+// be kept across refreshes. It is safe for concurrent use.
+type Sampler struct {
+	mu   sync.Mutex
+	prev map[int]cpuSample
+}
+
+// NewSampler creates a new process tree sampler.
+func NewSampler() *Sampler {
+	return &Sampler{prev: make(map[int]cpuSample)}
+}
+
+// Sample returns usage for each of the given root PIDs, including all of their
+// descendant processes. PIDs that are not running are omitted from the result.
+func (s *Sampler) Sample(pids []int) (map[int]Usage, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[int]Usage, len(pids))
+	seen := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		cpuTime, rss, ok := treeTotals(procs, pid)
+		if !ok {
+			continue
+		}
+		seen[pid] = true
+
+		usage := Usage{CPUPercent: -1, RSSBytes: rss}
+		if prev, ok := s.prev[pid]; ok {
+			elapsed := now.Sub(prev.at)
+			if elapsed > 0 && cpuTime >= prev.cpuTime {
+				usage.CPUPercent = float64(cpuTime-prev.cpuTime) / float64(elapsed) * 100
+			}
+		}
+		s.prev[pid] = cpuSample{cpuTime: cpuTime, at: now}
+		result[pid] = usage
+	}
+
+	// Forget processes that are no longer being sampled
+	for pid := range s.prev {
+		if !seen[pid] {
+			delete(s.prev, pid)
+		}
+	}
+
+	return result, nil
+}
+
+// treeTotals sums CPU time and RSS for root and all of its descendants.
+// Returns ok=false if root is not present in the process table.
+func treeTotals(procs []procInfo, root int) (time.Duration, int64, bool) {
+	children := make(map[int][]int)
+	byPID := make(map[int]procInfo, len(procs))
+	for _, p := range procs {
+		byPID[p.pid] = p
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+
+	if _, ok := byPID[root]; !ok {
+		return 0, 0, false
+	}
+
+	var cpuTime time.Duration
+	var rss int64
+	visited := make(map[int]bool)
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if visited[pid] {
+			continue
+		}
+		visited[pid] = true
+		p := byPID[pid]
+		cpuTime += p.cpuTime
+		rss += p.rss
+		queue = append(queue, children[pid]...)
+	}
+	return cpuTime, rss, true
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicksPerSecond is the USER_HZ value used for /proc CPU times.
+// It is 100 on all mainstream Linux architectures.
+const clockTicksPerSecond = 100
+
+// listProcesses reads the process table from /proc.
+func listProcesses() ([]procInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+
+	pageSize := int64(os.Getpagesize())
+	var procs []procInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue // process exited while scanning
+		}
+		p, err := parseProcStat(pid, string(data), pageSize)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// parseProcStat parses a /proc/<pid>/stat line. The command name is wrapped
+// in parentheses and may itself contain spaces or parentheses, so fields are
+// read after the last ')'.
+func parseProcStat(pid int, line string, pageSize int64) (procInfo, error) {
+	end := strings.LastIndex(line, ")")
+	if end < 0 {
+		return procInfo{}, fmt.Errorf("malformed stat line")
+	}
+	// Fields after the command: state(0) ppid(1) ... utime(11) stime(12) ... rss(21)
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 22 {
+		return procInfo{}, fmt.Errorf("short stat line")
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return procInfo{}, err
+	}
+	utime, err := strconv.ParseInt(fields[11], 10, 64)
+	if err != nil {
+		return procInfo{}, err
+	}
+	stime, err := strconv.ParseInt(fields[12], 10, 64)
+	if err != nil {
+		return procInfo{}, err
+	}
+	rssPages, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return procInfo{}, err
+	}
+
+	return procInfo{
+		pid:     pid,
+		ppid:    ppid,
+		cpuTime: time.Duration(utime+stime) * time.Second / clockTicksPerSecond,
+		rss:     rssPages * pageSize,
+	}, nil
+}
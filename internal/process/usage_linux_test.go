@@ -0,0 +1,32 @@
+//go:build linux
+
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseProcStat(t *testing.T) {
+	// Command names may contain spaces and parentheses
+	line := "1234 (my (weird) cmd) S 42 1234 1234 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 1 0 100 1000000 300 18446744073709551615"
+	p, err := parseProcStat(1234, line, 4096)
+	if err != nil {
+		t.Fatalf("parseProcStat failed: %v", err)
+	}
+	if p.ppid != 42 {
+		t.Errorf("ppid = %d, want 42", p.ppid)
+	}
+	if p.cpuTime != 3*time.Second {
+		t.Errorf("cpuTime = %v, want 3s", p.cpuTime)
+	}
+	if p.rss != 300*4096 {
+		t.Errorf("rss = %d, want %d", p.rss, 300*4096)
+	}
+}
+
+func TestParseProcStatMalformed(t *testing.T) {
+	if _, err := parseProcStat(1, "garbage", 4096); err == nil {
+		t.Error("expected error for malformed line")
+	}
+}
@@ -0,0 +1,60 @@
+//go:build !linux && !windows
+
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listProcesses reads the process table using ps (macOS and BSDs have no /proc).
+func listProcesses() ([]procInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=,time=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ps: %w", err)
+	}
+
+	var procs []procInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		rssKB, err3 := strconv.ParseInt(fields[2], 10, 64)
+		cpuTime, err4 := parsePSTime(fields[3])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+		procs = append(procs, procInfo{pid: pid, ppid: ppid, cpuTime: cpuTime, rss: rssKB * 1024})
+	}
+	return procs, nil
+}
+
+// parsePSTime parses a ps cumulative CPU time in the form [[dd-]hh:]mm:ss[.ff].
+func parsePSTime(s string) (time.Duration, error) {
+	var days int64
+	if idx := strings.Index(s, "-"); idx >= 0 {
+		d, err := strconv.ParseInt(s[:idx], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		days = d
+		s = s[idx+1:]
+	}
+
+	parts := strings.Split(s, ":")
+	var total float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, err
+		}
+		total = total*60 + v
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(total*float64(time.Second)), nil
+}
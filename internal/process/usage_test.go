@@ -0,0 +1,60 @@
+package process
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTreeTotals(t *testing.T) {
+	procs := []procInfo{
+		{pid: 1, ppid: 0, cpuTime: time.Second, rss: 100},
+		{pid: 10, ppid: 1, cpuTime: 2 * time.Second, rss: 1000},
+		{pid: 11, ppid: 10, cpuTime: 3 * time.Second, rss: 2000},
+		{pid: 12, ppid: 10, cpuTime: 4 * time.Second, rss: 4000},
+		{pid: 20, ppid: 1, cpuTime: 5 * time.Second, rss: 8000},
+	}
+
+	cpu, rss, ok := treeTotals(procs, 10)
+	if !ok {
+		t.Fatal("expected root 10 to be found")
+	}
+	if cpu != 9*time.Second {
+		t.Errorf("cpu = %v, want 9s", cpu)
+	}
+	if rss != 7000 {
+		t.Errorf("rss = %d, want 7000", rss)
+	}
+
+	if _, _, ok := treeTotals(procs, 99); ok {
+		t.Error("expected missing root to report ok=false")
+	}
+}
+
+func TestSamplerSelf(t *testing.T) {
+	s := NewSampler()
+	pid := os.Getpid()
+
+	first, err := s.Sample([]int{pid})
+	if err != nil {
+		t.Skipf("process sampling unavailable: %v", err)
+	}
+	usage, ok := first[pid]
+	if !ok {
+		t.Fatal("expected a sample for the current process")
+	}
+	if usage.CPUPercent >= 0 {
+		t.Errorf("first sample should have no CPU%%, got %.1f", usage.CPUPercent)
+	}
+	if usage.RSSBytes <= 0 {
+		t.Errorf("expected positive RSS, got %d", usage.RSSBytes)
+	}
+
+	second, err := s.Sample([]int{pid})
+	if err != nil {
+		t.Fatalf("second sample failed: %v", err)
+	}
+	if second[pid].CPUPercent < 0 {
+		t.Errorf("second sample should have CPU%%, got %.1f", second[pid].CPUPercent)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package process
+
+import "fmt"
+
+// listProcesses is not supported on Windows; top shows "-" for CPU and memory.
+func listProcesses() ([]procInfo, error) {
+	return nil, fmt.Errorf("process sampling is not supported on windows")
+}
@@ -0,0 +1,127 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var artifactRegex = regexp.MustCompile(`\{\{artifact:\s*([^}]+)\}\}`)
+
+// ArtifactPath returns where the artifact ref ("task/path") lives within a
+// pipeline iteration's state directory: in the task's own directory.
+func ArtifactPath(outputDir, ref string) (string, error) {
+	taskName, path, ok := strings.Cut(ref, "/")
+	if !ok || taskName == "" || path == "" {
+		return "", fmt.Errorf("invalid artifact %q: expected \"task/path\"", ref)
+	}
+	path = filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid artifact %q: path must stay inside the task's directory", ref)
+	}
+	return filepath.Join(outputDir, taskName, path), nil
+}
+
+// ProcessArtifactDirectives replaces {{artifact:task/path}} directives with
+// the contents of a file an upstream task wrote to its directory in the
+// pipeline output directory, e.g. {{artifact:planner/plan.md}} for
+// $SWARM_STATE_DIR/planner/plan.md. Truncation policies work as for
+// {{output:...}}, and maxBytes (0 = no limit) caps each artifact.
+// A missing artifact is an error. If outputDir is empty (not running in a
+// pipeline), missing-artifact placeholders are used.
+func ProcessArtifactDirectives(content, outputDir string, maxBytes int) (string, error) {
+	matches := artifactRegex.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content, nil
+	}
+
+	// Process from end to start to preserve indices
+	result := content
+	for i := len(matches) - 1; i >= 0; i-- {
+		match := matches[i]
+		ref, truncation, err := parseArtifactReference(content[match[2]:match[3]])
+		if err != nil {
+			return "", err
+		}
+
+		var replacement string
+		if outputDir == "" {
+			replacement = fmt.Sprintf("(No artifact %q available — not running in a pipeline)", ref)
+		} else {
+			replacement, err = resolveArtifact(outputDir, ref, truncation, maxBytes)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		result = result[:match[0]] + replacement + result[match[1]:]
+	}
+
+	return result, nil
+}
+
+// parseArtifactReference splits the inside of an {{artifact:...}} directive
+// into the task/path reference and its optional truncation policy, checking
+// the reference's form.
+func parseArtifactReference(inner string) (string, *Truncation, error) {
+	ref, truncation, err := parseReference("artifact", inner)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := ArtifactPath("", ref); err != nil {
+		return "", nil, err
+	}
+	return ref, truncation, nil
+}
+
+// ArtifactReferences returns the tasks referenced by {{artifact:...}}
+// directives in content, sorted and without duplicates.
+func ArtifactReferences(content string) []string {
+	seen := make(map[string]bool)
+	var tasks []string
+	for _, m := range artifactRegex.FindAllStringSubmatch(content, -1) {
+		ref, _, err := parseArtifactReference(m[1])
+		if err != nil {
+			continue
+		}
+		task, _, _ := strings.Cut(ref, "/")
+		if !seen[task] {
+			seen[task] = true
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
+// HasArtifactDirectives reports whether content contains {{artifact:...}} directives.
+func HasArtifactDirectives(content string) bool {
+	return artifactRegex.MatchString(content)
+}
+
+func resolveArtifact(outputDir, ref string, truncation *Truncation, maxBytes int) (string, error) {
+	path, err := ArtifactPath(outputDir, ref)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			taskName, _, _ := strings.Cut(ref, "/")
+			return "", fmt.Errorf("artifact %q not found: task %q did not write %s", ref, taskName, path)
+		}
+		return "", fmt.Errorf("failed to read artifact %q: %w", ref, err)
+	}
+
+	text := strings.TrimRight(string(data), "\n")
+	if truncation != nil {
+		text = truncation.Apply(text, ref)
+	}
+	if maxBytes > 0 {
+		text = Truncation{Last: true, Count: maxBytes}.Apply(text, ref)
+	}
+	return fmt.Sprintf("--- Artifact %q ---\n%s\n--- End artifact %q ---", ref, text, ref), nil
+}
@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcessArtifactDirectives(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "planner", "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "planner", "docs", "plan.md"), []byte("# Plan\nstep 1\nstep 2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "planner.txt"), []byte("the whole output"), 0644)
+
+	result, err := ProcessArtifactDirectives("Before\n{{artifact:planner/docs/plan.md}}\nAfter", dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Before\n--- Artifact \"planner/docs/plan.md\" ---\n# Plan\nstep 1\nstep 2\n--- End artifact \"planner/docs/plan.md\" ---\nAfter"
+	if result != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result, want)
+	}
+	if strings.Contains(result, "the whole output") {
+		t.Error("only the artifact should be inlined, not the task's output")
+	}
+
+	result, err = ProcessArtifactDirectives("{{artifact: planner/docs/plan.md | last 1 lines}}", dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "step 2") || strings.Contains(result, "step 1") {
+		t.Errorf("expected only the last line, got:\n%s", result)
+	}
+}
+
+func TestProcessArtifactDirectives_Missing(t *testing.T) {
+	dir := t.TempDir()
+	_, err := ProcessArtifactDirectives("{{artifact:planner/plan.md}}", dir, 0)
+	if err == nil {
+		t.Fatal("expected an error for a missing artifact")
+	}
+	if !strings.Contains(err.Error(), `artifact "planner/plan.md" not found`) || !strings.Contains(err.Error(), filepath.Join(dir, "planner", "plan.md")) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Outside a pipeline there is nothing to read
+	result, err := ProcessArtifactDirectives("{{artifact:planner/plan.md}}", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "not running in a pipeline") {
+		t.Errorf("expected a placeholder, got %q", result)
+	}
+}
+
+func TestProcessArtifactDirectives_InvalidReference(t *testing.T) {
+	dir := t.TempDir()
+	for _, content := range []string{
+		"{{artifact:planner}}",
+		"{{artifact:planner/}}",
+		"{{artifact:planner/../../etc/passwd}}",
+		"{{artifact:planner/plan.md | most of it}}",
+	} {
+		if _, err := ProcessArtifactDirectives(content, dir, 0); err == nil {
+			t.Errorf("expected an error for %s", content)
+		}
+	}
+}
+
+func TestArtifactReferences(t *testing.T) {
+	content := "{{artifact:reviewer/notes.md}} {{artifact:planner/plan.md}} {{artifact:planner/tasks.md | first 10 lines}} {{artifact:bad}}"
+	got := ArtifactReferences(content)
+	want := []string{"planner", "reviewer"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ArtifactReferences() = %v, want %v", got, want)
+	}
+}
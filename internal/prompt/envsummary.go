@@ -0,0 +1,121 @@
+package prompt
+
+import (
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// envSummaryTools are the tools whose availability is reported in an
+// environment summary.
+var envSummaryTools = []string{
+	"git", "gh", "make", "docker", "go", "node", "npm", "pnpm", "yarn", "bun",
+	"python3", "pip3", "uv", "cargo", "rustc", "java", "mvn", "gradle",
+	"ruby", "bundle", "rg", "jq", "curl",
+}
+
+// languageExtensions maps file extensions to the language reported in an
+// environment summary.
+var languageExtensions = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript",
+	".mjs": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript",
+	".rs": "Rust", ".java": "Java", ".kt": "Kotlin", ".rb": "Ruby",
+	".php": "PHP", ".cs": "C#", ".c": "C", ".h": "C", ".cpp": "C++",
+	".cc": "C++", ".hpp": "C++", ".swift": "Swift", ".scala": "Scala",
+	".sh": "Shell", ".sql": "SQL", ".html": "HTML", ".css": "CSS",
+	".scss": "CSS", ".vue": "Vue", ".svelte": "Svelte", ".md": "Markdown",
+}
+
+// skippedDirs are directories not counted in repo language stats.
+var skippedDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true,
+}
+
+// maxScannedFiles bounds the repo scan so summaries stay cheap in large trees.
+const maxScannedFiles = 20000
+
+// EnvSummary describes the host the agent runs on: OS, available tools, and
+// the languages of the repository in dir.
+func EnvSummary(dir string) string {
+	var b strings.Builder
+	b.WriteString("Environment summary (detected by swarm):\n")
+	fmt.Fprintf(&b, "- OS: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+
+	var tools []string
+	for _, tool := range envSummaryTools {
+		if _, err := exec.LookPath(tool); err == nil {
+			tools = append(tools, tool)
+		}
+	}
+	if len(tools) > 0 {
+		fmt.Fprintf(&b, "- Tools on PATH: %s\n", strings.Join(tools, ", "))
+	} else {
+		b.WriteString("- Tools on PATH: none of the common tools detected\n")
+	}
+
+	if langs := languageStats(dir); langs != "" {
+		fmt.Fprintf(&b, "- Repository languages (by file count): %s\n", langs)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// InjectEnvSummary injects an environment summary at the beginning of the prompt content.
+func InjectEnvSummary(promptContent, summary string) string {
+	return summary + "\n\n" + promptContent
+}
+
+// languageStats returns the most common languages in dir by file count,
+// e.g. "Go 80%, Markdown 15%, Shell 5%". Hidden and dependency directories
+// are skipped.
+func languageStats(dir string) string {
+	counts := make(map[string]int)
+	total, scanned := 0, 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || skippedDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if scanned++; scanned > maxScannedFiles {
+			return filepath.SkipAll
+		}
+		if lang, ok := languageExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+			counts[lang]++
+			total++
+		}
+		return nil
+	})
+	if total == 0 {
+		return ""
+	}
+
+	langs := make([]string, 0, len(counts))
+	for lang := range counts {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if counts[langs[i]] != counts[langs[j]] {
+			return counts[langs[i]] > counts[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) > 5 {
+		langs = langs[:5]
+	}
+
+	parts := make([]string, len(langs))
+	for i, lang := range langs {
+		parts[i] = fmt.Sprintf("%s %d%%", lang, counts[lang]*100/total)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,41 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnvSummary(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"main.go", "util.go", "cmd/run.go", "README.md", ".git/config.go", "node_modules/x/index.js"}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary := EnvSummary(dir)
+	if !strings.Contains(summary, "OS: "+runtime.GOOS+"/"+runtime.GOARCH) {
+		t.Errorf("expected OS line, got:\n%s", summary)
+	}
+	if !strings.Contains(summary, "Repository languages (by file count): Go 75%, Markdown 25%") {
+		t.Errorf("expected language stats skipping hidden and dependency dirs, got:\n%s", summary)
+	}
+
+	if got := InjectEnvSummary("Do the task", summary); !strings.HasPrefix(got, "Environment summary") || !strings.HasSuffix(got, "\n\nDo the task") {
+		t.Errorf("InjectEnvSummary = %q", got)
+	}
+}
+
+func TestEnvSummary_NoSourceFiles(t *testing.T) {
+	if summary := EnvSummary(t.TempDir()); strings.Contains(summary, "Repository languages") {
+		t.Errorf("expected no language stats for an empty dir, got:\n%s", summary)
+	}
+}
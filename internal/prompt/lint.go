@@ -0,0 +1,164 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderRegex matches {{...}} placeholders in prompt content.
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([^}]*?)\s*\}\}`)
+
+// negatedInstructionRegex matches instructions such as "Do not commit your
+// changes" or "Never push", capturing the rest of the instruction.
+var negatedInstructionRegex = regexp.MustCompile(`(?i)\b(?:do not|don't|never)\s+([^.!?\n]+)`)
+
+// sentenceSplitRegex splits text into sentences and lines.
+var sentenceSplitRegex = regexp.MustCompile(`[.!?\n]`)
+
+// fillerWords are skipped when comparing instructions, so "commit your
+// changes" and "commit the changes" are the same action.
+var fillerWords = map[string]bool{"the": true, "a": true, "an": true, "your": true, "any": true, "all": true}
+
+// LintIssue is a problem found in a prompt by Lint.
+type LintIssue struct {
+	// Warning is true for issues that don't stop the prompt from working
+	Warning bool
+	Message string
+}
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	// Prefix and Suffix are the task's prefix and suffix, checked for
+	// instructions that conflict with the prompt
+	Prefix string
+	Suffix string
+
+	// MaxTokens is the estimated size above which a prompt is too long
+	// (0 = no limit)
+	MaxTokens int
+}
+
+// Lint checks prompt content for common problems: unresolved placeholders,
+// malformed {{output:...}} and {{artifact:...}} references, no mention of
+// the SWARM_TASK_ID swarm gives the agent, excessive length, and
+// instructions that contradict the prefix or suffix.
+func Lint(content string, opts LintOptions) []LintIssue {
+	var issues []LintIssue
+
+	for _, m := range placeholderRegex.FindAllStringSubmatch(content, -1) {
+		inner := m[1]
+		switch {
+		case inner == "stdin" || inner == "STDIN":
+		case strings.HasPrefix(inner, "output:"):
+			if _, _, err := parseOutputReference(strings.TrimPrefix(inner, "output:")); err != nil {
+				issues = append(issues, LintIssue{Message: err.Error()})
+			}
+		case strings.HasPrefix(inner, "artifact:"):
+			if _, _, err := parseArtifactReference(strings.TrimPrefix(inner, "artifact:")); err != nil {
+				issues = append(issues, LintIssue{Message: err.Error()})
+			}
+		default:
+			issues = append(issues, LintIssue{Message: fmt.Sprintf("unresolved placeholder %s", m[0])})
+		}
+	}
+
+	if !strings.Contains(content+opts.Prefix+opts.Suffix, "SWARM_TASK_ID") {
+		issues = append(issues, LintIssue{Warning: true, Message: "prompt never mentions SWARM_TASK_ID, so the agent may not use its task ID"})
+	}
+
+	full := ApplyPrefixSuffix(content, opts.Prefix, opts.Suffix)
+	if tokens := EstimateTokens(full); opts.MaxTokens > 0 && tokens > opts.MaxTokens {
+		issues = append(issues, LintIssue{Message: fmt.Sprintf("prompt is too long: ~%d tokens estimated, limit is %d", tokens, opts.MaxTokens)})
+	}
+
+	for _, conflict := range conflictingInstructions(content, opts.Prefix+"\n"+opts.Suffix) {
+		issues = append(issues, LintIssue{Warning: true, Message: conflict})
+	}
+	return issues
+}
+
+// OutputReferences returns the tasks referenced by {{output:...}} directives
+// in content, sorted and without duplicates.
+func OutputReferences(content string) []string {
+	seen := make(map[string]bool)
+	var tasks []string
+	for _, m := range outputRegex.FindAllStringSubmatch(content, -1) {
+		ref, _, err := parseOutputReference(m[1])
+		if err != nil {
+			continue
+		}
+		task, _, _ := strings.Cut(ref, ".")
+		if task != "" && !seen[task] {
+			seen[task] = true
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
+// conflictingInstructions reports actions the prompt forbids that the
+// prefix/suffix ask for, and vice versa.
+func conflictingInstructions(content, wrapper string) []string {
+	if strings.TrimSpace(wrapper) == "" {
+		return nil
+	}
+	var conflicts []string
+	for _, action := range negatedActions(wrapper) {
+		if asksFor(content, action) {
+			conflicts = append(conflicts, fmt.Sprintf("prompt asks to %q but the prefix/suffix forbids it", action))
+		}
+	}
+	for _, action := range negatedActions(content) {
+		if asksFor(wrapper, action) {
+			conflicts = append(conflicts, fmt.Sprintf("prefix/suffix asks to %q but the prompt forbids it", action))
+		}
+	}
+	return conflicts
+}
+
+// negatedActions returns the actions forbidden by "do not"/"never"
+// instructions in text.
+func negatedActions(text string) []string {
+	var actions []string
+	for _, m := range negatedInstructionRegex.FindAllStringSubmatch(text, -1) {
+		if action := instructionAction(m[1]); action != "" {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// asksFor reports whether text has an instruction to perform action, e.g.
+// "Commit your changes" or "Always commit the changes" for "commit changes".
+func asksFor(text, action string) bool {
+	for _, sentence := range sentenceSplitRegex.Split(text, -1) {
+		sentence = strings.ToLower(strings.TrimLeft(strings.TrimSpace(sentence), "-*0123456789) "))
+		for _, lead := range []string{"always ", "you must ", "make sure to "} {
+			sentence = strings.TrimPrefix(sentence, lead)
+		}
+		if instructionAction(sentence) == action {
+			return true
+		}
+	}
+	return false
+}
+
+// instructionAction reduces an instruction to its verb and the first word of
+// its object, lowercased and without filler words: "Commit your changes to
+// main" becomes "commit changes".
+func instructionAction(instruction string) string {
+	var words []string
+	for _, w := range strings.Fields(strings.ToLower(instruction)) {
+		w = strings.Trim(w, ",;:\"'`()")
+		if w == "" || fillerWords[w] {
+			continue
+		}
+		if words = append(words, w); len(words) == 2 {
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
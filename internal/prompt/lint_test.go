@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lintMessages returns the messages of issues, marking warnings.
+func lintMessages(issues []LintIssue) []string {
+	var msgs []string
+	for _, issue := range issues {
+		if issue.Warning {
+			msgs = append(msgs, "warning: "+issue.Message)
+		} else {
+			msgs = append(msgs, issue.Message)
+		}
+	}
+	return msgs
+}
+
+func TestLint_Clean(t *testing.T) {
+	content := "Your task ID is in SWARM_TASK_ID.\n\nReview {{output:coder | last 50 lines}}, {{artifact:planner/plan.md}} and {{stdin}}. Commit your changes."
+	if issues := Lint(content, LintOptions{Prefix: "Be concise.", MaxTokens: 1000}); len(issues) != 0 {
+		t.Errorf("expected no issues, got %q", lintMessages(issues))
+	}
+}
+
+func TestLint_Placeholders(t *testing.T) {
+	content := "SWARM_TASK_ID {{branch}} {{output:coder | middle 5 lines}} {{artifact:plan.md}}"
+	msgs := lintMessages(Lint(content, LintOptions{}))
+	if len(msgs) != 3 || msgs[0] != "unresolved placeholder {{branch}}" || !strings.Contains(msgs[1], "invalid truncation") || !strings.Contains(msgs[2], "invalid artifact") {
+		t.Errorf("unexpected issues %q", msgs)
+	}
+}
+
+func TestLint_TaskIDAndLength(t *testing.T) {
+	msgs := lintMessages(Lint(strings.Repeat("x", 400), LintOptions{MaxTokens: 50}))
+	if len(msgs) != 2 || !strings.Contains(msgs[0], "SWARM_TASK_ID") || !strings.Contains(msgs[1], "too long: ~100 tokens") {
+		t.Errorf("unexpected issues %q", msgs)
+	}
+}
+
+func TestLint_ConflictingInstructions(t *testing.T) {
+	content := "SWARM_TASK_ID\n\n- Commit the changes when done.\n- Never run the full test suite."
+	msgs := lintMessages(Lint(content, LintOptions{
+		Prefix: "Do not commit your changes.",
+		Suffix: "Always run the full test suite. Do not modify the tests.",
+	}))
+	want := []string{
+		`warning: prompt asks to "commit changes" but the prefix/suffix forbids it`,
+		`warning: prefix/suffix asks to "run full" but the prompt forbids it`,
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("issues = %q, want %q", msgs, want)
+	}
+}
+
+func TestOutputReferences(t *testing.T) {
+	content := "{{output:plan}} {{output:review.verdict}} {{output: plan | last 10 lines}} {{output:x | bad}}"
+	if got, want := OutputReferences(content), []string{"plan", "review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OutputReferences = %q, want %q", got, want)
+	}
+}
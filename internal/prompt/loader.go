@@ -84,6 +84,40 @@ func LoadPromptFromFile(filePath string) (string, error) {
 	return wrapped, nil
 }
 
+// CompactPromptName returns the name of the compact variant of a prompt,
+// e.g. "coder" -> "coder.compact". A compact variant is a shorter version of
+// a prompt used when a run overflows the agent's context window.
+func CompactPromptName(name string) string {
+	return strings.TrimSuffix(name, ".md") + ".compact"
+}
+
+// CompactPromptFile returns the path of the compact variant of a prompt file,
+// e.g. "prompts/coder.md" -> "prompts/coder.compact.md".
+func CompactPromptFile(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".compact" + ext
+}
+
+// LoadCompactPrompt loads the compact variant of a prompt. It returns an empty
+// string if the prompt has no compact variant.
+func LoadCompactPrompt(promptsDir, name string) (string, error) {
+	compact := CompactPromptName(name)
+	if _, err := os.Stat(filepath.Join(promptsDir, compact+".md")); os.IsNotExist(err) {
+		return "", nil
+	}
+	return LoadPrompt(promptsDir, compact)
+}
+
+// LoadCompactPromptFromFile loads the compact variant of a prompt file. It
+// returns an empty string if the file has no compact variant.
+func LoadCompactPromptFromFile(filePath string) (string, error) {
+	compact := CompactPromptFile(filePath)
+	if _, err := os.Stat(compact); os.IsNotExist(err) {
+		return "", nil
+	}
+	return LoadPromptFromFile(compact)
+}
+
 // WrapPromptString wraps a raw prompt string with system/user tags.
 func WrapPromptString(content string) string {
 	return wrapPrompt(content)
@@ -191,6 +225,13 @@ func InjectOutputDir(promptContent, outputDir, taskName string) string {
 	return line + "\n\n" + promptContent
 }
 
+// InjectOutputSchema tells the agent to write its structured output, a JSON
+// object matching schema, to path.
+func InjectOutputSchema(promptContent, path, schema string) string {
+	line := fmt.Sprintf("When you finish, write your structured result as a JSON object to %s. It must match this JSON schema: %s", path, schema)
+	return line + "\n\n" + promptContent
+}
+
 // InjectIteration injects the current iteration number and total into the prompt.
 // A total of 0 means unlimited iterations.
 func InjectIteration(promptContent string, current, total int) string {
@@ -203,6 +244,22 @@ func InjectIteration(promptContent string, current, total int) string {
 	return line + "\n\n" + promptContent
 }
 
+// InjectMessages appends instructions queued with `swarm exec` to the prompt,
+// oldest first, so the agent treats them as additions to its task.
+func InjectMessages(promptContent string, messages []string) string {
+	if len(messages) == 0 {
+		return promptContent
+	}
+	var b strings.Builder
+	b.WriteString(promptContent)
+	b.WriteString("\n\nAdditional instructions for this iteration:\n")
+	for _, m := range messages {
+		b.WriteString("\n- ")
+		b.WriteString(strings.ReplaceAll(strings.TrimSpace(m), "\n", "\n  "))
+	}
+	return b.String()
+}
+
 // InjectSubAgentRestriction injects a message telling the agent not to spawn sub-agents.
 // This is used when an agent is already a sub-agent to prevent deep nesting.
 func InjectSubAgentRestriction(promptContent, parentID string) string {
@@ -266,6 +323,26 @@ func CombinePrompts(base, additional string) string {
 	return base + "\n\n---\n\n" + additional
 }
 
+// InjectStdin replaces every {{stdin}} (or {{STDIN}}) placeholder in the
+// prompt with content read from stdin. Prompts without a placeholder are
+// returned unchanged.
+func InjectStdin(promptContent, stdin string) string {
+	if stdin == "" {
+		return promptContent
+	}
+	promptContent = strings.ReplaceAll(promptContent, "{{stdin}}", stdin)
+	return strings.ReplaceAll(promptContent, "{{STDIN}}", stdin)
+}
+
+// InjectMatrix replaces each {{matrix.key}} placeholder in the prompt with
+// the pipeline matrix instance's value for key.
+func InjectMatrix(promptContent string, values map[string]string) string {
+	for k, v := range values {
+		promptContent = strings.ReplaceAll(promptContent, "{{matrix."+k+"}}", v)
+	}
+	return promptContent
+}
+
 // SelectPrompt presents an interactive prompt selection and returns the selected prompt.
 func SelectPrompt(promptsDir string) (name string, content string, err error) {
 	prompts, err := ListPrompts(promptsDir)
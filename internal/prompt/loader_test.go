@@ -459,3 +459,56 @@ func TestApplyPrefixSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadCompactPrompt(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "coder.md"), []byte("full"), 0644)
+	os.WriteFile(filepath.Join(dir, "coder.compact.md"), []byte("short"), 0644)
+	os.WriteFile(filepath.Join(dir, "planner.md"), []byte("full"), 0644)
+
+	got, err := LoadCompactPrompt(dir, "coder")
+	if err != nil || got != "short" {
+		t.Errorf("LoadCompactPrompt(coder) = %q, %v; want %q", got, err, "short")
+	}
+	got, err = LoadCompactPrompt(dir, "planner")
+	if err != nil || got != "" {
+		t.Errorf("LoadCompactPrompt(planner) = %q, %v; want no variant", got, err)
+	}
+
+	got, err = LoadCompactPromptFromFile(filepath.Join(dir, "coder.md"))
+	if err != nil || got != "short" {
+		t.Errorf("LoadCompactPromptFromFile() = %q, %v; want %q", got, err, "short")
+	}
+}
+
+func TestInjectStdin(t *testing.T) {
+	got := InjectStdin("Spec:\n{{stdin}}\n\nAgain: {{STDIN}}", "build a CLI")
+	if got != "Spec:\nbuild a CLI\n\nAgain: build a CLI" {
+		t.Errorf("InjectStdin = %q", got)
+	}
+
+	if got := InjectStdin("No placeholder", "build a CLI"); got != "No placeholder" {
+		t.Errorf("prompt without placeholder changed: %q", got)
+	}
+	if got := InjectStdin("Spec: {{stdin}}", ""); got != "Spec: {{stdin}}" {
+		t.Errorf("empty stdin should leave the placeholder, got %q", got)
+	}
+}
+
+func TestInjectMatrix(t *testing.T) {
+	got := InjectMatrix("Review the {{matrix.module}} module on {{matrix.os}}; {{matrix.other}}", map[string]string{"module": "auth", "os": "linux"})
+	if got != "Review the auth module on linux; {{matrix.other}}" {
+		t.Errorf("InjectMatrix = %q", got)
+	}
+}
+
+func TestInjectMessages(t *testing.T) {
+	if got := InjectMessages("Fix the bug.", nil); got != "Fix the bug." {
+		t.Errorf("InjectMessages without messages = %q", got)
+	}
+	got := InjectMessages("Fix the bug.", []string{"also update the changelog", "then\nrun the tests "})
+	want := "Fix the bug.\n\nAdditional instructions for this iteration:\n\n- also update the changelog\n- then\n  run the tests"
+	if got != want {
+		t.Errorf("InjectMessages = %q, want %q", got, want)
+	}
+}
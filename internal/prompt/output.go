@@ -1,19 +1,43 @@
 package prompt
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/mj1618/swarm-cli/internal/taskoutput"
 )
 
 var outputRegex = regexp.MustCompile(`\{\{output:\s*([^}]+)\}\}`)
 
 // ProcessOutputDirectives replaces {{output:task_name}} directives with the
 // contents of the corresponding task output file from the pipeline output directory.
+// {{output:task_name.field}} is replaced with a field of the task's structured
+// output (swarm-output.json), and {{output:task_name}} falls back to the
+// structured output when the task wrote no text output.
+// A truncation policy limits how much is injected, e.g.
+// {{output:planner | last 200 lines}} or {{output:planner | first 4096 bytes}}.
 // If outputDir is empty (not running in a pipeline), missing-output placeholders are used.
 func ProcessOutputDirectives(content, outputDir string) (string, error) {
+	return processOutputDirectives(content, outputDir, 0)
+}
+
+// ProcessOutputDirectivesTruncated is like ProcessOutputDirectives but also
+// keeps only the last maxBytes bytes of each injected output, for a task's
+// max_injected_bytes or a retry after a context overflow.
+func ProcessOutputDirectivesTruncated(content, outputDir string, maxBytes int) (string, error) {
+	return processOutputDirectives(content, outputDir, maxBytes)
+}
+
+// HasOutputDirectives reports whether content contains {{output:...}} directives.
+func HasOutputDirectives(content string) bool {
+	return outputRegex.MatchString(content)
+}
+
+func processOutputDirectives(content, outputDir string, maxBytes int) (string, error) {
 	matches := outputRegex.FindAllStringSubmatchIndex(content, -1)
 	if len(matches) == 0 {
 		return content, nil
@@ -23,22 +47,29 @@ func ProcessOutputDirectives(content, outputDir string) (string, error) {
 	result := content
 	for i := len(matches) - 1; i >= 0; i-- {
 		match := matches[i]
-		taskName := strings.TrimSpace(content[match[2]:match[3]])
+		ref, truncation, err := parseOutputReference(content[match[2]:match[3]])
+		if err != nil {
+			return "", err
+		}
+
+		// Apply the reference's policy, then the overall byte cap
+		limit := func(text string) string {
+			if truncation != nil {
+				text = truncation.Apply(text, ref)
+			}
+			if maxBytes > 0 {
+				text = Truncation{Last: true, Count: maxBytes}.Apply(text, ref)
+			}
+			return text
+		}
 
 		var replacement string
 		if outputDir == "" {
-			replacement = fmt.Sprintf("(No output available from task %q — not running in a pipeline)", taskName)
+			replacement = fmt.Sprintf("(No output available from task %q — not running in a pipeline)", ref)
 		} else {
-			outputPath := filepath.Join(outputDir, taskName+".txt")
-			data, err := os.ReadFile(outputPath)
+			replacement, err = resolveOutput(outputDir, ref, limit)
 			if err != nil {
-				if os.IsNotExist(err) {
-					replacement = fmt.Sprintf("(No output available from task %q)", taskName)
-				} else {
-					return "", fmt.Errorf("failed to read output for task %q: %w", taskName, err)
-				}
-			} else {
-				replacement = fmt.Sprintf("--- Output from task %q ---\n%s\n--- End output from task %q ---", taskName, strings.TrimRight(string(data), "\n"), taskName)
+				return "", err
 			}
 		}
 
@@ -47,3 +78,39 @@ func ProcessOutputDirectives(content, outputDir string) (string, error) {
 
 	return result, nil
 }
+
+// resolveOutput returns the replacement for an {{output:...}} reference, which
+// names either a task or a field of a task's structured output. limit is
+// applied to the injected text.
+func resolveOutput(outputDir, ref string, limit func(string) string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, ref+".txt"))
+	if err == nil {
+		return fmt.Sprintf("--- Output from task %q ---\n%s\n--- End output from task %q ---", ref, limit(strings.TrimRight(string(data), "\n")), ref), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read output for task %q: %w", ref, err)
+	}
+
+	taskName, field, _ := strings.Cut(ref, ".")
+	structured, err := taskoutput.Read(outputDir, taskName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("(No output available from task %q)", ref), nil
+		}
+		return "", fmt.Errorf("failed to read structured output for task %q: %w", taskName, err)
+	}
+
+	if field == "" {
+		data, err := json.MarshalIndent(structured, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("--- Output from task %q ---\n%s\n--- End output from task %q ---", taskName, limit(string(data)), taskName), nil
+	}
+
+	value, ok := taskoutput.Lookup(structured, field)
+	if !ok {
+		return fmt.Sprintf("(No field %q in output of task %q)", field, taskName), nil
+	}
+	return limit(taskoutput.Format(value)), nil
+}
@@ -1,6 +1,7 @@
 package prompt
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -134,3 +135,98 @@ func TestProcessOutputDirectives_WhitespaceInName(t *testing.T) {
 		t.Errorf("expected task output with trimmed name, got:\n%s", result)
 	}
 }
+
+func TestProcessOutputDirectives_StructuredOutput(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tester"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"status": "fail", "failures": 3, "details": {"file": "auth.go"}}`
+	if err := os.WriteFile(filepath.Join(dir, "tester", "swarm-output.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "Status: {{output:tester.status}} ({{output:tester.failures}} failures in {{output:tester.details.file}}) {{output:tester.missing}}"
+	result, err := ProcessOutputDirectives(content, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `Status: fail (3 failures in auth.go) (No field "missing" in output of task "tester")`
+	if result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+
+	// Without a text output, the whole structured output is injected
+	result, err = ProcessOutputDirectives("{{output:tester}}", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, `"status": "fail"`) || !strings.Contains(result, `--- Output from task "tester" ---`) {
+		t.Errorf("expected structured output, got:\n%s", result)
+	}
+}
+
+func TestProcessOutputDirectivesTruncated(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, strings.Repeat("x", 20))
+	}
+	lines = append(lines, "final line")
+	os.WriteFile(filepath.Join(dir, "planner.txt"), []byte(strings.Join(lines, "\n")), 0644)
+
+	result, err := ProcessOutputDirectivesTruncated("{{output:planner}}", dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, `(Output from task "planner" truncated: first`) {
+		t.Errorf("expected truncation notice, got:\n%s", result)
+	}
+	if !strings.Contains(result, "final line") {
+		t.Errorf("expected the end of the output to be kept, got:\n%s", result)
+	}
+	if !strings.HasPrefix(result, `--- Output from task "planner" ---`) {
+		t.Errorf("expected output header to be kept, got:\n%s", result)
+	}
+	if len(result) > 300 {
+		t.Errorf("expected output truncated to about 100 bytes, got %d", len(result))
+	}
+
+	if !HasOutputDirectives("see {{output:planner}}") || HasOutputDirectives("no directives") {
+		t.Error("HasOutputDirectives mismatch")
+	}
+}
+
+func TestProcessOutputDirectives_TruncationPolicy(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	os.WriteFile(filepath.Join(dir, "planner.txt"), []byte(strings.Join(lines, "\n")), 0644)
+
+	result, err := ProcessOutputDirectives("{{output:planner | last 3 lines}}", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "first 7 lines omitted)\nline 8\nline 9\nline 10\n") || strings.Contains(result, "line 7\n") {
+		t.Errorf("expected only the last 3 lines, got:\n%s", result)
+	}
+
+	result, err = ProcessOutputDirectives("{{output: planner | first 2 lines }}", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "line 1\nline 2\n(Output from task \"planner\" truncated: last 8 lines omitted)") {
+		t.Errorf("expected only the first 2 lines, got:\n%s", result)
+	}
+
+	result, err = ProcessOutputDirectives("{{output:planner | first 1000 bytes}}", dir)
+	if err != nil || strings.Contains(result, "truncated") {
+		t.Errorf("expected short output to be injected whole, got %v:\n%s", err, result)
+	}
+
+	if _, err := ProcessOutputDirectives("{{output:planner | middle 3 lines}}", dir); err == nil {
+		t.Error("expected error for invalid truncation policy")
+	}
+}
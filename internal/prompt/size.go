@@ -0,0 +1,35 @@
+package prompt
+
+import "fmt"
+
+// charsPerToken is the rough number of characters per token used to estimate
+// prompt sizes without a model-specific tokenizer.
+const charsPerToken = 4
+
+// EstimateTokens returns a rough estimate of the number of tokens in content.
+func EstimateTokens(content string) int {
+	return (len(content) + charsPerToken - 1) / charsPerToken
+}
+
+// SizeGuard checks final prompts (after prefix/suffix, stdin, and
+// {{output:...}} content are applied) against an estimated token limit.
+type SizeGuard struct {
+	// MaxTokens is the estimated token limit (0 = no limit)
+	MaxTokens int
+
+	// Fail reports whether an oversized prompt should fail the iteration
+	// instead of only producing a warning
+	Fail bool
+}
+
+// Check returns an error if content's estimated token count exceeds the limit.
+// The error is classed as a context overflow by the agent failure classifier.
+func (g SizeGuard) Check(content string) error {
+	if g.MaxTokens <= 0 {
+		return nil
+	}
+	if tokens := EstimateTokens(content); tokens > g.MaxTokens {
+		return fmt.Errorf("prompt is too long: ~%d tokens estimated, max_prompt_tokens is %d", tokens, g.MaxTokens)
+	}
+	return nil
+}
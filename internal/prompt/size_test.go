@@ -0,0 +1,27 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSizeGuard(t *testing.T) {
+	if got := EstimateTokens(strings.Repeat("a", 401)); got != 101 {
+		t.Errorf("EstimateTokens() = %d, want 101", got)
+	}
+
+	small := strings.Repeat("a", 400)
+	big := strings.Repeat("a", 4000)
+
+	if err := (SizeGuard{}).Check(big); err != nil {
+		t.Errorf("guard without a limit should pass, got %v", err)
+	}
+	guard := SizeGuard{MaxTokens: 500}
+	if err := guard.Check(small); err != nil {
+		t.Errorf("small prompt should pass, got %v", err)
+	}
+	err := guard.Check(big)
+	if err == nil || !strings.Contains(err.Error(), "~1000 tokens") {
+		t.Errorf("expected size error mentioning ~1000 tokens, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// truncationRegex matches truncation policies such as "last 200 lines" or
+// "first 4096 bytes".
+var truncationRegex = regexp.MustCompile(`^(first|last)\s+(\d+)\s+(lines?|bytes?)$`)
+
+// Truncation limits how much of a task's output is injected by an
+// {{output:...}} directive, e.g. {{output:planner | last 200 lines}}.
+type Truncation struct {
+	Last  bool // keep the end of the output rather than the start
+	Count int
+	Lines bool // count lines rather than bytes
+}
+
+// ParseTruncation parses a truncation policy of the form
+// "first|last <n> lines|bytes".
+func ParseTruncation(policy string) (Truncation, error) {
+	m := truncationRegex.FindStringSubmatch(strings.TrimSpace(policy))
+	if m == nil {
+		return Truncation{}, fmt.Errorf("invalid truncation %q: expected \"first|last <n> lines|bytes\"", policy)
+	}
+	count, err := strconv.Atoi(m[2])
+	if err != nil || count <= 0 {
+		return Truncation{}, fmt.Errorf("invalid truncation %q: count must be positive", policy)
+	}
+	return Truncation{Last: m[1] == "last", Count: count, Lines: strings.HasPrefix(m[3], "line")}, nil
+}
+
+// parseOutputReference splits the inside of an {{output:...}} directive into
+// the task (or task.field) reference and its optional truncation policy.
+func parseOutputReference(inner string) (string, *Truncation, error) {
+	return parseReference("output", inner)
+}
+
+// parseReference splits the inside of a {{directive:...}} into its reference
+// and optional truncation policy.
+func parseReference(directive, inner string) (string, *Truncation, error) {
+	ref, policy, found := strings.Cut(inner, "|")
+	ref = strings.TrimSpace(ref)
+	if !found {
+		return ref, nil, nil
+	}
+	t, err := ParseTruncation(policy)
+	if err != nil {
+		return "", nil, fmt.Errorf("{{%s:%s}}: %w", directive, strings.TrimSpace(inner), err)
+	}
+	return ref, &t, nil
+}
+
+// Apply truncates text to the policy, noting what was omitted so the agent
+// knows the output is incomplete.
+func (t Truncation) Apply(text, ref string) string {
+	if t.Lines {
+		lines := strings.Split(text, "\n")
+		if len(lines) <= t.Count {
+			return text
+		}
+		omitted := len(lines) - t.Count
+		if t.Last {
+			return fmt.Sprintf("(Output from task %q truncated: first %d lines omitted)\n%s", ref, omitted, strings.Join(lines[omitted:], "\n"))
+		}
+		return fmt.Sprintf("%s\n(Output from task %q truncated: last %d lines omitted)", strings.Join(lines[:t.Count], "\n"), ref, omitted)
+	}
+
+	if len(text) <= t.Count {
+		return text
+	}
+	if t.Last {
+		// Start at a line boundary where possible
+		tail := text[len(text)-t.Count:]
+		if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+			tail = tail[i+1:]
+		}
+		return fmt.Sprintf("(Output from task %q truncated: first %d bytes omitted)\n%s", ref, len(text)-len(tail), tail)
+	}
+	// End at a line boundary where possible
+	head := text[:t.Count]
+	if i := strings.LastIndexByte(head, '\n'); i > 0 {
+		head = head[:i]
+	}
+	return fmt.Sprintf("%s\n(Output from task %q truncated: last %d bytes omitted)", head, ref, len(text)-len(head))
+}
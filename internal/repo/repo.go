@@ -0,0 +1,100 @@
+// Package repo resolves the repositories that compose tasks run in, so one
+// swarm.yaml can orchestrate agents across several repositories.
+package repo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// scpLikeRegex matches scp-style git URLs such as git@github.com:org/repo.git.
+var scpLikeRegex = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^/].*$`)
+
+// IsURL reports whether spec names a remote git repository rather than a
+// local directory.
+func IsURL(spec string) bool {
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://", "file://"} {
+		if strings.HasPrefix(spec, scheme) {
+			return true
+		}
+	}
+	return scpLikeRegex.MatchString(spec)
+}
+
+// WorkspaceDir returns the directory remote repositories are cloned into
+// (~/.swarm/repos).
+func WorkspaceDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".swarm", "repos"), nil
+}
+
+// Resolve returns the absolute directory of a task's repo. Local paths are
+// resolved against baseDir and must exist. Git URLs are cloned into the
+// workspace on first use; an existing clone is reused as is, so agents'
+// uncommitted work is never overwritten.
+func Resolve(spec, baseDir string) (string, error) {
+	if !IsURL(spec) {
+		dir := spec
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		dir = filepath.Clean(dir)
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", fmt.Errorf("repo %s: %w", spec, err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("repo %s: not a directory", spec)
+		}
+		return dir, nil
+	}
+
+	workspace, err := WorkspaceDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(workspace, CloneName(spec))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create repo workspace: %w", err)
+	}
+	cmd := exec.Command("git", "clone", "--quiet", spec, dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %v: %s", spec, err, strings.TrimSpace(string(output)))
+	}
+	return dir, nil
+}
+
+// CloneName returns the workspace-relative directory a git URL is cloned
+// into, e.g. "github.com/org/service" for git@github.com:org/service.git.
+func CloneName(url string) string {
+	name := url
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	} else if scpLikeRegex.MatchString(name) {
+		name = strings.Replace(name, ":", "/", 1)
+	}
+	if at := strings.LastIndex(name, "@"); at >= 0 && at < strings.Index(name+"/", "/") {
+		name = name[at+1:] // drop user info
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		parts = append(parts, strings.ReplaceAll(part, ":", "_"))
+	}
+	return filepath.Join(parts...)
+}
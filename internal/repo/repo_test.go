@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsURL(t *testing.T) {
+	for spec, want := range map[string]bool{
+		"../other-service":                       false,
+		"/abs/path":                              false,
+		"https://github.com/org/service.git":     true,
+		"git@github.com:org/service.git":         true,
+		"ssh://git@example.com:2222/org/svc.git": true,
+		"file:///tmp/repo":                       true,
+	} {
+		if got := IsURL(spec); got != want {
+			t.Errorf("IsURL(%q) = %v, want %v", spec, got, want)
+		}
+	}
+}
+
+func TestCloneName(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/org/service.git":     "github.com/org/service",
+		"git@github.com:org/service.git":         "github.com/org/service",
+		"ssh://git@example.com:2222/org/svc.git": "example.com_2222/org/svc",
+		"https://example.com/../../etc/":         "example.com/etc",
+	} {
+		if got := CloneName(url); got != filepath.FromSlash(want) {
+			t.Errorf("CloneName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestResolve_LocalPath(t *testing.T) {
+	base := t.TempDir()
+	other := filepath.Join(filepath.Dir(base), filepath.Base(base)+"-svc")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(other)
+
+	dir, err := Resolve("../"+filepath.Base(other), base)
+	if err != nil || dir != other {
+		t.Errorf("Resolve = %q, %v; want %q", dir, err, other)
+	}
+	if _, err := Resolve("missing", base); err == nil {
+		t.Error("expected error for missing repo dir")
+	}
+}
+
+func TestResolve_CloneURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	src := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"-c", "user.email=t@example.com", "-c", "user.name=t", "commit", "--quiet", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	url := "file://" + src
+	dir, err := Resolve(url, "")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		t.Errorf("expected a clone in %s: %v", dir, err)
+	}
+
+	// An existing clone is reused
+	if again, err := Resolve(url, ""); err != nil || again != dir {
+		t.Errorf("second Resolve = %q, %v; want %q", again, err, dir)
+	}
+}
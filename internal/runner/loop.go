@@ -13,9 +13,15 @@ import (
 
 	"github.com/mj1618/swarm-cli/internal/agent"
 	"github.com/mj1618/swarm-cli/internal/config"
+	"github.com/mj1618/swarm-cli/internal/detach"
+	"github.com/mj1618/swarm-cli/internal/exitreport"
 	"github.com/mj1618/swarm-cli/internal/logparser"
+	"github.com/mj1618/swarm-cli/internal/notify"
+	"github.com/mj1618/swarm-cli/internal/pathguard"
 	"github.com/mj1618/swarm-cli/internal/prompt"
+	"github.com/mj1618/swarm-cli/internal/scratch"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
 )
 
 // LoopConfig holds the configuration for running the multi-iteration agent loop.
@@ -49,6 +55,36 @@ type LoopConfig struct {
 
 	// IterTimeout is the timeout per iteration (0 = no timeout)
 	IterTimeout time.Duration
+
+	// CompactPromptContent is the compact variant of the prompt, used to retry
+	// an iteration that overflowed the agent's context window (empty = none)
+	CompactPromptContent string
+
+	// ResultCriteria fails an iteration whose result event matches it even
+	// if the agent CLI exits 0 (nil = exit status only)
+	ResultCriteria *agent.ResultCriteria
+
+	// PathGuard, when set, kills the agent and stops the loop if it edits a
+	// file the guard doesn't allow (nil = any file)
+	PathGuard *pathguard.Guard
+
+	// HealthCheck, when set, runs periodically while the loop is active and
+	// records the agent's health in its state (nil = no health check)
+	HealthCheck *agent.HealthCheck
+
+	// Dir is the directory the agent runs in (empty = current directory)
+	Dir string
+
+	// AfterIteration, when set, is called after each iteration with whether
+	// it failed, e.g. to merge a worktree's changes back
+	AfterIteration func(iteration int, failed bool)
+
+	// MaxPending, when set, makes each iteration wait while more than this
+	// many task files wait in TodoDir (0 = no limit)
+	MaxPending int
+
+	// TodoDir is the todo queue MaxPending counts
+	TodoDir string
 }
 
 // LoopResult contains the result of running the loop.
@@ -68,6 +104,24 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 	// Mutex to protect concurrent access to agentState fields
 	var stateMu sync.Mutex
 
+	// Whether the most recent iteration failed (protected by stateMu)
+	var lastIterFailed bool
+
+	// Give the agent a scratch dir for throwaway files
+	env := cfg.Env
+	exitFile := ""
+	if dir, err := scratch.Create(agentState.ID); err != nil {
+		fmt.Fprintf(cfg.Output, "[swarm] Warning: %v\n", err)
+	} else {
+		agentState.ScratchDir = dir
+		env = append(append([]string(nil), cfg.Env...), scratch.Env(dir))
+		exitFile = exitreport.Path(dir)
+	}
+
+	// Record the versions this run starts with
+	agentState.Manifest = agent.NewManifest(cfg.Command, agentState.WorkingDir)
+	_ = mgr.MergeUpdate(agentState)
+
 	// Set up total timeout context
 	var timeoutCtx context.Context
 	var timeoutCancel context.CancelFunc
@@ -89,20 +143,50 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 		agentState.TerminatedAt = &now
 		if agentState.ExitReason == "" {
 			agentState.ExitReason = "completed"
+			if lastIterFailed {
+				agentState.ExitReason = agentState.LastErrorClass
+			}
 		}
 		_ = mgr.MergeUpdate(agentState)
 
-		// Execute on-complete hook (copy hook value while holding lock)
-		onComplete := agentState.OnComplete
 		stateMu.Unlock()
 
-		if onComplete != "" {
-			if err := agent.ExecuteOnCompleteHook(agentState); err != nil {
-				fmt.Fprintf(cfg.Output, "[swarm] Warning: on-complete hook failed: %v\n", err)
+		// Execute on-complete/on-failure/on-timeout hooks
+		if err := agent.ExecuteHooks(agentState, agent.NewHookOptions(cfg.Config, cfg.Output)); err != nil {
+			fmt.Fprintf(cfg.Output, "[swarm] Warning: %v\n", err)
+			for _, run := range agentState.FailedHooks() {
+				notify.Send(cfg.Config, notify.HookFailedEvent(run).ForAgent(agentState), cfg.Output)
+			}
+		}
+		if len(agentState.HookRuns) > 0 {
+			stateMu.Lock()
+			_ = mgr.MergeUpdate(agentState)
+			stateMu.Unlock()
+		}
+
+		// After the hook, which may still read the scratch dir
+		if agentState.ScratchDir != "" {
+			if err := scratch.Finish(agentState.ID, cfg.Config.ScratchRetentionDuration()); err != nil {
+				fmt.Fprintf(cfg.Output, "[swarm] Warning: failed to clean up scratch dir: %v\n", err)
 			}
 		}
 	}()
 
+	// Probe the agent's health until the loop ends
+	if cfg.HealthCheck != nil {
+		check := *cfg.HealthCheck
+		check.OnUnhealthy = func(err error) {
+			notify.Send(cfg.Config, notify.UnhealthyEvent(check.Command, err).ForAgent(agentState), cfg.Output)
+		}
+		stopHealth := check.Start(cfg.Output, func(err error) {
+			stateMu.Lock()
+			agentState.RecordHealth(err, time.Now())
+			_ = mgr.MergeUpdate(agentState)
+			stateMu.Unlock()
+		})
+		defer stopHealth()
+	}
+
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -114,9 +198,20 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 		startingIteration = 1
 	}
 
-	// Track cumulative usage across iterations (each runner resets to zero)
-	var cumulativeInputTokens int64
-	var cumulativeOutputTokens int64
+	// Check prompt sizes before launching each attempt
+	var guard prompt.SizeGuard
+	if cfg.Config != nil {
+		guard = prompt.SizeGuard{MaxTokens: cfg.Config.MaxPromptTokens, Fail: cfg.Config.FailOnPromptLimit()}
+	}
+
+	// Track cumulative usage across iterations (each runner resets to zero),
+	// on top of the usage of the iterations an agent ran before it was
+	// continued (`swarm run --continue`)
+	baseInputTokens := agentState.InputTokens
+	baseOutputTokens := agentState.OutputTokens
+	baseCostUSD := agentState.TotalCost
+	cumulativeInputTokens := baseInputTokens
+	cumulativeOutputTokens := baseOutputTokens
 	var cumulativeCostUSD float64
 
 	// Run iterations (0 means unlimited), starting from startingIteration
@@ -164,28 +259,37 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 			// Check for termination
 			if currentState.TerminateMode == "immediate" {
 				fmt.Fprintln(cfg.Output, "\n[swarm] Received immediate termination signal")
-				agentState.ExitReason = "killed"
+				agentState.ExitReason = currentState.TerminationExitReason()
 				stateMu.Unlock()
 				return result, nil
 			}
 			if currentState.TerminateMode == "after_iteration" && i > 1 {
 				fmt.Fprintln(cfg.Output, "\n[swarm] Terminating after iteration as requested")
-				agentState.ExitReason = "killed"
+				agentState.ExitReason = currentState.TerminationExitReason()
 				stateMu.Unlock()
 				return result, nil
 			}
 
 			// Check for pause state and wait while paused
 			if currentState.Paused {
-				fmt.Fprintln(cfg.Output, "\n[swarm] Agent paused, waiting for resume...")
+				if currentState.Approval != "" {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Agent paused, waiting for approval: %s\n", currentState.Approval)
+				} else if currentState.ResumeAt != nil {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Agent paused until %s, waiting for resume...\n", currentState.ResumeAt.Format(time.DateTime))
+				} else {
+					fmt.Fprintln(cfg.Output, "\n[swarm] Agent paused, waiting for resume...")
+				}
 				agentState.Paused = true
 				now := time.Now()
 				agentState.PausedAt = &now
 				_ = mgr.MergeUpdate(agentState)
+				pausedEvent := notify.PauseEvent(agentID, currentState).ForAgent(agentState)
 				stateMu.Unlock()
+				notify.Send(cfg.Config, pausedEvent, cfg.Output)
 
 				for currentState.Paused && currentState.Status == "running" {
 					time.Sleep(1 * time.Second)
+					_, _ = mgr.ResumeIfDue(agentID)
 					currentState, err = mgr.Get(agentID)
 					if err != nil {
 						break
@@ -195,7 +299,7 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 						if currentState.TerminateMode == "immediate" {
 							fmt.Fprintln(cfg.Output, "\n[swarm] Received immediate termination signal")
 							stateMu.Lock()
-							agentState.ExitReason = "killed"
+							agentState.ExitReason = currentState.TerminationExitReason()
 							stateMu.Unlock()
 							return result, nil
 						}
@@ -216,12 +320,67 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 			}
 		}
 
+		// An agent older than max_agent_age is stopped between iterations
+		if maxAge := cfg.Config.MaxAgentAgeDuration(); maxAge > 0 && time.Since(agentState.StartedAt) > maxAge {
+			stateMu.Lock()
+			now := time.Now()
+			agentState.MaxAgeExceededAt = &now
+			agentState.ExitReason = state.ExitReasonMaxAge
+			stateMu.Unlock()
+			fmt.Fprintf(cfg.Output, "\n[swarm] Stopping: running longer than max_agent_age (%s)\n", maxAge)
+			return result, nil
+		}
+
+		// An agent that has used up its budget is stopped between iterations
+		stateMu.Lock()
+		overBudget := agentState.BudgetExceeded()
+		if overBudget != "" {
+			agentState.ExitReason = agent.FailureBudget
+		}
+		stateMu.Unlock()
+		if overBudget != "" {
+			fmt.Fprintf(cfg.Output, "\n[swarm] Stopping: %s\n", overBudget)
+			return result, nil
+		}
+
+		// A planner waits while the doers have a backlog of its tasks
+		if cfg.MaxPending > 0 {
+			waited := false
+			hasRoom := todoqueue.WaitForRoom(timeoutCtx, cfg.TodoDir, cfg.MaxPending, func(pending int) {
+				waited = true
+				fmt.Fprintf(cfg.Output, "\n[swarm] %d tasks pending (max_pending %d), waiting for doers to catch up...\n", pending, cfg.MaxPending)
+			}, func() bool {
+				current, err := mgr.Get(agentID)
+				return err == nil && current != nil && current.TerminateMode != ""
+			})
+			if !hasRoom {
+				if timeoutCtx.Err() != nil {
+					fmt.Fprintln(cfg.Output, "\n[swarm] Total timeout reached, stopping")
+					result.TimedOut = true
+					return result, nil
+				}
+				fmt.Fprintln(cfg.Output, "\n[swarm] Received termination signal while waiting")
+				if current, err := mgr.Get(agentID); err == nil && current != nil {
+					stateMu.Lock()
+					agentState.ExitReason = current.TerminationExitReason()
+					stateMu.Unlock()
+				}
+				return result, nil
+			}
+			if waited {
+				fmt.Fprintln(cfg.Output, "\n[swarm] Todo queue has room, continuing")
+			}
+		}
+
 		// Update current iteration and get values needed for this iteration
 		stateMu.Lock()
 		agentState.CurrentIter = i
+		iterStartedAt := time.Now()
+		agentState.StartIteration(iterStartedAt)
 		_ = mgr.MergeUpdate(agentState)
 		iterationsForDisplay := agentState.Iterations
 		modelForConfig := agentState.Model
+		medianIteration := agentState.MedianIterationDuration()
 		stateMu.Unlock()
 
 		if iterationsForDisplay == 0 {
@@ -230,91 +389,245 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 			fmt.Fprintf(cfg.Output, "\n[swarm] === Iteration %d/%d ===\n", i, iterationsForDisplay)
 		}
 
-		// Generate a per-iteration agent ID and inject it into the prompt.
-		iterationAgentID := state.GenerateID()
-		iterationPrompt := prompt.InjectAgentID(cfg.PromptContent, iterationAgentID)
-		iterationPrompt = prompt.InjectIteration(iterationPrompt, i, iterationsForDisplay)
-
-		// Create agent config with per-iteration timeout
-		agentCfg := agent.Config{
-			Model:   modelForConfig,
-			Prompt:  iterationPrompt,
-			Command: cfg.Command,
-			Env:     cfg.Env,
-			Timeout: cfg.IterTimeout,
-		}
-
-		// Run agent with usage tracking
-		runner := agent.NewRunner(agentCfg)
-		
-		// Set up usage callback to update state
-		// Capture cumulative values at iteration start for accumulation
-		iterStartInput := cumulativeInputTokens
-		iterStartOutput := cumulativeOutputTokens
-		iterStartCost := cumulativeCostUSD
-		runner.SetUsageCallback(func(stats logparser.UsageStats) {
+		// Flag the iteration if it runs much longer than usual, and with
+		// slow_iteration_action = "fail" stop it
+		iterCtx, cancelIter := context.WithCancel(timeoutCtx)
+		slowLimit := cfg.Config.SlowIterationLimit(medianIteration)
+		slowMessage := agent.SlowIterationMessage(i, slowLimit, medianIteration)
+		stopSlowWatch := agent.WatchSlowIteration(slowLimit, func() {
 			stateMu.Lock()
-			// Accumulate: previous iterations' totals + this iteration's running totals
-			agentState.InputTokens = iterStartInput + stats.InputTokens
-			agentState.OutputTokens = iterStartOutput + stats.OutputTokens
-			agentState.CurrentTask = stats.CurrentTask
-
-			// Use cost from CLI if available (accounts for cache pricing), otherwise calculate
-			if stats.TotalCostUSD > 0 {
-				agentState.TotalCost = iterStartCost + stats.TotalCostUSD
-			} else if cfg.Config != nil {
-				pricing := cfg.Config.GetPricing(agentState.Model)
-				agentState.TotalCost = pricing.CalculateCost(agentState.InputTokens, agentState.OutputTokens)
-			}
-
-			// Update state (will be throttled by the parser's update frequency)
+			agentState.SlowIteration = true
 			_ = mgr.MergeUpdate(agentState)
 			stateMu.Unlock()
+			if cfg.Config.FailSlowIterations() {
+				fmt.Fprintf(cfg.Output, "\n[swarm] Warning: %s, stopping it\n", slowMessage)
+				cancelIter()
+			} else {
+				fmt.Fprintf(cfg.Output, "\n[swarm] Warning: %s\n", slowMessage)
+			}
+			notify.Send(cfg.Config, notify.SlowIterationEvent(i, slowMessage).ForAgent(agentState), cfg.Output)
 		})
 
-		// Run agent - errors should NOT stop the run (including iteration timeouts)
-		if err := runner.RunWithContext(timeoutCtx, cfg.Output); err != nil {
+		// An iteration that exceeds the budget may finish, but not run on for long
+		budgetGuard := agent.NewBudgetGuard(cancelIter)
+
+		// Instructions queued with `swarm exec` are added to this iteration only
+		var messages []string
+		if queued, err := mgr.TakeMessages(agentID); err != nil {
+			fmt.Fprintf(cfg.Output, "[swarm] Warning: %v\n", err)
+		} else {
+			for _, m := range queued {
+				fmt.Fprintf(cfg.Output, "[swarm] Instruction added: %s\n", m.Text)
+				messages = append(messages, m.Text)
+			}
+		}
+
+		// Generate a per-iteration agent ID and inject it into the prompt.
+		iterationAgentID := state.GenerateID()
+		promptContent := cfg.PromptContent
+		command := cfg.Command
+		mitigation := ""
+		var record state.IterationRecord
+
+		// Attempts of this iteration: a context overflow is retried once
+		for {
+			iterationPrompt := prompt.InjectAgentID(promptContent, iterationAgentID)
+			iterationPrompt = prompt.InjectIteration(iterationPrompt, i, iterationsForDisplay)
+			iterationPrompt = prompt.InjectMessages(iterationPrompt, messages)
+
+			// Create agent config with per-iteration timeout
+			agentCfg := agent.Config{
+				Model:    modelForConfig,
+				Prompt:   iterationPrompt,
+				Command:  command,
+				Env:      env,
+				ExitFile: exitFile,
+				Run:      agent.RunInfo{Iteration: i, TotalIterations: iterationsForDisplay, TaskName: agentState.Name, RunID: agentState.ID, ControlID: agentState.ID},
+				Timeout:  cfg.IterTimeout,
+				// Keep the agent CLI's stderr out of the JSONL log
+				StderrFile:     detach.StderrLogPath(agentState.LogFile),
+				ResultCriteria: cfg.ResultCriteria,
+				PathGuard:      cfg.PathGuard,
+				Dir:            cfg.Dir,
+			}
+
+			// Run agent with usage tracking
+			runner := agent.NewRunner(agentCfg)
+
+			// Set up usage callback to update state
+			// Capture cumulative values at iteration start for accumulation
+			iterStartInput := cumulativeInputTokens
+			iterStartOutput := cumulativeOutputTokens
 			stateMu.Lock()
-			agentState.FailedIters++
-			agentState.LastError = err.Error()
-			if strings.Contains(err.Error(), "timed out") {
-				fmt.Fprintf(cfg.Output, "\n[swarm] Iteration %d timed out after %v (continuing)\n", i, cfg.IterTimeout)
-				// Record that this iteration timed out
-				agentState.TimeoutReason = "iteration"
+			iterStartTotalCost := agentState.TotalCost
+			stateMu.Unlock()
+			runner.SetUsageCallback(func(stats logparser.UsageStats) {
+				stateMu.Lock()
+				// Accumulate: previous iterations' totals + this iteration's running totals
+				agentState.InputTokens = iterStartInput + stats.InputTokens
+				agentState.OutputTokens = iterStartOutput + stats.OutputTokens
+				agentState.CurrentTask = stats.CurrentTask
+
+				// Use cost from CLI if available (accounts for cache pricing), otherwise
+				// price this iteration's tokens so far, keeping earlier iterations'
+				// cost in case the iteration never finishes
+				if stats.TotalCostUSD > 0 {
+					agentState.TotalCost = iterStartTotalCost + stats.TotalCostUSD
+				} else if cfg.Config != nil {
+					pricing := cfg.Config.GetPricing(agentState.Model)
+					agentState.TotalCost = iterStartTotalCost + pricing.CalculateCost(stats.InputTokens, stats.OutputTokens)
+				}
+
+				if reason := agentState.BudgetExceeded(); reason != "" && budgetGuard.Exceeded(reason) {
+					fmt.Fprintf(cfg.Output, "\n[swarm] %s, stopping after this iteration (or in %v)\n", reason, agent.BudgetGracePeriod)
+				}
+
+				// Update state (will be throttled by the parser's update frequency)
+				_ = mgr.MergeUpdate(agentState)
+				stateMu.Unlock()
+			})
+			runner.SetPodStatusCallback(func(podName, phase string) {
+				stateMu.Lock()
+				agentState.PodName = podName
+				agentState.PodStatus = phase
 				_ = mgr.MergeUpdate(agentState)
-				// Reset timeout reason after recording (will be set to "total" if total timeout hit)
-				agentState.TimeoutReason = ""
+				stateMu.Unlock()
+			})
+
+			// Run agent - errors should NOT stop the run (including iteration timeouts)
+			var runErr error
+			if sizeErr := guard.Check(iterationPrompt); sizeErr != nil && guard.Fail {
+				runErr = sizeErr
 			} else {
-				fmt.Fprintf(cfg.Output, "\n[swarm] Agent error (continuing): %v\n", err)
+				if sizeErr != nil {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Warning: %v\n", sizeErr)
+				}
+				runErr = runner.RunWithContext(iterCtx, cfg.Output)
+			}
+			if runErr != nil && iterCtx.Err() != nil && timeoutCtx.Err() == nil {
+				if budgetErr := budgetGuard.Stop(); budgetErr != nil {
+					runErr = budgetErr
+				} else {
+					runErr = fmt.Errorf("%w: %s", agent.ErrSlowIteration, slowMessage)
+				}
+			}
+
+			// Retry once with a mitigation when the agent's context overflowed
+			retry := false
+			if runErr != nil && mitigation == "" && runner.ClassifyFailure(runErr) == agent.FailureContextOverflow {
+				mitigation, promptContent, command = ContextOverflowMitigation(cfg.PromptContent, cfg.CompactPromptContent, command)
+				stateMu.Lock()
+				agentState.ContextOverflows++
+				stateMu.Unlock()
+				if mitigation != "" {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Context overflow in iteration %d, retrying with %s\n", i, mitigation)
+					retry = true
+				} else {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Context overflow in iteration %d (add a compact prompt variant to retry automatically)\n", i)
+				}
+			}
+
+			switch err := runErr; {
+			case retry:
+				// The retried attempt's outcome is counted instead
+			case err != nil:
+				stateMu.Lock()
+				agentState.FailedIters++
+				agentState.LastError = err.Error()
+				agentState.LastErrorClass = runner.ClassifyFailure(err)
+				lastIterFailed = true
+				if tail := runner.StderrTail(); len(tail) > 0 {
+					agentState.LastStderr = strings.Join(tail, "\n")
+				}
+				if strings.Contains(err.Error(), "timed out") {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Iteration %d timed out after %v (continuing)\n", i, cfg.IterTimeout)
+					// Record that this iteration timed out
+					agentState.TimeoutReason = "iteration"
+					_ = mgr.MergeUpdate(agentState)
+					// Reset timeout reason after recording (will be set to "total" if total timeout hit)
+					agentState.TimeoutReason = ""
+				} else {
+					fmt.Fprintf(cfg.Output, "\n[swarm] Agent error (continuing): %v\n", err)
+				}
+				runner.PrintStderrTail(cfg.Output)
+				stateMu.Unlock()
+			default:
+				stateMu.Lock()
+				agentState.SuccessfulIters++
+				lastIterFailed = false
+				stateMu.Unlock()
+			}
+
+			// Capture final usage stats from this iteration and accumulate
+			finalStats := runner.UsageStats()
+			cumulativeInputTokens += finalStats.InputTokens
+			cumulativeOutputTokens += finalStats.OutputTokens
+			if finalStats.TotalCostUSD > 0 {
+				cumulativeCostUSD += finalStats.TotalCostUSD
 			}
-			stateMu.Unlock()
-		} else {
 			stateMu.Lock()
-			agentState.SuccessfulIters++
+			agentState.InputTokens = cumulativeInputTokens
+			agentState.OutputTokens = cumulativeOutputTokens
+			// Attribute the attempt's usage to the model the CLI reported
+			// using, which differs from the requested one after a fallback
+			usageModel := finalStats.Model
+			if usageModel == "" {
+				usageModel = modelForConfig
+			}
+			agentState.AddModelUsage(usageModel, finalStats.InputTokens, finalStats.OutputTokens,
+				cfg.Config.UsageCost(usageModel, finalStats.InputTokens, finalStats.OutputTokens, finalStats.TotalCostUSD))
+			if finalStats.CurrentTask != "" {
+				agentState.CurrentTask = finalStats.CurrentTask
+			}
+			agentState.RecordExitReport(runner.ExitReport())
+			if cumulativeCostUSD > 0 {
+				agentState.TotalCost = baseCostUSD + cumulativeCostUSD
+			} else if cfg.Config != nil {
+				pricing := cfg.Config.GetPricing(agentState.Model)
+				agentState.TotalCost = baseCostUSD + pricing.CalculateCost(agentState.InputTokens-baseInputTokens, agentState.OutputTokens-baseOutputTokens)
+			}
+			_ = mgr.MergeUpdate(agentState)
 			stateMu.Unlock()
+			notify.CheckCostAlert(cfg.Config, agentState.WorkingDir, agentState, cfg.Output)
+
+			// The history record covers all attempts of the iteration
+			prev := record
+			record = runner.IterationRecord(i, iterStartedAt, runErr, cfg.Config)
+			record.InputTokens += prev.InputTokens
+			record.OutputTokens += prev.OutputTokens
+			record.CostUSD += prev.CostUSD
+
+			if !retry {
+				break
+			}
 		}
-		
-		// Capture final usage stats from this iteration and accumulate
-		finalStats := runner.UsageStats()
-		cumulativeInputTokens += finalStats.InputTokens
-		cumulativeOutputTokens += finalStats.OutputTokens
-		if finalStats.TotalCostUSD > 0 {
-			cumulativeCostUSD += finalStats.TotalCostUSD
-		}
+
+		stoppedSlow := stopSlowWatch() && cfg.Config.FailSlowIterations()
+		_ = budgetGuard.Stop()
+		cancelIter()
 		stateMu.Lock()
-		agentState.InputTokens = cumulativeInputTokens
-		agentState.OutputTokens = cumulativeOutputTokens
-		if finalStats.CurrentTask != "" {
-			agentState.CurrentTask = finalStats.CurrentTask
-		}
-		if cumulativeCostUSD > 0 {
-			agentState.TotalCost = cumulativeCostUSD
-		} else if cfg.Config != nil {
-			pricing := cfg.Config.GetPricing(agentState.Model)
-			agentState.TotalCost = pricing.CalculateCost(agentState.InputTokens, agentState.OutputTokens)
-		}
+		agentState.FinishIteration(time.Now(), !stoppedSlow)
 		_ = mgr.MergeUpdate(agentState)
+		if err := mgr.AppendHistory(agentState.ID, record); err != nil {
+			fmt.Fprintf(cfg.Output, "[swarm] Warning: failed to record iteration history: %v\n", err)
+		}
+		iterFailed := lastIterFailed
+		violated := lastIterFailed && agentState.LastErrorClass == agent.FailurePathViolation
 		stateMu.Unlock()
+		if _, err := mgr.SampleTodoQueue(cfg.Config.TodoQueueDirs(agentState.WorkingDir)); err != nil {
+			fmt.Fprintf(cfg.Output, "[swarm] Warning: failed to sample todo queue: %v\n", err)
+		}
+
+		if cfg.AfterIteration != nil {
+			cfg.AfterIteration(i, iterFailed)
+		}
+
+		// An agent that edited files outside its allowed paths isn't trusted
+		// with more iterations
+		if violated {
+			fmt.Fprintln(cfg.Output, "\n[swarm] Stopping agent after a path violation")
+			return result, nil
+		}
 
 		// Check for signals and total timeout
 		select {
@@ -339,3 +652,17 @@ func RunLoop(cfg LoopConfig) (*LoopResult, error) {
 	fmt.Fprintf(cfg.Output, "\n[swarm] Run completed (%d iterations)\n", currentIter)
 	return result, nil
 }
+
+// ContextOverflowMitigation picks how to retry an attempt that overflowed the
+// agent's context window: the compact prompt variant if there is one,
+// otherwise a fresh session if the command continues an earlier one. It
+// returns an empty mitigation if neither applies.
+func ContextOverflowMitigation(promptContent, compactPromptContent string, command config.CommandConfig) (mitigation, retryPrompt string, retryCommand config.CommandConfig) {
+	if compactPromptContent != "" {
+		return "compact prompt", compactPromptContent, command
+	}
+	if fresh, ok := command.WithoutSessionArgs(); ok {
+		return "session reset", promptContent, fresh
+	}
+	return "", promptContent, command
+}
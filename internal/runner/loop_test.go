@@ -2,11 +2,16 @@ package runner
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mj1618/swarm-cli/internal/agent"
 	"github.com/mj1618/swarm-cli/internal/config"
 	"github.com/mj1618/swarm-cli/internal/state"
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
 )
 
 func TestLoopConfigStructure(t *testing.T) {
@@ -408,3 +413,191 @@ func TestRunLoopNegativeStartingIteration(t *testing.T) {
 		t.Errorf("RunLoop returned error: %v", err)
 	}
 }
+
+// TestRunLoopStopsSlowIteration tests that slow_iteration_action = "fail"
+// stops an iteration running far longer than the agent's median.
+func TestRunLoopStopsSlowIteration(t *testing.T) {
+	mgr, err := state.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	agentState := &state.AgentState{
+		ID:                 state.GenerateID(),
+		Name:               "test-slow-agent",
+		PID:                12345,
+		Prompt:             "test-prompt",
+		Model:              "test-model",
+		StartedAt:          time.Now(),
+		Iterations:         1,
+		Status:             "running",
+		IterationDurations: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+	}
+	if err := mgr.Register(agentState); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	defer mgr.Remove(agentState.ID)
+
+	appConfig := config.DefaultConfig()
+	appConfig.SlowIterationAction = config.SlowIterationFail
+
+	var buf bytes.Buffer
+	started := time.Now()
+	_, err = RunLoop(LoopConfig{
+		Manager:       mgr,
+		AgentState:    agentState,
+		PromptContent: "test prompt",
+		Command: config.CommandConfig{
+			Executable: "sleep",
+			Args:       []string{"10"},
+		},
+		Config:            appConfig,
+		Output:            &buf,
+		StartingIteration: 1,
+	})
+	if err != nil {
+		t.Fatalf("RunLoop returned error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("slow iteration was not stopped (took %v)", elapsed)
+	}
+	if !strings.Contains(buf.String(), "is slow") {
+		t.Errorf("expected a slow iteration warning, got:\n%s", buf.String())
+	}
+
+	updated, err := mgr.Get(agentState.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if updated.FailedIters != 1 || updated.LastErrorClass != agent.FailureTimeout {
+		t.Errorf("expected one failed iteration classed as a timeout, got %d, %q", updated.FailedIters, updated.LastErrorClass)
+	}
+	if len(updated.IterationDurations) != 3 || updated.SlowIteration {
+		t.Errorf("a stopped slow iteration should not be recorded, got %v (slow=%v)", updated.IterationDurations, updated.SlowIteration)
+	}
+}
+
+// TestRunLoopContinuedUsage tests that a continued agent's usage totals
+// build on the usage of the iterations it ran before.
+func TestRunLoopContinuedUsage(t *testing.T) {
+	mgr, err := state.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	agentState := &state.AgentState{
+		ID:           state.GenerateID(),
+		Name:         "test-continued-agent",
+		PID:          12345,
+		Prompt:       "test-prompt",
+		Model:        "test-model",
+		StartedAt:    time.Now(),
+		Iterations:   3,
+		CurrentIter:  2,
+		Status:       "running",
+		InputTokens:  200,
+		OutputTokens: 20,
+		TotalCost:    1.0,
+	}
+	if err := mgr.Register(agentState); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	defer mgr.Remove(agentState.ID)
+
+	var buf bytes.Buffer
+	_, err = RunLoop(LoopConfig{
+		Manager:       mgr,
+		AgentState:    agentState,
+		PromptContent: "test prompt",
+		Command: config.CommandConfig{
+			Executable: "sh",
+			Args:       []string{"-c", `echo '{"type":"result","subtype":"success","result":"done","total_cost_usd":0.5,"usage":{"input_tokens":100,"output_tokens":10}}'`},
+		},
+		Config:            config.DefaultConfig(),
+		Output:            &buf,
+		StartingIteration: 3,
+	})
+	if err != nil {
+		t.Fatalf("RunLoop returned error: %v", err)
+	}
+
+	updated, err := mgr.Get(agentState.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if updated.InputTokens != 300 || updated.OutputTokens != 30 {
+		t.Errorf("tokens = %d in / %d out, want 300 / 30", updated.InputTokens, updated.OutputTokens)
+	}
+	if updated.TotalCost != 1.5 {
+		t.Errorf("TotalCost = %v, want 1.5", updated.TotalCost)
+	}
+}
+
+func TestRunLoopMaxPending(t *testing.T) {
+	oldInterval := todoqueue.PollInterval
+	todoqueue.PollInterval = 10 * time.Millisecond
+	defer func() { todoqueue.PollInterval = oldInterval }()
+
+	todoDir := t.TempDir()
+	for _, name := range []string{"a.todo.md", "b.todo.md"} {
+		if err := os.WriteFile(filepath.Join(todoDir, name), []byte("task"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mgr, err := state.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	agentState := &state.AgentState{
+		ID:          state.GenerateID(),
+		Name:        "test-planner",
+		PID:         12345,
+		Prompt:      "test-prompt",
+		Model:       "test-model",
+		StartedAt:   time.Now(),
+		Iterations:  1,
+		CurrentIter: 0,
+		Status:      "running",
+	}
+	if err := mgr.Register(agentState); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	defer mgr.Remove(agentState.ID)
+
+	// A doer claims a task while the planner waits
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.Rename(filepath.Join(todoDir, "a.todo.md"), filepath.Join(todoDir, "a.processing.md"))
+	}()
+
+	var buf bytes.Buffer
+	_, err = RunLoop(LoopConfig{
+		Manager:       mgr,
+		AgentState:    agentState,
+		PromptContent: "test prompt",
+		Command: config.CommandConfig{
+			Executable: "sh",
+			Args:       []string{"-c", "echo planned"},
+		},
+		Config:     config.DefaultConfig(),
+		Output:     &buf,
+		MaxPending: 1,
+		TodoDir:    todoDir,
+	})
+	if err != nil {
+		t.Fatalf("RunLoop returned error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "2 tasks pending (max_pending 1), waiting for doers to catch up") {
+		t.Errorf("expected the planner to wait, output:\n%s", output)
+	}
+	if !strings.Contains(output, "Todo queue has room, continuing") {
+		t.Errorf("expected the planner to continue, output:\n%s", output)
+	}
+	if agentState.SuccessfulIters != 1 {
+		t.Errorf("SuccessfulIters = %d, want 1", agentState.SuccessfulIters)
+	}
+}
@@ -3,14 +3,16 @@ package scope
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Scope represents whether operations are scoped to the current project or globally.
 type Scope int
 
 const (
-	// ScopeProject scopes operations to the current working directory.
-	// - Lists only agents started in this directory
+	// ScopeProject scopes operations to the current project.
+	// - Lists only agents started inside the current git repository
+	//   (or in this directory when outside a repository)
 	// - Uses ./swarm/prompts/ for prompts
 	ScopeProject Scope = iota
 
@@ -60,3 +62,29 @@ func ProjectPromptsDir() string {
 func CurrentWorkingDir() (string, error) {
 	return os.Getwd()
 }
+
+// ProjectRoot returns the root of the git repository containing dir, found by
+// walking up to the nearest directory with a .git entry (a directory, or a file
+// for worktrees and submodules). Returns ok=false if dir is not inside a repository.
+func ProjectRoot(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Contains reports whether dir is root or a directory inside it.
+func Contains(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
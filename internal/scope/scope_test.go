@@ -0,0 +1,50 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectRoot(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "frontend", "src")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	root, ok := ProjectRoot(sub)
+	if !ok || root != repo {
+		t.Errorf("ProjectRoot(%s) = %q, %v; want %q, true", sub, root, ok, repo)
+	}
+
+	// Worktrees and submodules use a .git file
+	wt := filepath.Join(t.TempDir(), "wt")
+	os.MkdirAll(wt, 0755)
+	os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: /elsewhere\n"), 0644)
+	if root, ok := ProjectRoot(wt); !ok || root != wt {
+		t.Errorf("ProjectRoot(%s) = %q, %v; want %q, true", wt, root, ok, wt)
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		root, dir string
+		want      bool
+	}{
+		{"/repo", "/repo", true},
+		{"/repo", "/repo/frontend", true},
+		{"/repo", "/repo-other", false},
+		{"/repo", "/", false},
+		{"/repo/frontend", "/repo", false},
+		{"/", "/anything", true},
+	}
+	for _, tt := range tests {
+		if got := Contains(tt.root, tt.dir); got != tt.want {
+			t.Errorf("Contains(%q, %q) = %v, want %v", tt.root, tt.dir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package scratch manages agents' scratch directories: a temp directory per
+// agent, exported as SWARM_SCRATCH_DIR, for the throwaway files (analysis
+// notes, intermediate output) agents would otherwise leave in the repo.
+package scratch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EnvVar is the environment variable agents find their scratch dir in.
+const EnvVar = "SWARM_SCRATCH_DIR"
+
+// Root returns the directory scratch dirs are created in (~/.swarm/scratch).
+func Root() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".swarm", "scratch"), nil
+}
+
+// Path returns the scratch dir of an agent, whether or not it exists.
+func Path(agentID string) (string, error) {
+	root, err := Root()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, agentID), nil
+}
+
+// Create creates the scratch dir of an agent, keeping its contents if it
+// already exists (e.g. for an agent continued with the same ID).
+func Create(agentID string) (string, error) {
+	dir, err := Path(agentID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create scratch dir: %w", err)
+	}
+	// The agent is running again, so the dir is no longer up for pruning
+	os.Remove(releasedPath(dir))
+	return dir, nil
+}
+
+// Env returns the environment variable pointing an agent at dir.
+func Env(dir string) string {
+	return EnvVar + "=" + dir
+}
+
+// releasedPath is the marker next to a scratch dir recording when its agent
+// terminated. Only released dirs are pruned, so a running agent's dir is
+// never removed however long it runs.
+func releasedPath(dir string) string {
+	return dir + releasedSuffix
+}
+
+const releasedSuffix = ".released"
+
+// Release is called when an agent terminates. With no retention its scratch
+// dir is removed; otherwise it's kept for Prune to remove once retention has
+// passed.
+func Release(agentID string, retention time.Duration) error {
+	if retention <= 0 {
+		return Remove(agentID)
+	}
+	dir, err := Path(agentID)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(releasedPath(dir), nil, 0600)
+}
+
+// Remove removes the scratch dir of an agent.
+func Remove(agentID string) error {
+	dir, err := Path(agentID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.Remove(releasedPath(dir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Prune removes the scratch dirs released more than retention ago and
+// returns how many were removed.
+func Prune(retention time.Duration) (int, error) {
+	root, err := Root()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-retention)
+	removed := 0
+	for _, entry := range entries {
+		agentID, ok := strings.CutSuffix(entry.Name(), releasedSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := Remove(agentID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Finish releases the scratch dir of a terminated agent and, with a
+// retention, prunes the scratch dirs whose retention has passed.
+func Finish(agentID string, retention time.Duration) error {
+	if err := Release(agentID, retention); err != nil {
+		return err
+	}
+	if retention <= 0 {
+		return nil
+	}
+	_, err := Prune(retention)
+	return err
+}
@@ -0,0 +1,85 @@
+package scratch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateRelease(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	dir, err := Create("abc123")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Creating it again keeps its contents
+	if _, err := Create("abc123"); err != nil {
+		t.Fatalf("Create() again error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.md")); err != nil {
+		t.Errorf("expected notes.md to be kept: %v", err)
+	}
+
+	if err := Release("abc123", time.Hour); err != nil {
+		t.Fatalf("Release() with retention error = %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("expected scratch dir to be retained: %v", err)
+	}
+
+	if err := Release("abc123", 0); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected scratch dir to be removed, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, id := range []string{"expired", "running", "recent"} {
+		if _, err := Create(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"expired", "recent"} {
+		if err := Release(id, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expired, _ := Path("expired")
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(expired+releasedSuffix, old, old); err != nil {
+		t.Fatal(err)
+	}
+	// A running agent's dir isn't pruned, however old
+	running, _ := Path("running")
+	if err := os.Chtimes(running, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(time.Hour)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune() removed %d, want 1", removed)
+	}
+	for id, want := range map[string]bool{"expired": false, "running": true, "recent": true} {
+		dir, _ := Path(id)
+		_, err := os.Stat(dir)
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", id, exists, want)
+		}
+	}
+	if _, err := os.Stat(expired + releasedSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected the released marker to be removed, got %v", err)
+	}
+}
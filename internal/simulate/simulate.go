@@ -0,0 +1,262 @@
+// Package simulate plays scripted agent runs: it writes the synthetic
+// stream-json events a real agent CLI would, with delays, token usage, and
+// failures, so pipelines, budgets, watchdogs, and the TUI can be tested and
+// demoed without calling a model.
+package simulate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvVar holds the script of a simulated run: a path to a script file, or
+// DefaultScriptName. Set by the hidden --simulate flag and inherited by
+// detached agents and pipeline tasks.
+const EnvVar = "SWARM_SIMULATE"
+
+// DefaultScriptName selects the built-in script.
+const DefaultScriptName = "default"
+
+// Script describes what every simulated iteration does.
+type Script struct {
+	// Steps are played in order
+	Steps []Step `yaml:"steps"`
+
+	// StepDelay is the pause before each step without its own delay, e.g.
+	// "500ms" (empty = none)
+	StepDelay string `yaml:"step_delay"`
+
+	// Result is the final result text of a successful iteration
+	Result string `yaml:"result"`
+
+	// CostUSD is the cost the result event reports (0 = let swarm price the
+	// tokens)
+	CostUSD float64 `yaml:"cost_usd"`
+
+	// FailIterations fail at the end instead of succeeding, and FailRate is
+	// the chance any other iteration does. Failure is the error they report,
+	// e.g. "API Error: 429 rate_limit_error" to simulate rate limiting.
+	FailIterations []int   `yaml:"fail_iterations"`
+	FailRate       float64 `yaml:"fail_rate"`
+	Failure        string  `yaml:"failure"`
+
+	// HangIterations stop producing output after their steps and never
+	// exit, to exercise timeouts and watchdogs
+	HangIterations []int `yaml:"hang_iterations"`
+}
+
+// Step is one thing a simulated agent does. A step with several fields
+// waits, then says its text, calls its tool, and reports its tokens in one
+// assistant message.
+type Step struct {
+	Delay        string                 `yaml:"delay"`
+	Text         string                 `yaml:"text"`
+	Tool         string                 `yaml:"tool"`
+	Input        map[string]interface{} `yaml:"input"`
+	InputTokens  int64                  `yaml:"input_tokens"`
+	OutputTokens int64                  `yaml:"output_tokens"`
+
+	// Fail ends the iteration with this error
+	Fail string `yaml:"fail"`
+
+	// Hang stops output here until the process is killed
+	Hang bool `yaml:"hang"`
+}
+
+// DefaultScript is a short, cheap-looking iteration that reads, edits, and
+// tests some code.
+func DefaultScript() *Script {
+	return &Script{
+		StepDelay: "1s",
+		Steps: []Step{
+			{Text: "Reading the task", InputTokens: 1200, OutputTokens: 40},
+			{Tool: "Read", Input: map[string]interface{}{"file_path": "README.md"}, InputTokens: 2400, OutputTokens: 30},
+			{Text: "Making the change", Tool: "Edit", Input: map[string]interface{}{"file_path": "main.go"}, InputTokens: 3100, OutputTokens: 420},
+			{Tool: "Bash", Input: map[string]interface{}{"command": "go test ./..."}, InputTokens: 3600, OutputTokens: 25},
+			{Text: "Tests pass", InputTokens: 3900, OutputTokens: 60},
+		},
+		Result:  "Simulated iteration complete",
+		CostUSD: 0.02,
+		Failure: "simulated failure",
+	}
+}
+
+// Load reads the script named by EnvVar's convention: DefaultScriptName or
+// a YAML file.
+func Load(name string) (*Script, error) {
+	if name == "" || name == DefaultScriptName {
+		return DefaultScript(), nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulation script: %w", err)
+	}
+	script := &Script{Failure: "simulated failure"}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(script); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid simulation script %s: %w", name, err)
+	}
+	if err := script.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid simulation script %s: %w", name, err)
+	}
+	return script, nil
+}
+
+// Validate checks the script's durations and rates.
+func (s *Script) Validate() error {
+	if _, err := parseDelay(s.StepDelay); err != nil {
+		return fmt.Errorf("step_delay: %w", err)
+	}
+	for i, step := range s.Steps {
+		if _, err := parseDelay(step.Delay); err != nil {
+			return fmt.Errorf("step %d: delay: %w", i+1, err)
+		}
+	}
+	if s.FailRate < 0 || s.FailRate > 1 {
+		return fmt.Errorf("fail_rate must be between 0 and 1, got %g", s.FailRate)
+	}
+	return nil
+}
+
+func parseDelay(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// Run is one simulated iteration.
+type Run struct {
+	Script    *Script
+	Model     string
+	Prompt    string
+	Iteration int // SWARM_ITERATION, 0 if unknown
+
+	// Sleep waits between steps (time.Sleep if nil)
+	Sleep func(time.Duration)
+}
+
+// Play writes the iteration's events to w. It returns an error if the
+// iteration fails, after writing the failure's result event. A hanging
+// iteration never returns.
+func (r *Run) Play(w io.Writer) error {
+	sleep := r.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	enc := json.NewEncoder(w)
+	emit := func(event map[string]interface{}) {
+		_ = enc.Encode(event)
+	}
+	started := time.Now()
+	s := r.Script
+
+	emit(map[string]interface{}{"type": "system", "subtype": "init", "model": r.Model, "session_id": "simulated"})
+	if prompt := firstLine(r.Prompt); prompt != "" {
+		emit(map[string]interface{}{"type": "user", "message": map[string]interface{}{
+			"role": "user", "content": []map[string]interface{}{{"type": "text", "text": prompt}},
+		}})
+	}
+
+	stepDelay, _ := parseDelay(s.StepDelay)
+	for _, step := range s.Steps {
+		delay, _ := parseDelay(step.Delay)
+		if step.Delay == "" {
+			delay = stepDelay
+		}
+		sleep(delay)
+
+		if step.Hang {
+			hang()
+		}
+		if step.Fail != "" {
+			return r.fail(emit, step.Fail, started)
+		}
+
+		var content []map[string]interface{}
+		if step.Text != "" {
+			content = append(content, map[string]interface{}{"type": "text", "text": step.Text})
+		}
+		if step.Tool != "" {
+			content = append(content, map[string]interface{}{"type": "tool_use", "name": step.Tool, "input": step.Input})
+		}
+		if len(content) == 0 && step.InputTokens == 0 && step.OutputTokens == 0 {
+			continue
+		}
+		message := map[string]interface{}{"role": "assistant", "model": r.Model, "content": content}
+		if step.InputTokens > 0 || step.OutputTokens > 0 {
+			message["usage"] = map[string]int64{"input_tokens": step.InputTokens, "output_tokens": step.OutputTokens}
+		}
+		emit(map[string]interface{}{"type": "assistant", "message": message})
+	}
+
+	if slices.Contains(s.HangIterations, r.Iteration) {
+		hang()
+	}
+	if slices.Contains(s.FailIterations, r.Iteration) || (s.FailRate > 0 && rand.Float64() < s.FailRate) {
+		return r.fail(emit, s.Failure, started)
+	}
+
+	result := map[string]interface{}{
+		"type":        "result",
+		"subtype":     "success",
+		"is_error":    false,
+		"result":      s.Result,
+		"duration_ms": time.Since(started).Milliseconds(),
+	}
+	if s.CostUSD > 0 {
+		result["total_cost_usd"] = s.CostUSD
+	}
+	emit(result)
+	return nil
+}
+
+// fail writes an error result event and returns the failure.
+func (r *Run) fail(emit func(map[string]interface{}), message string, started time.Time) error {
+	event := map[string]interface{}{
+		"type":        "result",
+		"subtype":     "error_during_execution",
+		"is_error":    true,
+		"result":      message,
+		"duration_ms": time.Since(started).Milliseconds(),
+	}
+	if r.Script.CostUSD > 0 {
+		event["total_cost_usd"] = r.Script.CostUSD
+	}
+	emit(event)
+	return errors.New(message)
+}
+
+// hang blocks until the process is killed. (An empty select would be
+// reported as a deadlock, since nothing else runs.)
+func hang() {
+	for {
+		time.Sleep(time.Hour)
+	}
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 200 {
+		s = s[:200]
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package simulate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/logparser"
+)
+
+func TestPlay(t *testing.T) {
+	var slept time.Duration
+	script := &Script{
+		StepDelay: "1s",
+		Steps: []Step{
+			{Text: "Reading", InputTokens: 100, OutputTokens: 10},
+			{Tool: "Bash", Input: map[string]interface{}{"command": "go test ./..."}, Delay: "5s", InputTokens: 200, OutputTokens: 20},
+		},
+		Result:  "done",
+		CostUSD: 0.25,
+	}
+	run := &Run{Script: script, Model: "sim", Prompt: "fix it\nplease", Sleep: func(d time.Duration) { slept += d }}
+
+	var out bytes.Buffer
+	if err := run.Play(&out); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if slept != 6*time.Second {
+		t.Errorf("slept %v, want 6s", slept)
+	}
+
+	stats := logparser.ScanLogFile(&out)
+	if stats.InputTokens != 300 || stats.OutputTokens != 30 || stats.TotalCostUSD != 0.25 || stats.Model != "sim" {
+		t.Errorf("parsed usage = %+v, want 300 in, 30 out, $0.25, model sim", stats)
+	}
+}
+
+func TestPlayFailures(t *testing.T) {
+	script := &Script{
+		Steps:          []Step{{Text: "hi"}},
+		FailIterations: []int{2},
+		Failure:        "API Error: 429 rate_limit_error",
+	}
+	noSleep := func(time.Duration) {}
+
+	var out bytes.Buffer
+	if err := (&Run{Script: script, Iteration: 1, Sleep: noSleep}).Play(&out); err != nil {
+		t.Errorf("iteration 1 failed: %v", err)
+	}
+
+	out.Reset()
+	err := (&Run{Script: script, Iteration: 2, Sleep: noSleep}).Play(&out)
+	if err == nil || err.Error() != script.Failure {
+		t.Errorf("iteration 2 error = %v, want %q", err, script.Failure)
+	}
+	if !strings.Contains(out.String(), `"is_error":true`) {
+		t.Errorf("failed iteration should end with an error result, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	script.Steps = append(script.Steps, Step{Fail: "boom"}, Step{Text: "never"})
+	if err := (&Run{Script: script, Iteration: 1, Sleep: noSleep}).Play(&out); err == nil || err.Error() != "boom" {
+		t.Errorf("fail step error = %v, want boom", err)
+	}
+	if strings.Contains(out.String(), "never") {
+		t.Error("steps after a fail step should not run")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if script, err := Load(DefaultScriptName); err != nil || len(script.Steps) == 0 {
+		t.Errorf("Load(default) = %v, %v", script, err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "script.yaml")
+	os.WriteFile(path, []byte("steps:\n  - text: hi\n    delay: 2s\nfail_rate: 0.5\n"), 0644)
+	script, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(script.Steps) != 1 || script.FailRate != 0.5 || script.Failure == "" {
+		t.Errorf("Load = %+v", script)
+	}
+
+	for name, content := range map[string]string{
+		"bad delay":     "steps:\n  - delay: soon\n",
+		"bad rate":      "fail_rate: 2\n",
+		"unknown field": "stepz: []\n",
+	} {
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: Load should fail", name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// alertsDirName is the directory (next to the lock file) holding fired alerts.
+const alertsDirName = "alerts"
+
+// CostAlertRecord is a cost alert that has fired for a project: the
+// cumulative cost of its agents crossed the alert threshold.
+type CostAlertRecord struct {
+	Project   string    `json:"project"`
+	Threshold float64   `json:"threshold_usd"`
+	CostUSD   float64   `json:"cost_usd"`
+	FiredAt   time.Time `json:"fired_at"`
+}
+
+// CheckCostAlert totals the cost of every agent in the manager's scope and
+// reports whether the total has just crossed threshold. It returns true only
+// once per crossing, however many agents check: the alert is recorded until
+// the total drops back below the threshold (e.g. after `swarm prune`) or
+// the threshold changes.
+func (m *Manager) CheckCostAlert(threshold float64) (*CostAlertRecord, bool, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, false, err
+	}
+	defer m.unlock(fl)
+
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, false, err
+	}
+	var ids []string
+	for id, entry := range idx.Agents {
+		if m.entryInScope(entry) {
+			ids = append(ids, id)
+		}
+	}
+	var total float64
+	for _, a := range m.loadAgents(ids) {
+		total += a.TotalCost
+	}
+
+	project := m.projectKey()
+	path := m.costAlertPath(project)
+	var fired CostAlertRecord
+	err = readJSONFile(path, &fired)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to read cost alert: %w", err)
+	}
+	alreadyFired := err == nil && fired.Threshold == threshold
+
+	if total < threshold {
+		if err == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, false, fmt.Errorf("failed to clear cost alert: %w", err)
+			}
+		}
+		return nil, false, nil
+	}
+	if alreadyFired {
+		return &fired, false, nil
+	}
+
+	record := CostAlertRecord{Project: project, Threshold: threshold, CostUSD: total, FiredAt: time.Now()}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create alerts directory: %w", err)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return nil, false, fmt.Errorf("failed to write cost alert: %w", err)
+	}
+	return &record, true, nil
+}
+
+// CostAlerts returns the fired cost alerts of projects in the manager's
+// scope, most recent first.
+func (m *Manager) CostAlerts() ([]CostAlertRecord, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	dir := filepath.Join(filepath.Dir(m.lockPath), alertsDirName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []CostAlertRecord
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "cost-") {
+			continue
+		}
+		var record CostAlertRecord
+		if err := readJSONFile(filepath.Join(dir, entry.Name()), &record); err != nil {
+			continue
+		}
+		if !m.inScope(record.Project) {
+			continue
+		}
+		alerts = append(alerts, record)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].FiredAt.After(alerts[j].FiredAt) })
+	return alerts, nil
+}
+
+// costAlertPath returns the file recording a fired cost alert for project.
+func (m *Manager) costAlertPath(project string) string {
+	return filepath.Join(filepath.Dir(m.lockPath), alertsDirName, "cost-"+CounterKey(project)+".json")
+}
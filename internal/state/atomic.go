@@ -0,0 +1,80 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// backupSuffix is appended to a state file's path for the copy of its previous contents.
+const backupSuffix = ".bak"
+
+// writeFileAtomic writes data to path through a temp file and rename, so a
+// crash mid-write never leaves a truncated file behind. The previous contents
+// are kept at path+".bak" for readJSONFile to fall back on.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Keep the current version as the backup. A hard link is cheap and leaves
+	// the old file in place until the rename replaces it. Best effort: some
+	// filesystems don't support links.
+	bakPath := path + backupSuffix
+	os.Remove(bakPath)
+	os.Link(path, bakPath)
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// readJSONFile reads and decodes a JSON state file. If the file can't be parsed
+// it falls back to the backup written by writeFileAtomic. Errors from reading
+// the primary file (including os.IsNotExist) are returned unchanged.
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	parseErr := json.Unmarshal(data, v)
+	if parseErr == nil {
+		return nil
+	}
+
+	bakData, err := os.ReadFile(path + backupSuffix)
+	if err != nil {
+		return parseErr
+	}
+	if err := json.Unmarshal(bakData, v); err != nil {
+		return parseErr
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %s is corrupt (%v), using backup\n", path, parseErr)
+	return nil
+}
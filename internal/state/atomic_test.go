@@ -0,0 +1,82 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomicKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+
+	if err := writeFileAtomic(path, []byte(`{"id":"v1"}`), 0644); err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte(`{"id":"v2"}`), 0644); err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"id":"v2"}` {
+		t.Errorf("file = %s, want v2", data)
+	}
+	bak, _ := os.ReadFile(path + backupSuffix)
+	if string(bak) != `{"id":"v1"}` {
+		t.Errorf("backup = %s, want v1", bak)
+	}
+
+	// No temp files should be left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("expected file and backup only, got %d entries", len(entries))
+	}
+}
+
+func TestReadJSONFileFallsBackToBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	os.WriteFile(path+backupSuffix, []byte(`{"id":"good"}`), 0644)
+	os.WriteFile(path, []byte(`{"id":"trunc`), 0644)
+
+	var agent AgentState
+	if err := readJSONFile(path, &agent); err != nil {
+		t.Fatalf("readJSONFile failed: %v", err)
+	}
+	if agent.ID != "good" {
+		t.Errorf("ID = %q, want %q", agent.ID, "good")
+	}
+}
+
+func TestReadJSONFileCorruptWithoutBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	os.WriteFile(path, []byte(`not json`), 0644)
+
+	var agent AgentState
+	if err := readJSONFile(path, &agent); err == nil {
+		t.Error("expected parse error without a backup")
+	}
+}
+
+func TestCorruptAgentFileRecoversFromBackup(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{ID: GenerateID(), StartedAt: time.Now(), Status: "running", CurrentIter: 1}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	agent.CurrentIter = 2
+	if err := mgr.Update(agent); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Simulate a torn write of the current version
+	os.WriteFile(mgr.agentPath(agent.ID), []byte(`{"id": "`), 0644)
+
+	got, err := mgr.Get(agent.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.CurrentIter != 1 {
+		t.Errorf("CurrentIter = %d, want 1 from backup", got.CurrentIter)
+	}
+}
@@ -0,0 +1,58 @@
+package state
+
+import "fmt"
+
+// Budget caps the cumulative cost and tokens of an agent or pipeline. A zero
+// field is no cap.
+type Budget struct {
+	USD    float64 `json:"usd,omitempty"`
+	Tokens int64   `json:"tokens,omitempty"`
+}
+
+// IsZero reports whether the budget caps nothing.
+func (b Budget) IsZero() bool {
+	return b.USD <= 0 && b.Tokens <= 0
+}
+
+// Used returns the fraction of the budget used by costUSD and tokens: the
+// larger of the cost and token fractions.
+func (b Budget) Used(costUSD float64, tokens int64) float64 {
+	used := 0.0
+	if b.USD > 0 {
+		used = costUSD / b.USD
+	}
+	if b.Tokens > 0 {
+		used = max(used, float64(tokens)/float64(b.Tokens))
+	}
+	return used
+}
+
+// Exceeded describes the cap reached by costUSD and tokens, or returns "" if
+// they're within the budget.
+func (b Budget) Exceeded(costUSD float64, tokens int64) string {
+	if b.USD > 0 && costUSD >= b.USD {
+		return fmt.Sprintf("budget reached ($%.2f of $%.2f)", costUSD, b.USD)
+	}
+	if b.Tokens > 0 && tokens >= b.Tokens {
+		return fmt.Sprintf("token budget reached (%d of %d tokens)", tokens, b.Tokens)
+	}
+	return ""
+}
+
+// BudgetUsed returns the fraction of its budget the agent has used, and
+// false if it has no budget.
+func (a *AgentState) BudgetUsed() (float64, bool) {
+	if a.Budget == nil || a.Budget.IsZero() {
+		return 0, false
+	}
+	return a.Budget.Used(a.TotalCost, a.InputTokens+a.OutputTokens), true
+}
+
+// BudgetExceeded describes the budget cap the agent has reached, or returns
+// "" if it has none or is within it.
+func (a *AgentState) BudgetExceeded() string {
+	if a.Budget == nil {
+		return ""
+	}
+	return a.Budget.Exceeded(a.TotalCost, a.InputTokens+a.OutputTokens)
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	b := Budget{USD: 10, Tokens: 1000}
+	if got := b.Used(2.5, 500); got != 0.5 {
+		t.Errorf("Used() = %v, want the larger fraction 0.5", got)
+	}
+	if reason := b.Exceeded(9.99, 999); reason != "" {
+		t.Errorf("Exceeded() = %q within the budget", reason)
+	}
+	if reason := b.Exceeded(10, 0); !strings.Contains(reason, "$10.00 of $10.00") {
+		t.Errorf("Exceeded() = %q, want the cost cap", reason)
+	}
+	if reason := b.Exceeded(0, 1200); !strings.Contains(reason, "1200 of 1000 tokens") {
+		t.Errorf("Exceeded() = %q, want the token cap", reason)
+	}
+
+	a := &AgentState{TotalCost: 3, InputTokens: 100, OutputTokens: 20}
+	if _, ok := a.BudgetUsed(); ok || a.BudgetExceeded() != "" {
+		t.Error("agent without a budget reported one")
+	}
+	a.Budget = &Budget{Tokens: 100}
+	if used, ok := a.BudgetUsed(); !ok || used != 1.2 {
+		t.Errorf("BudgetUsed() = %v, %v; want 1.2, true", used, ok)
+	}
+	if a.BudgetExceeded() == "" {
+		t.Error("expected the token budget to be exceeded")
+	}
+}
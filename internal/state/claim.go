@@ -0,0 +1,145 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// claimsDirName is the directory (next to the lock file) holding claims.
+const claimsDirName = "claims"
+
+// Claim records that an owner (e.g. an agent's run) has taken a key, so
+// parallel agents coordinating through `swarm claim` don't both act on it,
+// e.g. two planners creating the same task.
+type Claim struct {
+	Key       string     `json:"key"`
+	Owner     string     `json:"owner"`
+	Project   string     `json:"project,omitempty"`
+	ClaimedAt time.Time  `json:"claimed_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the claim has lapsed at now.
+func (c *Claim) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+// Claim atomically takes key for owner unless another owner holds it: a
+// compare-and-set across all swarm processes. It returns the claim now held
+// and whether owner holds it. Claiming a key owner already holds succeeds and
+// renews it. A ttl > 0 makes the claim lapse after ttl, so a crashed owner
+// doesn't hold a key forever. Keys are per project (or global with the global
+// scope).
+func (m *Manager) Claim(key, owner string, ttl time.Duration) (*Claim, bool, error) {
+	if key == "" || owner == "" {
+		return nil, false, fmt.Errorf("claim key and owner must not be empty")
+	}
+
+	fl, err := m.lock()
+	if err != nil {
+		return nil, false, err
+	}
+	defer m.unlock(fl)
+
+	now := time.Now()
+	project := m.projectKey()
+	path := m.claimPath(project, key)
+
+	var existing Claim
+	err = readJSONFile(path, &existing)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to read claim: %w", err)
+	}
+	if err == nil && existing.Owner != owner && !existing.Expired(now) {
+		return &existing, false, nil
+	}
+
+	claim := Claim{Key: key, Owner: owner, Project: project, ClaimedAt: now}
+	if err == nil && existing.Owner == owner && !existing.Expired(now) {
+		claim.ClaimedAt = existing.ClaimedAt
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		claim.ExpiresAt = &expires
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create claims directory: %w", err)
+	}
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return nil, false, fmt.Errorf("failed to write claim: %w", err)
+	}
+	return &claim, true, nil
+}
+
+// ReleaseClaim gives up owner's claim on key. Releasing a key that isn't
+// claimed (or has lapsed) is not an error; releasing another owner's claim is.
+func (m *Manager) ReleaseClaim(key, owner string) error {
+	fl, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock(fl)
+
+	path := m.claimPath(m.projectKey(), key)
+	var existing Claim
+	if err := readJSONFile(path, &existing); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read claim: %w", err)
+	}
+	if existing.Owner != owner && !existing.Expired(time.Now()) {
+		return fmt.Errorf("%q is claimed by %s", key, existing.Owner)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release claim: %w", err)
+	}
+	return nil
+}
+
+// Claims returns the unexpired claims of projects in the manager's scope,
+// sorted by key.
+func (m *Manager) Claims() ([]Claim, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	dir := filepath.Join(filepath.Dir(m.lockPath), claimsDirName)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var claims []Claim
+	for _, entry := range entries {
+		var claim Claim
+		if err := readJSONFile(filepath.Join(dir, entry.Name()), &claim); err != nil {
+			continue
+		}
+		if claim.Expired(now) || !m.inScope(claim.Project) {
+			continue
+		}
+		claims = append(claims, claim)
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].Key < claims[j].Key })
+	return claims, nil
+}
+
+// claimPath returns the file recording the claim on key in project.
+func (m *Manager) claimPath(project, key string) string {
+	return filepath.Join(filepath.Dir(m.lockPath), claimsDirName, CounterKey(project, key)+".json")
+}
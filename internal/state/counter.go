@@ -0,0 +1,99 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// countersDirName is the directory (next to the lock file) holding shared counters.
+const countersDirName = "counters"
+
+// counterState is the on-disk format of a shared counter.
+type counterState struct {
+	Claimed int `json:"claimed"`
+}
+
+// Counter is a cross-process counter stored alongside agent state. Parallel
+// pipeline instances use it to share one iteration budget, e.g. "run the DAG
+// 50 times total across 5 instances".
+type Counter struct {
+	mgr   *Manager
+	path  string
+	limit int
+}
+
+// CounterKey builds a counter key from its parts, e.g. a working directory and
+// pipeline name. The key is hashed so it is always a safe file name.
+func CounterKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Counter returns the shared counter for key, which hands out at most limit claims.
+func (m *Manager) Counter(key string, limit int) *Counter {
+	return &Counter{
+		mgr:   m,
+		path:  filepath.Join(filepath.Dir(m.lockPath), countersDirName, key+".json"),
+		limit: limit,
+	}
+}
+
+// Reset sets the counter back to zero claims.
+func (c *Counter) Reset() error {
+	fl, err := c.mgr.lock()
+	if err != nil {
+		return err
+	}
+	defer c.mgr.unlock(fl)
+
+	return c.save(counterState{})
+}
+
+// Claim reserves the next value of the counter. It returns the claimed value
+// (starting at 1) and false once the limit has been reached.
+func (c *Counter) Claim() (int, bool, error) {
+	fl, err := c.mgr.lock()
+	if err != nil {
+		return 0, false, err
+	}
+	defer c.mgr.unlock(fl)
+
+	var st counterState
+	if err := readJSONFile(c.path, &st); err != nil && !os.IsNotExist(err) {
+		return 0, false, fmt.Errorf("failed to read counter: %w", err)
+	}
+	if st.Claimed >= c.limit {
+		return st.Claimed, false, nil
+	}
+
+	st.Claimed++
+	if err := c.save(st); err != nil {
+		return 0, false, err
+	}
+	return st.Claimed, true, nil
+}
+
+// Limit returns the maximum number of claims.
+func (c *Counter) Limit() int {
+	return c.limit
+}
+
+// save writes the counter state. Callers must hold the lock.
+func (c *Counter) save(st counterState) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create counters directory: %w", err)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write counter: %w", err)
+	}
+	return nil
+}
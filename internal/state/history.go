@@ -0,0 +1,99 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// historyDirName is the directory (next to the lock file) holding agents'
+// iteration histories, one JSON Lines file per agent.
+const historyDirName = "history"
+
+// IterationRecord records one iteration of an agent.
+type IterationRecord struct {
+	Iteration    int       `json:"iteration"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Status       string    `json:"status"`                // succeeded, failed
+	ErrorClass   string    `json:"error_class,omitempty"` // Failure class of a failed iteration
+	Error        string    `json:"error,omitempty"`
+	Model        string    `json:"model,omitempty"` // Model the agent CLI reported using
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+	Summary      string    `json:"summary,omitempty"` // The agent's result, or its exit report's summary
+}
+
+// Duration returns how long the iteration ran.
+func (r IterationRecord) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// historyPath returns the path of the file holding an agent's iteration history.
+func (m *Manager) historyPath(id string) string {
+	return filepath.Join(filepath.Dir(m.lockPath), historyDirName, id+".jsonl")
+}
+
+// AppendHistory adds a finished iteration to an agent's history.
+func (m *Manager) AppendHistory(id string, record IterationRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	fl, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock(fl)
+
+	path := m.historyPath(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history of agent %s: %w", id, err)
+	}
+	return f.Close()
+}
+
+// History returns an agent's iteration history, oldest first. An agent that
+// hasn't finished an iteration has none. Unreadable lines, such as one cut
+// short by a crash, are skipped.
+func (m *Manager) History(id string) ([]IterationRecord, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	f, err := os.Open(m.historyPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []IterationRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record IterationRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
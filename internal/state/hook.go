@@ -0,0 +1,28 @@
+package state
+
+import "time"
+
+// HookRun is the outcome of one of an agent's hooks, after any retries.
+type HookRun struct {
+	Event      string    `json:"event"` // complete, failure, or timeout
+	Command    string    `json:"command"`
+	Attempts   int       `json:"attempts"`
+	Error      string    `json:"error,omitempty"` // Why the last attempt failed (empty = succeeded)
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Succeeded reports whether the hook's last attempt succeeded.
+func (r HookRun) Succeeded() bool {
+	return r.Error == ""
+}
+
+// FailedHooks returns the agent's hook runs that failed on every attempt.
+func (a *AgentState) FailedHooks() []HookRun {
+	var failed []HookRun
+	for _, r := range a.HookRuns {
+		if !r.Succeeded() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockTimeout is how long Lock waits for another process to release the state lock.
+var lockTimeout = 30 * time.Second
+
+// lockRetryInterval is how often Lock retries while the lock is held elsewhere.
+const lockRetryInterval = 50 * time.Millisecond
+
+// fileLock provides cross-process file locking. The holder's PID is written to
+// the lock file so waiters that time out can report who holds it. The kernel
+// releases the lock when its holder exits, so locks are never left stale.
+type fileLock struct {
+	path string
+	file *os.File
+}
+
+// newFileLock creates a new file lock.
+func newFileLock(path string) *fileLock {
+	return &fileLock{path: path}
+}
+
+// Lock acquires an exclusive lock on the file, waiting up to lockTimeout.
+func (fl *fileLock) Lock() error {
+	start := time.Now()
+	for {
+		acquired, err := fl.tryAcquire()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			fl.writeHolder()
+			return nil
+		}
+
+		if time.Since(start) >= lockTimeout {
+			if holder := readLockHolder(fl.path); holder > 0 {
+				return fmt.Errorf("timed out after %s waiting for state lock %s (held by pid %d)", lockTimeout, fl.path, holder)
+			}
+			return fmt.Errorf("timed out after %s waiting for state lock %s", lockTimeout, fl.path)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// tryAcquire makes a single non-blocking attempt to lock the file at fl.path.
+func (fl *fileLock) tryAcquire() (bool, error) {
+	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	acquired, err := tryLockFile(f)
+	if err != nil {
+		f.Close()
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if !acquired {
+		f.Close()
+		return false, nil
+	}
+
+	fl.file = f
+	return true, nil
+}
+
+// Unlock clears the recorded holder, releases the lock, and closes the file.
+func (fl *fileLock) Unlock() error {
+	if fl.file == nil {
+		return nil
+	}
+	// Clear the holder PID so a later crash by the next holder isn't confused with ours
+	fl.file.Truncate(0)
+	unlockFile(fl.file)
+	err := fl.file.Close()
+	fl.file = nil
+	return err
+}
+
+// writeHolder records the current process as the lock holder.
+func (fl *fileLock) writeHolder() {
+	if err := fl.file.Truncate(0); err != nil {
+		return
+	}
+	fl.file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+}
+
+// readLockHolder returns the PID recorded in a lock file, or 0 if none.
+func readLockHolder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
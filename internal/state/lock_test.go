@@ -0,0 +1,86 @@
+//go:build !windows
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// holdLock locks path from a separate open file, as another process would.
+func holdLock(t *testing.T, path string, holderPID int) *os.File {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("failed to open lock file: %v", err)
+	}
+	acquired, err := tryLockFile(f)
+	if err != nil || !acquired {
+		t.Fatalf("failed to hold lock: acquired=%v err=%v", acquired, err)
+	}
+	f.WriteAt([]byte(strconv.Itoa(holderPID)), 0)
+	t.Cleanup(func() {
+		unlockFile(f)
+		f.Close()
+	})
+	return f
+}
+
+func TestLockRecordsHolderPID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+	fl := newFileLock(path)
+	if err := fl.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	if holder := readLockHolder(path); holder != os.Getpid() {
+		t.Errorf("holder = %d, want %d", holder, os.Getpid())
+	}
+
+	if err := fl.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if holder := readLockHolder(path); holder != 0 {
+		t.Errorf("holder after unlock = %d, want 0", holder)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	defer func() { lockTimeout = old }()
+
+	path := filepath.Join(t.TempDir(), "state.lock")
+	holdLock(t, path, os.Getppid())
+
+	err := newFileLock(path).Lock()
+	if err == nil {
+		t.Fatal("expected timeout error while lock is held by a live process")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLockKeepsLockWithDeadHolderPID(t *testing.T) {
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	defer func() { lockTimeout = old }()
+
+	// The lock is held even though the recorded PID isn't running (e.g. a
+	// reused PID or another PID namespace), so it must not be broken
+	path := filepath.Join(t.TempDir(), "state.lock")
+	holdLock(t, path, 9999999) // Almost certainly doesn't exist
+
+	err := newFileLock(path).Lock()
+	if err == nil {
+		t.Fatal("expected timeout error while the lock is held")
+	}
+	if !strings.Contains(err.Error(), "held by pid 9999999") {
+		t.Errorf("expected the error to name the holder, got: %v", err)
+	}
+}
@@ -3,47 +3,26 @@
 package state
 
 import (
-	"fmt"
+	"errors"
 	"os"
 	"syscall"
 )
 
-// fileLock provides cross-process file locking using flock.
-type fileLock struct {
-	path string
-	file *os.File
-}
-
-// newFileLock creates a new file lock.
-func newFileLock(path string) *fileLock {
-	return &fileLock{path: path}
-}
-
-// Lock acquires an exclusive lock on the file.
-func (fl *fileLock) Lock() error {
-	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open lock file: %w", err)
+// tryLockFile attempts a non-blocking exclusive flock on f.
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == nil {
+		return true, nil
 	}
-	fl.file = f
-
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		f.Close()
-		return fmt.Errorf("failed to acquire lock: %w", err)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
 	}
-	return nil
+	return false, err
 }
 
-// Unlock releases the lock and closes the file.
-func (fl *fileLock) Unlock() error {
-	if fl.file == nil {
-		return nil
-	}
-	// Unlock and close
-	syscall.Flock(int(fl.file.Fd()), syscall.LOCK_UN)
-	err := fl.file.Close()
-	fl.file = nil
-	return err
+// unlockFile releases the flock on f.
+func unlockFile(f *os.File) {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
 }
 
 // isProcessRunning checks if a process with the given PID is still running.
@@ -3,66 +3,48 @@
 package state
 
 import (
-	"fmt"
+	"errors"
 	"os"
 
 	"golang.org/x/sys/windows"
 )
 
-// fileLock provides cross-process file locking using Windows LockFileEx.
-type fileLock struct {
-	path string
-	file *os.File
-}
-
-// newFileLock creates a new file lock.
-func newFileLock(path string) *fileLock {
-	return &fileLock{path: path}
-}
-
-// Lock acquires an exclusive lock on the file.
-func (fl *fileLock) Lock() error {
-	f, err := os.OpenFile(fl.path, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open lock file: %w", err)
-	}
-	fl.file = f
+// lockRegionOffsetHigh places the locked byte at 4GiB, well past the PID.
+const lockRegionOffsetHigh = 1
 
-	// Use LockFileEx with LOCKFILE_EXCLUSIVE_LOCK for exclusive locking
-	// Lock the entire file (offset 0, length MaxUint32)
-	ol := &windows.Overlapped{}
-	err = windows.LockFileEx(
+// tryLockFile attempts a non-blocking exclusive LockFileEx on f.
+func tryLockFile(f *os.File) (bool, error) {
+	// Lock a byte past the recorded PID (Windows locks are mandatory, so locking
+	// the PID itself would stop waiters from reading it). LOCKFILE_FAIL_IMMEDIATELY
+	// makes contention return an error instead of blocking.
+	ol := &windows.Overlapped{OffsetHigh: lockRegionOffsetHigh}
+	err := windows.LockFileEx(
 		windows.Handle(f.Fd()),
-		windows.LOCKFILE_EXCLUSIVE_LOCK,
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
 		0,
 		1,
 		0,
 		ol,
 	)
-	if err != nil {
-		f.Close()
-		return fmt.Errorf("failed to acquire lock: %w", err)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
 	}
-	return nil
+	return false, err
 }
 
-// Unlock releases the lock and closes the file.
-func (fl *fileLock) Unlock() error {
-	if fl.file == nil {
-		return nil
-	}
-	// Unlock the file
-	ol := &windows.Overlapped{}
+// unlockFile releases the LockFileEx lock on f.
+func unlockFile(f *os.File) {
+	ol := &windows.Overlapped{OffsetHigh: lockRegionOffsetHigh}
 	windows.UnlockFileEx(
-		windows.Handle(fl.file.Fd()),
+		windows.Handle(f.Fd()),
 		0,
 		1,
 		0,
 		ol,
 	)
-	err := fl.file.Close()
-	fl.file = nil
-	return err
 }
 
 // isProcessRunning checks if a process with the given PID is still running.
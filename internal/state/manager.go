@@ -8,9 +8,12 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/mj1618/swarm-cli/internal/exitreport"
+	"github.com/mj1618/swarm-cli/internal/process"
 	"github.com/mj1618/swarm-cli/internal/scope"
 )
 
@@ -19,8 +22,10 @@ type AgentState struct {
 	ID            string            `json:"id"`
 	Name          string            `json:"name,omitempty"`
 	ParentID      string            `json:"parent_id,omitempty"` // Parent agent ID for sub-agents
+	RunID         string            `json:"run_id,omitempty"`    // Pipeline run this agent executes
 	Labels        map[string]string `json:"labels,omitempty"`
 	PID           int               `json:"pid"`
+	ProcessStart  string            `json:"process_start,omitempty"` // Start of the PID's process (see process.StartTime), to detect PID reuse
 	Prompt        string            `json:"prompt"`
 	PromptContent string            `json:"prompt_content,omitempty"` // Stored for -s/--stdin so clone/replay can reconstruct
 	Model         string            `json:"model"`
@@ -31,20 +36,32 @@ type AgentState struct {
 	TerminateMode string            `json:"terminate_mode"`      // "", "immediate", "after_iteration"
 	Paused        bool              `json:"paused"`              // Whether agent loop is paused
 	PausedAt      *time.Time        `json:"paused_at,omitempty"` // When agent entered pause loop
+	ResumeAt      *time.Time        `json:"resume_at,omitempty"` // When a timed pause (`swarm stop --for`) ends
+	Approval      string            `json:"approval,omitempty"`  // What a pause awaiting `swarm approve` is for (`swarm stop --await-approval`)
 	LogFile       string            `json:"log_file"`
 	WorkingDir    string            `json:"working_dir"`              // Directory where agent was started
+	ProjectDir    string            `json:"project_dir,omitempty"`    // Compose project that started the agent in another repo (empty = WorkingDir)
+	ScratchDir    string            `json:"scratch_dir,omitempty"`    // SWARM_SCRATCH_DIR, removed on termination unless scratch_retention is set
 	EnvNames      []string          `json:"env_names,omitempty"`      // Environment variable names (values not stored for security)
+	Image         string            `json:"image,omitempty"`          // Container image the agent runs in (empty = host)
+	AgentArgs     []string          `json:"agent_args,omitempty"`     // Extra flags passed through to the agent CLI
+	ModelParams   map[string]string `json:"model_params,omitempty"`   // Model params (e.g. temperature) passed to the agent CLI
 	TimeoutAt     *time.Time        `json:"timeout_at,omitempty"`     // When total timeout will trigger
 	TimeoutReason string            `json:"timeout_reason,omitempty"` // "total" or "iteration" when terminated by timeout
 
 	// Termination tracking
 	TerminatedAt *time.Time `json:"terminated_at,omitempty"` // When agent stopped
-	ExitReason   string     `json:"exit_reason,omitempty"`   // completed, killed, signal, error
+	ExitReason   string     `json:"exit_reason,omitempty"`   // completed, killed, signal, crashed, or a failure class when the final iteration failed
 
 	// Iteration outcomes
-	SuccessfulIters int    `json:"successful_iterations"` // Iterations that completed without error
-	FailedIters     int    `json:"failed_iterations"`     // Iterations that errored
-	LastError       string `json:"last_error,omitempty"`  // Last error message if any
+	SuccessfulIters int    `json:"successful_iterations"`      // Iterations that completed without error
+	FailedIters     int    `json:"failed_iterations"`          // Iterations that errored
+	LastError       string `json:"last_error,omitempty"`       // Last error message if any
+	LastErrorClass  string `json:"last_error_class,omitempty"` // Failure class of LastError (auth_error, rate_limited, context_overflow, tool_denied, timeout, crash)
+	LastStderr      string `json:"last_stderr,omitempty"`      // Trailing stderr lines from the last failed iteration
+
+	// ContextOverflows counts attempts that overflowed the agent's context window
+	ContextOverflows int `json:"context_overflows,omitempty"`
 
 	// Token and cost tracking
 	InputTokens  int64   `json:"input_tokens"`           // Total input tokens used
@@ -52,22 +69,199 @@ type AgentState struct {
 	TotalCost    float64 `json:"total_cost_usd"`         // Total cost in USD
 	CurrentTask  string  `json:"current_task,omitempty"` // Last activity summary (e.g., "Read: auth.ts")
 
+	// Budget caps the agent's cost and tokens (budget_usd / budget_tokens);
+	// it's stopped after the iteration that reaches it
+	Budget *Budget `json:"budget,omitempty"`
+
+	// ModelUsage splits the token and cost totals by model (model -> usage),
+	// for agents whose model changed between iterations or fell back
+	ModelUsage map[string]*ModelUsage `json:"model_usage,omitempty"`
+
+	// LastIteration summarizes the most recently completed pipeline iteration
+	LastIteration *IterationSummary `json:"last_iteration,omitempty"`
+
+	// ExitReport is the swarm-exit.json the agent wrote in its most recent
+	// iteration that wrote one
+	ExitReport *exitreport.Report `json:"exit_report,omitempty"`
+
+	// Manifest is the versions of swarm, the agent CLI, and the repo the
+	// agent started with
+	Manifest *Manifest `json:"manifest,omitempty"`
+
 	// Hooks
 	OnComplete string `json:"on_complete,omitempty"` // Command to run when agent completes
+	OnFailure  string `json:"on_failure,omitempty"`  // Command to run when agent ends in failure
+	OnTimeout  string `json:"on_timeout,omitempty"`  // Command to run when agent times out
+
+	// HookRuns records how each hook that ran after termination went
+	HookRuns []HookRun `json:"hook_runs,omitempty"`
+
+	// Notes recorded by `swarm annotate`
+	Notes []Note `json:"notes,omitempty"`
+
+	// Kubernetes job backend
+	PodName   string `json:"pod_name,omitempty"`   // Pod running the current iteration
+	PodStatus string `json:"pod_status,omitempty"` // Pod phase (Pending, Running, Succeeded, Failed)
+
+	// MaxAgeExceededAt is when the agent was found running longer than
+	// max_agent_age and told to stop
+	MaxAgeExceededAt *time.Time `json:"max_age_exceeded_at,omitempty"`
+
+	// Liveness probe (health_cmd / --health-cmd)
+	Health          string     `json:"health,omitempty"`            // healthy or unhealthy (empty = no health check has run)
+	HealthError     string     `json:"health_error,omitempty"`      // Why the last health check failed
+	HealthCheckedAt *time.Time `json:"health_checked_at,omitempty"` // When the health check last ran
+
+	// Iteration durations, for flagging slow iterations (slow_iteration_factor)
+	IterationStartedAt *time.Time      `json:"iteration_started_at,omitempty"`   // When the current iteration started
+	IterationDurations []time.Duration `json:"iteration_durations_ns,omitempty"` // Durations of recent completed iterations, oldest first
+	SlowIteration      bool            `json:"slow_iteration,omitempty"`         // The current iteration has run much longer than the median
+}
+
+// StartedFrom reports whether the agent was started from dir, either running
+// there or started there by a compose task with a repo elsewhere.
+func (a *AgentState) StartedFrom(dir string) bool {
+	return a.WorkingDir == dir || a.ProjectDir == dir
+}
+
+// RecordExitReport records the exit report of an iteration. Its summary
+// becomes the agent's current task.
+func (a *AgentState) RecordExitReport(report *exitreport.Report) {
+	if report == nil {
+		return
+	}
+	a.ExitReport = report
+	if report.Summary != "" {
+		a.CurrentTask = report.Summary
+	}
+}
+
+// RecordHealth records the result of a health check run at checkedAt (a nil
+// err means the check passed).
+func (a *AgentState) RecordHealth(err error, checkedAt time.Time) {
+	a.HealthCheckedAt = &checkedAt
+	if err != nil {
+		a.Health = "unhealthy"
+		a.HealthError = err.Error()
+		return
+	}
+	a.Health = "healthy"
+	a.HealthError = ""
+}
+
+// Unhealthy reports whether the agent is running and its last health check
+// failed.
+func (a *AgentState) Unhealthy() bool {
+	return a.Status == "running" && a.Health == "unhealthy"
+}
+
+// iterationHistorySize is how many recent iteration durations are kept.
+const iterationHistorySize = 20
+
+// minIterationHistory is how many completed iterations are needed before an
+// iteration can be compared against the median.
+const minIterationHistory = 3
+
+// StartIteration records that an iteration started at now.
+func (a *AgentState) StartIteration(now time.Time) {
+	a.IterationStartedAt = &now
+	a.SlowIteration = false
+}
+
+// FinishIteration records the duration of the iteration started by
+// StartIteration. Iterations that were stopped for being slow should not be
+// recorded (record=false), so they don't drag the median up.
+func (a *AgentState) FinishIteration(now time.Time, record bool) {
+	if a.IterationStartedAt != nil && record {
+		a.IterationDurations = append(a.IterationDurations, now.Sub(*a.IterationStartedAt))
+		if n := len(a.IterationDurations); n > iterationHistorySize {
+			a.IterationDurations = a.IterationDurations[n-iterationHistorySize:]
+		}
+	}
+	a.IterationStartedAt = nil
+	a.SlowIteration = false
+}
+
+// MedianIterationDuration returns the median duration of recent iterations,
+// or 0 when too few have completed to tell.
+func (a *AgentState) MedianIterationDuration() time.Duration {
+	n := len(a.IterationDurations)
+	if n < minIterationHistory {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), a.IterationDurations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Slow reports whether the agent is running and its current iteration has
+// been flagged as slow.
+func (a *AgentState) Slow() bool {
+	return a.Status == "running" && a.SlowIteration
+}
+
+// Note is a timestamped annotation attached to an agent by a human.
+type Note struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// IterationSummary records the outcome of one pipeline iteration.
+type IterationSummary struct {
+	Iteration    int               `json:"iteration"`
+	Tasks        map[string]string `json:"tasks"` // task name -> status (succeeded, failed, skipped)
+	Succeeded    int               `json:"succeeded"`
+	Failed       int               `json:"failed"`
+	Skipped      int               `json:"skipped"`
+	InputTokens  int64             `json:"input_tokens"`
+	OutputTokens int64             `json:"output_tokens"`
+	Cost         float64           `json:"cost_usd"`
+	Duration     time.Duration     `json:"duration_ns"`
+	FinishedAt   time.Time         `json:"finished_at"`
 }
 
 // State holds all agent states.
+// It is the format of `swarm state export` backups and of the legacy state.json file.
 type State struct {
 	Agents map[string]*AgentState `json:"agents"`
 }
 
+// indexEntry is the summary of an agent kept in the index, so lookups by name,
+// parent, scope, and status don't need to read every agent file.
+type indexEntry struct {
+	Name         string    `json:"name,omitempty"`
+	ParentID     string    `json:"parent_id,omitempty"`
+	WorkingDir   string    `json:"working_dir"`
+	ProjectDir   string    `json:"project_dir,omitempty"`
+	Status       string    `json:"status"`
+	PID          int       `json:"pid"`
+	ProcessStart string    `json:"process_start,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// stateIndex maps agent IDs to their summaries.
+type stateIndex struct {
+	Agents map[string]indexEntry `json:"agents"`
+}
+
+// indexFileName is the name of the index file inside the agents directory.
+const indexFileName = "index.json"
+
 // Manager handles state persistence for agents.
+// Each agent is stored in its own file under agentsDir, so a runner updating
+// its own agent only rewrites that agent's file rather than the whole state.
 type Manager struct {
-	statePath  string
-	lockPath   string // Path to lock file for cross-process synchronization
-	scope      scope.Scope
-	workingDir string // Used for filtering when scope is ScopeProject
-	mu         sync.Mutex
+	agentsDir       string // Directory holding one <id>.json file per agent
+	indexPath       string // Path to the agent index
+	legacyStatePath string // Path to the old single-file state, migrated on startup
+	lockPath        string // Path to lock file for cross-process synchronization
+	scope           scope.Scope
+	workingDir      string // Used for filtering when scope is ScopeProject
+	projectRoot     string // Git repository root containing workingDir, if any
+	mu              sync.Mutex
 }
 
 // NewManager creates a new state manager.
@@ -98,11 +292,28 @@ func NewManagerWithScope(s scope.Scope, workingDir string) (*Manager, error) {
 		}
 	}
 
+	agentsDir := filepath.Join(swarmDir, "agents")
+	if err := os.MkdirAll(agentsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create agents directory: %w", err)
+	}
+
 	mgr := &Manager{
-		statePath:  filepath.Join(swarmDir, "state.json"),
-		lockPath:   filepath.Join(swarmDir, "state.lock"),
-		scope:      s,
-		workingDir: workingDir,
+		agentsDir:       agentsDir,
+		indexPath:       filepath.Join(agentsDir, indexFileName),
+		legacyStatePath: filepath.Join(swarmDir, "state.json"),
+		lockPath:        filepath.Join(swarmDir, "state.lock"),
+		scope:           s,
+		workingDir:      workingDir,
+	}
+	if s == scope.ScopeProject {
+		if root, ok := scope.ProjectRoot(workingDir); ok {
+			mgr.projectRoot = root
+		}
+	}
+
+	// Move agents out of the old single-file state
+	if err := mgr.migrateLegacyState(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to migrate state.json: %v\n", err)
 	}
 
 	// Clean up stale entries on startup
@@ -121,76 +332,72 @@ func GenerateID() string {
 	return hex.EncodeToString(b)
 }
 
-// copyAgentState creates a deep copy of an AgentState to avoid race conditions
-// when the returned pointer is used outside the lock.
-func copyAgentState(agent *AgentState) *AgentState {
-	if agent == nil {
-		return nil
-	}
-	copy := *agent
-
-	// Deep copy map
-	if agent.Labels != nil {
-		copy.Labels = make(map[string]string, len(agent.Labels))
-		for k, v := range agent.Labels {
-			copy.Labels[k] = v
-		}
+// Register adds a new agent to the state.
+// If the agent has a name that conflicts with a running agent, a number suffix is added.
+func (m *Manager) Register(agent *AgentState) error {
+	fl, err := m.lock()
+	if err != nil {
+		return err
 	}
+	defer m.unlock(fl)
 
-	// Deep copy slices
-	if agent.EnvNames != nil {
-		copy.EnvNames = make([]string, len(agent.EnvNames))
-		for i, v := range agent.EnvNames {
-			copy.EnvNames[i] = v
-		}
+	idx, err := m.loadIndex()
+	if err != nil {
+		return err
 	}
 
-	// Deep copy time pointers
-	if agent.PausedAt != nil {
-		t := *agent.PausedAt
-		copy.PausedAt = &t
-	}
-	if agent.TerminatedAt != nil {
-		t := *agent.TerminatedAt
-		copy.TerminatedAt = &t
-	}
-	if agent.TimeoutAt != nil {
-		t := *agent.TimeoutAt
-		copy.TimeoutAt = &t
+	// Ensure name uniqueness among running agents by appending number if needed
+	if agent.Name != "" {
+		agent.Name = m.uniqueName(idx, agent.Name)
 	}
 
-	return &copy
+	return m.putAgent(idx, agent)
 }
 
-// Register adds a new agent to the state.
-// If the agent has a name that conflicts with a running agent, a number suffix is added.
-func (m *Manager) Register(agent *AgentState) error {
+// Reserve registers agent unless a running agent in scope already has its
+// name, checking and registering under one lock so that concurrent starts
+// (e.g. two `swarm up -d`) can't both start the same agent. It returns the
+// running agent holding the name, or nil once agent is registered.
+// Reserve an agent before spawning its process, with PID 0 until SetPID
+// (cleanup gives an agent without a PID 30 seconds to get one).
+func (m *Manager) Reserve(agent *AgentState) (*AgentState, error) {
 	fl, err := m.lock()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
-		state = &State{Agents: make(map[string]*AgentState)}
+		return nil, err
 	}
 
-	// Ensure name uniqueness among running agents by appending number if needed
 	if agent.Name != "" {
-		agent.Name = m.uniqueName(state, agent.Name)
+		for id, entry := range idx.Agents {
+			if entry.Name == agent.Name && entry.Status == "running" && m.entryInScope(entry) {
+				return m.loadAgent(id)
+			}
+		}
+		agent.Name = m.uniqueName(idx, agent.Name)
 	}
 
-	state.Agents[agent.ID] = agent
-	return m.save(state)
+	return nil, m.putAgent(idx, agent)
+}
+
+// SetPID atomically records the PID of an agent reserved before its process
+// was started.
+func (m *Manager) SetPID(id string, pid int) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		agent.PID = pid
+	})
 }
 
 // uniqueName returns a unique name by appending a number suffix if needed.
 // Only considers running agents for conflicts.
-func (m *Manager) uniqueName(state *State, baseName string) string {
+func (m *Manager) uniqueName(idx *stateIndex, baseName string) string {
 	// Check if base name is available
 	nameInUse := func(name string) bool {
-		for _, existing := range state.Agents {
+		for _, existing := range idx.Agents {
 			if existing.Name == name && existing.Status == "running" {
 				return true
 			}
@@ -241,21 +448,20 @@ func (m *Manager) Update(agent *AgentState) error {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return err
 	}
 
-	if _, exists := state.Agents[agent.ID]; !exists {
+	if _, exists := idx.Agents[agent.ID]; !exists {
 		return fmt.Errorf("agent not found: %s", agent.ID)
 	}
 
-	state.Agents[agent.ID] = agent
-	return m.save(state)
+	return m.putAgent(idx, agent)
 }
 
 // MergeUpdate updates an existing agent's state while preserving "control signal"
-// fields (Iterations, Model, TerminateMode, Paused) from the current disk state.
+// fields (Iterations, Model, TerminateMode, Paused, ResumeAt) from the current disk state.
 // This prevents the runner from overwriting changes made by `swarm top` or other commands.
 // Use this from the runner loop instead of Update().
 func (m *Manager) MergeUpdate(agent *AgentState) error {
@@ -265,21 +471,20 @@ func (m *Manager) MergeUpdate(agent *AgentState) error {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return err
 	}
 
-	existing, exists := state.Agents[agent.ID]
-	if !exists {
-		return fmt.Errorf("agent not found: %s", agent.ID)
+	existing, err := m.loadAgent(agent.ID)
+	if err != nil {
+		return err
 	}
 
 	// Merge control signal fields from disk to preserve external changes
 	mergeControlFields(existing, agent)
 
-	state.Agents[agent.ID] = agent
-	return m.save(state)
+	return m.putAgent(idx, agent)
 }
 
 // mergeControlFields copies control signal fields from the existing (disk) state
@@ -299,103 +504,201 @@ func mergeControlFields(existing, agent *AgentState) {
 	// Paused: preserve disk value - this is set by `swarm pause`
 	agent.Paused = existing.Paused
 	// PausedAt is NOT preserved - it's set by the runner/executor to acknowledge pause
+	agent.ResumeAt = existing.ResumeAt
+	agent.Approval = existing.Approval
+
+	// Notes: preserve disk value - these are added by `swarm annotate`
+	agent.Notes = existing.Notes
+
+	// MaxAgeExceededAt: preserve disk value - set by StopAgentsOlderThan
+	if existing.MaxAgeExceededAt != nil {
+		agent.MaxAgeExceededAt = existing.MaxAgeExceededAt
+	}
 }
 
-// SetIterations atomically updates the Iterations field for an agent.
-// Use this instead of Update() when explicitly changing the iteration count.
-func (m *Manager) SetIterations(id string, iterations int) error {
+// modifyAgent loads a single agent, applies fn, and saves it back under the lock.
+func (m *Manager) modifyAgent(id string, fn func(agent *AgentState)) error {
 	fl, err := m.lock()
 	if err != nil {
 		return err
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return err
 	}
 
-	agent, exists := state.Agents[id]
-	if !exists {
-		return fmt.Errorf("agent not found: %s", id)
+	agent, err := m.loadAgent(id)
+	if err != nil {
+		return err
 	}
 
-	agent.Iterations = iterations
-	return m.save(state)
+	fn(agent)
+	return m.putAgent(idx, agent)
+}
+
+// SetIterations atomically updates the Iterations field for an agent.
+// Use this instead of Update() when explicitly changing the iteration count.
+func (m *Manager) SetIterations(id string, iterations int) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		agent.Iterations = iterations
+	})
+}
+
+// LimitRemainingIterations atomically lowers an agent's Iterations so it
+// stops after at most n more iterations beyond its current one. An agent
+// that would stop sooner is left alone. Returns the agent's Iterations.
+func (m *Manager) LimitRemainingIterations(id string, n int) (int, error) {
+	var iterations int
+	err := m.modifyAgent(id, func(agent *AgentState) {
+		limit := agent.CurrentIter + n
+		if agent.Iterations == 0 || agent.Iterations > limit {
+			agent.Iterations = limit
+		}
+		iterations = agent.Iterations
+	})
+	return iterations, err
 }
 
 // SetModel atomically updates the Model field for an agent.
 // Use this instead of Update() when explicitly changing the model.
 func (m *Manager) SetModel(id string, model string) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		agent.Model = model
+	})
+}
+
+// SetTerminateMode atomically updates the TerminateMode field for an agent.
+// Use this instead of Update() when explicitly setting termination mode.
+func (m *Manager) SetTerminateMode(id string, mode string) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		agent.TerminateMode = mode
+	})
+}
+
+// SetPaused atomically updates the Paused field for an agent.
+// Use this instead of Update() when explicitly pausing/resuming.
+func (m *Manager) SetPaused(id string, paused bool) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		applyPause(agent, paused, nil)
+	})
+}
+
+// SetPausedMany atomically pauses or resumes several agents in one locked
+// batch. A non-nil resumeAt pauses them until that time (see SetPausedUntil).
+// No agent is updated if any of them can't be loaded.
+func (m *Manager) SetPausedMany(ids []string, paused bool, resumeAt *time.Time) error {
+	return m.modifyAgents(ids, func(agent *AgentState) {
+		applyPause(agent, paused, resumeAt)
+	})
+}
+
+// AwaitApproval atomically pauses several agents until they're approved with
+// `swarm approve`; request says what needs approving. No agent is updated if
+// any of them can't be loaded.
+func (m *Manager) AwaitApproval(ids []string, request string) error {
+	return m.modifyAgents(ids, func(agent *AgentState) {
+		applyPause(agent, true, nil)
+		agent.Approval = request
+	})
+}
+
+// modifyAgents loads several agents, applies fn to each, and saves them back
+// in one locked batch. No agent is updated if any of them can't be loaded.
+func (m *Manager) modifyAgents(ids []string, fn func(agent *AgentState)) error {
 	fl, err := m.lock()
 	if err != nil {
 		return err
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return err
 	}
 
-	agent, exists := state.Agents[id]
-	if !exists {
-		return fmt.Errorf("agent not found: %s", id)
+	agents := make([]*AgentState, 0, len(ids))
+	for _, id := range ids {
+		agent, err := m.loadAgent(id)
+		if err != nil {
+			return err
+		}
+		agents = append(agents, agent)
 	}
 
-	agent.Model = model
-	return m.save(state)
+	for _, agent := range agents {
+		fn(agent)
+		if err := m.putAgent(idx, agent); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// SetTerminateMode atomically updates the TerminateMode field for an agent.
-// Use this instead of Update() when explicitly setting termination mode.
-func (m *Manager) SetTerminateMode(id string, mode string) error {
-	fl, err := m.lock()
-	if err != nil {
-		return err
+// applyPause sets an agent's pause control fields.
+func applyPause(agent *AgentState, paused bool, resumeAt *time.Time) {
+	agent.Paused = paused
+	agent.ResumeAt = nil
+	agent.Approval = ""
+	if paused && resumeAt != nil {
+		t := *resumeAt
+		agent.ResumeAt = &t
 	}
-	defer m.unlock(fl)
-
-	state, err := m.load()
-	if err != nil {
-		return err
+	if !paused {
+		agent.PausedAt = nil
 	}
+	// When paused=true, leave PausedAt as-is (nil if not yet acknowledged).
+	// The runner/executor will set PausedAt when it actually enters the pause state.
+}
 
-	agent, exists := state.Agents[id]
-	if !exists {
-		return fmt.Errorf("agent not found: %s", id)
-	}
+// AddNote atomically appends a timestamped note to an agent.
+func (m *Manager) AddNote(id string, text string) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		agent.Notes = append(agent.Notes, Note{Time: time.Now(), Text: text})
+	})
+}
 
-	agent.TerminateMode = mode
-	return m.save(state)
+// ClearNotes atomically removes all notes from an agent.
+func (m *Manager) ClearNotes(id string) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		agent.Notes = nil
+	})
 }
 
-// SetPaused atomically updates the Paused field for an agent.
-// Use this instead of Update() when explicitly pausing/resuming.
-func (m *Manager) SetPaused(id string, paused bool) error {
+// SetPausedUntil atomically pauses an agent and records when the runner should
+// resume it automatically.
+func (m *Manager) SetPausedUntil(id string, resumeAt time.Time) error {
+	return m.modifyAgent(id, func(agent *AgentState) {
+		applyPause(agent, true, &resumeAt)
+	})
+}
+
+// ResumeIfDue atomically resumes a paused agent whose ResumeAt has passed.
+// It reports whether the agent was resumed.
+func (m *Manager) ResumeIfDue(id string) (bool, error) {
 	fl, err := m.lock()
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	agent, err := m.loadAgent(id)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	agent, exists := state.Agents[id]
-	if !exists {
-		return fmt.Errorf("agent not found: %s", id)
+	if !agent.Paused || agent.ResumeAt == nil || time.Now().Before(*agent.ResumeAt) {
+		return false, nil
 	}
 
-	agent.Paused = paused
-	if !paused {
-		agent.PausedAt = nil
+	idx, err := m.loadIndex()
+	if err != nil {
+		return false, err
 	}
-	// When paused=true, leave PausedAt as-is (nil if not yet acknowledged).
-	// The runner/executor will set PausedAt when it actually enters the pause state.
-	return m.save(state)
+	agent.Paused = false
+	agent.PausedAt = nil
+	agent.ResumeAt = nil
+	return true, m.putAgent(idx, agent)
 }
 
 // Get retrieves an agent's state by ID.
@@ -408,21 +711,13 @@ func (m *Manager) Get(id string) (*AgentState, error) {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
-	if err != nil {
-		return nil, err
-	}
-
-	agent, exists := state.Agents[id]
-	if !exists {
-		return nil, fmt.Errorf("agent not found: %s", id)
-	}
-
-	return copyAgentState(agent), nil
+	return m.loadAgent(id)
 }
 
 // GetByNameOrID retrieves an agent's state by ID or name.
-// It first tries to find by ID, then falls back to searching by name.
+// It first tries to find by ID, then falls back to searching by name. When
+// several agents share the name, one in scope is preferred, then a running
+// one, then the most recently started.
 // Note: GetByNameOrID does not filter by scope - it retrieves the agent regardless of working directory.
 // Returns a copy of the state to avoid race conditions.
 func (m *Manager) GetByNameOrID(identifier string) (*AgentState, error) {
@@ -432,26 +727,85 @@ func (m *Manager) GetByNameOrID(identifier string) (*AgentState, error) {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return nil, err
 	}
 
 	// First try direct ID lookup
-	if agent, exists := state.Agents[identifier]; exists {
-		return copyAgentState(agent), nil
+	if _, exists := idx.Agents[identifier]; exists {
+		return m.loadAgent(identifier)
 	}
 
-	// Fall back to name search
-	for _, agent := range state.Agents {
-		if agent.Name == identifier && identifier != "" {
-			return copyAgentState(agent), nil
+	// Fall back to name search. Names are reused (e.g. by restarts), so
+	// prefer an agent in scope, then a running one, then the latest.
+	var bestID string
+	var best indexEntry
+	for id, entry := range idx.Agents {
+		if entry.Name != identifier || identifier == "" {
+			continue
 		}
+		if bestID == "" || m.preferByName(entry, best) {
+			bestID, best = id, entry
+		}
+	}
+	if bestID != "" {
+		return m.loadAgent(bestID)
 	}
 
 	return nil, fmt.Errorf("agent not found: %s", identifier)
 }
 
+// preferByName reports whether a is a better match than b for a name both
+// agents have: one in scope over one that isn't, a running one over a
+// stopped one, then the most recently started.
+func (m *Manager) preferByName(a, b indexEntry) bool {
+	if aIn, bIn := m.entryInScope(a), m.entryInScope(b); aIn != bIn {
+		return aIn
+	}
+	if aRunning, bRunning := a.Status == "running", b.Status == "running"; aRunning != bRunning {
+		return aRunning
+	}
+	return a.StartedAt.After(b.StartedAt)
+}
+
+// Successor returns the agent that replaced agent, or nil if there is none:
+// the most recently started agent with the same name and working directory
+// that started after it, e.g. the new agent `swarm restart` registers under
+// the old name.
+func (m *Manager) Successor(agent *AgentState) (*AgentState, error) {
+	if agent.Name == "" {
+		return nil, nil
+	}
+
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var successorID string
+	var latest time.Time
+	for id, entry := range idx.Agents {
+		if id == agent.ID || entry.Name != agent.Name || entry.WorkingDir != agent.WorkingDir {
+			continue
+		}
+		if entry.StartedAt.After(agent.StartedAt) && entry.StartedAt.After(latest) {
+			successorID = id
+			latest = entry.StartedAt
+		}
+	}
+	if successorID == "" {
+		return nil, nil
+	}
+	return m.loadAgent(successorID)
+}
+
 // GetLast returns the most recently started agent.
 // Respects the manager's scope setting.
 // Returns an error if no agents are found.
@@ -463,30 +817,28 @@ func (m *Manager) GetLast() (*AgentState, error) {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("no agents found")
-		}
 		return nil, err
 	}
 
-	var latest *AgentState
-	for _, agent := range state.Agents {
+	var latestID string
+	var latest indexEntry
+	for id, entry := range idx.Agents {
 		// Filter by scope
-		if m.scope == scope.ScopeProject && agent.WorkingDir != m.workingDir {
+		if !m.entryInScope(entry) {
 			continue
 		}
-		if latest == nil || agent.StartedAt.After(latest.StartedAt) {
-			latest = agent
+		if latestID == "" || entry.StartedAt.After(latest.StartedAt) {
+			latestID, latest = id, entry
 		}
 	}
 
-	if latest == nil {
+	if latestID == "" {
 		return nil, fmt.Errorf("no agents found")
 	}
 
-	return copyAgentState(latest), nil
+	return m.loadAgent(latestID)
 }
 
 // GetChildren returns all agents that have the given parentID as their parent.
@@ -499,21 +851,20 @@ func (m *Manager) GetChildren(parentID string) ([]*AgentState, error) {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	var children []*AgentState
-	for _, agent := range state.Agents {
-		if agent.ParentID == parentID {
-			children = append(children, copyAgentState(agent))
+	var ids []string
+	for id, entry := range idx.Agents {
+		if entry.ParentID == parentID {
+			ids = append(ids, id)
 		}
 	}
 
+	children := m.loadAgents(ids)
+
 	// Sort by StartedAt time (oldest first)
 	sort.Slice(children, func(i, j int) bool {
 		return children[i].StartedAt.Before(children[j].StartedAt)
@@ -532,36 +883,35 @@ func (m *Manager) GetDescendants(parentID string) ([]*AgentState, error) {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
 	// Build a map of parent -> children for efficient traversal
-	childrenMap := make(map[string][]*AgentState)
-	for _, agent := range state.Agents {
-		if agent.ParentID != "" {
-			childrenMap[agent.ParentID] = append(childrenMap[agent.ParentID], agent)
+	childrenMap := make(map[string][]string)
+	for id, entry := range idx.Agents {
+		if entry.ParentID != "" {
+			childrenMap[entry.ParentID] = append(childrenMap[entry.ParentID], id)
 		}
 	}
 
 	// BFS to find all descendants
-	var descendants []*AgentState
+	var ids []string
 	queue := []string{parentID}
 
 	for len(queue) > 0 {
 		currentID := queue[0]
 		queue = queue[1:]
 
-		for _, child := range childrenMap[currentID] {
-			descendants = append(descendants, copyAgentState(child))
-			queue = append(queue, child.ID)
+		for _, childID := range childrenMap[currentID] {
+			ids = append(ids, childID)
+			queue = append(queue, childID)
 		}
 	}
 
+	descendants := m.loadAgents(ids)
+
 	// Sort by StartedAt time (oldest first)
 	sort.Slice(descendants, func(i, j int) bool {
 		return descendants[i].StartedAt.Before(descendants[j].StartedAt)
@@ -571,7 +921,7 @@ func (m *Manager) GetDescendants(parentID string) ([]*AgentState, error) {
 }
 
 // List returns agents filtered by the manager's scope.
-// For ScopeProject, only returns agents started in the manager's working directory.
+// For ScopeProject, only returns agents started in the manager's project (see inScope).
 // For ScopeGlobal, returns all agents.
 // If onlyRunning is true, only returns agents with status "running".
 // Results are always sorted by StartedAt time (oldest first).
@@ -583,27 +933,26 @@ func (m *Manager) List(onlyRunning bool) ([]*AgentState, error) {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	var agents []*AgentState
-	for _, agent := range state.Agents {
+	var ids []string
+	for id, entry := range idx.Agents {
 		// Filter by scope
-		if m.scope == scope.ScopeProject && agent.WorkingDir != m.workingDir {
+		if !m.entryInScope(entry) {
 			continue
 		}
 		// Filter by status if onlyRunning is true
-		if onlyRunning && agent.Status != "running" {
+		if onlyRunning && entry.Status != "running" {
 			continue
 		}
-		agents = append(agents, copyAgentState(agent))
+		ids = append(ids, id)
 	}
 
+	agents := m.loadAgents(ids)
+
 	// Sort by StartedAt time (oldest first)
 	sort.Slice(agents, func(i, j int) bool {
 		return agents[i].StartedAt.Before(agents[j].StartedAt)
@@ -620,13 +969,144 @@ func (m *Manager) Remove(id string) error {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return err
 	}
 
-	delete(state.Agents, id)
-	return m.save(state)
+	if err := m.removeAgentFiles(id); err != nil {
+		return err
+	}
+
+	if _, exists := idx.Agents[id]; !exists {
+		return nil
+	}
+	delete(idx.Agents, id)
+	return m.saveIndex(idx)
+}
+
+// removeAgentFiles deletes an agent's state file, iteration history and
+// queued messages, along with their backups.
+func (m *Manager) removeAgentFiles(id string) error {
+	if err := os.Remove(m.agentPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	os.Remove(m.agentPath(id) + backupSuffix)
+	os.Remove(m.historyPath(id))
+	os.Remove(m.messagesPath(id))
+	os.Remove(m.messagesPath(id) + backupSuffix)
+	return nil
+}
+
+// Import adds agents from a backup. In merge mode, agents whose IDs already
+// exist are left untouched. In replace mode, existing agents in the manager's
+// scope are removed first, which is refused while any of them is running.
+// Agents the backup has as running are imported as
+// terminated, since their PIDs belong to another machine or boot. Returns the
+// number of agents imported.
+func (m *Manager) Import(agents []*AgentState, replace bool) (int, error) {
+	// IDs name the agents' state files, so reject any that could escape the
+	// agents directory before touching state
+	for _, agent := range agents {
+		if agent != nil && agent.ID != "" && !validAgentID(agent.ID) {
+			return 0, fmt.Errorf("invalid agent ID %q in backup", agent.ID)
+		}
+	}
+
+	fl, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer m.unlock(fl)
+
+	idx, err := m.loadIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	if replace {
+		// A running agent would keep writing the state being replaced
+		var running []string
+		for id, entry := range idx.Agents {
+			if m.entryInScope(entry) && entry.Status == "running" {
+				running = append(running, id)
+			}
+		}
+		if len(running) > 0 {
+			sort.Strings(running)
+			return 0, fmt.Errorf("cannot replace state while %d agent(s) are running (%s); kill them first", len(running), strings.Join(running, ", "))
+		}
+
+		for id, entry := range idx.Agents {
+			if !m.entryInScope(entry) {
+				continue
+			}
+			if err := m.removeAgentFiles(id); err != nil {
+				return 0, err
+			}
+			delete(idx.Agents, id)
+		}
+		if err := m.saveIndex(idx); err != nil {
+			return 0, err
+		}
+	}
+
+	imported := 0
+	for _, agent := range agents {
+		if agent == nil || agent.ID == "" {
+			continue
+		}
+		if _, exists := idx.Agents[agent.ID]; exists {
+			continue
+		}
+		if agent.Status == "running" {
+			now := time.Now()
+			agent.Status = "terminated"
+			agent.PID = 0
+			if agent.ExitReason == "" {
+				agent.ExitReason = "crashed"
+			}
+			if agent.TerminatedAt == nil {
+				agent.TerminatedAt = &now
+			}
+		}
+		if err := m.putAgent(idx, agent); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+
+	return imported, nil
+}
+
+// validAgentID reports whether id is a plain agent ID: letters, digits, '-'
+// and '_' only, like the IDs GenerateID returns.
+func validAgentID(id string) bool {
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return id != ""
+}
+
+// inScope reports whether an agent started in workingDir is visible to this manager.
+// Inside a git repository, project scope covers agents started anywhere in the
+// repository (e.g. via -C ./frontend); outside one it requires the exact directory.
+func (m *Manager) inScope(workingDir string) bool {
+	if m.scope != scope.ScopeProject {
+		return true
+	}
+	if m.projectRoot != "" {
+		return scope.Contains(m.projectRoot, workingDir)
+	}
+	return workingDir == m.workingDir
+}
+
+// entryInScope reports whether an agent is visible to this manager. An agent
+// a compose project started in another repo is visible from both.
+func (m *Manager) entryInScope(entry indexEntry) bool {
+	return m.inScope(entry.WorkingDir) || (entry.ProjectDir != "" && m.inScope(entry.ProjectDir))
 }
 
 // WorkingDir returns the working directory used for filtering.
@@ -634,34 +1114,220 @@ func (m *Manager) WorkingDir() string {
 	return m.workingDir
 }
 
-func (m *Manager) load() (*State, error) {
-	data, err := os.ReadFile(m.statePath)
+// projectKey is the directory project-scoped records, such as cost alerts
+// and claims, are kept for: the git repository root, or the working directory
+// outside a repository.
+func (m *Manager) projectKey() string {
+	if m.projectRoot != "" {
+		return m.projectRoot
+	}
+	return m.workingDir
+}
+
+// agentPath returns the path of the file holding a single agent's state.
+func (m *Manager) agentPath(id string) string {
+	return filepath.Join(m.agentsDir, id+".json")
+}
+
+// loadAgent reads a single agent's state from its file.
+func (m *Manager) loadAgent(id string) (*AgentState, error) {
+	var agent AgentState
+	if err := readJSONFile(m.agentPath(id), &agent); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("agent not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to load state for agent %s: %w", id, err)
+	}
+	return &agent, nil
+}
+
+// loadAgents reads the given agents, skipping any whose file is missing or unreadable.
+func (m *Manager) loadAgents(ids []string) []*AgentState {
+	var agents []*AgentState
+	for _, id := range ids {
+		agent, err := m.loadAgent(id)
+		if err != nil {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	return agents
+}
+
+// saveAgent writes a single agent's state to its file.
+func (m *Manager) saveAgent(agent *AgentState) error {
+	if err := os.MkdirAll(m.agentsDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(agent, "", "  ")
 	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(m.agentPath(agent.ID), data, 0644)
+}
+
+// putAgent saves an agent and updates the index. The index is only rewritten
+// when the agent's summary changes, so routine updates such as token counts
+// touch a single small file.
+func (m *Manager) putAgent(idx *stateIndex, agent *AgentState) error {
+	// Record the start of a newly set PID's process, so the agent isn't
+	// mistaken for whatever process later reuses the PID
+	if existing, exists := idx.Agents[agent.ID]; agent.PID > 0 && (!exists || existing.PID != agent.PID) {
+		agent.ProcessStart, _ = process.StartTime(agent.PID)
+	} else if exists && agent.ProcessStart == "" {
+		agent.ProcessStart = existing.ProcessStart
+	}
+
+	if err := m.saveAgent(agent); err != nil {
+		return err
+	}
+
+	entry := newIndexEntry(agent)
+	if existing, exists := idx.Agents[agent.ID]; exists && existing.equal(entry) {
+		return nil
+	}
+	idx.Agents[agent.ID] = entry
+	return m.saveIndex(idx)
+}
+
+// equal reports whether two index entries hold the same summary.
+// StartedAt is compared with time.Equal since it loses its monotonic reading on disk.
+func (e indexEntry) equal(other indexEntry) bool {
+	return e.Name == other.Name &&
+		e.ParentID == other.ParentID &&
+		e.WorkingDir == other.WorkingDir &&
+		e.ProjectDir == other.ProjectDir &&
+		e.Status == other.Status &&
+		e.PID == other.PID &&
+		e.ProcessStart == other.ProcessStart &&
+		e.StartedAt.Equal(other.StartedAt)
+}
+
+// newIndexEntry builds the index summary for an agent.
+func newIndexEntry(agent *AgentState) indexEntry {
+	return indexEntry{
+		Name:         agent.Name,
+		ParentID:     agent.ParentID,
+		WorkingDir:   agent.WorkingDir,
+		ProjectDir:   agent.ProjectDir,
+		Status:       agent.Status,
+		PID:          agent.PID,
+		ProcessStart: agent.ProcessStart,
+		StartedAt:    agent.StartedAt,
+	}
+}
+
+// loadIndex reads the agent index. If the index is missing or unreadable it is
+// rebuilt from the agent files on disk.
+func (m *Manager) loadIndex() (*stateIndex, error) {
+	var idx stateIndex
+	if err := readJSONFile(m.indexPath, &idx); err != nil {
 		if os.IsNotExist(err) {
-			return &State{Agents: make(map[string]*AgentState)}, nil
+			return m.rebuildIndex()
 		}
-		return nil, err
+		// The index only summarizes the agent files, so it can always be rebuilt
+		fmt.Fprintf(os.Stderr, "Warning: failed to load state index, rebuilding: %v\n", err)
+		return m.rebuildIndex()
+	}
+
+	if idx.Agents == nil {
+		idx.Agents = make(map[string]indexEntry)
 	}
 
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
+	return &idx, nil
+}
+
+// rebuildIndex scans the agents directory and builds an index from the agent files.
+func (m *Manager) rebuildIndex() (*stateIndex, error) {
+	idx := &stateIndex{Agents: make(map[string]indexEntry)}
+
+	entries, err := os.ReadDir(m.agentsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
 		return nil, err
 	}
 
-	if state.Agents == nil {
-		state.Agents = make(map[string]*AgentState)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == indexFileName || filepath.Ext(name) != ".json" {
+			continue
+		}
+		agent, err := m.loadAgent(name[:len(name)-len(".json")])
+		if err != nil {
+			continue
+		}
+		idx.Agents[agent.ID] = newIndexEntry(agent)
 	}
 
-	return &state, nil
+	return idx, nil
 }
 
-func (m *Manager) save(state *State) error {
-	data, err := json.MarshalIndent(state, "", "  ")
+func (m *Manager) saveIndex(idx *stateIndex) error {
+	if err := os.MkdirAll(m.agentsDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(idx, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(m.statePath, data, 0644)
+	return writeFileAtomic(m.indexPath, data, 0644)
+}
+
+// migrateLegacyState moves agents from the old single state.json file into
+// per-agent files, then renames state.json so the migration only runs once.
+func (m *Manager) migrateLegacyState() error {
+	if m.legacyStatePath == "" {
+		return nil
+	}
+
+	fl, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock(fl)
+
+	data, err := os.ReadFile(m.legacyStatePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var legacy State
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+	}
+
+	idx, err := m.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	for id, agent := range legacy.Agents {
+		// Per-agent files written since are newer than the legacy copy
+		if _, exists := idx.Agents[id]; exists {
+			continue
+		}
+		if err := m.saveAgent(agent); err != nil {
+			return err
+		}
+		idx.Agents[id] = newIndexEntry(agent)
+	}
+
+	if err := m.saveIndex(idx); err != nil {
+		return err
+	}
+
+	return os.Rename(m.legacyStatePath, m.legacyStatePath+".migrated")
 }
 
 // cleanup removes stale entries (processes that are no longer running).
@@ -672,44 +1338,46 @@ func (m *Manager) cleanup() error {
 	}
 	defer m.unlock(fl)
 
-	state, err := m.load()
+	idx, err := m.loadIndex()
 	if err != nil {
 		return err
 	}
 
-	changed := false
 	now := time.Now()
-	for id, agent := range state.Agents {
-		// Handle agents with PID=0 (registered but child never started or updated PID)
-		if agent.Status == "running" && agent.PID == 0 {
-			// Give some time for the parent to update the PID after starting child
-			if time.Since(agent.StartedAt) > 30*time.Second {
-				agent.Status = "terminated"
-				agent.ExitReason = "crashed"
-				agent.TerminatedAt = &now
-				state.Agents[id] = agent
-				changed = true
-			}
+	for id, entry := range idx.Agents {
+		if entry.Status != "running" {
 			continue
 		}
 
-		// Check if process is still running
-		if agent.Status == "running" && !isProcessRunning(agent.PID) {
-			agent.Status = "terminated"
-			// If the process died without setting exit reason, it crashed
-			if agent.ExitReason == "" {
-				agent.ExitReason = "crashed"
-			}
-			if agent.TerminatedAt == nil {
-				agent.TerminatedAt = &now
-			}
-			state.Agents[id] = agent
-			changed = true
+		// Agents with PID=0 are registered but the child never started or updated the PID.
+		// Give some time for the parent to update the PID after starting child.
+		var stale bool
+		if entry.PID == 0 {
+			stale = time.Since(entry.StartedAt) > 30*time.Second
+		} else {
+			stale = !isProcessRunning(entry.PID) || !process.SameProcess(entry.PID, entry.ProcessStart)
+		}
+		if !stale {
+			continue
 		}
-	}
 
-	if changed {
-		return m.save(state)
+		agent, err := m.loadAgent(id)
+		if err != nil {
+			continue
+		}
+		agent.Status = "terminated"
+		// If the process died without setting exit reason, it crashed
+		if agent.ExitReason == "" || agent.PID == 0 {
+			agent.ExitReason = "crashed"
+			recoverUsage(agent)
+		}
+		if agent.TerminatedAt == nil {
+			agent.TerminatedAt = &now
+		}
+		if err := m.putAgent(idx, agent); err != nil {
+			return err
+		}
 	}
+
 	return nil
 }
@@ -172,28 +172,22 @@ func TestStateJSONFormat(t *testing.T) {
 		t.Fatalf("Register failed: %v", err)
 	}
 
-	// Read the state file directly
+	// Read the agent's state file directly
 	homeDir, _ := os.UserHomeDir()
-	statePath := filepath.Join(homeDir, ".swarm", "state.json")
-	data, err := os.ReadFile(statePath)
+	agentPath := filepath.Join(homeDir, ".swarm", "agents", agent.ID+".json")
+	data, err := os.ReadFile(agentPath)
 	if err != nil {
-		t.Fatalf("Failed to read state file: %v", err)
+		t.Fatalf("Failed to read agent state file: %v", err)
 	}
 
 	// Verify it's valid JSON
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		t.Fatalf("State file is not valid JSON: %v", err)
+	var savedAgent AgentState
+	if err := json.Unmarshal(data, &savedAgent); err != nil {
+		t.Fatalf("Agent state file is not valid JSON: %v", err)
 	}
 
-	// Verify our agent is in the state
-	if state.Agents == nil {
-		t.Fatal("State.Agents is nil")
-	}
-
-	savedAgent, exists := state.Agents[agent.ID]
-	if !exists {
-		t.Fatalf("Agent %s not found in state file", agent.ID)
+	if savedAgent.ID != agent.ID {
+		t.Errorf("Saved agent ID mismatch: got %s, want %s", savedAgent.ID, agent.ID)
 	}
 
 	if savedAgent.Prompt != "json-test" {
@@ -1,8 +1,11 @@
 package state
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,14 +13,16 @@ import (
 )
 
 // newTestManager creates a Manager backed by a temp directory so tests
-// don't interfere with the real ~/swarm/state.json or each other.
+// don't interfere with the real ~/.swarm state or each other.
 func newTestManager(t *testing.T) *Manager {
 	t.Helper()
 	dir := t.TempDir()
 	return &Manager{
-		statePath: filepath.Join(dir, "state.json"),
-		lockPath:  filepath.Join(dir, "state.lock"),
-		scope:     scope.ScopeGlobal,
+		agentsDir:       filepath.Join(dir, "agents"),
+		indexPath:       filepath.Join(dir, "agents", indexFileName),
+		legacyStatePath: filepath.Join(dir, "state.json"),
+		lockPath:        filepath.Join(dir, "state.lock"),
+		scope:           scope.ScopeGlobal,
 	}
 }
 
@@ -48,11 +53,11 @@ func TestNewManager(t *testing.T) {
 		t.Fatal("NewManager returned nil manager")
 	}
 
-	// Verify state path is set correctly
+	// Verify agents directory is set correctly
 	homeDir, _ := os.UserHomeDir()
-	expectedPath := filepath.Join(homeDir, ".swarm", "state.json")
-	if mgr.statePath != expectedPath {
-		t.Errorf("state path mismatch: got %s, want %s", mgr.statePath, expectedPath)
+	expectedDir := filepath.Join(homeDir, ".swarm", "agents")
+	if mgr.agentsDir != expectedDir {
+		t.Errorf("agents dir mismatch: got %s, want %s", mgr.agentsDir, expectedDir)
 	}
 }
 
@@ -648,3 +653,1103 @@ func TestGetLastNoAgents(t *testing.T) {
 	}
 	// Note: This test is best-effort since other tests may leave agents in global state
 }
+
+func TestMergeUpdateSkipsIndexRewrite(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{
+		ID:        GenerateID(),
+		Name:      "worker",
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+		Status:    "running",
+	}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	before, err := os.Stat(mgr.indexPath)
+	if err != nil {
+		t.Fatalf("index not written: %v", err)
+	}
+
+	// Usage updates don't change the index summary
+	time.Sleep(10 * time.Millisecond)
+	agent.InputTokens = 1000
+	if err := mgr.MergeUpdate(agent); err != nil {
+		t.Fatalf("MergeUpdate failed: %v", err)
+	}
+
+	after, err := os.Stat(mgr.indexPath)
+	if err != nil {
+		t.Fatalf("index missing: %v", err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("index should not be rewritten when only usage changes")
+	}
+
+	got, err := mgr.GetByNameOrID("worker")
+	if err != nil {
+		t.Fatalf("GetByNameOrID failed: %v", err)
+	}
+	if got.InputTokens != 1000 {
+		t.Errorf("InputTokens = %d, want 1000", got.InputTokens)
+	}
+}
+
+func TestGetByNameOrIDPrefersRunningThenLatest(t *testing.T) {
+	mgr := newTestManager(t)
+	now := time.Now()
+
+	register := func(id, status string, started time.Time) {
+		t.Helper()
+		if err := mgr.Register(&AgentState{ID: id, Name: "worker", StartedAt: started, Status: status}); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	register("old00001", "terminated", now.Add(-3*time.Hour))
+	register("old00002", "terminated", now.Add(-2*time.Hour))
+
+	got, err := mgr.GetByNameOrID("worker")
+	if err != nil {
+		t.Fatalf("GetByNameOrID failed: %v", err)
+	}
+	if got.ID != "old00002" {
+		t.Errorf("got %s, want the latest agent old00002", got.ID)
+	}
+
+	register("run00001", "running", now.Add(-4*time.Hour))
+	if got, _ := mgr.GetByNameOrID("worker"); got == nil || got.ID != "run00001" {
+		t.Errorf("got %v, want the running agent run00001", got)
+	}
+}
+
+func TestIndexRebuiltFromAgentFiles(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{ID: GenerateID(), Name: "orphan", StartedAt: time.Now(), Status: "terminated"}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := os.Remove(mgr.indexPath); err != nil {
+		t.Fatalf("failed to remove index: %v", err)
+	}
+
+	agents, err := mgr.List(false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(agents) != 1 || agents[0].ID != agent.ID {
+		t.Errorf("expected agent to be recovered from its file, got %v", agents)
+	}
+}
+
+func TestMigrateLegacyState(t *testing.T) {
+	mgr := newTestManager(t)
+
+	legacy := `{"agents": {"abc12345": {"id": "abc12345", "name": "old", "status": "terminated", "prompt": "legacy"}}}`
+	if err := os.WriteFile(mgr.legacyStatePath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("failed to write legacy state: %v", err)
+	}
+
+	if err := mgr.migrateLegacyState(); err != nil {
+		t.Fatalf("migrateLegacyState failed: %v", err)
+	}
+
+	got, err := mgr.Get("abc12345")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Prompt != "legacy" {
+		t.Errorf("Prompt = %q, want %q", got.Prompt, "legacy")
+	}
+
+	if _, err := os.Stat(mgr.legacyStatePath); !os.IsNotExist(err) {
+		t.Error("legacy state.json should be renamed after migration")
+	}
+	if _, err := os.Stat(mgr.legacyStatePath + ".migrated"); err != nil {
+		t.Errorf("expected migrated backup: %v", err)
+	}
+}
+
+func TestImportMergeAndReplace(t *testing.T) {
+	mgr := newTestManager(t)
+
+	existing := &AgentState{ID: "aaaa0001", Prompt: "current", StartedAt: time.Now(), Status: "terminated"}
+	if err := mgr.Register(existing); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	backup := []*AgentState{
+		{ID: "aaaa0001", Prompt: "from-backup", Status: "terminated"},
+		{ID: "bbbb0002", Prompt: "restored", Status: "terminated"},
+	}
+
+	imported, err := mgr.Import(backup, false)
+	if err != nil {
+		t.Fatalf("Import merge failed: %v", err)
+	}
+	if imported != 1 {
+		t.Errorf("merge imported %d, want 1", imported)
+	}
+	got, _ := mgr.Get("aaaa0001")
+	if got.Prompt != "current" {
+		t.Errorf("merge should keep existing agent, got prompt %q", got.Prompt)
+	}
+
+	other := &AgentState{ID: "cccc0003", StartedAt: time.Now(), Status: "terminated"}
+	if err := mgr.Register(other); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	imported, err = mgr.Import(backup, true)
+	if err != nil {
+		t.Fatalf("Import replace failed: %v", err)
+	}
+	if imported != 2 {
+		t.Errorf("replace imported %d, want 2", imported)
+	}
+	got, _ = mgr.Get("aaaa0001")
+	if got.Prompt != "from-backup" {
+		t.Errorf("replace should overwrite agent, got prompt %q", got.Prompt)
+	}
+	if _, err := mgr.Get("cccc0003"); err == nil {
+		t.Error("replace should remove agents not in the backup")
+	}
+}
+
+func TestImportReplace(t *testing.T) {
+	mgr := newTestManager(t)
+
+	running := &AgentState{ID: "aaaa0001", StartedAt: time.Now(), Status: "running", PID: os.Getpid()}
+	if err := mgr.Register(running); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	backup := []*AgentState{{ID: "bbbb0002", Status: "terminated"}}
+	if _, err := mgr.Import(backup, true); err == nil {
+		t.Fatal("replace should be refused while an agent is running")
+	}
+	if _, err := mgr.Get(running.ID); err != nil {
+		t.Errorf("refused replace removed the running agent: %v", err)
+	}
+
+	// Once it has finished, its history and queued messages go with it
+	running.Status = "terminated"
+	if err := mgr.Update(running); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	mgr.AppendHistory(running.ID, IterationRecord{Iteration: 1, Status: "succeeded"})
+	mgr.QueueMessage(running.ID, "stale")
+	if _, err := mgr.Import(backup, true); err != nil {
+		t.Fatalf("Import replace failed: %v", err)
+	}
+	for _, path := range []string{mgr.historyPath(running.ID), mgr.messagesPath(running.ID)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("replace left %s behind", filepath.Base(path))
+		}
+	}
+}
+
+func TestImportRejectsPathIDs(t *testing.T) {
+	mgr := newTestManager(t)
+
+	backup := []*AgentState{
+		{ID: "aaaa0001", Status: "terminated"},
+		{ID: "../../escape", Status: "terminated"},
+	}
+	if _, err := mgr.Import(backup, false); err == nil {
+		t.Fatal("expected an error for an ID with path separators")
+	}
+	if _, err := mgr.Get("aaaa0001"); err == nil {
+		t.Error("no agents should be imported from a backup with an invalid ID")
+	}
+	if _, err := os.Stat(filepath.Join(mgr.agentsDir, "../../escape.json")); !os.IsNotExist(err) {
+		t.Error("import wrote outside the agents directory")
+	}
+}
+
+func TestImportTerminatesRunningAgents(t *testing.T) {
+	mgr := newTestManager(t)
+
+	backup := []*AgentState{{ID: "aaaa0001", PID: 4242, Status: "running", StartedAt: time.Now()}}
+	if _, err := mgr.Import(backup, false); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	got, err := mgr.Get("aaaa0001")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Status != "terminated" || got.PID != 0 || got.TerminatedAt == nil {
+		t.Errorf("imported running agent = status %q, PID %d, terminated at %v; want terminated with no PID", got.Status, got.PID, got.TerminatedAt)
+	}
+}
+
+func TestProjectScopeIncludesRepoSubdirectories(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.scope = scope.ScopeProject
+	mgr.workingDir = "/repo"
+	mgr.projectRoot = "/repo"
+
+	now := time.Now()
+	for _, a := range []*AgentState{
+		{ID: "root0001", WorkingDir: "/repo", StartedAt: now, Status: "terminated"},
+		{ID: "sub00002", WorkingDir: "/repo/frontend", StartedAt: now, Status: "terminated"},
+		{ID: "other003", WorkingDir: "/repo-other", StartedAt: now, Status: "terminated"},
+	} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	agents, err := mgr.List(false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(agents) != 2 {
+		t.Fatalf("expected 2 agents in project, got %d", len(agents))
+	}
+	for _, a := range agents {
+		if a.ID == "other003" {
+			t.Error("agent outside the repository should not be listed")
+		}
+	}
+
+	// Outside a repository, only the exact directory matches
+	mgr.projectRoot = ""
+	agents, _ = mgr.List(false)
+	if len(agents) != 1 || agents[0].ID != "root0001" {
+		t.Errorf("expected only exact directory match without a project root, got %d agents", len(agents))
+	}
+}
+
+func TestProjectScopeIncludesAgentsInOtherRepos(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.scope = scope.ScopeProject
+	mgr.workingDir = "/repo"
+	mgr.projectRoot = "/repo"
+
+	now := time.Now()
+	for _, a := range []*AgentState{
+		{ID: "svc00001", WorkingDir: "/other-service", ProjectDir: "/repo", StartedAt: now, Status: "running"},
+		{ID: "other002", WorkingDir: "/other-service", StartedAt: now, Status: "running"},
+	} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	agents, err := mgr.List(false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(agents) != 1 || agents[0].ID != "svc00001" || !agents[0].StartedFrom("/repo") {
+		t.Errorf("expected only the agent started by the project, got %d agents", len(agents))
+	}
+
+	// The repo it runs in sees it too
+	mgr.workingDir = "/other-service"
+	mgr.projectRoot = ""
+	if agents, _ = mgr.List(false); len(agents) != 2 {
+		t.Errorf("expected both agents from the other repo, got %d", len(agents))
+	}
+}
+
+func TestCounterClaimAndReset(t *testing.T) {
+	mgr := newTestManager(t)
+	key := CounterKey("pipeline", "/tmp/project", "main")
+
+	// Separate Counter values (as in separate processes) share the same file
+	counters := []*Counter{mgr.Counter(key, 5), mgr.Counter(key, 5)}
+
+	var claimed []int
+	for {
+		n, ok, err := counters[len(claimed)%2].Claim()
+		if err != nil {
+			t.Fatalf("Claim failed: %v", err)
+		}
+		if !ok {
+			break
+		}
+		claimed = append(claimed, n)
+	}
+	if len(claimed) != 5 || claimed[0] != 1 || claimed[4] != 5 {
+		t.Errorf("claimed = %v, want 1..5", claimed)
+	}
+
+	if err := counters[0].Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if n, ok, _ := counters[1].Claim(); !ok || n != 1 {
+		t.Errorf("after Reset, Claim() = %d, %v; want 1, true", n, ok)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.scope = scope.ScopeProject
+	mgr.workingDir = "/repo"
+	mgr.projectRoot = "/repo"
+
+	claim, ok, err := mgr.Claim("task:add-auth", "planner-1", 0)
+	if err != nil || !ok || claim.Owner != "planner-1" {
+		t.Fatalf("Claim() = %+v, %v, %v; want planner-1 to take it", claim, ok, err)
+	}
+	if claim, ok, _ := mgr.Claim("task:add-auth", "planner-2", 0); ok || claim.Owner != "planner-1" {
+		t.Errorf("second owner Claim() = %+v, %v; want planner-1 to keep it", claim, ok)
+	}
+	if _, ok, _ := mgr.Claim("task:add-auth", "planner-1", 0); !ok {
+		t.Error("reclaiming a held key should succeed")
+	}
+	if err := mgr.ReleaseClaim("task:add-auth", "planner-2"); err == nil {
+		t.Error("releasing another owner's claim should fail")
+	}
+
+	// Another project has its own keys
+	mgr.workingDir, mgr.projectRoot = "/elsewhere", ""
+	if _, ok, _ := mgr.Claim("task:add-auth", "planner-2", 0); !ok {
+		t.Error("a key should be claimable in another project")
+	}
+	if claims, _ := mgr.Claims(); len(claims) != 1 || claims[0].Owner != "planner-2" {
+		t.Errorf("Claims() = %+v, want only this project's claim", claims)
+	}
+	mgr.workingDir, mgr.projectRoot = "/repo", "/repo"
+
+	if err := mgr.ReleaseClaim("task:add-auth", "planner-1"); err != nil {
+		t.Fatalf("ReleaseClaim() error = %v", err)
+	}
+	if _, ok, _ := mgr.Claim("task:add-auth", "planner-2", 0); !ok {
+		t.Error("a released key should be claimable")
+	}
+
+	// An expired claim can be taken over
+	if _, ok, _ := mgr.Claim("lease", "a", time.Nanosecond); !ok {
+		t.Fatal("Claim() with a ttl failed")
+	}
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := mgr.Claim("lease", "b", 0); !ok {
+		t.Error("an expired claim should be claimable")
+	}
+}
+
+func TestClaimConcurrent(t *testing.T) {
+	base := newTestManager(t)
+
+	// Separate managers (as in separate processes) race for one key
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mgr := &Manager{agentsDir: base.agentsDir, indexPath: base.indexPath, lockPath: base.lockPath, scope: base.scope}
+			_, ok, err := mgr.Claim("task:add-auth", fmt.Sprintf("planner-%d", i), 0)
+			if err != nil {
+				t.Errorf("Claim() error = %v", err)
+			}
+			if ok {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if winners != 1 {
+		t.Errorf("%d owners claimed the key, want exactly 1", winners)
+	}
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	base := newTestManager(t)
+
+	// Separate managers (as in separate `swarm up -d` processes) race to
+	// start the same agent
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var reserved []string
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mgr := &Manager{agentsDir: base.agentsDir, indexPath: base.indexPath, lockPath: base.lockPath, scope: base.scope}
+			agent := &AgentState{ID: fmt.Sprintf("agent%03d", i), Name: "planner", Status: "running", StartedAt: time.Now()}
+			running, err := mgr.Reserve(agent)
+			if err != nil {
+				t.Errorf("Reserve() error = %v", err)
+				return
+			}
+			if running == nil {
+				mu.Lock()
+				reserved = append(reserved, agent.ID)
+				mu.Unlock()
+			} else if running.Name != "planner" {
+				t.Errorf("Reserve() returned %q, want the running planner", running.Name)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(reserved) != 1 {
+		t.Fatalf("%d agents were reserved, want exactly 1: %v", len(reserved), reserved)
+	}
+
+	if err := base.SetPID(reserved[0], 4242); err != nil {
+		t.Fatalf("SetPID() error = %v", err)
+	}
+	agent, err := base.Get(reserved[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agent.PID != 4242 || agent.Name != "planner" {
+		t.Errorf("got %s with PID %d, want planner with PID 4242", agent.Name, agent.PID)
+	}
+}
+
+func TestPIDReuseDetected(t *testing.T) {
+	mgr := newTestManager(t)
+
+	// The agent's PID is alive (this test), so its start is recorded
+	agent := &AgentState{ID: "live0001", PID: os.Getpid(), StartedAt: time.Now(), Status: "running"}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if !agent.ProcessAlive() {
+		t.Fatal("expected the agent's process to be alive")
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if agent.ProcessStart == "" {
+		t.Fatal("expected the process start to be recorded")
+	}
+
+	// The PID was since reused by another process
+	reused := *agent
+	reused.ID = "gone0001"
+	if err := mgr.Register(&reused); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := mgr.modifyAgent("gone0001", func(a *AgentState) { a.ProcessStart = "not-" + a.ProcessStart }); err != nil {
+		t.Fatalf("modifyAgent failed: %v", err)
+	}
+	stored, _ := mgr.Get("gone0001")
+	if stored.ProcessStart != "not-"+agent.ProcessStart {
+		t.Fatalf("ProcessStart = %q, want the recorded start kept", stored.ProcessStart)
+	}
+	if stored.ProcessAlive() {
+		t.Error("expected a reused PID not to count as the agent's process")
+	}
+	// (checked without signalling, since the PID is this test's)
+	if err := stored.checkProcess(); err == nil {
+		t.Error("expected a reused PID to be refused for signalling")
+	}
+
+	if err := mgr.cleanup(); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if a, _ := mgr.Get("gone0001"); a.Status != "terminated" {
+		t.Errorf("agent with a reused PID has status %q, want terminated", a.Status)
+	}
+	if a, _ := mgr.Get("live0001"); a.Status != "running" {
+		t.Errorf("live agent has status %q, want running", a.Status)
+	}
+}
+
+func TestRecoverUsageFromLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "agent.log")
+	os.WriteFile(logFile, []byte(`{"type":"result","total_cost_usd":1.25,"usage":{"input_tokens":1000,"output_tokens":100}}`+"\n"), 0644)
+
+	// State only saw part of the usage before the runner died
+	agent := &AgentState{ID: "a", LogFile: logFile, InputTokens: 400, OutputTokens: 40, TotalCost: 0.5}
+	recoverUsage(agent)
+
+	if agent.InputTokens != 1000 || agent.OutputTokens != 100 || agent.TotalCost != 1.25 {
+		t.Errorf("recoverUsage() = %d/%d/$%.2f, want 1000/100/$1.25", agent.InputTokens, agent.OutputTokens, agent.TotalCost)
+	}
+}
+
+func TestRunsSaveListAndGet(t *testing.T) {
+	mgr := newTestManager(t)
+
+	runs, err := mgr.ListRuns()
+	if err != nil {
+		t.Fatalf("ListRuns failed: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Fatalf("expected no runs, got %d", len(runs))
+	}
+
+	now := time.Now()
+	first := &RunState{ID: "abc12345", Pipeline: "main", PID: os.Getpid(), StartedAt: now.Add(-time.Hour), Status: "completed"}
+	second := &RunState{ID: "abd67890", Pipeline: "main", PID: os.Getpid(), StartedAt: now, Status: "running",
+		Tasks: []RunTask{{Task: "coder", Iteration: 1, Status: "succeeded", TotalCost: 0.5}}}
+	// A running run whose process is gone is reported as crashed
+	crashed := &RunState{ID: "ffff0000", Pipeline: "other", PID: 999999999, StartedAt: now.Add(-2 * time.Hour), Status: "running"}
+	for _, r := range []*RunState{second, first, crashed} {
+		if err := mgr.SaveRun(r); err != nil {
+			t.Fatalf("SaveRun failed: %v", err)
+		}
+	}
+
+	runs, err = mgr.ListRuns()
+	if err != nil {
+		t.Fatalf("ListRuns failed: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(runs))
+	}
+	if runs[0].ID != "ffff0000" || runs[1].ID != "abc12345" || runs[2].ID != "abd67890" {
+		t.Errorf("runs not sorted by start time: %s, %s, %s", runs[0].ID, runs[1].ID, runs[2].ID)
+	}
+	if runs[0].Status != "crashed" {
+		t.Errorf("expected dead running run to be crashed, got %q", runs[0].Status)
+	}
+
+	got, err := mgr.GetRun("abd")
+	if err != nil {
+		t.Fatalf("GetRun by prefix failed: %v", err)
+	}
+	if len(got.Tasks) != 1 || got.Tasks[0].Task != "coder" {
+		t.Errorf("unexpected tasks: %+v", got.Tasks)
+	}
+	if _, err := mgr.GetRun("ab"); err == nil {
+		t.Error("expected error for ambiguous prefix")
+	}
+	if _, err := mgr.GetRun("zzz"); err == nil {
+		t.Error("expected error for unknown run")
+	}
+
+	if err := mgr.RemoveRun("abc12345"); err != nil {
+		t.Fatalf("RemoveRun failed: %v", err)
+	}
+	if _, err := mgr.GetRun("abc12345"); err == nil {
+		t.Error("expected removed run to be gone")
+	}
+}
+
+func TestAddNotePreservedByMergeUpdate(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{ID: GenerateID(), PID: os.Getpid(), StartedAt: time.Now(), Status: "running"}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := mgr.AddNote(agent.ID, "paused while we fix CI"); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	// The runner's stale copy must not drop the note
+	agent.CurrentIter = 2
+	if err := mgr.MergeUpdate(agent); err != nil {
+		t.Fatalf("MergeUpdate failed: %v", err)
+	}
+
+	got, err := mgr.Get(agent.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(got.Notes) != 1 || got.Notes[0].Text != "paused while we fix CI" || got.Notes[0].Time.IsZero() {
+		t.Fatalf("Notes = %+v, want one timestamped note", got.Notes)
+	}
+
+	if err := mgr.ClearNotes(agent.ID); err != nil {
+		t.Fatalf("ClearNotes failed: %v", err)
+	}
+	got, _ = mgr.Get(agent.ID)
+	if len(got.Notes) != 0 {
+		t.Errorf("Notes = %+v, want none after ClearNotes", got.Notes)
+	}
+}
+
+func TestPausedUntilResumeIfDue(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{ID: GenerateID(), PID: os.Getpid(), StartedAt: time.Now(), Status: "running"}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := mgr.SetPausedUntil(agent.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SetPausedUntil failed: %v", err)
+	}
+	if resumed, err := mgr.ResumeIfDue(agent.ID); err != nil || resumed {
+		t.Fatalf("ResumeIfDue before deadline = %v, %v; want false", resumed, err)
+	}
+
+	// A runner's MergeUpdate keeps the timer
+	if err := mgr.MergeUpdate(agent); err != nil {
+		t.Fatalf("MergeUpdate failed: %v", err)
+	}
+	got, _ := mgr.Get(agent.ID)
+	if !got.Paused || got.ResumeAt == nil {
+		t.Fatalf("Paused = %v, ResumeAt = %v; want timed pause preserved", got.Paused, got.ResumeAt)
+	}
+
+	if err := mgr.SetPausedUntil(agent.ID, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("SetPausedUntil failed: %v", err)
+	}
+	if resumed, err := mgr.ResumeIfDue(agent.ID); err != nil || !resumed {
+		t.Fatalf("ResumeIfDue after deadline = %v, %v; want true", resumed, err)
+	}
+	got, _ = mgr.Get(agent.ID)
+	if got.Paused || got.ResumeAt != nil {
+		t.Errorf("Paused = %v, ResumeAt = %v; want resumed", got.Paused, got.ResumeAt)
+	}
+
+	// A plain pause has no timer
+	mgr.SetPausedUntil(agent.ID, time.Now().Add(-time.Second))
+	mgr.SetPaused(agent.ID, true)
+	if resumed, _ := mgr.ResumeIfDue(agent.ID); resumed {
+		t.Error("SetPaused should clear the resume timer")
+	}
+}
+
+func TestSetPausedMany(t *testing.T) {
+	mgr := newTestManager(t)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		agent := &AgentState{ID: GenerateID(), PID: os.Getpid(), StartedAt: time.Now(), Status: "running"}
+		if err := mgr.Register(agent); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		ids = append(ids, agent.ID)
+	}
+
+	resumeAt := time.Now().Add(time.Hour)
+	if err := mgr.SetPausedMany(ids[:2], true, &resumeAt); err != nil {
+		t.Fatalf("SetPausedMany failed: %v", err)
+	}
+	for i, id := range ids {
+		got, _ := mgr.Get(id)
+		if wantPaused := i < 2; got.Paused != wantPaused || (got.ResumeAt != nil) != wantPaused {
+			t.Errorf("agent %d: Paused = %v, ResumeAt = %v; want paused = %v", i, got.Paused, got.ResumeAt, wantPaused)
+		}
+	}
+
+	// An unknown agent fails the whole batch
+	if err := mgr.SetPausedMany([]string{ids[2], "missing"}, true, nil); err == nil {
+		t.Error("expected error for unknown agent")
+	}
+	if got, _ := mgr.Get(ids[2]); got.Paused {
+		t.Error("no agent should be updated when the batch fails")
+	}
+
+	if err := mgr.SetPausedMany(ids, false, nil); err != nil {
+		t.Fatalf("SetPausedMany failed: %v", err)
+	}
+	for i, id := range ids {
+		if got, _ := mgr.Get(id); got.Paused || got.ResumeAt != nil {
+			t.Errorf("agent %d still paused", i)
+		}
+	}
+}
+
+func TestAwaitApproval(t *testing.T) {
+	mgr := newTestManager(t)
+	agent := &AgentState{ID: GenerateID(), PID: os.Getpid(), StartedAt: time.Now(), Status: "running"}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := mgr.AwaitApproval([]string{agent.ID}, "merge the migration"); err != nil {
+		t.Fatalf("AwaitApproval failed: %v", err)
+	}
+	got, _ := mgr.Get(agent.ID)
+	if !got.Paused || got.Approval != "merge the migration" {
+		t.Errorf("Paused = %v, Approval = %q; want an approval pause", got.Paused, got.Approval)
+	}
+
+	// The runner's own updates keep the request
+	got.CurrentIter = 2
+	if err := mgr.MergeUpdate(got); err != nil {
+		t.Fatalf("MergeUpdate failed: %v", err)
+	}
+	if got, _ := mgr.Get(agent.ID); got.Approval != "merge the migration" {
+		t.Errorf("MergeUpdate dropped the approval request, got %q", got.Approval)
+	}
+
+	// Resuming clears it
+	if err := mgr.SetPaused(agent.ID, false); err != nil {
+		t.Fatalf("SetPaused failed: %v", err)
+	}
+	if got, _ := mgr.Get(agent.ID); got.Paused || got.Approval != "" {
+		t.Errorf("Paused = %v, Approval = %q; want resumed", got.Paused, got.Approval)
+	}
+}
+
+func TestRecordHealth(t *testing.T) {
+	a := &AgentState{Status: "running"}
+	checked := time.Now()
+	a.RecordHealth(os.ErrDeadlineExceeded, checked)
+	if !a.Unhealthy() || a.HealthError == "" || a.HealthCheckedAt == nil || !a.HealthCheckedAt.Equal(checked) {
+		t.Errorf("expected an unhealthy agent, got %+v", a)
+	}
+
+	a.RecordHealth(nil, checked)
+	if a.Unhealthy() || a.Health != "healthy" || a.HealthError != "" {
+		t.Errorf("expected a healthy agent, got %+v", a)
+	}
+
+	a.RecordHealth(os.ErrDeadlineExceeded, checked)
+	a.Status = "terminated"
+	if a.Unhealthy() {
+		t.Error("terminated agents are not reported unhealthy")
+	}
+}
+
+func TestCheckCostAlert(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.scope = scope.ScopeProject
+	mgr.workingDir = "/repo"
+	mgr.projectRoot = "/repo"
+
+	now := time.Now()
+	for _, a := range []*AgentState{
+		{ID: "agent001", WorkingDir: "/repo", StartedAt: now, Status: "terminated", TotalCost: 15},
+		{ID: "agent002", WorkingDir: "/repo/api", StartedAt: now, Status: "running", TotalCost: 5},
+		{ID: "other003", WorkingDir: "/elsewhere", StartedAt: now, Status: "running", TotalCost: 100},
+	} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	if _, crossed, err := mgr.CheckCostAlert(25); err != nil || crossed {
+		t.Fatalf("$20 of $25 should not alert (crossed=%v, err=%v)", crossed, err)
+	}
+
+	agent, _ := mgr.Get("agent002")
+	agent.TotalCost = 12
+	if err := mgr.MergeUpdate(agent); err != nil {
+		t.Fatalf("MergeUpdate failed: %v", err)
+	}
+	record, crossed, err := mgr.CheckCostAlert(25)
+	if err != nil || !crossed {
+		t.Fatalf("$27 of $25 should alert (crossed=%v, err=%v)", crossed, err)
+	}
+	if record.CostUSD != 27 || record.Project != "/repo" {
+		t.Errorf("record = %+v", record)
+	}
+	if _, crossed, _ := mgr.CheckCostAlert(25); crossed {
+		t.Error("an alert should only fire once per crossing")
+	}
+	if alerts, _ := mgr.CostAlerts(); len(alerts) != 1 || alerts[0].Threshold != 25 {
+		t.Errorf("CostAlerts() = %+v, want the fired alert", alerts)
+	}
+
+	// Another project doesn't see it
+	mgr.workingDir = "/elsewhere"
+	mgr.projectRoot = ""
+	if alerts, _ := mgr.CostAlerts(); len(alerts) != 0 {
+		t.Errorf("expected no alerts for another project, got %+v", alerts)
+	}
+	mgr.workingDir = "/repo"
+	mgr.projectRoot = "/repo"
+
+	// Raising the threshold above the total re-arms the alert
+	if _, crossed, _ := mgr.CheckCostAlert(50); crossed {
+		t.Error("$27 of $50 should not alert")
+	}
+	if alerts, _ := mgr.CostAlerts(); len(alerts) != 0 {
+		t.Errorf("expected the alert to be cleared, got %+v", alerts)
+	}
+	if _, crossed, _ := mgr.CheckCostAlert(25); !crossed {
+		t.Error("expected the alert to fire again after being re-armed")
+	}
+}
+
+func TestIterationDurations(t *testing.T) {
+	a := &AgentState{Status: "running"}
+	start := time.Now()
+
+	for _, d := range []time.Duration{4 * time.Second, 2 * time.Second} {
+		a.StartIteration(start)
+		a.FinishIteration(start.Add(d), true)
+	}
+	if m := a.MedianIterationDuration(); m != 0 {
+		t.Errorf("median with 2 iterations = %v, want 0 (too few)", m)
+	}
+
+	a.StartIteration(start)
+	a.FinishIteration(start.Add(9*time.Second), true)
+	if m := a.MedianIterationDuration(); m != 4*time.Second {
+		t.Errorf("median = %v, want 4s", m)
+	}
+	a.StartIteration(start)
+	a.FinishIteration(start.Add(time.Second), true)
+	if m := a.MedianIterationDuration(); m != 3*time.Second {
+		t.Errorf("even median = %v, want 3s", m)
+	}
+
+	// A stopped slow iteration isn't recorded
+	a.StartIteration(start)
+	a.SlowIteration = true
+	if !a.Slow() {
+		t.Error("expected a running agent with a slow iteration to be slow")
+	}
+	a.FinishIteration(start.Add(time.Hour), false)
+	if len(a.IterationDurations) != 4 || a.Slow() || a.IterationStartedAt != nil {
+		t.Errorf("after an unrecorded iteration: %v, slow=%v", a.IterationDurations, a.Slow())
+	}
+
+	for i := 0; i < 30; i++ {
+		a.StartIteration(start)
+		a.FinishIteration(start.Add(time.Minute), true)
+	}
+	if len(a.IterationDurations) != iterationHistorySize {
+		t.Errorf("history = %d, want %d", len(a.IterationDurations), iterationHistorySize)
+	}
+}
+
+func TestSuccessor(t *testing.T) {
+	mgr := newTestManager(t)
+	start := time.Now().Add(-time.Hour)
+
+	for _, a := range []*AgentState{
+		{ID: "first001", Name: "coder", WorkingDir: "/repo", StartedAt: start, Status: "terminated"},
+		{ID: "other002", Name: "coder", WorkingDir: "/elsewhere", StartedAt: start.Add(time.Minute), Status: "terminated"},
+		{ID: "second03", Name: "coder", WorkingDir: "/repo", StartedAt: start.Add(2 * time.Minute), Status: "terminated"},
+		{ID: "third004", Name: "coder", WorkingDir: "/repo", StartedAt: start.Add(3 * time.Minute), Status: "running"},
+		{ID: "older005", Name: "coder", WorkingDir: "/repo", StartedAt: start.Add(-time.Minute), Status: "terminated"},
+	} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	first, _ := mgr.Get("first001")
+	successor, err := mgr.Successor(first)
+	if err != nil || successor == nil || successor.ID != "third004" {
+		t.Errorf("Successor() = %v, %v; want third004", successor, err)
+	}
+
+	third, _ := mgr.Get("third004")
+	if successor, _ := mgr.Successor(third); successor != nil {
+		t.Errorf("the newest agent should have no successor, got %s", successor.ID)
+	}
+	if successor, _ := mgr.Successor(&AgentState{ID: "x", WorkingDir: "/repo", StartedAt: start}); successor != nil {
+		t.Error("an unnamed agent should have no successor")
+	}
+}
+
+func TestModelUsage(t *testing.T) {
+	agent := &AgentState{Model: "opus", InputTokens: 300, OutputTokens: 30, TotalCost: 3}
+
+	// Agents without per-model usage attribute everything to their model
+	legacy := agent.UsageByModel()
+	if len(legacy) != 1 || legacy["opus"].InputTokens != 300 || legacy["opus"].Cost != 3 {
+		t.Errorf("UsageByModel() without ModelUsage = %+v", legacy)
+	}
+
+	agent.AddModelUsage("opus", 100, 10, 1)
+	agent.AddModelUsage("sonnet", 200, 20, 2)
+	agent.AddModelUsage("opus", 50, 5, 0.5)
+	agent.AddModelUsage("", 1, 1, 0)
+	agent.AddModelUsage("haiku", 0, 0, 0)
+
+	byModel := agent.UsageByModel()
+	if len(byModel) != 3 {
+		t.Fatalf("UsageByModel() = %+v, want opus, sonnet, and default", byModel)
+	}
+	if got := byModel["opus"]; got.InputTokens != 150 || got.OutputTokens != 15 || got.Cost != 1.5 {
+		t.Errorf("opus usage = %+v", got)
+	}
+	if got := byModel["sonnet"]; got.InputTokens != 200 || got.Cost != 2 {
+		t.Errorf("sonnet usage = %+v", got)
+	}
+	if got := byModel[DefaultModelKey]; got.InputTokens != 1 {
+		t.Errorf("default model usage = %+v", got)
+	}
+}
+
+func TestHistoryAppendAndRemove(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{ID: "hist1234", Name: "coder", PID: os.Getpid(), Status: "running", StartedAt: time.Now()}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	records, err := mgr.History(agent.ID)
+	if err != nil || records != nil {
+		t.Fatalf("History before any iteration = %v, %v", records, err)
+	}
+
+	started := time.Now().Add(-time.Minute).Truncate(time.Second)
+	for i, status := range []string{"succeeded", "failed"} {
+		record := IterationRecord{Iteration: i + 1, StartedAt: started, FinishedAt: started.Add(30 * time.Second),
+			Status: status, InputTokens: 100, CostUSD: 0.5, Summary: "did things"}
+		if err := mgr.AppendHistory(agent.ID, record); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
+	}
+
+	// A line cut short by a crash is skipped
+	f, err := os.OpenFile(mgr.historyPath(agent.ID), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"iteration": 3, "sta`)
+	f.Close()
+
+	records, err = mgr.History(agent.ID)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Iteration != 1 || records[1].Status != "failed" || records[1].Duration() != 30*time.Second {
+		t.Errorf("History = %+v", records)
+	}
+
+	if err := mgr.Remove(agent.ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(mgr.historyPath(agent.ID)); !os.IsNotExist(err) {
+		t.Error("Remove left the agent's history behind")
+	}
+}
+
+func TestStopAgentsOlderThan(t *testing.T) {
+	mgr := newTestManager(t)
+
+	now := time.Now()
+	old := &AgentState{ID: "old12345", Name: "forgotten", PID: os.Getpid(), Status: "running", StartedAt: now.Add(-48 * time.Hour)}
+	young := &AgentState{ID: "new12345", Name: "fresh", PID: os.Getpid(), Status: "running", StartedAt: now.Add(-time.Hour)}
+	for _, a := range []*AgentState{old, young} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	stopped, err := mgr.StopAgentsOlderThan(24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("StopAgentsOlderThan failed: %v", err)
+	}
+	if len(stopped) != 1 || stopped[0].ID != old.ID {
+		t.Fatalf("stopped = %v, want only %s", stopped, old.ID)
+	}
+
+	got, err := mgr.Get(old.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxAgeExceededAt == nil || got.TerminateMode != "after_iteration" {
+		t.Errorf("old agent: MaxAgeExceededAt = %v, TerminateMode = %q", got.MaxAgeExceededAt, got.TerminateMode)
+	}
+	if reason := got.TerminationExitReason(); reason != ExitReasonMaxAge {
+		t.Errorf("TerminationExitReason() = %q, want %q", reason, ExitReasonMaxAge)
+	}
+	if got, _ := mgr.Get(young.ID); got.MaxAgeExceededAt != nil || got.TerminateMode != "" {
+		t.Errorf("young agent was stopped: %+v", got)
+	}
+
+	// Within the grace period an already flagged agent is left alone
+	stopped, err = mgr.StopAgentsOlderThan(24*time.Hour, now.Add(time.Minute))
+	if err != nil || len(stopped) != 0 {
+		t.Errorf("second StopAgentsOlderThan = %v, %v, want nothing stopped", stopped, err)
+	}
+
+	// The agent's own writes keep the flag
+	got.TerminateMode = ""
+	got.MaxAgeExceededAt = nil
+	if err := mgr.MergeUpdate(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mgr.Get(old.ID); got.MaxAgeExceededAt == nil {
+		t.Error("MergeUpdate dropped MaxAgeExceededAt")
+	}
+}
+
+func TestLimitRemainingIterations(t *testing.T) {
+	mgr := newTestManager(t)
+
+	forever := &AgentState{ID: "lim12345", Name: "forever", PID: os.Getpid(), Status: "running", StartedAt: time.Now(), Iterations: 0, CurrentIter: 7}
+	short := &AgentState{ID: "lim67890", Name: "short", PID: os.Getpid(), Status: "running", StartedAt: time.Now(), Iterations: 9, CurrentIter: 8}
+	for _, a := range []*AgentState{forever, short} {
+		if err := mgr.Register(a); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	if got, err := mgr.LimitRemainingIterations(forever.ID, 3); err != nil || got != 10 {
+		t.Errorf("unlimited agent: LimitRemainingIterations = %d, %v, want 10", got, err)
+	}
+	// An agent that stops sooner keeps its count
+	if got, err := mgr.LimitRemainingIterations(short.ID, 3); err != nil || got != 9 {
+		t.Errorf("short agent: LimitRemainingIterations = %d, %v, want 9", got, err)
+	}
+	if a, _ := mgr.Get(forever.ID); a.Iterations != 10 {
+		t.Errorf("stored Iterations = %d, want 10", a.Iterations)
+	}
+}
+
+func TestMessageQueue(t *testing.T) {
+	mgr := newTestManager(t)
+
+	agent := &AgentState{ID: "msg12345", Name: "coder", PID: os.Getpid(), Status: "running", StartedAt: time.Now()}
+	if err := mgr.Register(agent); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if messages, err := mgr.TakeMessages(agent.ID); err != nil || messages != nil {
+		t.Fatalf("TakeMessages with none queued = %v, %v", messages, err)
+	}
+	for i, text := range []string{"update the changelog", "and the README"} {
+		n, err := mgr.QueueMessage(agent.ID, text)
+		if err != nil || n != i+1 {
+			t.Fatalf("QueueMessage = %d, %v", n, err)
+		}
+	}
+	if messages, err := mgr.Messages(agent.ID); err != nil || len(messages) != 2 {
+		t.Fatalf("Messages = %v, %v", messages, err)
+	}
+
+	messages, err := mgr.TakeMessages(agent.ID)
+	if err != nil || len(messages) != 2 || messages[0].Text != "update the changelog" || messages[1].Text != "and the README" {
+		t.Fatalf("TakeMessages = %+v, %v", messages, err)
+	}
+	if messages, _ := mgr.TakeMessages(agent.ID); len(messages) != 0 {
+		t.Errorf("messages were delivered twice: %+v", messages)
+	}
+
+	mgr.QueueMessage(agent.ID, "never delivered")
+	if err := mgr.Remove(agent.ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(mgr.messagesPath(agent.ID)); !os.IsNotExist(err) {
+		t.Error("Remove left the agent's messages behind")
+	}
+}
+
+func TestScheduleRecords(t *testing.T) {
+	mgr := newTestManager(t)
+
+	records, err := mgr.Schedules("/work/project")
+	if err != nil || len(records) != 0 {
+		t.Fatalf("Schedules before any run = %v, %v", records, err)
+	}
+
+	lastRun := time.Now().Truncate(time.Second)
+	nextRun := lastRun.Add(24 * time.Hour)
+	record := ScheduleRecord{Schedule: "0 2 * * *", LastRun: &lastRun, LastResult: ScheduleStarted, NextRun: &nextRun}
+	if err := mgr.UpdateSchedule("/work/project", "pipeline:nightly", record); err != nil {
+		t.Fatalf("UpdateSchedule failed: %v", err)
+	}
+	if err := mgr.UpdateSchedule("/work/project", "task:report", ScheduleRecord{Schedule: "@daily"}); err != nil {
+		t.Fatalf("UpdateSchedule failed: %v", err)
+	}
+
+	records, err = mgr.Schedules("/work/project")
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Schedules = %v, %v", records, err)
+	}
+	got := records["pipeline:nightly"]
+	if got.LastResult != ScheduleStarted || !got.LastRun.Equal(lastRun) || !got.NextRun.Equal(nextRun) {
+		t.Errorf("record = %+v", got)
+	}
+	if other, _ := mgr.Schedules("/work/other"); len(other) != 0 {
+		t.Errorf("schedules of another project = %v", other)
+	}
+}
@@ -0,0 +1,42 @@
+package state
+
+import "strings"
+
+// Manifest records the versions of the tools an agent started with, so a run
+// that behaves differently from an earlier one can be traced to what changed.
+type Manifest struct {
+	SwarmVersion string `json:"swarm_version"`
+	SwarmCommit  string `json:"swarm_commit,omitempty"`
+	AgentCLI     string `json:"agent_cli,omitempty"`     // Executable of the agent command
+	AgentVersion string `json:"agent_version,omitempty"` // First line of its --version output
+	GitCommit    string `json:"git_commit,omitempty"`    // HEAD of the working directory's repo
+	GitBranch    string `json:"git_branch,omitempty"`
+	GitDirty     bool   `json:"git_dirty,omitempty"` // The working tree had uncommitted changes
+}
+
+// String formats the manifest on one line, e.g.
+// "swarm v1.2.0 · claude 2.0.14 (Claude Code) · git main@3f2a9c1 (dirty)".
+func (m *Manifest) String() string {
+	parts := []string{"swarm " + m.SwarmVersion}
+	if m.AgentCLI != "" {
+		agent := m.AgentCLI
+		if m.AgentVersion != "" {
+			agent += " " + m.AgentVersion
+		}
+		parts = append(parts, agent)
+	}
+	if m.GitCommit != "" {
+		commit := m.GitCommit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		if m.GitBranch != "" {
+			commit = m.GitBranch + "@" + commit
+		}
+		if m.GitDirty {
+			commit += " (dirty)"
+		}
+		parts = append(parts, "git "+commit)
+	}
+	return strings.Join(parts, " · ")
+}
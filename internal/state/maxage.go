@@ -0,0 +1,69 @@
+package state
+
+import "time"
+
+// ExitReasonMaxAge is the exit reason of an agent stopped for running longer
+// than max_agent_age.
+const ExitReasonMaxAge = "max_age"
+
+// MaxAgeGracePeriod is how long an agent over max_agent_age has to finish its
+// current iteration before StopAgentsOlderThan kills it.
+const MaxAgeGracePeriod = 30 * time.Minute
+
+// TerminationExitReason returns the exit reason of an agent stopped through
+// its TerminateMode: max_age if StopAgentsOlderThan stopped it, otherwise
+// killed.
+func (a *AgentState) TerminationExitReason() string {
+	if a.MaxAgeExceededAt != nil {
+		return ExitReasonMaxAge
+	}
+	return "killed"
+}
+
+// StopAgentsOlderThan stops the running agents in scope that started more
+// than maxAge before now: each is flagged (MaxAgeExceededAt) and told to
+// terminate after its current iteration, and one still running
+// MaxAgeGracePeriod after that is killed. Returns the agents it flagged or
+// killed.
+func (m *Manager) StopAgentsOlderThan(maxAge time.Duration, now time.Time) ([]*AgentState, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var stopped []*AgentState
+	for id, entry := range idx.Agents {
+		if entry.Status != "running" || !m.entryInScope(entry) || now.Sub(entry.StartedAt) <= maxAge {
+			continue
+		}
+		agent, err := m.loadAgent(id)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case agent.MaxAgeExceededAt == nil:
+			agent.MaxAgeExceededAt = &now
+			if agent.TerminateMode != "immediate" {
+				agent.TerminateMode = "after_iteration"
+			}
+		case now.Sub(*agent.MaxAgeExceededAt) > MaxAgeGracePeriod && agent.TerminateMode != "immediate":
+			agent.TerminateMode = "immediate"
+			agent.ExitReason = ExitReasonMaxAge
+			_ = agent.ForceKill()
+		default:
+			continue
+		}
+		if err := m.putAgent(idx, agent); err != nil {
+			return stopped, err
+		}
+		stopped = append(stopped, agent)
+	}
+	return stopped, nil
+}
@@ -0,0 +1,94 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// messagesDirName is the directory (next to the lock file) holding the
+// instructions queued for agents with `swarm exec`, one file per agent.
+const messagesDirName = "messages"
+
+// Message is a one-off instruction queued for a running agent, added to the
+// prompt of its next iteration.
+type Message struct {
+	Text     string    `json:"text"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// messagesPath returns the path of the file holding an agent's queued messages.
+func (m *Manager) messagesPath(id string) string {
+	return filepath.Join(filepath.Dir(m.lockPath), messagesDirName, id+".json")
+}
+
+// QueueMessage queues an instruction for an agent's next iteration and
+// returns how many messages are now queued for it.
+func (m *Manager) QueueMessage(id, text string) (int, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer m.unlock(fl)
+
+	path := m.messagesPath(id)
+	var messages []Message
+	if err := readJSONFile(path, &messages); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read messages of agent %s: %w", id, err)
+	}
+	messages = append(messages, Message{Text: text, QueuedAt: time.Now()})
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create messages directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return 0, fmt.Errorf("failed to queue message for agent %s: %w", id, err)
+	}
+	return len(messages), nil
+}
+
+// Messages returns the messages queued for an agent, oldest first.
+func (m *Manager) Messages(id string) ([]Message, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	var messages []Message
+	if err := readJSONFile(m.messagesPath(id), &messages); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read messages of agent %s: %w", id, err)
+	}
+	return messages, nil
+}
+
+// TakeMessages removes and returns the messages queued for an agent, oldest
+// first, so each is delivered to exactly one iteration.
+func (m *Manager) TakeMessages(id string) ([]Message, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	path := m.messagesPath(id)
+	var messages []Message
+	if err := readJSONFile(path, &messages); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read messages of agent %s: %w", id, err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to clear messages of agent %s: %w", id, err)
+	}
+	// Delivered messages must not come back from the backup
+	os.Remove(path + backupSuffix)
+	return messages, nil
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/mj1618/swarm-cli/internal/process"
+)
+
+// ProcessAlive reports whether the agent's process is still running: its PID
+// exists and, when the process start was recorded, belongs to the same
+// process rather than one the PID was reused for.
+func (a *AgentState) ProcessAlive() bool {
+	return isProcessRunning(a.PID) && process.SameProcess(a.PID, a.ProcessStart)
+}
+
+// Kill sends the agent's process a termination signal, unless its PID now
+// belongs to another process.
+func (a *AgentState) Kill() error {
+	if err := a.checkProcess(); err != nil {
+		return err
+	}
+	return process.Kill(a.PID)
+}
+
+// ForceKill kills the agent's process and its descendants (see
+// process.ForceKill), unless its PID now belongs to another process.
+func (a *AgentState) ForceKill() error {
+	if err := a.checkProcess(); err != nil {
+		return err
+	}
+	return process.ForceKill(a.PID)
+}
+
+// checkProcess returns an error if the agent's PID was reused by another
+// process, which must not be signalled.
+func (a *AgentState) checkProcess() error {
+	if a.PID > 0 && !process.SameProcess(a.PID, a.ProcessStart) {
+		return fmt.Errorf("PID %d now belongs to another process", a.PID)
+	}
+	return nil
+}
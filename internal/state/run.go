@@ -0,0 +1,164 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runsDirName is the directory (next to the lock file) holding pipeline runs.
+const runsDirName = "runs"
+
+// RunState records one execution of a pipeline, so logs, outputs, and costs
+// can be grouped per run rather than only per agent.
+type RunState struct {
+	ID         string     `json:"id"`
+	Pipeline   string     `json:"pipeline"`
+	AgentID    string     `json:"agent_id,omitempty"` // Detached pipeline agent executing the run
+	PID        int        `json:"pid"`
+	WorkingDir string     `json:"working_dir"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Status     string     `json:"status"` // running, completed, failed, terminated, crashed
+	Error      string     `json:"error,omitempty"`
+
+	Iterations          int      `json:"iterations"` // Planned iterations (0 = unlimited)
+	CompletedIterations int      `json:"completed_iterations"`
+	OutputDirs          []string `json:"output_dirs,omitempty"` // One per iteration
+
+	Tasks []RunTask `json:"tasks,omitempty"`
+
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	TotalCost    float64 `json:"total_cost_usd"`
+}
+
+// RunTask records one execution of a task within a pipeline run.
+type RunTask struct {
+	Task         string        `json:"task"`
+	Iteration    int           `json:"iteration"`
+	Status       string        `json:"status"` // succeeded, failed
+	Error        string        `json:"error,omitempty"`
+	StartedAt    time.Time     `json:"started_at"`
+	Duration     time.Duration `json:"duration_ns"`
+	InputTokens  int64         `json:"input_tokens"`
+	OutputTokens int64         `json:"output_tokens"`
+	TotalCost    float64       `json:"total_cost_usd"`
+	Mitigation   string        `json:"mitigation,omitempty"` // How a context overflow was retried
+}
+
+// runPath returns the path of the file holding a run's state.
+func (m *Manager) runPath(id string) string {
+	return filepath.Join(filepath.Dir(m.lockPath), runsDirName, id+".json")
+}
+
+// SaveRun writes a run's state.
+func (m *Manager) SaveRun(run *RunState) error {
+	fl, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock(fl)
+
+	path := m.runPath(run.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create runs directory: %w", err)
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// GetRun returns a run by ID or unique ID prefix.
+func (m *Manager) GetRun(id string) (*RunState, error) {
+	runs, err := m.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*RunState
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+		if strings.HasPrefix(run.ID, id) {
+			matches = append(matches, run)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("run not found: %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("run ID %q is ambiguous (%d matches)", id, len(matches))
+	}
+}
+
+// ListRuns returns the runs in scope, oldest first. Runs whose process died
+// without recording an outcome are reported as crashed.
+func (m *Manager) ListRuns() ([]*RunState, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	dir := filepath.Join(filepath.Dir(m.lockPath), runsDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var runs []*RunState
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		var run RunState
+		if err := readJSONFile(filepath.Join(dir, name), &run); err != nil {
+			continue
+		}
+		if !m.inScope(run.WorkingDir) {
+			continue
+		}
+		if run.Status == "running" && !isProcessRunning(run.PID) {
+			run.Status = "crashed"
+		}
+		runs = append(runs, &run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+// RemoveRun deletes a run's state.
+func (m *Manager) RemoveRun(id string) error {
+	fl, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock(fl)
+
+	path := m.runPath(id)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	os.Remove(path + backupSuffix)
+	return nil
+}
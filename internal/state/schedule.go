@@ -0,0 +1,79 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// schedulesDirName is the directory (next to the lock file) holding the runs
+// of `swarm scheduler`, one file per project.
+const schedulesDirName = "schedules"
+
+// Schedule outcomes recorded in ScheduleRecord.LastResult.
+const (
+	ScheduleStarted = "started"
+	ScheduleSkipped = "skipped"
+	ScheduleFailed  = "failed"
+)
+
+// ScheduleRecord records the runs of a scheduled pipeline or task.
+type ScheduleRecord struct {
+	Schedule   string     `json:"schedule"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	LastResult string     `json:"last_result,omitempty"` // started, skipped, failed
+	LastError  string     `json:"last_error,omitempty"`  // Why the last run was skipped or failed
+	NextRun    *time.Time `json:"next_run,omitempty"`
+}
+
+// schedulesPath returns the path of the file holding a project's schedule records.
+func (m *Manager) schedulesPath(project string) string {
+	return filepath.Join(filepath.Dir(m.lockPath), schedulesDirName, CounterKey("schedules", project)+".json")
+}
+
+// Schedules returns the schedule records of a project, keyed by scheduled
+// pipeline or task (e.g. "pipeline:nightly").
+func (m *Manager) Schedules(project string) (map[string]ScheduleRecord, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	return m.readSchedules(project)
+}
+
+// UpdateSchedule stores the record of one of a project's schedules.
+func (m *Manager) UpdateSchedule(project, key string, record ScheduleRecord) error {
+	fl, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock(fl)
+
+	records, err := m.readSchedules(project)
+	if err != nil {
+		return err
+	}
+	records[key] = record
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := m.schedulesPath(project)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create schedules directory: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+func (m *Manager) readSchedules(project string) (map[string]ScheduleRecord, error) {
+	records := make(map[string]ScheduleRecord)
+	if err := readJSONFile(m.schedulesPath(project), &records); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read schedules: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mj1618/swarm-cli/internal/todoqueue"
+)
+
+// todoQueueDirName is the directory (next to the lock file) holding todo
+// queue histories.
+const todoQueueDirName = "todoqueue"
+
+// SampleTodoQueue scans the todo queue in todoDir and doneDir, records a
+// sample in its history, and returns the queue's metrics. It returns nil if
+// todoDir doesn't exist. The history is shared by every agent and viewer
+// sampling the same queue.
+func (m *Manager) SampleTodoQueue(todoDir, doneDir string) (*todoqueue.Metrics, error) {
+	fl, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(fl)
+
+	snap, err := todoqueue.Scan(todoDir, doneDir)
+	if err != nil || snap == nil {
+		return nil, err
+	}
+
+	path := filepath.Join(filepath.Dir(m.lockPath), todoQueueDirName, CounterKey(todoDir)+".json")
+	var history todoqueue.History
+	if err := readJSONFile(path, &history); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read todo queue history: %w", err)
+	}
+
+	now := time.Now()
+	if !history.Record(snap, now) {
+		return history.Metrics(now), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create todo queue directory: %w", err)
+	}
+	data, err := json.Marshal(history)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write todo queue history: %w", err)
+	}
+	return history.Metrics(now), nil
+}
@@ -0,0 +1,87 @@
+package state
+
+import "github.com/mj1618/swarm-cli/internal/logparser"
+
+// recoverUsage brings a crashed agent's token and cost totals up to date from
+// its log file. A runner that dies mid-iteration never records that
+// iteration's usage, but the agent CLI's usage events are still in the log.
+// The log scan is checkpointed, so repeated scans only read new output.
+func recoverUsage(agent *AgentState) {
+	if agent.LogFile == "" {
+		return
+	}
+	stats, err := logparser.ScanLogFileCached(agent.LogFile)
+	if err != nil {
+		return
+	}
+
+	if stats.InputTokens > agent.InputTokens {
+		agent.InputTokens = stats.InputTokens
+	}
+	if stats.OutputTokens > agent.OutputTokens {
+		agent.OutputTokens = stats.OutputTokens
+	}
+	if stats.TotalCostUSD > agent.TotalCost {
+		agent.TotalCost = stats.TotalCostUSD
+	}
+}
+
+// DefaultModelKey is the ModelUsage key for usage of the agent CLI's default
+// model, when no model was requested or reported.
+const DefaultModelKey = "default"
+
+// ModelUsage is the token usage and cost attributed to one model.
+type ModelUsage struct {
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost_usd"`
+}
+
+// AddModelUsage adds usage to the agent's totals for model.
+func (a *AgentState) AddModelUsage(model string, inputTokens, outputTokens int64, cost float64) {
+	a.ModelUsage = AddModelUsage(a.ModelUsage, model, inputTokens, outputTokens, cost)
+}
+
+// AddModelUsage adds usage to byModel's totals for model, allocating byModel
+// if it's nil, and returns it.
+func AddModelUsage(byModel map[string]*ModelUsage, model string, inputTokens, outputTokens int64, cost float64) map[string]*ModelUsage {
+	if inputTokens == 0 && outputTokens == 0 && cost == 0 {
+		return byModel
+	}
+	if model == "" {
+		model = DefaultModelKey
+	}
+	if byModel == nil {
+		byModel = make(map[string]*ModelUsage)
+	}
+	usage := byModel[model]
+	if usage == nil {
+		usage = &ModelUsage{}
+		byModel[model] = usage
+	}
+	usage.InputTokens += inputTokens
+	usage.OutputTokens += outputTokens
+	usage.Cost += cost
+	return byModel
+}
+
+// UsageByModel returns the agent's usage split by model. Agents that predate
+// per-model accounting have all their usage attributed to their model.
+func (a *AgentState) UsageByModel() map[string]ModelUsage {
+	byModel := make(map[string]ModelUsage)
+	if len(a.ModelUsage) == 0 {
+		if a.InputTokens == 0 && a.OutputTokens == 0 && a.TotalCost == 0 {
+			return byModel
+		}
+		model := a.Model
+		if model == "" {
+			model = DefaultModelKey
+		}
+		byModel[model] = ModelUsage{InputTokens: a.InputTokens, OutputTokens: a.OutputTokens, Cost: a.TotalCost}
+		return byModel
+	}
+	for model, usage := range a.ModelUsage {
+		byModel[model] = *usage
+	}
+	return byModel
+}
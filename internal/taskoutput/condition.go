@@ -0,0 +1,60 @@
+package taskoutput
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// conditionRegex matches "<field>", "<field> == <value>", and "<field> != <value>".
+var conditionRegex = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*(?:(==|!=)\s*(.*?))?\s*$`)
+
+// Condition is a test on a field of a task's structured output, used by
+// depends_on `when:` expressions.
+type Condition struct {
+	Field string
+	Op    string // "==", "!=", or "" for a truthiness test
+	Value string
+}
+
+// ParseCondition parses an expression of the form "field", "field == value",
+// or "field != value". Values may be quoted.
+func ParseCondition(expr string) (*Condition, error) {
+	m := conditionRegex.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid condition %q: expected \"field\", \"field == value\", or \"field != value\"", expr)
+	}
+	c := &Condition{Field: m[1], Op: m[2], Value: unquote(m[3])}
+	if c.Op != "" && m[3] == "" {
+		return nil, fmt.Errorf("invalid condition %q: missing value after %s", expr, c.Op)
+	}
+	return c, nil
+}
+
+// Eval evaluates the condition against a structured output. A missing output
+// or field only satisfies "!=" comparisons.
+func (c *Condition) Eval(output map[string]interface{}) bool {
+	v, ok := Lookup(output, c.Field)
+	switch c.Op {
+	case "==":
+		return ok && Format(v) == c.Value
+	case "!=":
+		return !ok || Format(v) != c.Value
+	}
+	return ok && truthy(v)
+}
+
+// String returns the condition as an expression.
+func (c *Condition) String() string {
+	if c.Op == "" {
+		return c.Field
+	}
+	return fmt.Sprintf("%s %s %s", c.Field, c.Op, c.Value)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,170 @@
+package taskoutput
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Schema declares the shape of a task's structured output. It supports a
+// subset of JSON Schema: type, properties, required, items, and enum.
+type Schema struct {
+	Type       string             `yaml:"type" json:"type,omitempty"`
+	Properties map[string]*Schema `yaml:"properties" json:"properties,omitempty"`
+	Required   []string           `yaml:"required" json:"required,omitempty"`
+	Items      *Schema            `yaml:"items" json:"items,omitempty"`
+	Enum       []interface{}      `yaml:"enum" json:"enum,omitempty"`
+}
+
+// validTypes are the supported values of Schema.Type.
+var validTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// Check reports errors in the schema itself.
+func (s *Schema) Check() error {
+	return s.check("output_schema")
+}
+
+func (s *Schema) check(path string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Type != "" && !validTypes[s.Type] {
+		return fmt.Errorf("%s: unsupported type %q (use object, array, string, number, integer, boolean, or null)", path, s.Type)
+	}
+	for _, name := range s.Required {
+		if s.Properties != nil && s.Properties[name] == nil {
+			return fmt.Errorf("%s: required field %q is not in properties", path, name)
+		}
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		if err := s.Properties[name].check(path + "." + name); err != nil {
+			return err
+		}
+	}
+	return s.Items.check(path + "[]")
+}
+
+// Validate checks a decoded JSON value against the schema.
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(value, "")
+}
+
+func (s *Schema) validate(value interface{}, path string) error {
+	if s == nil {
+		return nil
+	}
+	where := path
+	if where == "" {
+		where = "output"
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		return fmt.Errorf("%s: expected %s, got %s", where, s.Type, typeName(value))
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if Format(allowed) == Format(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowed := make([]string, len(s.Enum))
+			for i, v := range s.Enum {
+				allowed[i] = Format(v)
+			}
+			return fmt.Errorf("%s: %s is not one of [%s]", where, Format(value), strings.Join(allowed, ", "))
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required field %q", where, joinPath(path, name))
+			}
+		}
+		for _, name := range sortedKeys(s.Properties) {
+			if v, ok := val[name]; ok {
+				if err := s.Properties[name].validate(v, joinPath(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasType reports whether value is of the given schema type.
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// typeName returns the schema type name of a decoded JSON value.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+// Package taskoutput implements the structured task output contract: a
+// pipeline task may write a JSON object to swarm-output.json in its own
+// directory under SWARM_STATE_DIR. Swarm validates it against the task's
+// declared output_schema and exposes its fields to downstream prompts
+// ({{output:task.field}}) and depends_on conditions.
+package taskoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileName is the name of a task's structured output file.
+const FileName = "swarm-output.json"
+
+// Path returns where taskName writes its structured output within a
+// pipeline iteration's state directory.
+func Path(stateDir, taskName string) string {
+	return filepath.Join(stateDir, taskName, FileName)
+}
+
+// Read loads the structured output of taskName. The returned error satisfies
+// os.IsNotExist when the task wrote no structured output.
+func Read(stateDir, taskName string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(Path(stateDir, taskName))
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON object: %w", FileName, err)
+	}
+	return out, nil
+}
+
+// Lookup returns the value at a dotted field path (e.g. "result.status")
+// within a structured output.
+func Lookup(output map[string]interface{}, field string) (interface{}, bool) {
+	var cur interface{} = output
+	for _, part := range strings.Split(field, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = obj[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Format renders a field value for use in a prompt or comparison. Strings are
+// returned as-is; other values are rendered as JSON.
+func Format(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// truthy reports whether a field value counts as true in a condition.
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != "" && val != "false"
+	case float64:
+		return val != 0
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package taskoutput
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestReadAndLookup(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Read(dir, "coder"); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "coder"), 0755)
+	os.WriteFile(Path(dir, "coder"), []byte(`{"files": ["a.go"], "summary": {"ok": true, "count": 2}}`), 0644)
+
+	out, err := Read(dir, "coder")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if v, ok := Lookup(out, "summary.count"); !ok || Format(v) != "2" {
+		t.Errorf("summary.count = %v, %v", v, ok)
+	}
+	if v, ok := Lookup(out, "files"); !ok || Format(v) != `["a.go"]` {
+		t.Errorf("files = %v, %v", Format(v), ok)
+	}
+	if _, ok := Lookup(out, "summary.missing"); ok {
+		t.Error("expected missing field lookup to fail")
+	}
+
+	os.WriteFile(Path(dir, "coder"), []byte(`not json`), 0644)
+	if _, err := Read(dir, "coder"); err == nil || os.IsNotExist(err) {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	var schema Schema
+	err := yaml.Unmarshal([]byte(`
+type: object
+required: [status, files]
+properties:
+  status: {type: string, enum: [pass, fail]}
+  files:
+    type: array
+    items: {type: string}
+  count: {type: integer}
+`), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Check(); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   map[string]interface{}
+		wantErr string
+	}{
+		{"valid", map[string]interface{}{"status": "pass", "files": []interface{}{"a.go"}, "count": float64(2)}, ""},
+		{"missing required", map[string]interface{}{"status": "pass"}, `missing required field "files"`},
+		{"enum", map[string]interface{}{"status": "maybe", "files": []interface{}{}}, "not one of [pass, fail]"},
+		{"item type", map[string]interface{}{"status": "fail", "files": []interface{}{float64(1)}}, "files[0]: expected string, got number"},
+		{"integer", map[string]interface{}{"status": "fail", "files": []interface{}{}, "count": 1.5}, "count: expected integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	bad := &Schema{Type: "object", Properties: map[string]*Schema{"n": {Type: "float"}}}
+	if err := bad.Check(); err == nil || !strings.Contains(err.Error(), "output_schema.n") {
+		t.Errorf("expected unsupported type error, got %v", err)
+	}
+	missing := &Schema{Type: "object", Required: []string{"x"}, Properties: map[string]*Schema{"y": {Type: "string"}}}
+	if err := missing.Check(); err == nil {
+		t.Error("expected error for required field not in properties")
+	}
+	var none *Schema
+	if err := none.Check(); err != nil {
+		t.Errorf("nil schema should be valid, got %v", err)
+	}
+}
+
+func TestCondition(t *testing.T) {
+	output := map[string]interface{}{
+		"status": "pass",
+		"count":  float64(0),
+		"ok":     true,
+		"result": map[string]interface{}{"score": float64(9.5)},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"status == pass", true},
+		{`status == "fail"`, false},
+		{"status != fail", true},
+		{"result.score == 9.5", true},
+		{"ok", true},
+		{"count", false},
+		{"missing", false},
+		{"missing != x", true},
+		{"ok == true", true},
+	}
+	for _, tt := range tests {
+		cond, err := ParseCondition(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCondition(%q) failed: %v", tt.expr, err)
+		}
+		if got := cond.Eval(output); got != tt.want {
+			t.Errorf("%q evaluated to %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "status ==", "a b"} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("expected ParseCondition(%q) to fail", expr)
+		}
+	}
+	if cond, _ := ParseCondition("status==pass"); cond.Eval(nil) {
+		t.Error("condition on missing output should not hold")
+	}
+}
@@ -0,0 +1,70 @@
+package todoqueue
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PollInterval is how often WaitForRoom checks the queue again.
+var PollInterval = 10 * time.Second
+
+// Pending returns the number of task files in todoDir, including
+// subdirectories, waiting for a doer. A missing todoDir has none.
+func Pending(todoDir string) (int, error) {
+	pending := 0
+	err := filepath.WalkDir(todoDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == todoDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		for _, suffix := range queuedSuffixes {
+			if strings.HasSuffix(d.Name(), suffix) {
+				pending++
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan %s: %w", todoDir, err)
+	}
+	return pending, nil
+}
+
+// WaitForRoom blocks a planner with max_pending while more than max task
+// files wait in todoDir, checking every PollInterval. waiting is called with
+// the count when it starts to wait. It returns false if ctx is done, or stop
+// returns true, before the queue has room. A queue that can't be read is
+// treated as having room.
+func WaitForRoom(ctx context.Context, todoDir string, max int, waiting func(pending int), stop func() bool) bool {
+	waited := false
+	for {
+		pending, err := Pending(todoDir)
+		if err != nil || pending <= max {
+			return true
+		}
+		if !waited && waiting != nil {
+			waiting(pending)
+		}
+		waited = true
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(PollInterval):
+		}
+		if stop != nil && stop() {
+			return false
+		}
+	}
+}
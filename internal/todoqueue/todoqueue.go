@@ -0,0 +1,234 @@
+// Package todoqueue measures the todo-file queue planners and doers
+// coordinate through: planners write <name>.todo.md files, doers claim one by
+// renaming it to .processing.md, and move it to the done directory when
+// finished.
+package todoqueue
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Default directories of the queue, relative to the working directory.
+const (
+	DefaultTodoDir = "swarm/todos"
+	DefaultDoneDir = "swarm/done"
+)
+
+// SampleInterval is the least time between two samples with the same counts.
+const SampleInterval = time.Minute
+
+// Retention is how long samples are kept.
+const Retention = 7 * 24 * time.Hour
+
+// RateWindow is the period creation and completion rates are averaged over.
+const RateWindow = time.Hour
+
+// minRatePeriod is the least sampled time a rate is reported for.
+const minRatePeriod = 5 * time.Minute
+
+// queuedSuffixes mark task files waiting for a doer.
+var queuedSuffixes = []string{".todo.md", ".pending.md"}
+
+// processingSuffix marks a task file a doer has claimed.
+const processingSuffix = ".processing.md"
+
+// Snapshot is the state of the queue at one moment.
+type Snapshot struct {
+	Queued     int
+	Processing []string // claimed task files, relative to the todo directory
+	Done       int
+}
+
+// Scan counts the task files in todoDir and the finished ones (any .md
+// file) in doneDir, including subdirectories. It returns nil if todoDir
+// doesn't exist, i.e. the queue isn't in use.
+func Scan(todoDir, doneDir string) (*Snapshot, error) {
+	if info, err := os.Stat(todoDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	snap := &Snapshot{}
+	err := filepath.WalkDir(todoDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if strings.HasSuffix(name, processingSuffix) {
+			rel, _ := filepath.Rel(todoDir, path)
+			snap.Processing = append(snap.Processing, filepath.ToSlash(rel))
+			return nil
+		}
+		for _, suffix := range queuedSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				snap.Queued++
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", todoDir, err)
+	}
+
+	err = filepath.WalkDir(doneDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == doneDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".md") {
+			snap.Done++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", doneDir, err)
+	}
+	return snap, nil
+}
+
+// Sample records the queue's counts at a moment.
+type Sample struct {
+	Time       time.Time `json:"time"`
+	Queued     int       `json:"queued"`
+	Processing int       `json:"processing"`
+	Done       int       `json:"done"`
+}
+
+// total is the number of tasks ever created that are still on disk.
+func (s Sample) total() int {
+	return s.Queued + s.Processing + s.Done
+}
+
+// History is the queue's samples over time, oldest first, and when each
+// claimed task file was first seen.
+type History struct {
+	Samples []Sample             `json:"samples"`
+	Claims  map[string]time.Time `json:"claims,omitempty"`
+}
+
+// Record adds snap to the history at now: a sample, unless the last one has
+// the same counts and is less than SampleInterval old, and the claims, with
+// finished ones dropped. Samples older than Retention are dropped. It
+// reports whether the history changed.
+func (h *History) Record(snap *Snapshot, now time.Time) bool {
+	changed := len(snap.Processing) != len(h.Claims)
+	claims := make(map[string]time.Time, len(snap.Processing))
+	for _, path := range snap.Processing {
+		seen, ok := h.Claims[path]
+		if !ok {
+			seen = now
+			changed = true
+		}
+		claims[path] = seen
+	}
+	h.Claims = claims
+
+	sample := Sample{Time: now, Queued: snap.Queued, Processing: len(snap.Processing), Done: snap.Done}
+	if n := len(h.Samples); n > 0 {
+		last := h.Samples[n-1]
+		if now.Sub(last.Time) < SampleInterval && last.Queued == sample.Queued &&
+			last.Processing == sample.Processing && last.Done == sample.Done {
+			return changed
+		}
+	}
+	h.Samples = append(h.Samples, sample)
+
+	cutoff := now.Add(-Retention)
+	for len(h.Samples) > 0 && h.Samples[0].Time.Before(cutoff) {
+		h.Samples = h.Samples[1:]
+	}
+	return true
+}
+
+// Metrics summarizes the queue.
+type Metrics struct {
+	Queued     int `json:"queued"`
+	Processing int `json:"processing"`
+
+	// OldestClaim is how long the longest-held claim has been processing
+	OldestClaim time.Duration `json:"oldest_claim_ns"`
+
+	// CreatedPerHour and CompletedPerHour are the rates tasks were created
+	// and finished over the last RateWindow. HasRates is false until there
+	// are enough samples to tell.
+	CreatedPerHour   float64 `json:"created_per_hour"`
+	CompletedPerHour float64 `json:"completed_per_hour"`
+	HasRates         bool    `json:"has_rates"`
+}
+
+// Metrics returns the queue's current depth, oldest claim, and rates at now.
+// It returns nil for an empty history.
+func (h *History) Metrics(now time.Time) *Metrics {
+	if len(h.Samples) == 0 {
+		return nil
+	}
+	last := h.Samples[len(h.Samples)-1]
+	m := &Metrics{Queued: last.Queued, Processing: last.Processing}
+	for _, seen := range h.Claims {
+		if age := now.Sub(seen); age > m.OldestClaim {
+			m.OldestClaim = age
+		}
+	}
+
+	// Rates count increases between consecutive samples, so archiving or
+	// deleting finished task files doesn't make them negative
+	start := len(h.Samples) - 1
+	for start > 0 && !h.Samples[start-1].Time.Before(now.Add(-RateWindow)) {
+		start--
+	}
+	if start > 0 {
+		start-- // the last sample before the window is the baseline
+	}
+	period := last.Time.Sub(h.Samples[start].Time)
+	if period < minRatePeriod {
+		return m
+	}
+	var created, completed int
+	for i := start + 1; i < len(h.Samples); i++ {
+		prev, cur := h.Samples[i-1], h.Samples[i]
+		created += max(cur.total()-prev.total(), 0)
+		completed += max(cur.Done-prev.Done, 0)
+	}
+	m.CreatedPerHour = float64(created) / period.Hours()
+	m.CompletedPerHour = float64(completed) / period.Hours()
+	m.HasRates = true
+	return m
+}
+
+// PlannersAhead reports whether tasks are being created faster than they're
+// finished while some wait in the queue.
+func (m *Metrics) PlannersAhead() bool {
+	return m.HasRates && m.Queued > 0 && m.CreatedPerHour > m.CompletedPerHour
+}
+
+// String summarizes the metrics on one line, e.g.
+// "4 queued · 2 processing (oldest claim 14m) · 3.0/h created · 2.0/h done".
+func (m *Metrics) String() string {
+	s := fmt.Sprintf("%d queued · %d processing", m.Queued, m.Processing)
+	if m.Processing > 0 {
+		s += fmt.Sprintf(" (oldest claim %s)", formatAge(m.OldestClaim))
+	}
+	if m.HasRates {
+		s += fmt.Sprintf(" · %.1f/h created · %.1f/h done", m.CreatedPerHour, m.CompletedPerHour)
+	}
+	return s
+}
+
+// formatAge formats a claim's age to the minute, e.g. "14m" or "2h5m".
+func formatAge(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,162 @@
+package todoqueue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("task"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	todoDir := filepath.Join(dir, "todos")
+	doneDir := filepath.Join(dir, "done")
+
+	snap, err := Scan(todoDir, doneDir)
+	if err != nil || snap != nil {
+		t.Fatalf("Scan without a todo dir = %v, %v, want nil, nil", snap, err)
+	}
+
+	writeFiles(t, todoDir, "a.todo.md", "b.pending.md", "backend/c.todo.md", "d.processing.md", "notes.txt")
+	snap, err = Scan(todoDir, doneDir)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if snap.Queued != 3 || len(snap.Processing) != 1 || snap.Done != 0 {
+		t.Errorf("Scan without a done dir = %+v", snap)
+	}
+
+	writeFiles(t, doneDir, "e.done.md", "f.reviewed.md")
+	snap, err = Scan(todoDir, doneDir)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if snap.Done != 2 {
+		t.Errorf("Done = %d, want 2", snap.Done)
+	}
+	if snap.Processing[0] != "d.processing.md" {
+		t.Errorf("Processing = %v", snap.Processing)
+	}
+}
+
+func TestRecordAndMetrics(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var h History
+
+	if h.Metrics(start) != nil {
+		t.Error("Metrics of an empty history should be nil")
+	}
+	if !h.Record(&Snapshot{Queued: 2}, start) {
+		t.Error("first Record should change the history")
+	}
+	if h.Record(&Snapshot{Queued: 2}, start.Add(10*time.Second)) {
+		t.Error("Record with the same counts within SampleInterval should not change the history")
+	}
+
+	m := h.Metrics(start.Add(time.Minute))
+	if m.Queued != 2 || m.HasRates {
+		t.Errorf("Metrics after one sample = %+v", m)
+	}
+
+	// A doer claims a task, a planner adds four, and two are finished
+	h.Record(&Snapshot{Queued: 1, Processing: []string{"a.processing.md"}}, start.Add(10*time.Minute))
+	h.Record(&Snapshot{Queued: 5, Processing: []string{"a.processing.md"}}, start.Add(20*time.Minute))
+	h.Record(&Snapshot{Queued: 3, Processing: []string{"a.processing.md"}, Done: 2}, start.Add(30*time.Minute))
+
+	m = h.Metrics(start.Add(30 * time.Minute))
+	if !m.HasRates {
+		t.Fatalf("Metrics after 30m = %+v, want rates", m)
+	}
+	if m.CreatedPerHour != 8 || m.CompletedPerHour != 4 {
+		t.Errorf("rates = %.1f created, %.1f done per hour, want 8, 4", m.CreatedPerHour, m.CompletedPerHour)
+	}
+	if m.OldestClaim != 20*time.Minute {
+		t.Errorf("OldestClaim = %v, want 20m", m.OldestClaim)
+	}
+	if !m.PlannersAhead() {
+		t.Error("PlannersAhead = false, want true")
+	}
+
+	// Finished claims are dropped; archiving done files doesn't count as negative throughput
+	h.Record(&Snapshot{Queued: 4, Done: 0}, start.Add(40*time.Minute))
+	m = h.Metrics(start.Add(40 * time.Minute))
+	if m.Processing != 0 || m.OldestClaim != 0 || len(h.Claims) != 0 {
+		t.Errorf("claims after finishing = %+v, %v", m, h.Claims)
+	}
+	if m.CompletedPerHour < 0 || m.CreatedPerHour < 0 {
+		t.Errorf("negative rates %+v", m)
+	}
+}
+
+func TestRecordRetention(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var h History
+	h.Record(&Snapshot{Queued: 1}, start)
+	h.Record(&Snapshot{Queued: 2}, start.Add(Retention+time.Hour))
+	if len(h.Samples) != 1 || h.Samples[0].Queued != 2 {
+		t.Errorf("Samples = %+v, want only the recent one", h.Samples)
+	}
+}
+
+func TestPending(t *testing.T) {
+	todoDir := filepath.Join(t.TempDir(), "todos")
+	if n, err := Pending(todoDir); err != nil || n != 0 {
+		t.Fatalf("Pending without a todo dir = %d, %v, want 0, nil", n, err)
+	}
+
+	writeFiles(t, todoDir, "a.todo.md", "b.pending.md", "backend/c.todo.md", "d.processing.md", "notes.txt")
+	if n, err := Pending(todoDir); err != nil || n != 3 {
+		t.Errorf("Pending = %d, %v, want 3", n, err)
+	}
+}
+
+func TestWaitForRoom(t *testing.T) {
+	defer func(interval time.Duration) { PollInterval = interval }(PollInterval)
+	PollInterval = 10 * time.Millisecond
+
+	todoDir := t.TempDir()
+	writeFiles(t, todoDir, "a.todo.md", "b.todo.md", "c.todo.md")
+
+	if !WaitForRoom(context.Background(), todoDir, 3, func(int) { t.Error("waited with room in the queue") }, nil) {
+		t.Error("WaitForRoom with room = false")
+	}
+
+	// A doer claims a task while the planner waits
+	waitedFor := 0
+	checks := 0
+	ok := WaitForRoom(context.Background(), todoDir, 2, func(pending int) { waitedFor = pending }, func() bool {
+		checks++
+		if checks == 2 {
+			if err := os.Rename(filepath.Join(todoDir, "a.todo.md"), filepath.Join(todoDir, "a.processing.md")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return false
+	})
+	if !ok || waitedFor != 3 {
+		t.Errorf("WaitForRoom = %v after waiting for %d pending, want true after 3", ok, waitedFor)
+	}
+
+	if WaitForRoom(context.Background(), todoDir, 1, nil, func() bool { return true }) {
+		t.Error("WaitForRoom = true after stop")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if WaitForRoom(ctx, todoDir, 1, nil, nil) {
+		t.Error("WaitForRoom = true after the context was done")
+	}
+}
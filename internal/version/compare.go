@@ -0,0 +1,70 @@
+package version
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern matches the dotted numeric part of a version, such as "2.0.14"
+// in "v2.0.14-rc1" or "2.0.14 (Claude Code)".
+var numberPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// Parse returns the numeric components of the first version number in s, or
+// false if s has none. Pre-release and build suffixes are ignored.
+func Parse(s string) ([]int, bool) {
+	match := numberPattern.FindString(s)
+	if match == "" {
+		return nil, false
+	}
+	var parts []int
+	for _, p := range strings.Split(match, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// Compare compares two parsed versions, treating missing components as 0:
+// -1 if a < b, 0 if they're equal, and 1 if a > b.
+func Compare(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether the version in current is min or newer. A version
+// that can't be parsed, such as a "dev" build's, satisfies any minimum.
+func AtLeast(current, min string) bool {
+	c, ok := Parse(current)
+	if !ok {
+		return true
+	}
+	m, ok := Parse(min)
+	if !ok {
+		return true
+	}
+	return Compare(c, m) >= 0
+}
+
+// IsRelease reports whether this is a release build rather than a "dev" one.
+func IsRelease() bool {
+	_, ok := Parse(Version)
+	return ok
+}
@@ -0,0 +1,23 @@
+package version
+
+import "testing"
+
+func TestAtLeast(t *testing.T) {
+	for _, tt := range []struct {
+		current, min string
+		want         bool
+	}{
+		{"1.4.0", "1.4.0", true},
+		{"v1.4.1", "1.4", true},
+		{"1.10.0", "1.9.3", true},
+		{"1.3.9", "1.4.0", false},
+		{"2.0.14 (Claude Code)", "2.1", false},
+		{"1.4.0-rc1", "1.4.0", true},
+		{"dev", "1.4.0", true},
+		{"1.0.0", "latest", true},
+	} {
+		if got := AtLeast(tt.current, tt.min); got != tt.want {
+			t.Errorf("AtLeast(%q, %q) = %v, want %v", tt.current, tt.min, got, tt.want)
+		}
+	}
+}
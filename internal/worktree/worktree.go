@@ -0,0 +1,287 @@
+// Package worktree gives agents isolated git worktrees, so parallel
+// instances of a task can edit the same repository without clobbering each
+// other's changes. Each worktree has its own swarm/<name> branch; its
+// changes are committed there and optionally merged back into the original
+// checkout.
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BranchPrefix prefixes the branches of agents' worktrees.
+const BranchPrefix = "swarm/"
+
+// lockRetries is how often a git command that failed on a lock held by a
+// concurrent git command (e.g. another agent merging back) is retried.
+const lockRetries = 50
+
+// lockRetryInterval is how long to wait between lock retries.
+const lockRetryInterval = 100 * time.Millisecond
+
+// unsafeNameChars matches characters not allowed in worktree names, which
+// are used in both branch names and directory names.
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Worktree is a git worktree an agent works in.
+type Worktree struct {
+	// RepoDir is the top level of the checkout the worktree was created
+	// from, which changes are merged back into
+	RepoDir string
+
+	// Path is the top level of the worktree
+	Path string
+
+	// Dir is the directory the agent works in: the worktree's equivalent of
+	// the directory the worktree was created for
+	Dir string
+
+	// Branch is the worktree's branch
+	Branch string
+}
+
+// Root returns the directory worktrees are created in (~/.swarm/worktrees).
+func Root() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".swarm", "worktrees"), nil
+}
+
+// SanitizeName turns an agent name such as "pipeline:build.2" into one
+// usable as a branch and directory name.
+func SanitizeName(name string) string {
+	name = strings.Trim(unsafeNameChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		return "agent"
+	}
+	return name
+}
+
+// Create creates a worktree for dir, which must be inside a git repository,
+// on a new branch swarm/<name> starting at the repository's HEAD.
+func Create(dir, name string) (*Worktree, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("worktree isolation needs a git repository: %w", err)
+	}
+	rel, err := relToTop(dir, top)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := Root()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create worktree dir: %w", err)
+	}
+
+	name = SanitizeName(name)
+	w := &Worktree{
+		RepoDir: top,
+		Path:    filepath.Join(root, name),
+		Branch:  BranchPrefix + name,
+	}
+	w.Dir = filepath.Join(w.Path, rel)
+	if _, err := git(top, "worktree", "add", "--quiet", "-b", w.Branch, w.Path, "HEAD"); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	return w, nil
+}
+
+// relToTop returns dir relative to the repository top level, resolving
+// symlinks since git reports the top level with them resolved.
+func relToTop(dir, top string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(top), abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not inside repository %s", dir, top)
+	}
+	return rel, nil
+}
+
+// Commit commits all changes in the worktree to its branch, reporting
+// whether there was anything to commit.
+func (w *Worktree) Commit(message string) (bool, error) {
+	if _, err := git(w.Path, "add", "-A"); err != nil {
+		return false, fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if err := exec.Command("git", "-C", w.Path, "diff", "--cached", "--quiet").Run(); err == nil {
+		return false, nil
+	}
+
+	args := append(identityArgs(w.Path), "commit", "--quiet", "--no-verify", "-m", message)
+	if _, err := git(w.Path, args...); err != nil {
+		return false, fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return true, nil
+}
+
+// Merge commits the worktree's changes and merges its branch into the
+// original checkout. A merge that conflicts is aborted, leaving the changes
+// on the branch.
+func (w *Worktree) Merge(message string) error {
+	if _, err := w.Commit(message); err != nil {
+		return err
+	}
+	args := append(identityArgs(w.RepoDir), "merge", "--quiet", "--no-edit", w.Branch)
+	if _, err := git(w.RepoDir, args...); err != nil {
+		if _, statErr := git(w.RepoDir, "rev-parse", "-q", "--verify", "MERGE_HEAD"); statErr == nil {
+			_, _ = git(w.RepoDir, "merge", "--abort")
+		}
+		return fmt.Errorf("failed to merge %s into %s (changes kept on the branch): %w", w.Branch, w.RepoDir, err)
+	}
+	return nil
+}
+
+// FinishIteration commits an iteration's changes to the branch and, with
+// merge, merges the branch into the original checkout.
+func (w *Worktree) FinishIteration(iteration int, merge bool) error {
+	message := fmt.Sprintf("swarm: iteration %d", iteration)
+	if merge {
+		return w.Merge(message)
+	}
+	_, err := w.Commit(message)
+	return err
+}
+
+// Remove commits any remaining changes and removes the worktree. Its
+// branch is deleted if it has been merged, and otherwise kept so no work is
+// lost; Remove reports whether it was kept.
+func (w *Worktree) Remove(message string) (bool, error) {
+	if _, err := w.Commit(message); err != nil {
+		return false, err
+	}
+	if _, err := git(w.RepoDir, "worktree", "remove", "--force", w.Path); err != nil {
+		return false, fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	if _, err := git(w.RepoDir, "branch", "-d", w.Branch); err != nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// identityArgs returns the options giving git a committer identity in dir
+// when none is configured, as where agents run often has none.
+func identityArgs(dir string) []string {
+	if email, _ := git(dir, "config", "user.email"); email != "" {
+		return nil
+	}
+	return []string{"-c", "user.name=swarm", "-c", "user.email=swarm@localhost"}
+}
+
+// git runs a git command in dir and returns its trimmed output. Commands
+// that fail because a concurrent git command holds a lock are retried.
+func git(dir string, args ...string) (string, error) {
+	for attempt := 0; ; attempt++ {
+		output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		out := strings.TrimSpace(string(output))
+		if err == nil {
+			return out, nil
+		}
+		if attempt < lockRetries && strings.Contains(out, ".lock") {
+			time.Sleep(lockRetryInterval)
+			continue
+		}
+		if out == "" {
+			return "", err
+		}
+		return "", errors.New(out)
+	}
+}
+
+// Branch is an agent worktree's branch.
+type Branch struct {
+	// Name is the branch name, e.g. swarm/coder-1a2b3c
+	Name string
+
+	// Path is the branch's worktree, empty once the worktree is removed
+	Path string
+
+	// Unmerged is the number of the branch's commits not in HEAD
+	Unmerged int
+}
+
+// List returns the swarm/ branches of the repository containing dir.
+func List(dir string) ([]Branch, error) {
+	out, err := git(dir, "for-each-ref", "--format=%(refname:short)", "refs/heads/"+BranchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := worktreePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for _, name := range strings.Fields(out) {
+		count, err := git(dir, "rev-list", "--count", "HEAD.."+name)
+		if err != nil {
+			return nil, err
+		}
+		unmerged, _ := strconv.Atoi(count)
+		branches = append(branches, Branch{Name: name, Path: paths[name], Unmerged: unmerged})
+	}
+	return branches, nil
+}
+
+// worktreePaths maps branch names to the worktrees they're checked out in.
+func worktreePaths(dir string) (map[string]string, error) {
+	out, err := git(dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string)
+	path := ""
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+		} else if b, ok := strings.CutPrefix(line, "branch refs/heads/"); ok {
+			paths[b] = path
+		}
+	}
+	return paths, nil
+}
+
+// Prune cleans up after agents in the repository containing dir: it drops
+// git's records of worktrees whose directories are gone and deletes swarm/
+// branches without a worktree that have nothing left to merge. Worktrees in
+// use and branches with unmerged changes are left alone. It returns the
+// deleted branches.
+func Prune(dir string) ([]string, error) {
+	if _, err := git(dir, "worktree", "prune"); err != nil {
+		return nil, fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+	branches, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for _, b := range branches {
+		if b.Path != "" || b.Unmerged > 0 {
+			continue
+		}
+		if _, err := git(dir, "branch", "-d", b.Name); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", b.Name, err)
+		}
+		deleted = append(deleted, b.Name)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,199 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initRepo creates a git repository with one commit and a subdirectory.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@localhost", "commit", "--quiet", "-m", "init"},
+	} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return dir
+}
+
+func TestSanitizeName(t *testing.T) {
+	for name, want := range map[string]string{
+		"coder":            "coder",
+		"pipeline:build.2": "pipeline-build.2",
+		"my task/1":        "my-task-1",
+		"::":               "agent",
+	} {
+		if got := SanitizeName(name); got != want {
+			t.Errorf("SanitizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCreateAndMerge(t *testing.T) {
+	repo := initRepo(t)
+
+	w, err := Create(filepath.Join(repo, "sub"), "coder.1")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if w.Branch != "swarm/coder.1" {
+		t.Errorf("Branch = %q, want swarm/coder.1", w.Branch)
+	}
+	if w.Dir != filepath.Join(w.Path, "sub") {
+		t.Errorf("Dir = %q, want the worktree's sub dir", w.Dir)
+	}
+
+	if err := os.WriteFile(filepath.Join(w.Dir, "b.txt"), []byte("b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "sub", "b.txt")); !os.IsNotExist(err) {
+		t.Fatal("edit in the worktree leaked into the original checkout")
+	}
+
+	if err := w.Merge("iteration 1"); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "sub", "b.txt")); err != nil {
+		t.Errorf("merged file missing from the original checkout: %v", err)
+	}
+
+	kept, err := w.Remove("final")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if kept {
+		t.Error("merged branch was kept")
+	}
+	if _, err := os.Stat(w.Path); !os.IsNotExist(err) {
+		t.Error("worktree dir not removed")
+	}
+}
+
+func TestRemoveKeepsUnmergedBranch(t *testing.T) {
+	repo := initRepo(t)
+
+	w, err := Create(repo, "tester")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.Dir, "c.txt"), []byte("c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, err := w.Remove("final")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if !kept {
+		t.Error("unmerged branch was deleted")
+	}
+	if out, err := git(repo, "show", w.Branch+":c.txt"); err != nil || out != "c" {
+		t.Errorf("branch content = %q, %v; want the committed change", out, err)
+	}
+}
+
+func TestMergeConflictKeepsBranch(t *testing.T) {
+	repo := initRepo(t)
+
+	w, err := Create(repo, "coder")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.Dir, "sub", "a.txt"), []byte("worktree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "sub", "a.txt"), []byte("checkout\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(repo, "-c", "user.name=test", "-c", "user.email=test@localhost", "commit", "--quiet", "-am", "conflicting"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Merge("iteration 1"); err == nil {
+		t.Fatal("expected a merge conflict error")
+	}
+	if _, err := git(repo, "rev-parse", "-q", "--verify", "MERGE_HEAD"); err == nil {
+		t.Error("conflicting merge was not aborted")
+	}
+	if kept, err := w.Remove("final"); err != nil || !kept {
+		t.Errorf("Remove = %v, %v; want the unmerged branch kept", kept, err)
+	}
+}
+
+func TestCreateOutsideRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	if _, err := Create(t.TempDir(), "coder"); err == nil {
+		t.Error("expected error outside a git repository")
+	}
+}
+
+func TestListAndPrune(t *testing.T) {
+	repo := initRepo(t)
+
+	active, err := Create(repo, "active")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	unmerged, err := Create(repo, "unmerged")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(unmerged.Dir, "d.txt"), []byte("d\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unmerged.Remove("final"); err != nil {
+		t.Fatal(err)
+	}
+	// A worktree whose agent was killed before it could clean up
+	stale, err := Create(repo, "stale")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := os.RemoveAll(stale.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := List(repo)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := make(map[string]Branch)
+	for _, b := range branches {
+		got[b.Name] = b
+	}
+	if len(got) != 3 || got["swarm/active"].Path == "" || got["swarm/unmerged"].Unmerged != 1 {
+		t.Errorf("List = %+v", branches)
+	}
+
+	deleted, err := Prune(repo)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "swarm/stale" {
+		t.Errorf("Prune deleted %v, want [swarm/stale]", deleted)
+	}
+	if _, err := os.Stat(active.Path); err != nil {
+		t.Errorf("Prune removed an active worktree: %v", err)
+	}
+}